@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// accessibleOutput switches printResults to printAccessible. It is set from
+// --a11y or the a11y config by applyColorMode.
+var accessibleOutput bool
+
+// printAccessible writes results [startAt, end) for screen readers: one
+// labelled fact per line, no indentation, box drawing, symbols or colors,
+// and every result announced with its place among those shown, as "Result
+// 3 of 10", or "Result 13 (3 of 10)" past the first page, as its number is
+// still the one to open it by.
+func printAccessible(w io.Writer, results []SearchResult, startAt, end int, snippetWords int, shortDomains bool, query string) {
+	if isTerminalWriter(w) {
+		heading := tr("a11y_heading", query, len(results))
+		if len(results) == 1 {
+			heading = tr("a11y_heading_one", query)
+		}
+		fmt.Fprintf(w, "%s\n\n", heading)
+	}
+	position := 0
+	for _, group := range groupResults(results, startAt, end, searchOpts.GroupBy) {
+		if group.Name != "" {
+			heading := tr("a11y_group", group.Name, len(group.Indexes))
+			if len(group.Indexes) == 1 {
+				heading = tr("a11y_group_one", group.Name)
+			}
+			fmt.Fprintf(w, "%s\n\n", heading)
+		}
+		for _, i := range group.Indexes {
+			position++
+			printAccessibleResult(w, results[i], i+1, position, end-startAt, snippetWords, shortDomains)
+		}
+	}
+}
+
+// printAccessibleResult writes one result for printAccessible, numbered
+// index, at position of the shown results.
+func printAccessibleResult(w io.Writer, result SearchResult, index, position, shown int, snippetWords int, shortDomains bool) {
+	title := result.Title
+	if title == "" {
+		title = tr("no_title")
+	}
+	if index == position {
+		fmt.Fprintln(w, tr("a11y_result", position, shown, porcelainField(title)))
+	} else {
+		fmt.Fprintln(w, tr("a11y_result_page", index, position, shown, porcelainField(title)))
+	}
+	if domain := extractDomain(result.URL, shortDomains); domain != "" {
+		fmt.Fprintf(w, "%s: %s\n", tr("a11y_site"), domain)
+	}
+	if badges := resultBadges(result); len(badges) > 0 {
+		fmt.Fprintf(w, "%s: %s\n", tr("a11y_domain_tags"), strings.Join(badges, ", "))
+	}
+	if result.URL != "" {
+		fmt.Fprintf(w, "%s: %s\n", tr("a11y_link"), result.URL)
+	}
+	if result.Link != nil {
+		fmt.Fprintf(w, "%s: %s\n", tr("a11y_link_status"), accessibleLinkCheck(result))
+	}
+	if result.OpenAccess != nil {
+		fmt.Fprintf(w, "%s: %s\n", tr("a11y_open_access"), formatOpenAccess(result.OpenAccess))
+	}
+	if result.ArchiveURL != "" {
+		fmt.Fprintln(w, tr("archived_copy", result.ArchiveURL))
+	}
+	if result.Citations != nil {
+		fmt.Fprintln(w, tr("cited_by", *result.Citations))
+	}
+	if result.WordCount != 0 {
+		fmt.Fprintln(w, tr("word_count", result.WordCount))
+	}
+	if result.Language != "" {
+		fmt.Fprintf(w, "%s: %s\n", tr("a11y_language"), result.Language)
+	}
+	if result.SiteInfo != nil {
+		fmt.Fprintf(w, "%s: %s\n", tr("a11y_site_info"), formatSiteInfo(result.SiteInfo))
+	}
+	if note := clusterNote(result); note != "" {
+		fmt.Fprintln(w, note)
+	}
+	if len(result.AlsoOn) > 0 {
+		fmt.Fprintf(w, "%s: %s\n", tr("a11y_also_on"), strings.Join(result.AlsoOn, ", "))
+	}
+	if result.Content != "" {
+		fmt.Fprintf(w, "%s: %s\n", tr("a11y_summary"), formatContent(result.Content, snippetWords))
+	}
+	for _, detail := range accessibleDetails(result) {
+		fmt.Fprintln(w, detail)
+	}
+	if engines := resultEngineNames(result); len(engines) > 0 {
+		fmt.Fprintf(w, "%s: %s\n", tr("a11y_found_by"), strings.Join(engines, ", "))
+	}
+	fmt.Fprintln(w)
+}
+
+// accessibleLinkCheck spells out a --check-links status without symbols.
+func accessibleLinkCheck(result SearchResult) string {
+	check := result.Link
+	switch check.Status {
+	case "alive":
+		return tr("link_alive", check.Code)
+	case "redirect":
+		return tr("link_redirect", check.Target)
+	}
+	if check.Code != 0 {
+		return tr("link_dead_code", check.Code)
+	}
+	return tr("link_dead", check.Error)
+}
+
+// accessibleDetails is the category-specific information printCategorySpecific
+// shows, as labelled lines.
+func accessibleDetails(result SearchResult) []string {
+	var lines []string
+	add := func(label, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			lines = append(lines, tr(label)+": "+value)
+		}
+	}
+	published := ""
+	if result.PublishedDate != "" {
+		if date := parseDate(result.PublishedDate); date != nil {
+			published = formatDate(*date)
+		}
+	}
+
+	switch result.Category {
+	case "news", "social media":
+		add("a11y_published", published)
+	case "images":
+		add("a11y_resolution", result.Resolution)
+		add("a11y_source", result.Source)
+		add("a11y_image", result.ImgSrc)
+	case "videos", "music":
+		if result.Length != nil {
+			add("a11y_length", formatLength(result.Length))
+		}
+		add("a11y_author", result.Author)
+		add("a11y_album", result.Album)
+	case "map":
+		if result.Address != nil {
+			add("a11y_address", accessibleAddress(result.Address))
+		}
+		if result.Longitude != 0 || result.Latitude != 0 {
+			add("a11y_coordinates", tr("a11y_coords", result.Latitude, result.Longitude))
+		}
+	case "science":
+		add("a11y_published", published)
+		add("a11y_author", result.Author)
+		add("a11y_journal", result.Journal)
+		add("a11y_publisher", result.Publisher)
+	case "it":
+		add("a11y_details", result.Metadata)
+		add("a11y_install", result.InstallURL)
+	case "files":
+		switch result.Template {
+		case "torrent.html":
+			add("a11y_magnet", result.MagnetLink)
+			add("a11y_size", result.FileSize)
+			add("a11y_peers", tr("a11y_peers_count", result.Seed, result.Leech))
+		case "files.html":
+			add("a11y_size", result.Size)
+			add("a11y_details", result.Metadata)
+		}
+	}
+	return lines
+}
+
+// accessibleAddress joins the address parts printAddress shows over several
+// lines into one.
+func accessibleAddress(address map[string]interface{}) string {
+	var parts []string
+	street := ""
+	for _, key := range []string{"house_number", "road"} {
+		if s, ok := address[key].(string); ok && s != "" {
+			street = strings.TrimSpace(street + " " + s)
+		}
+	}
+	if street != "" {
+		parts = append(parts, street)
+	}
+	for _, key := range []string{"locality", "postcode", "country"} {
+		if s, ok := address[key].(string); ok && s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// resultEngineNames lists the engines that found result, the main engine
+// first, as printEngines does.
+func resultEngineNames(result SearchResult) []string {
+	var names []string
+	if result.Engine != "" {
+		names = append(names, result.Engine)
+	}
+	for _, engine := range result.Engines {
+		if engine != result.Engine {
+			names = append(names, engine)
+		}
+	}
+	return names
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"sx/backends"
+)
+
+func TestPrintAccessible(t *testing.T) {
+	results := []SearchResult{
+		{Title: "First", URL: "https://a.example/1"},
+		{
+			Title:    "Ubuntu ISO",
+			URL:      "https://docs.example.org/iso",
+			Content:  "<b>Ubuntu</b> desktop image",
+			Category: "files",
+			Template: "torrent.html",
+			FileSize: "4.7 GB",
+			Seed:     12,
+			Leech:    3,
+			Engine:   "piratebay",
+			Engines:  []string{"piratebay", "nyaa"},
+			Link:     &backends.LinkCheck{Status: "alive", Code: 200},
+		},
+		{Title: "Third", URL: "https://c.example/3"},
+	}
+
+	var buf bytes.Buffer
+	printAccessible(&buf, results, 1, 2, 0, false, "ubuntu")
+	want := strings.Join([]string{
+		"Result 2 (1 of 1): Ubuntu ISO",
+		"Site: docs.example.org",
+		"Link: https://docs.example.org/iso",
+		"Link status: alive, HTTP 200",
+		"Summary: Ubuntu desktop image",
+		"Size: 4.7 GB",
+		"Peers: 12 seeders, 3 leechers",
+		"Found by: piratebay, nyaa",
+		"", "",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrintAccessibleCountsShownResults(t *testing.T) {
+	results := []SearchResult{{Title: "A"}, {Title: "B"}, {Title: "C"}, {Title: "D"}, {Title: "E"}}
+	var buf bytes.Buffer
+	printAccessible(&buf, results, 0, 2, 0, false, "q")
+	printAccessible(&buf, results, 2, 4, 0, false, "q")
+	var announced []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "Result ") {
+			announced = append(announced, line)
+		}
+	}
+	want := []string{"Result 1 of 2: A", "Result 2 of 2: B", "Result 3 (1 of 2): C", "Result 4 (2 of 2): D"}
+	if strings.Join(announced, "\n") != strings.Join(want, "\n") {
+		t.Errorf("announced %q, want %q", announced, want)
+	}
+}
+
+func TestPrintResults_Accessible(t *testing.T) {
+	defer func(v bool) { accessibleOutput = v }(accessibleOutput)
+	accessibleOutput = true
+
+	var buf bytes.Buffer
+	printResults(&buf, []SearchResult{{URL: "https://a.example/"}}, 10, 0, false, true, 0, false, "q")
+	if got := buf.String(); !strings.HasPrefix(got, "Result 1 of 1: No title\n") {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
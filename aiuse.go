@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// aiPolicyModes are the --ai-policy values: report notes why a page's site
+// doesn't want it used for AI, exclude also leaves such pages out.
+var aiPolicyModes = []string{"report", "exclude"}
+
+func validateAIPolicyMode(mode string) bool {
+	for _, m := range aiPolicyModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// aiCrawlers are the user agents of crawlers that collect pages for AI
+// training or answers. A site that shuts them out in robots.txt doesn't
+// want its pages used that way.
+var aiCrawlers = []string{
+	"GPTBot", "ChatGPT-User", "CCBot", "Google-Extended", "anthropic-ai", "ClaudeBot",
+	"PerplexityBot", "Bytespider", "Applebot-Extended", "cohere-ai", "Meta-ExternalAgent",
+}
+
+// robotsRule is an Allow or Disallow line of a robots.txt group.
+type robotsRule struct {
+	allow   bool
+	length  int // of the path pattern; the longest match wins
+	pattern *regexp.Regexp
+}
+
+// robotsGroup is the rules for the user agents a group names.
+type robotsGroup struct {
+	agents []string // lowercased
+	rules  []robotsRule
+}
+
+// robotsRules is a parsed robots.txt, or ai.txt, which uses the same
+// syntax.
+type robotsRules []robotsGroup
+
+// parseRobots parses robots.txt as RFC 9309 describes it: groups of
+// User-agent lines followed by Allow and Disallow rules, with * and $ in
+// paths. Other lines are ignored.
+func parseRobots(text string) robotsRules {
+	var groups robotsRules
+	inAgents := false
+	for _, line := range strings.Split(text, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				groups = append(groups, robotsGroup{})
+			}
+			inAgents = true
+			groups[len(groups)-1].agents = append(groups[len(groups)-1].agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			// An empty Disallow allows everything
+			if len(groups) == 0 || value == "" {
+				continue
+			}
+			groups[len(groups)-1].rules = append(groups[len(groups)-1].rules, robotsRule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: robotsPattern(value),
+			})
+		}
+	}
+	return groups
+}
+
+// robotsPattern compiles a robots.txt path, which matches as a prefix, *
+// standing for any characters and a trailing $ for the end of the path.
+func robotsPattern(path string) *regexp.Regexp {
+	anchored := strings.HasSuffix(path, "$")
+	path = strings.TrimSuffix(path, "$")
+	parts := strings.Split(path, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// allowed reports whether agent may fetch path. The groups naming agent
+// apply, or the * groups if none does; among their rules the longest
+// matching path wins, Allow on a tie.
+func (r robotsRules) allowed(agent, path string) bool {
+	agent = strings.ToLower(agent)
+	var rules []robotsRule
+	for _, wanted := range []string{agent, "*"} {
+		named := false
+		for _, group := range r {
+			for _, a := range group.agents {
+				if a == wanted {
+					rules = append(rules, group.rules...)
+					named = true
+					break
+				}
+			}
+		}
+		if named {
+			break
+		}
+	}
+	best, allow := -1, true
+	for _, rule := range rules {
+		if rule.pattern.MatchString(path) && (rule.length > best || rule.length == best && rule.allow) {
+			best, allow = rule.length, rule.allow
+		}
+	}
+	return allow
+}
+
+// siteFiles are a site's robots.txt and ai.txt, nil when it has none.
+type siteFiles struct {
+	once   sync.Once
+	robots robotsRules
+	aiTxt  robotsRules
+}
+
+// aiUseChecker finds out whether sites restrict using their pages for AI,
+// fetching each site's robots.txt and ai.txt once.
+type aiUseChecker struct {
+	client  *http.Client
+	config  *Config
+	exclude bool // leave restricted pages out rather than report them
+	mu      sync.Mutex
+	sites   map[string]*siteFiles
+}
+
+func newAIUseChecker(client *http.Client, config *Config, exclude bool) *aiUseChecker {
+	return &aiUseChecker{client: client, config: config, exclude: exclude, sites: make(map[string]*siteFiles)}
+}
+
+// site returns the robots.txt and ai.txt of the site at u.
+func (c *aiUseChecker) site(u *url.URL) *siteFiles {
+	origin := u.Scheme + "://" + u.Host
+	c.mu.Lock()
+	files, ok := c.sites[origin]
+	if !ok {
+		files = &siteFiles{}
+		c.sites[origin] = files
+	}
+	c.mu.Unlock()
+
+	files.once.Do(func() {
+		if body, err := fetchPageHTML(c.client, origin+"/robots.txt", c.config); err == nil {
+			files.robots = parseRobots(string(body))
+		}
+		if body, err := fetchPageHTML(c.client, origin+"/ai.txt", c.config); err == nil {
+			files.aiTxt = parseRobots(string(body))
+		}
+	})
+	return files
+}
+
+// siteRestrictions returns why the site of rawURL doesn't want the page
+// used for AI according to its robots.txt and ai.txt, nothing if it
+// doesn't say so.
+func (c *aiUseChecker) siteRestrictions(rawURL string) []string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	files := c.site(u)
+
+	var reasons []string
+	if files.robots != nil {
+		if !files.robots.allowed("*", path) {
+			reasons = append(reasons, "robots.txt disallows crawling it")
+		} else {
+			var blocked []string
+			for _, agent := range aiCrawlers {
+				if !files.robots.allowed(agent, path) {
+					blocked = append(blocked, agent)
+				}
+			}
+			if len(blocked) > 0 {
+				reasons = append(reasons, "robots.txt disallows "+strings.Join(blocked, ", "))
+			}
+		}
+	}
+	if files.aiTxt != nil && !files.aiTxt.allowed("*", path) {
+		reasons = append(reasons, "ai.txt disallows it")
+	}
+	return reasons
+}
+
+// pageRestrictions returns why a page's own markup says it doesn't want to
+// be used for AI: a noai robots meta tag or a TDMRep reservation of text
+// and data mining rights.
+func pageRestrictions(html []byte) []string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return nil
+	}
+	var reasons []string
+	doc.Find("meta[name]").Each(func(_ int, s *goquery.Selection) {
+		name := strings.ToLower(s.AttrOr("name", ""))
+		content := strings.ToLower(s.AttrOr("content", ""))
+		switch {
+		case name == "robots" || isAICrawler(name):
+			for _, directive := range strings.FieldsFunc(content, func(r rune) bool { return r == ',' || r == ' ' }) {
+				if directive == "noai" {
+					reasons = append(reasons, fmt.Sprintf("the page's %s meta tag says noai", s.AttrOr("name", "")))
+					break
+				}
+			}
+		case name == "tdm-reservation" && strings.TrimSpace(content) == "1":
+			reasons = append(reasons, "the page reserves text and data mining rights (TDMRep)")
+		}
+	})
+	return reasons
+}
+
+// isAICrawler reports whether agent is one of aiCrawlers, ignoring case.
+func isAICrawler(agent string) bool {
+	for _, crawler := range aiCrawlers {
+		if strings.EqualFold(crawler, agent) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRobotsAllowed(t *testing.T) {
+	rules := parseRobots(`# comment
+User-agent: *
+Disallow: /private/
+Allow: /private/press
+Disallow: /*.pdf$
+
+User-agent: GPTBot
+User-agent: CCBot
+Disallow: /
+
+User-agent: Googlebot
+`)
+	tests := []struct {
+		agent, path string
+		want        bool
+	}{
+		{"Mozilla", "/", true},
+		{"Mozilla", "/private/notes", false},
+		{"Mozilla", "/private/press/2024", true},
+		{"Mozilla", "/paper.pdf", false},
+		{"Mozilla", "/paper.pdf?download", true},
+		{"gptbot", "/", false},
+		{"CCBot", "/blog", false},
+		// A group without rules allows everything rather than falling
+		// back to *
+		{"Googlebot", "/private/notes", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.agent, tt.path); got != tt.want {
+			t.Errorf("allowed(%q, %q) = %v, want %v", tt.agent, tt.path, got, tt.want)
+		}
+	}
+	if !parseRobots("User-agent: *\nDisallow:\n").allowed("*", "/") {
+		t.Error("empty Disallow should allow everything")
+	}
+}
+
+func TestSiteRestrictions(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fetches.Add(1)
+			fmt.Fprint(w, "User-agent: *\nDisallow: /admin\n\nUser-agent: GPTBot\nUser-agent: ClaudeBot\nDisallow: /articles/\n")
+		case "/ai.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /images/\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	checker := newAIUseChecker(setupHTTPClient(config), config, false)
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/", nil},
+		{"/admin/users", []string{"robots.txt disallows crawling it"}},
+		{"/articles/one", []string{"robots.txt disallows GPTBot, ClaudeBot"}},
+		{"/images/cat.png", []string{"ai.txt disallows it"}},
+	}
+	for _, tt := range tests {
+		if got := checker.siteRestrictions(server.URL + tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("siteRestrictions(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("robots.txt fetched %d times, want once", n)
+	}
+	if got := checker.siteRestrictions("mailto:someone@example.com"); got != nil {
+		t.Errorf("non-HTTP URL: %q", got)
+	}
+}
+
+func TestPageRestrictions(t *testing.T) {
+	tests := []struct {
+		html string
+		want []string
+	}{
+		{`<html><head><meta name="robots" content="index, follow"></head></html>`, nil},
+		{`<html><head><meta name="robots" content="noindex,noai,noimageai"></head></html>`,
+			[]string{"the page's robots meta tag says noai"}},
+		{`<html><head><meta name="CCBot" content="noai"></head></html>`,
+			[]string{"the page's CCBot meta tag says noai"}},
+		{`<html><head><meta name="tdm-reservation" content="1"></head></html>`,
+			[]string{"the page reserves text and data mining rights (TDMRep)"}},
+		{`<html><head><meta name="tdm-reservation" content="0"></head></html>`, nil},
+	}
+	for _, tt := range tests {
+		if got := pageRestrictions([]byte(tt.html)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pageRestrictions(%q) = %q, want %q", tt.html, got, tt.want)
+		}
+	}
+}
+
+func TestFetchTextDocumentAIPolicy(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: CCBot\nDisallow: /blocked\n")
+			return
+		}
+		fmt.Fprint(w, `<html><head><title>Page</title></head><body><article><p>`+
+			strings.Repeat("Some article text about ownership in Rust. ", 10)+`</p></article></body></html>`)
+	}))
+	defer server.Close()
+	client := setupHTTPClient(config)
+
+	result := SearchResult{URL: server.URL + "/blocked", Title: "Blocked"}
+	doc := fetchTextDocument(client, result, config, newAIUseChecker(client, config, false))
+	if !doc.read || !strings.Contains(doc.head, "AI use: restricted (robots.txt disallows CCBot)") {
+		t.Errorf("report: %+v", doc)
+	}
+	if doc := fetchTextDocument(client, result, config, newAIUseChecker(client, config, true)); !doc.excluded {
+		t.Errorf("exclude: %+v", doc)
+	}
+
+	allowed := SearchResult{URL: server.URL + "/open", Title: "Open"}
+	if doc := fetchTextDocument(client, allowed, config, newAIUseChecker(client, config, true)); doc.excluded || strings.Contains(doc.head, "AI use") {
+		t.Errorf("unrestricted page: %+v", doc)
+	}
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sx/backends"
+
+	"github.com/fatih/color"
+)
+
+// answerCacheMaxAge is how long the answers of answer_engines are reused.
+// Result lists are fetched fresh for every search; answers cost an API
+// request each, but go stale quickly (exchange rates, scores), so they are
+// only kept briefly.
+const answerCacheMaxAge = time.Hour
+
+// instantAnswer computes an answer for calculator and unit conversion
+// queries locally. Currency conversions need live rates and are left to
+// the search backend's answerers.
+func instantAnswer(query string) (string, bool) {
+	query = strings.TrimSpace(query)
+	if answer, ok := convertUnits(query); ok {
+		return answer, true
+	}
+	if value, ok := calculate(query); ok {
+		return fmt.Sprintf("%s = %s", strings.Join(strings.Fields(query), " "), formatNumber(value)), true
+	}
+	return "", false
+}
+
+// mergeAnswers joins answer lists, such as sx's own instant answer and
+// the backend's, dropping answers that repeat an earlier one apart from
+// case and spacing.
+func mergeAnswers(lists ...[]string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, answer := range list {
+			key := strings.ToLower(strings.Join(strings.Fields(answer), " "))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, answer)
+		}
+	}
+	return merged
+}
+
+// answerCacheEntry is an engine's answers to a query in the cache file,
+// with when they were fetched.
+type answerCacheEntry struct {
+	Answers []string  `json:"answers"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// answerCacheMu serializes reading and writing the answer cache file
+// between concurrent searches (--all-categories).
+var answerCacheMu sync.Mutex
+
+// answerCacheFile is where answers are cached, or "" if the cache
+// directory can't be resolved.
+func answerCacheFile() string {
+	dir := appDir(baseCache)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "answers.json")
+}
+
+// answerCacheKey identifies an engine's answers to a query with the options
+// the engine is asked with.
+func answerCacheKey(engine, query string, opts *SearchOptions) string {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	key := strings.Join([]string{engine, query, opts.Language, opts.Country, opts.TimeRange, opts.SafeSearch}, "\n")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// loadAnswerCache reads the answers fetched within answerCacheMaxAge.
+func loadAnswerCache(now time.Time) map[string]answerCacheEntry {
+	cache := map[string]answerCacheEntry{}
+	path := answerCacheFile()
+	if path == "" {
+		return cache
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	var entries map[string]answerCacheEntry
+	if json.Unmarshal(data, &entries) != nil {
+		return cache
+	}
+	for key, entry := range entries {
+		if now.Sub(entry.Fetched) < answerCacheMaxAge {
+			cache[key] = entry
+		}
+	}
+	return cache
+}
+
+func saveAnswerCache(cache map[string]answerCacheEntry) error {
+	path := answerCacheFile()
+	if path == "" {
+		return fmt.Errorf("no cache directory")
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// engineAnswers asks the answer_engines for their answers to query, apart
+// from searched, the engine whose answers the search already has, and
+// merges them in the order configured. Engines that can't answer (not
+// configured, no answers at all, or Tavily without include_answer) are
+// skipped quietly. Answers, none included, are cached for
+// answerCacheMaxAge.
+func engineAnswers(query string, opts *SearchOptions, config *Config, mgr *backends.Manager, searched string) []string {
+	if len(config.AnswerEngines) == 0 {
+		return nil
+	}
+	answerCacheMu.Lock()
+	defer answerCacheMu.Unlock()
+	now := time.Now()
+	cache := loadAnswerCache(now)
+	changed := false
+
+	var lists [][]string
+	for _, name := range config.AnswerEngines {
+		if name == searched || name == "tavily" && !config.EnginesTavily.IncludeAnswer {
+			continue
+		}
+		key := answerCacheKey(name, query, opts)
+		if entry, ok := cache[key]; ok {
+			lists = append(lists, entry.Answers)
+			continue
+		}
+		backend, ok := mgr.GetBackend(name)
+		if !ok || !backend.IsAvailable() {
+			continue
+		}
+		detailed, ok := backend.(backends.DetailedSearchBackend)
+		if !ok {
+			continue
+		}
+		resp, err := detailed.SearchDetailed(backends.SearchOptions{
+			Query:      query,
+			Language:   opts.Language,
+			Country:    opts.Country,
+			TimeRange:  opts.TimeRange,
+			SafeSearch: opts.SafeSearch,
+			PageNo:     1,
+			NumResults: 1,
+		})
+		if err != nil {
+			printNotice("%v", err)
+			continue
+		}
+		cache[key] = answerCacheEntry{Answers: resp.Answers, Fetched: now}
+		changed = true
+		lists = append(lists, resp.Answers)
+	}
+	if changed {
+		if err := saveAnswerCache(cache); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not cache answers: %v\n", err)
+		}
+	}
+	return mergeAnswers(lists...)
+}
+
+// printAnswers shows instant answers above the search results.
+func printAnswers(w io.Writer, answers []string, noColor bool) {
+	if len(answers) == 0 {
+		return
+	}
+	if noColor {
+		color.NoColor = true
+	}
+	green := color.New(color.FgGreen, color.Bold)
+	for _, answer := range answers {
+		fmt.Fprintf(w, "%s\n", green.Sprint(answer))
+	}
+	fmt.Fprintln(w)
+}
+
+// formatNumber renders a result without float noise: integers plainly,
+// everything else with up to 10 significant digits.
+func formatNumber(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'g', 10, 64)
+}
+
+// dateLike matches dashed number runs such as dates and year ranges
+// ("2024-01-15", "1939-1945") and bare slashed pairs and dates ("9/11",
+// "24/7", "12/25/2024"), which are searches rather than arithmetic.
+var dateLike = regexp.MustCompile(`^(\d+(-\d+)+|\d+/\d+|\d{1,2}/\d{1,2}/\d{2,4})$`)
+
+// calculate evaluates an arithmetic expression with + - * / % ^ and
+// parentheses. Queries that are a bare number, look like a date, or contain
+// anything other than numbers and operators are not treated as calculations.
+func calculate(expr string) (float64, bool) {
+	expr = strings.TrimSpace(expr)
+	if !strings.ContainsAny(expr, "+-*/%^") || strings.Trim(expr, "0123456789.+-*/%^() \t") != "" {
+		return 0, false
+	}
+	if _, err := strconv.ParseFloat(expr, 64); err == nil || dateLike.MatchString(expr) {
+		return 0, false
+	}
+	p := &calcParser{input: strings.ReplaceAll(expr, " ", "")}
+	value, err := p.parseExpr()
+	if err != nil || p.pos != len(p.input) || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
+
+// calcParser is a recursive descent parser over the grammar
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = factor { ("*" | "/" | "%") factor }
+//	factor = unary [ "^" factor ]
+//	unary  = [ "-" | "+" ] unary | number | "(" expr ")"
+type calcParser struct {
+	input string
+	pos   int
+}
+
+func (p *calcParser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *calcParser) parseExpr() (float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch p.peek() {
+		case '+':
+			p.pos++
+			right, err := p.parseTerm()
+			if err != nil {
+				return 0, err
+			}
+			left += right
+		case '-':
+			p.pos++
+			right, err := p.parseTerm()
+			if err != nil {
+				return 0, err
+			}
+			left -= right
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *calcParser) parseTerm() (float64, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case '*':
+			left *= right
+		case '/':
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left /= right
+		case '%':
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left = math.Mod(left, right)
+		}
+	}
+}
+
+func (p *calcParser) parseFactor() (float64, error) {
+	base, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	if p.peek() == '^' {
+		p.pos++
+		exp, err := p.parseFactor()
+		if err != nil {
+			return 0, err
+		}
+		return math.Pow(base, exp), nil
+	}
+	return base, nil
+}
+
+func (p *calcParser) parseUnary() (float64, error) {
+	switch c := p.peek(); {
+	case c == '-':
+		p.pos++
+		v, err := p.parseUnary()
+		return -v, err
+	case c == '+':
+		p.pos++
+		return p.parseUnary()
+	case c == '(':
+		p.pos++
+		v, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return v, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, fmt.Errorf("expected number at position %d", start)
+	}
+	return strconv.ParseFloat(p.input[start:p.pos], 64)
+}
+
+// unit describes a unit as a factor to the base unit of its dimension.
+type unit struct {
+	dimension string
+	factor    float64
+	name      string
+}
+
+// units maps accepted spellings to units. Temperatures are handled
+// separately since they are offset, not just scaled.
+var units = map[string]unit{}
+
+func init() {
+	define := func(dimension string, factor float64, name string, aliases ...string) {
+		for _, alias := range append(aliases, name) {
+			units[alias] = unit{dimension, factor, name}
+		}
+	}
+	// Length, base meter
+	define("length", 1e-3, "mm", "millimeter", "millimeters")
+	define("length", 1e-2, "cm", "centimeter", "centimeters")
+	define("length", 1, "m", "meter", "meters", "metre", "metres")
+	define("length", 1e3, "km", "kilometer", "kilometers", "kilometre", "kilometres")
+	define("length", 0.0254, "in", "inch", "inches")
+	define("length", 0.3048, "ft", "foot", "feet")
+	define("length", 0.9144, "yd", "yard", "yards")
+	define("length", 1609.344, "mi", "mile", "miles")
+	define("length", 1852, "nmi", "nautical mile")
+	// Mass, base gram
+	define("mass", 1e-3, "mg", "milligram", "milligrams")
+	define("mass", 1, "g", "gram", "grams")
+	define("mass", 1e3, "kg", "kilogram", "kilograms", "kilo", "kilos")
+	define("mass", 1e6, "t", "tonne", "tonnes")
+	define("mass", 28.349523125, "oz", "ounce", "ounces")
+	define("mass", 453.59237, "lb", "lbs", "pound", "pounds")
+	define("mass", 6350.29318, "st", "stone", "stones")
+	// Volume, base liter
+	define("volume", 1e-3, "ml", "milliliter", "milliliters")
+	define("volume", 1, "l", "liter", "liters", "litre", "litres")
+	define("volume", 0.2365882365, "cup", "cups")
+	define("volume", 0.946352946, "qt", "quart", "quarts")
+	define("volume", 3.785411784, "gal", "gallon", "gallons")
+	define("volume", 0.0295735295625, "floz", "fl oz", "fluid ounce", "fluid ounces")
+	// Data, base byte
+	define("data", 1, "B", "byte", "bytes")
+	define("data", 1e3, "KB", "kb", "kilobyte", "kilobytes")
+	define("data", 1e6, "MB", "mb", "megabyte", "megabytes")
+	define("data", 1e9, "GB", "gb", "gigabyte", "gigabytes")
+	define("data", 1e12, "TB", "tb", "terabyte", "terabytes")
+	define("data", 1<<10, "KiB", "kib", "kibibyte", "kibibytes")
+	define("data", 1<<20, "MiB", "mib", "mebibyte", "mebibytes")
+	define("data", 1<<30, "GiB", "gib", "gibibyte", "gibibytes")
+	define("data", 1<<40, "TiB", "tib", "tebibyte", "tebibytes")
+}
+
+var temperatureUnits = map[string]string{
+	"c": "°C", "°c": "°C", "celsius": "°C",
+	"f": "°F", "°f": "°F", "fahrenheit": "°F",
+	"k": "K", "kelvin": "K",
+}
+
+var conversionPattern = regexp.MustCompile(`(?i)^(-?\d+(?:\.\d+)?)\s*([a-z° ]+?)\s+(?:to|in|as)\s+([a-z° ]+)$`)
+
+// convertUnits answers "<number> <unit> to|in <unit>" queries for length,
+// mass, volume, data size and temperature.
+func convertUnits(query string) (string, bool) {
+	m := conversionPattern.FindStringSubmatch(query)
+	if m == nil {
+		return "", false
+	}
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return "", false
+	}
+	from, to := strings.TrimSpace(m[2]), strings.TrimSpace(m[3])
+
+	if fromT, ok := temperatureUnits[strings.ToLower(from)]; ok {
+		toT, ok := temperatureUnits[strings.ToLower(to)]
+		if !ok {
+			return "", false
+		}
+		result := fromKelvin(toKelvin(value, fromT), toT)
+		return fmt.Sprintf("%s %s = %s %s", formatNumber(value), fromT, formatNumber(round(result, 2)), toT), true
+	}
+
+	fromU, ok := lookupUnit(from)
+	if !ok {
+		return "", false
+	}
+	toU, ok := lookupUnit(to)
+	if !ok || fromU.dimension != toU.dimension {
+		return "", false
+	}
+	result := value * fromU.factor / toU.factor
+	return fmt.Sprintf("%s %s = %s %s", formatNumber(value), fromU.name, formatNumber(round(result, 6)), toU.name), true
+}
+
+// lookupUnit matches the exact spelling first so that "MB" and "Mb" style
+// distinctions survive, then falls back to lowercase.
+func lookupUnit(s string) (unit, bool) {
+	if u, ok := units[s]; ok {
+		return u, true
+	}
+	u, ok := units[strings.ToLower(s)]
+	return u, ok
+}
+
+func toKelvin(v float64, unit string) float64 {
+	switch unit {
+	case "°C":
+		return v + 273.15
+	case "°F":
+		return (v-32)*5/9 + 273.15
+	}
+	return v
+}
+
+func fromKelvin(v float64, unit string) float64 {
+	switch unit {
+	case "°C":
+		return v - 273.15
+	case "°F":
+		return (v-273.15)*9/5 + 32
+	}
+	return v
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"sx/backends"
+)
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"12*37", 444},
+		{"2 + 3 * 4", 14},
+		{"(2 + 3) * 4", 20},
+		{"2^3^2", 512},
+		{"-3 + 5", 2},
+		{"10 / 4", 2.5},
+		{"1.5/3", 0.5},
+		{"17 % 5", 2},
+	}
+	for _, tt := range tests {
+		got, ok := calculate(tt.expr)
+		if !ok || got != tt.want {
+			t.Errorf("calculate(%q) = %v, %v; want %v", tt.expr, got, ok, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"42", "-5", "2024-01-15", "1939-1945", "9/11", "24/7", "12/25/2024", "covid-19", "1/0", "(1+2", "golang"} {
+		if got, ok := calculate(expr); ok {
+			t.Errorf("calculate(%q) = %v, want no answer", expr, got)
+		}
+	}
+}
+
+func TestConvertUnits(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"10 km to miles", "10 km = 6.213712 mi"},
+		{"5 ft in cm", "5 ft = 152.4 cm"},
+		{"12 in to cm", "12 in = 30.48 cm"},
+		{"1 GiB in MB", "1 GiB = 1073.741824 MB"},
+		{"100 F to C", "100 °F = 37.78 °C"},
+		{"0 celsius in kelvin", "0 °C = 273.15 K"},
+		{"2 lbs to kg", "2 lb = 0.907185 kg"},
+	}
+	for _, tt := range tests {
+		got, ok := convertUnits(tt.query)
+		if !ok || got != tt.want {
+			t.Errorf("convertUnits(%q) = %q, %v; want %q", tt.query, got, ok, tt.want)
+		}
+	}
+
+	for _, query := range []string{"100 usd to eur", "5 km to kg", "how to convert km to miles"} {
+		if got, ok := convertUnits(query); ok {
+			t.Errorf("convertUnits(%q) = %q, want no answer", query, got)
+		}
+	}
+}
+
+func TestInstantAnswer(t *testing.T) {
+	if got, ok := instantAnswer("12*37"); !ok || got != "12*37 = 444" {
+		t.Errorf("instantAnswer(12*37) = %q, %v", got, ok)
+	}
+	if _, ok := instantAnswer("golang generics"); ok {
+		t.Error("instantAnswer() answered a regular query")
+	}
+}
+
+func TestMergeAnswers(t *testing.T) {
+	got := mergeAnswers(
+		[]string{"12*37 = 444"},
+		[]string{"12*37  =  444", "Go: a programming language", "go: A programming language"},
+		[]string{"", "Go is fast"},
+	)
+	want := []string{"12*37 = 444", "Go: a programming language", "Go is fast"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("mergeAnswers() = %q, want %q", got, want)
+	}
+}
+
+// answeringBackend answers every search with its answers, counting searches
+type answeringBackend struct {
+	stubBackend
+	answers  []string
+	searches int
+}
+
+func (a *answeringBackend) SearchDetailed(opts backends.SearchOptions) (*backends.SearchResponse, error) {
+	a.searches++
+	return &backends.SearchResponse{Answers: a.answers}, nil
+}
+
+func TestEngineAnswers(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	tavily := &answeringBackend{stubBackend: stubBackend{name: "tavily"}, answers: []string{"Go is a programming language by Google."}}
+	serpapi := &answeringBackend{stubBackend: stubBackend{name: "serpapi"}, answers: []string{"go is a programming language by google."}}
+	mgr := backends.NewManager()
+	mgr.Register(tavily)
+	mgr.Register(serpapi)
+	mgr.Register(&stubBackend{name: "brave"}) // no answers
+	config := &Config{AnswerEngines: []string{"tavily", "brave", "missing", "serpapi"}}
+	config.EnginesTavily.IncludeAnswer = true
+	opts := &SearchOptions{Language: "en"}
+
+	// Merged with the engine's answers, repeats dropped across backends
+	searxng := []string{"Go: a programming language"}
+	got := mergeAnswers(searxng, engineAnswers("golang", opts, config, mgr, "searxng"))
+	want := []string{"Go: a programming language", "Go is a programming language by Google."}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("answers = %q, want %q", got, want)
+	}
+	if tavily.searches != 1 || serpapi.searches != 1 {
+		t.Errorf("searches: tavily %d, serpapi %d", tavily.searches, serpapi.searches)
+	}
+
+	// Cached: the same query (apart from spacing) searches no backend again
+	if got := engineAnswers("  Golang ", opts, config, mgr, "searxng"); len(got) != 1 {
+		t.Errorf("cached answers = %q", got)
+	}
+	if tavily.searches != 1 || serpapi.searches != 1 {
+		t.Errorf("cached searches: tavily %d, serpapi %d", tavily.searches, serpapi.searches)
+	}
+	// The engine that searched isn't asked again
+	engineAnswers("rust", opts, config, mgr, "tavily")
+	if tavily.searches != 1 || serpapi.searches != 2 {
+		t.Errorf("searched engine: tavily %d, serpapi %d", tavily.searches, serpapi.searches)
+	}
+	// Other languages are separate
+	engineAnswers("golang", &SearchOptions{Language: "de"}, config, mgr, "searxng")
+	if tavily.searches != 2 {
+		t.Errorf("other language: tavily %d", tavily.searches)
+	}
+	// So are other safe search levels and time ranges
+	engineAnswers("golang", &SearchOptions{Language: "en", SafeSearch: "strict"}, config, mgr, "searxng")
+	engineAnswers("golang", &SearchOptions{Language: "en", TimeRange: "day"}, config, mgr, "searxng")
+	if tavily.searches != 4 {
+		t.Errorf("other filters: tavily %d", tavily.searches)
+	}
+	// Tavily is only asked for answers with include_answer
+	config.EnginesTavily.IncludeAnswer = false
+	engineAnswers("python", opts, config, mgr, "searxng")
+	if tavily.searches != 4 || serpapi.searches != 6 {
+		t.Errorf("include_answer off: tavily %d, serpapi %d", tavily.searches, serpapi.searches)
+	}
+}
+
+func TestAnswerCacheExpires(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	now := time.Now()
+	cache := map[string]answerCacheEntry{
+		"fresh": {Answers: []string{"1 EUR = 1.08 USD"}, Fetched: now.Add(-answerCacheMaxAge / 2)},
+		"stale": {Answers: []string{"1 EUR = 1.02 USD"}, Fetched: now.Add(-answerCacheMaxAge)},
+	}
+	if err := saveAnswerCache(cache); err != nil {
+		t.Fatal(err)
+	}
+	loaded := loadAnswerCache(now)
+	if _, ok := loaded["fresh"]; !ok || len(loaded) != 1 {
+		t.Errorf("loaded %v", loaded)
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+const (
+	// maxSourceChars caps the page text sent to the model per source
+	maxSourceChars = 6000
+	// askSystemPrompt tells the model to answer from the sources only
+	askSystemPrompt = "You answer questions using web search results. Answer from the numbered sources you are given, " +
+		"citing them inline as [1], [2] after the statements they support. Be concise. " +
+		"If the sources don't answer the question, say so instead of guessing."
+)
+
+// check reports what is missing to call the model.
+func (c LLMConfig) check() error {
+	var missing []string
+	if c.URL == "" {
+		missing = append(missing, "url")
+	}
+	if c.Model == "" {
+		missing = append(missing, "model")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("llm: set %s in the [llm] config table", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// chatMessage is a message of an OpenAI-compatible chat completion.
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// askSource is a search result the model answers from.
+type askSource struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	Text  string `json:"-"`
+}
+
+// searchSources searches for query and reads the pages of the first n
+// results, falling back to a result's snippet when its page can't be read.
+func searchSources(query string, n int) ([]askSource, error) {
+	opts := SearchOptions{SafeSearch: config.SafeSearch, PageNo: 1}
+	if err := applyProfile(config, "", &opts, false); err != nil {
+		return nil, err
+	}
+	state := searchState{Query: query}
+	results, err := fetchResults(&state, nil, n, &opts, config)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) > n {
+		results = results[:n]
+	}
+
+	client := setupHTTPClient(config)
+	sources := make([]askSource, len(results))
+	var wg sync.WaitGroup
+	for i, result := range results {
+		sources[i] = askSource{Title: result.Title, URL: result.URL, Text: result.Content}
+		if result.URL == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(source *askSource) {
+			defer wg.Done()
+			_, markdown, err := fetchArticle(client, source.URL, config)
+			if err != nil {
+				if config.Debug {
+					fmt.Fprintf(os.Stderr, "Debug: %v\n", err)
+				}
+				return
+			}
+			if text := strings.TrimSpace(markdown); text != "" {
+				source.Text = text
+			}
+		}(&sources[i])
+	}
+	wg.Wait()
+
+	var usable []askSource
+	for _, source := range sources {
+		if strings.TrimSpace(source.Text) != "" {
+			usable = append(usable, source)
+		}
+	}
+	return usable, nil
+}
+
+// sourcesPrompt numbers sources for the model, starting at first, and
+// appends the question.
+func sourcesPrompt(question string, sources []askSource, first int) string {
+	var b strings.Builder
+	b.WriteString("Sources:\n\n")
+	for i, source := range sources {
+		text := source.Text
+		if len(text) > maxSourceChars {
+			text = strings.ToValidUTF8(text[:maxSourceChars], "") + " […]"
+		}
+		fmt.Fprintf(&b, "[%d] %s\nURL: %s\n%s\n\n", first+i, source.Title, source.URL, text)
+	}
+	fmt.Fprintf(&b, "Question: %s", question)
+	return b.String()
+}
+
+// postChat sends a chat completion request with messages and the extra
+// body fields to the [llm] API.
+func postChat(c LLMConfig, messages []chatMessage, extra map[string]interface{}) (*http.Response, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"model":    c.Model,
+		"messages": messages,
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimSuffix(c.URL, "/") + "/chat/completions"
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sx/"+version)
+	if body["stream"] == true {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	apiKey := os.Getenv("SX_LLM_API_KEY")
+	if apiKey == "" {
+		apiKey = c.APIKey
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	// Answers take a while to generate; only the connection is timed out
+	client := setupHTTPClient(config)
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("llm: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return resp, nil
+}
+
+// completeChat sends messages to the [llm] API and returns its answer
+// once it is complete.
+func completeChat(c LLMConfig, messages []chatMessage, extra map[string]interface{}) (string, error) {
+	resp, err := postChat(c, messages, extra)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var completion struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return "", fmt.Errorf("llm: unexpected response: %v", err)
+	}
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("llm: empty response")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
+
+// streamChat sends messages to the chat completions endpoint of the [llm]
+// API and copies the answer to w as it is generated. It returns the whole
+// answer.
+func streamChat(c LLMConfig, messages []chatMessage, w io.Writer) (string, error) {
+	resp, err := postChat(c, messages, map[string]interface{}{"stream": true})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var answer strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != nil {
+			return answer.String(), fmt.Errorf("llm: %s", chunk.Error.Message)
+		}
+		for _, choice := range chunk.Choices {
+			answer.WriteString(choice.Delta.Content)
+			io.WriteString(w, choice.Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return answer.String(), err
+	}
+	return answer.String(), nil
+}
+
+// printSources lists the sources an answer cites by number.
+func printSources(w io.Writer, sources []askSource, first int) {
+	dim := color.New(color.FgHiBlack)
+	fmt.Fprintln(w)
+	for i, source := range sources {
+		fmt.Fprintf(w, "%s %s %s\n", dim.Sprintf("[%d]", first+i), hyperlink(w, source.Title, source.URL, config.NoColor), dim.Sprint(source.URL))
+	}
+}
+
+// runAsk searches for question, reads the top pages and streams the
+// model's answer with numbered citations, followed by the sources. With
+// chatMode, follow-up questions are read from stdin afterwards.
+func runAsk(question string, n int, chatMode bool) error {
+	if err := config.LLM.check(); err != nil {
+		return err
+	}
+	installTransports(config)
+	backendMgr = initBackendManager(config)
+
+	printNotice("Searching and reading the top %d pages…", n)
+	sources, err := searchSources(question, n)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no results found for %q", question)
+	}
+
+	if chatMode {
+		return chat(question, sources, n, os.Stdin)
+	}
+	messages := []chatMessage{
+		{Role: "system", Content: askSystemPrompt},
+		{Role: "user", Content: sourcesPrompt(question, sources, 1)},
+	}
+	answer, err := streamChat(config.LLM, messages, os.Stdout)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(answer, "\n") {
+		fmt.Println()
+	}
+	printSources(os.Stdout, sources, 1)
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLLMConfigCheck(t *testing.T) {
+	if err := (LLMConfig{URL: "http://x"}).check(); err == nil || !strings.Contains(err.Error(), "model") {
+		t.Errorf("check without model: %v", err)
+	}
+	if err := (LLMConfig{URL: "http://x", Model: "m"}).check(); err != nil {
+		t.Errorf("check: %v", err)
+	}
+}
+
+func TestSourcesPrompt(t *testing.T) {
+	sources := []askSource{
+		{Title: "Go", URL: "https://go.dev", Text: "Go is a language."},
+		{Title: "Long", URL: "https://long.example", Text: strings.Repeat("a", maxSourceChars+100)},
+	}
+	prompt := sourcesPrompt("what is go?", sources, 3)
+	for _, want := range []string{"[3] Go\nURL: https://go.dev\nGo is a language.", "[4] Long", " […]", "Question: what is go?"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, strings.Repeat("a", maxSourceChars+1)) {
+		t.Error("long source not truncated")
+	}
+}
+
+func TestStreamChat(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+	t.Setenv("SX_LLM_API_KEY", "")
+
+	var request struct {
+		Model    string        `json:"model"`
+		Messages []chatMessage `json:"messages"`
+		Stream   bool          `json:"stream"`
+	}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range []string{"Go is ", "a language [1]."} {
+			fmt.Fprintf(w, "data: {\"choices\": [{\"delta\": {\"content\": %q}}]}\n\n", part)
+		}
+		fmt.Fprint(w, ": keep-alive\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	c := LLMConfig{URL: server.URL + "/v1/", Model: "small", APIKey: "key"}
+	var out bytes.Buffer
+	answer, err := streamChat(c, []chatMessage{{Role: "user", Content: "what is go?"}}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "Go is a language [1]." || out.String() != answer {
+		t.Errorf("answer %q, streamed %q", answer, out.String())
+	}
+	if request.Model != "small" || !request.Stream || len(request.Messages) != 1 || auth != "Bearer key" {
+		t.Errorf("request %+v, Authorization %q", request, auth)
+	}
+
+	c.URL = server.URL + "/missing"
+	if _, err := streamChat(c, nil, &out); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing endpoint: %v", err)
+	}
+}
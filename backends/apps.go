@@ -0,0 +1,368 @@
+package backends
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// App stores the apps backend searches
+const (
+	StoreFlathub = "flathub"
+	StoreFDroid  = "fdroid"
+)
+
+// AppsBackend implements SearchBackend for app stores: Flathub for Linux
+// desktop apps and F-Droid for free Android apps, neither of which needs a
+// key. Each result links to the app's store page, with a link that
+// installs it.
+type AppsBackend struct {
+	Stores     []string // flathub, fdroid; both when empty
+	Timeout    time.Duration
+	FlathubURL string // overridable for testing
+	FDroidURL  string // overridable for testing
+	client     *http.Client
+}
+
+// NewAppsBackend creates a new app store backend
+func NewAppsBackend(stores []string, timeout time.Duration) *AppsBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &AppsBackend{
+		Stores:     stores,
+		Timeout:    timeout,
+		FlathubURL: "https://flathub.org",
+		FDroidURL:  "https://search.f-droid.org",
+		client:     newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (a *AppsBackend) Name() string {
+	return "apps"
+}
+
+// IsAvailable reports true: the stores' searches need no key
+func (a *AppsBackend) IsAvailable() bool {
+	return true
+}
+
+// MaxResults is the most apps asked of Flathub for one search; F-Droid
+// has no pages, so later pages are cut from the stores' longer lists
+func (a *AppsBackend) MaxResults() int {
+	return 100
+}
+
+// flathubResponse is the Flathub search response
+type flathubResponse struct {
+	Hits []struct {
+		AppID             string      `json:"app_id"`
+		Name              string      `json:"name"`
+		Summary           string      `json:"summary"`
+		Icon              string      `json:"icon"`
+		DeveloperName     string      `json:"developer_name"`
+		ProjectLicense    string      `json:"project_license"`
+		InstallsLastMonth int         `json:"installs_last_month"`
+		Verified          bool        `json:"verification_verified"`
+		UpdatedAt         interface{} `json:"updated_at"` // Unix seconds
+	} `json:"hits"`
+}
+
+// fdroidResponse is the F-Droid search response
+type fdroidResponse struct {
+	Apps []struct {
+		Name    string `json:"name"`
+		Summary string `json:"summary"`
+		Icon    string `json:"icon"`
+		URL     string `json:"url"`
+	} `json:"apps"`
+}
+
+// appsPeriods maps time ranges to how recently an app must have been
+// updated
+var appsPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// stores returns the stores to search for opts: those configured, less
+// those the site filters leave out
+func (a *AppsBackend) stores(opts SearchOptions) []string {
+	stores := a.Stores
+	if len(stores) == 0 {
+		stores = []string{StoreFlathub, StoreFDroid}
+	}
+	hosts := map[string]string{StoreFlathub: "flathub.org", StoreFDroid: "f-droid.org"}
+	sites := nonEmpty(opts.Sites)
+	var kept []string
+	for _, store := range stores {
+		host := hosts[store]
+		if len(sites) > 0 && !onAnySite(host, sites) || onAnySite(host, opts.ExcludeSites) {
+			continue
+		}
+		kept = append(kept, store)
+	}
+	return kept
+}
+
+// Search searches each store in turn and takes turns between their
+// results. A store that fails is left out; the search fails only if every
+// store does.
+func (a *AppsBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	var lists [][]SearchResult
+	var firstErr error
+	for _, store := range a.stores(opts) {
+		var results []SearchResult
+		var err error
+		switch store {
+		case StoreFlathub:
+			results, err = a.searchFlathub(opts)
+		case StoreFDroid:
+			results, err = a.searchFDroid(opts)
+		default:
+			err = &BackendError{
+				Backend: a.Name(),
+				Err:     fmt.Errorf("unknown store %q (flathub or fdroid)", store),
+				Code:    ErrCodeUnavailable,
+			}
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		lists = append(lists, results)
+	}
+	if len(lists) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+
+	results := takeTurns(lists)
+	start, end := pageBounds(opts)
+	if start >= len(results) {
+		return []SearchResult{}, nil
+	}
+	return results[start:min(end, len(results))], nil
+}
+
+// do sends a request to a store and returns the body of its answer
+func (a *AppsBackend) do(req *http.Request, store string) ([]byte, error) {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", projectUserAgent)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("%s: request failed: %v", store, err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("%s: failed to read response: %v", store, err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+	switch {
+	case resp.StatusCode == 429:
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("%s: rate limited: %s", store, strings.TrimSpace(string(body))),
+			Code:    ErrCodeRateLimit,
+		}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("%s: HTTP %d: %s", store, resp.StatusCode, strings.TrimSpace(string(body))),
+			Code:    resp.StatusCode,
+		}
+	}
+	return body, nil
+}
+
+// searchFlathub searches Flathub for as many apps as the requested page
+// ends with
+func (a *AppsBackend) searchFlathub(opts SearchOptions) ([]SearchResult, error) {
+	_, end := pageBounds(opts)
+	payload, err := json.Marshal(map[string]interface{}{
+		"query":         plainQuery(opts),
+		"hits_per_page": min(end, a.MaxResults()),
+		"page":          1,
+	})
+	if err != nil {
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("flathub: failed to encode request: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+	req, err := http.NewRequest("POST", a.FlathubURL+"/api/v2/search", bytes.NewReader(payload))
+	if err != nil {
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("flathub: failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	body, err := a.do(req, StoreFlathub)
+	if err != nil {
+		return nil, err
+	}
+
+	var flathubResp flathubResponse
+	if err := json.Unmarshal(body, &flathubResp); err != nil {
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("flathub: failed to parse JSON: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var since time.Time
+	if period, ok := appsPeriods[opts.TimeRange]; ok {
+		since = time.Now().Add(-period)
+	}
+	results := make([]SearchResult, 0, len(flathubResp.Hits))
+	for _, app := range flathubResp.Hits {
+		if app.AppID == "" || mentionsAny(app.Name+" "+app.Summary, opts.NoneOf) {
+			continue
+		}
+		updated := unixTime(app.UpdatedAt)
+		if !since.IsZero() && (updated.IsZero() || updated.Before(since)) {
+			continue
+		}
+
+		facts := []string{"Flathub", app.AppID}
+		if app.Verified {
+			facts = append(facts, "verified")
+		}
+		if app.InstallsLastMonth > 0 {
+			facts = append(facts, fmt.Sprintf("%d installs last month", app.InstallsLastMonth))
+		}
+		if app.ProjectLicense != "" && !strings.HasPrefix(app.ProjectLicense, "LicenseRef-") {
+			facts = append(facts, app.ProjectLicense)
+		}
+		result := SearchResult{
+			Title:        collapseSpace(app.Name),
+			URL:          a.FlathubURL + "/apps/" + app.AppID,
+			Content:      collapseSpace(app.Summary),
+			Category:     "it",
+			Author:       collapseSpace(app.DeveloperName),
+			Source:       StoreFlathub,
+			ThumbnailSrc: app.Icon,
+			InstallURL:   "https://dl.flathub.org/repo/appstream/" + app.AppID + ".flatpakref",
+			Metadata:     strings.Join(facts, " · "),
+			Engine:       a.Name(),
+			Engines:      []string{a.Name()},
+		}
+		if !updated.IsZero() {
+			result.PublishedDate = updated.UTC().Format(time.RFC3339)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// searchFDroid searches F-Droid, whose apps have no date: a time range
+// leaves none
+func (a *AppsBackend) searchFDroid(opts SearchOptions) ([]SearchResult, error) {
+	if opts.TimeRange != "" {
+		return nil, nil
+	}
+	params := url.Values{}
+	params.Set("q", plainQuery(opts))
+	req, err := http.NewRequest("GET", a.FDroidURL+"/api/search_apps?"+params.Encode(), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("fdroid: failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	body, err := a.do(req, StoreFDroid)
+	if err != nil {
+		return nil, err
+	}
+
+	var fdroidResp fdroidResponse
+	if err := json.Unmarshal(body, &fdroidResp); err != nil {
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("fdroid: failed to parse JSON: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	results := make([]SearchResult, 0, len(fdroidResp.Apps))
+	for _, app := range fdroidResp.Apps {
+		if app.URL == "" || mentionsAny(app.Name+" "+app.Summary, opts.NoneOf) {
+			continue
+		}
+		// Package pages are .../packages/<package name>/
+		pkg := path.Base(strings.TrimSuffix(urlPath(app.URL), "/"))
+		facts := []string{"F-Droid"}
+		var install string
+		if pkg != "" && pkg != "." && pkg != "/" {
+			facts = append(facts, pkg)
+			install = "market://details?id=" + pkg
+		}
+		results = append(results, SearchResult{
+			Title:        collapseSpace(app.Name),
+			URL:          app.URL,
+			Content:      collapseSpace(app.Summary),
+			Category:     "it",
+			Source:       StoreFDroid,
+			ThumbnailSrc: app.Icon,
+			InstallURL:   install,
+			Metadata:     strings.Join(facts, " · "),
+			Engine:       a.Name(),
+			Engines:      []string{a.Name()},
+		})
+	}
+	return results, nil
+}
+
+// urlPath returns the path of link, or "" if it has none
+func urlPath(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// unixTime converts Unix seconds, given as a JSON number or string, to a
+// time; the zero time if there are none
+func unixTime(v interface{}) time.Time {
+	var seconds int64
+	switch v := v.(type) {
+	case float64:
+		seconds = int64(v)
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return time.Time{}
+		}
+		seconds = n
+	}
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
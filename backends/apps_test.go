@@ -0,0 +1,171 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const flathubTestResponse = `{"query": "maps", "hits": [
+	{"app_id": "org.gnome.Maps", "name": "Maps", "summary": "Find places around the world", "icon": "https://dl.flathub.org/media/maps.png",
+	 "developer_name": "The GNOME Project", "project_license": "GPL-2.0-or-later", "installs_last_month": 12000,
+	 "verification_verified": true, "updated_at": 1709985600},
+	{"app_id": "com.example.StreetMaps", "name": "Street Maps", "summary": "Street maps", "project_license": "LicenseRef-proprietary", "updated_at": %d},
+	{"name": "No ID"}
+]}`
+
+const fdroidTestResponse = `{"apps": [
+	{"name": "OsmAnd~", "summary": "Offline maps and navigation", "icon": "https://f-droid.org/repo/icons/osmand.png",
+	 "url": "https://f-droid.org/en/packages/net.osmand.plus/"},
+	{"name": "Organic Maps", "summary": "Offline maps for travelers", "url": "https://f-droid.org/en/packages/app.organicmaps/"},
+	{"name": "No URL"}
+]}`
+
+// newAppStores starts fake Flathub and F-Droid servers, returning a
+// backend searching them and the Flathub request bodies it receives
+func newAppStores(t *testing.T, flathubStatus int) (*AppsBackend, *[]map[string]interface{}, func()) {
+	var requests []map[string]interface{}
+	flathub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/v2/search" {
+			t.Errorf("unexpected Flathub request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+		w.WriteHeader(flathubStatus)
+		fmt.Fprintf(w, flathubTestResponse, time.Now().Add(-time.Hour).Unix())
+	}))
+	fdroid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/search_apps" || r.URL.Query().Get("q") == "" {
+			t.Errorf("unexpected F-Droid request %s", r.URL)
+		}
+		w.Write([]byte(fdroidTestResponse))
+	}))
+	a := NewAppsBackend(nil, 10*time.Second)
+	a.FlathubURL, a.FDroidURL = flathub.URL, fdroid.URL
+	return a, &requests, func() { flathub.Close(); fdroid.Close() }
+}
+
+func TestAppsBackend_Search(t *testing.T) {
+	a, requests, done := newAppStores(t, http.StatusOK)
+	defer done()
+
+	results, err := a.Search(SearchOptions{Query: "maps"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*requests) != 1 || (*requests)[0]["query"] != "maps" || (*requests)[0]["hits_per_page"] != 10.0 {
+		t.Errorf("unexpected Flathub requests %v", *requests)
+	}
+	// The stores take turns
+	var titles []string
+	for _, r := range results {
+		titles = append(titles, r.Title)
+	}
+	if len(titles) != 4 || titles[0] != "Maps" || titles[1] != "OsmAnd~" || titles[2] != "Street Maps" || titles[3] != "Organic Maps" {
+		t.Fatalf("unexpected results %q", titles)
+	}
+
+	flathub := results[0]
+	if flathub.URL != a.FlathubURL+"/apps/org.gnome.Maps" || flathub.Content != "Find places around the world" ||
+		flathub.Author != "The GNOME Project" || flathub.Category != "it" || flathub.Source != "flathub" ||
+		flathub.InstallURL != "https://dl.flathub.org/repo/appstream/org.gnome.Maps.flatpakref" ||
+		flathub.PublishedDate != "2024-03-09T12:00:00Z" || flathub.Engine != "apps" {
+		t.Errorf("unexpected Flathub result %+v", flathub)
+	}
+	if flathub.Metadata != "Flathub · org.gnome.Maps · verified · 12000 installs last month · GPL-2.0-or-later" {
+		t.Errorf("Flathub metadata %q", flathub.Metadata)
+	}
+	if results[2].Metadata != "Flathub · com.example.StreetMaps" {
+		t.Errorf("metadata without facts: %q", results[2].Metadata)
+	}
+	fdroid := results[1]
+	if fdroid.URL != "https://f-droid.org/en/packages/net.osmand.plus/" || fdroid.Source != "fdroid" ||
+		fdroid.InstallURL != "market://details?id=net.osmand.plus" || fdroid.Metadata != "F-Droid · net.osmand.plus" ||
+		fdroid.ThumbnailSrc != "https://f-droid.org/repo/icons/osmand.png" {
+		t.Errorf("unexpected F-Droid result %+v", fdroid)
+	}
+
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want int
+	}{
+		{"none of", SearchOptions{Query: "maps", NoneOf: []string{"offline"}}, 2},
+		{"one store", SearchOptions{Query: "maps", Sites: []string{"f-droid.org"}}, 2},
+		{"store excluded", SearchOptions{Query: "maps", ExcludeSites: []string{"f-droid.org"}}, 2},
+		{"time range", SearchOptions{Query: "maps", TimeRange: "month"}, 1}, // only Flathub apps have dates
+		{"second page", SearchOptions{Query: "maps", NumResults: 3, PageNo: 2}, 1},
+		{"no store", SearchOptions{Query: "maps", Sites: []string{"example.com"}}, 0},
+	}
+	for _, tt := range tests {
+		results, err := a.Search(tt.opts)
+		if err != nil || len(results) != tt.want {
+			t.Errorf("%s: got %d results, %v; want %d", tt.name, len(results), err, tt.want)
+		}
+	}
+}
+
+func TestAppsBackend_Stores(t *testing.T) {
+	a, requests, done := newAppStores(t, http.StatusOK)
+	defer done()
+
+	a.Stores = []string{StoreFDroid}
+	if results, err := a.Search(SearchOptions{Query: "maps"}); err != nil || len(results) != 2 || len(*requests) != 0 {
+		t.Errorf("F-Droid only: %d results, %v, %d Flathub requests", len(results), err, len(*requests))
+	}
+	a.Stores = []string{"appstore"}
+	if _, err := a.Search(SearchOptions{Query: "maps"}); err == nil || err.(*BackendError).Code != ErrCodeUnavailable {
+		t.Errorf("unknown store: %v", err)
+	}
+}
+
+func TestAppsBackend_Errors(t *testing.T) {
+	// One store failing leaves the other's results
+	a, _, done := newAppStores(t, http.StatusServiceUnavailable)
+	results, err := a.Search(SearchOptions{Query: "maps"})
+	done()
+	if err != nil || len(results) != 2 {
+		t.Errorf("with Flathub failing: %d results, %v", len(results), err)
+	}
+
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusTooManyRequests, `slow down`, ErrCodeRateLimit},
+		{http.StatusInternalServerError, `oops`, http.StatusInternalServerError},
+		{http.StatusOK, `not json`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		a := NewAppsBackend(nil, 10*time.Second)
+		a.FlathubURL, a.FDroidURL = server.URL, server.URL
+		_, err := a.Search(SearchOptions{Query: "test"})
+		server.Close()
+		if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		}
+	}
+}
+
+func TestUnixTime(t *testing.T) {
+	if got := unixTime(1709985600.0); !got.Equal(time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("number: %v", got)
+	}
+	if got := unixTime("1709985600"); got.Unix() != 1709985600 {
+		t.Errorf("string: %v", got)
+	}
+	for _, v := range []interface{}{nil, "soon", 0.0} {
+		if got := unixTime(v); !got.IsZero() {
+			t.Errorf("%v: %v", v, got)
+		}
+	}
+}
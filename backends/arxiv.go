@@ -0,0 +1,310 @@
+package backends
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ArxivBackend implements SearchBackend for the arXiv API, which searches
+// the preprints on arxiv.org and returns an Atom feed
+type ArxivBackend struct {
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewArxivBackend creates a new arXiv backend
+func NewArxivBackend(timeout time.Duration) *ArxivBackend {
+	if timeout == 0 {
+		timeout = 15 * time.Second
+	}
+	return &ArxivBackend{
+		Timeout: timeout,
+		BaseURL: "https://export.arxiv.org/api/query",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (a *ArxivBackend) Name() string {
+	return "arxiv"
+}
+
+// IsAvailable reports true: the arXiv API needs no key
+func (a *ArxivBackend) IsAvailable() bool {
+	return true
+}
+
+// arxivFeed is the Atom feed the arXiv API returns
+type arxivFeed struct {
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Summary   string `xml:"summary"`
+		Published string `xml:"published"`
+		Authors   []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Links []struct {
+			Href  string `xml:"href,attr"`
+			Title string `xml:"title,attr"`
+		} `xml:"link"`
+		DOI             string `xml:"http://arxiv.org/schemas/atom doi"`
+		JournalRef      string `xml:"http://arxiv.org/schemas/atom journal_ref"`
+		PrimaryCategory struct {
+			Term string `xml:"term,attr"`
+		} `xml:"http://arxiv.org/schemas/atom primary_category"`
+	} `xml:"entry"`
+}
+
+// arxivFields are the field prefixes of arXiv's query syntax, which query
+// words may use, e.g. au:hinton
+var arxivFields = map[string]bool{
+	"all": true, "ti": true, "au": true, "abs": true, "co": true,
+	"jr": true, "cat": true, "rn": true, "id": true,
+}
+
+// arxivToken splits a query into words and quoted phrases
+var arxivToken = regexp.MustCompile(`"[^"]*"|\S+`)
+
+// arxivPeriods maps time ranges to how recently a paper must have been
+// submitted
+var arxivPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// arxivTerm searches all fields for term, quoted if it has several words
+func arxivTerm(term string) string {
+	return "all:" + quoteIfSpaced(strings.Trim(term, `"`))
+}
+
+// arxivQuery renders opts in arXiv's search_query syntax: terms joined by
+// AND, AnyOf terms by OR, NoneOf terms after ANDNOT, and a time range as a
+// submittedDate range ending at now.
+func arxivQuery(opts SearchOptions, now time.Time) string {
+	var required []string
+	for _, token := range arxivToken.FindAllString(opts.Query, -1) {
+		field, _, found := strings.Cut(token, ":")
+		switch {
+		case found && arxivFields[strings.ToLower(field)]:
+			required = append(required, token)
+		case strings.HasPrefix(token, `"`):
+			if phrase := strings.TrimSpace(strings.Trim(token, `"`)); phrase != "" {
+				required = append(required, "all:"+quotePhrase(phrase))
+			}
+		case token != "OR" && token != "AND":
+			required = append(required, arxivTerm(token))
+		}
+	}
+	for _, phrase := range opts.Exact {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			required = append(required, "all:"+quotePhrase(phrase))
+		}
+	}
+	for _, term := range opts.AllOf {
+		if term = strings.TrimSpace(term); term != "" {
+			required = append(required, arxivTerm(term))
+		}
+	}
+	var anyOf []string
+	for _, term := range opts.AnyOf {
+		if term = strings.TrimSpace(term); term != "" {
+			anyOf = append(anyOf, arxivTerm(term))
+		}
+	}
+	switch len(anyOf) {
+	case 0:
+	case 1:
+		required = append(required, anyOf[0])
+	default:
+		required = append(required, "("+strings.Join(anyOf, " OR ")+")")
+	}
+	if period, ok := arxivPeriods[opts.TimeRange]; ok {
+		const layout = "200601021504"
+		required = append(required, fmt.Sprintf("submittedDate:[%s TO %s]",
+			now.Add(-period).UTC().Format(layout), now.UTC().Format(layout)))
+	}
+
+	query := strings.Join(required, " AND ")
+	for _, term := range opts.NoneOf {
+		if term = strings.TrimSpace(term); term != "" {
+			query += " ANDNOT " + arxivTerm(term)
+		}
+	}
+	return query
+}
+
+// onArxiv reports whether the site filters of opts leave arxiv.org in
+func onArxiv(opts SearchOptions) bool {
+	sites := nonEmpty(opts.Sites)
+	return (len(sites) == 0 || onAnySite("arxiv.org", sites)) && !onAnySite("arxiv.org", opts.ExcludeSites)
+}
+
+// params builds the request parameters for opts at now
+func (a *ArxivBackend) params(opts SearchOptions, now time.Time) url.Values {
+	num := opts.NumResults
+	if num <= 0 {
+		num = 10
+	}
+	params := url.Values{}
+	params.Set("search_query", arxivQuery(opts, now))
+	params.Set("max_results", strconv.Itoa(num))
+	if opts.PageNo > 1 {
+		params.Set("start", strconv.Itoa((opts.PageNo-1)*num))
+	}
+	params.Set("sortBy", "relevance")
+	return params
+}
+
+// Search performs a search against the arXiv API
+func (a *ArxivBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	if !onArxiv(opts) {
+		return []SearchResult{}, nil
+	}
+
+	req, err := http.NewRequest("GET", a.BaseURL+"?"+a.params(opts, time.Now()).Encode(), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Accept", "application/atom+xml")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("request failed: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	switch {
+	// arXiv answers 503 to clients that don't wait between requests
+	case resp.StatusCode == 429 || resp.StatusCode == 503:
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("rate limited: %s", strings.TrimSpace(string(body))),
+			Code:    ErrCodeRateLimit,
+		}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body))),
+			Code:    resp.StatusCode,
+		}
+	}
+
+	var feed arxivFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, &BackendError{
+			Backend: a.Name(),
+			Err:     fmt.Errorf("failed to parse Atom feed: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	results := make([]SearchResult, 0, len(feed.Entries))
+	for _, entry := range feed.Entries {
+		// A malformed query comes back as a single error entry
+		if strings.Contains(entry.ID, "arxiv.org/api/errors") {
+			return nil, &BackendError{
+				Backend: a.Name(),
+				Err:     fmt.Errorf("%s", collapseSpace(entry.Summary)),
+				Code:    ErrCodeInvalidResponse,
+			}
+		}
+
+		link := strings.Replace(entry.ID, "http://", "https://", 1)
+		var pdf string
+		for _, l := range entry.Links {
+			if l.Title == "pdf" {
+				pdf = strings.Replace(l.Href, "http://", "https://", 1)
+			}
+		}
+		var authors []string
+		for _, author := range entry.Authors {
+			if name := collapseSpace(author.Name); name != "" {
+				authors = append(authors, name)
+			}
+		}
+		journal := collapseSpace(entry.JournalRef)
+		if journal == "" {
+			journal = "arXiv"
+			if entry.PrimaryCategory.Term != "" {
+				journal += " " + entry.PrimaryCategory.Term
+			}
+		}
+		doi := strings.TrimSpace(entry.DOI)
+		if doi == "" {
+			// arXiv registers a DOI for every paper, without its version
+			if id := arxivID(link); id != "" {
+				doi = "10.48550/arXiv." + id
+			}
+		}
+
+		results = append(results, SearchResult{
+			Title:         collapseSpace(entry.Title),
+			URL:           link,
+			Content:       collapseSpace(entry.Summary),
+			Category:      "science",
+			PublishedDate: entry.Published,
+			Author:        authorList(authors),
+			Journal:       journal,
+			DOI:           doi,
+			PDFURL:        pdf,
+			Engine:        a.Name(),
+			Engines:       []string{a.Name()},
+		})
+	}
+	return results, nil
+}
+
+// arxivVersion matches the version suffix of an arXiv ID
+var arxivVersion = regexp.MustCompile(`v\d+$`)
+
+// arxivID returns the arXiv ID, without its version, of an abstract page
+// URL such as https://arxiv.org/abs/2301.00001v2
+func arxivID(link string) string {
+	_, id, found := strings.Cut(link, "arxiv.org/abs/")
+	if !found {
+		return ""
+	}
+	return arxivVersion.ReplaceAllString(id, "")
+}
+
+// authorList joins the names of a paper's authors, the first three and
+// "et al." if there are more
+func authorList(authors []string) string {
+	if len(authors) > 3 {
+		return strings.Join(authors[:3], ", ") + " et al."
+	}
+	return strings.Join(authors, ", ")
+}
+
+// collapseSpace joins the words of s with single spaces
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,141 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const arxivTestFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <title>ArXiv Query</title>
+  <entry>
+    <id>http://arxiv.org/abs/1706.03762v7</id>
+    <published>2017-06-12T17:57:34Z</published>
+    <title>Attention Is All
+      You Need</title>
+    <summary>  The dominant sequence transduction models are based on
+      complex recurrent or convolutional neural networks.</summary>
+    <author><name>Ashish Vaswani</name></author>
+    <author><name>Noam Shazeer</name></author>
+    <author><name>Niki Parmar</name></author>
+    <author><name>Jakob Uszkoreit</name></author>
+    <link href="http://arxiv.org/abs/1706.03762v7" rel="alternate" type="text/html"/>
+    <link title="pdf" href="http://arxiv.org/pdf/1706.03762v7" rel="related" type="application/pdf"/>
+    <arxiv:primary_category term="cs.CL" scheme="http://arxiv.org/schemas/atom"/>
+  </entry>
+  <entry>
+    <id>http://arxiv.org/abs/1512.03385v1</id>
+    <published>2015-12-10T19:51:55Z</published>
+    <title>Deep Residual Learning for Image Recognition</title>
+    <summary>Deeper neural networks are more difficult to train.</summary>
+    <author><name>Kaiming He</name></author>
+    <arxiv:doi>10.1109/CVPR.2016.90</arxiv:doi>
+    <arxiv:journal_ref>CVPR 2016</arxiv:journal_ref>
+    <arxiv:primary_category term="cs.CV" scheme="http://arxiv.org/schemas/atom"/>
+  </entry>
+</feed>`
+
+func TestArxivQuery(t *testing.T) {
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		opts SearchOptions
+		want string
+	}{
+		{SearchOptions{Query: "attention transformer"}, "all:attention AND all:transformer"},
+		{SearchOptions{Query: `au:hinton "deep belief" OR`}, `au:hinton AND all:"deep belief"`},
+		{SearchOptions{Query: "llm", Exact: []string{"in context"}, AnyOf: []string{"math", "code"}, NoneOf: []string{"vision"}},
+			`all:llm AND all:"in context" AND (all:math OR all:code) ANDNOT all:vision`},
+		{SearchOptions{Query: "llm", TimeRange: "week"}, "all:llm AND submittedDate:[202403021200 TO 202403091200]"},
+	}
+	for _, tt := range tests {
+		if got := arxivQuery(tt.opts, now); got != tt.want {
+			t.Errorf("arxivQuery(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+
+	params := NewArxivBackend(0).params(SearchOptions{Query: "llm", NumResults: 20, PageNo: 3}, now)
+	if params.Get("max_results") != "20" || params.Get("start") != "40" || params.Get("sortBy") != "relevance" {
+		t.Errorf("unexpected params %v", params)
+	}
+}
+
+func TestArxivBackend_Search(t *testing.T) {
+	var got url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Write([]byte(arxivTestFeed))
+	}))
+	defer server.Close()
+
+	a := NewArxivBackend(10 * time.Second)
+	a.BaseURL = server.URL
+	results, err := a.Search(SearchOptions{Query: "attention", NumResults: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Get("search_query") != "all:attention" || got.Get("max_results") != "5" {
+		t.Errorf("unexpected request %v", got)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	first := results[0]
+	if first.Title != "Attention Is All You Need" || first.URL != "https://arxiv.org/abs/1706.03762v7" ||
+		first.Content != "The dominant sequence transduction models are based on complex recurrent or convolutional neural networks." {
+		t.Errorf("unexpected result %+v", first)
+	}
+	if first.Author != "Ashish Vaswani, Noam Shazeer, Niki Parmar et al." || first.Journal != "arXiv cs.CL" ||
+		first.PublishedDate != "2017-06-12T17:57:34Z" || first.Category != "science" || first.Engine != "arxiv" {
+		t.Errorf("metadata not mapped: %+v", first)
+	}
+	if first.DOI != "10.48550/arXiv.1706.03762" || first.PDFURL != "https://arxiv.org/pdf/1706.03762v7" {
+		t.Errorf("DOI/PDF: %q %q", first.DOI, first.PDFURL)
+	}
+	if second := results[1]; second.Journal != "CVPR 2016" || second.DOI != "10.1109/CVPR.2016.90" || second.Author != "Kaiming He" {
+		t.Errorf("published paper: %+v", second)
+	}
+
+	got = nil
+	for _, opts := range []SearchOptions{
+		{Query: "attention", Sites: []string{"github.com"}},
+		{Query: "attention", ExcludeSites: []string{"arxiv.org"}},
+	} {
+		if results, err := a.Search(opts); err != nil || len(results) != 0 {
+			t.Errorf("%+v: %d results, %v", opts, len(results), err)
+		}
+	}
+	if got != nil {
+		t.Error("searched arXiv with it filtered out")
+	}
+}
+
+func TestArxivBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusOK, `<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>http://arxiv.org/api/errors#incorrect_id_format_for_1234</id>
+			<title>Error</title><summary>incorrect id format for 1234</summary></entry></feed>`, ErrCodeInvalidResponse},
+		{http.StatusServiceUnavailable, `Rate exceeded.`, ErrCodeRateLimit},
+		{http.StatusInternalServerError, `oops`, http.StatusInternalServerError},
+		{http.StatusOK, `not xml <`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		a := NewArxivBackend(10 * time.Second)
+		a.BaseURL = server.URL
+		_, err := a.Search(SearchOptions{Query: "test"})
+		server.Close()
+		if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		}
+	}
+}
@@ -33,7 +33,7 @@ func NewBingBackend(timeout time.Duration) *BingBackend {
 	return &BingBackend{
 		BaseURL: "https://www.bing.com",
 		Timeout: timeout,
-		client:  &http.Client{Timeout: timeout},
+		client:  newHTTPClient(timeout, nil),
 	}
 }
 
@@ -47,10 +47,7 @@ func (b *BingBackend) IsAvailable() bool {
 }
 
 func (b *BingBackend) Search(opts SearchOptions) ([]SearchResult, error) {
-	query := opts.Query
-	if opts.Site != "" {
-		query = fmt.Sprintf("site:%s %s", opts.Site, query)
-	}
+	query := operatorQuery(opts)
 
 	count := opts.NumResults
 	if count <= 0 {
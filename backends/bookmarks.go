@@ -0,0 +1,309 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Bookmark is a saved or visited page imported from a browser
+type Bookmark struct {
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Folder    string    `json:"folder,omitempty"` // folder path, e.g. "Bookmarks Toolbar / Go"
+	Tags      []string  `json:"tags,omitempty"`
+	Source    string    `json:"source"`            // browser and profile, e.g. "firefox:default-release"
+	History   bool      `json:"history,omitempty"` // visited but not bookmarked
+	Added     time.Time `json:"added,omitzero"`
+	LastVisit time.Time `json:"last_visit,omitzero"`
+	Visits    int       `json:"visits,omitempty"`
+}
+
+// BookmarkIndex is the file `sx bookmarks import` writes and the bookmarks
+// backend searches
+type BookmarkIndex struct {
+	Imported  time.Time  `json:"imported"`
+	Sources   []string   `json:"sources"`
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+// LoadBookmarkIndex reads the bookmark index at path
+func LoadBookmarkIndex(path string) (*BookmarkIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var index BookmarkIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &index, nil
+}
+
+// BookmarksBackend implements SearchBackend over the user's own bookmarks
+// and browsing history, imported with `sx bookmarks import`
+type BookmarksBackend struct {
+	Path string // the bookmark index
+}
+
+// NewBookmarksBackend creates a bookmarks backend searching the index at
+// path
+func NewBookmarksBackend(path string) *BookmarksBackend {
+	return &BookmarksBackend{Path: path}
+}
+
+// Name returns the backend identifier
+func (b *BookmarksBackend) Name() string {
+	return "bookmarks"
+}
+
+// IsAvailable checks if bookmarks have been imported
+func (b *BookmarksBackend) IsAvailable() bool {
+	if b.Path == "" {
+		return false
+	}
+	_, err := os.Stat(b.Path)
+	return err == nil
+}
+
+// bookmarkPeriods maps time ranges to how recently a page must have been
+// bookmarked or visited
+var bookmarkPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// bookmarkMatch is a bookmark that matches the query, with its score
+type bookmarkMatch struct {
+	bookmark Bookmark
+	score    int
+}
+
+// Search finds the imported bookmarks and history entries that contain
+// every query term in their title, URL, folder or tags. Title matches
+// count more than folder and tag matches, which count more than URL
+// matches, and bookmarks a little more than history; equal matches list
+// often and recently visited pages first.
+func (b *BookmarksBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	index, err := LoadBookmarkIndex(b.Path)
+	if os.IsNotExist(err) {
+		return nil, &BackendError{
+			Backend: b.Name(),
+			Err:     fmt.Errorf("no bookmarks imported; run: sx bookmarks import"),
+			Code:    ErrCodeUnavailable,
+		}
+	}
+	if err != nil {
+		return nil, &BackendError{
+			Backend: b.Name(),
+			Err:     err,
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var since time.Time
+	if period, ok := bookmarkPeriods[opts.TimeRange]; ok {
+		since = time.Now().Add(-period)
+	}
+	var matches []bookmarkMatch
+	// Pages imported from several sources are listed once, as their best
+	// match
+	seen := make(map[string]int)
+	for _, bookmark := range index.Bookmarks {
+		if !since.IsZero() && bookmark.Added.Before(since) && bookmark.LastVisit.Before(since) {
+			continue
+		}
+		host := ""
+		if u, err := url.Parse(bookmark.URL); err == nil {
+			host = u.Hostname()
+		}
+		if len(opts.Sites) > 0 && !onAnySite(host, opts.Sites) || onAnySite(host, opts.ExcludeSites) {
+			continue
+		}
+		score, ok := bookmarkScore(bookmark, opts)
+		if !ok {
+			continue
+		}
+		i, dup := seen[bookmark.URL]
+		if !dup {
+			seen[bookmark.URL] = len(matches)
+			matches = append(matches, bookmarkMatch{bookmark, score})
+			continue
+		}
+		visits := max(matches[i].bookmark.Visits, bookmark.Visits)
+		if score > matches[i].score {
+			matches[i] = bookmarkMatch{bookmark, score}
+		}
+		matches[i].bookmark.Visits = visits
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.bookmark.Visits != b.bookmark.Visits {
+			return a.bookmark.Visits > b.bookmark.Visits
+		}
+		return lastUsed(a.bookmark).After(lastUsed(b.bookmark))
+	})
+
+	num := opts.NumResults
+	if num <= 0 {
+		num = 10
+	}
+	start := 0
+	if opts.PageNo > 1 {
+		start = (opts.PageNo - 1) * num
+	}
+	if start >= len(matches) {
+		return []SearchResult{}, nil
+	}
+	matches = matches[start:min(start+num, len(matches))]
+
+	results := make([]SearchResult, len(matches))
+	for i, m := range matches {
+		results[i] = SearchResult{
+			Title:    firstNonEmpty(m.bookmark.Title, m.bookmark.URL),
+			URL:      m.bookmark.URL,
+			Content:  bookmarkSnippet(m.bookmark),
+			Source:   m.bookmark.Source,
+			Category: "general",
+			Score:    float64(m.score),
+			Engine:   b.Name(),
+			Engines:  []string{b.Name()},
+		}
+	}
+	return results, nil
+}
+
+// bookmarkScore scores how well bookmark matches the query and builder
+// terms, reporting false when it doesn't match.
+func bookmarkScore(bookmark Bookmark, opts SearchOptions) (int, bool) {
+	title := strings.ToLower(bookmark.Title)
+	labels := strings.ToLower(bookmark.Folder + " " + strings.Join(bookmark.Tags, " "))
+	link := strings.ToLower(bookmark.URL)
+	score, ok := termScore(opts, func(term string) int {
+		term = strings.ToLower(term)
+		switch {
+		case strings.Contains(title, term):
+			return 3
+		case strings.Contains(labels, term):
+			return 2
+		case strings.Contains(link, term):
+			return 1
+		}
+		return 0
+	})
+	if !ok {
+		return 0, false
+	}
+	if !bookmark.History {
+		score++
+	}
+	return score, true
+}
+
+// termScore adds up the weights of the query words (or their synonyms),
+// the AllOf and Exact terms and the best AnyOf term, reporting false when
+// one of them isn't found or a NoneOf term is. weight scores a term by
+// where it is found, 0 if nowhere.
+func termScore(opts SearchOptions, weight func(term string) int) (int, bool) {
+	// A query word matches if it or a synonym of it does
+	synonyms := make(map[string][]string)
+	for _, group := range opts.Synonyms {
+		for _, term := range group {
+			synonyms[strings.ToLower(term)] = group
+		}
+	}
+
+	score := 0
+	var required []string
+	for _, word := range strings.Fields(opts.Query) {
+		if word = strings.Trim(word, `"'`); word != "" {
+			required = append(required, word)
+		}
+	}
+	required = append(required, opts.AllOf...)
+	required = append(required, opts.Exact...)
+	for _, term := range required {
+		best := weight(term)
+		for _, synonym := range synonyms[strings.ToLower(term)] {
+			best = max(best, weight(synonym))
+		}
+		if best == 0 {
+			return 0, false
+		}
+		score += best
+	}
+	if len(opts.AnyOf) > 0 {
+		best := 0
+		for _, term := range opts.AnyOf {
+			best = max(best, weight(term))
+		}
+		if best == 0 {
+			return 0, false
+		}
+		score += best
+	}
+	for _, term := range opts.NoneOf {
+		if weight(term) > 0 {
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+// onAnySite reports whether host is one of sites or a subdomain of one
+func onAnySite(host string, sites []string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	for _, site := range sites {
+		site = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(site)), "www.")
+		if site != "" && (host == site || strings.HasSuffix(host, "."+site)) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastUsed is when bookmark was last visited, or added if never
+func lastUsed(bookmark Bookmark) time.Time {
+	if bookmark.LastVisit.After(bookmark.Added) {
+		return bookmark.LastVisit
+	}
+	return bookmark.Added
+}
+
+// bookmarkSnippet describes where a bookmark is kept and how it was used,
+// in place of a page snippet
+func bookmarkSnippet(bookmark Bookmark) string {
+	var parts []string
+	if bookmark.History {
+		parts = append(parts, "History")
+	} else if bookmark.Folder != "" {
+		parts = append(parts, bookmark.Folder)
+	} else {
+		parts = append(parts, "Bookmarks")
+	}
+	if len(bookmark.Tags) > 0 {
+		parts = append(parts, "tags: "+strings.Join(bookmark.Tags, ", "))
+	}
+	if !bookmark.Added.IsZero() {
+		parts = append(parts, "added "+bookmark.Added.Local().Format("2006-01-02"))
+	}
+	switch {
+	case bookmark.Visits == 1:
+		parts = append(parts, "visited once")
+	case bookmark.Visits > 1:
+		parts = append(parts, fmt.Sprintf("visited %d times", bookmark.Visits))
+	}
+	if !bookmark.LastVisit.IsZero() {
+		parts = append(parts, "last "+bookmark.LastVisit.Local().Format("2006-01-02"))
+	}
+	return strings.Join(parts, " · ")
+}
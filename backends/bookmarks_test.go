@@ -0,0 +1,108 @@
+package backends
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeBookmarkIndex(t *testing.T, bookmarks []Bookmark) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bookmarks.json")
+	data, err := json.Marshal(BookmarkIndex{Imported: time.Now(), Sources: []string{"firefox:test"}, Bookmarks: bookmarks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func resultURLs(results []SearchResult) []string {
+	urls := make([]string, len(results))
+	for i, r := range results {
+		urls[i] = r.URL
+	}
+	return urls
+}
+
+func TestBookmarksBackend_Search(t *testing.T) {
+	now := time.Now()
+	path := writeBookmarkIndex(t, []Bookmark{
+		{Title: "Go Packages", URL: "https://pkg.go.dev/", Folder: "Toolbar / Go", Source: "firefox:test", Added: now.Add(-400 * 24 * time.Hour)},
+		{Title: "Concurrency patterns", URL: "https://go.dev/talks/concurrency", Folder: "Toolbar / Go", Source: "firefox:test", Visits: 3, LastVisit: now},
+		{Title: "Effective Go", URL: "https://go.dev/doc/effective_go", Source: "firefox:test", History: true, Visits: 40, LastVisit: now},
+		{Title: "Rust channels", URL: "https://doc.rust-lang.org/std/sync/mpsc/", Tags: []string{"concurrency"}, Source: "firefox:test"},
+		{Title: "Go Packages (Chrome)", URL: "https://pkg.go.dev/", Source: "chrome:Default", Visits: 7},
+	})
+	b := NewBookmarksBackend(path)
+	if !b.IsAvailable() || b.Name() != "bookmarks" {
+		t.Fatal("backend with an index is unavailable")
+	}
+
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want []string
+	}{
+		// A bookmarked title match first, then a visited one and a folder
+		// match, the often visited page first; pkg.go.dev is listed once
+		{"ranking", SearchOptions{Query: "go"}, []string{"https://pkg.go.dev/", "https://go.dev/doc/effective_go", "https://go.dev/talks/concurrency"}},
+		{"all terms", SearchOptions{Query: "go concurrency"}, []string{"https://go.dev/talks/concurrency"}},
+		{"tags", SearchOptions{Query: "concurrency", ExcludeSites: []string{"go.dev"}}, []string{"https://doc.rust-lang.org/std/sync/mpsc/"}},
+		{"sites", SearchOptions{Query: "go", Sites: []string{"pkg.go.dev"}}, []string{"https://pkg.go.dev/"}},
+		{"none of", SearchOptions{Query: "go", NoneOf: []string{"packages", "effective"}}, []string{"https://go.dev/talks/concurrency"}},
+		{"any of", SearchOptions{AnyOf: []string{"rust", "effective"}}, []string{"https://doc.rust-lang.org/std/sync/mpsc/", "https://go.dev/doc/effective_go"}},
+		{"time range", SearchOptions{Query: "go", TimeRange: "year"}, []string{"https://go.dev/doc/effective_go", "https://go.dev/talks/concurrency"}},
+		{"paging", SearchOptions{Query: "go", NumResults: 2, PageNo: 2}, []string{"https://go.dev/talks/concurrency"}},
+		{"past the end", SearchOptions{Query: "go", NumResults: 2, PageNo: 3}, []string{}},
+	}
+	for _, tt := range tests {
+		results, err := b.Search(tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := resultURLs(results); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	results, _ := b.Search(SearchOptions{Query: "packages"})
+	if len(results) != 1 || results[0].Title != "Go Packages" || !strings.Contains(results[0].Content, "visited 7 times") || results[0].Engine != "bookmarks" {
+		t.Errorf("merged result: %+v", results)
+	}
+}
+
+func TestBookmarksBackend_NotImported(t *testing.T) {
+	b := NewBookmarksBackend(filepath.Join(t.TempDir(), "bookmarks.json"))
+	if b.IsAvailable() {
+		t.Error("backend without an index is available")
+	}
+	_, err := b.Search(SearchOptions{Query: "go"})
+	if be, ok := err.(*BackendError); !ok || be.Code != ErrCodeUnavailable || !strings.Contains(err.Error(), "sx bookmarks import") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestBookmarkSnippet(t *testing.T) {
+	added := time.Date(2024, 3, 9, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		bookmark Bookmark
+		want     string
+	}{
+		{Bookmark{Folder: "Toolbar / Go", Tags: []string{"golang", "docs"}, Added: added, Visits: 1},
+			"Toolbar / Go · tags: golang, docs · added 2024-03-09 · visited once"},
+		{Bookmark{History: true, Visits: 4, LastVisit: added}, "History · visited 4 times · last 2024-03-09"},
+		{Bookmark{}, "Bookmarks"},
+	}
+	for _, tt := range tests {
+		if got := bookmarkSnippet(tt.bookmark); got != tt.want {
+			t.Errorf("bookmarkSnippet(%+v) = %q, want %q", tt.bookmark, got, tt.want)
+		}
+	}
+}
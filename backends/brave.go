@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -26,9 +27,7 @@ func NewBraveBackend(apiKey string, timeout time.Duration) *BraveBackend {
 		APIKey:  apiKey,
 		Timeout: timeout,
 		BaseURL: "https://api.search.brave.com/res/v1/web/search",
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		client: newHTTPClient(timeout, nil),
 	}
 }
 
@@ -63,6 +62,29 @@ type braveResult struct {
 	Age         string `json:"age,omitempty"`
 }
 
+// braveFreshness maps time ranges to Brave's freshness parameter
+var braveFreshness = map[string]string{
+	"day":   "pd",
+	"week":  "pw",
+	"month": "pm",
+	"year":  "py",
+}
+
+// braveLocale splits a language such as "en-US" into Brave's search_lang
+// ("en") and, unless country is given, country ("US"). "all" and "auto"
+// leave the language to Brave.
+func braveLocale(language, country string) (string, string) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "all" || language == "auto" {
+		language = ""
+	}
+	lang, region, _ := strings.Cut(language, "-")
+	if country == "" && len(region) == 2 {
+		country = region
+	}
+	return lang, strings.ToUpper(strings.TrimSpace(country))
+}
+
 // Search performs a search against Brave Search API
 func (b *BraveBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 	if !b.IsAvailable() {
@@ -76,7 +98,9 @@ func (b *BraveBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 	// Build URL
 	baseURL := b.BaseURL
 	params := url.Values{}
-	params.Set("q", opts.Query)
+	// Brave has no site parameter: sites become site: operators
+	query := excludeSiteQuery(siteQuery(ComposeQuery(opts), opts.Sites), opts.ExcludeSites)
+	params.Set("q", query)
 	
 	// Set result count (max 20)
 	count := opts.NumResults
@@ -99,10 +123,16 @@ func (b *BraveBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 		safeSearch = "strict"
 	}
 	params.Set("safesearch", safeSearch)
-	
-	// Filter by site
-	if opts.Site != "" {
-		params.Set("site", opts.Site)
+
+	if freshness, ok := braveFreshness[opts.TimeRange]; ok {
+		params.Set("freshness", freshness)
+	}
+	lang, country := braveLocale(opts.Language, opts.Country)
+	if lang != "" {
+		params.Set("search_lang", lang)
+	}
+	if country != "" {
+		params.Set("country", country)
 	}
 
 	reqURL := baseURL + "?" + params.Encode()
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 )
@@ -226,3 +227,27 @@ func TestBraveBackend_Search_Pagination(t *testing.T) {
 		t.Errorf("expected offset=20 for page 3, got %q", capturedOffset)
 	}
 }
+
+func TestBraveBackend_Search_Filters(t *testing.T) {
+	var captured url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = r.URL.Query()
+		resp := braveSearchResponse{Web: braveWebResults{Results: []braveResult{}}}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	b := newTestBraveBackend(server.URL, "key")
+	b.Search(SearchOptions{Query: "test", TimeRange: "week", Language: "en-GB", Sites: []string{"go.dev"}})
+	want := map[string]string{"q": "site:go.dev test", "freshness": "pw", "search_lang": "en", "country": "GB", "site": ""}
+	for param, value := range want {
+		if got := captured.Get(param); got != value {
+			t.Errorf("%s = %q, want %q", param, got, value)
+		}
+	}
+
+	b.Search(SearchOptions{Query: "test", Language: "de", Country: "at"})
+	if captured.Get("search_lang") != "de" || captured.Get("country") != "AT" {
+		t.Errorf("search_lang, country = %q, %q, want de, AT", captured.Get("search_lang"), captured.Get("country"))
+	}
+}
@@ -27,7 +27,7 @@ func NewBraveWebBackend(timeout time.Duration) *BraveWebBackend {
 	return &BraveWebBackend{
 		BaseURL: "https://search.brave.com",
 		Timeout: timeout,
-		client:  &http.Client{Timeout: timeout},
+		client:  newHTTPClient(timeout, nil),
 	}
 }
 
@@ -41,10 +41,7 @@ func (b *BraveWebBackend) IsAvailable() bool {
 }
 
 func (b *BraveWebBackend) Search(opts SearchOptions) ([]SearchResult, error) {
-	query := opts.Query
-	if opts.Site != "" {
-		query = fmt.Sprintf("site:%s %s", opts.Site, query)
-	}
+	query := operatorQuery(opts)
 
 	params := url.Values{}
 	params.Set("q", query)
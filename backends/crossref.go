@@ -0,0 +1,290 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CrossrefBackend implements SearchBackend for the Crossref REST API,
+// which searches the metadata publishers register for their DOIs
+type CrossrefBackend struct {
+	Mailto  string // contact address, which puts requests in Crossref's "polite" pool
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewCrossrefBackend creates a new Crossref backend
+func NewCrossrefBackend(mailto string, timeout time.Duration) *CrossrefBackend {
+	if timeout == 0 {
+		timeout = 15 * time.Second
+	}
+	return &CrossrefBackend{
+		Mailto:  mailto,
+		Timeout: timeout,
+		BaseURL: "https://api.crossref.org/works",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (c *CrossrefBackend) Name() string {
+	return "crossref"
+}
+
+// IsAvailable reports true: the Crossref API needs no key
+func (c *CrossrefBackend) IsAvailable() bool {
+	return true
+}
+
+// crossrefResponse is the Crossref works response
+type crossrefResponse struct {
+	Status  string `json:"status"`
+	Message struct {
+		Items []crossrefWork `json:"items"`
+	} `json:"message"`
+}
+
+// crossrefWork is a work in a Crossref response
+type crossrefWork struct {
+	DOI            string   `json:"DOI"`
+	Title          []string `json:"title"`
+	Subtitle       []string `json:"subtitle"`
+	ContainerTitle []string `json:"container-title"`
+	Publisher      string   `json:"publisher"`
+	Abstract       string   `json:"abstract"`
+	Type           string   `json:"type"`
+	Issued         struct {
+		DateParts [][]int `json:"date-parts"`
+	} `json:"issued"`
+	Author []struct {
+		Given  string `json:"given"`
+		Family string `json:"family"`
+		Name   string `json:"name"` // organizations
+	} `json:"author"`
+	Link []struct {
+		URL         string `json:"URL"`
+		ContentType string `json:"content-type"`
+	} `json:"link"`
+	Resource struct {
+		Primary struct {
+			URL string `json:"URL"`
+		} `json:"primary"`
+	} `json:"resource"`
+	Score float64 `json:"score"`
+}
+
+// crossrefFields are the work fields sx asks Crossref for
+const crossrefFields = "DOI,title,subtitle,container-title,publisher,abstract,type,issued,author,link,resource,score"
+
+// crossrefPeriods maps time ranges to how recently a work must have been
+// published
+var crossrefPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// params builds the request parameters for opts at now. Crossref has no
+// query syntax; builder terms become plain keywords.
+func (c *CrossrefBackend) params(opts SearchOptions, now time.Time) url.Values {
+	num := opts.NumResults
+	if num <= 0 {
+		num = 10
+	}
+	params := url.Values{}
+	params.Set("query", plainQuery(opts))
+	params.Set("rows", strconv.Itoa(min(num, 1000)))
+	if opts.PageNo > 1 {
+		params.Set("offset", strconv.Itoa((opts.PageNo-1)*num))
+	}
+	params.Set("select", crossrefFields)
+	if period, ok := crossrefPeriods[opts.TimeRange]; ok {
+		params.Set("filter", "from-pub-date:"+now.Add(-period).UTC().Format("2006-01-02"))
+	}
+	if c.Mailto != "" {
+		params.Set("mailto", c.Mailto)
+	}
+	return params
+}
+
+// Search performs a search against the Crossref API
+func (c *CrossrefBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	req, err := http.NewRequest("GET", c.BaseURL+"?"+c.params(opts, time.Now()).Encode(), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Mailto != "" {
+		req.Header.Set("User-Agent", "sx (mailto:"+c.Mailto+")")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("request failed: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	switch {
+	case resp.StatusCode == 429:
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("rate limited: %s", strings.TrimSpace(string(body))),
+			Code:    ErrCodeRateLimit,
+		}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body))),
+			Code:    resp.StatusCode,
+		}
+	}
+
+	var crossrefResp crossrefResponse
+	if err := json.Unmarshal(body, &crossrefResp); err != nil {
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	results := make([]SearchResult, 0, len(crossrefResp.Message.Items))
+	for _, work := range crossrefResp.Message.Items {
+		if work.DOI == "" || !crossrefOnSites(work, opts) {
+			continue
+		}
+		result := work.result()
+		if mentionsAny(result.Title+" "+result.Content, opts.NoneOf) {
+			continue
+		}
+		result.Engine = c.Name()
+		result.Engines = []string{c.Name()}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// crossrefOnSites reports whether the site filters of opts leave work in.
+// A work is on doi.org and on the site of its publisher's landing page.
+func crossrefOnSites(work crossrefWork, opts SearchOptions) bool {
+	hosts := []string{"doi.org"}
+	if u, err := url.Parse(work.Resource.Primary.URL); err == nil && u.Host != "" {
+		hosts = append(hosts, u.Hostname())
+	}
+	sites := nonEmpty(opts.Sites)
+	included := len(sites) == 0
+	for _, host := range hosts {
+		if onAnySite(host, opts.ExcludeSites) {
+			return false
+		}
+		included = included || onAnySite(host, sites)
+	}
+	return included
+}
+
+// mentionsAny reports whether text contains any of terms, ignoring case
+func mentionsAny(text string, terms []string) bool {
+	text = strings.ToLower(text)
+	for _, term := range nonEmpty(terms) {
+		if strings.Contains(text, strings.ToLower(term)) {
+			return true
+		}
+	}
+	return false
+}
+
+// result converts a Crossref work to a science result linking to its DOI
+func (w crossrefWork) result() SearchResult {
+	title := markupText(strings.Join(w.Title, " "))
+	if len(w.Subtitle) > 0 && w.Subtitle[0] != "" {
+		title += ": " + markupText(w.Subtitle[0])
+	}
+	if title == "" {
+		title = w.DOI
+	}
+	var authors []string
+	for _, author := range w.Author {
+		if name := collapseSpace(firstNonEmpty(strings.TrimSpace(author.Given+" "+author.Family), author.Name)); name != "" {
+			authors = append(authors, name)
+		}
+	}
+	var journal string
+	if len(w.ContainerTitle) > 0 {
+		journal = markupText(w.ContainerTitle[0])
+	}
+	var pdf string
+	for _, link := range w.Link {
+		if link.ContentType == "application/pdf" {
+			pdf = link.URL
+			break
+		}
+	}
+	return SearchResult{
+		Title:         title,
+		URL:           "https://doi.org/" + w.DOI,
+		Content:       markupText(jatsHeading.ReplaceAllString(w.Abstract, "")),
+		Category:      "science",
+		PublishedDate: crossrefDate(w.Issued.DateParts),
+		Author:        authorList(authors),
+		Journal:       journal,
+		Publisher:     collapseSpace(w.Publisher),
+		DOI:           w.DOI,
+		PDFURL:        pdf,
+		Metadata:      strings.ReplaceAll(w.Type, "-", " "),
+		Score:         w.Score,
+	}
+}
+
+// markupTag matches the JATS XML and HTML tags in Crossref titles and
+// abstracts, blockTag those that separate words, and jatsHeading the
+// heading many abstracts open with
+var (
+	markupTag   = regexp.MustCompile(`<[^>]+>`)
+	blockTag    = regexp.MustCompile(`</?(?:jats:)?(?:p|title|sec|list|list-item|br)\b[^>]*>`)
+	jatsHeading = regexp.MustCompile(`(?s)^\s*<jats:title>.*?</jats:title>`)
+)
+
+// markupText returns the text of Crossref metadata with markup in it
+func markupText(s string) string {
+	s = markupTag.ReplaceAllString(blockTag.ReplaceAllString(s, " "), "")
+	return collapseSpace(html.UnescapeString(s))
+}
+
+// crossrefDate formats the first date-parts of a Crossref date, a year
+// with an optional month and day, as a date; a missing month or day is
+// taken as the first.
+func crossrefDate(parts [][]int) string {
+	if len(parts) == 0 || len(parts[0]) == 0 || parts[0][0] == 0 {
+		return ""
+	}
+	date := []int{parts[0][0], 1, 1}
+	copy(date[1:], parts[0][1:min(len(parts[0]), 3)])
+	return fmt.Sprintf("%04d-%02d-%02d", date[0], date[1], date[2])
+}
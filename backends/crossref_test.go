@@ -0,0 +1,136 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const crossrefTestResponse = `{"status": "ok", "message-type": "work-list", "message": {"total-results": 3, "items": [
+	{"DOI": "10.1038/nature14539", "title": ["Deep learning"], "container-title": ["Nature"], "publisher": "Springer Science and Business Media LLC",
+	 "type": "journal-article", "issued": {"date-parts": [[2015, 5, 27]]}, "score": 42.5,
+	 "author": [{"given": "Yann", "family": "LeCun"}, {"given": "Yoshua", "family": "Bengio"}, {"given": "Geoffrey", "family": "Hinton"}, {"name": "Some Consortium"}],
+	 "abstract": "<jats:title>Abstract</jats:title><jats:p>Deep learning allows models to learn CO<jats:sub>2</jats:sub> &amp; more.</jats:p><jats:p>Second paragraph.</jats:p>",
+	 "link": [{"URL": "https://www.nature.com/articles/nature14539.xml", "content-type": "text/xml"}, {"URL": "https://www.nature.com/articles/nature14539.pdf", "content-type": "application/pdf"}],
+	 "resource": {"primary": {"URL": "https://www.nature.com/articles/nature14539"}}},
+	{"DOI": "10.1109/cvpr.2016.90", "title": ["Deep Residual Learning"], "subtitle": ["for <i>Image</i> Recognition"], "publisher": "IEEE",
+	 "type": "proceedings-article", "issued": {"date-parts": [[2016, 6]]},
+	 "resource": {"primary": {"URL": "https://ieeexplore.ieee.org/document/7780459/"}}},
+	{"title": ["No DOI"]}
+]}}`
+
+func TestCrossrefBackend_Params(t *testing.T) {
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	c := NewCrossrefBackend("me@example.com", 0)
+	params := c.params(SearchOptions{Query: "deep learning", AllOf: []string{"review"}, NumResults: 20, PageNo: 3, TimeRange: "year"}, now)
+	if params.Get("query") != "deep learning review" || params.Get("rows") != "20" || params.Get("offset") != "40" ||
+		params.Get("filter") != "from-pub-date:2023-03-10" || params.Get("mailto") != "me@example.com" || params.Get("select") != crossrefFields {
+		t.Errorf("unexpected params %v", params)
+	}
+	params = NewCrossrefBackend("", 0).params(SearchOptions{Query: "x"}, now)
+	if params.Get("rows") != "10" || params.Has("offset") || params.Has("filter") || params.Has("mailto") {
+		t.Errorf("defaults: %v", params)
+	}
+}
+
+func TestCrossrefBackend_Search(t *testing.T) {
+	var query url.Values
+	var agent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		agent = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(crossrefTestResponse))
+	}))
+	defer server.Close()
+
+	c := NewCrossrefBackend("me@example.com", 10*time.Second)
+	c.BaseURL = server.URL
+	results, err := c.Search(SearchOptions{Query: "deep learning"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query.Get("query") != "deep learning" || agent != "sx (mailto:me@example.com)" {
+		t.Errorf("unexpected request %v %q", query, agent)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	first := results[0]
+	if first.Title != "Deep learning" || first.URL != "https://doi.org/10.1038/nature14539" || first.DOI != "10.1038/nature14539" ||
+		first.Journal != "Nature" || first.Publisher != "Springer Science and Business Media LLC" ||
+		first.PublishedDate != "2015-05-27" || first.Author != "Yann LeCun, Yoshua Bengio, Geoffrey Hinton et al." ||
+		first.PDFURL != "https://www.nature.com/articles/nature14539.pdf" || first.Category != "science" ||
+		first.Metadata != "journal article" || first.Score != 42.5 || first.Engine != "crossref" {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	if first.Content != "Deep learning allows models to learn CO2 & more. Second paragraph." {
+		t.Errorf("abstract: %q", first.Content)
+	}
+	if second := results[1]; second.Title != "Deep Residual Learning: for Image Recognition" || second.PublishedDate != "2016-06-01" ||
+		second.Author != "" || second.Journal != "" {
+		t.Errorf("unexpected second result %+v", second)
+	}
+
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want int
+	}{
+		{"publisher site", SearchOptions{Query: "deep", Sites: []string{"nature.com"}}, 1},
+		{"doi.org", SearchOptions{Query: "deep", Sites: []string{"doi.org"}}, 2},
+		{"excluded site", SearchOptions{Query: "deep", ExcludeSites: []string{"ieee.org"}}, 1},
+		{"none of", SearchOptions{Query: "deep", NoneOf: []string{"residual"}}, 1},
+	}
+	for _, tt := range tests {
+		results, err := c.Search(tt.opts)
+		if err != nil || len(results) != tt.want {
+			t.Errorf("%s: got %d results, %v; want %d", tt.name, len(results), err, tt.want)
+		}
+	}
+}
+
+func TestCrossrefBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusTooManyRequests, `too many`, ErrCodeRateLimit},
+		{http.StatusBadRequest, `{"status": "failed", "message": [{"message": "Invalid filter"}]}`, http.StatusBadRequest},
+		{http.StatusOK, `not json`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		c := NewCrossrefBackend("", 10*time.Second)
+		c.BaseURL = server.URL
+		_, err := c.Search(SearchOptions{Query: "test"})
+		server.Close()
+		if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		}
+	}
+}
+
+func TestCrossrefDate(t *testing.T) {
+	tests := map[string][][]int{
+		"2015-05-27": {{2015, 5, 27}},
+		"2016-06-01": {{2016, 6}},
+		"2017-01-01": {{2017}},
+		"":           {{}},
+	}
+	for want, parts := range tests {
+		if got := crossrefDate(parts); got != want {
+			t.Errorf("crossrefDate(%v) = %q, want %q", parts, got, want)
+		}
+	}
+	if got := crossrefDate(nil); got != "" {
+		t.Errorf("no date: %q", got)
+	}
+}
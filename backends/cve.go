@@ -0,0 +1,524 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CVEBackend implements SearchBackend for security advisories. Keywords
+// search the CVEs of the NVD (National Vulnerability Database); queries of
+// advisory IDs look them up, CVE IDs in the NVD and others, such as GHSA-,
+// PYSEC- or RUSTSEC- IDs, in OSV. Results come most severe first.
+type CVEBackend struct {
+	APIKey  string // NVD API key, optional; raises its rate limit
+	Timeout time.Duration
+	NVDURL  string // overridable for testing
+	OSVURL  string // overridable for testing
+	client  *http.Client
+}
+
+// NewCVEBackend creates a new CVE backend
+func NewCVEBackend(apiKey string, timeout time.Duration) *CVEBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &CVEBackend{
+		APIKey:  apiKey,
+		Timeout: timeout,
+		NVDURL:  "https://services.nvd.nist.gov/rest/json/cves/2.0",
+		OSVURL:  "https://api.osv.dev",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (c *CVEBackend) Name() string {
+	return "cve"
+}
+
+// IsAvailable reports true: neither the NVD nor OSV needs a key
+func (c *CVEBackend) IsAvailable() bool {
+	return true
+}
+
+// MaxResults is how many CVEs a keyword search sorts by severity; later
+// pages are cut from them
+func (c *CVEBackend) MaxResults() int {
+	return 200
+}
+
+// nvdResponse is the NVD CVE API's response
+type nvdResponse struct {
+	TotalResults    int `json:"totalResults"`
+	Vulnerabilities []struct {
+		CVE nvdCVE `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+// nvdCVE is a CVE in an NVD response
+type nvdCVE struct {
+	ID           string `json:"id"`
+	Published    string `json:"published"` // UTC, without a zone
+	VulnStatus   string `json:"vulnStatus"`
+	Descriptions []struct {
+		Lang  string `json:"lang"`
+		Value string `json:"value"`
+	} `json:"descriptions"`
+	Metrics    map[string][]nvdMetric `json:"metrics"`
+	Weaknesses []struct {
+		Description []struct {
+			Value string `json:"value"`
+		} `json:"description"`
+	} `json:"weaknesses"`
+}
+
+// nvdMetric is one CVSS assessment of a CVE
+type nvdMetric struct {
+	Type     string `json:"type"` // Primary (the NVD's own) or Secondary
+	CVSSData struct {
+		Version      string  `json:"version"`
+		VectorString string  `json:"vectorString"`
+		BaseScore    float64 `json:"baseScore"`
+		BaseSeverity string  `json:"baseSeverity"` // CVSS 3 and 4
+	} `json:"cvssData"`
+	BaseSeverity string `json:"baseSeverity"` // CVSS 2
+}
+
+// nvdMetricKeys are the NVD's CVSS versions, preferred first
+var nvdMetricKeys = []string{"cvssMetricV40", "cvssMetricV31", "cvssMetricV30", "cvssMetricV2"}
+
+// osvVuln is an OSV advisory
+type osvVuln struct {
+	ID        string   `json:"id"`
+	Summary   string   `json:"summary"`
+	Details   string   `json:"details"`
+	Aliases   []string `json:"aliases"`
+	Published string   `json:"published"`
+	Severity  []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"` // a CVSS vector
+	} `json:"severity"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+	} `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string   `json:"severity"`
+		CWEIDs   []string `json:"cwe_ids"`
+	} `json:"database_specific"`
+}
+
+// cvePeriods maps time ranges to how recently an advisory must have been
+// published
+var cvePeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// nvdMaxRange is the longest publication date range the NVD accepts; longer
+// time ranges are applied to the results instead
+const nvdMaxRange = 120 * 24 * time.Hour
+
+// advisoryID matches the IDs of advisories: CVE and GHSA IDs, and the
+// PREFIX-YEAR-NUMBER IDs of OSV's other databases, such as PYSEC-2021-19
+var advisoryID = regexp.MustCompile(`^(?:(?i:cve)-\d{4}-\d{4,}|(?i:ghsa)(?:-[0-9a-zA-Z]{4}){3}|[A-Z][A-Z0-9]+-\d{4}-[\w.-]+)$`)
+
+// advisoryIDs returns the advisory IDs the query consists of, in their
+// canonical case, or nil if it has other words
+func advisoryIDs(opts SearchOptions) []string {
+	var ids []string
+	for _, word := range strings.Fields(plainQuery(opts)) {
+		if !advisoryID.MatchString(word) {
+			return nil
+		}
+		prefix, rest, _ := strings.Cut(word, "-")
+		switch strings.ToUpper(prefix) {
+		case "CVE":
+			word = strings.ToUpper(word)
+		case "GHSA":
+			word = "GHSA-" + strings.ToLower(rest)
+		}
+		ids = append(ids, word)
+	}
+	return ids
+}
+
+// severityRanks orders severities, most severe highest
+var severityRanks = map[string]int{"critical": 4, "high": 3, "medium": 2, "low": 1}
+
+// sortBySeverity orders results most severe first: by severity, then CVSS
+// score, then newest first
+func sortBySeverity(results []SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Advisory, results[j].Advisory
+		if ra, rb := severityRanks[a.Severity], severityRanks[b.Severity]; ra != rb {
+			return ra > rb
+		}
+		if a.CVSS != b.CVSS {
+			return a.CVSS > b.CVSS
+		}
+		return results[i].PublishedDate > results[j].PublishedDate
+	})
+}
+
+// onSite reports whether the site filters of opts leave host in
+func onSite(host string, opts SearchOptions) bool {
+	sites := nonEmpty(opts.Sites)
+	return (len(sites) == 0 || onAnySite(host, sites)) && !onAnySite(host, opts.ExcludeSites)
+}
+
+// Search looks up the advisories the query names, or searches the NVD for
+// its keywords, and sorts what it finds by severity
+func (c *CVEBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	now := time.Now()
+	var results []SearchResult
+	if ids := advisoryIDs(opts); len(ids) > 0 {
+		// An advisory that fails to load is left out; the search fails only
+		// if every one does
+		var firstErr error
+		failed := 0
+		for _, id := range ids {
+			var found []SearchResult
+			var err error
+			switch {
+			case strings.HasPrefix(id, "CVE-"):
+				if !onSite("nvd.nist.gov", opts) {
+					continue
+				}
+				params := url.Values{}
+				params.Set("cveId", id)
+				found, _, err = c.searchNVD(params, opts)
+			default:
+				if !onSite("osv.dev", opts) {
+					continue
+				}
+				found, err = c.lookupOSV(id, opts)
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				failed++
+				continue
+			}
+			results = append(results, found...)
+		}
+		if failed == len(ids) {
+			return nil, firstErr
+		}
+	} else if onSite("nvd.nist.gov", opts) {
+		found, err := c.searchKeywords(opts, now)
+		if err != nil {
+			return nil, err
+		}
+		results = found
+	}
+
+	if period, ok := cvePeriods[opts.TimeRange]; ok {
+		since := now.Add(-period).UTC().Format(time.RFC3339)
+		kept := results[:0]
+		for _, r := range results {
+			if r.PublishedDate >= since {
+				kept = append(kept, r)
+			}
+		}
+		results = kept
+	}
+	sortBySeverity(results)
+
+	start, end := pageBounds(opts)
+	if start >= len(results) {
+		return []SearchResult{}, nil
+	}
+	return results[start:min(end, len(results))], nil
+}
+
+// searchKeywords searches the NVD for CVEs matching all of the query's
+// keywords. The NVD lists them oldest first, so when more match than one
+// search sorts, the newest are taken.
+func (c *CVEBackend) searchKeywords(opts SearchOptions, now time.Time) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("keywordSearch", plainQuery(opts))
+	params.Set("resultsPerPage", strconv.Itoa(c.MaxResults()))
+	if period, ok := cvePeriods[opts.TimeRange]; ok && period <= nvdMaxRange {
+		params.Set("pubStartDate", now.Add(-period).UTC().Format("2006-01-02T15:04:05.000Z"))
+		params.Set("pubEndDate", now.UTC().Format("2006-01-02T15:04:05.000Z"))
+	}
+	results, total, err := c.searchNVD(params, opts)
+	if err != nil || total <= c.MaxResults() {
+		return results, err
+	}
+	params.Set("startIndex", strconv.Itoa(total-c.MaxResults()))
+	newest, _, err := c.searchNVD(params, opts)
+	if err != nil {
+		// The oldest matches still make a result
+		return results, nil
+	}
+	return newest, nil
+}
+
+// do sends a request to the NVD or OSV and returns the body of its answer.
+// A missing advisory returns no body and no error.
+func (c *CVEBackend) do(req *http.Request, source string) ([]byte, error) {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", projectUserAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("%s: request failed: %v", source, err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("%s: failed to read response: %v", source, err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	// The NVD explains errors in a message header
+	message := strings.TrimSpace(resp.Header.Get("message"))
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound && source == "osv":
+		return nil, nil
+	// The NVD answers 403 to clients over its rate limit
+	case resp.StatusCode == 429 || resp.StatusCode == 403 && source == "nvd":
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("%s: rate limited: %s", source, message),
+			Code:    ErrCodeRateLimit,
+		}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("%s: HTTP %d: %s", source, resp.StatusCode, message),
+			Code:    resp.StatusCode,
+		}
+	}
+	return body, nil
+}
+
+// searchNVD queries the NVD with params, returning its CVEs and how many
+// match in all
+func (c *CVEBackend) searchNVD(params url.Values, opts SearchOptions) ([]SearchResult, int, error) {
+	req, err := http.NewRequest("GET", c.NVDURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, 0, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("nvd: failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	if c.APIKey != "" {
+		req.Header.Set("apiKey", c.APIKey)
+	}
+	body, err := c.do(req, "nvd")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var nvdResp nvdResponse
+	if err := json.Unmarshal(body, &nvdResp); err != nil {
+		return nil, 0, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("nvd: failed to parse JSON: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	results := make([]SearchResult, 0, len(nvdResp.Vulnerabilities))
+	for _, v := range nvdResp.Vulnerabilities {
+		cve := v.CVE
+		if cve.ID == "" || cve.VulnStatus == "Rejected" {
+			continue
+		}
+		if mentionsAny(cve.description(), opts.NoneOf) {
+			continue
+		}
+		results = append(results, cve.result())
+	}
+	return results, nvdResp.TotalResults, nil
+}
+
+// result converts an NVD CVE to an IT result linking to its NVD page
+func (cve nvdCVE) result() SearchResult {
+	advisory := &Advisory{ID: cve.ID}
+	var version string
+	for _, key := range nvdMetricKeys {
+		metrics := cve.Metrics[key]
+		if len(metrics) == 0 {
+			continue
+		}
+		m := metrics[0]
+		for _, candidate := range metrics {
+			if candidate.Type == "Primary" {
+				m = candidate
+				break
+			}
+		}
+		advisory.CVSS = m.CVSSData.BaseScore
+		advisory.Vector = m.CVSSData.VectorString
+		advisory.Severity = strings.ToLower(firstNonEmpty(m.CVSSData.BaseSeverity, m.BaseSeverity))
+		version = m.CVSSData.Version
+		break
+	}
+	for _, w := range cve.Weaknesses {
+		for _, d := range w.Description {
+			if strings.HasPrefix(d.Value, "CWE-") && !slices.Contains(advisory.CWEs, d.Value) {
+				advisory.CWEs = append(advisory.CWEs, d.Value)
+			}
+		}
+	}
+
+	facts := []string{severityLabel(advisory)}
+	if version != "" {
+		facts = append(facts, "CVSS "+version)
+	}
+	facts = append(facts, strings.Join(advisory.CWEs, ", "), cve.VulnStatus)
+
+	result := SearchResult{
+		Title:    cve.ID,
+		URL:      "https://nvd.nist.gov/vuln/detail/" + cve.ID,
+		Content:  collapseSpace(cve.description()),
+		Category: "it",
+		Source:   "nvd",
+		Metadata: strings.Join(nonEmpty(facts), " · "),
+		Advisory: advisory,
+		Score:    advisory.CVSS,
+		Engine:   "cve",
+		Engines:  []string{"cve"},
+	}
+	if published, err := time.Parse("2006-01-02T15:04:05", cve.Published); err == nil {
+		result.PublishedDate = published.UTC().Format(time.RFC3339)
+	}
+	return result
+}
+
+// description returns the CVE's English description, else its first
+func (cve nvdCVE) description() string {
+	var description string
+	for _, d := range cve.Descriptions {
+		if d.Lang == "en" || description == "" {
+			description = d.Value
+		}
+	}
+	return description
+}
+
+// lookupOSV fetches one advisory from OSV; none if OSV doesn't know it
+func (c *CVEBackend) lookupOSV(id string, opts SearchOptions) ([]SearchResult, error) {
+	req, err := http.NewRequest("GET", c.OSVURL+"/v1/vulns/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("osv: failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	body, err := c.do(req, "osv")
+	if err != nil || body == nil {
+		return nil, err
+	}
+
+	var vuln osvVuln
+	if err := json.Unmarshal(body, &vuln); err != nil {
+		return nil, &BackendError{
+			Backend: c.Name(),
+			Err:     fmt.Errorf("osv: failed to parse JSON: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+	if vuln.ID == "" || mentionsAny(vuln.Summary+" "+vuln.Details, opts.NoneOf) {
+		return nil, nil
+	}
+	return []SearchResult{vuln.result()}, nil
+}
+
+// result converts an OSV advisory to an IT result linking to its OSV page
+func (v osvVuln) result() SearchResult {
+	advisory := &Advisory{
+		ID:       v.ID,
+		Aliases:  v.Aliases,
+		Severity: strings.ToLower(v.DatabaseSpecific.Severity),
+		CWEs:     v.DatabaseSpecific.CWEIDs,
+	}
+	// GitHub rates advisories moderate where CVSS says medium
+	if advisory.Severity == "moderate" {
+		advisory.Severity = "medium"
+	}
+	for _, s := range v.Severity {
+		if strings.HasPrefix(s.Type, "CVSS_") {
+			advisory.Vector = s.Score
+		}
+	}
+	for _, a := range v.Affected {
+		if a.Package.Name == "" {
+			continue
+		}
+		pkg := a.Package.Name
+		if a.Package.Ecosystem != "" {
+			pkg = a.Package.Ecosystem + "/" + pkg
+		}
+		if !slices.Contains(advisory.Affected, pkg) {
+			advisory.Affected = append(advisory.Affected, pkg)
+		}
+	}
+
+	facts := []string{severityLabel(advisory), strings.Join(advisory.CWEs, ", "), strings.Join(v.Aliases, ", ")}
+	if n := len(advisory.Affected); n > 3 {
+		facts = append(facts, strings.Join(advisory.Affected[:3], ", ")+fmt.Sprintf(" and %d more", n-3))
+	} else {
+		facts = append(facts, strings.Join(advisory.Affected, ", "))
+	}
+
+	title := v.ID
+	if summary := collapseSpace(v.Summary); summary != "" {
+		title += ": " + summary
+	}
+	result := SearchResult{
+		Title:    title,
+		URL:      "https://osv.dev/vulnerability/" + v.ID,
+		Content:  collapseSpace(v.Details),
+		Category: "it",
+		Source:   "osv",
+		Metadata: strings.Join(nonEmpty(facts), " · "),
+		Advisory: advisory,
+		Engine:   "cve",
+		Engines:  []string{"cve"},
+	}
+	if published, err := time.Parse(time.RFC3339, v.Published); err == nil {
+		result.PublishedDate = published.UTC().Format(time.RFC3339)
+	}
+	return result
+}
+
+// severityLabel shows an advisory's severity and score, e.g. "CRITICAL
+// 10.0", to lead its metadata
+func severityLabel(a *Advisory) string {
+	severity := strings.ToUpper(a.Severity)
+	if a.CVSS > 0 {
+		severity = strings.TrimSpace(fmt.Sprintf("%s %.1f", severity, a.CVSS))
+	}
+	return severity
+}
@@ -0,0 +1,232 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const nvdTestResponse = `{"resultsPerPage": 3, "startIndex": 0, "totalResults": 3, "vulnerabilities": [
+	{"cve": {"id": "CVE-2021-45105", "published": "2021-12-18T12:15:07.433", "vulnStatus": "Modified",
+	 "descriptions": [{"lang": "es", "value": "Versiones de Apache Log4j2"}, {"lang": "en", "value": "Apache Log4j2 versions 2.0-alpha1 through 2.16.0 did not protect from uncontrolled recursion."}],
+	 "metrics": {"cvssMetricV31": [{"type": "Secondary", "cvssData": {"version": "3.1", "baseScore": 7.5, "baseSeverity": "HIGH"}},
+	                               {"type": "Primary", "cvssData": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:N/A:H", "baseScore": 5.9, "baseSeverity": "MEDIUM"}}],
+	             "cvssMetricV2": [{"type": "Primary", "cvssData": {"version": "2.0", "baseScore": 4.3}, "baseSeverity": "MEDIUM"}]},
+	 "weaknesses": [{"description": [{"value": "CWE-674"}]}, {"description": [{"value": "NVD-CWE-Other"}, {"value": "CWE-674"}]}]}},
+	{"cve": {"id": "CVE-2021-44228", "published": "2021-12-10T10:15:09.143", "vulnStatus": "Analyzed",
+	 "descriptions": [{"lang": "en", "value": "Apache Log4j2 JNDI features do not protect against attacker controlled LDAP endpoints."}],
+	 "metrics": {"cvssMetricV31": [{"type": "Primary", "cvssData": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", "baseScore": 10.0, "baseSeverity": "CRITICAL"}}]},
+	 "weaknesses": [{"description": [{"value": "CWE-502"}]}, {"description": [{"value": "CWE-400"}]}]}},
+	{"cve": {"id": "CVE-2021-99999", "vulnStatus": "Rejected", "descriptions": [{"lang": "en", "value": "Rejected reason: duplicate"}]}}
+]}`
+
+const osvTestResponse = `{"id": "GHSA-jfh8-c2jp-5v3q", "summary": "Remote code injection in Log4j",
+	"details": "Logging untrusted data with log4j versions 2.0-beta9 through 2.14.1 can result in remote code execution.",
+	"aliases": ["CVE-2021-44228"], "published": "2021-12-10T00:40:56Z",
+	"severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H"}],
+	"affected": [{"package": {"ecosystem": "Maven", "name": "org.apache.logging.log4j:log4j-core"}},
+	             {"package": {"ecosystem": "Maven", "name": "org.apache.logging.log4j:log4j-core"}},
+	             {"package": {"ecosystem": "Maven", "name": "org.ops4j.pax.logging:pax-logging-log4j2"}}],
+	"database_specific": {"severity": "CRITICAL", "cwe_ids": ["CWE-20", "CWE-502"]}}`
+
+func TestAdvisoryIDs(t *testing.T) {
+	if got := advisoryIDs(SearchOptions{Query: "cve-2021-44228 ghsa-JFH8-c2jp-5v3q PYSEC-2021-19 RUSTSEC-2021-0001"}); !reflect.DeepEqual(got, []string{"CVE-2021-44228", "GHSA-jfh8-c2jp-5v3q", "PYSEC-2021-19", "RUSTSEC-2021-0001"}) {
+		t.Errorf("got %q", got)
+	}
+	for _, query := range []string{"log4j", "CVE-2021-44228 log4j", "cve-2021", "", "x-2021-1"} {
+		if got := advisoryIDs(SearchOptions{Query: query}); got != nil {
+			t.Errorf("%q: got %q", query, got)
+		}
+	}
+}
+
+func TestCVEBackend_SearchKeywords(t *testing.T) {
+	var queries []url.Values
+	var apiKeys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		apiKeys = append(apiKeys, r.Header.Get("apiKey"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(nvdTestResponse))
+	}))
+	defer server.Close()
+
+	c := NewCVEBackend("nvd-key", 10*time.Second)
+	c.NVDURL = server.URL
+	results, err := c.Search(SearchOptions{Query: "log4j", AllOf: []string{"apache"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(queries) != 1 || queries[0].Get("keywordSearch") != "log4j apache" || queries[0].Get("resultsPerPage") != "200" ||
+		queries[0].Has("startIndex") || queries[0].Has("pubStartDate") || apiKeys[0] != "nvd-key" {
+		t.Errorf("unexpected requests %v, keys %q", queries, apiKeys)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	// The critical CVE comes first, though the NVD listed it second
+	first := results[0]
+	if first.Title != "CVE-2021-44228" || first.URL != "https://nvd.nist.gov/vuln/detail/CVE-2021-44228" ||
+		first.Category != "it" || first.Score != 10 || first.PublishedDate != "2021-12-10T10:15:09Z" || first.Engine != "cve" {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	if first.Metadata != "CRITICAL 10.0 · CVSS 3.1 · CWE-502, CWE-400 · Analyzed" {
+		t.Errorf("metadata %q", first.Metadata)
+	}
+	want := &Advisory{ID: "CVE-2021-44228", Severity: "critical", CVSS: 10, Vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", CWEs: []string{"CWE-502", "CWE-400"}}
+	if !reflect.DeepEqual(first.Advisory, want) {
+		t.Errorf("advisory %+v", first.Advisory)
+	}
+
+	// The NVD's own score is preferred over a secondary one
+	second := results[1]
+	if second.Advisory.Severity != "medium" || second.Advisory.CVSS != 5.9 || !reflect.DeepEqual(second.Advisory.CWEs, []string{"CWE-674"}) ||
+		!strings.HasPrefix(second.Content, "Apache Log4j2 versions") {
+		t.Errorf("unexpected second result %+v", second)
+	}
+
+	if results, err := c.Search(SearchOptions{Query: "log4j", NoneOf: []string{"jndi"}}); err != nil || len(results) != 1 {
+		t.Errorf("none of: %d results, %v", len(results), err)
+	}
+	if results, err := c.Search(SearchOptions{Query: "log4j", NumResults: 1, PageNo: 2}); err != nil || len(results) != 1 || results[0].Title != "CVE-2021-45105" {
+		t.Errorf("second page: %+v, %v", results, err)
+	}
+	queries = nil
+	if results, err := c.Search(SearchOptions{Query: "log4j", TimeRange: "week"}); err != nil || len(results) != 0 || !queries[0].Has("pubStartDate") {
+		t.Errorf("week: %d results, %v, %v", len(results), err, queries)
+	}
+	queries = nil
+	if results, err := c.Search(SearchOptions{Query: "log4j", TimeRange: "year"}); err != nil || len(results) != 0 || queries[0].Has("pubStartDate") {
+		t.Errorf("year: %d results, %v, %v", len(results), err, queries)
+	}
+	queries = nil
+	if results, err := c.Search(SearchOptions{Query: "log4j", ExcludeSites: []string{"nvd.nist.gov"}}); err != nil || len(results) != 0 || len(queries) != 0 {
+		t.Errorf("excluded: %d results, %v, %d requests", len(results), err, len(queries))
+	}
+}
+
+func TestCVEBackend_SearchKeywordsNewest(t *testing.T) {
+	var starts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := r.URL.Query().Get("startIndex")
+		starts = append(starts, start)
+		id := "CVE-1999-0001"
+		if start != "" {
+			id = "CVE-2024-0001"
+		}
+		w.Write([]byte(`{"totalResults": 450, "vulnerabilities": [{"cve": {"id": "` + id + `"}}]}`))
+	}))
+	defer server.Close()
+
+	c := NewCVEBackend("", 10*time.Second)
+	c.NVDURL = server.URL
+	results, err := c.Search(SearchOptions{Query: "openssl"})
+	if err != nil || len(results) != 1 || results[0].Title != "CVE-2024-0001" {
+		t.Fatalf("got %+v, %v", results, err)
+	}
+	if !reflect.DeepEqual(starts, []string{"", strconv.Itoa(450 - c.MaxResults())}) {
+		t.Errorf("start indexes %q", starts)
+	}
+}
+
+func TestCVEBackend_LookUp(t *testing.T) {
+	var paths []string
+	nvd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, "nvd "+r.URL.Query().Get("cveId"))
+		w.Write([]byte(`{"totalResults": 1, "vulnerabilities": [{"cve": {"id": "CVE-2021-3156", "published": "2021-01-26T21:15:12.987",
+			"metrics": {"cvssMetricV2": [{"type": "Primary", "cvssData": {"version": "2.0", "baseScore": 7.2}, "baseSeverity": "HIGH"}]}}}]}`))
+	}))
+	defer nvd.Close()
+	osv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, "osv "+r.URL.Path)
+		if r.URL.Path != "/v1/vulns/GHSA-jfh8-c2jp-5v3q" {
+			http.Error(w, `{"code": 5, "message": "Bug not found."}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(osvTestResponse))
+	}))
+	defer osv.Close()
+
+	c := NewCVEBackend("", 10*time.Second)
+	c.NVDURL, c.OSVURL = nvd.URL, osv.URL
+	results, err := c.Search(SearchOptions{Query: "cve-2021-3156 GHSA-JFH8-C2JP-5V3Q PYSEC-2099-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(paths, []string{"nvd CVE-2021-3156", "osv /v1/vulns/GHSA-jfh8-c2jp-5v3q", "osv /v1/vulns/PYSEC-2099-1"}) {
+		t.Errorf("requests %q", paths)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	ghsa := results[0]
+	if ghsa.Title != "GHSA-jfh8-c2jp-5v3q: Remote code injection in Log4j" || ghsa.URL != "https://osv.dev/vulnerability/GHSA-jfh8-c2jp-5v3q" ||
+		ghsa.Source != "osv" || ghsa.PublishedDate != "2021-12-10T00:40:56Z" {
+		t.Errorf("unexpected OSV result %+v", ghsa)
+	}
+	if ghsa.Metadata != "CRITICAL · CWE-20, CWE-502 · CVE-2021-44228 · Maven/org.apache.logging.log4j:log4j-core, Maven/org.ops4j.pax.logging:pax-logging-log4j2" {
+		t.Errorf("metadata %q", ghsa.Metadata)
+	}
+	if a := ghsa.Advisory; a.Severity != "critical" || a.Vector == "" || len(a.Affected) != 2 || !reflect.DeepEqual(a.Aliases, []string{"CVE-2021-44228"}) {
+		t.Errorf("advisory %+v", a)
+	}
+	if sudo := results[1]; sudo.Metadata != "HIGH 7.2 · CVSS 2.0" || sudo.Advisory.Severity != "high" {
+		t.Errorf("unexpected NVD result %+v", sudo)
+	}
+
+	paths = nil
+	if results, err := c.Search(SearchOptions{Query: "CVE-2021-3156 GHSA-jfh8-c2jp-5v3q", Sites: []string{"osv.dev"}}); err != nil || len(results) != 1 || len(paths) != 1 {
+		t.Errorf("OSV only: %d results, %v, %q", len(results), err, paths)
+	}
+}
+
+func TestCVEBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusForbidden, ``, ErrCodeRateLimit},
+		{http.StatusTooManyRequests, ``, ErrCodeRateLimit},
+		{http.StatusNotFound, ``, http.StatusNotFound},
+		{http.StatusOK, `not json`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("message", "Invalid apiKey")
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		c := NewCVEBackend("", 10*time.Second)
+		c.NVDURL, c.OSVURL = server.URL, server.URL
+		for _, query := range []string{"log4j", "CVE-2021-44228"} {
+			_, err := c.Search(SearchOptions{Query: query})
+			if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != tt.code {
+				t.Errorf("%d %q: got %v, want code %d", tt.status, query, err, tt.code)
+			}
+		}
+		server.Close()
+	}
+
+	// A lookup that fails leaves out only that advisory
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cveId") == "CVE-2021-0001" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"totalResults": 1, "vulnerabilities": [{"cve": {"id": "CVE-2021-0002"}}]}`))
+	}))
+	defer server.Close()
+	c := NewCVEBackend("", 10*time.Second)
+	c.NVDURL = server.URL
+	if results, err := c.Search(SearchOptions{Query: "CVE-2021-0001 CVE-2021-0002"}); err != nil || len(results) != 1 {
+		t.Errorf("one failed: %d results, %v", len(results), err)
+	}
+}
@@ -0,0 +1,337 @@
+package backends
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ElasticBackend implements SearchBackend for an Elasticsearch or
+// OpenSearch index the user runs, such as a team's internal documents.
+// What it searches and how is up to the config: the index, a query
+// template and the document fields results are made of.
+type ElasticBackend struct {
+	URL      string // cluster URL, e.g. http://localhost:9200
+	Index    string // index name, pattern or comma-separated list
+	Query    string // JSON query template; see DefaultElasticQuery
+	APIKey   string // sent as "ApiKey <key>"; takes precedence over Username
+	Username string
+	Password string
+
+	// Document fields, which may be dotted paths into nested objects
+	TitleField   string // "title" by default
+	URLField     string // "url" by default; results link to the document without it
+	ContentField string // "content" by default
+	DateField    string // filtered by --time-range when set
+
+	Timeout time.Duration
+	client  *http.Client
+}
+
+// DefaultElasticQuery is the query template used when none is configured.
+// {{query}} is replaced by the search (see phraseQuery) as a JSON string,
+// quotes included.
+const DefaultElasticQuery = `{"simple_query_string": {"query": {{query}}, "default_operator": "and"}}`
+
+// NewElasticBackend creates a new Elasticsearch/OpenSearch backend
+func NewElasticBackend(baseURL, index string, timeout time.Duration) *ElasticBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &ElasticBackend{
+		URL:     strings.TrimSuffix(baseURL, "/"),
+		Index:   index,
+		Timeout: timeout,
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// SetClientCert loads a PEM client certificate and key presented to a
+// cluster that requires mutual TLS. Empty paths remove the certificate.
+func (e *ElasticBackend) SetClientCert(certFile, keyFile string) error {
+	cert, err := LoadClientCert(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	e.client = newHTTPClient(e.Timeout, clientCertTransport(cert))
+	return nil
+}
+
+// Name returns the backend identifier
+func (e *ElasticBackend) Name() string {
+	return "elastic"
+}
+
+// IsAvailable checks if a cluster and index are configured
+func (e *ElasticBackend) IsAvailable() bool {
+	return e.URL != "" && e.Index != ""
+}
+
+// elasticRanges maps time ranges to Elasticsearch date math
+var elasticRanges = map[string]string{
+	"day":   "now-1d",
+	"week":  "now-1w",
+	"month": "now-1M",
+	"year":  "now-1y",
+}
+
+// elasticTerm matches documents containing term, as a phrase if it has
+// several words
+func elasticTerm(term string) map[string]any {
+	return map[string]any{"simple_query_string": map[string]any{"query": quotePhrase(term)}}
+}
+
+// body builds the search request for opts: the query template filled in,
+// wrapped in a bool query for the AnyOf and NoneOf terms and the time range
+func (e *ElasticBackend) body(opts SearchOptions) ([]byte, error) {
+	text, err := json.Marshal(phraseQuery(opts))
+	if err != nil {
+		return nil, err
+	}
+	template := e.Query
+	if strings.TrimSpace(template) == "" {
+		template = DefaultElasticQuery
+	}
+	// Templates may quote the placeholder or not
+	filled := strings.ReplaceAll(template, `"{{query}}"`, string(text))
+	filled = strings.ReplaceAll(filled, "{{query}}", string(text))
+	var query any
+	if err := json.Unmarshal([]byte(filled), &query); err != nil {
+		return nil, fmt.Errorf("invalid query template: %v", err)
+	}
+
+	var should, mustNot, filter []any
+	for _, term := range nonEmpty(opts.AnyOf) {
+		should = append(should, elasticTerm(term))
+	}
+	for _, term := range nonEmpty(opts.NoneOf) {
+		mustNot = append(mustNot, elasticTerm(term))
+	}
+	if since, ok := elasticRanges[opts.TimeRange]; ok && e.DateField != "" {
+		filter = append(filter, map[string]any{"range": map[string]any{e.DateField: map[string]any{"gte": since}}})
+	}
+	if len(should)+len(mustNot)+len(filter) > 0 {
+		boolQuery := map[string]any{"must": []any{query}}
+		if len(should) > 0 {
+			boolQuery["should"] = should
+			boolQuery["minimum_should_match"] = 1
+		}
+		if len(mustNot) > 0 {
+			boolQuery["must_not"] = mustNot
+		}
+		if len(filter) > 0 {
+			boolQuery["filter"] = filter
+		}
+		query = map[string]any{"bool": boolQuery}
+	}
+
+	start, end := pageBounds(opts)
+	content := firstNonEmpty(e.ContentField, "content")
+	return json.Marshal(map[string]any{
+		"query": query,
+		"from":  start,
+		"size":  end - start,
+		// Passages around the matches make better snippets than the
+		// start of a long document
+		"highlight": map[string]any{
+			"fields":              map[string]any{content: map[string]any{}},
+			"pre_tags":            []string{""},
+			"post_tags":           []string{""},
+			"fragment_size":       200,
+			"number_of_fragments": 2,
+		},
+	})
+}
+
+// elasticResponse is the search response, or the error, of the cluster
+type elasticResponse struct {
+	Hits struct {
+		Hits []struct {
+			Index     string              `json:"_index"`
+			ID        string              `json:"_id"`
+			Score     float64             `json:"_score"`
+			Source    map[string]any      `json:"_source"`
+			Highlight map[string][]string `json:"highlight"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Error json.RawMessage `json:"error"`
+}
+
+// elasticError returns the reason of a cluster error, which is an object
+// with a type and reason or, from some proxies, a string
+func elasticError(raw json.RawMessage) string {
+	var e struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	if json.Unmarshal(raw, &e) == nil && e.Reason != "" {
+		if e.Type != "" {
+			return e.Type + ": " + e.Reason
+		}
+		return e.Reason
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return ""
+}
+
+// sourceField returns the text of a field of a document, following dotted
+// paths into nested objects unless the document has a field of that name.
+// Lists give their first text.
+func sourceField(source map[string]any, path string) string {
+	value, ok := source[path]
+	if !ok {
+		value = any(source)
+		for _, key := range strings.Split(path, ".") {
+			object, ok := value.(map[string]any)
+			if !ok {
+				return ""
+			}
+			value = object[key]
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// Search performs a search on the configured index
+func (e *ElasticBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	if !e.IsAvailable() {
+		return nil, &BackendError{
+			Backend: e.Name(),
+			Err:     fmt.Errorf("Elasticsearch url or index not configured"),
+			Code:    ErrCodeUnavailable,
+		}
+	}
+	payload, err := e.body(opts)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: e.Name(),
+			Err:     err,
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	req, err := http.NewRequest("POST", e.URL+"/"+url.PathEscape(e.Index)+"/_search", bytes.NewReader(payload))
+	if err != nil {
+		return nil, &BackendError{
+			Backend: e.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case e.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+e.APIKey)
+	case e.Username != "":
+		req.SetBasicAuth(e.Username, e.Password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: e.Name(),
+			Err:     fmt.Errorf("request failed: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: e.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var esResp elasticResponse
+	parseErr := json.Unmarshal(body, &esResp)
+
+	if resp.StatusCode != http.StatusOK {
+		message := elasticError(esResp.Error)
+		if parseErr != nil || message == "" {
+			message = strings.TrimSpace(string(body))
+		}
+		switch resp.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return nil, &BackendError{
+				Backend: e.Name(),
+				Err:     fmt.Errorf("authentication failed: %s", message),
+				Code:    ErrCodeAuth,
+			}
+		case http.StatusTooManyRequests:
+			return nil, &BackendError{
+				Backend: e.Name(),
+				Err:     fmt.Errorf("rate limited: %s", message),
+				Code:    ErrCodeRateLimit,
+			}
+		default:
+			return nil, &BackendError{
+				Backend: e.Name(),
+				Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, message),
+				Code:    resp.StatusCode,
+			}
+		}
+	}
+	if parseErr != nil {
+		return nil, &BackendError{
+			Backend: e.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", parseErr),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	titleField := firstNonEmpty(e.TitleField, "title")
+	urlField := firstNonEmpty(e.URLField, "url")
+	contentField := firstNonEmpty(e.ContentField, "content")
+	results := make([]SearchResult, 0, len(esResp.Hits.Hits))
+	for _, hit := range esResp.Hits.Hits {
+		link := sourceField(hit.Source, urlField)
+		if link == "" {
+			// The document itself, for indexes of text without links
+			link = e.URL + "/" + url.PathEscape(hit.Index) + "/_doc/" + url.PathEscape(hit.ID)
+		}
+		content := strings.Join(hit.Highlight[contentField], " … ")
+		if content == "" {
+			content = sourceField(hit.Source, contentField)
+		}
+		result := SearchResult{
+			Title:    collapseSpace(firstNonEmpty(sourceField(hit.Source, titleField), hit.ID)),
+			URL:      link,
+			Content:  collapseSpace(content),
+			Category: "general",
+			Metadata: hit.Index,
+			Score:    hit.Score,
+			Engine:   e.Name(),
+			Engines:  []string{e.Name()},
+		}
+		if e.DateField != "" {
+			result.PublishedDate = sourceField(hit.Source, e.DateField)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
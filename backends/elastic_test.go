@@ -0,0 +1,194 @@
+package backends
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const elasticTestResponse = `{"took": 3, "hits": {"total": {"value": 2, "relation": "eq"}, "hits": [
+	{"_index": "wiki", "_id": "42", "_score": 7.5,
+	 "_source": {"doc": {"title": "Deploying  the API"}, "link": "https://wiki.example/deploy", "body": "A long page about deploys.", "updated": "2024-05-01"},
+	 "highlight": {"body": ["rolling deploys of the API", "the deploy script"]}},
+	{"_index": "wiki", "_id": "43", "_score": 2,
+	 "_source": {"body": ["Notes without a title or link"]}}
+]}}`
+
+func TestSourceField(t *testing.T) {
+	source := map[string]any{
+		"a":       map[string]any{"b": "nested"},
+		"a.c":     "dotted",
+		"tags":    []any{"", "first"},
+		"version": float64(3),
+	}
+	for path, want := range map[string]string{"a.b": "nested", "a.c": "dotted", "tags": "first", "version": "3", "a": "", "x.y": ""} {
+		if got := sourceField(source, path); got != want {
+			t.Errorf("%s: got %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestElasticBackend_Search(t *testing.T) {
+	var body map[string]any
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/wiki/_search" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(elasticTestResponse))
+	}))
+	defer server.Close()
+
+	e := NewElasticBackend(server.URL+"/", "wiki", 10*time.Second)
+	e.APIKey = "k3y"
+	e.TitleField = "doc.title"
+	e.URLField = "link"
+	e.ContentField = "body"
+	e.DateField = "updated"
+	results, err := e.Search(SearchOptions{Query: "deploy", Exact: []string{"rolling deploy"}, NumResults: 5, PageNo: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth != "ApiKey k3y" {
+		t.Errorf("unexpected Authorization %q", auth)
+	}
+	wantQuery := map[string]any{"simple_query_string": map[string]any{"query": `deploy "rolling deploy"`, "default_operator": "and"}}
+	if !reflect.DeepEqual(body["query"], wantQuery) || body["from"] != float64(5) || body["size"] != float64(5) {
+		t.Errorf("unexpected body %v", body)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	first := results[0]
+	if first.Title != "Deploying the API" || first.URL != "https://wiki.example/deploy" ||
+		first.Content != "rolling deploys of the API … the deploy script" || first.Metadata != "wiki" ||
+		first.PublishedDate != "2024-05-01" || first.Score != 7.5 || first.Engine != "elastic" {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	// Documents without a title or link are named and linked by their ID
+	second := results[1]
+	if second.Title != "43" || second.URL != server.URL+"/wiki/_doc/43" || second.Content != "Notes without a title or link" {
+		t.Errorf("unexpected second result %+v", second)
+	}
+}
+
+func TestElasticBackend_Body(t *testing.T) {
+	e := NewElasticBackend("http://localhost:9200", "docs", 0)
+	e.Query = `{"multi_match": {"query": "{{query}}", "fields": ["title^2", "text"]}}`
+	e.DateField = "date"
+	payload, err := e.body(SearchOptions{Query: `say "hi"`, AnyOf: []string{"go", "rust lang"}, NoneOf: []string{"java"}, TimeRange: "week"})
+	if err != nil {
+		t.Fatalf("body failed: %v", err)
+	}
+	var body struct {
+		Query struct {
+			Bool struct {
+				Must               []map[string]any `json:"must"`
+				Should             []map[string]any `json:"should"`
+				MustNot            []map[string]any `json:"must_not"`
+				Filter             []map[string]any `json:"filter"`
+				MinimumShouldMatch int              `json:"minimum_should_match"`
+			} `json:"bool"`
+		} `json:"query"`
+		Size int `json:"size"`
+	}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		t.Fatalf("invalid body %s: %v", payload, err)
+	}
+	b := body.Query.Bool
+	match, _ := b.Must[0]["multi_match"].(map[string]any)
+	if len(b.Must) != 1 || match["query"] != `say "hi"` {
+		t.Errorf("template not filled in: %s", payload)
+	}
+	if len(b.Should) != 2 || b.MinimumShouldMatch != 1 || len(b.MustNot) != 1 || body.Size != 10 {
+		t.Errorf("unexpected clauses: %s", payload)
+	}
+	if !strings.Contains(string(payload), `"range":{"date":{"gte":"now-1w"}}`) ||
+		!strings.Contains(string(payload), `"query":"\"rust lang\""`) {
+		t.Errorf("unexpected filters: %s", payload)
+	}
+
+	e.Query = `{"match": {"text": {{query}}}`
+	if _, err := e.body(SearchOptions{Query: "x"}); err == nil || !strings.Contains(err.Error(), "invalid query template") {
+		t.Errorf("expected a template error, got %v", err)
+	}
+}
+
+func TestElasticBackend_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		code   int
+		msg    string
+	}{
+		{"auth", 401, `{"error": {"type": "security_exception", "reason": "missing authentication credentials"}, "status": 401}`, ErrCodeAuth, "security_exception: missing authentication credentials"},
+		{"missing index", 404, `{"error": {"type": "index_not_found_exception", "reason": "no such index [wiki]"}, "status": 404}`, 404, "no such index"},
+		{"rate limit", 429, `{"error": "Too many requests"}`, ErrCodeRateLimit, "Too many requests"},
+		{"bad json", 200, `not json`, ErrCodeInvalidResponse, "failed to parse JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			e := NewElasticBackend(server.URL, "wiki", 0)
+			_, err := e.Search(SearchOptions{Query: "x"})
+			var backendErr *BackendError
+			if !errors.As(err, &backendErr) || backendErr.Code != tt.code || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("got %v, want code %d with %q", err, tt.code, tt.msg)
+			}
+		})
+	}
+
+	if _, err := NewElasticBackend("", "", 0).Search(SearchOptions{Query: "x"}); err == nil {
+		t.Error("expected an error without a cluster")
+	}
+}
+
+func TestElasticBackend_SetClientCert(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(elasticTestResponse))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+	// Trust the test server, as ca_cert would
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	RootCAs = roots
+	defer func() { RootCAs = nil }()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	writeTestKeyPair(t, certFile, keyFile)
+
+	e := NewElasticBackend(server.URL, "wiki", 5*time.Second)
+	if _, err := e.Search(SearchOptions{Query: "deploy"}); err == nil {
+		t.Fatal("expected the handshake to fail without a client certificate")
+	}
+	if err := e.SetClientCert(certFile, keyFile); err != nil {
+		t.Fatalf("SetClientCert failed: %v", err)
+	}
+	if results, err := e.Search(SearchOptions{Query: "deploy"}); err != nil || len(results) != 2 {
+		t.Errorf("Search with client certificate: %d results, %v", len(results), err)
+	}
+	if err := e.SetClientCert("", keyFile); err == nil {
+		t.Error("expected an error for a key without certificate")
+	}
+}
@@ -28,7 +28,13 @@ type ExaBackend struct {
 	MCPURL     string
 	MCPTool    string
 	NumResults int
-	client     *http.Client
+
+	// API mode only
+	Type          string // auto, neural, keyword or fast; Exa's default if empty
+	UseAutoprompt bool   // let Exa rewrite the query for neural search
+	Highlights    int    // highlights to request per result, 0 for none
+
+	client *http.Client
 }
 
 func NewExaBackend(mode, apiKey string, timeout time.Duration, mcpURL, mcpTool string, numResults int) *ExaBackend {
@@ -52,7 +58,7 @@ func NewExaBackend(mode, apiKey string, timeout time.Duration, mcpURL, mcpTool s
 		MCPURL:     mcpURL,
 		MCPTool:    mcpTool,
 		NumResults: numResults,
-		client:     &http.Client{Timeout: timeout},
+		client:     newHTTPClient(timeout, nil),
 	}
 }
 
@@ -73,11 +79,14 @@ func (e *ExaBackend) IsAvailable() bool {
 	}
 }
 
+// MaxResults caps Exa batches at 25 results, the most its base price
+// covers; Exa has no page parameter
+func (e *ExaBackend) MaxResults() int {
+	return 25
+}
+
 func (e *ExaBackend) Search(opts SearchOptions) ([]SearchResult, error) {
-	query := opts.Query
-	if opts.Site != "" {
-		query = fmt.Sprintf("site:%s %s", opts.Site, query)
-	}
+	query := keywordQuery(opts)
 
 	count := opts.NumResults
 	if count <= 0 {
@@ -89,14 +98,14 @@ func (e *ExaBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 
 	switch e.Mode {
 	case ExaModeAPI:
-		return e.searchAPI(query, count)
+		return e.searchAPI(opts, count)
 	case ExaModeMCP:
 		return e.searchMCP(query, count)
 	case ExaModeAuto:
 		fallthrough
 	default:
 		if strings.TrimSpace(e.APIKey) != "" {
-			results, err := e.searchAPI(query, count)
+			results, err := e.searchAPI(opts, count)
 			if err == nil {
 				return results, nil
 			}
@@ -109,25 +118,76 @@ func (e *ExaBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 }
 
 type exaAPIRequest struct {
-	Query      string `json:"query"`
-	NumResults int    `json:"numResults,omitempty"`
+	Query              string       `json:"query"`
+	NumResults         int          `json:"numResults,omitempty"`
+	Type               string       `json:"type,omitempty"`
+	UseAutoprompt      bool         `json:"useAutoprompt,omitempty"`
+	IncludeDomains     []string     `json:"includeDomains,omitempty"`
+	ExcludeDomains     []string     `json:"excludeDomains,omitempty"`
+	StartPublishedDate string       `json:"startPublishedDate,omitempty"`
+	Contents           *exaContents `json:"contents,omitempty"`
+}
+
+type exaContents struct {
+	Highlights exaHighlights `json:"highlights"`
+}
+
+type exaHighlights struct {
+	NumSentences     int `json:"numSentences"`
+	HighlightsPerURL int `json:"highlightsPerUrl"`
 }
 
 type exaAPIResponse struct {
 	Results []struct {
-		Title   string `json:"title"`
-		URL     string `json:"url"`
-		Text    string `json:"text"`
-		Summary string `json:"summary"`
+		Title         string   `json:"title"`
+		URL           string   `json:"url"`
+		Text          string   `json:"text"`
+		Summary       string   `json:"summary"`
+		Highlights    []string `json:"highlights"`
+		PublishedDate string   `json:"publishedDate"`
+		Author        string   `json:"author"`
+		Score         float64  `json:"score"`
 	} `json:"results"`
 }
 
-func (e *ExaBackend) searchAPI(query string, count int) ([]SearchResult, error) {
+// exaSentences is how many sentences each requested highlight spans
+const exaSentences = 3
+
+// exaPeriods maps time ranges to how far back startPublishedDate goes
+var exaPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// apiRequest builds the API request for opts. Sites go to
+// includeDomains/excludeDomains, since site: operators mean nothing to
+// neural search.
+func (e *ExaBackend) apiRequest(opts SearchOptions, count int, now time.Time) exaAPIRequest {
+	req := exaAPIRequest{
+		Query:          plainQuery(opts),
+		NumResults:     count,
+		Type:           e.Type,
+		UseAutoprompt:  e.UseAutoprompt,
+		IncludeDomains: opts.Sites,
+		ExcludeDomains: opts.ExcludeSites,
+	}
+	if period, ok := exaPeriods[opts.TimeRange]; ok {
+		req.StartPublishedDate = now.Add(-period).UTC().Format("2006-01-02T15:04:05.000Z")
+	}
+	if e.Highlights > 0 {
+		req.Contents = &exaContents{Highlights: exaHighlights{NumSentences: exaSentences, HighlightsPerURL: e.Highlights}}
+	}
+	return req
+}
+
+func (e *ExaBackend) searchAPI(opts SearchOptions, count int) ([]SearchResult, error) {
 	if strings.TrimSpace(e.APIKey) == "" {
 		return nil, &BackendError{Backend: e.Name(), Err: fmt.Errorf("Exa API key not configured"), Code: ErrCodeUnavailable}
 	}
 
-	payload, err := json.Marshal(exaAPIRequest{Query: query, NumResults: count})
+	payload, err := json.Marshal(e.apiRequest(opts, count, time.Now()))
 	if err != nil {
 		return nil, &BackendError{Backend: e.Name(), Err: err, Code: ErrCodeInvalidResponse}
 	}
@@ -167,16 +227,20 @@ func (e *ExaBackend) searchAPI(query string, count int) ([]SearchResult, error)
 
 	results := make([]SearchResult, 0, len(parsed.Results))
 	for _, r := range parsed.Results {
-		content := r.Text
+		// Highlights are the passages that matched the query best
+		content := strings.Join(r.Highlights, " … ")
 		if strings.TrimSpace(content) == "" {
-			content = r.Summary
+			content = firstNonEmpty(r.Text, r.Summary)
 		}
 		results = append(results, SearchResult{
-			Title:   r.Title,
-			URL:     r.URL,
-			Content: content,
-			Engine:  e.Name(),
-			Engines: []string{e.Name()},
+			Title:         r.Title,
+			URL:           r.URL,
+			Content:       content,
+			PublishedDate: r.PublishedDate,
+			Author:        r.Author,
+			Score:         r.Score,
+			Engine:        e.Name(),
+			Engines:       []string{e.Name()},
 		})
 	}
 
@@ -124,3 +124,73 @@ func TestExaBackend_AutoFallsBackToMCP(t *testing.T) {
 		t.Fatalf("unexpected fallback results: %#v", results)
 	}
 }
+
+func TestExaBackend_APIRequest(t *testing.T) {
+	b := NewExaBackend(ExaModeAPI, "test-key", 2*time.Second, "", "", 10)
+	b.Type = "neural"
+	b.UseAutoprompt = true
+	b.Highlights = 2
+
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	req := b.apiRequest(SearchOptions{
+		Query:        "crdt libraries",
+		Sites:        []string{"github.com"},
+		ExcludeSites: []string{"reddit.com"},
+		TimeRange:    "week",
+	}, 25, now)
+
+	if req.Query != "crdt libraries" {
+		t.Errorf("query = %q, sites belong in includeDomains", req.Query)
+	}
+	if req.Type != "neural" || !req.UseAutoprompt || req.NumResults != 25 {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if len(req.IncludeDomains) != 1 || req.IncludeDomains[0] != "github.com" || len(req.ExcludeDomains) != 1 || req.ExcludeDomains[0] != "reddit.com" {
+		t.Errorf("domains = %v, %v", req.IncludeDomains, req.ExcludeDomains)
+	}
+	if req.StartPublishedDate != "2024-03-02T12:00:00.000Z" {
+		t.Errorf("startPublishedDate = %q", req.StartPublishedDate)
+	}
+	if req.Contents == nil || req.Contents.Highlights.HighlightsPerURL != 2 {
+		t.Errorf("contents = %+v", req.Contents)
+	}
+
+	b.Highlights = 0
+	if req := b.apiRequest(SearchOptions{Query: "x"}, 10, now); req.Contents != nil || req.StartPublishedDate != "" {
+		t.Errorf("unasked-for options in %+v", req)
+	}
+}
+
+func TestExaBackend_APIHighlights(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]interface{}{
+				{"title": "A", "url": "https://exa.example/a", "text": "full text", "highlights": []string{"first passage", "second passage"},
+					"publishedDate": "2024-03-01T00:00:00.000Z", "author": "Ada", "score": 0.42},
+				{"title": "B", "url": "https://exa.example/b", "summary": "a summary"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	b := NewExaBackend(ExaModeAPI, "test-key", 2*time.Second, "", "", 10)
+	b.BaseURL = server.URL
+	b.Highlights = 2
+
+	results, err := b.Search(SearchOptions{Query: "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Content != "first passage … second passage" {
+		t.Errorf("content = %q, want the highlights", results[0].Content)
+	}
+	if results[0].PublishedDate != "2024-03-01T00:00:00.000Z" || results[0].Author != "Ada" || results[0].Score != 0.42 {
+		t.Errorf("metadata not mapped: %+v", results[0])
+	}
+	if results[1].Content != "a summary" {
+		t.Errorf("content = %q, want the summary", results[1].Content)
+	}
+}
@@ -0,0 +1,368 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitHub search types: what the GitHub backend searches
+const (
+	GitHubRepositories = "repositories"
+	GitHubCode         = "code"
+	GitHubIssues       = "issues"
+)
+
+// GitHubBackend implements SearchBackend for the GitHub REST API's search
+// of repositories, code or issues and pull requests. A token raises the
+// rate limit; code search needs one.
+type GitHubBackend struct {
+	Token   string
+	Type    string // repositories (default), code or issues
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewGitHubBackend creates a new GitHub backend
+func NewGitHubBackend(token, searchType string, timeout time.Duration) *GitHubBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	if searchType == "" {
+		searchType = GitHubRepositories
+	}
+	return &GitHubBackend{
+		Token:   token,
+		Type:    searchType,
+		Timeout: timeout,
+		BaseURL: "https://api.github.com",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (g *GitHubBackend) Name() string {
+	return "github"
+}
+
+// IsAvailable reports true except for code search without a token, which
+// GitHub doesn't allow
+func (g *GitHubBackend) IsAvailable() bool {
+	return g.Type != GitHubCode || g.Token != ""
+}
+
+// githubOwner is the account a repository, issue or file belongs to
+type githubOwner struct {
+	Login string `json:"login"`
+}
+
+// githubRepository is a repository in GitHub search results
+type githubRepository struct {
+	FullName    string      `json:"full_name"`
+	HTMLURL     string      `json:"html_url"`
+	Description string      `json:"description"`
+	Owner       githubOwner `json:"owner"`
+	Stars       int         `json:"stargazers_count"`
+	Forks       int         `json:"forks_count"`
+	Language    string      `json:"language"`
+	Archived    bool        `json:"archived"`
+	PushedAt    string      `json:"pushed_at"`
+	License     *struct {
+		SPDXID string `json:"spdx_id"`
+	} `json:"license"`
+}
+
+// githubResponse is a GitHub search response; the fields of its items
+// depend on the search type
+type githubResponse struct {
+	Items []struct {
+		githubRepository
+
+		// Code
+		Name        string           `json:"name"`
+		Path        string           `json:"path"`
+		Repository  githubRepository `json:"repository"`
+		TextMatches []struct {
+			Property string `json:"property"`
+			Fragment string `json:"fragment"`
+		} `json:"text_matches"`
+
+		// Issues and pull requests
+		Title         string      `json:"title"`
+		Body          string      `json:"body"`
+		State         string      `json:"state"`
+		Comments      int         `json:"comments"`
+		User          githubOwner `json:"user"`
+		UpdatedAt     string      `json:"updated_at"`
+		RepositoryURL string      `json:"repository_url"`
+		PullRequest   *struct{}   `json:"pull_request"`
+		Labels        []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+
+		Score float64 `json:"score"`
+	} `json:"items"`
+	Message string `json:"message"`
+}
+
+// githubPeriods maps time ranges to how recently a repository must have
+// been pushed to, or an issue updated
+var githubPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// githubQuery renders opts in GitHub's search syntax: AnyOf terms joined
+// by OR, NoneOf terms after NOT, and a time range as a pushed: or updated:
+// qualifier. Query words may use GitHub's qualifiers, such as language:go.
+func githubQuery(opts SearchOptions, searchType string, now time.Time) string {
+	parts := []string{strings.TrimSpace(opts.Query)}
+	for _, phrase := range opts.Exact {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			parts = append(parts, quotePhrase(phrase))
+		}
+	}
+	for _, term := range opts.AllOf {
+		if term = strings.TrimSpace(term); term != "" {
+			parts = append(parts, quoteIfSpaced(term))
+		}
+	}
+	var anyOf []string
+	for _, term := range opts.AnyOf {
+		if term = strings.TrimSpace(term); term != "" {
+			anyOf = append(anyOf, quoteIfSpaced(term))
+		}
+	}
+	if len(anyOf) > 0 {
+		parts = append(parts, strings.Join(anyOf, " OR "))
+	}
+	for _, term := range opts.NoneOf {
+		if term = strings.TrimSpace(term); term != "" {
+			parts = append(parts, "NOT "+quoteIfSpaced(term))
+		}
+	}
+	if period, ok := githubPeriods[opts.TimeRange]; ok {
+		since := now.Add(-period).UTC().Format("2006-01-02")
+		switch searchType {
+		case GitHubRepositories:
+			parts = append(parts, "pushed:>="+since)
+		case GitHubIssues:
+			parts = append(parts, "updated:>="+since)
+		}
+	}
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+// onGitHub reports whether the site filters of opts leave github.com in
+func onGitHub(opts SearchOptions) bool {
+	sites := nonEmpty(opts.Sites)
+	return (len(sites) == 0 || onAnySite("github.com", sites)) && !onAnySite("github.com", opts.ExcludeSites)
+}
+
+// requestURL builds the search URL for opts
+func (g *GitHubBackend) requestURL(opts SearchOptions, now time.Time) string {
+	num := opts.NumResults
+	if num <= 0 {
+		num = 10
+	}
+	params := url.Values{}
+	params.Set("q", githubQuery(opts, g.Type, now))
+	params.Set("per_page", strconv.Itoa(min(num, 100)))
+	if opts.PageNo > 1 {
+		params.Set("page", strconv.Itoa(opts.PageNo))
+	}
+	return g.BaseURL + "/search/" + g.Type + "?" + params.Encode()
+}
+
+// Search performs a search against the GitHub API
+func (g *GitHubBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	switch g.Type {
+	case GitHubRepositories, GitHubIssues:
+	case GitHubCode:
+		if g.Token == "" {
+			return nil, &BackendError{
+				Backend: g.Name(),
+				Err:     fmt.Errorf("GitHub code search needs a token"),
+				Code:    ErrCodeUnavailable,
+			}
+		}
+	default:
+		return nil, &BackendError{
+			Backend: g.Name(),
+			Err:     fmt.Errorf("unknown search type %q (repositories, code or issues)", g.Type),
+			Code:    ErrCodeUnavailable,
+		}
+	}
+	if !onGitHub(opts) {
+		return []SearchResult{}, nil
+	}
+
+	req, err := http.NewRequest("GET", g.requestURL(opts, time.Now()), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: g.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	// The text-match media type adds the matching fragments of code
+	req.Header.Set("Accept", "application/vnd.github.text-match+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", "sx")
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: g.Name(),
+			Err:     fmt.Errorf("request failed: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: g.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var githubResp githubResponse
+	parseErr := json.Unmarshal(body, &githubResp)
+
+	if resp.StatusCode != http.StatusOK {
+		message := strings.TrimSpace(githubResp.Message)
+		if parseErr != nil || message == "" {
+			message = strings.TrimSpace(string(body))
+		}
+		switch {
+		case resp.StatusCode == 429 || resp.StatusCode == 403 && resp.Header.Get("X-RateLimit-Remaining") == "0":
+			return nil, &BackendError{
+				Backend: g.Name(),
+				Err:     fmt.Errorf("rate limited: %s", message),
+				Code:    ErrCodeRateLimit,
+			}
+		case resp.StatusCode == 401 || resp.StatusCode == 403:
+			return nil, &BackendError{
+				Backend: g.Name(),
+				Err:     fmt.Errorf("authentication failed: %s", message),
+				Code:    ErrCodeAuth,
+			}
+		default:
+			return nil, &BackendError{
+				Backend: g.Name(),
+				Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, message),
+				Code:    resp.StatusCode,
+			}
+		}
+	}
+	if parseErr != nil {
+		return nil, &BackendError{
+			Backend: g.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", parseErr),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	results := make([]SearchResult, 0, len(githubResp.Items))
+	for _, item := range githubResp.Items {
+		var result SearchResult
+		switch g.Type {
+		case GitHubRepositories:
+			repo := item.githubRepository
+			result = SearchResult{
+				Title:         repo.FullName,
+				URL:           repo.HTMLURL,
+				Content:       collapseSpace(repo.Description),
+				PublishedDate: repo.PushedAt,
+				Author:        repo.Owner.Login,
+				Metadata:      repoFacts(repo),
+			}
+		case GitHubCode:
+			var fragments []string
+			for _, match := range item.TextMatches {
+				if match.Property == "content" && strings.TrimSpace(match.Fragment) != "" {
+					fragments = append(fragments, strings.TrimSpace(match.Fragment))
+				}
+			}
+			// Fenced, so IT results show the fragments as code
+			var content string
+			if len(fragments) > 0 {
+				lang := strings.TrimPrefix(path.Ext(item.Path), ".")
+				content = "```" + lang + "\n" + strings.Join(fragments, "\n…\n") + "\n```"
+			}
+			result = SearchResult{
+				Title:    item.Repository.FullName + ": " + item.Path,
+				URL:      item.HTMLURL,
+				Content:  content,
+				Author:   item.Repository.Owner.Login,
+				Metadata: collapseSpace(item.Repository.Description),
+			}
+		case GitHubIssues:
+			kind := "issue"
+			if item.PullRequest != nil {
+				kind = "pull request"
+			}
+			// repository_url is the API URL, .../repos/owner/name
+			_, repo, _ := strings.Cut(item.RepositoryURL, "/repos/")
+			facts := []string{repo, kind, item.State}
+			if item.Comments > 0 {
+				facts = append(facts, fmt.Sprintf("%d comments", item.Comments))
+			}
+			for _, label := range item.Labels {
+				facts = append(facts, label.Name)
+			}
+			result = SearchResult{
+				Title:         item.Title,
+				URL:           item.HTMLURL,
+				Content:       collapseSpace(item.Body),
+				PublishedDate: item.UpdatedAt,
+				Author:        item.User.Login,
+				Metadata:      strings.Join(facts, " · "),
+			}
+		}
+		if result.URL == "" {
+			continue
+		}
+		result.Category = "it"
+		result.Score = item.Score
+		result.Engine = g.Name()
+		result.Engines = []string{g.Name()}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// repoFacts summarizes a repository: its language, stars, forks and
+// license, e.g. "Go · 1234 stars · 56 forks · MIT"
+func repoFacts(repo githubRepository) string {
+	var facts []string
+	if repo.Language != "" {
+		facts = append(facts, repo.Language)
+	}
+	facts = append(facts, fmt.Sprintf("%d stars", repo.Stars))
+	if repo.Forks > 0 {
+		facts = append(facts, fmt.Sprintf("%d forks", repo.Forks))
+	}
+	if repo.License != nil && repo.License.SPDXID != "" && repo.License.SPDXID != "NOASSERTION" {
+		facts = append(facts, repo.License.SPDXID)
+	}
+	if repo.Archived {
+		facts = append(facts, "archived")
+	}
+	return strings.Join(facts, " · ")
+}
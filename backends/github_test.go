@@ -0,0 +1,203 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const githubReposResponse = `{"total_count": 2, "incomplete_results": false, "items": [
+	{"full_name": "julienschmidt/httprouter", "html_url": "https://github.com/julienschmidt/httprouter",
+	 "description": "A high performance HTTP request router\n that scales well", "owner": {"login": "julienschmidt"},
+	 "stargazers_count": 16500, "forks_count": 1500, "language": "Go", "pushed_at": "2024-01-05T10:00:00Z",
+	 "license": {"spdx_id": "BSD-3-Clause"}, "score": 1.0},
+	{"full_name": "someone/old-router", "html_url": "https://github.com/someone/old-router", "owner": {"login": "someone"},
+	 "stargazers_count": 3, "archived": true, "license": {"spdx_id": "NOASSERTION"}},
+	{"full_name": "no/url"}
+]}`
+
+const githubCodeResponse = `{"total_count": 1, "items": [
+	{"name": "router.go", "path": "pkg/router.go", "html_url": "https://github.com/go-chi/chi/blob/abc/pkg/router.go", "score": 1,
+	 "repository": {"full_name": "go-chi/chi", "description": "lightweight router", "owner": {"login": "go-chi"}},
+	 "text_matches": [{"property": "content", "fragment": "func NewRouter() *Mux {\n"}, {"property": "path", "fragment": "pkg/router.go"},
+	                  {"property": "content", "fragment": "r.Get(\"/\", handler)"}]}
+]}`
+
+const githubIssuesResponse = `{"total_count": 2, "items": [
+	{"title": "Router panics on empty path", "html_url": "https://github.com/go-chi/chi/issues/42", "body": "Steps:\n\n1. run",
+	 "state": "open", "comments": 3, "user": {"login": "reporter"}, "updated_at": "2024-02-01T00:00:00Z",
+	 "repository_url": "https://api.github.com/repos/go-chi/chi", "labels": [{"name": "bug"}]},
+	{"title": "Add route groups", "html_url": "https://github.com/go-chi/chi/pull/43", "state": "closed",
+	 "user": {"login": "dev"}, "repository_url": "https://api.github.com/repos/go-chi/chi", "pull_request": {"url": "x"}}
+]}`
+
+func TestGitHubQuery(t *testing.T) {
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		opts       SearchOptions
+		searchType string
+		want       string
+	}{
+		{"plain", SearchOptions{Query: "http router"}, GitHubRepositories, "http router"},
+		{"builder", SearchOptions{Query: "router", Exact: []string{"path params"}, AllOf: []string{"go"}, AnyOf: []string{"fast", "tiny lib"}, NoneOf: []string{"deprecated"}},
+			GitHubRepositories, `router "path params" go fast OR "tiny lib" NOT deprecated`},
+		{"repositories pushed", SearchOptions{Query: "router", TimeRange: "week"}, GitHubRepositories, "router pushed:>=2024-03-02"},
+		{"issues updated", SearchOptions{Query: "router", TimeRange: "day"}, GitHubIssues, "router updated:>=2024-03-08"},
+		{"code has no date", SearchOptions{Query: "router", TimeRange: "year"}, GitHubCode, "router"},
+	}
+	for _, tt := range tests {
+		if got := githubQuery(tt.opts, tt.searchType, now); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGitHubBackend_IsAvailable(t *testing.T) {
+	if !NewGitHubBackend("", "", 0).IsAvailable() || !NewGitHubBackend("", GitHubIssues, 0).IsAvailable() {
+		t.Error("repository and issue search need no token")
+	}
+	if NewGitHubBackend("", GitHubCode, 0).IsAvailable() || !NewGitHubBackend("tok", GitHubCode, 0).IsAvailable() {
+		t.Error("code search needs a token")
+	}
+}
+
+func TestGitHubBackend_Search(t *testing.T) {
+	var path string
+	var query url.Values
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query, headers = r.URL.Path, r.URL.Query(), r.Header
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/search/code":
+			w.Write([]byte(githubCodeResponse))
+		case "/search/issues":
+			w.Write([]byte(githubIssuesResponse))
+		default:
+			w.Write([]byte(githubReposResponse))
+		}
+	}))
+	defer server.Close()
+
+	g := NewGitHubBackend("", "", 10*time.Second)
+	g.BaseURL = server.URL
+	results, err := g.Search(SearchOptions{Query: "http router", NumResults: 5, PageNo: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/search/repositories" || query.Get("q") != "http router" || query.Get("per_page") != "5" || query.Get("page") != "2" {
+		t.Errorf("unexpected request %s %v", path, query)
+	}
+	if headers.Get("Authorization") != "" || !strings.Contains(headers.Get("Accept"), "text-match") {
+		t.Errorf("unexpected headers %v", headers)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	first := results[0]
+	if first.Title != "julienschmidt/httprouter" || first.URL != "https://github.com/julienschmidt/httprouter" ||
+		first.Content != "A high performance HTTP request router that scales well" || first.Author != "julienschmidt" ||
+		first.PublishedDate != "2024-01-05T10:00:00Z" || first.Category != "it" || first.Engine != "github" || first.Score != 1 {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	if first.Metadata != "Go · 16500 stars · 1500 forks · BSD-3-Clause" {
+		t.Errorf("metadata: %q", first.Metadata)
+	}
+	if results[1].Metadata != "3 stars · archived" {
+		t.Errorf("metadata of the second result: %q", results[1].Metadata)
+	}
+
+	g = NewGitHubBackend("secret", GitHubCode, 10*time.Second)
+	g.BaseURL = server.URL
+	results, err = g.Search(SearchOptions{Query: "NewRouter"})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("code: %v, %d results", err, len(results))
+	}
+	if headers.Get("Authorization") != "Bearer secret" {
+		t.Errorf("authorization %q", headers.Get("Authorization"))
+	}
+	if code := results[0]; code.Title != "go-chi/chi: pkg/router.go" || code.URL != "https://github.com/go-chi/chi/blob/abc/pkg/router.go" ||
+		code.Content != "```go\nfunc NewRouter() *Mux {\n…\nr.Get(\"/\", handler)\n```" || code.Metadata != "lightweight router" {
+		t.Errorf("unexpected code result %+v", code)
+	}
+
+	g = NewGitHubBackend("", GitHubIssues, 10*time.Second)
+	g.BaseURL = server.URL
+	results, err = g.Search(SearchOptions{Query: "router"})
+	if err != nil || len(results) != 2 {
+		t.Fatalf("issues: %v, %d results", err, len(results))
+	}
+	if issue := results[0]; issue.Title != "Router panics on empty path" || issue.Content != "Steps: 1. run" || issue.Author != "reporter" ||
+		issue.PublishedDate != "2024-02-01T00:00:00Z" || issue.Metadata != "go-chi/chi · issue · open · 3 comments · bug" {
+		t.Errorf("unexpected issue %+v", issue)
+	}
+	if pull := results[1]; pull.Metadata != "go-chi/chi · pull request · closed" {
+		t.Errorf("pull request metadata: %q", pull.Metadata)
+	}
+}
+
+func TestGitHubBackend_Sites(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(githubReposResponse))
+	}))
+	defer server.Close()
+
+	g := NewGitHubBackend("", "", 10*time.Second)
+	g.BaseURL = server.URL
+	for _, opts := range []SearchOptions{
+		{Query: "router", Sites: []string{"gitlab.com"}},
+		{Query: "router", ExcludeSites: []string{"github.com"}},
+	} {
+		if results, err := g.Search(opts); err != nil || len(results) != 0 {
+			t.Errorf("%+v: %d results, %v", opts, len(results), err)
+		}
+	}
+	if results, err := g.Search(SearchOptions{Query: "router", Sites: []string{"github.com"}}); err != nil || len(results) != 2 {
+		t.Errorf("github.com: %d results, %v", len(results), err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestGitHubBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status    int
+		remaining string
+		body      string
+		code      int
+	}{
+		{http.StatusUnauthorized, "", `{"message": "Bad credentials"}`, ErrCodeAuth},
+		{http.StatusForbidden, "0", `{"message": "API rate limit exceeded"}`, ErrCodeRateLimit},
+		{http.StatusTooManyRequests, "", `{"message": "secondary rate limit"}`, ErrCodeRateLimit},
+		{http.StatusUnprocessableEntity, "", `{"message": "Validation Failed"}`, http.StatusUnprocessableEntity},
+		{http.StatusOK, "", `not json`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tt.remaining != "" {
+				w.Header().Set("X-RateLimit-Remaining", tt.remaining)
+			}
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		g := NewGitHubBackend("", "", 10*time.Second)
+		g.BaseURL = server.URL
+		_, err := g.Search(SearchOptions{Query: "test"})
+		server.Close()
+		if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		}
+	}
+
+	g := NewGitHubBackend("", GitHubCode, 10*time.Second)
+	if _, err := g.Search(SearchOptions{Query: "test"}); err == nil || err.(*BackendError).Code != ErrCodeUnavailable {
+		t.Errorf("code search without a token: %v", err)
+	}
+}
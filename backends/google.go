@@ -0,0 +1,267 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// googleMaxResults is how deep the Custom Search JSON API pages: start
+// plus num may not exceed 100.
+const googleMaxResults = 100
+
+// GoogleCSEBackend implements SearchBackend for the Google Programmable
+// Search Engine (Custom Search JSON API)
+type GoogleCSEBackend struct {
+	APIKey  string
+	CX      string // search engine ID
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewGoogleCSEBackend creates a new Google Programmable Search backend
+func NewGoogleCSEBackend(apiKey, cx string, timeout time.Duration) *GoogleCSEBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &GoogleCSEBackend{
+		APIKey:  apiKey,
+		CX:      cx,
+		Timeout: timeout,
+		BaseURL: "https://www.googleapis.com/customsearch/v1",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (g *GoogleCSEBackend) Name() string {
+	return "google"
+}
+
+// IsAvailable checks if the API key and search engine ID are configured
+func (g *GoogleCSEBackend) IsAvailable() bool {
+	return g.APIKey != "" && g.CX != ""
+}
+
+// googleResponse is the Custom Search JSON API response
+type googleResponse struct {
+	Items    []googleItem `json:"items"`
+	Spelling struct {
+		CorrectedQuery string `json:"correctedQuery"`
+	} `json:"spelling"`
+}
+
+type googleItem struct {
+	Title   string `json:"title"`
+	Link    string `json:"link"`
+	Snippet string `json:"snippet"`
+	PageMap struct {
+		Metatags     []map[string]string `json:"metatags"`
+		CSEThumbnail []struct {
+			Src string `json:"src"`
+		} `json:"cse_thumbnail"`
+	} `json:"pagemap"`
+}
+
+// googleError is the error body of the Google APIs
+type googleError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"` // e.g. RESOURCE_EXHAUSTED, PERMISSION_DENIED
+	} `json:"error"`
+}
+
+// googleDateRestrict maps time ranges to the dateRestrict parameter
+var googleDateRestrict = map[string]string{
+	"day":   "d1",
+	"week":  "w1",
+	"month": "m1",
+	"year":  "y1",
+}
+
+// googleLocale splits a language such as "de-AT" into the lr restriction
+// ("lang_de") and, unless country is given, the gl country ("at"). "all"
+// and "auto" leave the language to Google.
+func googleLocale(language, country string) (string, string) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "all" || language == "auto" {
+		language = ""
+	}
+	lang, region, _ := strings.Cut(language, "-")
+	if country == "" && len(region) == 2 {
+		country = region
+	}
+	if lang != "" {
+		lang = "lang_" + lang
+	}
+	return lang, strings.ToLower(strings.TrimSpace(country))
+}
+
+// published returns the publication date a page declares in its meta tags.
+func (item googleItem) published() string {
+	for _, tags := range item.PageMap.Metatags {
+		for _, key := range []string{"article:published_time", "og:updated_time", "date"} {
+			if date := strings.TrimSpace(tags[key]); date != "" {
+				return date
+			}
+		}
+	}
+	return ""
+}
+
+// Search performs a search against the Custom Search JSON API
+func (g *GoogleCSEBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	resp, err := g.SearchDetailed(opts)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// SearchDetailed is like Search but also returns Google's spelling
+// correction
+func (g *GoogleCSEBackend) SearchDetailed(opts SearchOptions) (*SearchResponse, error) {
+	if !g.IsAvailable() {
+		return nil, &BackendError{
+			Backend: g.Name(),
+			Err:     fmt.Errorf("Google API key or search engine ID (cx) not configured"),
+			Code:    ErrCodeUnavailable,
+		}
+	}
+
+	// At most 10 results per request
+	num := opts.NumResults
+	if num <= 0 || num > 10 {
+		num = 10
+	}
+	// Pages are 1-based start offsets; past the first 100 results there
+	// is nothing more to get
+	start := 1
+	if opts.PageNo > 1 {
+		start = (opts.PageNo-1)*num + 1
+	}
+	if start > googleMaxResults {
+		return &SearchResponse{}, nil
+	}
+	num = min(num, googleMaxResults-start+1)
+
+	params := url.Values{}
+	params.Set("key", g.APIKey)
+	params.Set("cx", g.CX)
+	// siteSearch takes a single site: sites become site: operators
+	params.Set("q", excludeSiteQuery(siteQuery(ComposeQuery(opts), opts.Sites), opts.ExcludeSites))
+	params.Set("num", fmt.Sprintf("%d", num))
+	params.Set("start", fmt.Sprintf("%d", start))
+	if opts.SafeSearch == "none" {
+		params.Set("safe", "off")
+	} else {
+		params.Set("safe", "active")
+	}
+	if restrict, ok := googleDateRestrict[opts.TimeRange]; ok {
+		params.Set("dateRestrict", restrict)
+	}
+	lang, country := googleLocale(opts.Language, opts.Country)
+	if lang != "" {
+		params.Set("lr", lang)
+	}
+	if country != "" {
+		params.Set("gl", country)
+	}
+
+	req, err := http.NewRequest("GET", g.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: g.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: g.Name(),
+			// The request URL carries the API key
+			Err:  fmt.Errorf("request failed: %s", redactKey(err.Error(), g.APIKey)),
+			Code: ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: g.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr googleError
+		message := strings.TrimSpace(string(body))
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			message = apiErr.Error.Message
+		}
+		switch {
+		// Exhausted daily quotas come as 429 or as 403 RESOURCE_EXHAUSTED
+		case resp.StatusCode == 429 || apiErr.Error.Status == "RESOURCE_EXHAUSTED":
+			return nil, &BackendError{
+				Backend: g.Name(),
+				Err:     fmt.Errorf("rate limited: %s", message),
+				Code:    ErrCodeRateLimit,
+			}
+		// An invalid key is a 400
+		case resp.StatusCode == 401 || resp.StatusCode == 403 || strings.Contains(message, "API key"):
+			return nil, &BackendError{
+				Backend: g.Name(),
+				Err:     fmt.Errorf("authentication failed: %s", message),
+				Code:    ErrCodeAuth,
+			}
+		default:
+			return nil, &BackendError{
+				Backend: g.Name(),
+				Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, message),
+				Code:    resp.StatusCode,
+			}
+		}
+	}
+
+	var googleResp googleResponse
+	if err := json.Unmarshal(body, &googleResp); err != nil {
+		return nil, &BackendError{
+			Backend: g.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	// Convert Google items to SearchResult
+	results := make([]SearchResult, len(googleResp.Items))
+	for i, item := range googleResp.Items {
+		results[i] = SearchResult{
+			Title:         item.Title,
+			URL:           item.Link,
+			Content:       strings.TrimSpace(item.Snippet),
+			Engine:        g.Name(),
+			Engines:       []string{g.Name()},
+			PublishedDate: item.published(),
+		}
+		if len(item.PageMap.CSEThumbnail) > 0 {
+			results[i].ThumbnailSrc = item.PageMap.CSEThumbnail[0].Src
+		}
+	}
+
+	var corrections []string
+	if corrected := googleResp.Spelling.CorrectedQuery; corrected != "" {
+		corrections = []string{corrected}
+	}
+	return &SearchResponse{Results: results, Corrections: corrections}, nil
+}
@@ -0,0 +1,145 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTestGoogleBackend(serverURL string) *GoogleCSEBackend {
+	return &GoogleCSEBackend{
+		APIKey:  "test-key",
+		CX:      "test-cx",
+		Timeout: 10 * time.Second,
+		BaseURL: serverURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func TestGoogleCSEBackend_IsAvailable(t *testing.T) {
+	tests := []struct {
+		apiKey, cx string
+		want       bool
+	}{
+		{"", "", false},
+		{"key", "", false},
+		{"", "cx", false},
+		{"key", "cx", true},
+	}
+	for _, tt := range tests {
+		g := NewGoogleCSEBackend(tt.apiKey, tt.cx, 10*time.Second)
+		if got := g.IsAvailable(); got != tt.want {
+			t.Errorf("IsAvailable(%q, %q) = %v, want %v", tt.apiKey, tt.cx, got, tt.want)
+		}
+	}
+	_, err := NewGoogleCSEBackend("key", "", 0).Search(SearchOptions{Query: "test"})
+	if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != ErrCodeUnavailable {
+		t.Errorf("expected ErrCodeUnavailable, got %v", err)
+	}
+}
+
+func TestGoogleCSEBackend_Search(t *testing.T) {
+	var got url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"spelling": {"correctedQuery": "golang generics"},
+			"items": [
+				{"title": "Generics", "link": "https://go.dev/doc/tutorial/generics", "snippet": "A tutorial.\n",
+				 "pagemap": {"metatags": [{"article:published_time": "2022-03-15T00:00:00Z"}],
+				             "cse_thumbnail": [{"src": "https://img.example/t.jpg"}]}},
+				{"title": "Wiki", "link": "https://en.wikipedia.org/wiki/Generic_programming", "snippet": "Generic programming"}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	g := newTestGoogleBackend(server.URL)
+	resp, err := g.SearchDetailed(SearchOptions{
+		Query: "golang genrics", Sites: []string{"go.dev"}, TimeRange: "week",
+		Language: "de-AT", SafeSearch: "none", PageNo: 3, NumResults: 5,
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	want := map[string]string{
+		"key": "test-key", "cx": "test-cx", "q": "site:go.dev golang genrics", "num": "5", "start": "11",
+		"safe": "off", "dateRestrict": "w1", "lr": "lang_de", "gl": "at",
+	}
+	for name, value := range want {
+		if got.Get(name) != value {
+			t.Errorf("%s = %q, want %q", name, got.Get(name), value)
+		}
+	}
+
+	if len(resp.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(resp.Results))
+	}
+	first := resp.Results[0]
+	if first.URL != "https://go.dev/doc/tutorial/generics" || first.Content != "A tutorial." || first.Engine != "google" {
+		t.Errorf("unexpected result %+v", first)
+	}
+	if first.PublishedDate != "2022-03-15T00:00:00Z" || first.ThumbnailSrc != "https://img.example/t.jpg" {
+		t.Errorf("pagemap not mapped: date %q, thumbnail %q", first.PublishedDate, first.ThumbnailSrc)
+	}
+	if len(resp.Corrections) != 1 || resp.Corrections[0] != "golang generics" {
+		t.Errorf("corrections = %v", resp.Corrections)
+	}
+}
+
+func TestGoogleCSEBackend_Paging(t *testing.T) {
+	requests := 0
+	var start, num string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		start, num = r.URL.Query().Get("start"), r.URL.Query().Get("num")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	g := newTestGoogleBackend(server.URL)
+	// The last page is cut to end at result 100
+	if _, err := g.Search(SearchOptions{Query: "q", PageNo: 15, NumResults: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if start != "99" || num != "2" {
+		t.Errorf("start %s, num %s, want 99, 2", start, num)
+	}
+	// Past result 100 there is nothing to ask for
+	results, err := g.Search(SearchOptions{Query: "q", PageNo: 11, NumResults: 10})
+	if err != nil || len(results) != 0 || requests != 1 {
+		t.Errorf("page 11: %d results, %d requests, err %v", len(results), requests, err)
+	}
+}
+
+func TestGoogleCSEBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{400, `{"error": {"code": 400, "message": "API key not valid. Please pass a valid API key.", "status": "INVALID_ARGUMENT"}}`, ErrCodeAuth},
+		{403, `{"error": {"code": 403, "message": "Quota exceeded for quota metric 'Queries'", "status": "RESOURCE_EXHAUSTED"}}`, ErrCodeRateLimit},
+		{429, `{"error": {"code": 429, "message": "Too many requests"}}`, ErrCodeRateLimit},
+		{500, `backend error`, 500},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		_, err := newTestGoogleBackend(server.URL).Search(SearchOptions{Query: "q"})
+		server.Close()
+		backendErr, ok := err.(*BackendError)
+		if !ok {
+			t.Fatalf("HTTP %d: expected BackendError, got %T", tt.status, err)
+		}
+		if backendErr.Code != tt.code {
+			t.Errorf("HTTP %d: code %d, want %d (%v)", tt.status, backendErr.Code, tt.code, err)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package backends
 
 import (
 	"fmt"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -20,30 +23,116 @@ type SearchResult struct {
 	Source        string                 `json:"source"`
 	Resolution    string                 `json:"resolution"`
 	ImgSrc        string                 `json:"img_src"`
+	ThumbnailSrc  string                 `json:"thumbnail_src"`
 	Address       map[string]interface{} `json:"address"`
 	Longitude     float64                `json:"longitude"`
 	Latitude      float64                `json:"latitude"`
 	Journal       string                 `json:"journal"`
 	Publisher     string                 `json:"publisher"`
+	DOI           string                 `json:"doi"`
+	PDFURL        string                 `json:"pdf_url"`
 	MagnetLink    string                 `json:"magnetlink"`
 	Seed          int                    `json:"seed"`
 	Leech         int                    `json:"leech"`
 	FileSize      string                 `json:"filesize"`
 	Size          string                 `json:"size"`
 	Metadata      string                 `json:"metadata"`
+	FeedURL       string                 `json:"feed_url,omitempty"`    // podcasts: the RSS feed of the episode's podcast
+	Album         string                 `json:"album,omitempty"`       // music: the album of a track, whose artist is Author
+	InstallURL    string                 `json:"install_url,omitempty"` // apps: a link that installs the app
+	Advisory      *Advisory              `json:"advisory,omitempty"`    // cve: the security advisory's ID, severity and packages
+	Score         float64                `json:"score"`
+
+	// Set by sx itself, not by backends
+	Link       *LinkCheck  `json:"link,omitempty"`        // --check-links result
+	AlsoOn     []string    `json:"also_on,omitempty"`     // domains of collapsed duplicates
+	DomainTags []string    `json:"domain_tags,omitempty"` // domain_tags of the result's site
+	Paywalled  bool        `json:"paywalled,omitempty"`   // --enrich paywall
+	ArchiveURL string      `json:"archive_url,omitempty"` // --archive-paywalled: archived copy of a paywalled page
+	OpenAccess *OpenAccess `json:"open_access,omitempty"` // --open-access result
+	Citations  *int        `json:"citations,omitempty"`   // --enrich citations
+	Favicon    string      `json:"favicon,omitempty"`     // --enrich favicon
+	WordCount  int         `json:"word_count,omitempty"`  // --enrich words
+	Language   string      `json:"language,omitempty"`    // --enrich language
+	SiteInfo   *SiteInfo   `json:"site_info,omitempty"`   // --enrich site
+	Cluster    int         `json:"cluster,omitempty"`     // --cluster: number of the result's cluster
+	Similar    int         `json:"similar,omitempty"`     // --cluster: members listed after a cluster's lead
+	Relevance  float64     `json:"relevance,omitempty"`   // --rerank score
+}
+
+// OpenAccess is where Unpaywall found a free copy of a paper
+type OpenAccess struct {
+	Status      string `json:"status"`                 // gold, green, hybrid, bronze or closed
+	URL         string `json:"url,omitempty"`          // the free copy's landing page
+	PDFURL      string `json:"pdf_url,omitempty"`      // the free copy's PDF
+	License     string `json:"license,omitempty"`      // e.g. cc-by
+	OriginalURL string `json:"original_url,omitempty"` // result URL replaced by the free copy
+}
+
+// SiteInfo is where a result's site is hosted
+type SiteInfo struct {
+	IP        string `json:"ip"`                   // the address the site's name resolves to
+	Country   string `json:"country,omitempty"`    // ISO 3166 code of the address's country
+	Network   string `json:"network,omitempty"`    // organization or provider the address belongs to
+	TLSIssuer string `json:"tls_issuer,omitempty"` // issuer of the site's TLS certificate
+}
+
+// Advisory is a security advisory, as the cve backend finds it
+type Advisory struct {
+	ID       string   `json:"id"`                 // e.g. CVE-2021-44228 or GHSA-jfh8-c2jp-5v3q
+	Aliases  []string `json:"aliases,omitempty"`  // the advisory's IDs in other databases
+	Severity string   `json:"severity,omitempty"` // critical, high, medium or low
+	CVSS     float64  `json:"cvss,omitempty"`     // CVSS base score, 0 to 10
+	Vector   string   `json:"vector,omitempty"`   // CVSS vector
+	CWEs     []string `json:"cwes,omitempty"`     // weaknesses, e.g. CWE-502
+	Affected []string `json:"affected,omitempty"` // packages, as ecosystem/name
+}
+
+// LinkCheck is the liveness of a result URL
+type LinkCheck struct {
+	Status string `json:"status"`           // alive, redirect or dead
+	Code   int    `json:"code,omitempty"`   // final HTTP status
+	Target string `json:"target,omitempty"` // where a redirect ends up
+	Error  string `json:"error,omitempty"`  // why an unreachable link failed
 }
 
 // SearchOptions contains parameters for a search query
 type SearchOptions struct {
-	Query      string
-	Categories []string
-	Engines    []string
-	Language   string
-	TimeRange  string
-	Site       string
-	SafeSearch string
-	PageNo     int
-	NumResults int
+	Query        string
+	Categories   []string
+	Engines      []string
+	Language     string
+	Country      string // two-letter region code, for backends that localize
+	TimeRange    string
+	Sites        []string
+	ExcludeSites []string
+	AllOf        []string   // terms that must all appear
+	AnyOf        []string   // terms of which at least one must appear
+	NoneOf       []string   // terms that must not appear
+	Exact        []string   // exact phrases
+	Synonyms     [][]string // groups of interchangeable terms, OR-ed into Query
+	SafeSearch   string
+	PageNo       int
+	NumResults   int
+}
+
+// SearchResponse is a page of results plus response-level metadata that
+// some backends report alongside them.
+type SearchResponse struct {
+	Results     []SearchResult
+	Corrections []string // "did you mean" spelling corrections
+	Suggestions []string // related query suggestions
+	Answers     []string // instant answers (conversions, calculations, ...)
+
+	// Set by Manager: the backends tried before, or besides, the one whose
+	// response this is, in the order they were tried
+	Fallbacks []FallbackAttempt
+}
+
+// FallbackAttempt records a backend that didn't provide the results.
+type FallbackAttempt struct {
+	Backend string `json:"backend"`
+	Reason  string `json:"reason"` // "timeout", "HTTP 503", "no results", ...
 }
 
 // BackendConfig contains engine-specific configuration
@@ -68,6 +157,39 @@ type SearchBackend interface {
 	IsAvailable() bool
 }
 
+// DetailedSearchBackend is implemented by backends that report
+// response-level metadata (corrections, suggestions, ...) in addition to
+// results. Backends that don't implement it are wrapped by searchDetailed.
+type DetailedSearchBackend interface {
+	SearchBackend
+
+	// SearchDetailed performs a search query and returns the full response
+	SearchDetailed(opts SearchOptions) (*SearchResponse, error)
+}
+
+// BatchBackend is implemented by backends that take no page number, only a
+// result count of up to MaxResults. The Manager emulates pages for them
+// from one batch of MaxResults results (see Manager.searchPage).
+type BatchBackend interface {
+	SearchBackend
+
+	// MaxResults is the most results a single request may ask for
+	MaxResults() int
+}
+
+// searchDetailed runs a search on any backend, returning the full response
+// for backends that provide one.
+func searchDetailed(backend SearchBackend, opts SearchOptions) (*SearchResponse, error) {
+	if d, ok := backend.(DetailedSearchBackend); ok {
+		return d.SearchDetailed(opts)
+	}
+	results, err := backend.Search(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResponse{Results: results}, nil
+}
+
 // BackendError represents an error from a specific backend
 type BackendError struct {
 	Backend string
@@ -84,6 +206,22 @@ func (e *BackendError) Unwrap() error {
 	return e.Err
 }
 
+// redactKey hides an API key that a request URL carries from an error
+// message about the request, as given and in its query and path escaped
+// forms.
+func redactKey(msg, key string) string {
+	if key == "" {
+		return msg
+	}
+	forms := []string{key, url.QueryEscape(key), url.PathEscape(key)}
+	// Longest first, so no form is left half replaced by a shorter one
+	sort.Slice(forms, func(i, j int) bool { return len(forms[i]) > len(forms[j]) })
+	for _, form := range forms {
+		msg = strings.ReplaceAll(msg, form, "***")
+	}
+	return msg
+}
+
 // Error codes for backend failures
 const (
 	ErrCodeUnavailable     = iota // Backend not configured
@@ -2,6 +2,7 @@ package backends
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -42,3 +43,15 @@ func TestBackendError_Codes(t *testing.T) {
 		seen[code] = true
 	}
 }
+
+func TestRedactKey(t *testing.T) {
+	key := "k3y/with+odd chars"
+	msg := `Get "https://api.example/q?key=k3y%2Fwith%2Bodd+chars&p=` + "k3y%2Fwith+odd%20chars" + `": dial tcp: k3y/with+odd chars`
+	got := redactKey(msg, key)
+	if strings.Contains(got, "k3y") || strings.Count(got, "***") != 3 {
+		t.Errorf("redactKey() = %q", got)
+	}
+	if got := redactKey("timeout", ""); got != "timeout" {
+		t.Errorf("no key: %q", got)
+	}
+}
@@ -31,7 +31,7 @@ func NewJinaBackend(apiKey string, timeout time.Duration, allowKeyless bool, bas
 		AllowKeyless: allowKeyless,
 		BaseURL:      strings.TrimRight(baseURL, "/") + "/",
 		Timeout:      timeout,
-		client:       &http.Client{Timeout: timeout},
+		client:       newHTTPClient(timeout, nil),
 	}
 }
 
@@ -70,8 +70,15 @@ func (j *JinaBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 		return nil, &BackendError{Backend: j.Name(), Err: fmt.Errorf("Jina backend not configured"), Code: ErrCodeUnavailable}
 	}
 
+	// X-Site scopes to a single site; several sites are OR-ed into the query
+	query := excludeSiteQuery(ComposeQuery(opts), opts.ExcludeSites)
+	if len(opts.Sites) > 1 {
+		query = siteQuery(query, opts.Sites)
+	}
+
 	reqBody := jinaRequest{
-		Query:    opts.Query,
+		Query:    query,
+		Country:  opts.Country,
 		Language: opts.Language,
 	}
 
@@ -92,8 +99,8 @@ func (j *JinaBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 	}
 
 	// Use X-Site header for site-scoped searches
-	if opts.Site != "" {
-		site := opts.Site
+	if len(opts.Sites) == 1 {
+		site := opts.Sites[0]
 		if !strings.HasPrefix(site, "http://") && !strings.HasPrefix(site, "https://") {
 			site = "https://" + site
 		}
@@ -125,7 +125,7 @@ func TestJinaBackend_Search_SiteFilter(t *testing.T) {
 	defer server.Close()
 
 	b := NewJinaBackend("key", 2*time.Second, false, server.URL)
-	b.Search(SearchOptions{Query: "test", Site: "example.com"})
+	b.Search(SearchOptions{Query: "test", Sites: []string{"example.com"}})
 
 	if capturedSiteHeader != "https://example.com" {
 		t.Errorf("expected X-Site header 'https://example.com', got %q", capturedSiteHeader)
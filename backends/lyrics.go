@@ -0,0 +1,190 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LyricsBackend implements SearchBackend for song lyrics through LRCLIB,
+// an open lyrics database that needs no key. It finds songs by title,
+// artist or a line of their lyrics, showing the lines that match.
+type LyricsBackend struct {
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewLyricsBackend creates a new lyrics backend
+func NewLyricsBackend(timeout time.Duration) *LyricsBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &LyricsBackend{
+		Timeout: timeout,
+		BaseURL: "https://lrclib.net",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (l *LyricsBackend) Name() string {
+	return "lyrics"
+}
+
+// IsAvailable reports true: LRCLIB needs no key
+func (l *LyricsBackend) IsAvailable() bool {
+	return true
+}
+
+// lrclibTrack is a song in an LRCLIB search response
+type lrclibTrack struct {
+	ID           int     `json:"id"`
+	TrackName    string  `json:"trackName"`
+	ArtistName   string  `json:"artistName"`
+	AlbumName    string  `json:"albumName"`
+	Duration     float64 `json:"duration"` // seconds
+	Instrumental bool    `json:"instrumental"`
+	PlainLyrics  string  `json:"plainLyrics"`
+	SyncedLyrics string  `json:"syncedLyrics"`
+}
+
+// lyricsExcerptLines is how many lines of lyrics a result shows
+const lyricsExcerptLines = 3
+
+// Search performs a search against LRCLIB. Its answer has no pages and
+// songs have no date, so later pages are cut from the one list and a time
+// range leaves none.
+func (l *LyricsBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	sites := nonEmpty(opts.Sites)
+	if opts.TimeRange != "" || len(sites) > 0 && !onAnySite("lrclib.net", sites) || onAnySite("lrclib.net", opts.ExcludeSites) {
+		return []SearchResult{}, nil
+	}
+
+	params := url.Values{}
+	params.Set("q", plainQuery(opts))
+	req, err := http.NewRequest("GET", l.BaseURL+"/api/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: l.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", projectUserAgent)
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: l.Name(),
+			Err:     fmt.Errorf("request failed: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: l.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	switch {
+	case resp.StatusCode == 429:
+		return nil, &BackendError{
+			Backend: l.Name(),
+			Err:     fmt.Errorf("rate limited: %s", strings.TrimSpace(string(body))),
+			Code:    ErrCodeRateLimit,
+		}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &BackendError{
+			Backend: l.Name(),
+			Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body))),
+			Code:    resp.StatusCode,
+		}
+	}
+
+	var tracks []lrclibTrack
+	if err := json.Unmarshal(body, &tracks); err != nil {
+		return nil, &BackendError{
+			Backend: l.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	words := strings.Fields(strings.ToLower(plainQuery(opts)))
+	results := make([]SearchResult, 0, len(tracks))
+	for _, track := range tracks {
+		if track.ID == 0 || mentionsAny(track.TrackName+" "+track.ArtistName+" "+track.PlainLyrics, opts.NoneOf) {
+			continue
+		}
+		kind := "lyrics"
+		content := lyricsExcerpt(track.PlainLyrics, words)
+		switch {
+		case track.Instrumental:
+			kind, content = "instrumental", ""
+		case track.SyncedLyrics != "":
+			kind = "synced lyrics"
+		}
+		result := SearchResult{
+			Title:    collapseSpace(track.TrackName),
+			URL:      l.BaseURL + "/api/get/" + strconv.Itoa(track.ID),
+			Content:  content,
+			Category: "music",
+			Author:   collapseSpace(track.ArtistName),
+			Album:    collapseSpace(track.AlbumName),
+			Metadata: kind,
+			Engine:   l.Name(),
+			Engines:  []string{l.Name()},
+		}
+		if track.Duration > 0 {
+			result.Length = track.Duration
+		}
+		results = append(results, result)
+	}
+
+	start, end := pageBounds(opts)
+	if start >= len(results) {
+		return []SearchResult{}, nil
+	}
+	return results[start:min(end, len(results))], nil
+}
+
+// lyricsExcerpt returns a few lines of lyrics, from the first line with
+// the most of the query words, joined with slashes; the opening lines if
+// none has any
+func lyricsExcerpt(lyrics string, words []string) string {
+	var lines []string
+	for _, line := range strings.Split(lyrics, "\n") {
+		if line = collapseSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	best, bestHits := 0, 0
+	for i, line := range lines {
+		lower := strings.ToLower(line)
+		hits := 0
+		for _, word := range words {
+			if strings.Contains(lower, word) {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = i, hits
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines[best:min(best+lyricsExcerptLines, len(lines))], " / ")
+}
@@ -0,0 +1,128 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const lrclibTestResponse = `[
+	{"id": 101, "trackName": "Bohemian Rhapsody", "artistName": "Queen", "albumName": "A Night at the Opera", "duration": 355,
+	 "instrumental": false, "plainLyrics": "Is this the real life?\nIs this just fantasy?\n\nCaught in a landslide\nNo escape from reality",
+	 "syncedLyrics": "[00:00.50] Is this the real life?"},
+	{"id": 102, "trackName": "Bohemian Rhapsody (Instrumental)", "artistName": "Queen", "duration": 355, "instrumental": true},
+	{"id": 103, "trackName": "Bohemian Rhapsody (Karaoke)", "artistName": "Sing Along", "plainLyrics": "Is this the real life?"},
+	{"trackName": "No ID"}
+]`
+
+func TestLyricsExcerpt(t *testing.T) {
+	lyrics := "First line\n\nSecond line\nCaught in a  landslide\nNo escape\nFifth line"
+	if got := lyricsExcerpt(lyrics, []string{"landslide", "escape"}); got != "Caught in a landslide / No escape / Fifth line" {
+		t.Errorf("got %q", got)
+	}
+	if got := lyricsExcerpt(lyrics, []string{"nowhere"}); got != "First line / Second line / Caught in a landslide" {
+		t.Errorf("no match: %q", got)
+	}
+	if got := lyricsExcerpt("", []string{"x"}); got != "" {
+		t.Errorf("no lyrics: %q", got)
+	}
+}
+
+func TestLyricsBackend_Search(t *testing.T) {
+	var q string
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q = r.URL.Query().Get("q")
+		if r.URL.Path != "/api/search" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(lrclibTestResponse))
+	}))
+	defer server.Close()
+
+	l := NewLyricsBackend(10 * time.Second)
+	l.BaseURL = server.URL
+	results, err := l.Search(SearchOptions{Query: "escape from reality", AllOf: []string{"queen"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q != "escape from reality queen" {
+		t.Errorf("q = %q", q)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	first := results[0]
+	if first.Title != "Bohemian Rhapsody" || first.URL != server.URL+"/api/get/101" || first.Author != "Queen" ||
+		first.Album != "A Night at the Opera" || first.Length != 355.0 || first.Category != "music" ||
+		first.Metadata != "synced lyrics" || first.Engine != "lyrics" {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	if first.Content != "No escape from reality" {
+		t.Errorf("content %q", first.Content)
+	}
+	if instrumental := results[1]; instrumental.Metadata != "instrumental" || instrumental.Content != "" {
+		t.Errorf("unexpected instrumental %+v", instrumental)
+	}
+	if results[2].Metadata != "lyrics" {
+		t.Errorf("metadata %q", results[2].Metadata)
+	}
+
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want int
+	}{
+		{"none of", SearchOptions{Query: "x", NoneOf: []string{"karaoke"}}, 2},
+		{"second page", SearchOptions{Query: "x", NumResults: 2, PageNo: 2}, 1},
+		{"past the end", SearchOptions{Query: "x", NumResults: 2, PageNo: 3}, 0},
+	}
+	for _, tt := range tests {
+		results, err := l.Search(tt.opts)
+		if err != nil || len(results) != tt.want {
+			t.Errorf("%s: got %d results, %v; want %d", tt.name, len(results), err, tt.want)
+		}
+	}
+
+	requests = 0
+	for _, opts := range []SearchOptions{
+		{Query: "x", TimeRange: "week"},
+		{Query: "x", Sites: []string{"genius.com"}},
+		{Query: "x", ExcludeSites: []string{"lrclib.net"}},
+	} {
+		if results, err := l.Search(opts); err != nil || len(results) != 0 {
+			t.Errorf("%+v: %d results, %v", opts, len(results), err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("expected no requests, got %d", requests)
+	}
+}
+
+func TestLyricsBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusTooManyRequests, `slow down`, ErrCodeRateLimit},
+		{http.StatusBadRequest, `{"code": 400, "message": "bad query"}`, http.StatusBadRequest},
+		{http.StatusOK, `{"not": "an array"}`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		l := NewLyricsBackend(10 * time.Second)
+		l.BaseURL = server.URL
+		_, err := l.Search(SearchOptions{Query: "test"})
+		server.Close()
+		if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		}
+	}
+}
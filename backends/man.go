@@ -0,0 +1,306 @@
+package backends
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// ManBackend implements SearchBackend over the system's documentation: the
+// man pages apropos knows and the pages of a local tldr cache. Results link
+// to man:name(section) and tldr:platform/name, which sx shows in the pager.
+type ManBackend struct {
+	TldrDirs []string // tldr "pages" directories; those that don't exist are skipped
+	// Apropos runs apropos for words and returns its output, nil without
+	// apropos; overridable for testing
+	Apropos func(words []string) ([]byte, error)
+}
+
+// NewManBackend creates a man page backend searching apropos, if it is
+// installed, and the tldr pages in tldrDirs
+func NewManBackend(tldrDirs []string) *ManBackend {
+	m := &ManBackend{TldrDirs: tldrDirs}
+	if _, err := exec.LookPath("apropos"); err == nil {
+		m.Apropos = runApropos
+	}
+	return m
+}
+
+// Name returns the backend identifier
+func (m *ManBackend) Name() string {
+	return "man"
+}
+
+// IsAvailable checks if apropos or a tldr cache is installed
+func (m *ManBackend) IsAvailable() bool {
+	return m.Apropos != nil || len(m.tldrDirs()) > 0
+}
+
+// runApropos lists the man pages whose name or description contains any
+// of words. apropos exits with 16 when nothing is found.
+func runApropos(words []string) ([]byte, error) {
+	out, err := exec.Command("apropos", append([]string{"--"}, words...)...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 16 {
+		return nil, nil
+	}
+	return out, err
+}
+
+// DocPage is a man or tldr page found by the man backend
+type DocPage struct {
+	Name        string
+	Section     string // man section, or the tldr platform
+	Description string
+	Examples    string // tldr only: the example descriptions
+	Tldr        bool
+}
+
+// URL is the man: or tldr: link sx opens the page with
+func (p DocPage) URL() string {
+	if p.Tldr {
+		return "tldr:" + p.Section + "/" + p.Name
+	}
+	return "man:" + p.Name + "(" + p.Section + ")"
+}
+
+// aproposLine matches a line of apropos output: "ls (1) - list directory
+// contents" from man-db, "ls(1), dir(1) - ..." from BSD and macOS
+var aproposLine = regexp.MustCompile(`^(.+?)\s*\(([^()]+)\)\s+-+\s+(.*)$`)
+
+// parseApropos reads the pages listed in apropos output
+func parseApropos(out string) []DocPage {
+	var pages []DocPage
+	for _, line := range strings.Split(out, "\n") {
+		m := aproposLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		// BSD apropos lists each name with its section: "a(1), b(1)"
+		for _, name := range strings.Split(m[1], ",") {
+			name = strings.TrimSpace(name)
+			section := m[2]
+			if i := strings.Index(name, "("); i > 0 && strings.HasSuffix(name, ")") {
+				name, section = name[:i], name[i+1:len(name)-1]
+			}
+			if name != "" {
+				pages = append(pages, DocPage{Name: name, Section: section, Description: strings.TrimSpace(m[3])})
+			}
+		}
+	}
+	return pages
+}
+
+// tldrPlatforms are the tldr page directories for the running system,
+// platform-specific pages first
+func tldrPlatforms(goos string) []string {
+	switch goos {
+	case "darwin":
+		return []string{"osx", "common"}
+	case "linux", "windows", "freebsd", "netbsd", "openbsd", "android":
+		return []string{goos, "common"}
+	}
+	return []string{"common"}
+}
+
+// tldrDirs returns the configured tldr directories that exist
+func (m *ManBackend) tldrDirs() []string {
+	var dirs []string
+	for _, dir := range m.TldrDirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// tldrPages reads the tldr pages of the running system from the first
+// directory that has any. A page in both a platform directory and common
+// is taken from the platform.
+func (m *ManBackend) tldrPages() []DocPage {
+	for _, dir := range m.tldrDirs() {
+		var pages []DocPage
+		seen := make(map[string]bool)
+		for _, platform := range tldrPlatforms(runtime.GOOS) {
+			files, _ := filepath.Glob(filepath.Join(dir, platform, "*.md"))
+			for _, file := range files {
+				name := strings.TrimSuffix(filepath.Base(file), ".md")
+				if seen[name] {
+					continue
+				}
+				data, err := os.ReadFile(file)
+				if err != nil {
+					continue
+				}
+				seen[name] = true
+				page := parseTldrPage(string(data))
+				page.Name, page.Section = name, platform
+				pages = append(pages, page)
+			}
+		}
+		if len(pages) > 0 {
+			return pages
+		}
+	}
+	return nil
+}
+
+// parseTldrPage reads the description ("> " lines, without the "More
+// information" link) and example descriptions ("- " lines, without the
+// brackets marking mnemonics as in "[c]reate") of a tldr page
+func parseTldrPage(content string) DocPage {
+	var description, examples []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, ">"):
+			line = strings.TrimSpace(strings.TrimPrefix(line, ">"))
+			if !strings.HasPrefix(line, "More information:") && !strings.HasPrefix(line, "See also:") {
+				description = append(description, line)
+			}
+		case strings.HasPrefix(line, "- "):
+			example := strings.TrimSuffix(strings.TrimPrefix(line, "- "), ":")
+			examples = append(examples, strings.NewReplacer("[", "", "]", "").Replace(example))
+		}
+	}
+	return DocPage{
+		Description: strings.Join(description, " "),
+		Examples:    strings.Join(examples, " "),
+		Tldr:        true,
+	}
+}
+
+// ReadTldrPage returns the tldr page a tldr:platform/name link points to,
+// from the first of dirs that has it
+func ReadTldrPage(dirs []string, ref string) ([]byte, error) {
+	platform, name, found := strings.Cut(strings.TrimPrefix(ref, "tldr:"), "/")
+	if !found || strings.ContainsAny(name, `/\`) || strings.Contains(platform, "..") || name == ".." {
+		return nil, fmt.Errorf("invalid tldr link %q", ref)
+	}
+	for _, dir := range dirs {
+		if data, err := os.ReadFile(filepath.Join(dir, platform, name+".md")); err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("tldr page %s not found", name)
+}
+
+// docMatch is a page that matches the query, with its score
+type docMatch struct {
+	page  DocPage
+	score int
+}
+
+// Search finds the man and tldr pages whose name or description contains
+// every query term; tldr pages match their examples too. Name matches
+// count most, and a page named exactly like the query comes first. Pages
+// have no site or date, so site and time filters leave none.
+func (m *ManBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	if len(opts.Sites) > 0 || opts.TimeRange != "" {
+		return []SearchResult{}, nil
+	}
+	words := strings.Fields(plainQuery(opts))
+	if len(words) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	pages := m.tldrPages()
+	if m.Apropos != nil {
+		out, err := m.Apropos(words)
+		if err != nil {
+			return nil, &BackendError{
+				Backend: m.Name(),
+				Err:     fmt.Errorf("apropos: %v", err),
+				Code:    ErrCodeUnavailable,
+			}
+		}
+		pages = append(pages, parseApropos(string(out))...)
+	} else if len(m.tldrDirs()) == 0 {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("neither apropos nor tldr pages found"),
+			Code:    ErrCodeUnavailable,
+		}
+	}
+
+	query := strings.ToLower(strings.TrimSpace(opts.Query))
+	var matches []docMatch
+	seen := make(map[string]bool)
+	for _, page := range pages {
+		if seen[page.URL()] {
+			continue
+		}
+		seen[page.URL()] = true
+		name := strings.ToLower(page.Name)
+		description := strings.ToLower(page.Description + " " + page.Examples)
+		score, ok := termScore(opts, func(term string) int {
+			term = strings.ToLower(term)
+			switch {
+			case strings.Contains(name, term):
+				return 3
+			case strings.Contains(description, term):
+				return 1
+			}
+			return 0
+		})
+		if !ok {
+			continue
+		}
+		if name == query {
+			score += 10
+		}
+		matches = append(matches, docMatch{page, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if len(a.page.Name) != len(b.page.Name) {
+			return len(a.page.Name) < len(b.page.Name)
+		}
+		// tldr's examples first, then the man page
+		return a.page.Tldr && !b.page.Tldr
+	})
+
+	num := opts.NumResults
+	if num <= 0 {
+		num = 10
+	}
+	start := 0
+	if opts.PageNo > 1 {
+		start = (opts.PageNo - 1) * num
+	}
+	if start >= len(matches) {
+		return []SearchResult{}, nil
+	}
+	matches = matches[start:min(start+num, len(matches))]
+
+	results := make([]SearchResult, len(matches))
+	for i, match := range matches {
+		page := match.page
+		title := page.Name + "(" + page.Section + ")"
+		source := "man"
+		if page.Tldr {
+			title = page.Name + " (tldr)"
+			source = "tldr " + page.Section
+		}
+		results[i] = SearchResult{
+			Title:    title,
+			URL:      page.URL(),
+			Content:  page.Description,
+			Source:   source,
+			Category: "it",
+			Score:    float64(match.score),
+			Engine:   m.Name(),
+			Engines:  []string{m.Name()},
+		}
+	}
+	return results, nil
+}
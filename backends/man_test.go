@@ -0,0 +1,129 @@
+package backends
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParseApropos(t *testing.T) {
+	out := "ls (1)               - list directory contents\n" +
+		"tar (1)              - an archiving utility\n" +
+		"git-add(1), git-stage(1) - Add file contents to the index\n" +
+		"apropos: nothing appropriate\n"
+	want := []DocPage{
+		{Name: "ls", Section: "1", Description: "list directory contents"},
+		{Name: "tar", Section: "1", Description: "an archiving utility"},
+		{Name: "git-add", Section: "1", Description: "Add file contents to the index"},
+		{Name: "git-stage", Section: "1", Description: "Add file contents to the index"},
+	}
+	if got := parseApropos(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestParseTldrPage(t *testing.T) {
+	page := parseTldrPage("# tar\n\n> Archiving utility.\n> Often combined with a compression method.\n> More information: <https://www.gnu.org/software/tar>.\n\n- [c]reate an archive:\n\n`tar cf {{target.tar}} {{file1}}`\n\n- E[x]tract an archive:\n\n`tar xf {{source.tar}}`\n")
+	if page.Description != "Archiving utility. Often combined with a compression method." ||
+		page.Examples != "create an archive Extract an archive" || !page.Tldr {
+		t.Errorf("got %+v", page)
+	}
+}
+
+func writeTldrPage(t *testing.T, dir, platform, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, platform), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, platform, name+".md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManBackend_Search(t *testing.T) {
+	dir := t.TempDir()
+	writeTldrPage(t, dir, "common", "tar", "# tar\n\n> Archiving utility.\n\n- Extract an archive:\n\n`tar xf {{source.tar}}`\n")
+	writeTldrPage(t, dir, "common", "zip", "# zip\n\n> Package and compress files into an archive.\n")
+	writeTldrPage(t, dir, "windows", "tar", "# tar\n\n> Windows tar.\n")
+
+	var asked []string
+	m := &ManBackend{
+		TldrDirs: []string{filepath.Join(dir, "missing"), dir},
+		Apropos: func(words []string) ([]byte, error) {
+			asked = words
+			return []byte("tar (1)              - an archiving utility\ntardy (1)            - archive postprocessor\n"), nil
+		},
+	}
+	if !m.IsAvailable() || m.Name() != "man" {
+		t.Fatal("backend unavailable")
+	}
+
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want []string
+	}{
+		// The exact name first, tldr before the man page
+		{"ranking", SearchOptions{Query: "tar"}, []string{"tldr:common/tar", "man:tar(1)", "man:tardy(1)"}},
+		{"descriptions and examples", SearchOptions{Query: "extract archive"}, []string{"tldr:common/tar"}},
+		{"none of", SearchOptions{Query: "archive", NoneOf: []string{"tar"}}, []string{"tldr:common/zip"}},
+		{"paging", SearchOptions{Query: "tar", NumResults: 2, PageNo: 2}, []string{"man:tardy(1)"}},
+		{"sites", SearchOptions{Query: "tar", Sites: []string{"gnu.org"}}, nil},
+		{"time range", SearchOptions{Query: "tar", TimeRange: "week"}, nil},
+	}
+	if runtime.GOOS == "windows" {
+		tests[0].want[0] = "tldr:windows/tar"
+	}
+	for _, tt := range tests {
+		results, err := m.Search(tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.URL)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if !reflect.DeepEqual(asked, []string{"tar"}) {
+		t.Errorf("apropos asked for %q", asked)
+	}
+
+	results, _ := m.Search(SearchOptions{Query: "tardy"})
+	if len(results) != 1 || results[0].Title != "tardy(1)" || results[0].Content != "archive postprocessor" ||
+		results[0].Category != "it" || results[0].Engine != "man" {
+		t.Errorf("result: %+v", results)
+	}
+}
+
+func TestManBackend_Errors(t *testing.T) {
+	m := &ManBackend{Apropos: func([]string) ([]byte, error) { return nil, errors.New("exit status 1") }}
+	if _, err := m.Search(SearchOptions{Query: "tar"}); err == nil || err.(*BackendError).Code != ErrCodeUnavailable {
+		t.Errorf("apropos failure: %v", err)
+	}
+	none := &ManBackend{TldrDirs: []string{filepath.Join(t.TempDir(), "missing")}}
+	if none.IsAvailable() {
+		t.Error("available without apropos or tldr pages")
+	}
+	if _, err := none.Search(SearchOptions{Query: "tar"}); err == nil {
+		t.Error("searched without apropos or tldr pages")
+	}
+}
+
+func TestReadTldrPage(t *testing.T) {
+	dir := t.TempDir()
+	writeTldrPage(t, dir, "common", "tar", "# tar\n")
+	if page, err := ReadTldrPage([]string{t.TempDir(), dir}, "tldr:common/tar"); err != nil || string(page) != "# tar\n" {
+		t.Errorf("got %q, %v", page, err)
+	}
+	for _, ref := range []string{"tldr:common/zip", "tldr:tar", "tldr:../../etc/passwd", "tldr:common/../x"} {
+		if _, err := ReadTldrPage([]string{dir}, ref); err == nil {
+			t.Errorf("%s: no error", ref)
+		}
+	}
+}
@@ -1,21 +1,35 @@
 package backends
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
 	"strings"
+	"sync"
 )
 
 // Manager coordinates search across multiple backends with fallback support
 type Manager struct {
-	primary   SearchBackend
-	fallbacks []SearchBackend
-	registry  map[string]SearchBackend
+	primary    SearchBackend
+	fallbacks  []SearchBackend
+	registry   map[string]SearchBackend
+	fallbackOn map[string]bool            // failure classes that move on; nil for all
+	batches    map[string]*SearchResponse // by batchKey, see searchPage
+	batchMu    sync.Mutex                 // guards batches for concurrent searches
+}
+
+// FailureClasses are the kinds of backend failure that SetFallbackOn can
+// select, as reported by failureClass.
+var FailureClasses = []string{
+	"no-results", "timeout", "network", "auth", "rate-limit",
+	"4xx", "5xx", "invalid-response", "degraded", "not-configured", "other",
 }
 
 // NewManager creates a new backend manager
 func NewManager() *Manager {
 	return &Manager{
 		registry: make(map[string]SearchBackend),
+		batches:  make(map[string]*SearchResponse),
 	}
 }
 
@@ -47,6 +61,36 @@ func (m *Manager) SetFallbacks(names []string) error {
 	return nil
 }
 
+// SetFallbackOn limits fallback to the given failure classes (see
+// FailureClasses): a backend failing any other way ends the search with its
+// error, or with its empty response for "no-results". An empty list restores
+// the default of falling back on every failure.
+func (m *Manager) SetFallbackOn(classes []string) error {
+	if len(classes) == 0 {
+		m.fallbackOn = nil
+		return nil
+	}
+	on := make(map[string]bool, len(classes))
+	for _, class := range classes {
+		known := false
+		for _, c := range FailureClasses {
+			known = known || c == class
+		}
+		if !known {
+			return fmt.Errorf("unknown fallback_on class: %s (available: %s)", class, strings.Join(FailureClasses, ", "))
+		}
+		on[class] = true
+	}
+	m.fallbackOn = on
+	return nil
+}
+
+// shouldFallback reports whether a backend failing with err (nil: no
+// results) hands over to the next backend.
+func (m *Manager) shouldFallback(err error) bool {
+	return m.fallbackOn == nil || m.fallbackOn[failureClass(err)]
+}
+
 // Search performs a search using the primary backend, falling back to alternatives.
 // On the first page, an empty (but successful) response also triggers fallbacks:
 // engines commonly report HTTP 200 with zero results when they are rate limited
@@ -55,21 +99,41 @@ func (m *Manager) SetFallbacks(names []string) error {
 // pagination doesn't mix results from different engines.
 // Returns the results, the backend name that succeeded, and any error.
 func (m *Manager) Search(opts SearchOptions) ([]SearchResult, string, error) {
+	resp, name, err := m.SearchDetailed(opts)
+	if err != nil || resp == nil {
+		return nil, name, err
+	}
+	return resp.Results, name, nil
+}
+
+// SearchDetailed is like Search but returns the full response, including
+// metadata such as spelling corrections from backends that report it.
+func (m *Manager) SearchDetailed(opts SearchOptions) (*SearchResponse, string, error) {
 	if m.primary == nil {
 		return nil, "", fmt.Errorf("no primary backend configured")
 	}
 
 	// Try primary backend first
-	results, err := m.primary.Search(opts)
-	if err == nil && (len(results) > 0 || opts.PageNo > 1) {
-		return results, m.primary.Name(), nil
+	resp, err := m.searchPage(m.primary, opts)
+	if err == nil && (len(resp.Results) > 0 || opts.PageNo > 1) {
+		return resp, m.primary.Name(), nil
+	}
+
+	if !m.shouldFallback(err) {
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, m.primary.Name(), nil
 	}
 
 	// Primary failed or returned nothing - collect errors and try fallbacks
 	var errors []string
+	attempts := []FallbackAttempt{{Backend: m.primary.Name(), Reason: fallbackReason(err)}}
 	emptyFrom := ""
+	var emptyResp *SearchResponse
 	if err == nil {
 		emptyFrom = m.primary.Name()
+		emptyResp = resp
 		errors = append(errors, fmt.Sprintf("%s: returned no results", m.primary.Name()))
 	} else {
 		errors = append(errors, err.Error())
@@ -84,31 +148,107 @@ func (m *Manager) Search(opts SearchOptions) ([]SearchResult, string, error) {
 			continue
 		}
 
-		results, fbErr := fb.Search(opts)
-		if fbErr == nil && len(results) > 0 {
-			return results, fb.Name(), nil
+		fbResp, fbErr := m.searchPage(fb, opts)
+		if fbErr == nil && len(fbResp.Results) > 0 {
+			fbResp.Fallbacks = attempts
+			return fbResp, fb.Name(), nil
 		}
+		attempts = append(attempts, FallbackAttempt{Backend: fb.Name(), Reason: fallbackReason(fbErr)})
 		if fbErr == nil {
 			if emptyFrom == "" {
 				emptyFrom = fb.Name()
+				emptyResp = fbResp
 			}
 			errors = append(errors, fmt.Sprintf("%s: returned no results", fb.Name()))
 		} else {
 			errors = append(errors, fbErr.Error())
 		}
+		if !m.shouldFallback(fbErr) {
+			break
+		}
 	}
 
 	// At least one backend answered successfully with zero results:
-	// treat the query as having no results rather than failing.
+	// treat the query as having no results rather than failing. Its
+	// response is kept: corrections matter most when nothing was found.
 	if emptyFrom != "" {
-		return nil, emptyFrom, nil
+		for _, attempt := range attempts {
+			if attempt.Backend != emptyFrom {
+				emptyResp.Fallbacks = append(emptyResp.Fallbacks, attempt)
+			}
+		}
+		return emptyResp, emptyFrom, nil
 	}
 
 	return nil, "", fmt.Errorf("all backends failed:\n  %s", strings.Join(errors, "\n  "))
 }
 
+// failureClass sorts a backend failure into one of FailureClasses; a nil
+// err means the backend answered with no results.
+func failureClass(err error) string {
+	if err == nil {
+		return "no-results"
+	}
+	msg := err.Error()
+	if stderrors.Is(err, context.DeadlineExceeded) || strings.Contains(msg, "Client.Timeout") || strings.Contains(msg, "deadline exceeded") {
+		return "timeout"
+	}
+	var backendErr *BackendError
+	if stderrors.As(err, &backendErr) {
+		switch code := backendErr.Code; {
+		case code == ErrCodeUnavailable:
+			return "not-configured"
+		case code == ErrCodeNetwork:
+			return "network"
+		case code == ErrCodeAuth || code == 401 || code == 403:
+			return "auth"
+		case code == ErrCodeRateLimit || code == 429:
+			return "rate-limit"
+		case code == ErrCodeInvalidResponse:
+			return "invalid-response"
+		case code == ErrCodeDegraded:
+			return "degraded"
+		case code >= 400 && code < 500:
+			return "4xx"
+		case code >= 500:
+			return "5xx"
+		}
+	}
+	return "other"
+}
+
+// fallbackReason summarizes why a backend didn't provide results; a nil
+// err means it answered with none.
+func fallbackReason(err error) string {
+	var backendErr *BackendError
+	switch class := failureClass(err); class {
+	case "4xx", "5xx":
+		stderrors.As(err, &backendErr)
+		return fmt.Sprintf("HTTP %d", backendErr.Code)
+	case "network":
+		return "network error"
+	case "auth":
+		return "authentication failed"
+	case "degraded":
+		return "upstream engines failing"
+	case "other":
+		return "error"
+	default:
+		return strings.ReplaceAll(class, "-", " ")
+	}
+}
+
 // SearchExplicit searches using a specific backend by name (no fallback)
 func (m *Manager) SearchExplicit(name string, opts SearchOptions) ([]SearchResult, error) {
+	resp, err := m.SearchExplicitDetailed(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// SearchExplicitDetailed is like SearchExplicit but returns the full response
+func (m *Manager) SearchExplicitDetailed(name string, opts SearchOptions) (*SearchResponse, error) {
 	backend, ok := m.registry[name]
 	if !ok {
 		return nil, fmt.Errorf("unknown backend: %s (available: %s)", name, m.availableNames())
@@ -116,7 +256,7 @@ func (m *Manager) SearchExplicit(name string, opts SearchOptions) ([]SearchResul
 	if !backend.IsAvailable() {
 		return nil, fmt.Errorf("backend %s is not configured (missing API key?)", name)
 	}
-	return backend.Search(opts)
+	return m.searchPage(backend, opts)
 }
 
 // GetBackend returns a backend by name
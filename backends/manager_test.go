@@ -368,3 +368,110 @@ func TestManager_FallbackOrder(t *testing.T) {
 		t.Errorf("unexpected results: %v", results)
 	}
 }
+
+// detailedMockBackend is a mockBackend that also reports response metadata
+type detailedMockBackend struct {
+	mockBackend
+	corrections []string
+}
+
+func (m *detailedMockBackend) SearchDetailed(opts SearchOptions) (*SearchResponse, error) {
+	results, err := m.Search(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResponse{Results: results, Corrections: m.corrections}, nil
+}
+
+func TestManager_SearchDetailed_KeepsCorrectionsWhenAllEmpty(t *testing.T) {
+	mgr := NewManager()
+	mgr.Register(&detailedMockBackend{
+		mockBackend: mockBackend{name: "primary", available: true},
+		corrections: []string{"golang"},
+	})
+	mgr.Register(&mockBackend{name: "fallback", available: true})
+	mgr.SetPrimary("primary")
+	mgr.SetFallbacks([]string{"fallback"})
+
+	resp, engine, err := mgr.SearchDetailed(SearchOptions{Query: "golnag"})
+	if err != nil {
+		t.Fatalf("SearchDetailed failed: %v", err)
+	}
+	if engine != "primary" {
+		t.Errorf("expected engine 'primary', got %q", engine)
+	}
+	if resp == nil || len(resp.Corrections) != 1 || resp.Corrections[0] != "golang" {
+		t.Errorf("expected corrections to be kept, got %+v", resp)
+	}
+}
+
+func TestManager_SearchDetailed_ReportsFallbackChain(t *testing.T) {
+	mgr := NewManager()
+	mgr.Register(&mockBackend{name: "primary", available: true, err: &BackendError{Backend: "primary", Err: fmt.Errorf("bad gateway"), Code: 502}})
+	mgr.Register(&mockBackend{name: "empty", available: true})
+	mgr.Register(&mockBackend{name: "unconfigured", available: false})
+	mgr.Register(&mockBackend{name: "fallback", available: true, results: []SearchResult{{Title: "ok"}}})
+	mgr.SetPrimary("primary")
+	mgr.SetFallbacks([]string{"empty", "unconfigured", "fallback"})
+
+	resp, engine, err := mgr.SearchDetailed(SearchOptions{Query: "test", PageNo: 1})
+	if err != nil || engine != "fallback" {
+		t.Fatalf("SearchDetailed() = %q, %v", engine, err)
+	}
+	want := []FallbackAttempt{{Backend: "primary", Reason: "HTTP 502"}, {Backend: "empty", Reason: "no results"}}
+	if fmt.Sprint(resp.Fallbacks) != fmt.Sprint(want) {
+		t.Errorf("Fallbacks = %v, want %v", resp.Fallbacks, want)
+	}
+}
+
+func TestFallbackReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, "no results"},
+		{&BackendError{Backend: "x", Err: fmt.Errorf("request failed: context deadline exceeded (Client.Timeout exceeded while awaiting headers)"), Code: ErrCodeNetwork}, "timeout"},
+		{&BackendError{Backend: "x", Err: fmt.Errorf("dial tcp: connection refused"), Code: ErrCodeNetwork}, "network error"},
+		{&BackendError{Backend: "x", Err: fmt.Errorf("authentication failed"), Code: ErrCodeAuth}, "authentication failed"},
+		{&BackendError{Backend: "x", Err: fmt.Errorf("HTTP 503"), Code: 503}, "HTTP 503"},
+		{fmt.Errorf("something else"), "error"},
+	}
+	for _, tt := range tests {
+		if got := fallbackReason(tt.err); got != tt.want {
+			t.Errorf("fallbackReason(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestManager_SetFallbackOn(t *testing.T) {
+	newMgr := func(primaryErr error) *Manager {
+		mgr := NewManager()
+		mgr.Register(&mockBackend{name: "primary", available: true, err: primaryErr})
+		mgr.Register(&mockBackend{name: "fallback", available: true, results: []SearchResult{{Title: "ok"}}})
+		mgr.SetPrimary("primary")
+		mgr.SetFallbacks([]string{"fallback"})
+		if err := mgr.SetFallbackOn([]string{"network", "5xx"}); err != nil {
+			t.Fatal(err)
+		}
+		return mgr
+	}
+
+	_, engine, err := newMgr(&BackendError{Backend: "primary", Err: fmt.Errorf("HTTP 503"), Code: 503}).SearchDetailed(SearchOptions{PageNo: 1})
+	if err != nil || engine != "fallback" {
+		t.Errorf("5xx: engine %q, err %v; want fallback", engine, err)
+	}
+
+	_, _, err = newMgr(&BackendError{Backend: "primary", Err: fmt.Errorf("bad key"), Code: ErrCodeAuth}).SearchDetailed(SearchOptions{PageNo: 1})
+	if err == nil || !strings.Contains(err.Error(), "bad key") {
+		t.Errorf("auth error should end the search, got %v", err)
+	}
+
+	resp, engine, err := newMgr(nil).SearchDetailed(SearchOptions{PageNo: 1})
+	if err != nil || engine != "primary" || len(resp.Results) != 0 {
+		t.Errorf("no-results not selected: engine %q, err %v", engine, err)
+	}
+
+	if err := NewManager().SetFallbackOn([]string{"sometimes"}); err == nil {
+		t.Error("unknown class accepted")
+	}
+}
@@ -0,0 +1,204 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MarginaliaPublicKey is the shared API key Marginalia offers for light
+// use; a key of your own has its own rate limit
+const MarginaliaPublicKey = "public"
+
+// MarginaliaBackend implements SearchBackend for the Marginalia Search
+// API, an index of the non-commercial, independent web
+type MarginaliaBackend struct {
+	APIKey  string
+	Index   int // search index, the ranking profile results come from
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewMarginaliaBackend creates a new Marginalia backend. Without a key it
+// uses the shared public one.
+func NewMarginaliaBackend(apiKey string, index int, timeout time.Duration) *MarginaliaBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	if apiKey == "" {
+		apiKey = MarginaliaPublicKey
+	}
+	return &MarginaliaBackend{
+		APIKey:  apiKey,
+		Index:   index,
+		Timeout: timeout,
+		BaseURL: "https://api.marginalia.nu",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (m *MarginaliaBackend) Name() string {
+	return "marginalia"
+}
+
+// IsAvailable reports true: the public key needs no configuration
+func (m *MarginaliaBackend) IsAvailable() bool {
+	return true
+}
+
+// MaxResults is the most results Marginalia returns for one request; it
+// has no page parameter
+func (m *MarginaliaBackend) MaxResults() int {
+	return 100
+}
+
+// marginaliaResponse is the Marginalia Search API response
+type marginaliaResponse struct {
+	Query   string `json:"query"`
+	Results []struct {
+		URL         string  `json:"url"`
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		Quality     float64 `json:"quality"`
+	} `json:"results"`
+}
+
+// marginaliaQuery renders opts in Marginalia's query syntax, which has
+// quoted phrases, -term and site: but no OR: AnyOf terms become plain
+// keywords, and several sites are filtered from the results.
+func marginaliaQuery(opts SearchOptions) string {
+	parts := []string{plainQuery(SearchOptions{Query: opts.Query, AllOf: opts.AllOf, AnyOf: opts.AnyOf})}
+	for _, phrase := range opts.Exact {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			parts = append(parts, quotePhrase(phrase))
+		}
+	}
+	for _, term := range opts.NoneOf {
+		if term = strings.TrimSpace(term); term != "" {
+			parts = append(parts, "-"+quoteIfSpaced(term))
+		}
+	}
+	if sites := nonEmpty(opts.Sites); len(sites) == 1 {
+		parts = append(parts, "site:"+sites[0])
+	}
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+// nonEmpty returns the trimmed, non-empty entries of list
+func nonEmpty(list []string) []string {
+	var out []string
+	for _, s := range list {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// requestURL builds the search URL for opts
+func (m *MarginaliaBackend) requestURL(opts SearchOptions) string {
+	num := opts.NumResults
+	if num <= 0 {
+		num = 10
+	}
+	params := url.Values{}
+	params.Set("count", strconv.Itoa(min(num, m.MaxResults())))
+	params.Set("index", strconv.Itoa(m.Index))
+	return fmt.Sprintf("%s/%s/search/%s?%s", strings.TrimSuffix(m.BaseURL, "/"),
+		url.PathEscape(m.APIKey), url.PathEscape(marginaliaQuery(opts)), params.Encode())
+}
+
+// Search performs a search against the Marginalia Search API
+func (m *MarginaliaBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	req, err := http.NewRequest("GET", m.requestURL(opts), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		msg := err.Error()
+		// The request URL carries the API key, path escaped
+		if m.APIKey != MarginaliaPublicKey {
+			msg = redactKey(msg, m.APIKey)
+		}
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("request failed: %s", msg),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	switch {
+	// The public key answers 503 when it is used too much
+	case resp.StatusCode == 429 || resp.StatusCode == 503:
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("rate limited: %s", strings.TrimSpace(string(body))),
+			Code:    ErrCodeRateLimit,
+		}
+	case resp.StatusCode == 401 || resp.StatusCode == 403:
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("authentication failed: %s", strings.TrimSpace(string(body))),
+			Code:    ErrCodeAuth,
+		}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body))),
+			Code:    resp.StatusCode,
+		}
+	}
+
+	var marginaliaResp marginaliaResponse
+	if err := json.Unmarshal(body, &marginaliaResp); err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	sites := nonEmpty(opts.Sites)
+	results := make([]SearchResult, 0, len(marginaliaResp.Results))
+	for _, r := range marginaliaResp.Results {
+		if len(sites) > 1 {
+			u, err := url.Parse(r.URL)
+			if err != nil || !onAnySite(u.Hostname(), sites) {
+				continue
+			}
+		}
+		results = append(results, SearchResult{
+			Title:   r.Title,
+			URL:     r.URL,
+			Content: strings.TrimSpace(r.Description),
+			Score:   r.Quality,
+			Engine:  m.Name(),
+			Engines: []string{m.Name()},
+		})
+	}
+	return results, nil
+}
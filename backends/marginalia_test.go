@@ -0,0 +1,103 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarginaliaQuery(t *testing.T) {
+	tests := []struct {
+		opts SearchOptions
+		want string
+	}{
+		{SearchOptions{Query: "golang"}, "golang"},
+		{SearchOptions{Query: "golang", Exact: []string{"error handling"}, AnyOf: []string{"wrap", "join"}, NoneOf: []string{"java", "try catch"}},
+			`golang wrap join "error handling" -java -"try catch"`},
+		{SearchOptions{Query: "golang", Sites: []string{"go.dev"}}, "golang site:go.dev"},
+		{SearchOptions{Query: "golang", Sites: []string{"go.dev", "golang.org"}}, "golang"},
+	}
+	for _, tt := range tests {
+		if got := marginaliaQuery(tt.opts); got != tt.want {
+			t.Errorf("marginaliaQuery(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestMarginaliaBackend_Search(t *testing.T) {
+	var gotPath, gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.EscapedPath(), r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"license": "CC-BY-NC-SA 4.0", "query": "golang",
+			"results": [
+				{"url": "https://go.dev/blog/", "title": "The Go Blog", "description": " Posts about Go. ", "quality": -3.5},
+				{"url": "https://golang.org/doc/", "title": "Documentation", "description": "", "quality": -4},
+				{"url": "https://example.com/go", "title": "Elsewhere", "description": "", "quality": -5}
+			]}`))
+	}))
+	defer server.Close()
+
+	m := NewMarginaliaBackend("", 2, 10*time.Second)
+	m.BaseURL = server.URL
+	if !m.IsAvailable() || m.APIKey != MarginaliaPublicKey {
+		t.Fatalf("backend without a key: %+v", m)
+	}
+	results, err := m.Search(SearchOptions{Query: "go lang", NumResults: 5, Sites: []string{"go.dev", "golang.org"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotPath != "/public/search/go%20lang" || gotQuery != "count=5&index=2" {
+		t.Errorf("unexpected request %s?%s", gotPath, gotQuery)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results on the sites, got %d", len(results))
+	}
+	first := results[0]
+	if first.Title != "The Go Blog" || first.Content != "Posts about Go." || first.Score != -3.5 || first.Engine != "marginalia" {
+		t.Errorf("unexpected result %+v", first)
+	}
+
+	m.Search(SearchOptions{Query: "golang", NumResults: 500})
+	if gotQuery != "count=100&index=2" {
+		t.Errorf("count not capped: %s", gotQuery)
+	}
+}
+
+func TestMarginaliaBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusServiceUnavailable, `Too many requests`, ErrCodeRateLimit},
+		{http.StatusTooManyRequests, `Slow down`, ErrCodeRateLimit},
+		{http.StatusUnauthorized, `Invalid API key`, ErrCodeAuth},
+		{http.StatusInternalServerError, `oops`, http.StatusInternalServerError},
+		{http.StatusOK, `not json`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		m := NewMarginaliaBackend("test-key", 0, 10*time.Second)
+		m.BaseURL = server.URL
+		_, err := m.Search(SearchOptions{Query: "test"})
+		server.Close()
+		backendErr, ok := err.(*BackendError)
+		if !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		}
+	}
+
+	for _, key := range []string{"secret-key", "secret key/1"} {
+		m := NewMarginaliaBackend(key, 0, time.Second)
+		m.BaseURL = "http://127.0.0.1:1"
+		if _, err := m.Search(SearchOptions{Query: "test"}); err == nil || strings.Contains(err.Error(), "secret") {
+			t.Errorf("request error leaks the API key %q: %v", key, err)
+		}
+	}
+}
@@ -21,7 +21,7 @@ func NewMCPHTTPClient(baseURL string, timeout time.Duration) *MCPHTTPClient {
 	}
 	return &MCPHTTPClient{
 		BaseURL: baseURL,
-		client:  &http.Client{Timeout: timeout},
+		client:  newHTTPClient(timeout, nil),
 	}
 }
 
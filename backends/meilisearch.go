@@ -0,0 +1,236 @@
+package backends
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MeilisearchBackend implements SearchBackend for a Meilisearch index the
+// user runs, such as an index of their own documents. Results are made of
+// the document fields the config names.
+type MeilisearchBackend struct {
+	URL    string // instance URL, e.g. http://localhost:7700
+	Index  string // index UID
+	APIKey string // a search or master key; none for instances without one
+
+	// Document fields, which may be dotted paths into nested objects
+	TitleField   string // "title" by default
+	URLField     string // "url" by default; results link to the document without it
+	ContentField string // "content" by default
+	DateField    string // a filterable attribute of Unix timestamps, for --time-range
+
+	Timeout time.Duration
+	client  *http.Client
+}
+
+// NewMeilisearchBackend creates a new Meilisearch backend
+func NewMeilisearchBackend(baseURL, index, apiKey string, timeout time.Duration) *MeilisearchBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &MeilisearchBackend{
+		URL:     strings.TrimSuffix(baseURL, "/"),
+		Index:   index,
+		APIKey:  apiKey,
+		Timeout: timeout,
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// SetClientCert loads a PEM client certificate and key presented to a
+// instance that requires mutual TLS. Empty paths remove the certificate.
+func (m *MeilisearchBackend) SetClientCert(certFile, keyFile string) error {
+	cert, err := LoadClientCert(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	m.client = newHTTPClient(m.Timeout, clientCertTransport(cert))
+	return nil
+}
+
+// Name returns the backend identifier
+func (m *MeilisearchBackend) Name() string {
+	return "meilisearch"
+}
+
+// IsAvailable checks if an instance and index are configured
+func (m *MeilisearchBackend) IsAvailable() bool {
+	return m.URL != "" && m.Index != ""
+}
+
+// meilisearchPeriods maps time ranges to how recent a document's date must be
+var meilisearchPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// body builds the search request for opts at now. AnyOf terms are added
+// as keywords; Meilisearch ranks documents with more of them first.
+func (m *MeilisearchBackend) body(opts SearchOptions, now time.Time) ([]byte, error) {
+	q := strings.Join(append([]string{phraseQuery(opts)}, nonEmpty(opts.AnyOf)...), " ")
+	start, end := pageBounds(opts)
+	req := map[string]any{
+		"q":      q,
+		"offset": start,
+		"limit":  end - start,
+		// Text around the matches makes a better snippet than the start
+		// of a long document
+		"attributesToCrop": []string{firstNonEmpty(m.ContentField, "content")},
+		"cropLength":       40,
+	}
+	if period, ok := meilisearchPeriods[opts.TimeRange]; ok && m.DateField != "" {
+		req["filter"] = fmt.Sprintf("%s >= %d", m.DateField, now.Add(-period).Unix())
+	}
+	return json.Marshal(req)
+}
+
+// meilisearchResponse is the search response, or the error, of the instance
+type meilisearchResponse struct {
+	Hits    []map[string]any `json:"hits"`
+	Message string           `json:"message"`
+	Code    string           `json:"code"`
+}
+
+// meilisearchDate returns a document date as RFC 3339: Unix timestamps
+// are converted, text is taken as it is
+func meilisearchDate(value any) string {
+	switch v := value.(type) {
+	case float64:
+		return time.Unix(int64(v), 0).UTC().Format(time.RFC3339)
+	case string:
+		return v
+	}
+	return ""
+}
+
+// Search performs a search on the configured index
+func (m *MeilisearchBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	if !m.IsAvailable() {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("Meilisearch url or index not configured"),
+			Code:    ErrCodeUnavailable,
+		}
+	}
+	payload, err := m.body(opts, time.Now())
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to build request: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	req, err := http.NewRequest("POST", m.URL+"/indexes/"+url.PathEscape(m.Index)+"/search", bytes.NewReader(payload))
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if m.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.APIKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("request failed: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var msResp meilisearchResponse
+	parseErr := json.Unmarshal(body, &msResp)
+
+	if resp.StatusCode != http.StatusOK {
+		message := strings.TrimSpace(msResp.Message)
+		if parseErr != nil || message == "" {
+			message = strings.TrimSpace(string(body))
+		}
+		switch resp.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return nil, &BackendError{
+				Backend: m.Name(),
+				Err:     fmt.Errorf("authentication failed: %s", message),
+				Code:    ErrCodeAuth,
+			}
+		case http.StatusTooManyRequests:
+			return nil, &BackendError{
+				Backend: m.Name(),
+				Err:     fmt.Errorf("rate limited: %s", message),
+				Code:    ErrCodeRateLimit,
+			}
+		default:
+			return nil, &BackendError{
+				Backend: m.Name(),
+				Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, message),
+				Code:    resp.StatusCode,
+			}
+		}
+	}
+	if parseErr != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", parseErr),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	titleField := firstNonEmpty(m.TitleField, "title")
+	urlField := firstNonEmpty(m.URLField, "url")
+	contentField := firstNonEmpty(m.ContentField, "content")
+	results := make([]SearchResult, 0, len(msResp.Hits))
+	for _, hit := range msResp.Hits {
+		id := sourceField(hit, "id")
+		title := firstNonEmpty(sourceField(hit, titleField), id)
+		content := sourceField(hit, contentField)
+		if formatted, ok := hit["_formatted"].(map[string]any); ok {
+			content = firstNonEmpty(sourceField(formatted, contentField), content)
+		}
+		if mentionsAny(title+" "+content, opts.NoneOf) {
+			continue
+		}
+		link := sourceField(hit, urlField)
+		if link == "" && id != "" {
+			// The document itself, for indexes of text without links
+			link = m.URL + "/indexes/" + url.PathEscape(m.Index) + "/documents/" + url.PathEscape(id)
+		}
+		result := SearchResult{
+			Title:    collapseSpace(title),
+			URL:      link,
+			Content:  collapseSpace(content),
+			Category: "general",
+			Metadata: m.Index,
+			Engine:   m.Name(),
+			Engines:  []string{m.Name()},
+		}
+		if m.DateField != "" {
+			result.PublishedDate = meilisearchDate(hit[m.DateField])
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
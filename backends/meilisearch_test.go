@@ -0,0 +1,125 @@
+package backends
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const meilisearchTestResponse = `{"hits": [
+	{"id": 7, "name": "Tax return 2023", "path": "file:///home/me/docs/tax-2023.pdf", "text": "The full text of the return ...", "modified": 1709985600,
+	 "_formatted": {"id": "7", "name": "Tax return 2023", "text": "…deductions for the home office…"}},
+	{"id": "note-1", "text": "A note without title or path"},
+	{"id": 9, "name": "Old draft", "text": "draft of the tax letter"}
+], "query": "tax", "processingTimeMs": 1, "limit": 5, "offset": 5, "estimatedTotalHits": 3}`
+
+func TestMeilisearchBackend_Search(t *testing.T) {
+	var body map[string]any
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/indexes/docs/search" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(meilisearchTestResponse))
+	}))
+	defer server.Close()
+
+	m := NewMeilisearchBackend(server.URL+"/", "docs", "s3arch", 10*time.Second)
+	m.TitleField = "name"
+	m.URLField = "path"
+	m.ContentField = "text"
+	m.DateField = "modified"
+	results, err := m.Search(SearchOptions{Query: "tax", Exact: []string{"home office"}, AnyOf: []string{"2023"}, NoneOf: []string{"draft"}, NumResults: 5, PageNo: 2, TimeRange: "year"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth != "Bearer s3arch" {
+		t.Errorf("unexpected Authorization %q", auth)
+	}
+	filter, _ := body["filter"].(string)
+	if body["q"] != `tax "home office" 2023` || body["offset"] != float64(5) || body["limit"] != float64(5) ||
+		!strings.HasPrefix(filter, "modified >= ") {
+		t.Errorf("unexpected body %v", body)
+	}
+	if crop, _ := body["attributesToCrop"].([]any); len(crop) != 1 || crop[0] != "text" {
+		t.Errorf("unexpected attributesToCrop %v", body["attributesToCrop"])
+	}
+	// The draft is left out by --none-of
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	first := results[0]
+	if first.Title != "Tax return 2023" || first.URL != "file:///home/me/docs/tax-2023.pdf" ||
+		first.Content != "…deductions for the home office…" || first.PublishedDate != "2024-03-09T12:00:00Z" ||
+		first.Metadata != "docs" || first.Engine != "meilisearch" {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	// Documents without a title or link are named and linked by their ID
+	second := results[1]
+	if second.Title != "note-1" || second.URL != server.URL+"/indexes/docs/documents/note-1" || second.Content != "A note without title or path" {
+		t.Errorf("unexpected second result %+v", second)
+	}
+}
+
+func TestMeilisearchBackend_Body(t *testing.T) {
+	m := NewMeilisearchBackend("http://localhost:7700", "docs", "", 0)
+	payload, err := m.body(SearchOptions{Query: "x", TimeRange: "week"}, time.Unix(1000000, 0))
+	if err != nil {
+		t.Fatalf("body failed: %v", err)
+	}
+	// Without a date field there is nothing to filter
+	if strings.Contains(string(payload), "filter") || !strings.Contains(string(payload), `"attributesToCrop":["content"]`) {
+		t.Errorf("unexpected body %s", payload)
+	}
+	m.DateField = "date"
+	payload, _ = m.body(SearchOptions{Query: "x", TimeRange: "week"}, time.Unix(1000000, 0))
+	var body struct {
+		Filter string `json:"filter"`
+	}
+	if json.Unmarshal(payload, &body); body.Filter != "date >= 395200" {
+		t.Errorf("unexpected filter %s", payload)
+	}
+}
+
+func TestMeilisearchBackend_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		code   int
+		msg    string
+	}{
+		{"auth", 403, `{"message": "The provided API key is invalid.", "code": "invalid_api_key", "type": "auth"}`, ErrCodeAuth, "API key is invalid"},
+		{"missing index", 404, `{"message": "Index ` + "`docs`" + ` not found.", "code": "index_not_found", "type": "invalid_request"}`, 404, "not found"},
+		{"bad filter", 400, `{"message": "Attribute ` + "`date`" + ` is not filterable.", "code": "invalid_search_filter"}`, 400, "not filterable"},
+		{"bad json", 200, `not json`, ErrCodeInvalidResponse, "failed to parse JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			m := NewMeilisearchBackend(server.URL, "docs", "", 0)
+			_, err := m.Search(SearchOptions{Query: "x"})
+			var backendErr *BackendError
+			if !errors.As(err, &backendErr) || backendErr.Code != tt.code || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("got %v, want code %d with %q", err, tt.code, tt.msg)
+			}
+		})
+	}
+
+	if _, err := NewMeilisearchBackend("", "", "", 0).Search(SearchOptions{Query: "x"}); err == nil {
+		t.Error("expected an error without an instance")
+	}
+}
@@ -0,0 +1,218 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MojeekBackend implements SearchBackend for the Mojeek Search API, an
+// independent index with its own crawler
+type MojeekBackend struct {
+	APIKey  string
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewMojeekBackend creates a new Mojeek backend
+func NewMojeekBackend(apiKey string, timeout time.Duration) *MojeekBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &MojeekBackend{
+		APIKey:  apiKey,
+		Timeout: timeout,
+		BaseURL: "https://api.mojeek.com/search",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (m *MojeekBackend) Name() string {
+	return "mojeek"
+}
+
+// IsAvailable checks if the API key is configured
+func (m *MojeekBackend) IsAvailable() bool {
+	return m.APIKey != ""
+}
+
+// mojeekResponse is the Mojeek Search API response
+type mojeekResponse struct {
+	Response struct {
+		Status  string `json:"status"` // "OK" or "ERROR: ..."
+		Results []struct {
+			Title string          `json:"title"`
+			URL   string          `json:"url"`
+			Desc  string          `json:"desc"`
+			PDate json.RawMessage `json:"pdate"` // published, see mojeekDate
+			Image struct {
+				URL string `json:"url"`
+			} `json:"image"`
+		} `json:"results"`
+	} `json:"response"`
+}
+
+// mojeekDate reads a publication date, which Mojeek gives as a Unix time
+// or as a string; zero means unknown.
+func mojeekDate(raw json.RawMessage) string {
+	var unix int64
+	if json.Unmarshal(raw, &unix) == nil {
+		if unix <= 0 {
+			return ""
+		}
+		return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+	}
+	var date string
+	if json.Unmarshal(raw, &date) == nil {
+		return strings.TrimSpace(date)
+	}
+	return ""
+}
+
+// mojeekSince maps time ranges to how far back the since parameter goes
+var mojeekSince = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// params builds the request parameters for opts at now.
+func (m *MojeekBackend) params(opts SearchOptions, now time.Time) url.Values {
+	num := opts.NumResults
+	if num <= 0 {
+		num = 10
+	}
+	params := url.Values{}
+	params.Set("api_key", m.APIKey)
+	params.Set("fmt", "json")
+	params.Set("q", excludeSiteQuery(siteQuery(ComposeQuery(opts), opts.Sites), opts.ExcludeSites))
+	params.Set("t", strconv.Itoa(num))
+	// s is the 1-based rank of the first result
+	if opts.PageNo > 1 {
+		params.Set("s", strconv.Itoa((opts.PageNo-1)*num+1))
+	}
+	if opts.SafeSearch != "none" {
+		params.Set("safe", "1")
+	}
+	if period, ok := mojeekSince[opts.TimeRange]; ok {
+		params.Set("since", now.Add(-period).Format("20060102"))
+	}
+	// Language and region bias results rather than restrict them
+	lang, country := googleLocale(opts.Language, opts.Country)
+	if lang != "" {
+		params.Set("lb", strings.ToUpper(strings.TrimPrefix(lang, "lang_")))
+	}
+	if country != "" {
+		params.Set("rb", strings.ToUpper(country))
+	}
+	return params
+}
+
+// Search performs a search against the Mojeek Search API
+func (m *MojeekBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	if !m.IsAvailable() {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("Mojeek API key not configured"),
+			Code:    ErrCodeUnavailable,
+		}
+	}
+
+	req, err := http.NewRequest("GET", m.BaseURL+"?"+m.params(opts, time.Now()).Encode(), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			// The request URL carries the API key
+			Err:  fmt.Errorf("request failed: %s", redactKey(err.Error(), m.APIKey)),
+			Code: ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var mojeekResp mojeekResponse
+	parseErr := json.Unmarshal(body, &mojeekResp)
+
+	// Errors come as a status message, with or without an HTTP error
+	status := mojeekResp.Response.Status
+	if resp.StatusCode != http.StatusOK || parseErr == nil && status != "" && status != "OK" {
+		message := strings.TrimSpace(strings.TrimPrefix(status, "ERROR:"))
+		if message == "" {
+			message = strings.TrimSpace(string(body))
+		}
+		lower := strings.ToLower(message)
+		switch {
+		case resp.StatusCode == 429 || strings.Contains(lower, "limit"):
+			return nil, &BackendError{
+				Backend: m.Name(),
+				Err:     fmt.Errorf("rate limited: %s", message),
+				Code:    ErrCodeRateLimit,
+			}
+		case resp.StatusCode == 401 || resp.StatusCode == 403 || strings.Contains(lower, "api key"):
+			return nil, &BackendError{
+				Backend: m.Name(),
+				Err:     fmt.Errorf("authentication failed: %s", message),
+				Code:    ErrCodeAuth,
+			}
+		case resp.StatusCode != http.StatusOK:
+			return nil, &BackendError{
+				Backend: m.Name(),
+				Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, message),
+				Code:    resp.StatusCode,
+			}
+		default:
+			return nil, &BackendError{
+				Backend: m.Name(),
+				Err:     fmt.Errorf("%s", message),
+				Code:    ErrCodeInvalidResponse,
+			}
+		}
+	}
+	if parseErr != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", parseErr),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	results := make([]SearchResult, len(mojeekResp.Response.Results))
+	for i, r := range mojeekResp.Response.Results {
+		results[i] = SearchResult{
+			Title:         r.Title,
+			URL:           r.URL,
+			Content:       strings.TrimSpace(r.Desc),
+			ThumbnailSrc:  r.Image.URL,
+			PublishedDate: mojeekDate(r.PDate),
+			Engine:        m.Name(),
+			Engines:       []string{m.Name()},
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,106 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMojeekBackend_Params(t *testing.T) {
+	m := NewMojeekBackend("key", 0)
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	params := m.params(SearchOptions{Query: "golang", Sites: []string{"go.dev"}, PageNo: 3, NumResults: 20, TimeRange: "week", Language: "de-AT"}, now)
+	want := map[string]string{
+		"api_key": "key", "fmt": "json", "q": "site:go.dev golang", "t": "20", "s": "41",
+		"safe": "1", "since": "20240302", "lb": "DE", "rb": "AT",
+	}
+	for k, v := range want {
+		if got := params.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+	if len(params) != len(want) {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	params = m.params(SearchOptions{Query: "golang", SafeSearch: "none"}, now)
+	if params.Get("t") != "10" || params.Has("s") || params.Has("safe") || params.Has("since") || params.Has("lb") {
+		t.Errorf("defaults: %v", params)
+	}
+}
+
+func TestMojeekBackend_Search(t *testing.T) {
+	var got url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"response": {"status": "OK", "head": {"query": "golang", "results": 2, "start": 1, "total": 1234},
+			"results": [
+				{"title": "The Go Programming Language", "url": "https://go.dev/", "desc": " Go is an open source language. ", "pdate": 1709942400,
+				 "image": {"url": "https://img.example/go.png"}},
+				{"title": "Go (programming language)", "url": "https://en.wikipedia.org/wiki/Go_(programming_language)", "desc": "Go is...", "pdate": 0}
+			]}}`))
+	}))
+	defer server.Close()
+
+	m := NewMojeekBackend("test-key", 10*time.Second)
+	m.BaseURL = server.URL
+	results, err := m.Search(SearchOptions{Query: "golang", NumResults: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Get("t") != "2" || got.Get("fmt") != "json" {
+		t.Errorf("unexpected request %v", got)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	first := results[0]
+	if first.Title != "The Go Programming Language" || first.Content != "Go is an open source language." || first.Engine != "mojeek" {
+		t.Errorf("unexpected result %+v", first)
+	}
+	if first.PublishedDate != "2024-03-09T00:00:00Z" || first.ThumbnailSrc != "https://img.example/go.png" {
+		t.Errorf("date/image not mapped: %+v", first)
+	}
+	if results[1].PublishedDate != "" {
+		t.Errorf("zero pdate = %q", results[1].PublishedDate)
+	}
+}
+
+func TestMojeekBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusOK, `{"response": {"status": "ERROR: Invalid API key"}}`, ErrCodeAuth},
+		{http.StatusOK, `{"response": {"status": "ERROR: Daily request limit reached"}}`, ErrCodeRateLimit},
+		{http.StatusOK, `{"response": {"status": "ERROR: Query missing"}}`, ErrCodeInvalidResponse},
+		{http.StatusForbidden, `Forbidden`, ErrCodeAuth},
+		{http.StatusInternalServerError, `oops`, http.StatusInternalServerError},
+		{http.StatusOK, `not json`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		m := NewMojeekBackend("test-key", 10*time.Second)
+		m.BaseURL = server.URL
+		_, err := m.Search(SearchOptions{Query: "test"})
+		server.Close()
+		backendErr, ok := err.(*BackendError)
+		if !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		} else if strings.Contains(err.Error(), "test-key") {
+			t.Errorf("error leaks the API key: %v", err)
+		}
+	}
+
+	if _, err := NewMojeekBackend("", 0).Search(SearchOptions{Query: "test"}); err == nil {
+		t.Error("searched without an API key")
+	}
+}
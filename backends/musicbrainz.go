@@ -0,0 +1,322 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MusicBrainzBackend implements SearchBackend for recordings, that is
+// tracks, in the MusicBrainz database, which needs no key. Each result
+// carries its artist, album and length.
+type MusicBrainzBackend struct {
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewMusicBrainzBackend creates a new MusicBrainz backend
+func NewMusicBrainzBackend(timeout time.Duration) *MusicBrainzBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &MusicBrainzBackend{
+		Timeout: timeout,
+		BaseURL: "https://musicbrainz.org",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (m *MusicBrainzBackend) Name() string {
+	return "musicbrainz"
+}
+
+// IsAvailable reports true: the MusicBrainz API needs no key
+func (m *MusicBrainzBackend) IsAvailable() bool {
+	return true
+}
+
+// projectUserAgent identifies sx and where to find it, for APIs such as
+// MusicBrainz and LRCLIB that ask clients to name themselves
+const projectUserAgent = "sx ( https://github.com/byteowlz/sx )"
+
+// musicbrainzResponse is the MusicBrainz recording search response
+type musicbrainzResponse struct {
+	Recordings []struct {
+		ID               string  `json:"id"`
+		Score            float64 `json:"score"`
+		Title            string  `json:"title"`
+		Length           float64 `json:"length"` // milliseconds
+		Disambiguation   string  `json:"disambiguation"`
+		FirstReleaseDate string  `json:"first-release-date"`
+		ArtistCredit     []struct {
+			Name       string `json:"name"`
+			JoinPhrase string `json:"joinphrase"`
+		} `json:"artist-credit"`
+		Releases []struct {
+			Title        string `json:"title"`
+			Status       string `json:"status"`
+			ReleaseGroup struct {
+				PrimaryType string `json:"primary-type"`
+			} `json:"release-group"`
+		} `json:"releases"`
+		Tags []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
+	} `json:"recordings"`
+	Error string `json:"error"`
+}
+
+// musicbrainzPeriods maps time ranges to how recently a recording must
+// first have been released
+var musicbrainzPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// musicbrainzFields are the recording fields a query word may name, as in
+// artist:queen
+var musicbrainzFields = map[string]bool{
+	"artist": true, "artistname": true, "recording": true, "release": true,
+	"tag": true, "isrc": true, "date": true, "firstreleasedate": true,
+	"country": true, "primarytype": true, "status": true, "dur": true,
+}
+
+// musicbrainzQuery renders opts in the Lucene syntax of MusicBrainz
+// searches. Query words only rank the recordings, which match any of
+// them; builder terms are required or excluded. Quoted phrases and
+// field:value words pass through, other words have Lucene's special
+// characters escaped.
+func musicbrainzQuery(opts SearchOptions, now time.Time) string {
+	var parts []string
+	for _, token := range arxivToken.FindAllString(opts.Query, -1) {
+		field, value, found := strings.Cut(token, ":")
+		switch {
+		case found && value != "" && musicbrainzFields[strings.ToLower(field)]:
+			parts = append(parts, strings.ToLower(field)+":"+luceneTerm(value))
+		case strings.HasPrefix(token, `"`):
+			if phrase := strings.TrimSpace(strings.Trim(token, `"`)); phrase != "" {
+				parts = append(parts, quotePhrase(phrase))
+			}
+		default:
+			parts = append(parts, luceneTerm(token))
+		}
+	}
+	for _, phrase := range opts.Exact {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			parts = append(parts, "+"+quotePhrase(phrase))
+		}
+	}
+	for _, term := range opts.AllOf {
+		if term = strings.TrimSpace(term); term != "" {
+			parts = append(parts, "+"+luceneTerm(term))
+		}
+	}
+	var anyOf []string
+	for _, term := range opts.AnyOf {
+		if term = strings.TrimSpace(term); term != "" {
+			anyOf = append(anyOf, luceneTerm(term))
+		}
+	}
+	if len(anyOf) > 0 {
+		parts = append(parts, "+("+strings.Join(anyOf, " OR ")+")")
+	}
+	for _, term := range opts.NoneOf {
+		if term = strings.TrimSpace(term); term != "" {
+			parts = append(parts, "-"+luceneTerm(term))
+		}
+	}
+	if period, ok := musicbrainzPeriods[opts.TimeRange]; ok {
+		parts = append(parts, "+firstreleasedate:["+now.Add(-period).UTC().Format("2006-01-02")+" TO *]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// luceneTerm escapes Lucene's special characters in term, quoting it if
+// it has several words
+func luceneTerm(term string) string {
+	if strings.ContainsAny(term, " \t") {
+		return quotePhrase(term)
+	}
+	var b strings.Builder
+	for _, r := range term {
+		if strings.ContainsRune(`+-&|!(){}[]^"~*?:\/`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// onMusicBrainz reports whether the site filters of opts leave
+// musicbrainz.org in
+func onMusicBrainz(opts SearchOptions) bool {
+	sites := nonEmpty(opts.Sites)
+	return (len(sites) == 0 || onAnySite("musicbrainz.org", sites)) && !onAnySite("musicbrainz.org", opts.ExcludeSites)
+}
+
+// params builds the request parameters for opts at now
+func (m *MusicBrainzBackend) params(opts SearchOptions, now time.Time) url.Values {
+	num := opts.NumResults
+	if num <= 0 {
+		num = 10
+	}
+	params := url.Values{}
+	params.Set("query", musicbrainzQuery(opts, now))
+	params.Set("limit", strconv.Itoa(min(num, 100)))
+	if opts.PageNo > 1 {
+		params.Set("offset", strconv.Itoa((opts.PageNo-1)*num))
+	}
+	params.Set("fmt", "json")
+	return params
+}
+
+// Search performs a search against the MusicBrainz API
+func (m *MusicBrainzBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	if !onMusicBrainz(opts) {
+		return []SearchResult{}, nil
+	}
+
+	req, err := http.NewRequest("GET", m.BaseURL+"/ws/2/recording?"+m.params(opts, time.Now()).Encode(), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", projectUserAgent)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("request failed: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var mbResp musicbrainzResponse
+	parseErr := json.Unmarshal(body, &mbResp)
+
+	switch {
+	// MusicBrainz answers 503 to clients over one request a second
+	case resp.StatusCode == 503 || resp.StatusCode == 429:
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("rate limited: %s", strings.TrimSpace(string(body))),
+			Code:    ErrCodeRateLimit,
+		}
+	case resp.StatusCode != http.StatusOK:
+		message := strings.TrimSpace(mbResp.Error)
+		if parseErr != nil || message == "" {
+			message = strings.TrimSpace(string(body))
+		}
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, message),
+			Code:    resp.StatusCode,
+		}
+	case parseErr != nil:
+		return nil, &BackendError{
+			Backend: m.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", parseErr),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	results := make([]SearchResult, 0, len(mbResp.Recordings))
+	for _, rec := range mbResp.Recordings {
+		if rec.ID == "" {
+			continue
+		}
+		var artist strings.Builder
+		for _, credit := range rec.ArtistCredit {
+			artist.WriteString(credit.Name + credit.JoinPhrase)
+		}
+
+		// The album is the first official release, else the first at all
+		var album, albumType string
+		for i, release := range rec.Releases {
+			if i == 0 || release.Status == "Official" {
+				album, albumType = release.Title, release.ReleaseGroup.PrimaryType
+			}
+			if release.Status == "Official" {
+				break
+			}
+		}
+
+		var facts []string
+		if albumType != "" {
+			facts = append(facts, albumType)
+		}
+		for _, tag := range rec.Tags {
+			if len(facts) >= 4 {
+				break
+			}
+			facts = append(facts, tag.Name)
+		}
+
+		title := collapseSpace(rec.Title)
+		if rec.Disambiguation != "" {
+			title += " (" + collapseSpace(rec.Disambiguation) + ")"
+		}
+		result := SearchResult{
+			Title:         title,
+			URL:           m.BaseURL + "/recording/" + rec.ID,
+			Content:       musicbrainzSummary(strings.TrimSpace(artist.String()), album, rec.FirstReleaseDate),
+			Category:      "music",
+			PublishedDate: rec.FirstReleaseDate,
+			Author:        strings.TrimSpace(artist.String()),
+			Album:         collapseSpace(album),
+			Metadata:      strings.Join(facts, " · "),
+			Score:         rec.Score,
+			Engine:        m.Name(),
+			Engines:       []string{m.Name()},
+		}
+		if rec.Length > 0 {
+			result.Length = rec.Length / 1000
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// musicbrainzSummary describes a recording in a sentence for its snippet,
+// e.g. "By Queen, on A Night at the Opera, 1975."
+func musicbrainzSummary(artist, album, released string) string {
+	var parts []string
+	if artist != "" {
+		parts = append(parts, "by "+artist)
+	}
+	if album != "" {
+		parts = append(parts, "on "+album)
+	}
+	if year, _, _ := strings.Cut(released, "-"); year != "" {
+		parts = append(parts, year)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	summary := strings.Join(parts, ", ") + "."
+	return strings.ToUpper(summary[:1]) + summary[1:]
+}
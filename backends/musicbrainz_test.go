@@ -0,0 +1,115 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const musicbrainzTestResponse = `{"created": "2024-03-09T12:00:00.000Z", "count": 2, "offset": 0, "recordings": [
+	{"id": "b1a9c0e9-d987-4042-ae91-78d6a3267d69", "score": 100, "title": "Bohemian Rhapsody", "length": 355000,
+	 "first-release-date": "1975-10-31",
+	 "artist-credit": [{"name": "Queen", "joinphrase": "", "artist": {"id": "0383dadf", "name": "Queen"}}],
+	 "releases": [{"title": "Greatest Hits", "status": "Bootleg", "release-group": {"primary-type": "Album"}},
+	              {"title": "A Night at the Opera", "status": "Official", "release-group": {"primary-type": "Album"}}],
+	 "tags": [{"count": 3, "name": "rock"}, {"count": 1, "name": "progressive rock"}]},
+	{"id": "c2", "score": 80, "title": "Under Pressure", "disambiguation": "live",
+	 "artist-credit": [{"name": "Queen", "joinphrase": " & "}, {"name": "David Bowie"}]},
+	{"title": "No ID"}
+]}`
+
+func TestMusicBrainzQuery(t *testing.T) {
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want string
+	}{
+		{"plain", SearchOptions{Query: "bohemian rhapsody"}, "bohemian rhapsody"},
+		{"escaped", SearchOptions{Query: "AC/DC thunder!"}, `AC\/DC thunder\!`},
+		{"fields and phrases", SearchOptions{Query: `artist:Queen "under pressure" unknown:x`}, `artist:Queen "under pressure" unknown\:x`},
+		{"builder", SearchOptions{Query: "song", Exact: []string{"killer queen"}, AllOf: []string{"live"}, AnyOf: []string{"1974", "1975"}, NoneOf: []string{"remix"}},
+			`song +"killer queen" +live +(1974 OR 1975) -remix`},
+		{"time range", SearchOptions{Query: "song", TimeRange: "month"}, "song +firstreleasedate:[2024-02-08 TO *]"},
+	}
+	for _, tt := range tests {
+		if got := musicbrainzQuery(tt.opts, now); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMusicBrainzBackend_Search(t *testing.T) {
+	var path, agent string
+	var query url.Values
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		path, query, agent = r.URL.Path, r.URL.Query(), r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(musicbrainzTestResponse))
+	}))
+	defer server.Close()
+
+	m := NewMusicBrainzBackend(10 * time.Second)
+	m.BaseURL = server.URL
+	results, err := m.Search(SearchOptions{Query: "bohemian rhapsody", NumResults: 5, PageNo: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/ws/2/recording" || query.Get("query") != "bohemian rhapsody" || query.Get("limit") != "5" ||
+		query.Get("offset") != "10" || query.Get("fmt") != "json" || agent != projectUserAgent {
+		t.Errorf("unexpected request %s %v %q", path, query, agent)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	first := results[0]
+	if first.Title != "Bohemian Rhapsody" || first.URL != server.URL+"/recording/b1a9c0e9-d987-4042-ae91-78d6a3267d69" ||
+		first.Author != "Queen" || first.Album != "A Night at the Opera" || first.Length != 355.0 ||
+		first.PublishedDate != "1975-10-31" || first.Category != "music" || first.Score != 100 || first.Engine != "musicbrainz" {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	if first.Content != "By Queen, on A Night at the Opera, 1975." || first.Metadata != "Album · rock · progressive rock" {
+		t.Errorf("content %q, metadata %q", first.Content, first.Metadata)
+	}
+	if second := results[1]; second.Title != "Under Pressure (live)" || second.Author != "Queen & David Bowie" ||
+		second.Album != "" || second.Length != nil || second.Content != "By Queen & David Bowie." {
+		t.Errorf("unexpected second result %+v", second)
+	}
+
+	if results, err := m.Search(SearchOptions{Query: "x", Sites: []string{"discogs.com"}}); err != nil || len(results) != 0 {
+		t.Errorf("another site: %d results, %v", len(results), err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestMusicBrainzBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusServiceUnavailable, `{"error": "Your requests are exceeding the allowable rate limit."}`, ErrCodeRateLimit},
+		{http.StatusBadRequest, `{"error": "Invalid query"}`, http.StatusBadRequest},
+		{http.StatusOK, `not json`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		m := NewMusicBrainzBackend(10 * time.Second)
+		m.BaseURL = server.URL
+		_, err := m.Search(SearchOptions{Query: "test"})
+		server.Close()
+		if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		}
+	}
+}
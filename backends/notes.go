@@ -0,0 +1,474 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// maxNoteText caps how much of a note's text the index keeps
+const maxNoteText = 32 << 10
+
+// noteExtensions are the files the notes backend indexes
+var noteExtensions = map[string]bool{
+	".md":       true,
+	".markdown": true,
+	".org":      true,
+}
+
+// Note is a notes file as the index keeps it
+type Note struct {
+	Path     string    `json:"path"` // relative to the notes directory
+	Title    string    `json:"title"`
+	Headings []string  `json:"headings,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+	Text     string    `json:"text"` // plain text, whitespace collapsed
+	Modified time.Time `json:"modified"`
+	Size     int64     `json:"size"`
+}
+
+// NoteIndex is the notes backend's index of a notes directory, kept up to
+// date with the files on each search
+type NoteIndex struct {
+	Dir   string `json:"dir"`
+	Notes []Note `json:"notes"`
+}
+
+// NotesBackend implements SearchBackend over a directory of Markdown and
+// Org notes
+type NotesBackend struct {
+	Dir       string // the notes directory
+	IndexPath string // where the index is kept
+}
+
+// NewNotesBackend creates a notes backend searching dir, keeping its index
+// at indexPath
+func NewNotesBackend(dir, indexPath string) *NotesBackend {
+	return &NotesBackend{Dir: dir, IndexPath: indexPath}
+}
+
+// Name returns the backend identifier
+func (n *NotesBackend) Name() string {
+	return "notes"
+}
+
+// IsAvailable checks if the notes directory is configured and exists
+func (n *NotesBackend) IsAvailable() bool {
+	if n.Dir == "" {
+		return false
+	}
+	info, err := os.Stat(n.Dir)
+	return err == nil && info.IsDir()
+}
+
+// noteMatch is a note that matches the query, with its score
+type noteMatch struct {
+	note  Note
+	score int
+}
+
+// Search finds the notes that contain every query term in their title,
+// headings, tags, text or path. Title matches count more than heading and
+// tag matches, which count more than the text; equal matches list recently
+// changed notes first. Notes are on no site, so a site filter leaves none.
+func (n *NotesBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	if !n.IsAvailable() {
+		msg := "no notes directory configured; set dir in [engines_notes]"
+		if n.Dir != "" {
+			msg = fmt.Sprintf("notes directory %s not found", n.Dir)
+		}
+		return nil, &BackendError{
+			Backend: n.Name(),
+			Err:     fmt.Errorf("%s", msg),
+			Code:    ErrCodeUnavailable,
+		}
+	}
+	index, err := RefreshNoteIndex(n.Dir, n.IndexPath)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: n.Name(),
+			Err:     err,
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+	if len(opts.Sites) > 0 {
+		return []SearchResult{}, nil
+	}
+
+	var since time.Time
+	if period, ok := bookmarkPeriods[opts.TimeRange]; ok {
+		since = time.Now().Add(-period)
+	}
+	var matches []noteMatch
+	for _, note := range index.Notes {
+		if !since.IsZero() && note.Modified.Before(since) {
+			continue
+		}
+		if score, ok := noteScore(note, opts); ok {
+			matches = append(matches, noteMatch{note, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		return a.note.Modified.After(b.note.Modified)
+	})
+
+	num := opts.NumResults
+	if num <= 0 {
+		num = 10
+	}
+	start := 0
+	if opts.PageNo > 1 {
+		start = (opts.PageNo - 1) * num
+	}
+	if start >= len(matches) {
+		return []SearchResult{}, nil
+	}
+	matches = matches[start:min(start+num, len(matches))]
+
+	results := make([]SearchResult, len(matches))
+	for i, m := range matches {
+		path := filepath.Join(n.Dir, filepath.FromSlash(m.note.Path))
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		results[i] = SearchResult{
+			Title:         m.note.Title,
+			URL:           (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String(),
+			Content:       noteSnippet(m.note, noteTerms(opts)),
+			Source:        m.note.Path,
+			Category:      "general",
+			PublishedDate: m.note.Modified.UTC().Format(time.RFC3339),
+			Score:         float64(m.score),
+			Engine:        n.Name(),
+			Engines:       []string{n.Name()},
+		}
+	}
+	return results, nil
+}
+
+// noteScore scores how well note matches the query and builder terms,
+// reporting false when it doesn't match.
+func noteScore(note Note, opts SearchOptions) (int, bool) {
+	title := strings.ToLower(note.Title)
+	labels := strings.ToLower(strings.Join(note.Headings, " ") + " " + strings.Join(note.Tags, " "))
+	text := strings.ToLower(note.Text + " " + note.Path)
+	return termScore(opts, func(term string) int {
+		term = strings.ToLower(term)
+		switch {
+		case strings.Contains(title, term):
+			return 3
+		case strings.Contains(labels, term):
+			return 2
+		case strings.Contains(text, term):
+			return 1
+		}
+		return 0
+	})
+}
+
+// noteTerms are the terms a note snippet is centered on
+func noteTerms(opts SearchOptions) []string {
+	terms := append([]string{}, opts.Exact...)
+	for _, word := range strings.Fields(opts.Query) {
+		if word = strings.Trim(word, `"'`); word != "" {
+			terms = append(terms, word)
+		}
+	}
+	terms = append(terms, opts.AllOf...)
+	return append(terms, opts.AnyOf...)
+}
+
+// noteSnippet is the part of note's text around the first of terms found
+// in it, or its beginning
+func noteSnippet(note Note, terms []string) string {
+	const before, after = 80, 200
+	text := []rune(note.Text)
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+	at := -1
+	for _, term := range terms {
+		if i := runeIndex(lower, []rune(strings.ToLower(term))); i >= 0 {
+			at = i
+			break
+		}
+	}
+	start := 0
+	if at > before {
+		start = at - before
+		// Start at a word
+		for start < at && !unicode.IsSpace(text[start]) {
+			start++
+		}
+	}
+	end := min(max(at, 0)+after, len(text))
+	if end < len(text) {
+		for end > start && !unicode.IsSpace(text[end]) {
+			end--
+		}
+	}
+	snippet := strings.TrimSpace(string(text[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// runeIndex returns the index of the first sub in s, -1 if there is none
+func runeIndex(s, sub []rune) int {
+	if len(sub) == 0 {
+		return -1
+	}
+	for i := 0; i+len(sub) <= len(s); i++ {
+		match := true
+		for j, r := range sub {
+			if s[i+j] != r {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// RefreshNoteIndex brings the index at indexPath up to date with the notes
+// in dir: notes added or changed since it was written are read, removed
+// ones dropped, and the index is rewritten if anything changed. Without an
+// indexPath every note is read each time.
+func RefreshNoteIndex(dir, indexPath string) (*NoteIndex, error) {
+	old := make(map[string]Note)
+	if data, err := os.ReadFile(indexPath); indexPath != "" && err == nil {
+		var index NoteIndex
+		// An unreadable index, or one of another directory, is rebuilt
+		if json.Unmarshal(data, &index) == nil && index.Dir == dir {
+			for _, note := range index.Notes {
+				old[note.Path] = note
+			}
+		}
+	}
+
+	index := &NoteIndex{Dir: dir, Notes: []Note{}}
+	changed := false
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skip hidden directories and files, e.g. .git and .obsidian
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !noteExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		note, ok := old[rel]
+		delete(old, rel)
+		if !ok || !note.Modified.Equal(info.ModTime()) || note.Size != info.Size() {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			note = parseNote(rel, string(data))
+			note.Modified = info.ModTime()
+			note.Size = info.Size()
+			changed = true
+		}
+		index.Notes = append(index.Notes, note)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(old) > 0 {
+		changed = true
+	}
+	if changed && indexPath != "" {
+		if err := writeNoteIndex(indexPath, index); err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
+
+// writeNoteIndex replaces the index file, through a temporary file so an
+// interrupted run can't leave it truncated.
+func writeNoteIndex(path string, index *NoteIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// parseNote reads the title, headings, tags and text of a Markdown or Org
+// note. The title comes from front matter or #+title, else the first
+// heading, else the file name.
+func parseNote(path, content string) Note {
+	note := Note{Path: path}
+	org := strings.EqualFold(filepath.Ext(path), ".org")
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if !org {
+		lines = parseFrontMatter(lines, &note)
+	}
+
+	var text []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case org && strings.HasPrefix(trimmed, "#+"):
+			key, value, _ := strings.Cut(trimmed[2:], ":")
+			switch strings.ToLower(key) {
+			case "title":
+				if note.Title == "" {
+					note.Title = strings.TrimSpace(value)
+				}
+			case "filetags":
+				note.Tags = append(note.Tags, orgTags(value)...)
+			}
+			continue
+		case org && strings.HasPrefix(line, "*"):
+			heading := strings.TrimLeft(line, "*")
+			if heading == "" || heading[0] != ' ' {
+				break
+			}
+			heading = strings.TrimSpace(heading)
+			// Trailing :tag:tag: belong to the heading
+			if i := strings.LastIndex(heading, " :"); i >= 0 && strings.HasSuffix(heading, ":") {
+				note.Tags = append(note.Tags, orgTags(heading[i+1:])...)
+				heading = strings.TrimSpace(heading[:i])
+			}
+			note.Headings = append(note.Headings, heading)
+			trimmed = heading
+		case !org && strings.HasPrefix(trimmed, "#"):
+			heading := strings.TrimLeft(trimmed, "#")
+			if heading == "" || heading[0] != ' ' {
+				break
+			}
+			heading = strings.TrimSpace(strings.TrimRight(heading, "#"))
+			note.Headings = append(note.Headings, heading)
+			trimmed = heading
+		}
+		if trimmed != "" {
+			text = append(text, trimmed)
+		}
+	}
+
+	if note.Title == "" && len(note.Headings) > 0 {
+		note.Title = note.Headings[0]
+	}
+	if note.Title == "" {
+		note.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	note.Text = strings.Join(strings.Fields(strings.Join(text, " ")), " ")
+	if len(note.Text) > maxNoteText {
+		cut := maxNoteText
+		// Don't split a UTF-8 sequence
+		for cut > 0 && note.Text[cut]&0xc0 == 0x80 {
+			cut--
+		}
+		note.Text = note.Text[:cut]
+	}
+	return note
+}
+
+// parseFrontMatter reads title and tags from the YAML front matter at the
+// start of a Markdown note, returning the lines after it
+func parseFrontMatter(lines []string, note *Note) []string {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return lines
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if t := strings.TrimSpace(lines[i]); t == "---" || t == "..." {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return lines
+	}
+	inTags := false
+	for _, line := range lines[1:end] {
+		key, value, found := strings.Cut(line, ":")
+		if inTags && strings.HasPrefix(strings.TrimSpace(line), "- ") {
+			note.Tags = append(note.Tags, unquote(strings.TrimPrefix(strings.TrimSpace(line), "- ")))
+			continue
+		}
+		inTags = false
+		if !found || strings.HasPrefix(line, " ") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "title":
+			note.Title = unquote(value)
+		case "tags", "keywords":
+			if value == "" {
+				inTags = true
+				continue
+			}
+			for _, tag := range strings.Split(strings.Trim(value, "[]"), ",") {
+				if tag = unquote(tag); tag != "" {
+					note.Tags = append(note.Tags, tag)
+				}
+			}
+		}
+	}
+	return lines[end+1:]
+}
+
+// unquote trims space and YAML quotes around value
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return value
+}
+
+// orgTags splits Org tags written as :tag1:tag2:
+func orgTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(strings.TrimSpace(value), ":") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,161 @@
+package backends
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeNote(t *testing.T, dir, name, content string, modified time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseNote(t *testing.T) {
+	md := parseNote("go/errors.md", "---\ntitle: \"Error handling\"\ntags:\n  - golang\n  - errors\ndraft: true\n---\n# Wrapping\n\nUse `fmt.Errorf` with %w.\n\n## Joining ##\nerrors.Join\n")
+	if md.Title != "Error handling" || !reflect.DeepEqual(md.Tags, []string{"golang", "errors"}) ||
+		!reflect.DeepEqual(md.Headings, []string{"Wrapping", "Joining"}) ||
+		md.Text != "Wrapping Use `fmt.Errorf` with %w. Joining errors.Join" {
+		t.Errorf("markdown: %+v", md)
+	}
+	if md := parseNote("inbox.md", "---\ntags: [a, 'b']\n---\n#hashtag only\n"); md.Title != "inbox" || !reflect.DeepEqual(md.Tags, []string{"a", "b"}) || md.Headings != nil {
+		t.Errorf("untitled markdown: %+v", md)
+	}
+
+	org := parseNote("journal.org", "#+title: Journal\n#+filetags: :personal:log:\n* Monday :work:\nShipped the release.\n** TODO Review\n*bold* text\n")
+	if org.Title != "Journal" || !reflect.DeepEqual(org.Tags, []string{"personal", "log", "work"}) ||
+		!reflect.DeepEqual(org.Headings, []string{"Monday", "TODO Review"}) ||
+		org.Text != "Monday Shipped the release. TODO Review *bold* text" {
+		t.Errorf("org: %+v", org)
+	}
+
+	long := parseNote("long.md", strings.Repeat("é", maxNoteText))
+	if len(long.Text) > maxNoteText || !strings.HasSuffix(long.Text, "é") {
+		t.Errorf("long note cut to %d bytes", len(long.Text))
+	}
+}
+
+func TestRefreshNoteIndex(t *testing.T) {
+	dir := t.TempDir()
+	indexPath := filepath.Join(t.TempDir(), "state", "notes-index.json")
+	then := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	writeNote(t, dir, "a.md", "# A\nfirst", then)
+	writeNote(t, dir, "sub/b.org", "* B\nsecond", then)
+	writeNote(t, dir, "c.txt", "not a note", then)
+	writeNote(t, dir, ".obsidian/d.md", "# Hidden", then)
+
+	index, err := RefreshNoteIndex(dir, indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, note := range index.Notes {
+		paths = append(paths, note.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{"a.md", "sub/b.org"}) {
+		t.Fatalf("indexed %q", paths)
+	}
+	if _, err := os.Stat(indexPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged notes are taken from the index, which isn't rewritten
+	os.Chtimes(indexPath, then, then)
+	if _, err := RefreshNoteIndex(dir, indexPath); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(indexPath); !info.ModTime().Equal(then) {
+		t.Error("index rewritten with no notes changed")
+	}
+
+	writeNote(t, dir, "a.md", "# A\nchanged", then.Add(time.Hour))
+	os.Remove(filepath.Join(dir, "sub", "b.org"))
+	index, err = RefreshNoteIndex(dir, indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Notes) != 1 || index.Notes[0].Text != "A changed" {
+		t.Errorf("after changes: %+v", index.Notes)
+	}
+}
+
+func TestNotesBackend_Search(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeNote(t, dir, "go.md", "# Go notes\nChannels and goroutines.", now.Add(-400*24*time.Hour))
+	writeNote(t, dir, "rust.md", "---\ntags: [concurrency]\n---\n# Rust\nChannels in Rust, unlike go.", now)
+	writeNote(t, dir, "cooking.org", "* Bread\nFlour, water, salt.", now)
+	n := NewNotesBackend(dir, filepath.Join(t.TempDir(), "notes-index.json"))
+	if !n.IsAvailable() || n.Name() != "notes" {
+		t.Fatal("backend with a notes directory is unavailable")
+	}
+
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want []string
+	}{
+		{"ranking", SearchOptions{Query: "go"}, []string{"go.md", "rust.md"}},
+		{"all terms", SearchOptions{Query: "channels rust"}, []string{"rust.md"}},
+		{"tags", SearchOptions{Query: "concurrency"}, []string{"rust.md"}},
+		{"none of", SearchOptions{Query: "channels", NoneOf: []string{"rust"}}, []string{"go.md"}},
+		{"time range", SearchOptions{Query: "channels", TimeRange: "month"}, []string{"rust.md"}},
+		{"sites", SearchOptions{Query: "go", Sites: []string{"go.dev"}}, nil},
+		// A tie: the newer note comes first
+		{"paging", SearchOptions{Query: "channels", NumResults: 1, PageNo: 2}, []string{"go.md"}},
+	}
+	for _, tt := range tests {
+		results, err := n.Search(tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.Source)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	results, _ := n.Search(SearchOptions{Query: "bread"})
+	if len(results) != 1 || results[0].Title != "Bread" || results[0].Content != "Bread Flour, water, salt." ||
+		!strings.HasPrefix(results[0].URL, "file:///") || !strings.HasSuffix(results[0].URL, "/cooking.org") || results[0].Engine != "notes" {
+		t.Errorf("result: %+v", results)
+	}
+}
+
+func TestNotesBackend_NotConfigured(t *testing.T) {
+	for _, dir := range []string{"", filepath.Join(t.TempDir(), "missing")} {
+		n := NewNotesBackend(dir, "")
+		if n.IsAvailable() {
+			t.Errorf("%q: available", dir)
+		}
+		if _, err := n.Search(SearchOptions{Query: "go"}); err == nil || err.(*BackendError).Code != ErrCodeUnavailable {
+			t.Errorf("%q: got %v", dir, err)
+		}
+	}
+}
+
+func TestNoteSnippet(t *testing.T) {
+	text := strings.Repeat("lorem ipsum ", 20) + "the needle is here " + strings.Repeat("dolor sit ", 30)
+	got := noteSnippet(Note{Text: strings.TrimSpace(text)}, []string{"missing", "NEEDLE"})
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "the needle is here") {
+		t.Errorf("got %q", got)
+	}
+	if got := noteSnippet(Note{Text: "short note"}, []string{"absent"}); got != "short note" {
+		t.Errorf("no match: %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+package backends
+
+import "fmt"
+
+// searchPage runs a search on backend. Backends that take a page number
+// are asked for opts.PageNo directly. A BatchBackend is asked once for
+// MaxResults results, and the requested page is sliced from that batch:
+// later pages of the same search, and pages past its end, need no request.
+func (m *Manager) searchPage(backend SearchBackend, opts SearchOptions) (*SearchResponse, error) {
+	batcher, ok := backend.(BatchBackend)
+	pageSize := opts.NumResults
+	if !ok || pageSize <= 0 || batcher.MaxResults() <= pageSize {
+		return searchDetailed(backend, opts)
+	}
+
+	page := opts.PageNo
+	if page < 1 {
+		page = 1
+	}
+	key := batchKey(backend, opts)
+	m.batchMu.Lock()
+	batch, cached := m.batches[key]
+	m.batchMu.Unlock()
+	if !cached {
+		batchOpts := opts
+		batchOpts.PageNo = 1
+		batchOpts.NumResults = batcher.MaxResults()
+		resp, err := searchDetailed(backend, batchOpts)
+		if err != nil {
+			return nil, err
+		}
+		batch = resp
+		m.batchMu.Lock()
+		if m.batches == nil {
+			m.batches = make(map[string]*SearchResponse)
+		}
+		m.batches[key] = batch
+		m.batchMu.Unlock()
+	}
+
+	start := (page - 1) * pageSize
+	if start >= len(batch.Results) {
+		return &SearchResponse{}, nil
+	}
+	end := start + pageSize
+	if end > len(batch.Results) {
+		end = len(batch.Results)
+	}
+	if page > 1 {
+		// Corrections, suggestions and answers belong to the first page
+		return &SearchResponse{Results: batch.Results[start:end]}, nil
+	}
+	resp := *batch
+	resp.Results = batch.Results[start:end]
+	return &resp, nil
+}
+
+// pageBounds returns where the requested page lies, [start, end), in a list
+// of results that starts with the first page; for backends without a page
+// parameter, which cut later pages from a longer list
+func pageBounds(opts SearchOptions) (start, end int) {
+	num := opts.NumResults
+	if num <= 0 {
+		num = 10
+	}
+	page := max(opts.PageNo, 1)
+	return (page - 1) * num, page * num
+}
+
+// takeTurns merges lists of results, such as those of several sites a
+// backend searches, taking one from each list in turn
+func takeTurns(lists [][]SearchResult) []SearchResult {
+	var results []SearchResult
+	for i := 0; ; i++ {
+		added := false
+		for _, list := range lists {
+			if i < len(list) {
+				results = append(results, list[i])
+				added = true
+			}
+		}
+		if !added {
+			return results
+		}
+	}
+}
+
+// batchKey identifies the batch that a page of a search is sliced from:
+// the backend and every option except the page number.
+func batchKey(backend SearchBackend, opts SearchOptions) string {
+	opts.PageNo = 0
+	return fmt.Sprintf("%s %#v", backend.Name(), opts)
+}
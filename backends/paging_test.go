@@ -0,0 +1,59 @@
+package backends
+
+import (
+	"fmt"
+	"testing"
+)
+
+// batchMockBackend is a BatchBackend that returns up to NumResults of its
+// results and counts the requests made
+type batchMockBackend struct {
+	mockBackend
+	max      int
+	requests []SearchOptions
+}
+
+func (m *batchMockBackend) MaxResults() int { return m.max }
+func (m *batchMockBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	m.requests = append(m.requests, opts)
+	results := m.results
+	if len(results) > opts.NumResults {
+		results = results[:opts.NumResults]
+	}
+	return results, nil
+}
+
+func TestManager_EmulatesPagesForBatchBackend(t *testing.T) {
+	var results []SearchResult
+	for i := 1; i <= 7; i++ {
+		results = append(results, SearchResult{URL: fmt.Sprintf("https://example.com/%d", i)})
+	}
+	backend := &batchMockBackend{mockBackend: mockBackend{name: "batch", available: true, results: results}, max: 20}
+	mgr := NewManager()
+	mgr.Register(backend)
+	mgr.SetPrimary("batch")
+
+	var got []string
+	for page := 1; page <= 4; page++ {
+		pageResults, _, err := mgr.Search(SearchOptions{Query: "q", PageNo: page, NumResults: 3})
+		if err != nil {
+			t.Fatalf("page %d: %v", page, err)
+		}
+		got = append(got, fmt.Sprint(len(pageResults)))
+		if page == 2 && pageResults[0].URL != "https://example.com/4" {
+			t.Errorf("page 2 starts with %s", pageResults[0].URL)
+		}
+	}
+	if fmt.Sprint(got) != "[3 3 1 0]" {
+		t.Errorf("page sizes = %v, want [3 3 1 0]", got)
+	}
+	if len(backend.requests) != 1 || backend.requests[0].NumResults != 20 {
+		t.Errorf("requests = %+v, want one for 20 results", backend.requests)
+	}
+
+	// A different search fetches its own batch
+	mgr.Search(SearchOptions{Query: "other", PageNo: 2, NumResults: 3})
+	if len(backend.requests) != 2 || backend.requests[1].PageNo != 1 {
+		t.Errorf("requests = %+v, want a first-page batch for the new query", backend.requests)
+	}
+}
@@ -0,0 +1,321 @@
+package backends
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PerplexityBackend implements SearchBackend for the Perplexity API's
+// online (Sonar) models: the answer they write goes to the response's
+// answers and the sources it cites become the results.
+type PerplexityBackend struct {
+	APIKey  string
+	Model   string // sonar, sonar-pro, sonar-reasoning, ...
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewPerplexityBackend creates a new Perplexity backend
+func NewPerplexityBackend(apiKey, model string, timeout time.Duration) *PerplexityBackend {
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	if model == "" {
+		model = "sonar"
+	}
+	return &PerplexityBackend{
+		APIKey:  apiKey,
+		Model:   model,
+		Timeout: timeout,
+		BaseURL: "https://api.perplexity.ai/chat/completions",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (p *PerplexityBackend) Name() string {
+	return "perplexity"
+}
+
+// IsAvailable checks if the API key is configured
+func (p *PerplexityBackend) IsAvailable() bool {
+	return p.APIKey != ""
+}
+
+// MaxResults is more sources than an answer cites. Perplexity has no page
+// parameter, and each request writes a new answer, so every page comes
+// from the one request.
+func (p *PerplexityBackend) MaxResults() int {
+	return 20
+}
+
+// perplexityMessage is a chat message
+type perplexityMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// perplexityRequest is the POST body for Perplexity chat completions
+type perplexityRequest struct {
+	Model               string              `json:"model"`
+	Messages            []perplexityMessage `json:"messages"`
+	SearchDomainFilter  []string            `json:"search_domain_filter,omitempty"`
+	SearchRecencyFilter string              `json:"search_recency_filter,omitempty"`
+}
+
+// perplexityResponse is the Perplexity chat completions response. Older
+// responses list only the cited URLs; newer ones also search_results.
+type perplexityResponse struct {
+	Choices []struct {
+		Message perplexityMessage `json:"message"`
+	} `json:"choices"`
+	Citations     []string `json:"citations"`
+	SearchResults []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Date    string `json:"date"`
+		Snippet string `json:"snippet"`
+	} `json:"search_results"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// perplexitySystemPrompt asks for the answer sx shows above the sources
+const perplexitySystemPrompt = "Be precise and concise. Cite the sources of each statement."
+
+// request builds the request body for opts. Sites and excluded sites go
+// to the domain filter, which takes excluded domains prefixed with -.
+func (p *PerplexityBackend) request(opts SearchOptions) perplexityRequest {
+	req := perplexityRequest{
+		Model: p.Model,
+		Messages: []perplexityMessage{
+			{Role: "system", Content: perplexitySystemPrompt},
+			{Role: "user", Content: plainQuery(opts)},
+		},
+	}
+	for _, site := range opts.Sites {
+		if site = strings.TrimSpace(site); site != "" {
+			req.SearchDomainFilter = append(req.SearchDomainFilter, site)
+		}
+	}
+	for _, site := range opts.ExcludeSites {
+		if site = strings.TrimSpace(site); site != "" {
+			req.SearchDomainFilter = append(req.SearchDomainFilter, "-"+site)
+		}
+	}
+	switch opts.TimeRange {
+	case "day", "week", "month", "year":
+		req.SearchRecencyFilter = opts.TimeRange
+	}
+	return req
+}
+
+// Search performs a search against the Perplexity API
+func (p *PerplexityBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	resp, err := p.SearchDetailed(opts)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// SearchDetailed is like Search but also returns Perplexity's answer
+func (p *PerplexityBackend) SearchDetailed(opts SearchOptions) (*SearchResponse, error) {
+	if !p.IsAvailable() {
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("Perplexity API key not configured"),
+			Code:    ErrCodeUnavailable,
+		}
+	}
+	// Each request writes a new answer; there are no further pages
+	if opts.PageNo > 1 {
+		return &SearchResponse{}, nil
+	}
+
+	bodyBytes, err := json.Marshal(p.request(opts))
+	if err != nil {
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("failed to marshal request: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	req, err := http.NewRequest("POST", p.BaseURL, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("request failed: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var perplexityResp perplexityResponse
+	parseErr := json.Unmarshal(respBody, &perplexityResp)
+
+	if resp.StatusCode != http.StatusOK {
+		message := strings.TrimSpace(perplexityResp.Error.Message)
+		if parseErr != nil || message == "" {
+			message = strings.TrimSpace(string(respBody))
+		}
+		switch resp.StatusCode {
+		case 401, 403:
+			return nil, &BackendError{
+				Backend: p.Name(),
+				Err:     fmt.Errorf("authentication failed: %s", message),
+				Code:    ErrCodeAuth,
+			}
+		case 429:
+			return nil, &BackendError{
+				Backend: p.Name(),
+				Err:     fmt.Errorf("rate limited: %s", message),
+				Code:    ErrCodeRateLimit,
+			}
+		default:
+			return nil, &BackendError{
+				Backend: p.Name(),
+				Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, message),
+				Code:    resp.StatusCode,
+			}
+		}
+	}
+	if parseErr != nil {
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", parseErr),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var answer string
+	if len(perplexityResp.Choices) > 0 {
+		answer = stripThinking(perplexityResp.Choices[0].Message.Content)
+	}
+
+	// Sources in citation order, so the answer's [n] is the nth result
+	var results []SearchResult
+	if len(perplexityResp.SearchResults) > 0 {
+		for i, r := range perplexityResp.SearchResults {
+			results = append(results, SearchResult{
+				Title:         firstNonEmpty(r.Title, citationTitle(r.URL)),
+				URL:           r.URL,
+				Content:       firstNonEmpty(citingSentences(answer, i+1), strings.TrimSpace(r.Snippet)),
+				PublishedDate: r.Date,
+				Engine:        p.Name(),
+				Engines:       []string{p.Name()},
+			})
+		}
+	} else {
+		for i, link := range perplexityResp.Citations {
+			results = append(results, SearchResult{
+				Title:   citationTitle(link),
+				URL:     link,
+				Content: citingSentences(answer, i+1),
+				Engine:  p.Name(),
+				Engines: []string{p.Name()},
+			})
+		}
+	}
+
+	num := opts.NumResults
+	if num > 0 && len(results) > num {
+		results = results[:num]
+	}
+	var answers []string
+	if answer != "" {
+		answers = []string{answer}
+	}
+	return &SearchResponse{Results: results, Answers: answers}, nil
+}
+
+// thinkingPattern matches the reasoning the sonar-reasoning models write
+// before their answer
+var thinkingPattern = regexp.MustCompile(`(?s)<think>.*?</think>`)
+
+// stripThinking removes the reasoning from a model's answer
+func stripThinking(content string) string {
+	return strings.TrimSpace(thinkingPattern.ReplaceAllString(content, ""))
+}
+
+// citationMarker matches the [n] citation markers in an answer
+var citationMarker = regexp.MustCompile(`\s*\[(\d+)\]`)
+
+// sentenceEnd splits an answer into sentences and list items
+var sentenceEnd = regexp.MustCompile(`(?:[.!?](?:\[\d+\])*\s+|\n+)`)
+
+// citingSentences joins the sentences of answer that cite source n, with
+// the citation markers and Markdown emphasis removed: what the answer
+// takes from that source.
+func citingSentences(answer string, n int) string {
+	var cited []string
+	ends := sentenceEnd.FindAllStringIndex(answer, -1)
+	start := 0
+	for i := 0; i <= len(ends); i++ {
+		end := len(answer)
+		if i < len(ends) {
+			end = ends[i][1]
+		}
+		sentence := answer[start:end]
+		start = end
+		citesN := false
+		for _, m := range citationMarker.FindAllStringSubmatch(sentence, -1) {
+			if m[1] == strconv.Itoa(n) {
+				citesN = true
+				break
+			}
+		}
+		if !citesN {
+			continue
+		}
+		sentence = citationMarker.ReplaceAllString(sentence, "")
+		sentence = strings.NewReplacer("**", "", "__", "").Replace(sentence)
+		sentence = strings.TrimLeft(strings.TrimSpace(sentence), "-*#> ")
+		if sentence = strings.Join(strings.Fields(sentence), " "); sentence != "" {
+			cited = append(cited, sentence)
+		}
+	}
+	return strings.Join(cited, " ")
+}
+
+// citationTitle stands in for the title of a source known only by its
+// URL: its host and path
+func citationTitle(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(u.Host, "www.")+u.Path, "/")
+}
@@ -0,0 +1,139 @@
+package backends
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPerplexityBackend_Request(t *testing.T) {
+	p := NewPerplexityBackend("key", "", 0)
+	req := p.request(SearchOptions{Query: "go generics", AllOf: []string{"constraints"}, NoneOf: []string{"java"},
+		Sites: []string{"go.dev"}, ExcludeSites: []string{"reddit.com"}, TimeRange: "month"})
+	if req.Model != "sonar" || req.Messages[1].Content != "go generics constraints" || req.SearchRecencyFilter != "month" ||
+		!reflect.DeepEqual(req.SearchDomainFilter, []string{"go.dev", "-reddit.com"}) {
+		t.Errorf("unexpected request %+v", req)
+	}
+	if req := p.request(SearchOptions{Query: "go"}); req.SearchDomainFilter != nil || req.SearchRecencyFilter != "" {
+		t.Errorf("defaults: %+v", req)
+	}
+}
+
+func TestPerplexityBackend_Search(t *testing.T) {
+	var got perplexityRequest
+	var auth string
+	body := `{"id": "x", "model": "sonar",
+		"choices": [{"message": {"role": "assistant", "content": "<think>Let me look.</think>Go added generics in 1.18 [1]. **Type parameters** take constraints[1][2].\n- Constraints are interfaces.[2]"}}],
+		"citations": ["https://go.dev/blog/intro-generics", "https://go.dev/ref/spec"],
+		"search_results": [
+			{"title": "An Introduction To Generics", "url": "https://go.dev/blog/intro-generics", "date": "2022-03-22"},
+			{"title": "", "url": "https://go.dev/ref/spec", "snippet": "The Go Programming Language Specification"},
+			{"title": "Uncited", "url": "https://example.com/", "snippet": "Found but not cited."}
+		]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	p := NewPerplexityBackend("pplx-key", "sonar-pro", 10*time.Second)
+	p.BaseURL = server.URL
+	resp, err := p.SearchDetailed(SearchOptions{Query: "go generics", NumResults: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth != "Bearer pplx-key" || got.Model != "sonar-pro" || got.Messages[1].Content != "go generics" {
+		t.Errorf("unexpected request %q %+v", auth, got)
+	}
+	wantAnswer := "Go added generics in 1.18 [1]. **Type parameters** take constraints[1][2].\n- Constraints are interfaces.[2]"
+	if !reflect.DeepEqual(resp.Answers, []string{wantAnswer}) {
+		t.Errorf("answers = %q", resp.Answers)
+	}
+	if len(resp.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(resp.Results))
+	}
+	first := resp.Results[0]
+	if first.Title != "An Introduction To Generics" || first.PublishedDate != "2022-03-22" || first.Engine != "perplexity" ||
+		first.Content != "Go added generics in 1.18. Type parameters take constraints." {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	if second := resp.Results[1]; second.Title != "go.dev/ref/spec" || second.Content != "Type parameters take constraints. Constraints are interfaces." {
+		t.Errorf("unexpected second result %+v", second)
+	}
+	if third := resp.Results[2]; third.Content != "Found but not cited." {
+		t.Errorf("uncited result: %+v", third)
+	}
+
+	resp, _ = p.SearchDetailed(SearchOptions{Query: "go generics", NumResults: 2})
+	if len(resp.Results) != 2 {
+		t.Errorf("got %d results, want 2", len(resp.Results))
+	}
+	if resp, err := p.SearchDetailed(SearchOptions{Query: "go generics", PageNo: 2}); err != nil || len(resp.Results) != 0 || len(resp.Answers) != 0 {
+		t.Errorf("page 2: %+v, %v", resp, err)
+	}
+}
+
+func TestPerplexityBackend_CitationsOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices": [{"message": {"content": "It is 42.[1]"}}], "citations": ["https://www.example.com/answer/"]}`))
+	}))
+	defer server.Close()
+
+	p := NewPerplexityBackend("key", "", 0)
+	p.BaseURL = server.URL
+	results, err := p.Search(SearchOptions{Query: "answer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Title != "example.com/answer" || results[0].Content != "It is 42." {
+		t.Errorf("got %+v", results)
+	}
+}
+
+func TestPerplexityBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusUnauthorized, `{"error": {"message": "Invalid API key", "type": "invalid_api_key"}}`, ErrCodeAuth},
+		{http.StatusTooManyRequests, `rate limit`, ErrCodeRateLimit},
+		{http.StatusBadRequest, `{"error": {"message": "Invalid model"}}`, http.StatusBadRequest},
+		{http.StatusOK, `not json`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		p := NewPerplexityBackend("key", "", 10*time.Second)
+		p.BaseURL = server.URL
+		_, err := p.Search(SearchOptions{Query: "test"})
+		server.Close()
+		if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		}
+	}
+
+	if _, err := NewPerplexityBackend("", "", 0).Search(SearchOptions{Query: "test"}); err == nil {
+		t.Error("searched without an API key")
+	}
+}
+
+func TestCitingSentences(t *testing.T) {
+	answer := "## Summary\nFirst claim[1]. Second claim, see [12]. Third![1][3] Fourth? No."
+	if got := citingSentences(answer, 1); got != "First claim. Third!" {
+		t.Errorf("source 1: %q", got)
+	}
+	if got := citingSentences(answer, 12); got != "Second claim, see." {
+		t.Errorf("source 12: %q", got)
+	}
+	if got := citingSentences(answer, 2); got != "" {
+		t.Errorf("uncited source: %q", got)
+	}
+}
@@ -0,0 +1,218 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PodcastsBackend implements SearchBackend for podcast episodes through
+// the iTunes Search API, which needs no key. Each result is an episode,
+// linking to its audio, with the feed of its podcast.
+type PodcastsBackend struct {
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewPodcastsBackend creates a new podcast backend
+func NewPodcastsBackend(timeout time.Duration) *PodcastsBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &PodcastsBackend{
+		Timeout: timeout,
+		BaseURL: "https://itunes.apple.com/search",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (p *PodcastsBackend) Name() string {
+	return "podcasts"
+}
+
+// IsAvailable reports true: the iTunes Search API needs no key
+func (p *PodcastsBackend) IsAvailable() bool {
+	return true
+}
+
+// MaxResults is the most results the iTunes Search API returns for one
+// request; it has no page parameter, so later pages are cut from a longer
+// list
+func (p *PodcastsBackend) MaxResults() int {
+	return 200
+}
+
+// itunesResponse is the iTunes Search API response for podcast episodes
+type itunesResponse struct {
+	ResultCount int `json:"resultCount"`
+	Results     []struct {
+		TrackName        string  `json:"trackName"`
+		CollectionName   string  `json:"collectionName"`
+		TrackViewURL     string  `json:"trackViewUrl"`
+		EpisodeURL       string  `json:"episodeUrl"`
+		FeedURL          string  `json:"feedUrl"`
+		ShortDescription string  `json:"shortDescription"`
+		Description      string  `json:"description"`
+		ReleaseDate      string  `json:"releaseDate"`
+		TrackTimeMillis  float64 `json:"trackTimeMillis"`
+		ArtworkURL160    string  `json:"artworkUrl160"`
+		ArtworkURL600    string  `json:"artworkUrl600"`
+		Genres           []struct {
+			Name string `json:"name"`
+		} `json:"genres"`
+	} `json:"results"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// params builds the request parameters for opts: as many episodes as the
+// requested page ends with. The API has no query syntax; builder terms
+// become plain keywords.
+func (p *PodcastsBackend) params(opts SearchOptions) url.Values {
+	_, end := pageBounds(opts)
+	params := url.Values{}
+	params.Set("term", plainQuery(opts))
+	params.Set("media", "podcast")
+	params.Set("entity", "podcastEpisode")
+	params.Set("limit", strconv.Itoa(min(end, p.MaxResults())))
+	if len(opts.Country) == 2 {
+		params.Set("country", strings.ToUpper(opts.Country))
+	}
+	if opts.SafeSearch == "strict" {
+		params.Set("explicit", "No")
+	}
+	return params
+}
+
+// Search performs a search against the iTunes Search API
+func (p *PodcastsBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	req, err := http.NewRequest("GET", p.BaseURL+"?"+p.params(opts).Encode(), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("request failed: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var itunesResp itunesResponse
+	parseErr := json.Unmarshal(body, &itunesResp)
+
+	switch {
+	// Apple answers 403 once a client sends too many requests
+	case resp.StatusCode == 403 || resp.StatusCode == 429:
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("rate limited: %s", strings.TrimSpace(string(body))),
+			Code:    ErrCodeRateLimit,
+		}
+	case resp.StatusCode != http.StatusOK:
+		message := strings.TrimSpace(itunesResp.ErrorMessage)
+		if parseErr != nil || message == "" {
+			message = strings.TrimSpace(string(body))
+		}
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, message),
+			Code:    resp.StatusCode,
+		}
+	case parseErr != nil:
+		return nil, &BackendError{
+			Backend: p.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", parseErr),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var since time.Time
+	if period, ok := bookmarkPeriods[opts.TimeRange]; ok {
+		since = time.Now().Add(-period)
+	}
+	sites := nonEmpty(opts.Sites)
+
+	results := make([]SearchResult, 0, len(itunesResp.Results))
+	for _, episode := range itunesResp.Results {
+		link := firstNonEmpty(episode.EpisodeURL, episode.TrackViewURL)
+		if link == "" {
+			continue
+		}
+		if !since.IsZero() {
+			if released, err := time.Parse(time.RFC3339, episode.ReleaseDate); err != nil || released.Before(since) {
+				continue
+			}
+		}
+		// A site filter matches where the audio or the feed is hosted
+		hosts := []string{urlHost(link), urlHost(episode.FeedURL)}
+		if len(sites) > 0 && !onAnySite(hosts[0], sites) && !onAnySite(hosts[1], sites) ||
+			onAnySite(hosts[0], opts.ExcludeSites) || onAnySite(hosts[1], opts.ExcludeSites) {
+			continue
+		}
+		content := collapseSpace(firstNonEmpty(episode.ShortDescription, episode.Description))
+		if mentionsAny(episode.TrackName+" "+episode.CollectionName+" "+content, opts.NoneOf) {
+			continue
+		}
+
+		result := SearchResult{
+			Title:         collapseSpace(episode.TrackName),
+			URL:           link,
+			Content:       content,
+			Category:      "music",
+			PublishedDate: episode.ReleaseDate,
+			Author:        collapseSpace(episode.CollectionName),
+			Source:        "podcast",
+			ImgSrc:        episode.ArtworkURL600,
+			ThumbnailSrc:  episode.ArtworkURL160,
+			FeedURL:       episode.FeedURL,
+			Engine:        p.Name(),
+			Engines:       []string{p.Name()},
+		}
+		if episode.TrackTimeMillis > 0 {
+			result.Length = episode.TrackTimeMillis / 1000
+		}
+		if len(episode.Genres) > 0 {
+			result.Metadata = episode.Genres[0].Name
+		}
+		results = append(results, result)
+	}
+
+	start, end := pageBounds(opts)
+	if start >= len(results) {
+		return []SearchResult{}, nil
+	}
+	return results[start:min(end, len(results))], nil
+}
+
+// urlHost returns the host of link, or "" if it has none
+func urlHost(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,116 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestPodcastsBackend_Params(t *testing.T) {
+	p := NewPodcastsBackend(0)
+	params := p.params(SearchOptions{Query: "rust", AllOf: []string{"async"}, NumResults: 500, Country: "de", SafeSearch: "strict"})
+	if params.Get("term") != "rust async" || params.Get("media") != "podcast" || params.Get("entity") != "podcastEpisode" ||
+		params.Get("limit") != "200" || params.Get("country") != "DE" || params.Get("explicit") != "No" {
+		t.Errorf("unexpected params %v", params)
+	}
+	if params := p.params(SearchOptions{Query: "rust", SafeSearch: "moderate"}); params.Get("limit") != "10" || params.Has("country") || params.Has("explicit") {
+		t.Errorf("defaults: %v", params)
+	}
+	// Pages are cut from the episodes up to their end
+	if params := p.params(SearchOptions{Query: "rust", NumResults: 5, PageNo: 3}); params.Get("limit") != "15" {
+		t.Errorf("page 3: %v", params)
+	}
+}
+
+func TestPodcastsBackend_Search(t *testing.T) {
+	recent := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	body := `{"resultCount": 3, "results": [
+		{"wrapperType": "podcastEpisode", "trackName": "Async Rust", "collectionName": "Rustacean Station",
+		 "episodeUrl": "https://audio.example.com/ep1.mp3", "trackViewUrl": "https://podcasts.apple.com/us/podcast/x?i=1",
+		 "feedUrl": "https://rustacean-station.org/podcast.rss", "shortDescription": "All about   futures.",
+		 "description": "Long description", "releaseDate": "` + recent + `", "trackTimeMillis": 3725000,
+		 "artworkUrl160": "https://img.example.com/160.jpg", "artworkUrl600": "https://img.example.com/600.jpg",
+		 "genres": [{"name": "Technology", "id": "1318"}]},
+		{"wrapperType": "podcastEpisode", "trackName": "Old episode", "collectionName": "Rustacean Station",
+		 "trackViewUrl": "https://podcasts.apple.com/us/podcast/x?i=2", "feedUrl": "https://rustacean-station.org/podcast.rss",
+		 "description": "From the archive", "releaseDate": "2019-05-01T07:00:00Z"},
+		{"wrapperType": "podcastEpisode", "trackName": "No link"}
+	]}`
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	p := NewPodcastsBackend(10 * time.Second)
+	p.BaseURL = server.URL
+	results, err := p.Search(SearchOptions{Query: "async rust"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query.Get("term") != "async rust" {
+		t.Errorf("unexpected query %v", query)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	first := results[0]
+	if first.Title != "Async Rust" || first.URL != "https://audio.example.com/ep1.mp3" || first.Author != "Rustacean Station" ||
+		first.FeedURL != "https://rustacean-station.org/podcast.rss" || first.Content != "All about futures." ||
+		first.Length != 3725.0 || first.Category != "music" || first.Metadata != "Technology" ||
+		first.ImgSrc != "https://img.example.com/600.jpg" || first.Engine != "podcasts" {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	if second := results[1]; second.URL != "https://podcasts.apple.com/us/podcast/x?i=2" || second.Content != "From the archive" || second.Length != nil {
+		t.Errorf("unexpected second result %+v", second)
+	}
+
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want int
+	}{
+		{"time range", SearchOptions{Query: "rust", TimeRange: "week"}, 1},
+		{"feed site", SearchOptions{Query: "rust", Sites: []string{"rustacean-station.org"}}, 2},
+		{"audio site", SearchOptions{Query: "rust", Sites: []string{"example.com"}}, 1},
+		{"excluded site", SearchOptions{Query: "rust", ExcludeSites: []string{"apple.com"}}, 1},
+		{"none of", SearchOptions{Query: "rust", NoneOf: []string{"archive"}}, 1},
+		{"page 2", SearchOptions{Query: "rust", NumResults: 1, PageNo: 2}, 1},
+		{"past the end", SearchOptions{Query: "rust", NumResults: 2, PageNo: 2}, 0},
+	}
+	for _, tt := range tests {
+		results, err := p.Search(tt.opts)
+		if err != nil || len(results) != tt.want {
+			t.Errorf("%s: got %d results, %v; want %d", tt.name, len(results), err, tt.want)
+		}
+	}
+}
+
+func TestPodcastsBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusForbidden, `Forbidden`, ErrCodeRateLimit},
+		{http.StatusBadRequest, `{"errorMessage": "Invalid value(s) for key(s): [country]"}`, http.StatusBadRequest},
+		{http.StatusOK, `not json`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		p := NewPodcastsBackend(10 * time.Second)
+		p.BaseURL = server.URL
+		_, err := p.Search(SearchOptions{Query: "test"})
+		server.Close()
+		if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package backends
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ComposeQuery renders the query and the query builder terms (AllOf,
+// AnyOf, NoneOf, Exact) with the common operator syntax understood by web
+// search engines: "exact phrase" all terms (any OR of) -none. Terms of the
+// query with Synonyms are expanded. Site filters are not included.
+func ComposeQuery(opts SearchOptions) string {
+	parts := []string{expandSynonyms(strings.TrimSpace(opts.Query), opts.Synonyms)}
+	for _, phrase := range opts.Exact {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			parts = append(parts, quotePhrase(phrase))
+		}
+	}
+	for _, term := range opts.AllOf {
+		if term = strings.TrimSpace(term); term != "" {
+			parts = append(parts, quoteIfSpaced(term))
+		}
+	}
+	var anyOf []string
+	for _, term := range opts.AnyOf {
+		if term = strings.TrimSpace(term); term != "" {
+			anyOf = append(anyOf, quoteIfSpaced(term))
+		}
+	}
+	switch len(anyOf) {
+	case 0:
+	case 1:
+		parts = append(parts, anyOf[0])
+	default:
+		parts = append(parts, "("+strings.Join(anyOf, " OR ")+")")
+	}
+	for _, term := range opts.NoneOf {
+		if term = strings.TrimSpace(term); term != "" {
+			parts = append(parts, "-"+quoteIfSpaced(term))
+		}
+	}
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+// expandSynonyms follows each term of query that belongs to a synonym
+// group with the other terms of the group, OR-ed: golang becomes
+// golang OR "go language". Matching is case-insensitive on stand-alone
+// words; quoted phrases, operator arguments (-term, site:term) and parts
+// of larger tokens are left alone.
+func expandSynonyms(query string, groups [][]string) string {
+	groupOf := make(map[string]int)
+	var terms []string
+	for i, group := range groups {
+		for _, term := range group {
+			term = strings.Join(strings.Fields(term), " ")
+			key := strings.ToLower(term)
+			if _, ok := groupOf[key]; !ok && term != "" {
+				groupOf[key] = i
+				terms = append(terms, regexp.QuoteMeta(term))
+			}
+		}
+	}
+	if len(terms) == 0 {
+		return query
+	}
+	// Longest first, so "go language" wins over "go"
+	sort.SliceStable(terms, func(a, b int) bool { return len(terms[a]) > len(terms[b]) })
+	pattern := regexp.MustCompile(`(?i)\b(?:` + strings.Join(terms, "|") + `)\b`)
+
+	var out strings.Builder
+	for i, segment := range strings.Split(query, `"`) {
+		if i > 0 {
+			out.WriteByte('"')
+		}
+		if i%2 == 1 {
+			out.WriteString(segment)
+			continue
+		}
+		last := 0
+		for _, loc := range pattern.FindAllStringIndex(segment, -1) {
+			// Only stand-alone terms: not -term, site:term or golang.org
+			if loc[0] > 0 && !strings.ContainsRune(" \t(", rune(segment[loc[0]-1])) ||
+				loc[1] < len(segment) && !strings.ContainsRune(" \t),", rune(segment[loc[1]])) {
+				continue
+			}
+			match := segment[loc[0]:loc[1]]
+			out.WriteString(segment[last:loc[1]])
+			for _, other := range groups[groupOf[strings.ToLower(match)]] {
+				if other = strings.Join(strings.Fields(other), " "); other != "" && !strings.EqualFold(other, match) {
+					out.WriteString(" OR " + quoteIfSpaced(other))
+				}
+			}
+			last = loc[1]
+		}
+		out.WriteString(segment[last:])
+	}
+	return out.String()
+}
+
+// plainQuery renders the query and builder terms as plain keywords for
+// backends that treat the query as natural language, where operators would
+// be matched literally. NoneOf terms can't be expressed and are dropped;
+// callers filter those results locally.
+func plainQuery(opts SearchOptions) string {
+	parts := []string{opts.Query}
+	parts = append(parts, opts.Exact...)
+	parts = append(parts, opts.AllOf...)
+	parts = append(parts, opts.AnyOf...)
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+// phraseQuery renders the query with the exact phrases quoted and the
+// AllOf terms added, for backends that search for phrases but have no OR
+// or negation operators; callers handle AnyOf and NoneOf themselves.
+func phraseQuery(opts SearchOptions) string {
+	parts := []string{opts.Query}
+	for _, phrase := range opts.Exact {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			parts = append(parts, quotePhrase(phrase))
+		}
+	}
+	for _, term := range opts.AllOf {
+		if term = strings.TrimSpace(term); term != "" {
+			parts = append(parts, quoteIfSpaced(term))
+		}
+	}
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+// operatorQuery builds the query string for backends that understand
+// search operators, applying the site inclusion and exclusion filters.
+func operatorQuery(opts SearchOptions) string {
+	return excludeSiteQuery(siteQuery(ComposeQuery(opts), opts.Sites), opts.ExcludeSites)
+}
+
+// keywordQuery builds the query string for natural-language backends:
+// builder terms as plain keywords, with site filters still applied since
+// these backends honor site: operators.
+func keywordQuery(opts SearchOptions) string {
+	return excludeSiteQuery(siteQuery(plainQuery(opts), opts.Sites), opts.ExcludeSites)
+}
+
+// quotePhrase wraps phrase in double quotes, dropping quotes inside it.
+func quotePhrase(phrase string) string {
+	return `"` + strings.Join(strings.Fields(strings.ReplaceAll(phrase, `"`, " ")), " ") + `"`
+}
+
+// quoteIfSpaced quotes multi-word terms so operators apply to the whole term.
+func quoteIfSpaced(term string) string {
+	if strings.ContainsAny(term, " \t") {
+		return quotePhrase(term)
+	}
+	return term
+}
+
+// siteQuery prefixes query with site: operators for the given sites.
+// A single site yields "site:a query"; several are OR-ed together as
+// "(site:a OR site:b) query". Empty entries are ignored.
+func siteQuery(query string, sites []string) string {
+	var ops []string
+	for _, site := range sites {
+		site = strings.TrimSpace(site)
+		if site == "" {
+			continue
+		}
+		ops = append(ops, "site:"+site)
+	}
+	switch len(ops) {
+	case 0:
+		return query
+	case 1:
+		return strings.TrimSpace(fmt.Sprintf("%s %s", ops[0], query))
+	default:
+		return strings.TrimSpace(fmt.Sprintf("(%s) %s", strings.Join(ops, " OR "), query))
+	}
+}
+
+// excludeSiteQuery appends a -site: operator for each excluded site.
+func excludeSiteQuery(query string, sites []string) string {
+	for _, site := range sites {
+		site = strings.TrimSpace(site)
+		if site == "" {
+			continue
+		}
+		query += " -site:" + site
+	}
+	return query
+}
@@ -0,0 +1,88 @@
+package backends
+
+import "testing"
+
+func TestSiteQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		sites []string
+		want  string
+	}{
+		{"no sites", nil, "golang"},
+		{"single site", []string{"go.dev"}, "site:go.dev golang"},
+		{"multiple sites", []string{"go.dev", "github.com"}, "(site:go.dev OR site:github.com) golang"},
+		{"blank entries skipped", []string{" ", "go.dev", ""}, "site:go.dev golang"},
+	}
+	for _, tt := range tests {
+		if got := siteQuery("golang", tt.sites); got != tt.want {
+			t.Errorf("%s: siteQuery() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOperatorQuery(t *testing.T) {
+	opts := SearchOptions{
+		Query:        "golang",
+		Sites:        []string{"go.dev"},
+		ExcludeSites: []string{"pinterest.com", "", "quora.com"},
+	}
+	want := "site:go.dev golang -site:pinterest.com -site:quora.com"
+	if got := operatorQuery(opts); got != want {
+		t.Errorf("operatorQuery() = %q, want %q", got, want)
+	}
+}
+
+func TestComposeQuery(t *testing.T) {
+	opts := SearchOptions{
+		Query:  "tutorial",
+		Exact:  []string{"error handling"},
+		AllOf:  []string{"golang", "best practices"},
+		AnyOf:  []string{"wrap", "errors.Is"},
+		NoneOf: []string{"panic", "java script"},
+	}
+	want := `tutorial "error handling" golang "best practices" (wrap OR errors.Is) -panic -"java script"`
+	if got := ComposeQuery(opts); got != want {
+		t.Errorf("ComposeQuery() =\n  %q\nwant\n  %q", got, want)
+	}
+
+	if got := ComposeQuery(SearchOptions{AllOf: []string{"go"}, AnyOf: []string{"chan"}}); got != "go chan" {
+		t.Errorf("ComposeQuery() without base query = %q", got)
+	}
+}
+
+func TestExpandSynonyms(t *testing.T) {
+	groups := [][]string{{"golang", "go language"}, {"k8s", "Kubernetes"}}
+	tests := map[string]string{
+		"golang generics":          `golang OR "go language" generics`,
+		"Go Language tutorial":     `Go Language OR golang tutorial`,
+		"kubernetes on k8s":        `kubernetes OR k8s on k8s OR Kubernetes`,
+		`"golang tips" -golang`:    `"golang tips" -golang`,
+		"site:golang.org channels": "site:golang.org channels",
+		"(golang) vs rust":         `(golang OR "go language") vs rust`,
+		"rust":                     "rust",
+	}
+	for query, want := range tests {
+		if got := expandSynonyms(query, groups); got != want {
+			t.Errorf("expandSynonyms(%q) = %q, want %q", query, got, want)
+		}
+	}
+
+	got := ComposeQuery(SearchOptions{Query: "golang", Synonyms: groups, AllOf: []string{"golang"}})
+	if want := `golang OR "go language" golang`; got != want {
+		t.Errorf("ComposeQuery() = %q, want %q", got, want)
+	}
+}
+
+func TestKeywordQuery(t *testing.T) {
+	opts := SearchOptions{
+		Query:  "tutorial",
+		Sites:  []string{"go.dev"},
+		Exact:  []string{"error handling"},
+		AnyOf:  []string{"wrap", "unwrap"},
+		NoneOf: []string{"panic"},
+	}
+	want := "site:go.dev tutorial error handling wrap unwrap"
+	if got := keywordQuery(opts); got != want {
+		t.Errorf("keywordQuery() = %q, want %q", got, want)
+	}
+}
@@ -1,10 +1,12 @@
 package backends
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -21,23 +23,19 @@ type SearxngBackend struct {
 	Timeout     time.Duration
 	NoVerifySSL bool
 	NoUserAgent bool
+	Preferences string           // encoded preferences token from the instance's preferences page
+	Cookie      string           // raw Cookie header, e.g. "language=de; safesearch=0"
+	TorProxy    string           // SOCKS5 proxy for .onion instances; DefaultTorProxy if empty
+	ClientCert  *tls.Certificate // presented to instances that require mutual TLS
 	client      *http.Client
 }
 
+// DefaultTorProxy is the SOCKS5 port of a local Tor daemon
+const DefaultTorProxy = "socks5://127.0.0.1:9050"
+
 // NewSearxngBackend creates a new SearXNG backend
 func NewSearxngBackend(baseURL, username, password, httpMethod string, timeout time.Duration, noVerifySSL, noUserAgent bool) *SearxngBackend {
-	client := &http.Client{
-		Timeout: timeout,
-	}
-
-	if noVerifySSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = tr
-	}
-
-	return &SearxngBackend{
+	s := &SearxngBackend{
 		BaseURL:     baseURL,
 		Username:    username,
 		Password:    password,
@@ -45,8 +43,97 @@ func NewSearxngBackend(baseURL, username, password, httpMethod string, timeout t
 		Timeout:     timeout,
 		NoVerifySSL: noVerifySSL,
 		NoUserAgent: noUserAgent,
-		client:      client,
 	}
+	s.client = s.newClient()
+	return s
+}
+
+// newClient creates the HTTP client for the instance's settings.
+func (s *SearxngBackend) newClient() *http.Client {
+	client := newHTTPClient(s.Timeout, s.transport())
+	if SearxngTransportWrapper != nil {
+		client.Transport = SearxngTransportWrapper(client.Transport)
+	}
+	return client
+}
+
+// transport builds the HTTP transport for the instance's settings, or
+// returns nil when the default transport will do. Onion instances are
+// always reached through the Tor proxy, unix socket instances never through
+// any proxy.
+func (s *SearxngBackend) transport() http.RoundTripper {
+	socket := s.socketPath()
+	if !s.NoVerifySSL && s.ClientCert == nil && !s.isOnion() && socket == "" {
+		return nil
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if socket != "" {
+		t.Proxy = nil
+		t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+	}
+	if s.NoVerifySSL || s.ClientCert != nil {
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: s.NoVerifySSL}
+		if s.ClientCert != nil {
+			t.TLSClientConfig.Certificates = []tls.Certificate{*s.ClientCert}
+		}
+	}
+	if s.isOnion() {
+		proxy, err := url.Parse(s.torProxy())
+		if err == nil {
+			t.Proxy = http.ProxyURL(proxy)
+		}
+	}
+	return t
+}
+
+// socketPath returns the socket of a unix:///path/to/searxng.sock
+// instance, or "" for instances reached over TCP.
+func (s *SearxngBackend) socketPath() string {
+	u, err := url.Parse(s.BaseURL)
+	if err != nil || u.Scheme != "unix" {
+		return ""
+	}
+	return u.Path
+}
+
+// requestBase is the URL that search paths are appended to. Requests to a
+// unix socket instance name a placeholder host; the dialer ignores it.
+func (s *SearxngBackend) requestBase() string {
+	if s.socketPath() != "" {
+		return "http://localhost"
+	}
+	return strings.TrimSuffix(s.BaseURL, "/")
+}
+
+// isOnion reports whether the instance is a Tor onion service.
+func (s *SearxngBackend) isOnion() bool {
+	u, err := url.Parse(s.BaseURL)
+	return err == nil && strings.HasSuffix(strings.ToLower(u.Hostname()), ".onion")
+}
+
+func (s *SearxngBackend) torProxy() string {
+	if s.TorProxy != "" {
+		return s.TorProxy
+	}
+	return DefaultTorProxy
+}
+
+// checkTorProxy explains a failed request to an onion instance when the
+// Tor proxy itself can't be reached, or returns nil.
+func (s *SearxngBackend) checkTorProxy() error {
+	proxy, err := url.Parse(s.torProxy())
+	if err != nil || proxy.Host == "" {
+		return fmt.Errorf("invalid tor_proxy %q: need a URL like %s", s.torProxy(), DefaultTorProxy)
+	}
+	conn, err := net.DialTimeout("tcp", proxy.Host, 3*time.Second)
+	if err != nil {
+		return fmt.Errorf("%s is an onion service, but the Tor proxy at %s is not reachable (is Tor running?): %v", s.BaseURL, proxy.Host, err)
+	}
+	conn.Close()
+	return nil
 }
 
 // Name returns the backend identifier
@@ -63,7 +150,7 @@ func (s *SearxngBackend) IsAvailable() bool {
 	// Try a simple health check or just validate URL is parseable
 	u, err := url.Parse(s.BaseURL)
 	if err != nil || u.Scheme == "" || u.Host == "" {
-		return false
+		return s.socketPath() != ""
 	}
 
 	return true
@@ -71,6 +158,16 @@ func (s *SearxngBackend) IsAvailable() bool {
 
 // Search performs a search against SearXNG
 func (s *SearxngBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	resp, err := s.SearchDetailed(opts)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// SearchDetailed performs a search against SearXNG, including the
+// corrections and suggestions it reports
+func (s *SearxngBackend) SearchDetailed(opts SearchOptions) (*SearchResponse, error) {
 	if !s.IsAvailable() {
 		return nil, &BackendError{
 			Backend: s.Name(),
@@ -79,20 +176,17 @@ func (s *SearxngBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 		}
 	}
 
-	query := opts.Query
-	if opts.Site != "" {
-		query = fmt.Sprintf("site:%s %s", opts.Site, query)
-	}
+	query := operatorQuery(opts)
 
 	var searchURL string
 	var reqBody io.Reader
 
 	if s.HTTPMethod == "POST" {
-		searchURL = fmt.Sprintf("%s/search", s.BaseURL)
+		searchURL = s.requestBase() + "/search"
 		data := s.buildParams(query, opts)
 		reqBody = strings.NewReader(data.Encode())
 	} else {
-		u, err := url.Parse(s.BaseURL + "/search")
+		u, err := url.Parse(s.requestBase() + "/search")
 		if err != nil {
 			return nil, &BackendError{
 				Backend: s.Name(),
@@ -131,8 +225,17 @@ func (s *SearxngBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 		req.SetBasicAuth(s.Username, s.Password)
 	}
 
+	if s.Cookie != "" {
+		req.Header.Set("Cookie", s.Cookie)
+	}
+
 	resp, err := s.client.Do(req)
 	if err != nil {
+		if s.isOnion() {
+			if torErr := s.checkTorProxy(); torErr != nil {
+				return nil, s.wrapError(torErr, ErrCodeNetwork)
+			}
+		}
 		return nil, s.wrapError(err, ErrCodeNetwork)
 	}
 	defer resp.Body.Close()
@@ -159,7 +262,8 @@ func (s *SearxngBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 	// An empty first page with unresponsive upstream engines means the
 	// instance is degraded (rate limited, CAPTCHA-blocked, ...), not that
 	// the query has no results. Surface it as an error so fallbacks run.
-	if len(searchResp.Results) == 0 && opts.PageNo <= 1 {
+	answers := append(parseAnswers(searchResp.Answers), infoboxAnswers(searchResp.Infoboxes)...)
+	if len(searchResp.Results) == 0 && len(answers) == 0 && opts.PageNo <= 1 {
 		if degraded := formatUnresponsiveEngines(searchResp.UnresponsiveEngines); degraded != "" {
 			return nil, &BackendError{
 				Backend: s.Name(),
@@ -175,7 +279,12 @@ func (s *SearxngBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 		results[i] = SearchResult(r)
 	}
 
-	return results, nil
+	return &SearchResponse{
+		Results:     results,
+		Corrections: searchResp.Corrections,
+		Suggestions: searchResp.Suggestions,
+		Answers:     answers,
+	}, nil
 }
 
 // buildParams constructs URL parameters for SearXNG
@@ -184,6 +293,12 @@ func (s *SearxngBackend) buildParams(query string, opts SearchOptions) url.Value
 	params.Set("q", query)
 	params.Set("format", "json")
 
+	// The instance applies the encoded preferences as if they were its
+	// cookies; explicit options below still take precedence
+	if s.Preferences != "" {
+		params.Set("preferences", s.Preferences)
+	}
+
 	if len(opts.Categories) > 0 {
 		normalized := make([]string, len(opts.Categories))
 		for i, cat := range opts.Categories {
@@ -227,12 +342,70 @@ func (s *SearxngBackend) wrapError(err error, code int) *BackendError {
 
 // Internal response type for parsing SearXNG JSON
 type SearxngResponse struct {
-	Results             []searxngResult `json:"results"`
-	UnresponsiveEngines json.RawMessage `json:"unresponsive_engines"`
+	Results             []searxngResult  `json:"results"`
+	UnresponsiveEngines json.RawMessage  `json:"unresponsive_engines"`
+	Corrections         []string         `json:"corrections"`
+	Suggestions         []string         `json:"suggestions"`
+	Answers             json.RawMessage  `json:"answers"`
+	Infoboxes           []searxngInfobox `json:"infoboxes"`
+}
+
+// searxngInfobox is a knowledge panel (Wikipedia, Wikidata, ...) that
+// SearXNG shows beside the results
+type searxngInfobox struct {
+	Infobox string `json:"infobox"` // title
+	Content string `json:"content"`
+}
+
+// infoboxAnswers renders infoboxes with content as "Title: content"
+// answer blocks.
+func infoboxAnswers(infoboxes []searxngInfobox) []string {
+	var answers []string
+	for _, box := range infoboxes {
+		content := strings.Join(strings.Fields(box.Content), " ")
+		if content == "" {
+			continue
+		}
+		if title := strings.TrimSpace(box.Infobox); title != "" {
+			content = title + ": " + content
+		}
+		answers = append(answers, content)
+	}
+	return answers
 }
 
 type searxngResult SearchResult
 
+// parseAnswers extracts SearXNG's instant answers. Older instances return
+// plain strings, newer ones objects with an "answer" field; anything else
+// is ignored.
+func parseAnswers(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil
+	}
+	var answers []string
+	for _, entry := range entries {
+		var text string
+		if err := json.Unmarshal(entry, &text); err != nil {
+			var obj struct {
+				Answer string `json:"answer"`
+			}
+			if err := json.Unmarshal(entry, &obj); err != nil {
+				continue
+			}
+			text = obj.Answer
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			answers = append(answers, text)
+		}
+	}
+	return answers
+}
+
 // formatUnresponsiveEngines renders SearXNG's unresponsive_engines field
 // (a list of [engine, reason, ...] tuples) as "engine (reason), ...".
 // The field's shape varies across SearXNG versions, so parse leniently
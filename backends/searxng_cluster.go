@@ -49,6 +49,40 @@ func NewMultiSearxngBackend(
 	}
 }
 
+// SetPreferences makes every instance search with the user's preferences:
+// token is an encoded preferences string as shown on SearXNG's preferences
+// page, cookie a raw Cookie header. Either may be empty.
+func (m *MultiSearxngBackend) SetPreferences(token, cookie string) {
+	for _, instance := range m.instances {
+		instance.Preferences = token
+		instance.Cookie = cookie
+	}
+}
+
+// SetTorProxy sets the SOCKS5 proxy that onion instances are reached
+// through, e.g. "socks5://127.0.0.1:9150" for Tor Browser. Other instances
+// keep connecting directly.
+func (m *MultiSearxngBackend) SetTorProxy(proxy string) {
+	for _, instance := range m.instances {
+		instance.TorProxy = proxy
+		instance.client = instance.newClient()
+	}
+}
+
+// SetClientCert loads a PEM client certificate and key that every instance
+// presents for mutual TLS. Empty paths remove the certificate.
+func (m *MultiSearxngBackend) SetClientCert(certFile, keyFile string) error {
+	cert, err := LoadClientCert(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	for _, instance := range m.instances {
+		instance.ClientCert = cert
+		instance.client = instance.newClient()
+	}
+	return nil
+}
+
 func (m *MultiSearxngBackend) Name() string {
 	return "searxng"
 }
@@ -63,6 +97,14 @@ func (m *MultiSearxngBackend) IsAvailable() bool {
 }
 
 func (m *MultiSearxngBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	resp, err := m.SearchDetailed(opts)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+func (m *MultiSearxngBackend) SearchDetailed(opts SearchOptions) (*SearchResponse, error) {
 	available := make([]*SearxngBackend, 0, len(m.instances))
 	for _, instance := range m.instances {
 		if instance.IsAvailable() {
@@ -88,12 +130,12 @@ func (m *MultiSearxngBackend) Search(opts SearchOptions) ([]SearchResult, error)
 	}
 }
 
-func (m *MultiSearxngBackend) searchOrdered(instances []*SearxngBackend, opts SearchOptions) ([]SearchResult, error) {
+func (m *MultiSearxngBackend) searchOrdered(instances []*SearxngBackend, opts SearchOptions) (*SearchResponse, error) {
 	var errs []error
 	for _, instance := range instances {
-		results, err := instance.Search(opts)
+		resp, err := instance.SearchDetailed(opts)
 		if err == nil {
-			return results, nil
+			return resp, nil
 		}
 		errs = append(errs, err)
 	}
@@ -101,10 +143,10 @@ func (m *MultiSearxngBackend) searchOrdered(instances []*SearxngBackend, opts Se
 	return nil, m.allInstancesFailed(errs)
 }
 
-func (m *MultiSearxngBackend) searchParallelFastest(instances []*SearxngBackend, opts SearchOptions) ([]SearchResult, error) {
+func (m *MultiSearxngBackend) searchParallelFastest(instances []*SearxngBackend, opts SearchOptions) (*SearchResponse, error) {
 	type result struct {
-		results []SearchResult
-		err     error
+		resp *SearchResponse
+		err  error
 	}
 
 	ch := make(chan result, len(instances))
@@ -112,8 +154,8 @@ func (m *MultiSearxngBackend) searchParallelFastest(instances []*SearxngBackend,
 	for _, instance := range instances {
 		inst := instance
 		go func() {
-			results, err := inst.Search(opts)
-			ch <- result{results: results, err: err}
+			resp, err := inst.SearchDetailed(opts)
+			ch <- result{resp: resp, err: err}
 		}()
 	}
 
@@ -121,7 +163,7 @@ func (m *MultiSearxngBackend) searchParallelFastest(instances []*SearxngBackend,
 	for i := 0; i < len(instances); i++ {
 		res := <-ch
 		if res.err == nil {
-			return res.results, nil
+			return res.resp, nil
 		}
 		errs = append(errs, res.err)
 	}
@@ -1,9 +1,19 @@
 package backends
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -98,3 +108,61 @@ func TestDeduplicateSearxngURLs(t *testing.T) {
 		t.Fatalf("unexpected deduped urls: %#v", got)
 	}
 }
+
+func TestMultiSearxngBackend_SetClientCert(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": [{"title": "Go", "url": "https://go.dev"}]}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	writeTestKeyPair(t, certFile, keyFile)
+
+	b := NewMultiSearxngBackend([]string{server.URL}, "", "", "GET", 5*time.Second, true, false, SearxngStrategyOrdered)
+	if _, err := b.Search(SearchOptions{Query: "golang"}); err == nil {
+		t.Fatal("expected the handshake to fail without a client certificate")
+	}
+	if err := b.SetClientCert(certFile, keyFile); err != nil {
+		t.Fatalf("SetClientCert failed: %v", err)
+	}
+	if _, err := b.Search(SearchOptions{Query: "golang"}); err != nil {
+		t.Errorf("Search with client certificate failed: %v", err)
+	}
+
+	if err := b.SetClientCert(certFile, ""); err == nil {
+		t.Error("expected an error for a certificate without key")
+	}
+}
+
+// writeTestKeyPair writes a self-signed certificate and its key as PEM.
+func writeTestKeyPair(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sx test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
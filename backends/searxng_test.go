@@ -2,8 +2,10 @@ package backends
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -225,7 +227,7 @@ func TestSearxngBackend_Search_WithSiteFilter(t *testing.T) {
 	defer server.Close()
 
 	b := NewSearxngBackend(server.URL, "", "", "GET", 10*time.Second, false, false)
-	b.Search(SearchOptions{Query: "test", Site: "example.com"})
+	b.Search(SearchOptions{Query: "test", Sites: []string{"example.com"}})
 
 	if capturedQuery != "site:example.com test" {
 		t.Errorf("expected 'site:example.com test', got %q", capturedQuery)
@@ -335,3 +337,133 @@ func TestNormalizeCategory(t *testing.T) {
 		}
 	}
 }
+
+func TestSearxngBackend_SearchDetailed_Corrections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": [], "corrections": ["golang generics"], "suggestions": ["go generics tutorial"]}`))
+	}))
+	defer server.Close()
+
+	b := NewSearxngBackend(server.URL, "", "", "GET", 10*time.Second, false, false)
+	resp, err := b.SearchDetailed(SearchOptions{Query: "golang genrics"})
+	if err != nil {
+		t.Fatalf("SearchDetailed failed: %v", err)
+	}
+	if len(resp.Corrections) != 1 || resp.Corrections[0] != "golang generics" {
+		t.Errorf("unexpected corrections: %v", resp.Corrections)
+	}
+	if len(resp.Suggestions) != 1 || resp.Suggestions[0] != "go generics tutorial" {
+		t.Errorf("unexpected suggestions: %v", resp.Suggestions)
+	}
+}
+
+func TestSearxngBackend_SearchDetailed_Answers(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"string answers", `{"results": [], "answers": ["100 USD = 92.31 EUR"], "unresponsive_engines": [["google", "timeout"]]}`},
+		{"object answers", `{"results": [], "answers": [{"answer": "100 USD = 92.31 EUR", "engine": "currency"}]}`},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(tt.body))
+		}))
+
+		b := NewSearxngBackend(server.URL, "", "", "GET", 10*time.Second, false, false)
+		resp, err := b.SearchDetailed(SearchOptions{Query: "100 usd to eur"})
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: SearchDetailed failed: %v", tt.name, err)
+		}
+		if len(resp.Answers) != 1 || resp.Answers[0] != "100 USD = 92.31 EUR" {
+			t.Errorf("%s: unexpected answers: %v", tt.name, resp.Answers)
+		}
+	}
+}
+
+func TestSearxngBackend_SearchDetailed_Infoboxes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": [], "infoboxes": [{"infobox": "Go", "content": "Go is a\n programming language."}, {"infobox": "Empty"}]}`))
+	}))
+	defer server.Close()
+
+	b := NewSearxngBackend(server.URL, "", "", "GET", 10*time.Second, false, false)
+	resp, err := b.SearchDetailed(SearchOptions{Query: "golang"})
+	if err != nil {
+		t.Fatalf("SearchDetailed failed: %v", err)
+	}
+	if len(resp.Answers) != 1 || resp.Answers[0] != "Go: Go is a programming language." {
+		t.Errorf("unexpected answers: %v", resp.Answers)
+	}
+}
+
+func TestMultiSearxngBackend_SetPreferences(t *testing.T) {
+	var preferences, cookie string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		preferences = r.URL.Query().Get("preferences")
+		cookie = r.Header.Get("Cookie")
+		w.Write([]byte(`{"results": [{"title": "Go", "url": "https://go.dev"}]}`))
+	}))
+	defer server.Close()
+
+	b := NewMultiSearxngBackend([]string{server.URL}, "", "", "GET", 10*time.Second, false, false, SearxngStrategyOrdered)
+	b.SetPreferences("eJx1Vk", "language=de")
+	if _, err := b.Search(SearchOptions{Query: "golang"}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if preferences != "eJx1Vk" || cookie != "language=de" {
+		t.Errorf("preferences = %q, Cookie = %q", preferences, cookie)
+	}
+}
+
+func TestSearxngBackend_OnionUsesTorProxy(t *testing.T) {
+	// A closed port stands in for a Tor daemon that isn't running
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	b := NewMultiSearxngBackend([]string{"http://searxexample.onion"}, "", "", "GET", 5*time.Second, false, false, SearxngStrategyOrdered)
+	b.SetTorProxy("socks5://" + addr)
+	_, err = b.Search(SearchOptions{Query: "golang"})
+	if err == nil || !strings.Contains(err.Error(), "Tor proxy at "+addr+" is not reachable") {
+		t.Errorf("expected Tor proxy error, got %v", err)
+	}
+
+	if NewSearxngBackend("https://searx.example.com", "", "", "GET", 5*time.Second, false, false).transport() != nil {
+		t.Error("clearnet instance should use the default transport")
+	}
+}
+
+func TestSearxngBackend_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "searxng.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" {
+			t.Errorf("path = %q, want /search", r.URL.Path)
+		}
+		w.Write([]byte(`{"results": [{"title": "Go", "url": "https://go.dev"}]}`))
+	}))
+	server.Listener.Close()
+	server.Listener = ln
+	server.Start()
+	defer server.Close()
+
+	b := NewSearxngBackend("unix://"+socket, "", "", "GET", 5*time.Second, false, false)
+	if !b.IsAvailable() {
+		t.Fatal("unix socket instance should be available")
+	}
+	results, err := b.Search(SearchOptions{Query: "golang"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].URL != "https://go.dev" {
+		t.Errorf("unexpected results: %v", results)
+	}
+}
@@ -0,0 +1,331 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SerpAPI engines sx knows how to page and filter; others get the query
+// only
+const (
+	SerpAPIGoogle     = "google"
+	SerpAPIBing       = "bing"
+	SerpAPIDuckDuckGo = "duckduckgo"
+	SerpAPIYahoo      = "yahoo"
+)
+
+// SerpAPIBackend implements SearchBackend for SerpAPI, which returns the
+// results of Google, Bing and other engines as JSON
+type SerpAPIBackend struct {
+	APIKey  string
+	Engine  string // SerpAPI engine parameter, google by default
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewSerpAPIBackend creates a new SerpAPI backend
+func NewSerpAPIBackend(apiKey, engine string, timeout time.Duration) *SerpAPIBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	if engine == "" {
+		engine = SerpAPIGoogle
+	}
+	return &SerpAPIBackend{
+		APIKey:  apiKey,
+		Engine:  engine,
+		Timeout: timeout,
+		BaseURL: "https://serpapi.com/search.json",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (s *SerpAPIBackend) Name() string {
+	return "serpapi"
+}
+
+// IsAvailable checks if the API key is configured
+func (s *SerpAPIBackend) IsAvailable() bool {
+	return s.APIKey != ""
+}
+
+// serpapiResponse is the part of a SerpAPI response sx uses
+type serpapiResponse struct {
+	Error          string `json:"error"`
+	OrganicResults []struct {
+		Title     string `json:"title"`
+		Link      string `json:"link"`
+		Snippet   string `json:"snippet"`
+		Date      string `json:"date"`
+		Source    string `json:"source"`
+		Thumbnail string `json:"thumbnail"`
+	} `json:"organic_results"`
+	KnowledgeGraph struct {
+		Title       string `json:"title"`
+		Type        string `json:"type"`
+		Description string `json:"description"`
+		Website     string `json:"website"`
+		Source      struct {
+			Name string `json:"name"`
+			Link string `json:"link"`
+		} `json:"source"`
+	} `json:"knowledge_graph"`
+	AnswerBox struct {
+		Answer  string `json:"answer"`
+		Result  string `json:"result"`
+		Snippet string `json:"snippet"`
+	} `json:"answer_box"`
+	SearchInformation struct {
+		SpellingFix       string `json:"spelling_fix"`
+		ShowingResultsFor string `json:"showing_results_for"`
+	} `json:"search_information"`
+	RelatedSearches []struct {
+		Query string `json:"query"`
+	} `json:"related_searches"`
+}
+
+// serpapiTimeRange maps time ranges to Google's tbs and DuckDuckGo's df
+var serpapiTimeRange = map[string]string{
+	"day":   "d",
+	"week":  "w",
+	"month": "m",
+	"year":  "y",
+}
+
+// params builds the request parameters for opts. The engines page and
+// filter with different parameters.
+func (s *SerpAPIBackend) params(opts SearchOptions) url.Values {
+	num := opts.NumResults
+	if num <= 0 {
+		num = 10
+	}
+	offset := 0
+	if opts.PageNo > 1 {
+		offset = (opts.PageNo - 1) * num
+	}
+	safe := opts.SafeSearch != "none"
+	lang, country := googleLocale(opts.Language, opts.Country)
+	period := serpapiTimeRange[opts.TimeRange]
+
+	params := url.Values{}
+	params.Set("engine", s.Engine)
+	params.Set("api_key", s.APIKey)
+	query := excludeSiteQuery(siteQuery(ComposeQuery(opts), opts.Sites), opts.ExcludeSites)
+
+	switch s.Engine {
+	case SerpAPIGoogle:
+		params.Set("q", query)
+		params.Set("num", strconv.Itoa(num))
+		if offset > 0 {
+			params.Set("start", strconv.Itoa(offset))
+		}
+		if safe {
+			params.Set("safe", "active")
+		} else {
+			params.Set("safe", "off")
+		}
+		if period != "" {
+			params.Set("tbs", "qdr:"+period)
+		}
+		if lang != "" {
+			params.Set("hl", strings.TrimPrefix(lang, "lang_"))
+		}
+		if country != "" {
+			params.Set("gl", country)
+		}
+	case SerpAPIBing:
+		params.Set("q", query)
+		params.Set("count", strconv.Itoa(num))
+		if offset > 0 {
+			params.Set("first", strconv.Itoa(offset+1))
+		}
+		if safe {
+			params.Set("safeSearch", "Strict")
+		} else {
+			params.Set("safeSearch", "Off")
+		}
+		if country != "" {
+			params.Set("cc", country)
+		}
+	case SerpAPIDuckDuckGo:
+		params.Set("q", query)
+		if offset > 0 {
+			params.Set("start", strconv.Itoa(offset))
+		}
+		if safe {
+			params.Set("safe", "1")
+		} else {
+			params.Set("safe", "-1")
+		}
+		if period != "" {
+			params.Set("df", period)
+		}
+		// kl is a region such as us-en or de-de
+		if country != "" {
+			region := strings.TrimPrefix(lang, "lang_")
+			if region == "" {
+				region = country
+			}
+			params.Set("kl", country+"-"+region)
+		}
+	case SerpAPIYahoo:
+		params.Set("p", query)
+		if offset > 0 {
+			params.Set("b", strconv.Itoa(offset+1))
+		}
+	default:
+		params.Set("q", query)
+	}
+	return params
+}
+
+// Search performs a search through SerpAPI
+func (s *SerpAPIBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	resp, err := s.SearchDetailed(opts)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// SearchDetailed is like Search but also returns the answer box, spelling
+// fixes and related searches
+func (s *SerpAPIBackend) SearchDetailed(opts SearchOptions) (*SearchResponse, error) {
+	if !s.IsAvailable() {
+		return nil, &BackendError{
+			Backend: s.Name(),
+			Err:     fmt.Errorf("SerpAPI API key not configured"),
+			Code:    ErrCodeUnavailable,
+		}
+	}
+
+	req, err := http.NewRequest("GET", s.BaseURL+"?"+s.params(opts).Encode(), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: s.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: s.Name(),
+			// The request URL carries the API key
+			Err:  fmt.Errorf("request failed: %s", redactKey(err.Error(), s.APIKey)),
+			Code: ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: s.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var serpResp serpapiResponse
+	parseErr := json.Unmarshal(body, &serpResp)
+
+	if resp.StatusCode != http.StatusOK {
+		message := strings.TrimSpace(string(body))
+		if parseErr == nil && serpResp.Error != "" {
+			message = serpResp.Error
+		}
+		switch {
+		// Running out of searches is a 429 too
+		case resp.StatusCode == 429 || strings.Contains(message, "run out of searches"):
+			return nil, &BackendError{
+				Backend: s.Name(),
+				Err:     fmt.Errorf("rate limited: %s", message),
+				Code:    ErrCodeRateLimit,
+			}
+		case resp.StatusCode == 401 || resp.StatusCode == 403:
+			return nil, &BackendError{
+				Backend: s.Name(),
+				Err:     fmt.Errorf("authentication failed: %s", message),
+				Code:    ErrCodeAuth,
+			}
+		default:
+			return nil, &BackendError{
+				Backend: s.Name(),
+				Err:     fmt.Errorf("HTTP %d: %s", resp.StatusCode, message),
+				Code:    resp.StatusCode,
+			}
+		}
+	}
+	if parseErr != nil {
+		return nil, &BackendError{
+			Backend: s.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", parseErr),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+	// An empty result page is reported as an error with status 200
+	if serpResp.Error != "" && !strings.Contains(serpResp.Error, "hasn't returned any results") {
+		return nil, &BackendError{
+			Backend: s.Name(),
+			Err:     fmt.Errorf("%s", serpResp.Error),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var results []SearchResult
+	// The knowledge graph panel leads the first page
+	if kg := serpResp.KnowledgeGraph; kg.Title != "" && opts.PageNo <= 1 {
+		link := firstNonEmpty(kg.Website, kg.Source.Link)
+		if link != "" {
+			title := kg.Title
+			if kg.Type != "" {
+				title += " (" + kg.Type + ")"
+			}
+			results = append(results, SearchResult{
+				Title:   title,
+				URL:     link,
+				Content: strings.TrimSpace(kg.Description),
+				Source:  kg.Source.Name,
+				Engine:  s.Name(),
+				Engines: []string{s.Name()},
+			})
+		}
+	}
+	for _, r := range serpResp.OrganicResults {
+		results = append(results, SearchResult{
+			Title:         r.Title,
+			URL:           r.Link,
+			Content:       strings.TrimSpace(r.Snippet),
+			PublishedDate: r.Date,
+			Source:        r.Source,
+			ThumbnailSrc:  r.Thumbnail,
+			Engine:        s.Name(),
+			Engines:       []string{s.Name()},
+		})
+	}
+
+	detailed := &SearchResponse{Results: results}
+	if answer := firstNonEmpty(serpResp.AnswerBox.Answer, serpResp.AnswerBox.Result, serpResp.AnswerBox.Snippet); answer != "" {
+		detailed.Answers = []string{strings.TrimSpace(answer)}
+	}
+	if fix := firstNonEmpty(serpResp.SearchInformation.SpellingFix, serpResp.SearchInformation.ShowingResultsFor); fix != "" {
+		detailed.Corrections = []string{fix}
+	}
+	for _, related := range serpResp.RelatedSearches {
+		if related.Query != "" {
+			detailed.Suggestions = append(detailed.Suggestions, related.Query)
+		}
+	}
+	return detailed, nil
+}
@@ -0,0 +1,149 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSerpAPIBackend_IsAvailable(t *testing.T) {
+	if NewSerpAPIBackend("", "", 0).IsAvailable() {
+		t.Error("available without an API key")
+	}
+	s := NewSerpAPIBackend("key", "", 0)
+	if !s.IsAvailable() || s.Engine != SerpAPIGoogle {
+		t.Errorf("NewSerpAPIBackend = %+v", s)
+	}
+	_, err := NewSerpAPIBackend("", "", 0).Search(SearchOptions{Query: "test"})
+	if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != ErrCodeUnavailable {
+		t.Errorf("expected ErrCodeUnavailable, got %v", err)
+	}
+}
+
+func TestSerpAPIBackend_Params(t *testing.T) {
+	opts := SearchOptions{Query: "golang", Sites: []string{"go.dev"}, PageNo: 3, NumResults: 10, TimeRange: "week", Language: "de-AT", SafeSearch: "none"}
+	tests := []struct {
+		engine string
+		want   map[string]string
+	}{
+		{SerpAPIGoogle, map[string]string{"q": "site:go.dev golang", "num": "10", "start": "20", "safe": "off", "tbs": "qdr:w", "hl": "de", "gl": "at"}},
+		{SerpAPIBing, map[string]string{"q": "site:go.dev golang", "count": "10", "first": "21", "safeSearch": "Off", "cc": "at"}},
+		{SerpAPIDuckDuckGo, map[string]string{"q": "site:go.dev golang", "start": "20", "safe": "-1", "df": "w", "kl": "at-de"}},
+		{SerpAPIYahoo, map[string]string{"p": "site:go.dev golang", "b": "21"}},
+		{"baidu", map[string]string{"q": "site:go.dev golang"}},
+	}
+	for _, tt := range tests {
+		params := NewSerpAPIBackend("key", tt.engine, 0).params(opts)
+		if params.Get("engine") != tt.engine || params.Get("api_key") != "key" {
+			t.Errorf("%s: engine/api_key = %q/%q", tt.engine, params.Get("engine"), params.Get("api_key"))
+		}
+		for k, v := range tt.want {
+			if got := params.Get(k); got != v {
+				t.Errorf("%s: %s = %q, want %q", tt.engine, k, got, v)
+			}
+		}
+		if len(params) != len(tt.want)+2 {
+			t.Errorf("%s: unexpected params %v", tt.engine, params)
+		}
+	}
+}
+
+func TestSerpAPIBackend_Search(t *testing.T) {
+	var got url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"search_information": {"spelling_fix": "golang generics"},
+			"knowledge_graph": {"title": "Go", "type": "Programming language", "description": "Go is a statically typed language.",
+			                    "website": "https://go.dev/", "source": {"name": "Wikipedia", "link": "https://en.wikipedia.org/wiki/Go"}},
+			"answer_box": {"snippet": "Generics were added in Go 1.18. "},
+			"organic_results": [
+				{"position": 1, "title": "Generics", "link": "https://go.dev/doc/tutorial/generics", "snippet": "A tutorial.\n",
+				 "date": "Mar 15, 2022", "thumbnail": "https://img.example/t.jpg"},
+				{"position": 2, "title": "Wiki", "link": "https://en.wikipedia.org/wiki/Generic_programming", "snippet": "Generic programming"}
+			],
+			"related_searches": [{"query": "golang generics constraints"}, {"query": ""}]
+		}`))
+	}))
+	defer server.Close()
+
+	s := NewSerpAPIBackend("test-key", "", 10*time.Second)
+	s.BaseURL = server.URL
+	resp, err := s.SearchDetailed(SearchOptions{Query: "golang genrics"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Get("q") != "golang genrics" || got.Get("engine") != "google" {
+		t.Errorf("unexpected request %v", got)
+	}
+	if len(resp.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(resp.Results))
+	}
+	kg := resp.Results[0]
+	if kg.Title != "Go (Programming language)" || kg.URL != "https://go.dev/" || kg.Content != "Go is a statically typed language." || kg.Source != "Wikipedia" {
+		t.Errorf("knowledge graph result = %+v", kg)
+	}
+	first := resp.Results[1]
+	if first.Content != "A tutorial." || first.PublishedDate != "Mar 15, 2022" || first.ThumbnailSrc != "https://img.example/t.jpg" || first.Engine != "serpapi" {
+		t.Errorf("organic result = %+v", first)
+	}
+	if len(resp.Answers) != 1 || resp.Answers[0] != "Generics were added in Go 1.18." {
+		t.Errorf("answers = %q", resp.Answers)
+	}
+	if len(resp.Corrections) != 1 || resp.Corrections[0] != "golang generics" {
+		t.Errorf("corrections = %q", resp.Corrections)
+	}
+	if len(resp.Suggestions) != 1 || resp.Suggestions[0] != "golang generics constraints" {
+		t.Errorf("suggestions = %q", resp.Suggestions)
+	}
+
+	// Later pages don't repeat the knowledge graph
+	resp, err = s.SearchDetailed(SearchOptions{Query: "golang genrics", PageNo: 2})
+	if err != nil || len(resp.Results) != 2 {
+		t.Errorf("page 2: %v, %v", resp, err)
+	}
+}
+
+func TestSerpAPIBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusUnauthorized, `{"error": "Invalid API key."}`, ErrCodeAuth},
+		{http.StatusTooManyRequests, `{"error": "Your account has run out of searches."}`, ErrCodeRateLimit},
+		{http.StatusBadRequest, `{"error": "Unsupported engine."}`, http.StatusBadRequest},
+		{http.StatusOK, `{"error": "Something went wrong."}`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		s := NewSerpAPIBackend("test-key", "", 10*time.Second)
+		s.BaseURL = server.URL
+		_, err := s.Search(SearchOptions{Query: "test"})
+		server.Close()
+		backendErr, ok := err.(*BackendError)
+		if !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		} else if strings.Contains(err.Error(), "test-key") {
+			t.Errorf("error leaks the API key: %v", err)
+		}
+	}
+
+	// No results is not an error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error": "Google hasn't returned any results for this query."}`))
+	}))
+	defer server.Close()
+	s := NewSerpAPIBackend("test-key", "", 10*time.Second)
+	s.BaseURL = server.URL
+	if results, err := s.Search(SearchOptions{Query: "test"}); err != nil || len(results) != 0 {
+		t.Errorf("empty page: %v, %v", results, err)
+	}
+}
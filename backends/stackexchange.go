@@ -0,0 +1,320 @@
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StackExchangeBackend implements SearchBackend for questions on Stack
+// Exchange sites, such as Stack Overflow and Super User, through the Stack
+// Exchange API. A key raises the daily quota but isn't needed.
+type StackExchangeBackend struct {
+	Key     string
+	Sites   []string // API site names or domains; stackoverflow when empty
+	Timeout time.Duration
+	BaseURL string // overridable for testing
+	client  *http.Client
+}
+
+// NewStackExchangeBackend creates a new Stack Exchange backend
+func NewStackExchangeBackend(key string, sites []string, timeout time.Duration) *StackExchangeBackend {
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &StackExchangeBackend{
+		Key:     key,
+		Sites:   sites,
+		Timeout: timeout,
+		BaseURL: "https://api.stackexchange.com/2.3",
+		client:  newHTTPClient(timeout, nil),
+	}
+}
+
+// Name returns the backend identifier
+func (s *StackExchangeBackend) Name() string {
+	return "stackexchange"
+}
+
+// IsAvailable reports true: the Stack Exchange API works without a key
+func (s *StackExchangeBackend) IsAvailable() bool {
+	return true
+}
+
+// MaxResults is the most questions the API returns for one request; with
+// several sites, later pages are cut from their combined lists
+func (s *StackExchangeBackend) MaxResults() int {
+	return 100
+}
+
+// stackexchangeResponse is the Stack Exchange API's search response
+type stackexchangeResponse struct {
+	Items []struct {
+		Title            string   `json:"title"`
+		Link             string   `json:"link"`
+		Body             string   `json:"body"`
+		Tags             []string `json:"tags"`
+		Score            int      `json:"score"`
+		AnswerCount      int      `json:"answer_count"`
+		ViewCount        int      `json:"view_count"`
+		AcceptedAnswerID int      `json:"accepted_answer_id"`
+		CreationDate     int64    `json:"creation_date"`
+		ClosedReason     string   `json:"closed_reason"`
+		Owner            struct {
+			DisplayName string `json:"display_name"`
+		} `json:"owner"`
+	} `json:"items"`
+	ErrorID      int    `json:"error_id"`
+	ErrorName    string `json:"error_name"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// stackexchangePeriods maps time ranges to how recently a question must
+// have been asked
+var stackexchangePeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// stackexchangeHosts are the domains of the sites that aren't
+// <name>.stackexchange.com
+var stackexchangeHosts = map[string]string{
+	"stackoverflow": "stackoverflow.com",
+	"superuser":     "superuser.com",
+	"serverfault":   "serverfault.com",
+	"askubuntu":     "askubuntu.com",
+	"stackapps":     "stackapps.com",
+	"mathoverflow":  "mathoverflow.net",
+}
+
+// stackexchangeHost returns the domain of a site given by its API name or
+// its domain
+func stackexchangeHost(site string) string {
+	if strings.Contains(site, ".") {
+		return strings.ToLower(site)
+	}
+	if host, ok := stackexchangeHosts[strings.ToLower(site)]; ok {
+		return host
+	}
+	return strings.ToLower(site) + ".stackexchange.com"
+}
+
+// sites returns the sites to search for opts: those configured, less
+// those the site filters leave out
+func (s *StackExchangeBackend) sites(opts SearchOptions) []string {
+	sites := s.Sites
+	if len(sites) == 0 {
+		sites = []string{"stackoverflow"}
+	}
+	include := nonEmpty(opts.Sites)
+	var kept []string
+	for _, site := range nonEmpty(sites) {
+		host := stackexchangeHost(site)
+		if len(include) > 0 && !onAnySite(host, include) || onAnySite(host, opts.ExcludeSites) {
+			continue
+		}
+		kept = append(kept, site)
+	}
+	return kept
+}
+
+// params builds the request parameters for searching site at now: as
+// many questions as the requested page ends with
+func (s *StackExchangeBackend) params(opts SearchOptions, site string, now time.Time) url.Values {
+	_, end := pageBounds(opts)
+	params := url.Values{}
+	params.Set("q", plainQuery(opts))
+	params.Set("site", site)
+	params.Set("order", "desc")
+	params.Set("sort", "relevance")
+	params.Set("pagesize", strconv.Itoa(min(end, s.MaxResults())))
+	params.Set("filter", "withbody")
+	if period, ok := stackexchangePeriods[opts.TimeRange]; ok {
+		params.Set("fromdate", strconv.FormatInt(now.Add(-period).Unix(), 10))
+	}
+	if s.Key != "" {
+		params.Set("key", s.Key)
+	}
+	return params
+}
+
+// Search searches each site in turn and takes turns between their
+// questions. A site that fails is left out; the search fails only if
+// every site does.
+func (s *StackExchangeBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	var lists [][]SearchResult
+	var firstErr error
+	for _, site := range s.sites(opts) {
+		results, err := s.searchSite(opts, site)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		lists = append(lists, results)
+	}
+	if len(lists) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+
+	results := takeTurns(lists)
+	start, end := pageBounds(opts)
+	if start >= len(results) {
+		return []SearchResult{}, nil
+	}
+	return results[start:min(end, len(results))], nil
+}
+
+// searchSite searches the questions of one site
+func (s *StackExchangeBackend) searchSite(opts SearchOptions, site string) ([]SearchResult, error) {
+	req, err := http.NewRequest("GET", s.BaseURL+"/search/advanced?"+s.params(opts, site, time.Now()).Encode(), nil)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: s.Name(),
+			Err:     fmt.Errorf("failed to create request: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: s.Name(),
+			Err:     fmt.Errorf("request failed: %v", err),
+			Code:    ErrCodeNetwork,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &BackendError{
+			Backend: s.Name(),
+			Err:     fmt.Errorf("failed to read response: %v", err),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	var seResp stackexchangeResponse
+	parseErr := json.Unmarshal(body, &seResp)
+
+	if resp.StatusCode != http.StatusOK {
+		message := strings.TrimSpace(seResp.ErrorMessage)
+		if parseErr != nil || message == "" {
+			message = strings.TrimSpace(string(body))
+		}
+		switch {
+		// The API answers 400 with error 502 to clients over its
+		// request rate or daily quota
+		case resp.StatusCode == 429 || seResp.ErrorName == "throttle_violation":
+			return nil, &BackendError{
+				Backend: s.Name(),
+				Err:     fmt.Errorf("%s: rate limited: %s", site, message),
+				Code:    ErrCodeRateLimit,
+			}
+		case seResp.ErrorID == 401 || seResp.ErrorID == 402 || seResp.ErrorID == 403 || seResp.ErrorID == 405:
+			return nil, &BackendError{
+				Backend: s.Name(),
+				Err:     fmt.Errorf("%s: authentication failed: %s", site, message),
+				Code:    ErrCodeAuth,
+			}
+		default:
+			return nil, &BackendError{
+				Backend: s.Name(),
+				Err:     fmt.Errorf("%s: HTTP %d: %s", site, resp.StatusCode, message),
+				Code:    resp.StatusCode,
+			}
+		}
+	}
+	if parseErr != nil {
+		return nil, &BackendError{
+			Backend: s.Name(),
+			Err:     fmt.Errorf("failed to parse JSON: %v", parseErr),
+			Code:    ErrCodeInvalidResponse,
+		}
+	}
+
+	results := make([]SearchResult, 0, len(seResp.Items))
+	for _, q := range seResp.Items {
+		title := html.UnescapeString(q.Title)
+		content := questionBody(q.Body)
+		if q.Link == "" || mentionsAny(title+" "+content, opts.NoneOf) {
+			continue
+		}
+
+		facts := []string{site, fmt.Sprintf("%d answers", q.AnswerCount)}
+		switch {
+		case q.AcceptedAnswerID != 0:
+			facts = append(facts, "accepted")
+		case q.AnswerCount == 0:
+			facts = append(facts, "unanswered")
+		}
+		if q.ClosedReason != "" {
+			facts = append(facts, "closed")
+		}
+		facts = append(facts, fmt.Sprintf("%d votes", q.Score), fmt.Sprintf("%d views", q.ViewCount))
+		if len(q.Tags) > 0 {
+			facts = append(facts, strings.Join(q.Tags, ", "))
+		}
+
+		result := SearchResult{
+			Title:    collapseSpace(title),
+			URL:      q.Link,
+			Content:  content,
+			Category: "it",
+			Author:   html.UnescapeString(q.Owner.DisplayName),
+			Metadata: strings.Join(facts, " · "),
+			Score:    float64(q.Score),
+			Engine:   s.Name(),
+			Engines:  []string{s.Name()},
+		}
+		if q.CreationDate > 0 {
+			result.PublishedDate = time.Unix(q.CreationDate, 0).UTC().Format(time.RFC3339)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// codeBlock matches the code blocks of a question's HTML body, and
+// codeBlockLang the language a block is marked with
+var (
+	codeBlock     = regexp.MustCompile(`(?is)<pre[^>]*>(.*?)</pre>`)
+	codeBlockLang = regexp.MustCompile(`(?i)class="[^"]*\b(?:lang|language)-([\w+#.-]+)`)
+)
+
+// questionBody returns the text of a question's HTML body, with its code
+// blocks kept as fenced Markdown blocks so IT results show them as code
+func questionBody(body string) string {
+	var parts []string
+	pos := 0
+	for _, m := range codeBlock.FindAllStringSubmatchIndex(body, -1) {
+		if prose := markupText(body[pos:m[0]]); prose != "" {
+			parts = append(parts, prose)
+		}
+		var lang string
+		if lm := codeBlockLang.FindStringSubmatch(body[m[0]:m[1]]); lm != nil {
+			lang = lm[1]
+		}
+		code := strings.Trim(html.UnescapeString(markupTag.ReplaceAllString(body[m[2]:m[3]], "")), "\n")
+		if strings.TrimSpace(code) != "" {
+			parts = append(parts, "```"+lang+"\n"+code+"\n```")
+		}
+		pos = m[1]
+	}
+	if prose := markupText(body[pos:]); prose != "" {
+		parts = append(parts, prose)
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,138 @@
+package backends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const stackexchangeTestResponse = `{"items": [
+	{"question_id": 1, "title": "How do I read a file &quot;line by line&quot; in Go?", "link": "https://stackoverflow.com/questions/1/read-lines",
+	 "body": "<p>I tried <code>ioutil</code>:</p>\n<pre class=\"lang-go s-code-block\"><code>data, _ := ioutil.ReadFile(&quot;f&quot;)\nfmt.Println(data)\n</code></pre>\n<p>Is there a <em>better</em> way?</p>",
+	 "tags": ["go", "file-io"], "score": 42, "answer_count": 3, "view_count": 1000, "accepted_answer_id": 7,
+	 "creation_date": 1709985600, "owner": {"display_name": "G&#246;ran"}},
+	{"question_id": 2, "title": "Deprecated question", "link": "https://stackoverflow.com/questions/2", "body": "<p>Old</p>",
+	 "score": -1, "answer_count": 0, "view_count": 5, "closed_reason": "Duplicate"},
+	{"question_id": 3, "title": "No link"}
+], "has_more": false, "quota_max": 300, "quota_remaining": 290}`
+
+func TestStackExchangeHost(t *testing.T) {
+	for site, want := range map[string]string{
+		"stackoverflow":          "stackoverflow.com",
+		"SuperUser":              "superuser.com",
+		"unix":                   "unix.stackexchange.com",
+		"unix.stackexchange.com": "unix.stackexchange.com",
+		"mathoverflow":           "mathoverflow.net",
+	} {
+		if got := stackexchangeHost(site); got != want {
+			t.Errorf("%s: got %q, want %q", site, got, want)
+		}
+	}
+}
+
+func TestQuestionBody(t *testing.T) {
+	body := "<p>Intro &amp; more</p>\n<pre><code class=\"language-python\">print(&quot;hi&quot;)\n</code></pre><p>After</p><pre>  </pre>"
+	want := "Intro & more\n\n```python\nprint(\"hi\")\n```\n\nAfter"
+	if got := questionBody(body); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := questionBody("<p>No code</p>"); got != "No code" {
+		t.Errorf("without code: %q", got)
+	}
+}
+
+func TestStackExchangeBackend_Search(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/advanced" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		queries = append(queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(stackexchangeTestResponse))
+	}))
+	defer server.Close()
+
+	s := NewStackExchangeBackend("k3y", nil, 10*time.Second)
+	s.BaseURL = server.URL
+	results, err := s.Search(SearchOptions{Query: "read file", AllOf: []string{"go"}, NumResults: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(queries) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(queries))
+	}
+	q := queries[0]
+	if q.Get("q") != "read file go" || q.Get("site") != "stackoverflow" || q.Get("sort") != "relevance" ||
+		q.Get("pagesize") != "5" || q.Get("filter") != "withbody" || q.Get("key") != "k3y" || q.Has("fromdate") {
+		t.Errorf("unexpected request %v", q)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	first := results[0]
+	if first.Title != `How do I read a file "line by line" in Go?` || first.URL != "https://stackoverflow.com/questions/1/read-lines" ||
+		first.Author != "Göran" || first.Category != "it" || first.Score != 42 || first.PublishedDate != "2024-03-09T12:00:00Z" ||
+		first.Engine != "stackexchange" {
+		t.Errorf("unexpected first result %+v", first)
+	}
+	if first.Metadata != "stackoverflow · 3 answers · accepted · 42 votes · 1000 views · go, file-io" {
+		t.Errorf("metadata %q", first.Metadata)
+	}
+	if want := "I tried ioutil:\n\n```go\ndata, _ := ioutil.ReadFile(\"f\")\nfmt.Println(data)\n```\n\nIs there a better way?"; first.Content != want {
+		t.Errorf("content %q", first.Content)
+	}
+	if second := results[1]; second.Metadata != "stackoverflow · 0 answers · unanswered · closed · -1 votes · 5 views" || second.PublishedDate != "" {
+		t.Errorf("unexpected second result %+v", second)
+	}
+
+	// Several sites take turns; site filters pick among them
+	queries = nil
+	s.Sites = []string{"stackoverflow", "superuser", "unix"}
+	results, err = s.Search(SearchOptions{Query: "x", TimeRange: "week", ExcludeSites: []string{"unix.stackexchange.com"}})
+	if err != nil || len(results) != 4 || len(queries) != 2 {
+		t.Fatalf("two sites: %d results, %v, %d requests", len(results), err, len(queries))
+	}
+	if queries[1].Get("site") != "superuser" || !queries[0].Has("fromdate") || results[1].Metadata[:9] != "superuser" {
+		t.Errorf("unexpected requests %v, metadata %q", queries, results[1].Metadata)
+	}
+	queries = nil
+	if results, err := s.Search(SearchOptions{Query: "x", Sites: []string{"askubuntu.com"}}); err != nil || len(results) != 0 || len(queries) != 0 {
+		t.Errorf("no site left: %d results, %v, %d requests", len(results), err, len(queries))
+	}
+	if results, err := s.Search(SearchOptions{Query: "x", NoneOf: []string{"deprecated"}, Sites: []string{"superuser.com"}}); err != nil || len(results) != 1 {
+		t.Errorf("none of: %d results, %v", len(results), err)
+	}
+	if results, err := s.Search(SearchOptions{Query: "x", NumResults: 3, PageNo: 2}); err != nil || len(results) != 3 {
+		t.Errorf("second page: %d results, %v", len(results), err)
+	}
+}
+
+func TestStackExchangeBackend_Errors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		code   int
+	}{
+		{http.StatusBadRequest, `{"error_id": 502, "error_message": "too many requests from this IP", "error_name": "throttle_violation"}`, ErrCodeRateLimit},
+		{http.StatusBadRequest, `{"error_id": 403, "error_message": "invalid key", "error_name": "access_denied"}`, ErrCodeAuth},
+		{http.StatusBadRequest, `{"error_id": 400, "error_message": "site is required", "error_name": "bad_parameter"}`, http.StatusBadRequest},
+		{http.StatusOK, `not json`, ErrCodeInvalidResponse},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		s := NewStackExchangeBackend("", nil, 10*time.Second)
+		s.BaseURL = server.URL
+		_, err := s.Search(SearchOptions{Query: "test"})
+		server.Close()
+		if backendErr, ok := err.(*BackendError); !ok || backendErr.Code != tt.code {
+			t.Errorf("%d %s: got %v, want code %d", tt.status, tt.body, err, tt.code)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -16,6 +17,7 @@ type TavilyBackend struct {
 	SearchDepth       string // "basic" (1 credit) or "advanced" (2 credits)
 	IncludeRawContent bool   // Return full page content inline
 	IncludeAnswer     bool   // Return a direct answer
+	Topic             string // "general" or "news"; the news category selects news too
 	BaseURL           string // overridable for testing
 	client            *http.Client
 }
@@ -35,9 +37,7 @@ func NewTavilyBackend(apiKey string, timeout time.Duration, searchDepth string,
 		IncludeRawContent: includeRawContent,
 		IncludeAnswer:     includeAnswer,
 		BaseURL:           "https://api.tavily.com/search",
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		client:            newHTTPClient(timeout, nil),
 	}
 }
 
@@ -51,21 +51,50 @@ func (t *TavilyBackend) IsAvailable() bool {
 	return t.APIKey != ""
 }
 
+// MaxResults is Tavily's max_results limit; it has no page parameter
+func (t *TavilyBackend) MaxResults() int {
+	return 20
+}
+
 // tavilyRequest is the POST body for Tavily search
 type tavilyRequest struct {
-	Query             string `json:"query"`
-	SearchDepth       string `json:"search_depth,omitempty"`
-	MaxResults        int    `json:"max_results,omitempty"`
-	IncludeRawContent bool   `json:"include_raw_content,omitempty"`
-	IncludeAnswer     bool   `json:"include_answer,omitempty"`
+	Query             string   `json:"query"`
+	SearchDepth       string   `json:"search_depth,omitempty"`
+	MaxResults        int      `json:"max_results,omitempty"`
+	IncludeRawContent bool     `json:"include_raw_content,omitempty"`
+	IncludeAnswer     bool     `json:"include_answer,omitempty"`
+	Topic             string   `json:"topic,omitempty"`
+	IncludeDomains    []string `json:"include_domains,omitempty"`
+	ExcludeDomains    []string `json:"exclude_domains,omitempty"`
+	Days              int      `json:"days,omitempty"`       // news topic only
+	TimeRange         string   `json:"time_range,omitempty"` // any topic
+}
+
+// tavilyDays maps time ranges to Tavily's days parameter
+var tavilyDays = map[string]int{
+	"day":   1,
+	"week":  7,
+	"month": 30,
+	"year":  365,
+}
+
+// topic selects Tavily's news topic for the news category, else the
+// configured topic.
+func (t *TavilyBackend) topic(opts SearchOptions) string {
+	for _, category := range opts.Categories {
+		if category == "news" {
+			return "news"
+		}
+	}
+	return t.Topic
 }
 
 // tavilyResponse is the Tavily search API response
 type tavilyResponse struct {
-	Query        string          `json:"query"`
-	Answer       string          `json:"answer"`
-	Results      []tavilyResult  `json:"results"`
-	ResponseTime float64         `json:"response_time"`
+	Query        string         `json:"query"`
+	Answer       string         `json:"answer"`
+	Results      []tavilyResult `json:"results"`
+	ResponseTime float64        `json:"response_time"`
 }
 
 type tavilyResult struct {
@@ -78,6 +107,16 @@ type tavilyResult struct {
 
 // Search performs a search against Tavily Search API
 func (t *TavilyBackend) Search(opts SearchOptions) ([]SearchResult, error) {
+	resp, err := t.SearchDetailed(opts)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// SearchDetailed is like Search but also returns Tavily's answer, which it
+// generates when include_answer is set
+func (t *TavilyBackend) SearchDetailed(opts SearchOptions) (*SearchResponse, error) {
 	if !t.IsAvailable() {
 		return nil, &BackendError{
 			Backend: t.Name(),
@@ -92,10 +131,8 @@ func (t *TavilyBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 		numResults = 10
 	}
 
-	query := opts.Query
-	if opts.Site != "" {
-		query = fmt.Sprintf("site:%s %s", opts.Site, query)
-	}
+	// Sites go to include_domains/exclude_domains instead of the query
+	query := plainQuery(opts)
 
 	reqBody := tavilyRequest{
 		Query:             query,
@@ -103,6 +140,13 @@ func (t *TavilyBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 		MaxResults:        numResults,
 		IncludeRawContent: t.IncludeRawContent,
 		IncludeAnswer:     t.IncludeAnswer,
+		Topic:             t.topic(opts),
+		IncludeDomains:    opts.Sites,
+		ExcludeDomains:    opts.ExcludeSites,
+		Days:              tavilyDays[opts.TimeRange],
+	}
+	if reqBody.Days > 0 {
+		reqBody.TimeRange = opts.TimeRange
 	}
 
 	bodyBytes, err := json.Marshal(reqBody)
@@ -191,8 +235,13 @@ func (t *TavilyBackend) Search(opts SearchOptions) ([]SearchResult, error) {
 			Content: content,
 			Engine:  t.Name(),
 			Engines: []string{t.Name()},
+			Score:   r.Score,
 		}
 	}
 
-	return results, nil
+	var answers []string
+	if answer := strings.TrimSpace(tavilyResp.Answer); answer != "" {
+		answers = []string{answer}
+	}
+	return &SearchResponse{Results: results, Answers: answers}, nil
 }
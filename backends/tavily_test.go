@@ -2,6 +2,7 @@ package backends
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -169,12 +170,10 @@ func TestTavilyBackend_Search_WithRawContent(t *testing.T) {
 }
 
 func TestTavilyBackend_Search_SiteFilter(t *testing.T) {
-	var capturedQuery string
+	var captured tavilyRequest
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		body, _ := io.ReadAll(r.Body)
-		var req tavilyRequest
-		json.Unmarshal(body, &req)
-		capturedQuery = req.Query
+		json.Unmarshal(body, &captured)
 
 		resp := tavilyResponse{Results: []tavilyResult{}}
 		json.NewEncoder(w).Encode(resp)
@@ -182,10 +181,36 @@ func TestTavilyBackend_Search_SiteFilter(t *testing.T) {
 	defer server.Close()
 
 	b := newTestTavilyBackend(server.URL, "key", "basic", false, false)
-	b.Search(SearchOptions{Query: "test", Site: "example.com"})
+	b.Search(SearchOptions{Query: "test", Sites: []string{"example.com"}, ExcludeSites: []string{"spam.com"}})
 
-	if capturedQuery != "site:example.com test" {
-		t.Errorf("expected 'site:example.com test', got %q", capturedQuery)
+	if captured.Query != "test" {
+		t.Errorf("expected query 'test', got %q", captured.Query)
+	}
+	if fmt.Sprint(captured.IncludeDomains) != "[example.com]" || fmt.Sprint(captured.ExcludeDomains) != "[spam.com]" {
+		t.Errorf("include_domains = %v, exclude_domains = %v", captured.IncludeDomains, captured.ExcludeDomains)
+	}
+}
+
+func TestTavilyBackend_Search_TopicAndDays(t *testing.T) {
+	var captured tavilyRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		captured = tavilyRequest{}
+		json.Unmarshal(body, &captured)
+		json.NewEncoder(w).Encode(tavilyResponse{})
+	}))
+	defer server.Close()
+
+	b := newTestTavilyBackend(server.URL, "key", "basic", false, false)
+	b.Search(SearchOptions{Query: "test", Categories: []string{"news"}, TimeRange: "week"})
+	if captured.Topic != "news" || captured.Days != 7 || captured.TimeRange != "week" {
+		t.Errorf("topic, days, time_range = %q, %d, %q, want news, 7, week", captured.Topic, captured.Days, captured.TimeRange)
+	}
+
+	b.Topic = "general"
+	b.Search(SearchOptions{Query: "test"})
+	if captured.Topic != "general" || captured.Days != 0 {
+		t.Errorf("topic, days = %q, %d, want general, 0", captured.Topic, captured.Days)
 	}
 }
 
@@ -276,3 +301,19 @@ func TestTavilyBackend_Search_NumResults(t *testing.T) {
 		t.Errorf("expected capped max_results=10, got %d", capturedMaxResults)
 	}
 }
+
+func TestTavilyBackend_SearchDetailed_Answer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tavilyResponse{Answer: " Go is a programming language "})
+	}))
+	defer server.Close()
+
+	b := newTestTavilyBackend(server.URL, "key", "basic", false, true)
+	resp, err := b.SearchDetailed(SearchOptions{Query: "golang"})
+	if err != nil {
+		t.Fatalf("SearchDetailed failed: %v", err)
+	}
+	if len(resp.Answers) != 1 || resp.Answers[0] != "Go is a programming language" {
+		t.Errorf("unexpected answers: %v", resp.Answers)
+	}
+}
@@ -0,0 +1,77 @@
+package backends
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TransportWrapper, when set, wraps the transport of every HTTP client the
+// backends create, e.g. to log requests. Set it before constructing
+// backends; clients built earlier are not affected.
+var TransportWrapper func(http.RoundTripper) http.RoundTripper
+
+// SearxngTransportWrapper, when set, also wraps the transport of SearXNG
+// instances' clients, for what only SearXNG is sent, such as a request ID
+// that matches its logs. Like TransportWrapper, set it before constructing
+// backends.
+var SearxngTransportWrapper func(http.RoundTripper) http.RoundTripper
+
+// RootCAs, when set, replaces the system roots for verifying backends' TLS
+// certificates, e.g. with a pool that adds a TLS-intercepting proxy's CA.
+// Like TransportWrapper, set it before constructing backends.
+var RootCAs *x509.CertPool
+
+// newHTTPClient creates the HTTP client for a backend. A nil transport uses
+// http.DefaultTransport.
+func newHTTPClient(timeout time.Duration, transport http.RoundTripper) *http.Client {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if RootCAs != nil {
+		if t, ok := transport.(*http.Transport); ok {
+			t = t.Clone()
+			if t.TLSClientConfig == nil {
+				t.TLSClientConfig = &tls.Config{}
+			}
+			t.TLSClientConfig.RootCAs = RootCAs
+			transport = t
+		}
+	}
+	if TransportWrapper != nil {
+		transport = TransportWrapper(transport)
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+// LoadClientCert loads a PEM client certificate and key that a backend
+// presents to servers requiring mutual TLS. Empty paths load none.
+func LoadClientCert(certFile, keyFile string) (*tls.Certificate, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("mutual TLS needs both a client certificate and a key")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %v", err)
+	}
+	return &cert, nil
+}
+
+// clientCertTransport returns a transport presenting cert, or nil for the
+// default transport without one.
+func clientCertTransport(cert *tls.Certificate) http.RoundTripper {
+	if cert == nil {
+		return nil
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{Certificates: []tls.Certificate{*cert}}
+	return t
+}
@@ -0,0 +1,56 @@
+package backends
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientUsesRootCAs(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	if _, err := newHTTPClient(5*time.Second, nil).Get(server.URL); err == nil {
+		t.Fatal("expected the test server's certificate to be untrusted")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	RootCAs = pool
+	defer func() { RootCAs = nil }()
+	resp, err := newHTTPClient(5*time.Second, nil).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request with RootCAs failed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+// headerTransport sets a header on every request
+type headerTransport struct {
+	base http.RoundTripper
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Request-ID", "abc")
+	return t.base.RoundTrip(req)
+}
+
+func TestSearxngTransportWrapperOnlyWrapsSearxng(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("X-Request-ID"))
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer server.Close()
+
+	SearxngTransportWrapper = func(rt http.RoundTripper) http.RoundTripper { return headerTransport{rt} }
+	defer func() { SearxngTransportWrapper = nil }()
+	NewSearxngBackend(server.URL, "", "", "GET", 5*time.Second, false, false).Search(SearchOptions{Query: "go"})
+	NewMeilisearchBackend(server.URL, "docs", "", 5*time.Second).Search(SearchOptions{Query: "go"})
+	if len(got) != 2 || got[0] != "abc" || got[1] != "" {
+		t.Errorf("X-Request-ID sent: %q, want only SearXNG's", got)
+	}
+}
@@ -0,0 +1,722 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"sx/backends"
+)
+
+// getBookmarksFile is the index `sx bookmarks import` writes and the
+// bookmarks engine searches.
+func getBookmarksFile() string {
+	return filepath.Join(appDir(baseData), "bookmarks.json")
+}
+
+// browserProfile is a browser profile bookmarks can be imported from.
+type browserProfile struct {
+	Browser string // firefox, chrome, chromium, brave, edge or vivaldi
+	Name    string // the profile directory's name
+	Dir     string
+}
+
+// source names the profile in the index, e.g. "firefox:default-release".
+func (p browserProfile) source() string {
+	return p.Browser + ":" + p.Name
+}
+
+// browserNames are the --browser values.
+var browserNames = []string{"firefox", "chrome", "chromium", "brave", "edge", "vivaldi"}
+
+// chromiumDirs are where Chromium-based browsers keep their profiles on
+// Linux, relative to the home directory, macOS, relative to the home
+// directory, and Windows, relative to %LOCALAPPDATA%.
+var chromiumDirs = []struct{ browser, linux, darwin, windows string }{
+	{"chrome", ".config/google-chrome", "Library/Application Support/Google/Chrome", "Google/Chrome/User Data"},
+	{"chromium", ".config/chromium", "Library/Application Support/Chromium", "Chromium/User Data"},
+	{"brave", ".config/BraveSoftware/Brave-Browser", "Library/Application Support/BraveSoftware/Brave-Browser", "BraveSoftware/Brave-Browser/User Data"},
+	{"edge", ".config/microsoft-edge", "Library/Application Support/Microsoft Edge", "Microsoft/Edge/User Data"},
+	{"vivaldi", ".config/vivaldi", "Library/Application Support/Vivaldi", "Vivaldi/User Data"},
+}
+
+// profileRoots returns the directories holding each browser's profiles.
+func profileRoots(goos, home string) map[string][]string {
+	roots := make(map[string][]string)
+	switch goos {
+	case "darwin":
+		roots["firefox"] = []string{filepath.Join(home, "Library/Application Support/Firefox/Profiles")}
+	case "windows":
+		roots["firefox"] = []string{filepath.Join(os.Getenv("APPDATA"), "Mozilla/Firefox/Profiles")}
+	default:
+		roots["firefox"] = []string{
+			filepath.Join(home, ".mozilla/firefox"),
+			filepath.Join(home, "snap/firefox/common/.mozilla/firefox"),
+			filepath.Join(home, ".var/app/org.mozilla.firefox/.mozilla/firefox"),
+		}
+	}
+	for _, c := range chromiumDirs {
+		switch goos {
+		case "darwin":
+			roots[c.browser] = []string{filepath.Join(home, c.darwin)}
+		case "windows":
+			roots[c.browser] = []string{filepath.Join(os.Getenv("LOCALAPPDATA"), c.windows)}
+		default:
+			roots[c.browser] = []string{filepath.Join(home, c.linux)}
+		}
+	}
+	return roots
+}
+
+// findBrowserProfiles lists the profiles of the given browsers found under
+// home, all browsers if none are given.
+func findBrowserProfiles(goos, home string, browsers []string) []browserProfile {
+	if len(browsers) == 0 {
+		browsers = browserNames
+	}
+	roots := profileRoots(goos, home)
+	var profiles []browserProfile
+	for _, browser := range browsers {
+		// Firefox keeps bookmarks and history in places.sqlite, Chromium
+		// in Bookmarks and History
+		marker := []string{"Bookmarks", "History"}
+		if browser == "firefox" {
+			marker = []string{"places.sqlite"}
+		}
+		seen := make(map[string]bool)
+		for _, root := range roots[browser] {
+			for _, name := range marker {
+				matches, _ := filepath.Glob(filepath.Join(root, "*", name))
+				sort.Strings(matches)
+				for _, match := range matches {
+					dir := filepath.Dir(match)
+					if seen[dir] {
+						continue
+					}
+					seen[dir] = true
+					profiles = append(profiles, browserProfile{Browser: browser, Name: filepath.Base(dir), Dir: dir})
+				}
+			}
+		}
+	}
+	return profiles
+}
+
+// importableURL reports whether a bookmark's URL is a page worth searching,
+// not a bookmarklet, saved query or browser page.
+func importableURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https", "ftp", "file":
+		return true
+	}
+	return false
+}
+
+// firefoxRoots name Firefox's root folders by their GUIDs.
+var firefoxRoots = map[string]string{
+	"menu________": "Bookmarks Menu",
+	"toolbar_____": "Bookmarks Toolbar",
+	"unfiled_____": "Other Bookmarks",
+	"mobile______": "Mobile Bookmarks",
+	"tags________": "Tags",
+	"root________": "",
+}
+
+func rowString(row map[string]interface{}, column string) string {
+	s, _ := row[column].(string)
+	return s
+}
+
+func rowInt(row map[string]interface{}, column string) int64 {
+	switch v := row[column].(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// unixMicro converts microseconds since 1970, as Firefox keeps times, to a
+// time, zero for none.
+func unixMicro(us int64) time.Time {
+	if us <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(us).UTC()
+}
+
+// chromeTime converts microseconds since 1601, as Chromium keeps times, to
+// a time, zero for none.
+func chromeTime(us int64) time.Time {
+	const epochOffset = 11644473600000000 // from 1601 to 1970
+	if us <= epochOffset {
+		return time.Time{}
+	}
+	return time.UnixMicro(us - epochOffset).UTC()
+}
+
+// firefoxFolder is a folder of moz_bookmarks.
+type firefoxFolder struct {
+	title  string
+	guid   string
+	parent int64
+}
+
+// importFirefoxPlaces reads the bookmarks, with their tags, and with
+// history also the visited pages from a Firefox places.sqlite.
+func importFirefoxPlaces(db *sqliteDB, source string, history bool) ([]backends.Bookmark, error) {
+	type place struct {
+		url, title string
+		visits     int
+		lastVisit  time.Time
+		hidden     bool
+	}
+	places := make(map[int64]place)
+	err := db.rows("moz_places", func(row map[string]interface{}) {
+		places[rowInt(row, "id")] = place{
+			url:       rowString(row, "url"),
+			title:     rowString(row, "title"),
+			visits:    int(rowInt(row, "visit_count")),
+			lastVisit: unixMicro(rowInt(row, "last_visit_date")),
+			hidden:    rowInt(row, "hidden") != 0,
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	folders := make(map[int64]firefoxFolder)
+	var items []map[string]interface{}
+	err = db.rows("moz_bookmarks", func(row map[string]interface{}) {
+		switch rowInt(row, "type") {
+		case 1:
+			items = append(items, row)
+		case 2:
+			folders[rowInt(row, "id")] = firefoxFolder{title: rowString(row, "title"), guid: rowString(row, "guid"), parent: rowInt(row, "parent")}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Tagging a page files a bookmark of it in a folder, named after the
+	// tag, under the tags root
+	tags := make(map[int64][]string)
+	var bookmarks []backends.Bookmark
+	bookmarked := make(map[int64]int)
+	for _, item := range items {
+		parent := folders[rowInt(item, "parent")]
+		if folders[parent.parent].guid == "tags________" {
+			fk := rowInt(item, "fk")
+			tags[fk] = append(tags[fk], parent.title)
+			continue
+		}
+		p, ok := places[rowInt(item, "fk")]
+		if !ok || !importableURL(p.url) {
+			continue
+		}
+		title := rowString(item, "title")
+		if title == "" {
+			title = p.title
+		}
+		bookmarked[rowInt(item, "fk")] = len(bookmarks)
+		bookmarks = append(bookmarks, backends.Bookmark{
+			Title:     title,
+			URL:       p.url,
+			Folder:    firefoxFolderPath(folders, rowInt(item, "parent")),
+			Source:    source,
+			Added:     unixMicro(rowInt(item, "dateAdded")),
+			LastVisit: p.lastVisit,
+			Visits:    p.visits,
+		})
+	}
+	for fk, names := range tags {
+		if i, ok := bookmarked[fk]; ok {
+			sort.Strings(names)
+			bookmarks[i].Tags = names
+		}
+	}
+
+	if history {
+		ids := make([]int64, 0, len(places))
+		for id := range places {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			p := places[id]
+			if _, ok := bookmarked[id]; ok || p.visits == 0 || p.hidden || !importableURL(p.url) {
+				continue
+			}
+			bookmarks = append(bookmarks, backends.Bookmark{
+				Title: p.title, URL: p.url, Source: source, History: true,
+				LastVisit: p.lastVisit, Visits: p.visits,
+			})
+		}
+	}
+	return bookmarks, nil
+}
+
+// firefoxFolderPath names the folder with the given id by its path from
+// the root, e.g. "Bookmarks Toolbar / Go".
+func firefoxFolderPath(folders map[int64]firefoxFolder, id int64) string {
+	var path []string
+	for depth := 0; depth < 64; depth++ {
+		folder, ok := folders[id]
+		if !ok {
+			break
+		}
+		name, isRoot := firefoxRoots[folder.guid]
+		if !isRoot {
+			name = folder.title
+		}
+		if name != "" {
+			path = append([]string{name}, path...)
+		}
+		if isRoot {
+			break
+		}
+		id = folder.parent
+	}
+	return strings.Join(path, " / ")
+}
+
+// chromeNode is a bookmark or folder of a Chromium Bookmarks file.
+type chromeNode struct {
+	Type      string       `json:"type"` // url or folder
+	Name      string       `json:"name"`
+	URL       string       `json:"url"`
+	DateAdded string       `json:"date_added"` // microseconds since 1601
+	Children  []chromeNode `json:"children"`
+}
+
+// importChromeBookmarks reads a Chromium Bookmarks file.
+func importChromeBookmarks(data []byte, source string) ([]backends.Bookmark, error) {
+	var file struct {
+		Roots struct {
+			BookmarkBar chromeNode `json:"bookmark_bar"`
+			Other       chromeNode `json:"other"`
+			Synced      chromeNode `json:"synced"`
+		} `json:"roots"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	var bookmarks []backends.Bookmark
+	var walk func(node chromeNode, folder string)
+	walk = func(node chromeNode, folder string) {
+		if node.Type == "url" {
+			if importableURL(node.URL) {
+				added, _ := strconv.ParseInt(node.DateAdded, 10, 64)
+				bookmarks = append(bookmarks, backends.Bookmark{
+					Title: node.Name, URL: node.URL, Folder: folder, Source: source, Added: chromeTime(added),
+				})
+			}
+			return
+		}
+		if node.Name != "" {
+			folder = strings.TrimPrefix(folder+" / "+node.Name, " / ")
+		}
+		for _, child := range node.Children {
+			walk(child, folder)
+		}
+	}
+	walk(file.Roots.BookmarkBar, "")
+	walk(file.Roots.Other, "")
+	walk(file.Roots.Synced, "")
+	return bookmarks, nil
+}
+
+// importChromeHistory reads the visited pages from a Chromium History
+// database.
+func importChromeHistory(db *sqliteDB, source string) ([]backends.Bookmark, error) {
+	var visited []backends.Bookmark
+	err := db.rows("urls", func(row map[string]interface{}) {
+		link := rowString(row, "url")
+		if rowInt(row, "visit_count") == 0 || rowInt(row, "hidden") != 0 || !importableURL(link) {
+			return
+		}
+		visited = append(visited, backends.Bookmark{
+			Title: rowString(row, "title"), URL: link, Source: source, History: true,
+			LastVisit: chromeTime(rowInt(row, "last_visit_time")),
+			Visits:    int(rowInt(row, "visit_count")),
+		})
+	})
+	return visited, err
+}
+
+// mozNode is a bookmark or folder of a Firefox bookmark backup.
+type mozNode struct {
+	GUID      string    `json:"guid"`
+	Title     string    `json:"title"`
+	Type      string    `json:"type"` // text/x-moz-place, -container or -separator
+	URI       string    `json:"uri"`
+	Tags      string    `json:"tags"`      // comma-separated
+	DateAdded int64     `json:"dateAdded"` // microseconds since 1970
+	Children  []mozNode `json:"children"`
+}
+
+// importFirefoxBackup reads a Firefox bookmark backup in JSON, as Firefox
+// saves it with Backup… in the bookmarks library.
+func importFirefoxBackup(data []byte, source string) ([]backends.Bookmark, error) {
+	var root mozNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	var bookmarks []backends.Bookmark
+	var walk func(node mozNode, folder string)
+	walk = func(node mozNode, folder string) {
+		switch node.Type {
+		case "text/x-moz-place":
+			if importableURL(node.URI) {
+				var tags []string
+				for _, tag := range strings.Split(node.Tags, ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						tags = append(tags, tag)
+					}
+				}
+				bookmarks = append(bookmarks, backends.Bookmark{
+					Title: node.Title, URL: node.URI, Folder: folder, Tags: tags, Source: source, Added: unixMicro(node.DateAdded),
+				})
+			}
+		case "text/x-moz-place-container":
+			name, isRoot := firefoxRoots[node.GUID]
+			if !isRoot {
+				name = node.Title
+			}
+			// Tag folders only repeat what Tags says
+			if node.GUID == "tags________" {
+				return
+			}
+			if name != "" {
+				folder = strings.TrimPrefix(folder+" / "+name, " / ")
+			}
+			for _, child := range node.Children {
+				walk(child, folder)
+			}
+		}
+	}
+	walk(root, "")
+	return bookmarks, nil
+}
+
+// importBookmarksHTML reads the bookmarks.html every browser exports, in
+// the Netscape bookmark file format: folders are <H3> headings followed by
+// a <DL> list of their contents.
+func importBookmarksHTML(r io.Reader, source string) ([]backends.Bookmark, error) {
+	z := html.NewTokenizer(r)
+	var bookmarks []backends.Bookmark
+	var folders []string
+	pending := "" // the heading of the folder whose list comes next
+	var current *backends.Bookmark
+	inHeading := false
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return bookmarks, nil
+			}
+			return nil, z.Err()
+		case html.StartTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "h3":
+				inHeading, pending = true, ""
+			case "dl":
+				folders = append(folders, pending)
+				pending = ""
+			case "a":
+				b := backends.Bookmark{Source: source}
+				for hasAttr {
+					var key, value []byte
+					key, value, hasAttr = z.TagAttr()
+					switch string(key) {
+					case "href":
+						b.URL = string(value)
+					case "add_date":
+						if seconds, err := strconv.ParseInt(string(value), 10, 64); err == nil && seconds > 0 {
+							b.Added = time.Unix(seconds, 0).UTC()
+						}
+					case "tags":
+						for _, tag := range strings.Split(string(value), ",") {
+							if tag = strings.TrimSpace(tag); tag != "" {
+								b.Tags = append(b.Tags, tag)
+							}
+						}
+					}
+				}
+				current = &b
+			}
+		case html.TextToken:
+			switch {
+			case inHeading:
+				pending += string(z.Text())
+			case current != nil:
+				current.Title += string(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "h3":
+				inHeading = false
+				pending = strings.TrimSpace(pending)
+			case "dl":
+				if len(folders) > 0 {
+					folders = folders[:len(folders)-1]
+				}
+			case "a":
+				if current != nil && importableURL(current.URL) {
+					current.Title = strings.TrimSpace(current.Title)
+					var path []string
+					for _, folder := range folders {
+						if folder != "" {
+							path = append(path, folder)
+						}
+					}
+					current.Folder = strings.Join(path, " / ")
+					bookmarks = append(bookmarks, *current)
+				}
+				current = nil
+			}
+		}
+	}
+}
+
+// importProfile reads a browser profile's bookmarks and, with history, the
+// pages visited in it.
+func importProfile(p browserProfile, history bool) ([]backends.Bookmark, error) {
+	if p.Browser == "firefox" {
+		db, err := openSQLite(filepath.Join(p.Dir, "places.sqlite"))
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		return importFirefoxPlaces(db, p.source(), history)
+	}
+
+	var bookmarks []backends.Bookmark
+	data, err := os.ReadFile(filepath.Join(p.Dir, "Bookmarks"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if bookmarks, err = importChromeBookmarks(data, p.source()); err != nil {
+			return nil, fmt.Errorf("Bookmarks: %v", err)
+		}
+	}
+	if history {
+		db, err := openSQLite(filepath.Join(p.Dir, "History"))
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		visited, err := importChromeHistory(db, p.source())
+		if err != nil {
+			return nil, fmt.Errorf("History: %v", err)
+		}
+		bookmarks = append(bookmarks, visited...)
+	}
+	return bookmarks, nil
+}
+
+// importBookmarkFile reads bookmarks from a file given with --file, telling
+// its format from its content: a Firefox places.sqlite or Chromium History
+// database, a Chromium Bookmarks file, a Firefox backup or an exported
+// bookmarks.html.
+func importBookmarkFile(path string, history bool) ([]backends.Bookmark, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	source := "file:" + filepath.Base(path)
+	switch {
+	case bytes.HasPrefix(data, []byte("SQLite format 3\x00")):
+		db, err := openSQLite(path)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		tables, err := db.tables()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case slices.Contains(tables, "moz_bookmarks"):
+			return importFirefoxPlaces(db, source, history)
+		case slices.Contains(tables, "urls"):
+			return importChromeHistory(db, source)
+		}
+		return nil, fmt.Errorf("%s is neither a Firefox places.sqlite nor a Chromium History database", path)
+	case bytes.HasPrefix(data, []byte("mozLz40\x00")):
+		return nil, fmt.Errorf("compressed Firefox backups (.jsonlz4) aren't supported; import the profile's places.sqlite or a backup saved as .json")
+	case bytes.Contains(data[:min(len(data), 4096)], []byte(`"roots"`)):
+		return importChromeBookmarks(data, source)
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")):
+		return importFirefoxBackup(data, source)
+	}
+	return importBookmarksHTML(bytes.NewReader(data), source)
+}
+
+// mergeBookmarks drops the repeated URLs of a source, keeping the first
+// bookmark of each with the tags and visits of the others. Visited pages
+// that are also bookmarked are kept only as bookmarks. Sources are kept
+// apart so that importing one again replaces only its own entries.
+func mergeBookmarks(entries []backends.Bookmark) []backends.Bookmark {
+	sort.SliceStable(entries, func(i, j int) bool { return !entries[i].History && entries[j].History })
+	index := make(map[string]int)
+	var merged []backends.Bookmark
+	for _, e := range entries {
+		i, ok := index[e.URL]
+		if !ok {
+			index[e.URL] = len(merged)
+			merged = append(merged, e)
+			continue
+		}
+		m := &merged[i]
+		if m.Title == "" {
+			m.Title = e.Title
+		}
+		// Visit counts of the same page in one browser are repeated, not
+		// added up
+		m.Visits = max(m.Visits, e.Visits)
+		if e.LastVisit.After(m.LastVisit) {
+			m.LastVisit = e.LastVisit
+		}
+		for _, tag := range e.Tags {
+			if !containsFold(m.Tags, tag) {
+				m.Tags = append(m.Tags, tag)
+			}
+		}
+	}
+	return merged
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// runBookmarksImport imports bookmarks, and with history visited pages,
+// from files or else from the profiles of the given browsers (all found if
+// none are given) into the bookmark index. Entries from the sources
+// imported replace those imported from them before.
+func runBookmarksImport(browsers, files []string, history bool) error {
+	for _, browser := range browsers {
+		if !containsFold(browserNames, browser) {
+			return fmt.Errorf("unknown browser %q, use: %s", browser, strings.Join(browserNames, ", "))
+		}
+	}
+	type imported struct {
+		source  string
+		entries []backends.Bookmark
+	}
+	var sources []imported
+	failed := 0
+	report := func(source string, entries []backends.Bookmark, err error) {
+		if err != nil {
+			failed++
+			printNotice("%s: %v", source, err)
+			return
+		}
+		sources = append(sources, imported{source, mergeBookmarks(entries)})
+	}
+
+	if len(files) > 0 {
+		for _, path := range files {
+			entries, err := importBookmarkFile(path, history)
+			report("file:"+filepath.Base(path), entries, err)
+		}
+	}
+	if len(files) == 0 || len(browsers) > 0 {
+		for i := range browsers {
+			browsers[i] = strings.ToLower(browsers[i])
+		}
+		home, _ := os.UserHomeDir()
+		profiles := findBrowserProfiles(runtime.GOOS, home, browsers)
+		if len(profiles) == 0 {
+			return fmt.Errorf("no browser profiles found; export your bookmarks and import them with --file")
+		}
+		for _, p := range profiles {
+			entries, err := importProfile(p, history)
+			report(p.source(), entries, err)
+		}
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("nothing could be imported")
+	}
+
+	path := getBookmarksFile()
+	index := &backends.BookmarkIndex{}
+	if previous, err := backends.LoadBookmarkIndex(path); err == nil {
+		index = previous
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	replaced := make(map[string]bool)
+	for _, s := range sources {
+		replaced[s.source] = true
+	}
+	var entries []backends.Bookmark
+	for _, b := range index.Bookmarks {
+		if !replaced[b.Source] {
+			entries = append(entries, b)
+		}
+	}
+	for _, s := range sources {
+		entries = append(entries, s.entries...)
+		if !containsFold(index.Sources, s.source) {
+			index.Sources = append(index.Sources, s.source)
+		}
+	}
+	index.Bookmarks = entries
+	index.Imported = time.Now().UTC().Truncate(time.Second)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := writeJSONFile(path, index); err != nil {
+		return err
+	}
+
+	for _, s := range sources {
+		bookmarks, visited := 0, 0
+		for _, b := range s.entries {
+			if b.History {
+				visited++
+			} else {
+				bookmarks++
+			}
+		}
+		line := fmt.Sprintf("%s: %d bookmarks", s.source, bookmarks)
+		if history {
+			line += fmt.Sprintf(", %d visited pages", visited)
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("%d entries in %s\n", len(index.Bookmarks), path)
+	if failed > 0 {
+		printNotice("%d sources could not be imported", failed)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sx/backends"
+)
+
+func TestImportFirefoxPlaces(t *testing.T) {
+	needSQLite(t)
+	db, err := openSQLite(filepath.Join("testdata", "bookmarks", "places.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	bookmarks, err := importFirefoxPlaces(db, "firefox:test", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The bookmarklet and the tag entry aren't bookmarks
+	if len(bookmarks) != 3 {
+		t.Fatalf("got %d bookmarks: %+v", len(bookmarks), bookmarks)
+	}
+	golang := bookmarks[0]
+	want := backends.Bookmark{
+		Title: "Go home", URL: "https://go.dev/", Folder: "Bookmarks Toolbar / Go", Tags: []string{"golang"},
+		Source: "firefox:test", Added: time.UnixMicro(1700000000000000 - 5000).UTC(),
+		LastVisit: time.UnixMicro(1700000000000000).UTC(), Visits: 12,
+	}
+	if !reflect.DeepEqual(golang, want) {
+		t.Errorf("got %+v\nwant %+v", golang, want)
+	}
+	// An untitled bookmark takes the page's title
+	if bookmarks[1].Title != "Long query" || bookmarks[1].Folder != "Other Bookmarks" {
+		t.Errorf("untitled bookmark: %+v", bookmarks[1])
+	}
+	if bookmarks[2].URL != "https://pkg.go.dev/" {
+		t.Errorf("bookmark from the write-ahead log: %+v", bookmarks[2])
+	}
+
+	withHistory, err := importFirefoxPlaces(db, "firefox:test", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rust and the filler pages; not hidden, unvisited or bookmarked ones
+	if len(withHistory) != 3+61 {
+		t.Fatalf("got %d entries with history", len(withHistory))
+	}
+	if rust := withHistory[3]; rust.URL != "https://doc.rust-lang.org/book/" || !rust.History || rust.Visits != 5 {
+		t.Errorf("first visited page: %+v", rust)
+	}
+}
+
+func TestImportChromeHistory(t *testing.T) {
+	needSQLite(t)
+	db, err := openSQLite(filepath.Join("testdata", "bookmarks", "History"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	visited, err := importChromeHistory(db, "chrome:Default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(visited) != 1 {
+		t.Fatalf("got %+v", visited)
+	}
+	if v := visited[0]; v.URL != "https://go.dev/doc/" || v.Visits != 4 || !v.LastVisit.Equal(time.UnixMicro(1700000000000000)) {
+		t.Errorf("got %+v", v)
+	}
+}
+
+func TestImportChromeBookmarks(t *testing.T) {
+	data := []byte(`{"checksum": "x", "roots": {
+		"bookmark_bar": {"type": "folder", "name": "Bookmarks bar", "children": [
+			{"type": "url", "name": "Go", "url": "https://go.dev/", "date_added": "13345000000000000"},
+			{"type": "folder", "name": "Rust", "children": [
+				{"type": "url", "name": "The Book", "url": "https://doc.rust-lang.org/book/", "date_added": "0"}
+			]}
+		]},
+		"other": {"type": "folder", "name": "Other bookmarks", "children": [
+			{"type": "url", "name": "Settings", "url": "chrome://settings/"}
+		]},
+		"synced": {"type": "folder", "name": "Mobile bookmarks", "children": []}
+	}, "version": 1}`)
+	bookmarks, err := importChromeBookmarks(data, "chrome:Default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 2 {
+		t.Fatalf("got %+v", bookmarks)
+	}
+	if b := bookmarks[0]; b.Folder != "Bookmarks bar" || !b.Added.Equal(time.Date(2023, 11, 21, 0, 26, 40, 0, time.UTC)) {
+		t.Errorf("got %+v", b)
+	}
+	if b := bookmarks[1]; b.Folder != "Bookmarks bar / Rust" || !b.Added.IsZero() {
+		t.Errorf("got %+v", b)
+	}
+}
+
+func TestImportFirefoxBackup(t *testing.T) {
+	backup := `{"guid": "root________", "title": "", "type": "text/x-moz-place-container", "children": [
+		{"guid": "toolbar_____", "title": "toolbar", "type": "text/x-moz-place-container", "children": [
+			{"title": "Go", "type": "text/x-moz-place", "uri": "https://go.dev/", "tags": "golang, lang", "dateAdded": 1700000000000000}
+		]},
+		{"guid": "tags________", "title": "tags", "type": "text/x-moz-place-container", "children": [
+			{"title": "golang", "type": "text/x-moz-place-container", "children": [
+				{"type": "text/x-moz-place", "uri": "https://go.dev/"}
+			]}
+		]}
+	]}`
+	bookmarks, err := importFirefoxBackup([]byte(backup), "file:backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []backends.Bookmark{{
+		Title: "Go", URL: "https://go.dev/", Folder: "Bookmarks Toolbar", Tags: []string{"golang", "lang"},
+		Source: "file:backup", Added: time.UnixMicro(1700000000000000).UTC(),
+	}}
+	if !reflect.DeepEqual(bookmarks, want) {
+		t.Errorf("got %+v", bookmarks)
+	}
+
+	path := filepath.Join(t.TempDir(), "bookmarks.jsonlz4")
+	os.WriteFile(path, []byte("mozLz40\x00\x10\x00\x00\x00"), 0644)
+	if _, err := importBookmarkFile(path, false); err == nil || !strings.Contains(err.Error(), ".jsonlz4") {
+		t.Errorf("compressed backup: %v", err)
+	}
+}
+
+func TestImportBookmarksHTML(t *testing.T) {
+	page := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000">Bookmarks bar</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev/" ADD_DATE="1700000000" TAGS="golang">The Go &amp; Programming Language</A>
+        <DT><H3>Rust</H3>
+        <DL><p>
+            <DT><A HREF="https://doc.rust-lang.org/book/">The Book</A>
+        </DL><p>
+        <DT><A HREF="javascript:void(0)">Bookmarklet</A>
+    </DL><p>
+    <DT><A HREF="https://example.org/">Example</A>
+</DL><p>
+`
+	bookmarks, err := importBookmarksHTML(strings.NewReader(page), "file:bookmarks.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, b := range bookmarks {
+		got = append(got, b.Title+" | "+b.Folder)
+	}
+	want := []string{"The Go & Programming Language | Bookmarks bar", "The Book | Bookmarks bar / Rust", "Example | "}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if b := bookmarks[0]; !reflect.DeepEqual(b.Tags, []string{"golang"}) || b.Added.Unix() != 1700000000 {
+		t.Errorf("got %+v", b)
+	}
+}
+
+func TestMergeBookmarks(t *testing.T) {
+	merged := mergeBookmarks([]backends.Bookmark{
+		{URL: "https://go.dev/", Title: "", History: true, Visits: 9, LastVisit: time.Unix(200, 0)},
+		{URL: "https://go.dev/", Title: "Go", Tags: []string{"golang"}, Visits: 2, LastVisit: time.Unix(100, 0)},
+		{URL: "https://go.dev/", Title: "Go (Chrome)", Tags: []string{"Golang", "lang"}},
+		{URL: "https://rust-lang.org/", Title: "Rust", History: true, Visits: 1},
+	})
+	if len(merged) != 2 {
+		t.Fatalf("got %+v", merged)
+	}
+	want := backends.Bookmark{URL: "https://go.dev/", Title: "Go", Tags: []string{"golang", "lang"}, Visits: 9, LastVisit: time.Unix(200, 0)}
+	if !reflect.DeepEqual(merged[0], want) || merged[1].Title != "Rust" {
+		t.Errorf("got %+v", merged)
+	}
+}
+
+func TestFindBrowserProfiles(t *testing.T) {
+	home := t.TempDir()
+	touch := func(path string) {
+		path = filepath.Join(home, path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, nil, 0644)
+	}
+	touch(".mozilla/firefox/abc.default-release/places.sqlite")
+	touch(".config/google-chrome/Default/Bookmarks")
+	touch(".config/google-chrome/Default/History")
+	touch(".config/google-chrome/Profile 1/History")
+	touch(".config/google-chrome/System Profile/Preferences")
+
+	var got []string
+	for _, p := range findBrowserProfiles("linux", home, nil) {
+		got = append(got, p.source())
+	}
+	want := []string{"firefox:abc.default-release", "chrome:Default", "chrome:Profile 1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if profiles := findBrowserProfiles("linux", home, []string{"brave"}); len(profiles) != 0 {
+		t.Errorf("got %+v", profiles)
+	}
+}
+
+func TestRunBookmarksImport(t *testing.T) {
+	needSQLite(t)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dir := t.TempDir()
+	html := filepath.Join(dir, "bookmarks.html")
+	os.WriteFile(html, []byte(`<DL><DT><A HREF="https://go.dev/">Go</A></DL>`), 0644)
+	places := filepath.Join("testdata", "bookmarks", "places.sqlite")
+
+	if err := runBookmarksImport(nil, []string{html, places}, false); err != nil {
+		t.Fatal(err)
+	}
+	index, err := backends.LoadBookmarkIndex(getBookmarksFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Bookmarks) != 4 || !reflect.DeepEqual(index.Sources, []string{"file:bookmarks.html", "file:places.sqlite"}) {
+		t.Errorf("got %d bookmarks from %q", len(index.Bookmarks), index.Sources)
+	}
+
+	// Importing a source again replaces its entries
+	os.WriteFile(html, []byte(`<DL><DT><A HREF="https://example.org/">Example</A></DL>`), 0644)
+	if err := runBookmarksImport(nil, []string{html}, false); err != nil {
+		t.Fatal(err)
+	}
+	index, _ = backends.LoadBookmarkIndex(getBookmarksFile())
+	urls := make(map[string]bool)
+	for _, b := range index.Bookmarks {
+		urls[b.URL] = true
+	}
+	if len(index.Bookmarks) != 4 || !urls["https://example.org/"] || !urls["https://go.dev/"] {
+		t.Errorf("after reimport: %+v", index.Bookmarks)
+	}
+
+	if err := runBookmarksImport([]string{"netscape"}, nil, false); err == nil {
+		t.Error("unknown browser didn't fail")
+	}
+}
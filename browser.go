@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// openResultURL opens rawURL, or with --print-url writes it to stdout for
+// SSH sessions and scripts. Man and tldr pages open in the terminal.
+func openResultURL(rawURL string, opts *SearchOptions, config *Config) error {
+	if opts.PrintURL {
+		fmt.Println(rawURL)
+		return nil
+	}
+	if isDocURL(rawURL) {
+		return openDoc(rawURL, config)
+	}
+	return openURL(rawURL, config)
+}
+
+// openURL opens rawURL with url_handler from the config, else the first
+// $BROWSER entry that starts, else the platform's URL handler.
+func openURL(rawURL string, config *Config) error {
+	commands := browserCommands(rawURL, config.URLHandler, os.Getenv("BROWSER"), runtime.GOOS)
+	if len(commands) == 0 {
+		return fmt.Errorf("unsupported platform")
+	}
+
+	var err error
+	for _, command := range commands {
+		if err = exec.Command(command[0], command[1:]...).Start(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// browserCommands lists the commands to try for opening rawURL. A
+// configured handler is used alone; $BROWSER may list several commands,
+// separated like PATH, before the platform default.
+func browserCommands(rawURL, handler, browserEnv, goos string) [][]string {
+	if command := handlerCommand(handler, rawURL); command != nil {
+		return [][]string{command}
+	}
+
+	var commands [][]string
+	for _, browser := range filepath.SplitList(browserEnv) {
+		if command := handlerCommand(browser, rawURL); command != nil {
+			commands = append(commands, command)
+		}
+	}
+	if command := handlerCommand(defaultURLHandlers[goos], rawURL); command != nil {
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// handlerCommand splits a handler command line and adds rawURL, in place
+// of "%s" when the handler contains it ($BROWSER convention).
+func handlerCommand(handler, rawURL string) []string {
+	command := strings.Fields(handler)
+	if len(command) == 0 {
+		return nil
+	}
+	substituted := false
+	for i, arg := range command[1:] {
+		if strings.Contains(arg, "%s") {
+			command[i+1] = strings.ReplaceAll(arg, "%s", rawURL)
+			substituted = true
+		}
+	}
+	if !substituted {
+		command = append(command, rawURL)
+	}
+	return command
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestBrowserCommands(t *testing.T) {
+	const u = "https://example.com/?q=a&b=c"
+
+	tests := []struct {
+		name       string
+		handler    string
+		browserEnv string
+		goos       string
+		want       [][]string
+	}{
+		{"windows default", "", "", "windows",
+			[][]string{{"rundll32", "url.dll,FileProtocolHandler", u}}},
+		{"linux default", "", "", "linux",
+			[][]string{{"xdg-open", u}}},
+		{"url_handler wins", "firefox --new-tab", "lynx", "linux",
+			[][]string{{"firefox", "--new-tab", u}}},
+		{"BROWSER list before default", "", "w3m:firefox %s", "linux",
+			[][]string{{"w3m", u}, {"firefox", u}, {"xdg-open", u}}},
+		{"unknown platform", "", "", "plan9", nil},
+	}
+	for _, tt := range tests {
+		got := browserCommands(u, tt.handler, tt.browserEnv, tt.goos)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// captureStdout returns what fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestPrintURL(t *testing.T) {
+	results := []SearchResult{
+		{URL: "https://go.dev/"},
+		{URL: "https://pkg.go.dev/"},
+		{URL: "https://gobyexample.com/"},
+	}
+	// A handler that would fail if anything were opened
+	config := &Config{URLHandler: "sx-no-such-browser"}
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want string
+	}{
+		{"--first", SearchOptions{First: true, PrintURL: true}, "https://go.dev/\n"},
+		{"--lucky", SearchOptions{Lucky: true, PrintURL: true}, pickLucky(results, false, rand.New(rand.NewSource(7))).URL + "\n"},
+	}
+	for _, tt := range tests {
+		var err error
+		out := captureStdout(t, func() {
+			chosen := chosenResult(results, &tt.opts, rand.New(rand.NewSource(7)))
+			err = openResultURL(chosen.URL, &tt.opts, config)
+		})
+		if err != nil || out != tt.want {
+			t.Errorf("%s: printed %q (%v), want %q", tt.name, out, err, tt.want)
+		}
+	}
+
+	opts := SearchOptions{First: true}
+	if err := openResultURL(results[0].URL, &opts, config); err == nil {
+		t.Error("without --print-url the URL wasn't handed to the handler")
+	}
+}
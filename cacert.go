@@ -0,0 +1,47 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	caPoolOnce sync.Once
+	caPool     *x509.CertPool
+)
+
+// rootCAs returns the system roots plus the PEM bundle from ca_cert, for
+// networks that intercept TLS, or nil when ca_cert isn't set. The bundle is
+// read once; if it can't be used the system roots stay in effect.
+func rootCAs(config *Config) *x509.CertPool {
+	if config.CACert == "" {
+		return nil
+	}
+	caPoolOnce.Do(func() {
+		pool, err := loadCABundle(config.CACert)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ca_cert: %v, using the system certificates\n", err)
+			return
+		}
+		caPool = pool
+	})
+	return caPool
+}
+
+// loadCABundle adds the certificates of a PEM bundle to the system roots.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	defer server.Close()
+
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCABundle(bundle); err != nil {
+		t.Errorf("loadCABundle() failed: %v", err)
+	}
+
+	empty := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCABundle(empty); err == nil {
+		t.Error("loadCABundle() accepted a file without certificates")
+	}
+}
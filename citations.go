@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxCitationBatch is how many works one OpenAlex request looks up.
+const maxCitationBatch = 50
+
+// The citation APIs: OpenAlex for DOIs, Semantic Scholar for arXiv
+// preprints and DOIs OpenAlex doesn't know.
+var (
+	openAlexAPI        = "https://api.openalex.org/works"
+	semanticScholarAPI = "https://api.semanticscholar.org/graph/v1/paper/batch"
+)
+
+// arxivVersion is the version suffix of an arXiv ID, which the citation
+// APIs don't take.
+var arxivVersion = regexp.MustCompile(`v\d+$`)
+
+// citationCache remembers counts, and works no API knows (-1), for the
+// rest of the run, so interactive paging doesn't look them up again.
+var citationCache = struct {
+	sync.Mutex
+	counts map[string]int
+}{counts: map[string]int{}}
+
+// citationKey identifies a result's work for the citation APIs: "DOI:..."
+// or "ARXIV:...", or "" for results that aren't papers.
+func citationKey(result SearchResult) string {
+	if doi := resultDOI(result); doi != "" {
+		return "DOI:" + strings.ToLower(doi)
+	}
+	if m := arxivAbsPattern.FindStringSubmatch(result.URL); m != nil {
+		return "ARXIV:" + arxivVersion.ReplaceAllString(m[1], "")
+	}
+	return ""
+}
+
+// enrichCitations sets the citation counts of results that are papers.
+// Works neither OpenAlex nor Semantic Scholar knows are left without one;
+// failed lookups are retried on the next call.
+func enrichCitations(results []SearchResult, config *Config) {
+	citationCache.Lock()
+	var missing []string
+	queued := map[string]bool{}
+	for _, result := range results {
+		key := citationKey(result)
+		if _, ok := citationCache.counts[key]; key != "" && !ok && !queued[key] {
+			queued[key] = true
+			missing = append(missing, key)
+		}
+	}
+	citationCache.Unlock()
+
+	if len(missing) > 0 {
+		client := setupHTTPClient(config)
+		found := map[string]int{}
+		failed := false
+		var dois []string
+		for _, key := range missing {
+			if doi, ok := strings.CutPrefix(key, "DOI:"); ok && !strings.ContainsAny(doi, "|,") {
+				dois = append(dois, doi)
+			}
+		}
+		for start := 0; start < len(dois); start += maxCitationBatch {
+			end := min(start+maxCitationBatch, len(dois))
+			if err := openAlexCitations(client, dois[start:end], found, config); err != nil {
+				failed = true
+				if config.Debug {
+					fmt.Fprintf(os.Stderr, "Debug: OpenAlex: %v\n", err)
+				}
+			}
+		}
+		var rest []string
+		for _, key := range missing {
+			if _, ok := found[key]; !ok {
+				rest = append(rest, key)
+			}
+		}
+		if len(rest) > 0 {
+			if err := semanticScholarCitations(client, rest, found); err != nil {
+				failed = true
+				if config.Debug {
+					fmt.Fprintf(os.Stderr, "Debug: Semantic Scholar: %v\n", err)
+				}
+			}
+		}
+
+		citationCache.Lock()
+		for _, key := range missing {
+			if count, ok := found[key]; ok {
+				citationCache.counts[key] = count
+			} else if !failed {
+				citationCache.counts[key] = -1
+			}
+		}
+		citationCache.Unlock()
+	}
+
+	citationCache.Lock()
+	defer citationCache.Unlock()
+	for i := range results {
+		if count, ok := citationCache.counts[citationKey(results[i])]; ok && count >= 0 {
+			results[i].Citations = &count
+		}
+	}
+}
+
+// openAlexCitations looks up the citation counts of dois in one request,
+// adding them to found by key.
+func openAlexCitations(client *http.Client, dois []string, found map[string]int, config *Config) error {
+	query := url.Values{
+		"filter":   {"doi:" + strings.Join(dois, "|")},
+		"per-page": {fmt.Sprint(maxCitationBatch)},
+		"select":   {"doi,cited_by_count"},
+	}
+	// OpenAlex serves identified clients from a faster pool
+	if email := unpaywallEmail(config); email != "" {
+		query.Set("mailto", email)
+	}
+	var body struct {
+		Results []struct {
+			DOI          string `json:"doi"`
+			CitedByCount int    `json:"cited_by_count"`
+		} `json:"results"`
+	}
+	if err := citationRequest(client, http.MethodGet, openAlexAPI+"?"+query.Encode(), nil, &body); err != nil {
+		return err
+	}
+	for _, work := range body.Results {
+		doi := strings.ToLower(strings.TrimPrefix(work.DOI, "https://doi.org/"))
+		found["DOI:"+doi] = work.CitedByCount
+	}
+	return nil
+}
+
+// semanticScholarCitations looks up the citation counts of works by key,
+// adding them to found.
+func semanticScholarCitations(client *http.Client, keys []string, found map[string]int) error {
+	payload, err := json.Marshal(map[string][]string{"ids": keys})
+	if err != nil {
+		return err
+	}
+	var body []*struct {
+		CitationCount int `json:"citationCount"`
+	}
+	if err := citationRequest(client, http.MethodPost, semanticScholarAPI+"?fields=citationCount", payload, &body); err != nil {
+		return err
+	}
+	for i, paper := range body {
+		if paper != nil && i < len(keys) {
+			found[keys[i]] = paper.CitationCount
+		}
+	}
+	return nil
+}
+
+func citationRequest(client *http.Client, method, endpoint string, payload []byte, v interface{}) error {
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "sx/"+version)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(v)
+}
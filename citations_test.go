@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCitationKey(t *testing.T) {
+	tests := []struct {
+		result SearchResult
+		want   string
+	}{
+		{SearchResult{DOI: "10.1038/Nature14539"}, "DOI:10.1038/nature14539"},
+		{SearchResult{URL: "https://doi.org/10.1145/3065386"}, "DOI:10.1145/3065386"},
+		{SearchResult{URL: "https://arxiv.org/abs/1706.03762v7"}, "ARXIV:1706.03762"},
+		{SearchResult{URL: "https://arxiv.org/pdf/2106.09685.pdf"}, "ARXIV:2106.09685"},
+		{SearchResult{URL: "https://go.dev/"}, ""},
+	}
+	for _, tt := range tests {
+		if got := citationKey(tt.result); got != tt.want {
+			t.Errorf("citationKey(%+v) = %q, want %q", tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestEnrichCitations(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+	citationCache.counts = map[string]int{}
+
+	var openAlexFilter string
+	var s2IDs []string
+	openAlex := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		openAlexFilter = r.URL.Query().Get("filter")
+		w.Write([]byte(`{"results": [{"doi": "https://doi.org/10.1234/known", "cited_by_count": 42}]}`))
+	}))
+	defer openAlex.Close()
+	s2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		s2IDs = body.IDs
+		w.Write([]byte(`[null, {"paperId": "x", "citationCount": 7}]`))
+	}))
+	defer s2.Close()
+	savedOA, savedS2 := openAlexAPI, semanticScholarAPI
+	defer func() { openAlexAPI, semanticScholarAPI = savedOA, savedS2 }()
+	openAlexAPI, semanticScholarAPI = openAlex.URL, s2.URL
+
+	results := []SearchResult{
+		{URL: "https://doi.org/10.1234/known"},
+		{URL: "https://doi.org/10.1234/unknown"},
+		{URL: "https://arxiv.org/abs/1706.03762v2"},
+		{URL: "https://go.dev/"},
+	}
+	enrichCitations(results, config)
+
+	if openAlexFilter != "doi:10.1234/known|10.1234/unknown" {
+		t.Errorf("OpenAlex filter %q", openAlexFilter)
+	}
+	if strings.Join(s2IDs, " ") != "DOI:10.1234/unknown ARXIV:1706.03762" {
+		t.Errorf("Semantic Scholar ids %q", s2IDs)
+	}
+	var got []int
+	for _, r := range results {
+		if r.Citations == nil {
+			got = append(got, -1)
+		} else {
+			got = append(got, *r.Citations)
+		}
+	}
+	if want := []int{42, -1, 7, -1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("citations = %v, want %v", got, want)
+	}
+
+	// Known and unknown works are cached, so a second run asks nothing.
+	openAlexFilter, s2IDs = "", nil
+	enrichCitations(results, config)
+	if openAlexFilter != "" || s2IDs != nil {
+		t.Errorf("second run looked up %q, %q", openAlexFilter, s2IDs)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that only identify the click or
+// campaign. Names ending in "_" are prefixes.
+var trackingParams = []string{
+	"utm_", "fbclid", "gclid", "gclsrc", "dclid", "gbraid", "wbraid",
+	"msclkid", "yclid", "twclid", "igshid", "mc_cid", "mc_eid",
+	"_hsenc", "_hsmi", "mkt_tok", "oly_anon_id", "oly_enc_id", "vero_id",
+}
+
+// redirectWrappers maps host and path of known click-tracking redirects to
+// the query parameter that carries the target URL.
+var redirectWrappers = []struct {
+	host  string // matches the host and its subdomains
+	path  string
+	param string
+}{
+	{"google.com", "/url", "q"},
+	{"google.com", "/url", "url"},
+	{"duckduckgo.com", "/l/", "uddg"},
+	{"facebook.com", "/l.php", "u"},
+	{"youtube.com", "/redirect", "q"},
+	{"out.reddit.com", "/", "url"},
+	{"steamcommunity.com", "/linkfilter/", "url"},
+	{"slack-redir.net", "/link", "url"},
+}
+
+// normalizeURL unwraps known redirect wrappers and strips tracking
+// parameters. Everything else, including parameter order, is preserved;
+// URLs that fail to parse are returned unchanged.
+func normalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	// Wrappers can be nested (a Google link to a Facebook redirect, ...)
+	for i := 0; i < 3; i++ {
+		target, ok := unwrapRedirect(u)
+		if !ok {
+			break
+		}
+		u = target
+	}
+
+	if u.RawQuery != "" {
+		kept := make([]string, 0, strings.Count(u.RawQuery, "&")+1)
+		for _, pair := range strings.Split(u.RawQuery, "&") {
+			name, _, _ := strings.Cut(pair, "=")
+			if decoded, err := url.QueryUnescape(name); err == nil {
+				name = decoded
+			}
+			if pair != "" && !isTrackingParam(name) {
+				kept = append(kept, pair)
+			}
+		}
+		u.RawQuery = strings.Join(kept, "&")
+		u.ForceQuery = false
+	}
+	return u.String()
+}
+
+// unwrapRedirect returns the target of a known redirect wrapper URL.
+func unwrapRedirect(u *url.URL) (*url.URL, bool) {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for _, w := range redirectWrappers {
+		if host != w.host && !strings.HasSuffix(host, "."+w.host) {
+			continue
+		}
+		if !strings.HasPrefix(u.Path, w.path) {
+			continue
+		}
+		value := u.Query().Get(w.param)
+		if value == "" {
+			continue
+		}
+		target, err := url.Parse(value)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			continue
+		}
+		return target, true
+	}
+	return nil, false
+}
+
+func isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range trackingParams {
+		if strings.HasSuffix(p, "_") && strings.HasPrefix(name, p) || name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeResults applies normalizeURL to each result's URL in place.
+func normalizeResults(results []SearchResult) []SearchResult {
+	for i := range results {
+		if results[i].URL != "" {
+			results[i].URL = normalizeURL(results[i].URL)
+		}
+	}
+	return results
+}
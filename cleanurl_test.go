@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://example.com/a?utm_source=x&id=5&UTM_Medium=y", "https://example.com/a?id=5"},
+		{"https://example.com/?fbclid=abc", "https://example.com/"},
+		{"https://example.com/p?b=2&gclid=1&a=1#top", "https://example.com/p?b=2&a=1#top"},
+		{"https://example.com/p?q=utm_source", "https://example.com/p?q=utm_source"},
+		{"https://www.google.com/url?q=https://go.dev/doc/%3Futm_campaign%3Dx&sa=U", "https://go.dev/doc/"},
+		{"https://duckduckgo.com/l/?uddg=https%3A%2F%2Fexample.org%2Fpage&rut=abc", "https://example.org/page"},
+		{"https://l.facebook.com/l.php?u=https%3A%2F%2Fwww.google.com%2Furl%3Fq%3Dhttps%253A%252F%252Fexample.net%252F", "https://example.net/"},
+		{"https://www.google.com/url?q=javascript:alert(1)", "https://www.google.com/url?q=javascript:alert(1)"},
+		{"https://www.youtube.com/watch?v=abc&feature=share", "https://www.youtube.com/watch?v=abc&feature=share"},
+		{"not a url", "not a url"},
+	}
+	for _, tt := range tests {
+		if got := normalizeURL(tt.in); got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeResultsDedupes(t *testing.T) {
+	page := normalizeResults([]SearchResult{
+		{URL: "https://example.com/a?utm_source=feed"},
+		{URL: "https://example.com/a"},
+		{Title: "no url"},
+	})
+	got := dedupeResults(nil, page)
+	if len(got) != 2 || got[0].URL != "https://example.com/a" {
+		t.Errorf("unexpected results: %v", got)
+	}
+}
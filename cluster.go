@@ -0,0 +1,156 @@
+package main
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// minHashSize is how many hash functions a result's signature has
+	minHashSize = 64
+	// clusterSimilarity is the estimated Jaccard similarity of their
+	// shingles above which two results count as saying the same
+	clusterSimilarity = 0.35
+)
+
+// shingles returns the two-word shingles of text, stopwords left out, or
+// its single words when it has only one.
+func shingles(text string) map[string]bool {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		stop := false
+		for _, set := range stopwordSets {
+			if set[word] {
+				stop = true
+				break
+			}
+		}
+		if !stop {
+			words = append(words, word)
+		}
+	}
+	set := map[string]bool{}
+	if len(words) == 1 {
+		set[words[0]] = true
+	}
+	for i := 0; i+1 < len(words); i++ {
+		set[words[i]+" "+words[i+1]] = true
+	}
+	return set
+}
+
+// minHash returns the MinHash signature of a shingle set, nil for an empty
+// set. The hash functions are one FNV hash remixed with a seed each.
+func minHash(set map[string]bool) []uint64 {
+	if len(set) == 0 {
+		return nil
+	}
+	sig := make([]uint64, minHashSize)
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for shingle := range set {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		base := h.Sum64()
+		for i := range sig {
+			// splitmix64 of the hash and the function's seed
+			x := base + uint64(i+1)*0x9e3779b97f4a7c15
+			x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+			x = (x ^ x>>27) * 0x94d049bb133111eb
+			x ^= x >> 31
+			sig[i] = min(sig[i], x)
+		}
+	}
+	return sig
+}
+
+// similarity estimates the Jaccard similarity of two signatures' sets.
+func similarity(a, b []uint64) float64 {
+	if a == nil || b == nil {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+// clusterResults groups results whose titles and snippets are similar and
+// reorders them so the best-ranked result of every cluster comes first,
+// in rank order, followed by the other members cluster by cluster.
+// Results in a cluster of two or more get its number in Cluster; the
+// first of each also counts the others in Similar.
+func clusterResults(results []SearchResult) {
+	sigs := make([][]uint64, len(results))
+	for i, result := range results {
+		sigs[i] = minHash(shingles(result.Title + " " + result.Content))
+	}
+
+	// Union-find over the pairs similar enough
+	parent := make([]int, len(results))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range results {
+		for j := i + 1; j < len(results); j++ {
+			if similarity(sigs[i], sigs[j]) >= clusterSimilarity {
+				// The better-ranked result stays the root
+				if a, b := find(i), find(j); a != b {
+					parent[max(a, b)] = min(a, b)
+				}
+			}
+		}
+	}
+
+	members := map[int][]int{}
+	for i := range results {
+		members[find(i)] = append(members[find(i)], i)
+	}
+	var leads, rest []SearchResult
+	cluster := 0
+	for i := range results {
+		group := members[i]
+		if len(group) == 0 {
+			continue
+		}
+		lead := results[i]
+		lead.Cluster, lead.Similar = 0, 0
+		if len(group) > 1 {
+			cluster++
+			lead.Cluster, lead.Similar = cluster, len(group)-1
+			for _, m := range group[1:] {
+				member := results[m]
+				member.Cluster, member.Similar = cluster, 0
+				rest = append(rest, member)
+			}
+		}
+		leads = append(leads, lead)
+	}
+	copy(results, append(leads, rest...))
+}
+
+// clusterNote tells where the rest of a result's cluster is listed.
+func clusterNote(result SearchResult) string {
+	switch {
+	case result.Similar > 0:
+		return tr("cluster_lead", result.Cluster, result.Similar)
+	case result.Cluster > 0:
+		return tr("cluster_member", result.Cluster)
+	}
+	return ""
+}
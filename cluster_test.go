@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSimilarityEstimatesJaccard(t *testing.T) {
+	a := minHash(shingles("stock markets fell sharply on monday after the central bank raised interest rates"))
+	b := minHash(shingles("Stock markets fell sharply on Monday after the central bank raised interest rates again"))
+	c := minHash(shingles("a recipe for sourdough bread with a long cold fermentation"))
+	if s := similarity(a, b); s < 0.7 {
+		t.Errorf("near-duplicates have similarity %.2f", s)
+	}
+	if s := similarity(a, c); s > 0.1 {
+		t.Errorf("unrelated texts have similarity %.2f", s)
+	}
+	if s := similarity(a, minHash(shingles("the and of"))); s != 0 {
+		t.Errorf("stopwords only: similarity %.2f", s)
+	}
+}
+
+func TestClusterResults(t *testing.T) {
+	results := []SearchResult{
+		{Title: "Markets fall after rate hike", Content: "Stock markets fell sharply on Monday after the central bank raised interest rates."},
+		{Title: "Sourdough basics", Content: "A recipe for sourdough bread with a long cold fermentation."},
+		{Title: "Rate hike sends markets lower", Content: "Stock markets fell sharply on Monday after the central bank raised interest rates by half a point."},
+		{Title: "Go 1.22 released", Content: "The release adds range over integers and fixes loop variable capture."},
+		{Title: "Markets fall after rate hike - Wire", Content: "Stock markets fell sharply on Monday after the central bank raised interest rates, traders said."},
+	}
+	clusterResults(results)
+
+	want := []string{"Markets fall after rate hike", "Sourdough basics", "Go 1.22 released", "Rate hike sends markets lower", "Markets fall after rate hike - Wire"}
+	if got := titles(results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("order = %q, want %q", got, want)
+	}
+	if results[0].Cluster != 1 || results[0].Similar != 2 {
+		t.Errorf("lead: cluster %d, similar %d", results[0].Cluster, results[0].Similar)
+	}
+	if results[1].Cluster != 0 || results[1].Similar != 0 {
+		t.Errorf("singleton: cluster %d, similar %d", results[1].Cluster, results[1].Similar)
+	}
+	if results[3].Cluster != 1 || results[3].Similar != 0 {
+		t.Errorf("member: cluster %d, similar %d", results[3].Cluster, results[3].Similar)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/fatih/color"
+)
+
+// maxCodeLines caps how much of a code block is shown per result.
+const maxCodeLines = 15
+
+// contentSegment is a run of result content: prose to be reflowed, or a
+// code block to be shown verbatim.
+type contentSegment struct {
+	text string
+	code bool
+	lang string
+}
+
+var (
+	// <pre>...</pre>, optionally wrapping <code class="language-x">
+	preBlockPattern = regexp.MustCompile(`(?is)<pre[^>]*>(.*?)</pre>`)
+	// ```lang\n...``` fenced blocks
+	fenceBlockPattern = regexp.MustCompile("(?s)```([\\w+#.-]*)[ \\t]*\\n(.*?)```")
+	codeLangPattern   = regexp.MustCompile(`(?i)class="[^"]*(?:language|lang)-([\w+#.-]+)`)
+	tagPattern        = regexp.MustCompile(`<[^>]*>`)
+)
+
+// splitCodeBlocks separates HTML <pre> and Markdown fenced code blocks from
+// the surrounding prose. Content without code blocks yields one prose
+// segment.
+func splitCodeBlocks(content string) []contentSegment {
+	type block struct {
+		start, end int
+		lang, code string
+	}
+	var blocks []block
+	for _, m := range preBlockPattern.FindAllStringSubmatchIndex(content, -1) {
+		inner := content[m[2]:m[3]]
+		lang := ""
+		if lm := codeLangPattern.FindStringSubmatch(inner); lm != nil {
+			lang = lm[1]
+		}
+		code := html.UnescapeString(tagPattern.ReplaceAllString(inner, ""))
+		blocks = append(blocks, block{m[0], m[1], lang, code})
+	}
+	for _, m := range fenceBlockPattern.FindAllStringSubmatchIndex(content, -1) {
+		overlaps := false
+		for _, b := range blocks {
+			if m[0] < b.end && b.start < m[1] {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			blocks = append(blocks, block{m[0], m[1], content[m[2]:m[3]], content[m[4]:m[5]]})
+		}
+	}
+	if len(blocks) == 0 {
+		return []contentSegment{{text: content}}
+	}
+
+	// Order blocks by position to interleave them with the prose
+	for i := 1; i < len(blocks); i++ {
+		for j := i; j > 0 && blocks[j].start < blocks[j-1].start; j-- {
+			blocks[j], blocks[j-1] = blocks[j-1], blocks[j]
+		}
+	}
+
+	var segments []contentSegment
+	pos := 0
+	for _, b := range blocks {
+		if prose := strings.TrimSpace(content[pos:b.start]); prose != "" {
+			segments = append(segments, contentSegment{text: prose})
+		}
+		if code := trimBlankLines(b.code); code != "" {
+			segments = append(segments, contentSegment{text: code, code: true, lang: b.lang})
+		}
+		pos = b.end
+	}
+	if prose := strings.TrimSpace(content[pos:]); prose != "" {
+		segments = append(segments, contentSegment{text: prose})
+	}
+	return segments
+}
+
+// hasCodeBlocks reports whether splitCodeBlocks found any code.
+func hasCodeBlocks(segments []contentSegment) bool {
+	for _, s := range segments {
+		if s.code {
+			return true
+		}
+	}
+	return false
+}
+
+// trimBlankLines drops leading and trailing blank lines and trailing
+// whitespace while keeping the indentation of the code itself.
+func trimBlankLines(code string) string {
+	lines := strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// highlightCode colors code for the terminal. The language comes from the
+// block's annotation, falling back to content analysis; unknown languages
+// and disabled colors return the code unchanged.
+func highlightCode(code, lang string) string {
+	if color.NoColor {
+		return code
+	}
+	var lexer chroma.Lexer
+	if lang != "" {
+		lexer = lexers.Get(lang)
+	}
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		return code
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return code
+	}
+	var buf bytes.Buffer
+	if err := formatters.Get("terminal256").Format(&buf, styles.Get("monokai"), iterator); err != nil {
+		return code
+	}
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+// printCodeAwareContent prints content with prose reflowed like regular
+// results and code blocks kept verbatim, highlighted and framed.
+func printCodeAwareContent(w io.Writer, segments []contentSegment, dim *color.Color, snippetWords int) {
+	for _, seg := range segments {
+		if !seg.code {
+			for _, line := range wrapText(formatContent(seg.text, snippetWords), getTerminalWidth()-5) {
+				fmt.Fprintf(w, "     %s\n", line)
+			}
+			continue
+		}
+
+		lines := strings.Split(seg.text, "\n")
+		truncated := len(lines) > maxCodeLines
+		if truncated {
+			lines = lines[:maxCodeLines]
+		}
+		highlighted := strings.Split(highlightCode(strings.Join(lines, "\n"), seg.lang), "\n")
+		for _, line := range highlighted {
+			fmt.Fprintf(w, "     %s %s\n", dim.Sprint("│"), line)
+		}
+		if truncated {
+			fmt.Fprintf(w, "     %s\n", dim.Sprint("│ ..."))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestSplitCodeBlocks_HTML(t *testing.T) {
+	content := `Use a map: <pre><code class="language-go">m := map[string]int{}
+if v, ok := m[&quot;k&quot;]; ok {
+	fmt.Println(v)
+}
+</code></pre> and check <b>ok</b>.`
+
+	segments := splitCodeBlocks(content)
+	if len(segments) != 3 {
+		t.Fatalf("expected 3 segments, got %d: %+v", len(segments), segments)
+	}
+	if segments[0].code || segments[0].text != "Use a map:" {
+		t.Errorf("unexpected leading prose: %+v", segments[0])
+	}
+	code := segments[1]
+	if !code.code || code.lang != "go" {
+		t.Errorf("expected go code segment, got %+v", code)
+	}
+	if !strings.Contains(code.text, "\n\tfmt.Println(v)\n") || !strings.Contains(code.text, `m["k"]`) {
+		t.Errorf("code formatting not preserved: %q", code.text)
+	}
+	if segments[2].code {
+		t.Errorf("expected trailing prose, got %+v", segments[2])
+	}
+}
+
+func TestSplitCodeBlocks_Fenced(t *testing.T) {
+	content := "Run this:\n```bash\n  go test ./...\n```\n"
+	segments := splitCodeBlocks(content)
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 segments, got %d: %+v", len(segments), segments)
+	}
+	if !segments[1].code || segments[1].lang != "bash" || segments[1].text != "  go test ./..." {
+		t.Errorf("unexpected code segment: %+v", segments[1])
+	}
+}
+
+func TestSplitCodeBlocks_NoCode(t *testing.T) {
+	segments := splitCodeBlocks("plain <code>inline</code> text")
+	if hasCodeBlocks(segments) {
+		t.Errorf("inline code should stay prose: %+v", segments)
+	}
+}
+
+func TestHighlightCode_NoColor(t *testing.T) {
+	saved := color.NoColor
+	defer func() { color.NoColor = saved }()
+
+	color.NoColor = true
+	code := "func main() {}"
+	if got := highlightCode(code, "go"); got != code {
+		t.Errorf("highlightCode() with colors disabled = %q", got)
+	}
+
+	color.NoColor = false
+	if got := highlightCode(code, "go"); got == code || !strings.Contains(got, "\x1b[") {
+		t.Errorf("highlightCode() did not add terminal colors: %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// minCollapseWords keeps short generic titles ("Home", "Sign in") from
+// being treated as the same story.
+const minCollapseWords = 3
+
+// titleSiteSuffix matches a trailing " - Site Name" or " | Site Name".
+var titleSiteSuffix = regexp.MustCompile(`\s+[-|–—·:]\s+[^-|–—·:]{1,40}$`)
+
+// normalizeTitle reduces a title to lowercase words without punctuation or
+// a trailing site name, so syndicated copies of a story compare equal.
+// Titles too short to identify a story return "".
+func normalizeTitle(title string) string {
+	title = titleSiteSuffix.ReplaceAllString(strings.TrimSpace(title), "")
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) < minCollapseWords {
+		return ""
+	}
+	return strings.Join(words, " ")
+}
+
+// collapseDuplicates drops results of page whose normalized title matches
+// an earlier result in existing or page, and records their domains in the
+// kept result's AlsoOn instead. Kept results in existing are updated in
+// place.
+func collapseDuplicates(existing, page []SearchResult) []SearchResult {
+	type duplicate struct{ key, domain string }
+
+	seen := make(map[string]bool, len(existing)+len(page))
+	for _, result := range existing {
+		seen[normalizeTitle(result.Title)] = true
+	}
+	fresh := page[:0:0]
+	var duplicates []duplicate
+	for _, result := range page {
+		key := normalizeTitle(result.Title)
+		if key != "" && seen[key] {
+			duplicates = append(duplicates, duplicate{key, extractDomain(result.URL, true)})
+			continue
+		}
+		seen[key] = true
+		fresh = append(fresh, result)
+	}
+	if len(duplicates) == 0 {
+		return fresh
+	}
+
+	kept := make(map[string]*SearchResult)
+	for _, results := range [][]SearchResult{existing, fresh} {
+		for i := range results {
+			key := normalizeTitle(results[i].Title)
+			if _, ok := kept[key]; !ok && key != "" {
+				kept[key] = &results[i]
+			}
+		}
+	}
+	for _, d := range duplicates {
+		addAlsoOn(kept[d.key], d.domain)
+	}
+	return fresh
+}
+
+// addAlsoOn records domain on result unless it is the result's own domain
+// or already listed.
+func addAlsoOn(result *SearchResult, domain string) {
+	if domain == "" || domain == extractDomain(result.URL, true) {
+		return
+	}
+	for _, d := range result.AlsoOn {
+		if d == domain {
+			return
+		}
+	}
+	result.AlsoOn = append(result.AlsoOn, domain)
+}
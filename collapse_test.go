@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Fed Raises Rates Again - Reuters", "fed raises rates again"},
+		{"Fed raises rates again | Yahoo Finance", "fed raises rates again"},
+		{"Fed raises rates, again!", "fed raises rates again"},
+		{"Home", ""},
+		{"Sign in - Google", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTitle(tt.in); got != tt.want {
+			t.Errorf("normalizeTitle(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCollapseDuplicates(t *testing.T) {
+	existing := []SearchResult{
+		{Title: "Fed raises rates again - Reuters", URL: "https://www.reuters.com/a"},
+	}
+	page := []SearchResult{
+		{Title: "Fed Raises Rates Again | Yahoo", URL: "https://finance.yahoo.com/b"},
+		{Title: "Storm hits the coast overnight", URL: "https://apnews.com/c"},
+		{Title: "Storm hits the coast overnight - NBC", URL: "https://www.nbcnews.com/d"},
+		{Title: "Fed raises rates again", URL: "https://reuters.com/e"},
+		{Title: "Home", URL: "https://one.example/"},
+		{Title: "Home", URL: "https://two.example/"},
+	}
+
+	got := collapseDuplicates(existing, page)
+
+	var urls []string
+	for _, r := range got {
+		urls = append(urls, r.URL)
+	}
+	wantURLs := []string{"https://apnews.com/c", "https://one.example/", "https://two.example/"}
+	if !reflect.DeepEqual(urls, wantURLs) {
+		t.Errorf("kept %v, want %v", urls, wantURLs)
+	}
+	if want := []string{"yahoo.com"}; !reflect.DeepEqual(existing[0].AlsoOn, want) {
+		t.Errorf("existing AlsoOn = %v, want %v", existing[0].AlsoOn, want)
+	}
+	if want := []string{"nbcnews.com"}; !reflect.DeepEqual(got[0].AlsoOn, want) {
+		t.Errorf("page AlsoOn = %v, want %v", got[0].AlsoOn, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -17,29 +18,139 @@ type Config struct {
 	SearxngUsername string   `toml:"searxng_username,omitempty"`
 	SearxngPassword string   `toml:"searxng_password,omitempty"`
 	ResultCount     int      `toml:"result_count"`
+	SnippetWords    int      `toml:"snippet_words"`
 	Categories      []string `toml:"categories,omitempty"`
 	SafeSearch      string   `toml:"safe_search"`
 	Engines         []string `toml:"engines,omitempty"`
 	Expand          bool     `toml:"expand"`
+	ShortDomains    bool     `toml:"short_domains"`
+	CleanURLs       bool     `toml:"clean_urls"`
+	CollapseTitles  bool     `toml:"collapse_titles"`
 	Language        string   `toml:"language,omitempty"`
 	HTTPMethod      string   `toml:"http_method"`
 	Timeout         float64  `toml:"timeout"`
+	FetchTimeout    float64  `toml:"fetch_timeout,omitempty"`
 	NoVerifySSL     bool     `toml:"no_verify_ssl"`
+	CACert          string   `toml:"ca_cert,omitempty"` // PEM bundle trusted besides the system roots
 	NoUserAgent     bool     `toml:"no_user_agent"`
 	NoColor         bool     `toml:"no_color"`
+	A11y            bool     `toml:"a11y,omitempty"`   // linear screen-reader output
+	Locale          string   `toml:"locale,omitempty"` // en or de; default from $LANG
 	URLHandler      string   `toml:"url_handler,omitempty"`
+	Hyperlinks      string   `toml:"hyperlinks,omitempty"` // auto, always or never
 	Debug           bool     `toml:"debug"`
+	DebugDump       string   `toml:"debug_dump,omitempty"`
+	PageCache       bool     `toml:"page_cache,omitempty"` // revalidate refetched pages instead of downloading them
 	DefaultOutput   string   `toml:"default_output,omitempty"`
 	HistoryEnabled  bool     `toml:"history_enabled"`
 	MaxHistory      int      `toml:"max_history"`
 
+	// Media player commands per category for --play, e.g. videos = "mpv"
+	Players map[string]string `toml:"players,omitempty"`
+	// Command that magnet links are handed to; the system handler if empty
+	TorrentClient string `toml:"torrent_client,omitempty"`
+	// Map provider (osm, google, apple) or URL template for --open-map
+	MapURL string `toml:"map_url,omitempty"`
+	// wttr.in compatible service for `sx weather`
+	WeatherURL string `toml:"weather_url,omitempty"`
+	// RDAP service for `sx whois`, which redirects to the registries' own
+	WhoisURL string `toml:"whois_url,omitempty"`
+	// Directory `sx paper` saves PDFs in; the current directory if empty
+	PaperDir string `toml:"paper_dir,omitempty"`
+	// Contact address sent to the Unpaywall API by --open-access
+	UnpaywallEmail string `toml:"unpaywall_email,omitempty"`
+	// Enrichers run on every search unless --enrich is given
+	Enrich []string `toml:"enrich,omitempty"`
+	// Tags shown with results from the listed domains, e.g.
+	// trusted = ["reuters.com"] or paywalled = ["nytimes.com"]
+	DomainTags map[string][]string `toml:"domain_tags,omitempty"`
+	// Look up archive.org copies of paywalled pages for --enrich paywall
+	// and read them for --text
+	ArchivePaywalled bool `toml:"archive_paywalled,omitempty"`
+
+	// SearXNG server-side preferences sent with every search: an encoded
+	// preferences token and/or a raw Cookie header
+	SearxngPreferences string `toml:"searxng_preferences,omitempty"`
+	SearxngCookie      string `toml:"searxng_cookie,omitempty"`
+	// SOCKS5 proxy for .onion SearXNG instances (default: local Tor)
+	TorProxy string `toml:"tor_proxy,omitempty"`
+	// PEM client certificate and key for SearXNG behind mutual TLS
+	SearxngClientCert string `toml:"searxng_client_cert,omitempty"`
+	SearxngClientKey  string `toml:"searxng_client_key,omitempty"`
+
+	// Restriction profiles and the one active by default
+	Profile  string             `toml:"profile,omitempty"`
+	Profiles map[string]Profile `toml:"profiles,omitempty"`
+
+	// Search shortcuts, each registered as a subcommand (e.g. `sx yt ...`)
+	Shortcuts map[string]Shortcut `toml:"shortcuts,omitempty"`
+
+	// SMTP server that `sx saved run --email` sends digests through
+	Email EmailConfig `toml:"email,omitempty"`
+
+	// Rerank or embeddings API that --rerank orders results with
+	Rerank RerankConfig `toml:"rerank,omitempty"`
+
+	// OpenAI-compatible chat API for `sx ask` and --extract-schema
+	LLM LLMConfig `toml:"llm,omitempty"`
+
 	// Multi-engine support
-	Engine          string       `toml:"engine"`
-	FallbackEngines []string     `toml:"fallback_engines,omitempty"`
-	EnginesBrave    BraveConfig  `toml:"engines_brave"`
-	EnginesTavily   TavilyConfig `toml:"engines_tavily"`
-	EnginesExa      ExaConfig    `toml:"engines_exa"`
-	EnginesJina     JinaConfig   `toml:"engines_jina"`
+	Engine               string              `toml:"engine"`
+	FallbackEngines      []string            `toml:"fallback_engines,omitempty"`
+	FallbackOn           []string            `toml:"fallback_on,omitempty"`    // failure classes that trigger fallback; all if empty
+	AnswerEngines        []string            `toml:"answer_engines,omitempty"` // asked for answers alongside every search
+	EnginesBrave         BraveConfig         `toml:"engines_brave"`
+	EnginesGoogle        GoogleCSEConfig     `toml:"engines_google"`
+	EnginesSerpAPI       SerpAPIConfig       `toml:"engines_serpapi"`
+	EnginesMojeek        MojeekConfig        `toml:"engines_mojeek"`
+	EnginesMarginalia    MarginaliaConfig    `toml:"engines_marginalia"`
+	EnginesTavily        TavilyConfig        `toml:"engines_tavily"`
+	EnginesExa           ExaConfig           `toml:"engines_exa"`
+	EnginesJina          JinaConfig          `toml:"engines_jina"`
+	EnginesPerplexity    PerplexityConfig    `toml:"engines_perplexity"`
+	EnginesGitHub        GitHubConfig        `toml:"engines_github"`
+	EnginesApps          AppsConfig          `toml:"engines_apps"`
+	EnginesStackExchange StackExchangeConfig `toml:"engines_stackexchange"`
+	EnginesCVE           CVEConfig           `toml:"engines_cve"`
+	EnginesElastic       ElasticConfig       `toml:"engines_elastic"`
+	EnginesMeilisearch   MeilisearchConfig   `toml:"engines_meilisearch"`
+	EnginesNotes         NotesConfig         `toml:"engines_notes"`
+	EnginesMan           ManConfig           `toml:"engines_man"`
+}
+
+// Shortcut is a named search preset from the [shortcuts] config table.
+// Flags given on the command line take precedence over its settings.
+type Shortcut struct {
+	Description string   `toml:"description,omitempty"`
+	Categories  []string `toml:"categories,omitempty"`
+	Engines     []string `toml:"engines,omitempty"` // SearXNG engines
+	Engine      string   `toml:"engine,omitempty"`  // search backend
+	Sites       []string `toml:"sites,omitempty"`
+}
+
+// EmailConfig holds the SMTP settings from the [email] config table.
+type EmailConfig struct {
+	Host     string   `toml:"host,omitempty"`
+	Port     int      `toml:"port,omitempty"` // 587 by default; 465 uses implicit TLS
+	Username string   `toml:"username,omitempty"`
+	Password string   `toml:"password,omitempty"` // or SX_SMTP_PASSWORD
+	From     string   `toml:"from,omitempty"`
+	To       []string `toml:"to,omitempty"`
+}
+
+// RerankConfig holds the API settings from the [rerank] config table.
+type RerankConfig struct {
+	API    string `toml:"api,omitempty"` // rerank (default), tei or embeddings
+	URL    string `toml:"url,omitempty"`
+	Model  string `toml:"model,omitempty"`
+	APIKey string `toml:"api_key,omitempty"` // or SX_RERANK_API_KEY
+}
+
+// LLMConfig holds the chat API settings from the [llm] config table.
+type LLMConfig struct {
+	URL    string `toml:"url,omitempty"` // base URL, e.g. http://localhost:11434/v1
+	Model  string `toml:"model,omitempty"`
+	APIKey string `toml:"api_key,omitempty"` // or SX_LLM_API_KEY
 }
 
 // BraveConfig holds Brave Search API configuration
@@ -47,12 +158,36 @@ type BraveConfig struct {
 	APIKey string `toml:"api_key,omitempty"`
 }
 
+// GoogleCSEConfig holds Google Programmable Search Engine configuration
+type GoogleCSEConfig struct {
+	APIKey string `toml:"api_key,omitempty"`
+	CX     string `toml:"cx,omitempty"` // search engine ID
+}
+
+// SerpAPIConfig holds SerpAPI configuration
+type SerpAPIConfig struct {
+	APIKey string `toml:"api_key,omitempty"`
+	Engine string `toml:"engine,omitempty"` // google, bing, duckduckgo, yahoo, ...
+}
+
+// MojeekConfig holds Mojeek Search API configuration
+type MojeekConfig struct {
+	APIKey string `toml:"api_key,omitempty"`
+}
+
+// MarginaliaConfig holds Marginalia Search API configuration
+type MarginaliaConfig struct {
+	APIKey string `toml:"api_key,omitempty"` // the shared "public" key if empty
+	Index  int    `toml:"index,omitempty"`   // search index (ranking profile), 0 by default
+}
+
 // TavilyConfig holds Tavily Search API configuration
 type TavilyConfig struct {
 	APIKey            string `toml:"api_key,omitempty"`
 	SearchDepth       string `toml:"search_depth,omitempty"`
 	IncludeRawContent bool   `toml:"include_raw_content,omitempty"`
 	IncludeAnswer     bool   `toml:"include_answer,omitempty"`
+	Topic             string `toml:"topic,omitempty"`
 }
 
 // ExaConfig holds Exa backend config for API and MCP modes.
@@ -62,6 +197,11 @@ type ExaConfig struct {
 	MCPURL     string `toml:"mcp_url,omitempty"`
 	MCPTool    string `toml:"mcp_tool,omitempty"`
 	NumResults int    `toml:"num_results,omitempty"`
+
+	// API mode only
+	Type          string `toml:"type,omitempty"` // auto | neural | keyword | fast
+	UseAutoprompt bool   `toml:"use_autoprompt,omitempty"`
+	Highlights    int    `toml:"highlights,omitempty"` // per result, 0 for none
 }
 
 // JinaConfig holds Jina backend config.
@@ -71,14 +211,86 @@ type JinaConfig struct {
 	BaseURL      string `toml:"base_url,omitempty"`
 }
 
+// PerplexityConfig holds Perplexity API configuration
+type PerplexityConfig struct {
+	APIKey string `toml:"api_key,omitempty"`
+	Model  string `toml:"model,omitempty"` // sonar (default), sonar-pro, sonar-reasoning, ...
+}
+
+// GitHubConfig holds the GitHub backend's token and what it searches
+type GitHubConfig struct {
+	Token string `toml:"token,omitempty"` // optional, raises the rate limit; GITHUB_TOKEN overrides it
+	Type  string `toml:"type,omitempty"`  // repositories (default), code (needs a token) or issues
+}
+
+// StackExchangeConfig holds the Stack Exchange backend's key and sites
+type StackExchangeConfig struct {
+	Key   string   `toml:"key,omitempty"`   // optional, raises the daily quota; STACKEXCHANGE_KEY overrides it
+	Sites []string `toml:"sites,omitempty"` // API site names, e.g. stackoverflow, superuser; stackoverflow by default
+}
+
+// CVEConfig holds the CVE backend's NVD API key
+type CVEConfig struct {
+	APIKey string `toml:"api_key,omitempty"` // optional, raises the NVD rate limit; NVD_API_KEY overrides it
+}
+
+// ElasticConfig holds the Elasticsearch or OpenSearch index the elastic
+// backend searches and the document fields its results are made of
+type ElasticConfig struct {
+	URL          string `toml:"url,omitempty"`           // e.g. http://localhost:9200
+	Index        string `toml:"index,omitempty"`         // index name, pattern or comma-separated list
+	Query        string `toml:"query,omitempty"`         // JSON query template, {{query}} is the search
+	APIKey       string `toml:"api_key,omitempty"`       // ELASTIC_API_KEY overrides it
+	Username     string `toml:"username,omitempty"`      // basic auth, without an API key
+	Password     string `toml:"password,omitempty"`      // ELASTIC_PASSWORD overrides it
+	TitleField   string `toml:"title_field,omitempty"`   // title by default
+	URLField     string `toml:"url_field,omitempty"`     // url by default
+	ContentField string `toml:"content_field,omitempty"` // content by default
+	DateField    string `toml:"date_field,omitempty"`    // needed for --time-range
+	ClientCert   string `toml:"client_cert,omitempty"`   // PEM client certificate for mutual TLS
+	ClientKey    string `toml:"client_key,omitempty"`
+}
+
+// MeilisearchConfig holds the Meilisearch index the meilisearch backend
+// searches and the document fields its results are made of
+type MeilisearchConfig struct {
+	URL          string `toml:"url,omitempty"`           // e.g. http://localhost:7700
+	Index        string `toml:"index,omitempty"`         // index UID
+	APIKey       string `toml:"api_key,omitempty"`       // MEILISEARCH_API_KEY overrides it
+	TitleField   string `toml:"title_field,omitempty"`   // title by default
+	URLField     string `toml:"url_field,omitempty"`     // url by default
+	ContentField string `toml:"content_field,omitempty"` // content by default
+	DateField    string `toml:"date_field,omitempty"`    // filterable Unix timestamps, for --time-range
+	ClientCert   string `toml:"client_cert,omitempty"`   // PEM client certificate for mutual TLS
+	ClientKey    string `toml:"client_key,omitempty"`
+}
+
+// AppsConfig holds the app stores the apps backend searches
+type AppsConfig struct {
+	Stores []string `toml:"stores,omitempty"` // flathub, fdroid; both by default
+}
+
+// NotesConfig holds the notes directory the notes backend searches
+type NotesConfig struct {
+	Dir string `toml:"dir,omitempty"` // Markdown and Org files; ~ is the home directory
+}
+
+// ManConfig holds where the man backend finds tldr pages
+type ManConfig struct {
+	TldrDir string `toml:"tldr_dir,omitempty"` // a "pages" directory; tldr clients' caches are found without it
+}
+
 const (
 	defaultSearxngURL      = "https://searxng.example.com"
 	defaultSearxngStrategy = "ordered"
 	defaultResultCount     = 10
+	defaultSnippetWords    = 128
 	defaultSafeSearch      = "strict"
 	defaultHTTPMethod      = "GET"
 	defaultTimeout         = 30.0
 	defaultExpand          = false
+	defaultCleanURLs       = true
+	defaultCollapseTitles  = true
 	defaultNoVerifySSL     = false
 	defaultNoUserAgent     = false
 	defaultNoColor         = false
@@ -86,12 +298,19 @@ const (
 	defaultDefaultOutput   = ""
 	defaultHistoryEnabled  = true
 	defaultMaxHistory      = 100
+	defaultMaxResults      = 200
 )
 
+// defaultURLHandlers open URLs when neither url_handler nor $BROWSER is
+// set. On Windows explorer.exe mangles URLs with query strings, so the
+// shell's URL protocol handler is invoked directly, as "start" does.
 var defaultURLHandlers = map[string]string{
 	"darwin":  "open",
 	"linux":   "xdg-open",
-	"windows": "explorer",
+	"freebsd": "xdg-open",
+	"openbsd": "xdg-open",
+	"netbsd":  "xdg-open",
+	"windows": "rundll32 url.dll,FileProtocolHandler",
 }
 
 func getConfigDir() string {
@@ -103,8 +322,11 @@ func getDefaultConfig() *Config {
 		SearxngURL:      "",
 		SearxngStrategy: defaultSearxngStrategy,
 		ResultCount:     defaultResultCount,
+		SnippetWords:    defaultSnippetWords,
 		SafeSearch:      defaultSafeSearch,
 		Expand:          defaultExpand,
+		CleanURLs:       defaultCleanURLs,
+		CollapseTitles:  defaultCollapseTitles,
 		HTTPMethod:      defaultHTTPMethod,
 		Timeout:         defaultTimeout,
 		NoVerifySSL:     defaultNoVerifySSL,
@@ -120,6 +342,10 @@ func getDefaultConfig() *Config {
 		// clients, while Brave's HTML results have proven trustworthy.
 		// Overridden by any fallback_engines value in the config file.
 		FallbackEngines: []string{"brave-web", "bing"},
+		Players: map[string]string{
+			"videos": "mpv",
+			"music":  "mpv --no-video",
+		},
 		EnginesTavily: TavilyConfig{
 			SearchDepth: "basic",
 		},
@@ -197,6 +423,15 @@ func deduplicateStrings(values []string) []string {
 	return out
 }
 
+// fetchTimeout is the per-page timeout for fetching result pages
+// (--html, --text). It falls back to the search timeout when unset.
+func (c *Config) fetchTimeout() time.Duration {
+	if c.FetchTimeout > 0 {
+		return time.Duration(c.FetchTimeout * float64(time.Second))
+	}
+	return time.Duration(c.Timeout * float64(time.Second))
+}
+
 func hasSearxngConfigured(config *Config) bool {
 	if strings.TrimSpace(config.SearxngURL) != "" {
 		return true
@@ -215,10 +450,12 @@ func createConfigFile(configDir, configFile string) error {
 		return err
 	}
 
-	// Prompt for SearXNG URL
-	fmt.Printf("Enter your SearXNG instance URL [%s]: ", defaultSearxngURL)
+	// Prompt for SearXNG URL; piped stdin holds the query, not an answer
 	var searxngURL string
-	fmt.Scanln(&searxngURL)
+	if isTerminal(os.Stdin) {
+		fmt.Fprintf(os.Stderr, "Enter your SearXNG instance URL [%s]: ", defaultSearxngURL)
+		fmt.Scanln(&searxngURL)
+	}
 	if strings.TrimSpace(searxngURL) == "" {
 		searxngURL = defaultSearxngURL
 	}
@@ -228,8 +465,11 @@ func createConfigFile(configDir, configFile string) error {
 		SearxngURL:      searxngURL,
 		SearxngStrategy: defaultSearxngStrategy,
 		ResultCount:     defaultResultCount,
+		SnippetWords:    defaultSnippetWords,
 		SafeSearch:      defaultSafeSearch,
 		Expand:          defaultExpand,
+		CleanURLs:       defaultCleanURLs,
+		CollapseTitles:  defaultCollapseTitles,
 		HTTPMethod:      defaultHTTPMethod,
 		Timeout:         defaultTimeout,
 		NoVerifySSL:     defaultNoVerifySSL,
@@ -259,6 +499,6 @@ func createConfigFile(configDir, configFile string) error {
 		return err
 	}
 
-	fmt.Printf("Created config file: %s\n", configFile)
+	fmt.Fprintf(os.Stderr, "Created config file: %s\n", configFile)
 	return nil
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestFetchTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      float64
+		fetchTimeout float64
+		want         time.Duration
+	}{
+		{"--fetch-timeout", 30, 5, 5 * time.Second},
+		{"fractional --fetch-timeout", 30, 0.25, 250 * time.Millisecond},
+		{"falls back to timeout", 2.5, 0, 2500 * time.Millisecond},
+		{"sub-second timeout", 0.5, 0, 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		config := &Config{Timeout: tt.timeout, FetchTimeout: tt.fetchTimeout}
+		if got := config.fetchTimeout(); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		if got := setupHTTPClient(config).Timeout; got != tt.want {
+			t.Errorf("%s: page client timeout %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFetchTimeoutFromConfigFile(t *testing.T) {
+	config := getDefaultConfig()
+	if _, err := toml.Decode("timeout = 1.5\nfetch_timeout = 0.75\n", config); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.fetchTimeout(); got != 750*time.Millisecond {
+		t.Errorf("got %v", got)
+	}
+	config.FetchTimeout = 0
+	if got := config.fetchTimeout(); got != 1500*time.Millisecond {
+		t.Errorf("fallback: got %v", got)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// maxSearchesPerTurn limits how often the model may search again
+	// before it has to answer
+	maxSearchesPerTurn = 2
+	// searchRequestPrefix starts a reply that asks for a search instead of
+	// answering
+	searchRequestPrefix = "SEARCH:"
+	// chatSystemPrompt adds re-searching to askSystemPrompt for follow-ups
+	chatSystemPrompt = askSystemPrompt + " The user may ask follow-up questions. " +
+		"If the sources so far can't answer one, reply with nothing but a line " +
+		"`" + searchRequestPrefix + " <search query>` and you will be given more sources, numbered on from the previous ones."
+)
+
+// conversation is an `sx ask --chat` session: the messages so far and the
+// sources they cite, numbered from 1 in the order they were found.
+type conversation struct {
+	messages   []chatMessage
+	sources    []askSource
+	n          int       // pages read per search
+	out        io.Writer // where answers stream to
+	transcript io.Writer // markdown log of the session; nil for none
+}
+
+// searchRequest returns the query of a reply that asks for a search.
+func searchRequest(reply string) (string, bool) {
+	query, ok := strings.CutPrefix(strings.TrimSpace(reply), searchRequestPrefix)
+	query = strings.Trim(strings.TrimSpace(query), "\"'`")
+	if !ok || query == "" || strings.Contains(query, "\n") {
+		return "", false
+	}
+	return query, true
+}
+
+// searchGate passes an answer through to w unless it turns out to be a
+// search request, holding back its start until that is clear.
+type searchGate struct {
+	w       io.Writer
+	held    bytes.Buffer
+	decided bool
+	search  bool
+}
+
+func (g *searchGate) Write(p []byte) (int, error) {
+	if g.decided {
+		if g.search {
+			return len(p), nil
+		}
+		return g.w.Write(p)
+	}
+	g.held.Write(p)
+	start := bytes.TrimLeft(g.held.Bytes(), " \t\r\n")
+	if len(start) < len(searchRequestPrefix) && bytes.HasPrefix([]byte(searchRequestPrefix), start) {
+		return len(p), nil
+	}
+	g.decided = true
+	g.search = bytes.HasPrefix(start, []byte(searchRequestPrefix))
+	if !g.search {
+		if _, err := g.w.Write(start); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// flush writes what is still held back, for answers shorter than the
+// search request prefix.
+func (g *searchGate) flush() error {
+	if g.decided {
+		return nil
+	}
+	g.decided = true
+	_, err := g.w.Write(bytes.TrimLeft(g.held.Bytes(), " \t\r\n"))
+	return err
+}
+
+// ask answers question, from sources found for it if given. When the
+// model asks for a search, the results are added as further sources and
+// the question goes to it again, up to maxSearchesPerTurn times. The
+// sources added in the turn are listed after the answer.
+func (c *conversation) ask(question string, sources []askSource) error {
+	first := len(c.sources) + 1
+	kept := len(c.messages)
+	content := question
+	if len(sources) > 0 {
+		content = sourcesPrompt(question, sources, first)
+		c.sources = append(c.sources, sources...)
+	}
+	c.messages = append(c.messages, chatMessage{Role: "user", Content: content})
+	c.log("## %s\n\n", question)
+
+	for searches := 0; ; searches++ {
+		gate := &searchGate{w: c.out}
+		reply, err := streamChat(config.LLM, c.messages, gate)
+		if err == nil {
+			err = gate.flush()
+		}
+		if err != nil {
+			// Forget the unanswered question so the session can go on
+			c.messages, c.sources = c.messages[:kept], c.sources[:first-1]
+			return err
+		}
+		c.messages = append(c.messages, chatMessage{Role: "assistant", Content: reply})
+
+		query, isSearch := searchRequest(reply)
+		if !isSearch {
+			if !strings.HasSuffix(reply, "\n") {
+				fmt.Fprintln(c.out)
+			}
+			c.log("%s\n\n", strings.TrimSpace(reply))
+			break
+		}
+		switch {
+		case searches > maxSearchesPerTurn:
+			c.messages, c.sources = c.messages[:kept], c.sources[:first-1]
+			return fmt.Errorf("the model kept asking for searches instead of answering")
+		case searches == maxSearchesPerTurn:
+			c.messages = append(c.messages, chatMessage{Role: "user", Content: "No more searches: answer from the sources you have."})
+			continue
+		}
+
+		printNotice("Searching for %q…", query)
+		c.log("_Searched for %q_\n\n", query)
+		found, err := searchSources(query, c.n)
+		if err != nil {
+			printNotice("Search failed: %v", err)
+		}
+		next := "No results were found for that search; answer from the sources you have."
+		if len(found) > 0 {
+			next = sourcesPrompt(question, found, len(c.sources)+1)
+			c.sources = append(c.sources, found...)
+		}
+		c.messages = append(c.messages, chatMessage{Role: "user", Content: next})
+	}
+
+	if added := c.sources[first-1:]; len(added) > 0 {
+		printSources(c.out, added, first)
+		fmt.Fprintln(c.out)
+		for i, source := range added {
+			c.log("[%d] [%s](%s)  \n", first+i, source.Title, source.URL)
+		}
+		c.log("\n")
+	}
+	return nil
+}
+
+// log appends to the session transcript.
+func (c *conversation) log(format string, args ...interface{}) {
+	if c.transcript != nil {
+		fmt.Fprintf(c.transcript, format, args...)
+	}
+}
+
+// transcriptPath is where the transcript of a session started with
+// question at start is kept, under the state directory.
+func transcriptPath(question string, start time.Time) string {
+	dir := getStateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "ask", start.Format("2006-01-02T150405")+"-"+slugify(question, 60)+".md")
+}
+
+// chat answers question, then the follow-up questions read from in, until
+// an empty line, "exit" or the end of input. The session is logged to a
+// markdown transcript in the state directory.
+func chat(question string, sources []askSource, n int, in io.Reader) error {
+	start := time.Now()
+	c := &conversation{
+		messages: []chatMessage{{Role: "system", Content: chatSystemPrompt}},
+		n:        n,
+		out:      os.Stdout,
+	}
+	if path := transcriptPath(question, start); path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			if f, err := os.Create(path); err == nil {
+				defer func() {
+					f.Close()
+					printNotice("Transcript saved to %s", path)
+				}()
+				c.transcript = f
+				c.log("# %s\n\n_%s, %s_\n\n", question, start.Format("2006-01-02 15:04"), config.LLM.Model)
+			}
+		}
+	}
+
+	if err := c.ask(question, sources); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Fprint(os.Stderr, "> ")
+		line, err := reader.ReadString('\n')
+		followUp := strings.TrimSpace(line)
+		if followUp == "" || followUp == "exit" || followUp == "quit" || followUp == "q" {
+			return nil
+		}
+		if askErr := c.ask(followUp, nil); askErr != nil {
+			fmt.Fprintln(os.Stderr, tr("error", askErr))
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
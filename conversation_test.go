@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchRequest(t *testing.T) {
+	tests := []struct {
+		reply string
+		query string
+		ok    bool
+	}{
+		{"SEARCH: go generics", "go generics", true},
+		{"  SEARCH: \"rust async\"\n", "rust async", true},
+		{"SEARCH:", "", false},
+		{"SEARCH: a\nand more", "", false},
+		{"Go has generics since 1.18 [1].", "", false},
+	}
+	for _, tt := range tests {
+		query, ok := searchRequest(tt.reply)
+		if query != tt.query || ok != tt.ok {
+			t.Errorf("searchRequest(%q) = %q, %v, want %q, %v", tt.reply, query, ok, tt.query, tt.ok)
+		}
+	}
+}
+
+func TestSearchGate(t *testing.T) {
+	write := func(parts ...string) (string, bool) {
+		var out bytes.Buffer
+		gate := &searchGate{w: &out}
+		for _, part := range parts {
+			gate.Write([]byte(part))
+		}
+		gate.flush()
+		return out.String(), gate.search
+	}
+	if out, search := write("\nSE", "ARCH: more", " results"); out != "" || !search {
+		t.Errorf("search request: wrote %q, search %v", out, search)
+	}
+	if out, search := write("\nSee", " [1]."); out != "See [1]." || search {
+		t.Errorf("answer: wrote %q, search %v", out, search)
+	}
+	if out, _ := write("SEA"); out != "SEA" {
+		t.Errorf("short answer: wrote %q", out)
+	}
+}
+
+func TestConversationAsk(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+	config.NoColor = true
+	t.Setenv("SX_LLM_API_KEY", "")
+
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\": [{\"delta\": {\"content\": \"Yes [1].\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+	config.LLM = LLMConfig{URL: server.URL, Model: "small"}
+
+	var out, transcript bytes.Buffer
+	c := &conversation{
+		messages:   []chatMessage{{Role: "system", Content: chatSystemPrompt}},
+		out:        &out,
+		transcript: &transcript,
+	}
+	sources := []askSource{{Title: "Go", URL: "https://go.dev", Text: "Go is a language."}}
+	if err := c.ask("is go a language?", sources); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ask("really?", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.messages) != 5 || c.messages[3].Content != "really?" || len(c.sources) != 1 {
+		t.Errorf("messages %+v, sources %+v", c.messages, c.sources)
+	}
+	if strings.Count(out.String(), "https://go.dev") != 1 {
+		t.Errorf("sources not listed once:\n%s", out.String())
+	}
+	for _, want := range []string{"## is go a language?\n\nYes [1].", "[1] [Go](https://go.dev)", "## really?"} {
+		if !strings.Contains(transcript.String(), want) {
+			t.Errorf("transcript lacks %q:\n%s", want, transcript.String())
+		}
+	}
+
+	fail = true
+	if err := c.ask("and then?", sources); err == nil {
+		t.Fatal("ask succeeded with a failing model")
+	}
+	if len(c.messages) != 5 || len(c.sources) != 1 {
+		t.Errorf("failed question kept: %d messages, %d sources", len(c.messages), len(c.sources))
+	}
+}
+
+func TestTranscriptPath(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", state)
+	start := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	want := filepath.Join(getStateDir(), "ask", "2024-05-01T093000-what-is-go.md")
+	if got := transcriptPath("What is Go?", start); got != want {
+		t.Errorf("transcriptPath = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// maxDumpBody caps how much of each request/response body --debug-dump
+// writes.
+const maxDumpBody = 1 << 20
+
+// sensitiveHeaders are always masked in debug output.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":        true,
+	"Proxy-Authorization":  true,
+	"Cookie":               true,
+	"Set-Cookie":           true,
+	"X-Api-Key":            true,
+	"X-Subscription-Token": true,
+}
+
+// sensitiveNamePattern matches header, query parameter and JSON field names
+// that carry credentials.
+var sensitiveNamePattern = regexp.MustCompile(`(?i)(^auth$|^key$|api[-_]?key|token|secret|passw(or)?d|authorization|signature|session[-_]?id|credential)`)
+
+// sensitiveJSONPattern matches "name": "value" pairs with sensitive names.
+var sensitiveJSONPattern = regexp.MustCompile(`(?i)("(?:auth|[\w-]*(?:api[-_]?key|token|secret|passw(?:or)?d|authorization|credential)[\w-]*)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// pathKeyHosts are APIs that take the API key as the first path segment,
+// with the shared keys that need no masking.
+var pathKeyHosts = map[string]string{
+	"api.marginalia.nu": "public",
+}
+
+// redactURL masks basic-auth passwords, credential-like query parameters
+// and API keys in the path of pathKeyHosts.
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	c := *u
+	if c.User != nil {
+		if _, ok := c.User.Password(); ok {
+			c.User = url.UserPassword(c.User.Username(), redacted)
+		}
+	}
+	if c.RawQuery != "" {
+		q := c.Query()
+		changed := false
+		for name := range q {
+			if sensitiveNamePattern.MatchString(name) {
+				q.Set(name, redacted)
+				changed = true
+			}
+		}
+		if changed {
+			c.RawQuery = q.Encode()
+		}
+	}
+	if shared, ok := pathKeyHosts[c.Hostname()]; ok {
+		segments := strings.SplitN(strings.TrimPrefix(c.Path, "/"), "/", 2)
+		if segments[0] != "" && segments[0] != shared {
+			segments[0] = redacted
+			c.Path = "/" + strings.Join(segments, "/")
+			c.RawPath = ""
+		}
+	}
+	return c.String()
+}
+
+// hostOnlyKey marks a request context whose URL is a secret as a whole,
+// such as a webhook's, so debug output names only its host.
+type hostOnlyKey struct{}
+
+// withHostOnlyDebug returns req with debug output limited to its host.
+func withHostOnlyDebug(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), hostOnlyKey{}, true))
+}
+
+// debugURL is req's URL as debug output shows it: redacted, or only the
+// scheme and host for requests marked by withHostOnlyDebug.
+func debugURL(req *http.Request) string {
+	if hostOnly, _ := req.Context().Value(hostOnlyKey{}).(bool); hostOnly {
+		return (&url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: "/" + redacted}).String()
+	}
+	return redactURL(req.URL)
+}
+
+// redactHeaders returns a copy of h with credential headers masked.
+func redactHeaders(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] || sensitiveNamePattern.MatchString(name) {
+			out[name] = []string{redacted}
+			continue
+		}
+		out[name] = append([]string(nil), values...)
+	}
+	return out
+}
+
+// redactBody masks credential-like fields in JSON and form bodies.
+func redactBody(body []byte, contentType string) []byte {
+	if strings.Contains(contentType, "application/x-www-form-urlencoded") {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for name := range form {
+				if sensitiveNamePattern.MatchString(name) {
+					form.Set(name, redacted)
+				}
+			}
+			return []byte(form.Encode())
+		}
+	}
+	return sensitiveJSONPattern.ReplaceAll(body, []byte(`${1}"`+redacted+`"`))
+}
+
+// formatHeaders renders headers one per line in a stable order.
+func formatHeaders(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		for _, v := range h[name] {
+			fmt.Fprintf(&b, "%s: %s\n", name, v)
+		}
+	}
+	return b.String()
+}
+
+// dumpSeq numbers --debug-dump files across all clients.
+var dumpSeq atomic.Int64
+
+// debugTransport logs each request with secrets redacted while debug output
+// is enabled, and with debug_dump set writes sanitized request/response
+// pairs there for bug reports. The config is checked per request so the
+// interactive 'd' toggle takes effect immediately.
+type debugTransport struct {
+	base   http.RoundTripper
+	out    io.Writer
+	config *Config
+}
+
+// withDebug wraps rt (nil for http.DefaultTransport) with debug logging.
+func withDebug(rt http.RoundTripper, config *Config) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &debugTransport{base: rt, out: os.Stderr, config: config}
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.config.Debug {
+		return t.base.RoundTrip(req)
+	}
+	dumpDir := t.config.DebugDump
+
+	var reqBody []byte
+	if dumpDir != "" && req.Body != nil {
+		reqBody, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	if id := req.Header.Get(requestIDHeader); id != "" {
+		fmt.Fprintf(t.out, "Debug: %s %s (request ID %s)\n", req.Method, debugURL(req), id)
+	} else {
+		fmt.Fprintf(t.out, "Debug: %s %s\n", req.Method, debugURL(req))
+	}
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(t.out, "Debug: %s %s failed after %s: %v\n", req.Method, req.URL.Host, elapsed, err)
+	} else {
+		fmt.Fprintf(t.out, "Debug: %s %s -> %s in %s\n", req.Method, req.URL.Host, resp.Status, elapsed)
+	}
+
+	if dumpDir != "" {
+		var respBody []byte
+		if resp != nil && resp.Body != nil {
+			respBody, _ = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+		}
+		if path, dumpErr := writeDump(dumpDir, req, reqBody, resp, respBody, err); dumpErr != nil {
+			fmt.Fprintf(t.out, "Debug: failed to write dump: %v\n", dumpErr)
+		} else {
+			fmt.Fprintf(t.out, "Debug: wrote %s\n", path)
+		}
+	}
+	return resp, err
+}
+
+// writeDump writes one sanitized request/response pair to a numbered file
+// in dir.
+func writeDump(dir string, req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, rtErr error) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%03d-%s.txt", time.Now().Format("20060102-150405"), dumpSeq.Add(1), slugify(req.URL.Host, 40))
+	path := filepath.Join(dir, name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", req.Method, debugURL(req))
+	b.WriteString(formatHeaders(redactHeaders(req.Header)))
+	if len(reqBody) > 0 {
+		b.WriteString("\n")
+		b.Write(truncateDump(redactBody(reqBody, req.Header.Get("Content-Type"))))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n---\n\n")
+	if rtErr != nil {
+		fmt.Fprintf(&b, "error: %v\n", rtErr)
+	} else if resp != nil {
+		fmt.Fprintf(&b, "%s %s\n", resp.Proto, resp.Status)
+		b.WriteString(formatHeaders(redactHeaders(resp.Header)))
+		if len(respBody) > 0 {
+			b.WriteString("\n")
+			b.Write(truncateDump(redactBody(respBody, resp.Header.Get("Content-Type"))))
+			b.WriteString("\n")
+		}
+	}
+
+	return path, os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+func truncateDump(body []byte) []byte {
+	if len(body) <= maxDumpBody {
+		return body
+	}
+	return append(body[:maxDumpBody:maxDumpBody], []byte("\n[truncated]")...)
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// rankChange is a result that was added, removed or moved between two
+// result sets. Ranks are 1-based; a rank of 0 means absent from that set.
+type rankChange struct {
+	URL     string `json:"url"`
+	Title   string `json:"title"`
+	OldRank int    `json:"old_rank,omitempty"`
+	NewRank int    `json:"new_rank,omitempty"`
+}
+
+// resultDiff is how a result set changed from an older one, by URL.
+type resultDiff struct {
+	Added     []rankChange `json:"added"`
+	Removed   []rankChange `json:"removed"`
+	Moved     []rankChange `json:"moved"`
+	Unchanged int          `json:"unchanged"`
+}
+
+// diffResults compares two result sets by URL. Added and moved results are
+// in their new order, removed ones in their old order. Results without a
+// URL, and repeats of a URL, are ignored.
+func diffResults(old, new []SearchResult) resultDiff {
+	ranks := func(results []SearchResult) map[string]int {
+		m := make(map[string]int, len(results))
+		for i, result := range results {
+			if _, ok := m[result.URL]; !ok && result.URL != "" {
+				m[result.URL] = i + 1
+			}
+		}
+		return m
+	}
+	oldRanks, newRanks := ranks(old), ranks(new)
+
+	d := resultDiff{Added: []rankChange{}, Removed: []rankChange{}, Moved: []rankChange{}}
+	for i, result := range new {
+		if newRanks[result.URL] != i+1 {
+			continue
+		}
+		change := rankChange{URL: result.URL, Title: result.Title, OldRank: oldRanks[result.URL], NewRank: i + 1}
+		switch change.OldRank {
+		case 0:
+			d.Added = append(d.Added, change)
+		case change.NewRank:
+			d.Unchanged++
+		default:
+			d.Moved = append(d.Moved, change)
+		}
+	}
+	for i, result := range old {
+		if oldRanks[result.URL] == i+1 && newRanks[result.URL] == 0 {
+			d.Removed = append(d.Removed, rankChange{URL: result.URL, Title: result.Title, OldRank: i + 1})
+		}
+	}
+	return d
+}
+
+// printDiff writes d as one line per change, new results first, then a
+// summary line.
+func printDiff(w io.Writer, d resultDiff, noColor bool) {
+	green, red, yellow, dim := color.New(color.FgGreen), color.New(color.FgRed), color.New(color.FgYellow), color.New(color.FgHiBlack)
+	if noColor {
+		for _, c := range []*color.Color{green, red, yellow, dim} {
+			c.DisableColor()
+		}
+	}
+	changes := append(append([]rankChange{}, d.Added...), d.Moved...)
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].NewRank < changes[j].NewRank })
+	line := func(c *color.Color, sign string, rank int, change rankChange, note string) {
+		title := change.Title
+		if title == "" {
+			title = tr("no_title")
+		}
+		fmt.Fprintf(w, "%s %3d. %s %s%s\n", c.Sprint(sign), rank, title, dim.Sprint(change.URL), note)
+	}
+	for _, change := range changes {
+		switch {
+		case change.OldRank == 0:
+			line(green, "+", change.NewRank, change, "")
+		case change.OldRank > change.NewRank:
+			line(yellow, "↑", change.NewRank, change, dim.Sprint(" "+tr("diff_was", change.OldRank)))
+		default:
+			line(yellow, "↓", change.NewRank, change, dim.Sprint(" "+tr("diff_was", change.OldRank)))
+		}
+	}
+	for _, change := range d.Removed {
+		line(red, "-", change.OldRank, change, "")
+	}
+	fmt.Fprintln(w, dim.Sprint(tr("diff_summary", len(d.Added), len(d.Removed), len(d.Moved), d.Unchanged)))
+}
+
+// loadResultSet reads results saved by --json -o, `sx snapshot` (given
+// the file or its directory) or --diff-last. Of a file that --append
+// wrote several documents to, the last is used.
+func loadResultSet(path string) ([]SearchResult, string, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "results.json")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var doc struct {
+		Query   string         `json:"query"`
+		Results []SearchResult `json:"results"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	found := false
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, "", fmt.Errorf("%s: %v", path, err)
+		}
+		// A bare array of results, or a document with a results array
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+			doc.Query = ""
+			err = json.Unmarshal(raw, &doc.Results)
+		} else {
+			doc.Results = nil
+			err = json.Unmarshal(raw, &doc)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: not a saved result set: %v", path, err)
+		}
+		found = true
+	}
+	if !found {
+		return nil, "", fmt.Errorf("%s: empty file", path)
+	}
+	return doc.Results, doc.Query, nil
+}
+
+// runDiff compares the result sets saved in oldPath and newPath.
+func runDiff(oldPath, newPath string, asJSON bool) error {
+	old, _, err := loadResultSet(oldPath)
+	if err != nil {
+		return err
+	}
+	new, _, err := loadResultSet(newPath)
+	if err != nil {
+		return err
+	}
+	d := diffResults(old, new)
+	if asJSON {
+		return writeDiffJSON(os.Stdout, d, map[string]interface{}{"old": oldPath, "new": newPath})
+	}
+	printDiff(os.Stdout, d, config.NoColor)
+	return nil
+}
+
+// writeDiffJSON writes d as a JSON object with extra fields.
+func writeDiffJSON(w io.Writer, d resultDiff, extra map[string]interface{}) error {
+	output := map[string]interface{}{
+		"added":     d.Added,
+		"removed":   d.Removed,
+		"moved":     d.Moved,
+		"unchanged": d.Unchanged,
+	}
+	for k, v := range extra {
+		output[k] = v
+	}
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// lastRunFile is where the last results for query are kept for
+// --diff-last, under the cache directory. The same query through another
+// engine or with other categories or filters has a last run of its own.
+func lastRunFile(query, engine string, opts *SearchOptions) string {
+	dir := appDir(baseCache)
+	if dir == "" {
+		return ""
+	}
+	key := []string{strings.ToLower(strings.TrimSpace(query)), engine}
+	if opts != nil {
+		normalized := func(values []string) string {
+			values = slices.Clone(values)
+			for i, v := range values {
+				values[i] = strings.ToLower(strings.TrimSpace(v))
+			}
+			slices.Sort(values)
+			return strings.Join(values, ",")
+		}
+		key = append(key,
+			normalized(opts.Categories), normalized(opts.SearxngEngines),
+			opts.TimeRange, opts.Language, opts.Country, opts.SafeSearch,
+			normalized(opts.Sites), normalized(opts.ExcludeSites), normalized(opts.BlockedDomains),
+			normalized(opts.ResultLang))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(key, "\n")))
+	return filepath.Join(dir, "runs", hex.EncodeToString(sum[:8])+".json")
+}
+
+// swapLastRun stores results as the last run of query through engine with
+// opts and returns the run stored before it, nil if there is none. Only the
+// keep most recent runs are kept.
+func swapLastRun(query, engine string, opts *SearchOptions, results []SearchResult, now time.Time, keep int) ([]SearchResult, time.Time, error) {
+	path := lastRunFile(query, engine, opts)
+	if path == "" {
+		return nil, time.Time{}, fmt.Errorf("no cache directory")
+	}
+	var previous []SearchResult
+	var previousAt time.Time
+	if info, err := os.Stat(path); err == nil {
+		previous, _, err = loadResultSet(path)
+		if err != nil {
+			previous = nil
+		}
+		previousAt = info.ModTime()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return previous, previousAt, err
+	}
+	output := jsonOutput(results, query, false, map[string]interface{}{
+		"engine_used":  engine,
+		"retrieved_at": now.UTC().Format(time.RFC3339),
+	})
+	if err := writeJSONFile(path, output); err != nil {
+		return previous, previousAt, err
+	}
+	pruneLastRuns(filepath.Dir(path), keep)
+	return previous, previousAt, nil
+}
+
+// pruneLastRuns removes all but the keep most recently written runs.
+func pruneLastRuns(dir string, keep int) {
+	if keep <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) <= keep {
+		return
+	}
+	type run struct {
+		path string
+		mod  time.Time
+	}
+	var runs []run
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && !entry.IsDir() {
+			runs = append(runs, run{filepath.Join(dir, entry.Name()), info.ModTime()})
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].mod.After(runs[j].mod) })
+	for _, r := range runs[min(keep, len(runs)):] {
+		os.Remove(r.path)
+	}
+}
+
+// printLastDiff prints, for --diff-last, how results differ from the
+// previous run of the query made at previousAt. Without one, every result
+// is new.
+func printLastDiff(previous []SearchResult, previousAt time.Time, results []SearchResult, query string) error {
+	d := diffResults(previous, results)
+	if searchOpts.JSON {
+		extra := map[string]interface{}{"query": query}
+		if !previousAt.IsZero() {
+			extra["previous_run"] = previousAt.UTC().Format(time.RFC3339)
+		}
+		return writeDiffJSON(os.Stdout, d, extra)
+	}
+	if previousAt.IsZero() {
+		printNotice("%s", tr("diff_no_last_run"))
+	} else {
+		printNotice("%s", tr("diff_since", previousAt.Local().Format("2006-01-02 15:04")))
+	}
+	printDiff(os.Stdout, d, config.NoColor)
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resultsFor(urls ...string) []SearchResult {
+	results := make([]SearchResult, len(urls))
+	for i, url := range urls {
+		results[i] = SearchResult{URL: url, Title: strings.ToUpper(url)}
+	}
+	return results
+}
+
+func TestDiffResults(t *testing.T) {
+	old := resultsFor("a", "b", "c", "d", "", "a")
+	new := resultsFor("c", "b", "e", "a", "c")
+	d := diffResults(old, new)
+
+	want := resultDiff{
+		Added:     []rankChange{{URL: "e", Title: "E", NewRank: 3}},
+		Removed:   []rankChange{{URL: "d", Title: "D", OldRank: 4}},
+		Moved:     []rankChange{{URL: "c", Title: "C", OldRank: 3, NewRank: 1}, {URL: "a", Title: "A", OldRank: 1, NewRank: 4}},
+		Unchanged: 1,
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("diffResults = %+v, want %+v", d, want)
+	}
+
+	if d := diffResults(nil, nil); d.Added == nil || d.Removed == nil || d.Moved == nil {
+		t.Errorf("empty diff has nil lists: %+v", d)
+	}
+}
+
+func TestPrintDiff(t *testing.T) {
+	var buf bytes.Buffer
+	printDiff(&buf, diffResults(resultsFor("a", "b", "c"), resultsFor("b", "d", "a")), true)
+	want := "↑   1. B b (was 2)\n" +
+		"+   2. D d\n" +
+		"↓   3. A a (was 1)\n" +
+		"-   3. C c\n" +
+		"1 added, 1 removed, 2 moved, 0 unchanged\n"
+	if buf.String() != want {
+		t.Errorf("printDiff:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestLoadResultSet(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	results, query, err := loadResultSet(write("one.json", `{"query": "go", "results": [{"url": "a"}, {"url": "b"}]}`))
+	if err != nil || query != "go" || len(results) != 2 {
+		t.Errorf("single document: %v, %q, %v", results, query, err)
+	}
+
+	// --append writes one document after another
+	results, query, err = loadResultSet(write("appended.json", "{\"query\": \"go\", \"results\": [{\"url\": \"a\"}]}\n{\"query\": \"rust\", \"results\": [{\"url\": \"c\"}]}\n"))
+	if err != nil || query != "rust" || len(results) != 1 || results[0].URL != "c" {
+		t.Errorf("appended documents: %v, %q, %v", results, query, err)
+	}
+
+	if results, _, err := loadResultSet(write("bare.json", `[{"url": "a"}]`)); err != nil || len(results) != 1 {
+		t.Errorf("bare array: %v, %v", results, err)
+	}
+
+	// A snapshot directory holds results.json
+	snapshot := filepath.Join(dir, "snapshot")
+	os.Mkdir(snapshot, 0755)
+	os.WriteFile(filepath.Join(snapshot, "results.json"), []byte(`{"results": [{"url": "a"}]}`), 0644)
+	if results, _, err := loadResultSet(snapshot); err != nil || len(results) != 1 {
+		t.Errorf("snapshot directory: %v, %v", results, err)
+	}
+
+	for _, content := range []string{"", "not json", `"a string"`} {
+		if _, _, err := loadResultSet(write("bad.json", content)); err == nil {
+			t.Errorf("loadResultSet(%q) didn't fail", content)
+		}
+	}
+}
+
+func TestSwapLastRun(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	now := time.Now()
+	opts := &SearchOptions{Categories: []string{"it", "news"}, TimeRange: "week"}
+
+	previous, previousAt, err := swapLastRun("Golang generics", "searxng", opts, resultsFor("a", "b"), now, 3)
+	if err != nil || previous != nil || !previousAt.IsZero() {
+		t.Fatalf("first run: %v, %v, %v", previous, previousAt, err)
+	}
+	// Queries differing in case and surrounding space are the same, and
+	// so are categories in another order
+	same := &SearchOptions{Categories: []string{"News", "it"}, TimeRange: "week"}
+	previous, previousAt, err = swapLastRun(" golang generics ", "searxng", same, resultsFor("b", "c"), now, 3)
+	if err != nil || !reflect.DeepEqual(previous, resultsFor("a", "b")) || previousAt.IsZero() {
+		t.Fatalf("second run: %v, %v, %v", previous, previousAt, err)
+	}
+	// Another engine, category or filter has a last run of its own
+	for _, other := range []struct {
+		engine string
+		opts   *SearchOptions
+	}{
+		{"brave", opts},
+		{"searxng", &SearchOptions{Categories: []string{"it"}, TimeRange: "week"}},
+		{"searxng", &SearchOptions{Categories: []string{"it", "news"}, TimeRange: "month"}},
+		{"searxng", &SearchOptions{Categories: []string{"it", "news"}, TimeRange: "week", Sites: []string{"go.dev"}}},
+	} {
+		if lastRunFile("golang generics", other.engine, other.opts) == lastRunFile("golang generics", "searxng", opts) {
+			t.Errorf("%s %+v shares the last run", other.engine, *other.opts)
+		}
+	}
+
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(lastRunFile("golang generics", "searxng", opts), old, old)
+	swapLastRun("rust", "searxng", nil, resultsFor("r"), now, 2)
+	swapLastRun("zig", "searxng", nil, resultsFor("z"), now, 2)
+	if _, err := os.Stat(lastRunFile("golang generics", "searxng", opts)); !os.IsNotExist(err) {
+		t.Errorf("oldest run wasn't pruned: %v", err)
+	}
+	if _, err := os.Stat(lastRunFile("zig", "searxng", nil)); err != nil {
+		t.Errorf("newest run is missing: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"compress/gzip"
 	"crypto/tls"
 	"encoding/json"
@@ -13,6 +14,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
@@ -22,8 +24,6 @@ import (
 	"sx/backends"
 )
 
-const maxContentWords = 128
-
 // Common realistic user agents to rotate through
 var userAgents = []string{
 	// Chrome on Windows
@@ -48,106 +48,212 @@ type SearchOptions struct {
 	SearxngEngines []string // SearXNG-specific engines (not to confuse with search backends)
 	SafeSearch     string
 	Language       string
+	Country        string
 	TimeRange      string
-	Site           string
+	Sites          []string
+	ExcludeSites   []string
+	BlockedDomains []string // from the active profile, filtered locally
+	Profile        string
 	PageNo         int
 	Expand         bool
+	FullContent    bool
+	RawURLs        bool
+	CheckLinks     bool
+	OpenAccess     string   // --open-access: annotate or replace
+	Enrich         []string // --enrich: enrichers to run, see enrichKinds
+	SiteInfo       bool     // --site-info: same as --enrich site
+	ArchiveCopies  bool     // --archive-paywalled: --enrich paywall with archive.org copies
+	Porcelain      bool
 	JSON           bool
 	First          bool
 	Lucky          bool
+	Weighted       bool  // --weighted: favor high-scoring results in --lucky
+	Seed           int64 // --seed: makes --lucky picks reproducible
+	PrintURL       bool  // --print-url: print URLs that would be opened instead
+	Play           bool  // --play: hand the first result to the media player
+	OpenMap        bool
+	Near           string // --near: "lat,lon" to sort map results by distance
 	NoPrompt       bool
 	Interactive    bool
 	Unsafe         bool
 	LinksOnly      bool
+	MagnetOnly     bool
+	Subscribe      bool   // --subscribe: output the podcasts among the results as OPML
+	Sort           string // --sort: result ordering key
+	GroupBy        string // --group-by: domain, engine or category headers
 	OutputFile     string
 	Top            bool
+	DiffLast       bool // --diff-last: compare with the last run of the query
 	Clean          bool
 	TextOnly       bool
+	MaxTokens      int             // --max-tokens: token budget for --text output
+	AIPolicy       string          // --ai-policy: report or exclude pages restricting AI use
+	ExtractSchema  string          // --extract-schema: JSON schema file
+	Schema         json.RawMessage // loaded from ExtractSchema
 	HTMLOnly       bool
 	ExplicitEngine string // --engine flag: force a specific search backend
+	QueryFile      string
+	Queries        []string   // --query: further queries searched in the same run
+	Relax          bool       // --relax: drop filters when nothing is found
+	AllCategories  bool       // --all-categories: search each category concurrently
+	ExpandQuery    string     // --expand-query: synonym file
+	Synonyms       [][]string // groups loaded from ExpandQuery
+	Autocorrect    bool
+	AnswerOnly     bool // --answer-only: print the instant answer, no results
+	DryRun         bool // --dry-run: print the backend request instead of sending it
+	Skip           int  // --skip: result offset to start output at
+	Page           int  // --page: 1-based page to start output at
+	All            bool
+	Download       bool   // --download: save image results to disk
+	Resize         int    // --resize: max image dimension in pixels
+	ImageFormat    string // --format: image conversion target
+	Thumbnails     bool
+	Max            int      // --max: result cap for --all
+	AllOf          []string // query builder: --all-of
+	AnyOf          []string // query builder: --any-of
+	NoneOf         []string // query builder: --none-of
+	ResultLang     []string // --result-lang: languages results must be written in
+	Keywords       bool     // --keywords: list the terms the results share
+	Cluster        bool     // --cluster: similar results after their cluster's lead
+	Rerank         bool     // --rerank: order results with the [rerank] API
+	Exact          []string // query builder: --exact
+	StdinMode      string   // query: stdin is the query; context: stdin lines are appended as quoted terms
+	Sinks          []string // --sink: where results are delivered
+	Pipe           string   // --pipe: command that stdout streams into
 }
 
-func printResults(results []SearchResult, count int, startAt int, expand bool, noColor bool, query string) {
+// printResults writes results [startAt, startAt+count) to w. Snippets are cut
+// to snippetWords words; 0 shows them in full. shortDomains labels results
+// with their registrable domain instead of the full host.
+func printResults(w io.Writer, results []SearchResult, count int, startAt int, expand bool, noColor bool, snippetWords int, shortDomains bool, query string) {
 	if noColor {
 		color.NoColor = true
 	}
 
-	cyan := color.New(color.FgCyan)
-	green := color.New(color.FgGreen, color.Bold)
-	yellow := color.New(color.FgYellow)
-	dim := color.New(color.FgHiBlack)
-
-	fmt.Println()
-
-	// Display the query at the top
-	bold := color.New(color.FgWhite, color.Bold)
-	fmt.Printf("Query: %s\n\n", bold.Sprint(query))
-	fmt.Println()
-
 	end := startAt + count
 	if end > len(results) {
 		end = len(results)
 	}
+	if accessibleOutput {
+		printAccessible(w, results, startAt, end, snippetWords, shortDomains, query)
+		return
+	}
 
-	for i, result := range results[startAt:end] {
-		index := startAt + i + 1
+	// Display the query at the top; piped output and files start with
+	// the results themselves
+	banner := isTerminalWriter(w)
+	bold := color.New(color.FgWhite, color.Bold)
+	if banner {
+		fmt.Fprintf(w, "\n%s\n\n", tr("query", bold.Sprint(query)))
+	}
+	legend := ""
+	if startAt < end {
+		legend = engineLegend(results[startAt:end])
+	}
+	if legend != "" {
+		fmt.Fprintln(w, tr("engines", legend))
+	}
+	if banner || legend != "" {
+		fmt.Fprintln(w)
+	}
 
-		// Format title (truncate if too long)
-		title := result.Title
-		if title == "" {
-			title = "No title"
+	for _, group := range groupResults(results, startAt, end, searchOpts.GroupBy) {
+		if group.Name != "" {
+			fmt.Fprintf(w, "%s\n\n", bold.Sprint(tr("group_heading", group.Name, len(group.Indexes))))
 		}
-		if len(title) > 70 {
-			title = title[:67] + "..."
-		}
-
-		// Extract domain from URL
-		domain := extractDomain(result.URL)
-
-		// Format and print result header
-		fmt.Printf(" %s %s %s\n",
-			cyan.Sprintf("%2d.", index),
-			green.Sprint(title),
-			yellow.Sprintf("[%s]", domain),
-		)
-
-		// Always show the full URL so agent/CLI consumers can copy exact links.
-		if result.URL != "" {
-			fmt.Printf("     %s\n", result.URL)
+		for _, i := range group.Indexes {
+			printResult(w, results[i], i+1, noColor, snippetWords, shortDomains)
 		}
+	}
+}
 
-		// Format and print content
-		if result.Content != "" {
-			content := formatContent(result.Content)
-			lines := wrapText(content, getTerminalWidth()-5)
-			for _, line := range lines {
-				fmt.Printf("     %s\n", line)
-			}
-		}
+// printResult writes one result, numbered index, as printResults does.
+func printResult(w io.Writer, result SearchResult, index int, noColor bool, snippetWords int, shortDomains bool) {
+	cyan := color.New(color.FgCyan)
+	green := color.New(color.FgGreen, color.Bold)
+	yellow := color.New(color.FgYellow)
+	dim := color.New(color.FgHiBlack)
 
-		// Category-specific formatting
-		printCategorySpecific(result, dim)
+	// Format title (truncate if too long)
+	title := result.Title
+	if title == "" {
+		title = tr("no_title")
+	}
+	if len(title) > 70 {
+		title = title[:67] + "..."
+	}
 
-		// Print engines
-		printEngines(result, dim)
+	// Extract domain from URL
+	domain := extractDomain(result.URL, shortDomains)
 
-		fmt.Println()
+	// Format and print result header; local files have no domain
+	site := ""
+	if domain != "" {
+		site = " " + yellow.Sprintf("[%s]", hyperlink(w, domain, domainURL(result.URL), noColor))
 	}
-}
+	fmt.Fprintf(w, " %s %s%s%s\n",
+		cyan.Sprintf("%2d.", index),
+		green.Sprint(hyperlink(w, title, result.URL, noColor)),
+		site,
+		domainBadges(resultBadges(result)),
+	)
 
-func extractDomain(urlStr string) string {
-	if urlStr == "" {
-		return ""
+	// Always show the full URL so agent/CLI consumers can copy exact links.
+	if result.URL != "" {
+		fmt.Fprintf(w, "     %s\n", result.URL)
+	}
+	if result.Link != nil {
+		fmt.Fprintf(w, "     %s\n", formatLinkCheck(result.Link))
+	}
+	if result.OpenAccess != nil {
+		fmt.Fprintf(w, "     %s\n", formatOpenAccess(result.OpenAccess))
+	}
+	if result.ArchiveURL != "" {
+		fmt.Fprintf(w, "     %s\n", dim.Sprint(tr("archived_copy", result.ArchiveURL)))
+	}
+	if result.Citations != nil {
+		fmt.Fprintf(w, "     %s\n", dim.Sprint(tr("cited_by", *result.Citations)))
+	}
+	if facts := pageFacts(result); facts != "" {
+		fmt.Fprintf(w, "     %s\n", dim.Sprint(facts))
+	}
+	if result.SiteInfo != nil {
+		fmt.Fprintf(w, "     %s\n", dim.Sprint(formatSiteInfo(result.SiteInfo)))
+	}
+	if note := clusterNote(result); note != "" {
+		fmt.Fprintf(w, "     %s\n", dim.Sprint(note))
+	}
+	if len(result.AlsoOn) > 0 {
+		fmt.Fprintf(w, "     %s\n", dim.Sprint(tr("also_on", strings.Join(result.AlsoOn, ", "))))
 	}
 
-	parts := strings.Split(urlStr, "//")
-	if len(parts) > 1 {
-		return strings.Split(parts[1], "/")[0]
+	// Format and print content; IT results keep their code blocks
+	var segments []contentSegment
+	if result.Category == "it" {
+		segments = splitCodeBlocks(result.Content)
 	}
-	return strings.Split(parts[0], "/")[0]
+	if hasCodeBlocks(segments) {
+		printCodeAwareContent(w, segments, dim, snippetWords)
+	} else if result.Content != "" {
+		content := formatContent(result.Content, snippetWords)
+		lines := wrapText(content, getTerminalWidth()-5)
+		for _, line := range lines {
+			fmt.Fprintf(w, "     %s\n", line)
+		}
+	}
+
+	// Category-specific formatting
+	printCategorySpecific(w, result, dim)
+
+	// Print engines
+	printEngines(w, result, dim)
+
+	fmt.Fprintln(w)
 }
 
-func formatContent(content string) string {
+// formatContent converts a result snippet to plain text, keeping at most
+// maxWords words. A maxWords of 0 or less keeps everything.
+func formatContent(content string, maxWords int) string {
 	// Simple HTML to text conversion
 	content = html.UnescapeString(content)
 
@@ -157,8 +263,8 @@ func formatContent(content string) string {
 
 	// Limit word count
 	words := strings.Fields(content)
-	if len(words) > maxContentWords {
-		words = words[:maxContentWords]
+	if maxWords > 0 && len(words) > maxWords {
+		words = words[:maxWords]
 		content = strings.Join(words, " ") + " ..."
 	} else {
 		content = strings.Join(words, " ")
@@ -204,23 +310,23 @@ func getTerminalWidth() int {
 	return 80
 }
 
-func printCategorySpecific(result SearchResult, dim *color.Color) {
+func printCategorySpecific(w io.Writer, result SearchResult, dim *color.Color) {
 	switch result.Category {
 	case "news":
 		if result.PublishedDate != "" {
 			if date := parseDate(result.PublishedDate); date != nil {
-				fmt.Printf("     %s\n", dim.Sprint(date.Format("January 2, 2006")))
+				fmt.Fprintf(w, "     %s\n", dim.Sprint(formatDate(*date)))
 			}
 		}
 
 	case "images":
 		if result.Source != "" || result.Resolution != "" {
-			fmt.Printf("     %s %s\n",
+			fmt.Fprintf(w, "     %s %s\n",
 				dim.Sprint(result.Resolution),
 				dim.Sprint(result.Source))
 		}
 		if result.ImgSrc != "" {
-			fmt.Printf("     %s\n", result.ImgSrc)
+			fmt.Fprintf(w, "     %s\n", result.ImgSrc)
 		}
 
 	case "videos", "music":
@@ -233,25 +339,31 @@ func printCategorySpecific(result SearchResult, dim *color.Color) {
 		if result.Author != "" {
 			parts = append(parts, result.Author)
 		}
+		if result.Album != "" {
+			parts = append(parts, "– "+result.Album)
+		}
 		if len(parts) > 0 {
-			fmt.Printf("     %s\n", dim.Sprint(strings.Join(parts, " ")))
+			fmt.Fprintf(w, "     %s\n", dim.Sprint(strings.Join(parts, " ")))
 		}
 
 	case "map":
 		if result.Address != nil {
-			printAddress(result.Address, dim)
+			printAddress(w, result.Address, dim)
 		}
 		if result.Longitude != 0 || result.Latitude != 0 {
-			fmt.Printf("     %s\n", dim.Sprintf("%.6f, %.6f", result.Latitude, result.Longitude))
+			fmt.Fprintf(w, "     %s\n", dim.Sprintf("%.6f, %.6f", result.Latitude, result.Longitude))
 		}
 
 	case "science":
 		var parts []string
 		if result.PublishedDate != "" {
 			if date := parseDate(result.PublishedDate); date != nil {
-				parts = append(parts, date.Format("January 2, 2006"))
+				parts = append(parts, formatDate(*date))
 			}
 		}
+		if result.Author != "" {
+			parts = append(parts, result.Author)
+		}
 		if result.Journal != "" {
 			parts = append(parts, result.Journal)
 		}
@@ -259,30 +371,39 @@ func printCategorySpecific(result SearchResult, dim *color.Color) {
 			parts = append(parts, result.Publisher)
 		}
 		if len(parts) > 0 {
-			fmt.Printf("     %s\n", dim.Sprint(strings.Join(parts, " ")))
+			fmt.Fprintf(w, "     %s\n", dim.Sprint(strings.Join(parts, " ")))
+		}
+
+	case "it":
+		// Facts about the repository, issue, app or advisory, e.g. "Go · 1234
+		// stars" or "CRITICAL 10.0 · CVSS 3.1"
+		if result.Metadata != "" {
+			fmt.Fprintf(w, "     %s\n", dim.Sprint(result.Metadata))
+		}
+		if result.InstallURL != "" {
+			fmt.Fprintf(w, "     %s\n", dim.Sprint(tr("install", result.InstallURL)))
 		}
 
 	case "files":
 		if result.Template == "torrent.html" {
 			if result.MagnetLink != "" {
-				fmt.Printf("     %s\n", dim.Sprint(result.MagnetLink))
+				fmt.Fprintf(w, "     %s\n", dim.Sprint(result.MagnetLink))
 			}
-			fmt.Printf("     %s ↑%d seeders, ↓%d leechers\n",
-				dim.Sprint(result.FileSize), result.Seed, result.Leech)
+			fmt.Fprintf(w, "     %s %s\n", dim.Sprint(result.FileSize), tr("peers", result.Seed, result.Leech))
 		} else if result.Template == "files.html" {
-			fmt.Printf("     %s %s\n", dim.Sprint(result.Size), dim.Sprint(result.Metadata))
+			fmt.Fprintf(w, "     %s %s\n", dim.Sprint(result.Size), dim.Sprint(result.Metadata))
 		}
 
 	case "social media":
 		if result.PublishedDate != "" {
 			if date := parseDate(result.PublishedDate); date != nil {
-				fmt.Printf("     %s\n", dim.Sprint(date.Format("January 2, 2006")))
+				fmt.Fprintf(w, "     %s\n", dim.Sprint(formatDate(*date)))
 			}
 		}
 	}
 }
 
-func printAddress(address map[string]interface{}, dim *color.Color) {
+func printAddress(w io.Writer, address map[string]interface{}, dim *color.Color) {
 	var parts []string
 
 	if houseNumber, ok := address["house_number"].(string); ok && houseNumber != "" {
@@ -293,7 +414,7 @@ func printAddress(address map[string]interface{}, dim *color.Color) {
 	}
 
 	if len(parts) > 0 {
-		fmt.Printf("     %s\n", strings.Join(parts, " "))
+		fmt.Fprintf(w, "     %s\n", strings.Join(parts, " "))
 	}
 
 	var cityParts []string
@@ -305,11 +426,11 @@ func printAddress(address map[string]interface{}, dim *color.Color) {
 	}
 
 	if len(cityParts) > 0 {
-		fmt.Printf("     %s\n", strings.Join(cityParts, ", "))
+		fmt.Fprintf(w, "     %s\n", strings.Join(cityParts, ", "))
 	}
 
 	if country, ok := address["country"].(string); ok && country != "" {
-		fmt.Printf("     %s\n", country)
+		fmt.Fprintf(w, "     %s\n", country)
 	}
 }
 
@@ -355,20 +476,19 @@ func getRandomUserAgent() string {
 	return userAgents[rand.Intn(len(userAgents))]
 }
 
-// setupHTTPClient creates an HTTP client with anti-bot detection features
+// setupHTTPClient creates an HTTP client for fetching result pages
 func setupHTTPClient(config *Config) *http.Client {
-	client := &http.Client{
-		Timeout: time.Duration(config.Timeout) * time.Second,
-	}
-
-	if config.NoVerifySSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	var transport http.RoundTripper
+	if roots := rootCAs(config); config.NoVerifySSL || roots != nil {
+		transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: config.NoVerifySSL, RootCAs: roots},
 		}
-		client.Transport = tr
 	}
 
-	return client
+	return &http.Client{
+		Timeout:   config.fetchTimeout(),
+		Transport: withPageCache(withDebug(transport, config), config),
+	}
 }
 
 // setupHTTPRequest creates an HTTP request with realistic browser headers
@@ -399,15 +519,15 @@ func setupHTTPRequest(method, url string, config *Config) (*http.Request, error)
 	return req, nil
 }
 
-func printHTMLOnly(results []SearchResult, outputFile string, config *Config) error {
+func printHTMLOnly(results []SearchResult, outputFile string, config *Config) (err error) {
 	var output io.Writer = os.Stdout
 
 	if outputFile != "" {
-		file, err := os.Create(outputFile)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %v", err)
+		file, openErr := createOutput(outputFile)
+		if openErr != nil {
+			return openErr
 		}
-		defer file.Close()
+		defer closeOutput(file, &err)
 		output = file
 	}
 
@@ -481,7 +601,38 @@ func printHTMLOnly(results []SearchResult, outputFile string, config *Config) er
 	return nil
 }
 
-func printEngines(result SearchResult, dim *color.Color) {
+// printNotice writes a dim status line to stderr, out of the way of the
+// results on stdout.
+func printNotice(format string, args ...interface{}) {
+	fmt.Fprintln(os.Stderr, color.New(color.FgHiBlack).Sprintf(format, args...))
+}
+
+// printCorrections shows SearXNG's "did you mean" corrections and related
+// suggestions below the results. In interactive mode the first correction
+// can be re-run with 'y'.
+func printCorrections(corrections, suggestions []string, interactive bool, noColor bool) {
+	if noColor {
+		color.NoColor = true
+	}
+	yellow := color.New(color.FgYellow)
+	dim := color.New(color.FgHiBlack)
+
+	if len(corrections) > 0 {
+		if interactive {
+			fmt.Fprintf(os.Stderr, "%s %s\n", tr("did_you_mean", yellow.Sprint(corrections[0])), dim.Sprint(tr("did_you_mean_hint")))
+		} else {
+			fmt.Fprintln(os.Stderr, tr("did_you_mean", yellow.Sprint(corrections[0])))
+		}
+	}
+	if len(suggestions) > 0 {
+		if len(suggestions) > 5 {
+			suggestions = suggestions[:5]
+		}
+		fmt.Fprintln(os.Stderr, dim.Sprint(tr("related", strings.Join(suggestions, ", "))))
+	}
+}
+
+func printEngines(w io.Writer, result SearchResult, dim *color.Color) {
 	engines := make([]string, len(result.Engines))
 	copy(engines, result.Engines)
 
@@ -495,18 +646,16 @@ func printEngines(result SearchResult, dim *color.Color) {
 		}
 	}
 
-	engineText := ""
 	if result.Engine != "" {
-		engineText = result.Engine
-		if len(engines) > 0 {
-			engineText += ", " + strings.Join(engines, ", ")
-		}
-	} else if len(engines) > 0 {
-		engineText = strings.Join(engines, ", ")
+		engines = append([]string{result.Engine}, engines...)
 	}
 
-	if engineText != "" {
-		fmt.Printf("     %s\n", dim.Sprintf("[%s]", engineText))
+	if len(engines) > 0 {
+		colored := make([]string, len(engines))
+		for i, engine := range engines {
+			colored[i] = engineColor(engine).Sprint(engine)
+		}
+		fmt.Fprintf(w, "     %s%s%s\n", dim.Sprint("["), strings.Join(colored, dim.Sprint(", ")), dim.Sprint("]"))
 	}
 }
 
@@ -552,6 +701,9 @@ func cleanSearchResult(result SearchResult) map[string]interface{} {
 	if result.ImgSrc != "" {
 		cleaned["img_src"] = result.ImgSrc
 	}
+	if result.ThumbnailSrc != "" {
+		cleaned["thumbnail_src"] = result.ThumbnailSrc
+	}
 	if len(result.Address) > 0 {
 		cleaned["address"] = result.Address
 	}
@@ -567,9 +719,27 @@ func cleanSearchResult(result SearchResult) map[string]interface{} {
 	if result.Publisher != "" {
 		cleaned["publisher"] = result.Publisher
 	}
+	if result.DOI != "" {
+		cleaned["doi"] = result.DOI
+	}
+	if result.PDFURL != "" {
+		cleaned["pdf_url"] = result.PDFURL
+	}
 	if result.MagnetLink != "" {
 		cleaned["magnetlink"] = result.MagnetLink
 	}
+	if result.FeedURL != "" {
+		cleaned["feed_url"] = result.FeedURL
+	}
+	if result.Album != "" {
+		cleaned["album"] = result.Album
+	}
+	if result.InstallURL != "" {
+		cleaned["install_url"] = result.InstallURL
+	}
+	if result.Advisory != nil {
+		cleaned["advisory"] = result.Advisory
+	}
 	if result.Seed != 0 {
 		cleaned["seed"] = result.Seed
 	}
@@ -585,15 +755,78 @@ func cleanSearchResult(result SearchResult) map[string]interface{} {
 	if result.Metadata != "" {
 		cleaned["metadata"] = result.Metadata
 	}
+	if result.Score != 0 {
+		cleaned["score"] = result.Score
+	}
+	if result.Link != nil {
+		cleaned["link"] = result.Link
+	}
+	if len(result.AlsoOn) > 0 {
+		cleaned["also_on"] = result.AlsoOn
+	}
+	if result.OpenAccess != nil {
+		cleaned["open_access"] = result.OpenAccess
+	}
+	if len(result.DomainTags) > 0 {
+		cleaned["domain_tags"] = result.DomainTags
+	}
+	if result.Paywalled {
+		cleaned["paywalled"] = true
+	}
+	if result.ArchiveURL != "" {
+		cleaned["archive_url"] = result.ArchiveURL
+	}
+	if result.SiteInfo != nil {
+		cleaned["site_info"] = result.SiteInfo
+	}
+	if result.Citations != nil {
+		cleaned["citations"] = *result.Citations
+	}
+	if result.Favicon != "" {
+		cleaned["favicon"] = result.Favicon
+	}
+	if result.WordCount != 0 {
+		cleaned["word_count"] = result.WordCount
+	}
+	if result.Language != "" {
+		cleaned["language"] = result.Language
+	}
+	if result.Cluster != 0 {
+		cleaned["cluster"] = result.Cluster
+	}
+	if result.Similar != 0 {
+		cleaned["similar"] = result.Similar
+	}
+	if result.Relevance != 0 {
+		cleaned["relevance"] = result.Relevance
+	}
 
 	return cleaned
 }
 
-func printJSONResults(results []SearchResult, query string) error {
+// jsonOutput builds the JSON document for a result set. Entries in extra
+// (e.g. corrections) are added as top-level keys next to query and results.
+func jsonOutput(results []SearchResult, query string, clean bool, extra map[string]interface{}) map[string]interface{} {
 	output := map[string]interface{}{
-		"query":   query,
-		"results": results,
+		"query": query,
 	}
+	if clean {
+		cleanedResults := make([]map[string]interface{}, len(results))
+		for i, result := range results {
+			cleanedResults[i] = cleanSearchResult(result)
+		}
+		output["results"] = cleanedResults
+	} else {
+		output["results"] = results
+	}
+	for k, v := range extra {
+		output[k] = v
+	}
+	return output
+}
+
+func printJSONResults(results []SearchResult, query string, extra map[string]interface{}) error {
+	output := jsonOutput(results, query, false, extra)
 	jsonData, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
 		return err
@@ -602,16 +835,8 @@ func printJSONResults(results []SearchResult, query string) error {
 	return nil
 }
 
-func printJSONResultsClean(results []SearchResult, query string) error {
-	cleanedResults := make([]map[string]interface{}, len(results))
-	for i, result := range results {
-		cleanedResults[i] = cleanSearchResult(result)
-	}
-
-	output := map[string]interface{}{
-		"query":   query,
-		"results": cleanedResults,
-	}
+func printJSONResultsClean(results []SearchResult, query string, extra map[string]interface{}) error {
+	output := jsonOutput(results, query, true, extra)
 	jsonData, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
 		return err
@@ -620,15 +845,15 @@ func printJSONResultsClean(results []SearchResult, query string) error {
 	return nil
 }
 
-func printLinksOnly(results []SearchResult, outputFile string) error {
+func printLinksOnly(results []SearchResult, outputFile string) (err error) {
 	var output io.Writer = os.Stdout
 
 	if outputFile != "" {
-		file, err := os.Create(outputFile)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %v", err)
+		file, openErr := createOutput(outputFile)
+		if openErr != nil {
+			return openErr
 		}
-		defer file.Close()
+		defer closeOutput(file, &err)
 		output = file
 	}
 
@@ -641,156 +866,280 @@ func printLinksOnly(results []SearchResult, outputFile string) error {
 	return nil
 }
 
-func printJSONToFile(results []SearchResult, outputFile string, query string, clean bool) error {
-	file, err := os.Create(outputFile)
+func printJSONToFile(results []SearchResult, outputFile string, query string, clean bool, extra map[string]interface{}) (err error) {
+	file, err := createOutput(outputFile)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %v", err)
+		return err
 	}
-	defer file.Close()
+	defer closeOutput(file, &err)
 
-	var output map[string]interface{}
-
-	if clean {
-		cleanedResults := make([]map[string]interface{}, len(results))
-		for i, result := range results {
-			cleanedResults[i] = cleanSearchResult(result)
-		}
-		output = map[string]interface{}{
-			"query":   query,
-			"results": cleanedResults,
-		}
-	} else {
-		output = map[string]interface{}{
-			"query":   query,
-			"results": results,
-		}
-	}
+	output := jsonOutput(results, query, clean, extra)
 
 	jsonData, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	_, err = file.Write(jsonData)
+	// Newline-terminated so --append builds a stream of documents
+	_, err = file.Write(append(jsonData, '\n'))
 	return err
 }
 
-func printResultsToFile(results []SearchResult, count int, startAt int, expand bool, noColor bool, query string, outputFile string) error {
-	file, err := os.Create(outputFile)
+func printResultsToFile(results []SearchResult, count int, startAt int, expand bool, snippetWords int, shortDomains bool, query string, outputFile string) error {
+	file, err := createOutput(outputFile)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %v", err)
+		return err
 	}
-	defer file.Close()
-
-	// Redirect stdout temporarily to file
-	oldStdout := os.Stdout
-	os.Stdout = file
 
 	// Always disable color for file output
-	printResults(results, count, startAt, expand, true, query)
+	noColor := color.NoColor
+	printResults(file, results, count, startAt, expand, true, snippetWords, shortDomains, query)
+	color.NoColor = noColor
 
-	// Restore stdout
-	os.Stdout = oldStdout
-
-	return nil
+	return file.Close()
 }
 
-func printTextOnly(results []SearchResult, outputFile string, config *Config) error {
+// printTextOnly fetches each result and writes it as markdown. Files
+// written with -o start with meta as front matter when it is given. With
+// maxTokens, the pages are fetched first and trimmed to fit about that
+// many tokens in total (see packTokens).
+func printTextOnly(results []SearchResult, outputFile string, config *Config, meta *provenance, maxTokens int, aiPolicy string) (err error) {
 	var output io.Writer = os.Stdout
 
 	if outputFile != "" {
-		file, err := os.Create(outputFile)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %v", err)
+		file, openErr := createOutput(outputFile)
+		if openErr != nil {
+			return openErr
 		}
-		defer file.Close()
+		defer closeOutput(file, &err)
 		output = file
+		if meta != nil {
+			writeFrontMatter(output, *meta)
+		}
 	}
 
-	client := &http.Client{
-		Timeout: time.Duration(config.Timeout) * time.Second,
+	client := setupHTTPClient(config)
+	var checker *aiUseChecker
+	if aiPolicy != "" {
+		checker = newAIUseChecker(client, config, aiPolicy == "exclude")
 	}
-
-	if config.NoVerifySSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	separator := "\n" + strings.Repeat("=", 80)
+	write := func(i int, doc textDocument) {
+		if i > 0 {
+			fmt.Fprintln(output, separator)
+		}
+		fmt.Fprint(output, doc.head)
+		if doc.read {
+			fmt.Fprintln(output, doc.body)
+		}
+	}
+	// Pages left out by --ai-policy exclude
+	excluded := 0
+	defer func() {
+		if excluded > 0 {
+			printNotice("%s", tr("ai_left_out", excluded))
+		}
+	}()
+
+	if maxTokens <= 0 {
+		written := 0
+		for _, result := range results {
+			doc := fetchTextDocument(client, result, config, checker)
+			if doc.excluded {
+				excluded++
+				continue
+			}
+			write(written, doc)
+			written++
 		}
-		client.Transport = tr
+		return nil
 	}
 
+	docs := make([]textDocument, len(results))
+	sem := make(chan struct{}, maxPageFetches)
+	var wg sync.WaitGroup
 	for i, result := range results {
-		if i > 0 {
-			fmt.Fprintln(output, "\n"+strings.Repeat("=", 80))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			docs[i] = fetchTextDocument(client, result, config, checker)
+		}()
+	}
+	wg.Wait()
+	kept := docs[:0]
+	for _, doc := range docs {
+		if doc.excluded {
+			excluded++
+		} else {
+			kept = append(kept, doc)
 		}
+	}
+	docs = kept
 
-		fmt.Fprintf(output, "URL: %s\n", result.URL)
-		fmt.Fprintf(output, "Title: %s\n\n", result.Title)
-
-		if result.URL == "" {
+	needs := make([]int, len(docs))
+	for i, doc := range docs {
+		needs[i] = estimateTokens(separator) + estimateTokens(doc.head) + estimateTokens(doc.body)
+	}
+	written := 0
+	for i, tokens := range packTokens(needs, maxTokens) {
+		if tokens == 0 {
 			continue
 		}
+		doc := docs[i]
+		doc.body = trimToTokens(doc.body, tokens-estimateTokens(separator)-estimateTokens(doc.head))
+		write(written, doc)
+		written++
+	}
+	if left := len(docs) - written; left > 0 {
+		printNotice("%s", tr("tokens_left_out", left, maxTokens))
+	}
+	return nil
+}
 
-		// Fetch the page
-		req, err := http.NewRequest("GET", result.URL, nil)
-		if err != nil {
-			fmt.Fprintf(output, "Error creating request: %v\n", err)
-			continue
-		}
+// textDocument is a result as --text writes it: the URL, title and
+// article metadata, then the article as markdown.
+type textDocument struct {
+	head     string
+	body     string
+	read     bool // whether the page could be read
+	excluded bool // left out by --ai-policy exclude
+}
 
-		if !config.NoUserAgent {
-			req.Header.Set("User-Agent", "sx/1.0")
+// fetchTextDocument fetches result's page for --text. Pages that can't be
+// read are reported and leave the body empty. With a checker, pages whose
+// site restricts AI use say so, or are excluded without being read.
+func fetchTextDocument(client *http.Client, result SearchResult, config *Config, checker *aiUseChecker) textDocument {
+	var head strings.Builder
+	fmt.Fprintf(&head, "URL: %s\n", result.URL)
+	fmt.Fprintf(&head, "Title: %s\n\n", result.Title)
+	if result.URL == "" {
+		return textDocument{head: head.String()}
+	}
+
+	var restrictions []string
+	if checker != nil {
+		restrictions = checker.siteRestrictions(result.URL)
+		if checker.exclude && len(restrictions) > 0 {
+			return textDocument{excluded: true}
 		}
-
-		resp, err := client.Do(req)
-		if err != nil {
-			fmt.Fprintf(output, "Error fetching page: %v\n", err)
-			continue
+	}
+	html, err := fetchPageHTML(client, result.URL, config)
+	if err == nil && checker != nil {
+		restrictions = append(restrictions, pageRestrictions(html)...)
+		if checker.exclude && len(restrictions) > 0 {
+			return textDocument{excluded: true}
 		}
-
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			fmt.Fprintf(output, "HTTP %d error\n", resp.StatusCode)
-			continue
+	}
+	// Paywalled pages are read from their archived copy if there is one
+	paywalled := onPaywalledDomain(result.URL, config) || err == nil && htmlPaywalled(html)
+	pageURL := result.URL
+	var archived string
+	if paywalled && config.ArchivePaywalled {
+		archived = result.ArchiveURL
+		if archived == "" {
+			var archiveErr error
+			if archived, archiveErr = archivedCopy(client, result.URL); archiveErr != nil && config.Debug {
+				fmt.Fprintf(os.Stderr, "Debug: archive %s: %v\n", result.URL, archiveErr)
+			}
 		}
-
-		// Parse URL for readability
-		parsedURL, err := url.Parse(result.URL)
-		if err != nil {
-			resp.Body.Close()
-			fmt.Fprintf(output, "Error parsing URL: %v\n", err)
-			continue
+		if archived != "" {
+			if archivedHTML, archiveErr := fetchPageHTML(client, archived, config); archiveErr == nil {
+				html, err, pageURL = archivedHTML, nil, archived
+			} else {
+				archived = ""
+			}
 		}
+	}
+	var article readability.Article
+	var markdown string
+	if err == nil {
+		article, markdown, err = articleFromHTML(html, pageURL)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("error", err))
+		return textDocument{head: head.String()}
+	}
 
-		// Use readability to extract main content
-		article, err := readability.FromReader(resp.Body, parsedURL)
-		resp.Body.Close()
-		if err != nil {
-			fmt.Fprintf(output, "Error extracting content: %v\n", err)
-			continue
-		}
+	// The article metadata
+	if article.Byline != "" {
+		fmt.Fprintf(&head, "Author: %s\n", article.Byline)
+	}
+	if article.PublishedTime != nil && !article.PublishedTime.IsZero() {
+		fmt.Fprintf(&head, "Published: %s\n", article.PublishedTime.Format("2006-01-02"))
+	}
+	if article.Excerpt != "" {
+		fmt.Fprintf(&head, "Excerpt: %s\n", article.Excerpt)
+	}
+	if len(restrictions) > 0 {
+		fmt.Fprintf(&head, "AI use: restricted (%s)\n", strings.Join(restrictions, "; "))
+	}
+	if paywalled {
+		head.WriteString("Paywall: yes\n")
+	}
+	if archived != "" {
+		fmt.Fprintf(&head, "Archived copy: %s\n", archived)
+	}
+	head.WriteString("\n")
+	return textDocument{head: head.String(), body: markdown, read: true}
+}
 
-		// Convert HTML to Markdown
-		converter := md.NewConverter("", true, nil)
-		markdown, err := converter.ConvertString(article.Content)
-		if err != nil {
-			fmt.Fprintf(output, "Error converting to markdown: %v\n", err)
-			continue
-		}
+// fetchArticle fetches rawURL and extracts its main content with
+// readability, converted to markdown.
+func fetchArticle(client *http.Client, rawURL string, config *Config) (readability.Article, string, error) {
+	body, err := fetchPageHTML(client, rawURL, config)
+	if err != nil {
+		return readability.Article{}, "", err
+	}
+	return articleFromHTML(body, rawURL)
+}
 
-		// Print the article metadata
-		if article.Byline != "" {
-			fmt.Fprintf(output, "Author: %s\n", article.Byline)
-		}
-		if article.PublishedTime != nil && !article.PublishedTime.IsZero() {
-			fmt.Fprintf(output, "Published: %s\n", article.PublishedTime.Format("2006-01-02"))
-		}
-		if article.Excerpt != "" {
-			fmt.Fprintf(output, "Excerpt: %s\n", article.Excerpt)
-		}
-		fmt.Fprintln(output)
+// fetchPageHTML fetches the HTML of rawURL as --text does.
+func fetchPageHTML(client *http.Client, rawURL string, config *Config) ([]byte, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for %s: %w", rawURL, err)
+	}
+	if !config.NoUserAgent {
+		req.Header.Set("User-Agent", "sx/1.0")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d fetching %s", resp.StatusCode, rawURL)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", rawURL, err)
+	}
+	return body, nil
+}
 
-		fmt.Fprintln(output, markdown)
+// articleFromHTML extracts the main content of the page at rawURL with
+// readability and converts it to markdown.
+func articleFromHTML(body []byte, rawURL string) (readability.Article, string, error) {
+	// Parse URL for readability
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return readability.Article{}, "", fmt.Errorf("parsing URL %s: %w", rawURL, err)
 	}
 
-	return nil
+	// Use readability to extract main content
+	article, err := readability.FromReader(bytes.NewReader(body), parsedURL)
+	if err != nil {
+		return readability.Article{}, "", fmt.Errorf("extracting content from %s: %w", rawURL, err)
+	}
+
+	// Convert HTML to Markdown
+	converter := md.NewConverter("", true, nil)
+	markdown, err := converter.ConvertString(article.Content)
+	if err != nil {
+		return readability.Article{}, "", fmt.Errorf("converting %s to markdown: %w", rawURL, err)
+	}
+	return article, markdown, nil
 }
@@ -2,33 +2,51 @@ package main
 
 import (
 	"bytes"
-	"io"
-	"os"
 	"strings"
 	"testing"
 )
 
 func TestPrintResultsAlwaysShowsFullURLs(t *testing.T) {
-	oldStdout := os.Stdout
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatalf("pipe: %v", err)
-	}
-	os.Stdout = w
-
-	printResults([]SearchResult{{
+	var buf bytes.Buffer
+	printResults(&buf, []SearchResult{{
 		Title:   "Example",
 		URL:     "https://example.com/full/path?with=query#fragment",
 		Content: "snippet",
-	}}, 1, 0, false, true, "example query")
+	}}, 1, 0, false, true, defaultSnippetWords, false, "example query")
 
-	_ = w.Close()
-	os.Stdout = oldStdout
-
-	var buf bytes.Buffer
-	_, _ = io.Copy(&buf, r)
 	out := buf.String()
 	if !strings.Contains(out, "https://example.com/full/path?with=query#fragment") {
 		t.Fatalf("expected full URL in output, got:\n%s", out)
 	}
 }
+
+func TestJSONOutputIncludesExtras(t *testing.T) {
+	out := jsonOutput([]SearchResult{{Title: "Go", URL: "https://go.dev"}}, "golnag", true,
+		map[string]interface{}{"corrections": []string{"golang"}})
+
+	if out["query"] != "golnag" {
+		t.Errorf("unexpected query: %v", out["query"])
+	}
+	corrections, ok := out["corrections"].([]string)
+	if !ok || len(corrections) != 1 || corrections[0] != "golang" {
+		t.Errorf("expected corrections in output, got %v", out["corrections"])
+	}
+	results, ok := out["results"].([]map[string]interface{})
+	if !ok || len(results) != 1 || results[0]["url"] != "https://go.dev" {
+		t.Errorf("expected cleaned results, got %v", out["results"])
+	}
+}
+
+func TestFormatContentSnippetWords(t *testing.T) {
+	content := "<b>one</b> two  three four"
+
+	if got := formatContent(content, 2); got != "one two ..." {
+		t.Errorf("formatContent(2) = %q", got)
+	}
+	if got := formatContent(content, 4); got != "one two three four" {
+		t.Errorf("formatContent(4) = %q", got)
+	}
+	if got := formatContent(content, 0); got != "one two three four" {
+		t.Errorf("formatContent(0) = %q, want untruncated", got)
+	}
+}
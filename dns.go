@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+// dnsTypes are the record types `sx dns` looks up by default, in the order
+// it lists them. IP addresses get their PTR records instead.
+var dnsTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT"}
+
+// dnsRecord is one record found by `sx dns`, as listed and emitted as JSON
+// with --json.
+type dnsRecord struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	Priority *int   `json:"priority,omitempty"` // MX preference, which may be 0
+}
+
+// dnsResolver is the part of net.Resolver `sx dns` uses, so tests can
+// answer without a DNS server.
+type dnsResolver interface {
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupNS(ctx context.Context, name string) ([]*net.NS, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// lookupName returns the host name a `sx dns` or `sx whois` argument names,
+// in ASCII. URLs, such as those of results, are accepted.
+func lookupName(arg string) string {
+	host := urlHost(arg)
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		return ascii
+	}
+	return host
+}
+
+// newDNSResolver returns the resolver for `sx dns`: the system's, or one
+// asking server (host or host:port) directly.
+func newDNSResolver(server string) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// validDNSTypes checks the --type values, returning them uppercased; the
+// default types if there are none.
+func validDNSTypes(types []string) ([]string, error) {
+	if len(types) == 0 {
+		return dnsTypes, nil
+	}
+	var valid []string
+	for _, t := range types {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t != "PTR" && !slices.Contains(dnsTypes, t) {
+			return nil, fmt.Errorf("unknown record type %q (%s or PTR)", t, strings.Join(dnsTypes, ", "))
+		}
+		if !slices.Contains(valid, t) {
+			valid = append(valid, t)
+		}
+	}
+	return valid, nil
+}
+
+// lookupDNS looks up the records of name of the given types, or the PTR
+// records of an IP address. Types name has no records of are left out; a
+// lookup failing otherwise is an error only if nothing was found.
+func lookupDNS(ctx context.Context, r dnsResolver, name string, types []string) ([]dnsRecord, error) {
+	if net.ParseIP(name) != nil {
+		types = []string{"PTR"}
+	}
+	var records []dnsRecord
+	var firstErr error
+	add := func(typ, value string) {
+		records = append(records, dnsRecord{Name: name, Type: typ, Value: value})
+	}
+	for _, typ := range types {
+		var err error
+		switch typ {
+		case "A", "AAAA":
+			network := "ip4"
+			if typ == "AAAA" {
+				network = "ip6"
+			}
+			var ips []net.IP
+			ips, err = r.LookupIP(ctx, network, name)
+			for _, ip := range ips {
+				add(typ, ip.String())
+			}
+		case "CNAME":
+			var cname string
+			cname, err = r.LookupCNAME(ctx, name)
+			// Names without an alias are their own canonical name
+			if cname = strings.TrimSuffix(cname, "."); err == nil && cname != "" && !strings.EqualFold(cname, name) {
+				add(typ, cname)
+			}
+		case "MX":
+			var mxs []*net.MX
+			mxs, err = r.LookupMX(ctx, name)
+			for _, mx := range mxs {
+				pref := int(mx.Pref)
+				records = append(records, dnsRecord{Name: name, Type: typ, Value: strings.TrimSuffix(mx.Host, "."), Priority: &pref})
+			}
+		case "NS":
+			var nss []*net.NS
+			nss, err = r.LookupNS(ctx, name)
+			for _, ns := range nss {
+				add(typ, strings.TrimSuffix(ns.Host, "."))
+			}
+		case "TXT":
+			var txts []string
+			txts, err = r.LookupTXT(ctx, name)
+			for _, txt := range txts {
+				add(typ, txt)
+			}
+		case "PTR":
+			var hosts []string
+			hosts, err = r.LookupAddr(ctx, name)
+			for _, host := range hosts {
+				add(typ, strings.TrimSuffix(host, "."))
+			}
+		}
+		var dnsErr *net.DNSError
+		if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(records) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	// MX records by preference, the others as the resolver gave them
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Type == "MX" && records[j].Type == "MX" && *records[i].Priority < *records[j].Priority
+	})
+	return records, nil
+}
+
+// runDNS implements `sx dns`.
+func runDNS(names, types []string, server string, asJSON bool) error {
+	types, err := validDNSTypes(types)
+	if err != nil {
+		return err
+	}
+	timeout := time.Duration(config.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	resolver := newDNSResolver(server)
+
+	records := []dnsRecord{}
+	for _, arg := range names {
+		name := lookupName(arg)
+		if name == "" {
+			return fmt.Errorf("no host name in %q", arg)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		found, err := lookupDNS(ctx, resolver, name, types)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if len(found) == 0 && !asJSON {
+			fmt.Fprintf(os.Stderr, "No %s records for %s\n", strings.Join(types, ", "), name)
+		}
+		records = append(records, found...)
+	}
+
+	if asJSON {
+		output, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format JSON: %v", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+	printDNSTable(os.Stdout, records)
+	return nil
+}
+
+// printDNSTable writes a row per record.
+func printDNSTable(w io.Writer, records []dnsRecord) {
+	if len(records) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTYPE\tVALUE")
+	for _, r := range records {
+		value := r.Value
+		if r.Priority != nil {
+			value = fmt.Sprintf("%d %s", *r.Priority, r.Value)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Name, r.Type, value)
+	}
+	tw.Flush()
+}
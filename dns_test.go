@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeResolver answers lookups from maps keyed by host name.
+type fakeResolver struct {
+	ips   map[string][]net.IP
+	cname map[string]string
+	mx    map[string][]*net.MX
+	ns    map[string][]*net.NS
+	txt   map[string][]string
+	ptr   map[string][]string
+	err   error
+}
+
+func (f fakeResolver) notFound(host string) error {
+	if f.err != nil {
+		return f.err
+	}
+	return &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (f fakeResolver) LookupIP(_ context.Context, network, host string) ([]net.IP, error) {
+	var ips []net.IP
+	for _, ip := range f.ips[host] {
+		if (ip.To4() != nil) == (network == "ip4") {
+			ips = append(ips, ip)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, f.notFound(host)
+	}
+	return ips, nil
+}
+
+func (f fakeResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if cname, ok := f.cname[host]; ok {
+		return cname, nil
+	}
+	return host + ".", f.err
+}
+
+func (f fakeResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	if mx, ok := f.mx[name]; ok {
+		return mx, nil
+	}
+	return nil, f.notFound(name)
+}
+
+func (f fakeResolver) LookupNS(_ context.Context, name string) ([]*net.NS, error) {
+	if ns, ok := f.ns[name]; ok {
+		return ns, nil
+	}
+	return nil, f.notFound(name)
+}
+
+func (f fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if txt, ok := f.txt[name]; ok {
+		return txt, nil
+	}
+	return nil, f.notFound(name)
+}
+
+func (f fakeResolver) LookupAddr(_ context.Context, addr string) ([]string, error) {
+	if ptr, ok := f.ptr[addr]; ok {
+		return ptr, nil
+	}
+	return nil, f.notFound(addr)
+}
+
+func TestLookupDNS(t *testing.T) {
+	r := fakeResolver{
+		ips:   map[string][]net.IP{"www.example.com": {net.ParseIP("93.184.215.14"), net.ParseIP("2606:2800:21f:cb07:6820:80da:af6b:8b2c")}},
+		cname: map[string]string{"www.example.com": "example.com."},
+		mx:    map[string][]*net.MX{"www.example.com": {{Host: "mx2.example.com.", Pref: 20}, {Host: "mx1.example.com.", Pref: 0}}},
+		txt:   map[string][]string{"www.example.com": {"v=spf1 -all"}},
+		ptr:   map[string][]string{"93.184.215.14": {"example.com."}},
+	}
+
+	records, err := lookupDNS(context.Background(), r, "www.example.com", dnsTypes)
+	if err != nil {
+		t.Fatalf("lookupDNS failed: %v", err)
+	}
+	var got []string
+	for _, rec := range records {
+		got = append(got, rec.Type+" "+rec.Value)
+	}
+	want := []string{"A 93.184.215.14", "AAAA 2606:2800:21f:cb07:6820:80da:af6b:8b2c", "CNAME example.com", "MX mx1.example.com", "MX mx2.example.com", "TXT v=spf1 -all"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if records[3].Priority == nil || *records[3].Priority != 0 || records[2].Priority != nil {
+		t.Errorf("unexpected priorities %+v", records)
+	}
+
+	// IP addresses get their names
+	records, err = lookupDNS(context.Background(), r, "93.184.215.14", dnsTypes)
+	if err != nil || len(records) != 1 || records[0].Type != "PTR" || records[0].Value != "example.com" {
+		t.Errorf("PTR: %+v, %v", records, err)
+	}
+
+	// Names without records aren't an error, failing lookups are
+	if records, err := lookupDNS(context.Background(), r, "nothing.example", []string{"A", "MX"}); err != nil || len(records) != 0 {
+		t.Errorf("no records: %+v, %v", records, err)
+	}
+	r.err = errors.New("i/o timeout")
+	if _, err := lookupDNS(context.Background(), r, "nothing.example", []string{"A", "MX"}); err == nil {
+		t.Error("expected the lookup error")
+	}
+	if records, err := lookupDNS(context.Background(), r, "www.example.com", []string{"A", "NS"}); err != nil || len(records) != 1 {
+		t.Errorf("partly failed: %+v, %v", records, err)
+	}
+}
+
+func TestValidDNSTypes(t *testing.T) {
+	if got, err := validDNSTypes([]string{"mx", " txt", "MX"}); err != nil || !reflect.DeepEqual(got, []string{"MX", "TXT"}) {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if got, _ := validDNSTypes(nil); !reflect.DeepEqual(got, dnsTypes) {
+		t.Errorf("default: %q", got)
+	}
+	if _, err := validDNSTypes([]string{"SOA"}); err == nil {
+		t.Error("expected an error for SOA")
+	}
+}
+
+func TestLookupName(t *testing.T) {
+	for arg, want := range map[string]string{
+		"Example.COM":                    "example.com",
+		"https://docs.python.org/3/":     "docs.python.org",
+		"bücher.de":                      "xn--bcher-kva.de",
+		"http://[2001:db8::1]:8080/path": "2001:db8::1",
+	} {
+		if got := lookupName(arg); got != want {
+			t.Errorf("%s: got %q, want %q", arg, got, want)
+		}
+	}
+}
+
+func TestPrintDNSTable(t *testing.T) {
+	pref := 10
+	var buf bytes.Buffer
+	printDNSTable(&buf, []dnsRecord{
+		{Name: "example.com", Type: "A", Value: "93.184.215.14"},
+		{Name: "example.com", Type: "MX", Value: "mail.example.com", Priority: &pref},
+	})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "NAME") || !strings.HasSuffix(lines[2], "MX    10 mail.example.com") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+}
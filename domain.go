@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// extractDomain returns the host of urlStr for display: lowercased, without
+// credentials or port, and with punycode labels decoded (xn--bcher-kva.de
+// becomes bücher.de). With registered set the host is reduced to its
+// registrable domain, so docs.python.org shows as python.org and
+// news.bbc.co.uk as bbc.co.uk. URLs without a scheme are accepted.
+func extractDomain(urlStr string, registered bool) string {
+	host := urlHost(urlStr)
+	if host == "" {
+		return ""
+	}
+	if registered && net.ParseIP(host) == nil {
+		if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+			host = domain
+		}
+	}
+	return unicodeHost(host)
+}
+
+// urlHost returns the lowercased ASCII host name of urlStr.
+func urlHost(urlStr string) string {
+	urlStr = strings.TrimSpace(urlStr)
+	if urlStr == "" {
+		return ""
+	}
+	u, err := url.Parse(urlStr)
+	if err != nil || u.Host == "" && (u.Scheme == "" || strings.Contains(u.Scheme, ".")) {
+		// Scheme-less "example.com/path" parses as a path, and
+		// "example.com:8080/x" as scheme "example.com"
+		u, err = url.Parse("//" + urlStr)
+	}
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
+// unicodeHost decodes punycode labels in host. Hosts that are not valid
+// IDNs, and IP addresses, are returned unchanged.
+func unicodeHost(host string) string {
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+	if decoded, err := idna.Display.ToUnicode(host); err == nil {
+		return decoded
+	}
+	return host
+}
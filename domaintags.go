@@ -0,0 +1,59 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// domainTagColors are the badge colors of common domain tags. Other tags
+// are shown in magenta.
+var domainTagColors = map[string]color.Attribute{
+	"trusted":     color.FgGreen,
+	"official":    color.FgGreen,
+	"paywalled":   color.FgYellow,
+	"opinion":     color.FgYellow,
+	"low-quality": color.FgRed,
+	"unreliable":  color.FgRed,
+}
+
+// tagDomains sets the DomainTags of results: the tags of the domain_tags
+// config whose domain lists hold the result's site or a parent domain of
+// it, in alphabetical order.
+func tagDomains(results []SearchResult, tags map[string][]string) {
+	if len(tags) == 0 {
+		return
+	}
+	names := make([]string, 0, len(tags))
+	for tag := range tags {
+		if strings.TrimSpace(tag) != "" {
+			names = append(names, tag)
+		}
+	}
+	sort.Strings(names)
+	for i := range results {
+		host := extractDomain(results[i].URL, false)
+		var found []string
+		for _, tag := range names {
+			if matchesAnySite(host, tags[tag]) {
+				found = append(found, strings.TrimSpace(tag))
+			}
+		}
+		results[i].DomainTags = found
+	}
+}
+
+// domainBadges renders a result's domain tags for its header line, as
+// " #trusted #paywalled".
+func domainBadges(tags []string) string {
+	var b strings.Builder
+	for _, tag := range tags {
+		attr, ok := domainTagColors[strings.ToLower(tag)]
+		if !ok {
+			attr = color.FgMagenta
+		}
+		b.WriteString(" " + color.New(attr).Sprint("#"+tag))
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestTagDomains(t *testing.T) {
+	tags := map[string][]string{
+		"trusted":     {"reuters.com", "www.apnews.com"},
+		"paywalled":   {"ft.com", "Reuters.com"},
+		"low-quality": {"content-farm.example"},
+		" ":           {"example.org"},
+	}
+	results := []SearchResult{
+		{URL: "https://www.reuters.com/world/"},
+		{URL: "https://apnews.com/article/1"},
+		{URL: "https://markets.ft.com/data"},
+		{URL: "https://notft.com/"},
+		{URL: "https://example.org/"},
+		{URL: "file:///home/me/notes/todo.md"},
+	}
+	tagDomains(results, tags)
+	want := [][]string{{"paywalled", "trusted"}, {"trusted"}, {"paywalled"}, nil, nil, nil}
+	for i, result := range results {
+		if !reflect.DeepEqual(result.DomainTags, want[i]) {
+			t.Errorf("%s: got %q, want %q", result.URL, result.DomainTags, want[i])
+		}
+	}
+}
+
+func TestDomainBadges(t *testing.T) {
+	saved := color.NoColor
+	defer func() { color.NoColor = saved }()
+	color.NoColor = true
+	if got := domainBadges([]string{"trusted", "internal"}); got != " #trusted #internal" {
+		t.Errorf("got %q", got)
+	}
+	if got := domainBadges(nil); got != "" {
+		t.Errorf("no tags: %q", got)
+	}
+
+	var buf bytes.Buffer
+	printResult(&buf, SearchResult{Title: "Markets", URL: "https://www.ft.com/markets", DomainTags: []string{"paywalled"}}, 1, true, 0, false)
+	if line, _, _ := strings.Cut(buf.String(), "\n"); !strings.HasSuffix(line, "[www.ft.com] #paywalled") {
+		t.Errorf("badge missing from header %q", line)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// errDryRun is returned by dryRunTransport in place of a response.
+var errDryRun = errors.New("dry run: request not sent")
+
+// dryRunTransport prints each request instead of sending it. Credentials
+// are masked the same way as in debug output.
+type dryRunTransport struct {
+	out io.Writer
+
+	mu    sync.Mutex
+	count int
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s %s\n", req.Method, redactURL(req.URL))
+	b.WriteString(formatHeaders(redactHeaders(req.Header)))
+	if len(body) > 0 {
+		b.WriteString("\n")
+		b.Write(redactBody(body, req.Header.Get("Content-Type")))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.count++
+	t.out.Write(b.Bytes())
+	return nil, errDryRun
+}
+
+// requests reports how many requests were printed.
+func (t *dryRunTransport) requests() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.count
+}
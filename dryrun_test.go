@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDryRunTransport(t *testing.T) {
+	var out bytes.Buffer
+	transport := &dryRunTransport{out: &out}
+	client := &http.Client{Transport: transport}
+
+	req, _ := http.NewRequest("POST", "https://api.example.com/search?q=go&api_key=secret-key", strings.NewReader(`{"query":"go","api_key":"secret-body"}`))
+	req.Header.Set("Authorization", "Bearer secret-header")
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err := client.Do(req)
+	if !errors.Is(err, errDryRun) {
+		t.Fatalf("expected errDryRun, got %v", err)
+	}
+	if transport.requests() != 1 {
+		t.Errorf("requests() = %d, want 1", transport.requests())
+	}
+
+	got := out.String()
+	for _, want := range []string{"POST https://api.example.com/search?", "q=go", "Authorization: REDACTED", `"query":"go"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("dry run output missing %q:\n%s", want, got)
+		}
+	}
+	for _, secret := range []string{"secret-key", "secret-body", "secret-header"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("dry run output leaked %q:\n%s", secret, got)
+		}
+	}
+}
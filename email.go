@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// defaultSMTPPort is the mail submission port, used with STARTTLS.
+const defaultSMTPPort = 587
+
+// check reports what is missing to send mail.
+func (c EmailConfig) check() error {
+	var missing []string
+	if c.Host == "" {
+		missing = append(missing, "host")
+	}
+	if c.From == "" {
+		missing = append(missing, "from")
+	}
+	if len(c.To) == 0 {
+		missing = append(missing, "to")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("email: set %s in the [email] config table", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// digestMessage builds a multipart/alternative mail with a plain text and
+// an HTML body.
+func digestMessage(from string, to []string, subject, text, html string, date time.Time) []byte {
+	b := make([]byte, 12)
+	rand.Read(b)
+	boundary := "sx-" + hex.EncodeToString(b)
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", text},
+		{"text/html", html},
+	} {
+		fmt.Fprintf(&msg, "--%s\r\n", boundary)
+		fmt.Fprintf(&msg, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		qp := quotedprintable.NewWriter(&msg)
+		qp.Write([]byte(strings.ReplaceAll(part.body, "\n", "\r\n")))
+		qp.Close()
+		msg.WriteString("\r\n")
+	}
+	fmt.Fprintf(&msg, "--%s--\r\n", boundary)
+	return msg.Bytes()
+}
+
+// sendMail delivers msg over SMTP. Port 465 connects with TLS; other ports
+// upgrade with STARTTLS when the server offers it. Credentials are only
+// sent over TLS (or to localhost).
+func sendMail(c EmailConfig, msg []byte) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+	port := c.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(port))
+	password := c.Password
+	if env := os.Getenv("SX_SMTP_PASSWORD"); env != "" {
+		password = env
+	}
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, password, c.Host)
+	}
+	// Both TLS from the start and STARTTLS trust ca_cert
+	tlsConfig := &tls.Config{ServerName: c.Host, RootCAs: rootCAs(config)}
+	var conn net.Conn
+	var err error
+	if port == 465 {
+		conn, err = tls.Dial("tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp: server doesn't support AUTH")
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.From); err != nil {
+		return err
+	}
+	for _, to := range c.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// digestSubject names the searches with new results and how many there
+// are in total.
+func digestSubject(sections []querySection) string {
+	names := make([]string, len(sections))
+	total := 0
+	for i, s := range sections {
+		names[i] = s.Query
+		total += len(s.window())
+	}
+	noun := "results"
+	if total == 1 {
+		noun = "result"
+	}
+	return fmt.Sprintf("sx: %d new %s for %s", total, noun, strings.Join(names, ", "))
+}
+
+// emailDigest mails the sections, as plain text and as an HTML report.
+func emailDigest(c EmailConfig, sections []querySection, now time.Time) error {
+	var text, html bytes.Buffer
+	noColor := color.NoColor
+	err := printMultiResults(&text, sections, &SearchOptions{}, config, nil)
+	color.NoColor = noColor
+	if err != nil {
+		return err
+	}
+	subject := digestSubject(sections)
+	if err := renderHTMLReport(&html, subject, sections, config.SnippetWords); err != nil {
+		return err
+	}
+	return sendMail(c, digestMessage(c.From, c.To, subject, text.String(), html.String(), now))
+}
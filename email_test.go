@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDigestMessage(t *testing.T) {
+	date := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	raw := digestMessage("sx@example.com", []string{"a@example.com", "b@example.com"}, "sx: 2 new results for Käse", "1. Title\n", "<p>Title</p>", date)
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "sx: 2 new results for Käse" {
+		t.Errorf("subject %q (%v)", subject, err)
+	}
+	if got := msg.Header.Get("To"); got != "a@example.com, b@example.com" {
+		t.Errorf("To %q", got)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type %q (%v)", mediaType, err)
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for _, want := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", "1. Title\r\n"},
+		{"text/html; charset=utf-8", "<p>Title</p>"},
+	} {
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(part)
+		if got := part.Header.Get("Content-Type"); got != want.contentType || string(body) != want.body {
+			t.Errorf("part %q %q, want %q %q", got, body, want.contentType, want.body)
+		}
+	}
+	if _, err := parts.NextPart(); err != io.EOF {
+		t.Errorf("extra part: %v", err)
+	}
+}
+
+func TestRenderHTMLReport(t *testing.T) {
+	sections := []querySection{{Query: "rust <releases>", Results: []SearchResult{
+		{Title: "Rust 2.0 & more", URL: "https://blog.rust-lang.org/2.0", Content: "<b>Big</b> news"},
+		{URL: "javascript:alert(1)"},
+	}}}
+	var b strings.Builder
+	if err := renderHTMLReport(&b, "digest", sections, 0); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"rust &lt;releases&gt; <span",
+		`<a href="https://blog.rust-lang.org/2.0"`,
+		"Rust 2.0 &amp; more",
+		"blog.rust-lang.org",
+		"Big news",
+		`href="#ZgotmplZ"`,
+		"No title",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestEmailConfigCheck(t *testing.T) {
+	if err := (EmailConfig{}).check(); err == nil || !strings.Contains(err.Error(), "host, from, to") {
+		t.Errorf("empty config: %v", err)
+	}
+	if err := (EmailConfig{Host: "mail", From: "a@b", To: []string{"c@d"}}).check(); err != nil {
+		t.Errorf("complete config: %v", err)
+	}
+}
+
+// smtpTestServer runs a minimal SMTP server for one session and returns
+// its port and the transcript of the session once it quits. With cert it
+// offers STARTTLS and nothing else; without, no extensions at all.
+func smtpTestServer(t *testing.T, cert *tls.Certificate) (int, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { conn.Close() }()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 test")
+		var transcript strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			transcript.WriteString(line)
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO") && cert != nil:
+				reply("250-test")
+				reply("250 STARTTLS")
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 test")
+			case cmd == "STARTTLS" && cert != nil:
+				reply("220 ready")
+				tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{*cert}})
+				if tlsConn.Handshake() != nil {
+					received <- transcript.String()
+					return
+				}
+				conn, r, cert = tlsConn, bufio.NewReader(tlsConn), nil
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					transcript.WriteString(line)
+				}
+				reply("250 ok")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- transcript.String()
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, received
+}
+
+// TestSendMail delivers through a server that offers no extensions, so the
+// message goes out unencrypted and unauthenticated.
+func TestSendMail(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+	port, received := smtpTestServer(t, nil)
+	c := EmailConfig{Host: "127.0.0.1", Port: port, From: "sx@example.com", To: []string{"me@example.com"}}
+	if err := sendMail(c, []byte("Subject: hi\r\n\r\nbody\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	transcript := <-received
+	for _, want := range []string{"MAIL FROM:<sx@example.com>", "RCPT TO:<me@example.com>", "Subject: hi\r\n", "body\r\n"} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("transcript lacks %q:\n%s", want, transcript)
+		}
+	}
+}
+
+// TestSendMailStartTLSTrustsCACert upgrades with STARTTLS to a server whose
+// certificate only ca_cert vouches for.
+func TestSendMailStartTLSTrustsCACert(t *testing.T) {
+	ca := httptest.NewUnstartedServer(nil)
+	ca.StartTLS()
+	ca.Close()
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate().Raw}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := config
+	defer func() {
+		config = saved
+		caPoolOnce, caPool = sync.Once{}, nil
+	}()
+	config = getDefaultConfig()
+	caPoolOnce, caPool = sync.Once{}, nil
+
+	c := EmailConfig{Host: "127.0.0.1", From: "sx@example.com", To: []string{"me@example.com"}}
+	cert := ca.TLS.Certificates[0]
+	// Without ca_cert the certificate isn't trusted
+	port, received := smtpTestServer(t, &cert)
+	c.Port = port
+	if err := sendMail(c, []byte("Subject: hi\r\n\r\nbody\r\n")); err == nil {
+		t.Error("untrusted certificate accepted")
+	}
+	<-received
+
+	config.CACert = bundle
+	port, received = smtpTestServer(t, &cert)
+	c.Port = port
+	if err := sendMail(c, []byte("Subject: hi\r\n\r\nbody\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	if transcript := <-received; !strings.Contains(transcript, "STARTTLS") || !strings.Contains(transcript, "body\r\n") {
+		t.Errorf("transcript:\n%s", transcript)
+	}
+}
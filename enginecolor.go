@@ -0,0 +1,67 @@
+package main
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// enginePalette holds the colors engines are assigned from. Dim, bold and
+// the plain white used elsewhere in the listing are left out so engine tags
+// stand apart from titles and snippets.
+var enginePalette = []color.Attribute{
+	color.FgBlue,
+	color.FgMagenta,
+	color.FgCyan,
+	color.FgGreen,
+	color.FgYellow,
+	color.FgRed,
+	color.FgHiBlue,
+	color.FgHiMagenta,
+	color.FgHiCyan,
+	color.FgHiGreen,
+	color.FgHiYellow,
+	color.FgHiRed,
+}
+
+// engineColor returns the color for an engine or backend name. The choice
+// depends only on the name, so an engine keeps its color across results,
+// pages and runs.
+func engineColor(name string) *color.Color {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(name)))
+	return color.New(enginePalette[h.Sum32()%uint32(len(enginePalette))])
+}
+
+// resultEngines lists the primary engines of results in order of first
+// appearance.
+func resultEngines(results []SearchResult) []string {
+	seen := map[string]bool{}
+	var engines []string
+	for _, r := range results {
+		name := r.Engine
+		if name == "" && len(r.Engines) > 0 {
+			name = r.Engines[0]
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			engines = append(engines, name)
+		}
+	}
+	return engines
+}
+
+// engineLegend renders "● google  ● bing ..." for results from more than
+// one engine, or "" when there is nothing to tell apart.
+func engineLegend(results []SearchResult) string {
+	engines := resultEngines(results)
+	if len(engines) < 2 || color.NoColor {
+		return ""
+	}
+	parts := make([]string, len(engines))
+	for i, name := range engines {
+		parts[i] = engineColor(name).Sprintf("● %s", name)
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestEngineColorIsStable(t *testing.T) {
+	oldNoColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = oldNoColor }()
+
+	if !engineColor("google").Equals(engineColor("Google")) {
+		t.Error("engine colors should not depend on case")
+	}
+	seen := map[string]bool{}
+	for _, name := range []string{"google", "bing", "duckduckgo", "brave", "wikipedia", "startpage"} {
+		seen[engineColor(name).Sprint("x")] = true
+	}
+	if len(seen) < 2 {
+		t.Error("all engines got the same color")
+	}
+}
+
+func TestEngineLegend(t *testing.T) {
+	oldNoColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = oldNoColor }()
+
+	results := []SearchResult{
+		{Engine: "google"},
+		{Engines: []string{"bing", "google"}},
+		{Engine: "google"},
+		{},
+	}
+	if got, want := resultEngines(results), []string{"google", "bing"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resultEngines = %v, want %v", got, want)
+	}
+	legend := engineLegend(results)
+	if !strings.Contains(legend, "google") || !strings.Contains(legend, "bing") {
+		t.Errorf("legend %q is missing engines", legend)
+	}
+	if got := engineLegend(results[:1]); got != "" {
+		t.Errorf("single-engine legend = %q, want none", got)
+	}
+
+	color.NoColor = true
+	if got := engineLegend(results); got != "" {
+		t.Errorf("legend without colors = %q, want none", got)
+	}
+}
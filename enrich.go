@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	// maxPageFetches limits how many result pages the enrichers fetch at once
+	maxPageFetches = 8
+	// maxEnrichBody is how much of a page the enrichers read
+	maxEnrichBody = 2 << 20
+	// languageSample is how much page text language detection looks at
+	languageSample = 4000
+)
+
+// enrichers are the --enrich steps, which add data to results before they
+// are sorted and shown. Each sets its own fields, so they run at once.
+var enrichers = map[string]func(results []SearchResult, config *Config){
+	"status":    checkLinks,
+	"favicon":   enrichFavicons,
+	"words":     enrichWordCounts,
+	"language":  enrichLanguages,
+	"citations": enrichCitations,
+	"site":      enrichSiteInfo,
+	"paywall":   enrichPaywalls,
+}
+
+// enrichKinds lists the --enrich values; "none" turns off the enrichers
+// the config enables.
+var enrichKinds = []string{"status", "favicon", "words", "language", "citations", "site", "paywall", "none"}
+
+// invalidEnrichKind returns the first of kinds that isn't an enricher, or "".
+func invalidEnrichKind(kinds []string) string {
+	for _, kind := range kinds {
+		if enrichers[kind] == nil && kind != "none" {
+			return kind
+		}
+	}
+	return ""
+}
+
+// enrichments are the enrichers opts asks for: those given with --enrich
+// or the enrich config, citations when sorting by them, site with
+// --site-info and paywall with --archive-paywalled.
+func enrichments(opts *SearchOptions) []string {
+	kinds := opts.Enrich
+	if opts.Sort == "citations" && !slices.Contains(kinds, "citations") {
+		kinds = append(slices.Clone(kinds), "citations")
+	}
+	if opts.SiteInfo && !slices.Contains(kinds, "site") {
+		kinds = append(slices.Clone(kinds), "site")
+	}
+	if opts.ArchiveCopies && !slices.Contains(kinds, "paywall") {
+		kinds = append(slices.Clone(kinds), "paywall")
+	}
+	return kinds
+}
+
+// enrichResults runs the enrichers in kinds over results concurrently.
+func enrichResults(results []SearchResult, kinds []string, config *Config) {
+	var wg sync.WaitGroup
+	for _, kind := range enrichKinds {
+		if enrich := enrichers[kind]; enrich != nil && slices.Contains(kinds, kind) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				enrich(results, config)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// pageInfo is what the page enrichers learn from a result page.
+type pageInfo struct {
+	Lang  string // primary subtag of <html lang>
+	Icon  string // the icon the page declares, as an absolute URL
+	Words int    // words of visible text
+	Text  string // the start of the visible text
+
+	Paywalled bool // whether the page marks itself as paywalled
+}
+
+type pageFetch struct {
+	once sync.Once
+	info *pageInfo // nil when the page couldn't be fetched
+}
+
+// pageInfos holds each result page fetched this run, so the favicon,
+// words and language enrichers fetch a page only once between them.
+var pageInfos = struct {
+	sync.Mutex
+	pages map[string]*pageFetch
+}{pages: map[string]*pageFetch{}}
+
+// resultPage returns what rawURL's page says about itself, fetching it on
+// first use, or nil if it can't be fetched or isn't HTML.
+func resultPage(client *http.Client, rawURL string, config *Config) *pageInfo {
+	pageInfos.Lock()
+	fetch := pageInfos.pages[rawURL]
+	if fetch == nil {
+		fetch = &pageFetch{}
+		pageInfos.pages[rawURL] = fetch
+	}
+	pageInfos.Unlock()
+
+	fetch.once.Do(func() {
+		info, err := fetchPageInfo(client, rawURL, config)
+		if err != nil {
+			if config.Debug {
+				fmt.Fprintf(os.Stderr, "Debug: enrich %s: %v\n", rawURL, err)
+			}
+			return
+		}
+		fetch.info = info
+	})
+	return fetch.info
+}
+
+func fetchPageInfo(client *http.Client, rawURL string, config *Config) (*pageInfo, error) {
+	req, err := setupHTTPRequest(http.MethodGet, rawURL, config)
+	if err != nil {
+		return nil, err
+	}
+	// Let the transport negotiate and decode compression
+	req.Header.Del("Accept-Encoding")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "html") {
+		return nil, fmt.Errorf("not HTML (%s)", ct)
+	}
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxEnrichBody))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &pageInfo{Paywalled: pagePaywalled(doc)}
+	if lang, ok := doc.Find("html").Attr("lang"); ok {
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(lang)), "-")
+		info.Lang = primary
+	}
+	doc.Find(`link[rel]`).EachWithBreak(func(_ int, sel *goquery.Selection) bool {
+		rels := strings.Fields(strings.ToLower(sel.AttrOr("rel", "")))
+		href := strings.TrimSpace(sel.AttrOr("href", ""))
+		if !slices.Contains(rels, "icon") || href == "" {
+			return true
+		}
+		if icon, err := resp.Request.URL.Parse(href); err == nil {
+			info.Icon = icon.String()
+		}
+		return false
+	})
+	body := doc.Find("body")
+	body.Find("script, style, noscript, template, svg").Remove()
+	words := strings.Fields(body.Text())
+	info.Words = len(words)
+	for _, word := range words {
+		if len(info.Text)+len(word) > languageSample {
+			break
+		}
+		info.Text += word + " "
+	}
+	return info, nil
+}
+
+// eachResultPage calls fn with each result that has a URL and its page
+// (nil if it couldn't be fetched), maxPageFetches at a time.
+func eachResultPage(results []SearchResult, config *Config, fn func(result *SearchResult, page *pageInfo)) {
+	client := setupHTTPClient(config)
+	sem := make(chan struct{}, maxPageFetches)
+	var wg sync.WaitGroup
+	for i := range results {
+		if results[i].URL == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(result *SearchResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			fn(result, resultPage(client, result.URL, config))
+		}(&results[i])
+	}
+	wg.Wait()
+}
+
+// enrichFavicons sets the icon each result's page declares, or the site's
+// /favicon.ico.
+func enrichFavicons(results []SearchResult, config *Config) {
+	eachResultPage(results, config, func(result *SearchResult, page *pageInfo) {
+		if page != nil && page.Icon != "" {
+			result.Favicon = page.Icon
+		} else if u, err := url.Parse(result.URL); err == nil && u.Host != "" {
+			result.Favicon = u.Scheme + "://" + u.Host + "/favicon.ico"
+		}
+	})
+}
+
+// enrichWordCounts sets how many words of text each result's page has.
+func enrichWordCounts(results []SearchResult, config *Config) {
+	eachResultPage(results, config, func(result *SearchResult, page *pageInfo) {
+		if page != nil {
+			result.WordCount = page.Words
+		}
+	})
+}
+
+// enrichLanguages sets the language of each result: detected from the
+// page text, else as the page declares it, else detected from the title
+// and snippet. Declared languages come last because site templates often
+// declare one whatever the page is written in.
+func enrichLanguages(results []SearchResult, config *Config) {
+	eachResultPage(results, config, func(result *SearchResult, page *pageInfo) {
+		var lang string
+		if page != nil {
+			if lang = detectLanguage(page.Text); lang == "" {
+				lang = page.Lang
+			}
+		}
+		if lang == "" {
+			lang = detectLanguage(result.Title + " " + result.Content)
+		}
+		result.Language = lang
+	})
+}
+
+// pageFacts summarizes the word count and language enrichers found for a
+// result, e.g. "1200 words · de".
+func pageFacts(result SearchResult) string {
+	var facts []string
+	if result.WordCount != 0 {
+		facts = append(facts, tr("word_count", result.WordCount))
+	}
+	if result.Language != "" {
+		facts = append(facts, result.Language)
+	}
+	return strings.Join(facts, " · ")
+}
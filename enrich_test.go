@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInvalidEnrichKind(t *testing.T) {
+	if kind := invalidEnrichKind([]string{"words", "language", "none"}); kind != "" {
+		t.Errorf("valid kinds rejected: %q", kind)
+	}
+	if kind := invalidEnrichKind([]string{"favicon", "pagerank"}); kind != "pagerank" {
+		t.Errorf("invalidEnrichKind = %q, want pagerank", kind)
+	}
+}
+
+func TestEnrichResultsFetchesEachPageOnce(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+	pageInfos.pages = map[string]*pageFetch{}
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pdf" {
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4"))
+			return
+		}
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html lang="de-AT"><head><link rel="shortcut icon" href="/static/icon.png">
+<style>body { color: red }</style></head>
+<body><p>Die Katze sitzt auf dem Dach und das ist nicht gut.</p><script>var x = 1;</script></body></html>`))
+	}))
+	defer server.Close()
+
+	results := []SearchResult{
+		{URL: server.URL + "/page"},
+		{URL: server.URL + "/pdf", Title: "The report on the state of the art"},
+	}
+	enrichResults(results, []string{"favicon", "words", "language"}, config)
+
+	if fetches.Load() != 1 {
+		t.Errorf("fetched the page %d times", fetches.Load())
+	}
+	page := results[0]
+	if page.Favicon != server.URL+"/static/icon.png" || page.WordCount != 11 || page.Language != "de" {
+		t.Errorf("page enriched as %q, %d words, %q", page.Favicon, page.WordCount, page.Language)
+	}
+	pdf := results[1]
+	if pdf.Favicon != server.URL+"/favicon.ico" || pdf.WordCount != 0 || pdf.Language != "en" {
+		t.Errorf("PDF enriched as %q, %d words, %q", pdf.Favicon, pdf.WordCount, pdf.Language)
+	}
+}
+
+func TestPageFacts(t *testing.T) {
+	saved := uiLocale
+	defer func() { uiLocale = saved }()
+	uiLocale = "en"
+
+	if got := pageFacts(SearchResult{WordCount: 1200, Language: "de"}); got != "1200 words · de" {
+		t.Errorf("pageFacts = %q", got)
+	}
+	if got := pageFacts(SearchResult{}); got != "" {
+		t.Errorf("pageFacts of plain result = %q", got)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxExtractChars caps the page text sent to the model per result
+	maxExtractChars = 12000
+	// maxExtractions limits how many results are extracted at once
+	maxExtractions = 4
+	// extractSystemPrompt tells the model to fill in the schema from the
+	// page only
+	extractSystemPrompt = "You extract structured data from web pages. Reply with a single JSON value that matches " +
+		"the given JSON schema, using only what the page states; use null for anything it doesn't. " +
+		"Reply with the JSON only, no prose and no code fences."
+)
+
+// extractRecord is one line of --extract-schema output: the data the
+// model extracted from a result's page, or why it couldn't.
+type extractRecord struct {
+	Rank  int             `json:"rank"`
+	URL   string          `json:"url"`
+	Title string          `json:"title"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// loadExtractSchema reads the JSON schema of --extract-schema.
+func loadExtractSchema(path string) (json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("schema %s is not a JSON object: %v", path, err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, err
+	}
+	return compact.Bytes(), nil
+}
+
+// extractPrompt asks for the schema's data from a page.
+func extractPrompt(result SearchResult, text string, schema json.RawMessage) string {
+	if len(text) > maxExtractChars {
+		text = strings.ToValidUTF8(text[:maxExtractChars], "") + " […]"
+	}
+	return fmt.Sprintf("JSON schema:\n%s\n\nPage: %s\nURL: %s\n\n%s", schema, result.Title, result.URL, text)
+}
+
+// extractJSON returns the JSON value in a model's reply, which some
+// models wrap in a code fence despite being told not to.
+func extractJSON(reply string) (json.RawMessage, error) {
+	reply = strings.TrimSpace(reply)
+	if strings.HasPrefix(reply, "```") {
+		reply = strings.TrimPrefix(reply, "```json")
+		reply = strings.TrimPrefix(reply, "```")
+		reply = strings.TrimSuffix(strings.TrimSpace(reply), "```")
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(strings.TrimSpace(reply))); err != nil {
+		return nil, fmt.Errorf("the model's reply is not JSON: %v", err)
+	}
+	return compact.Bytes(), nil
+}
+
+// extractResult reads result's page, the snippet standing in when it
+// can't be read, and has the model extract the schema's data from it.
+func extractResult(client *http.Client, result SearchResult, schema json.RawMessage, config *Config) (json.RawMessage, error) {
+	text := result.Content
+	if result.URL != "" {
+		_, markdown, err := fetchArticle(client, result.URL, config)
+		if err == nil && strings.TrimSpace(markdown) != "" {
+			text = markdown
+		} else if config.Debug && err != nil {
+			fmt.Fprintf(os.Stderr, "Debug: %v\n", err)
+		}
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("no page text")
+	}
+	messages := []chatMessage{
+		{Role: "system", Content: extractSystemPrompt},
+		{Role: "user", Content: extractPrompt(result, text, schema)},
+	}
+	// Servers that support structured output are held to the schema
+	reply, err := completeChat(config.LLM, messages, map[string]interface{}{
+		"response_format": map[string]interface{}{
+			"type":        "json_schema",
+			"json_schema": map[string]interface{}{"name": "record", "schema": schema},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return extractJSON(reply)
+}
+
+// printExtracted writes a JSON line per result with the data extracted by
+// the model, in rank order. first is the rank of the first result.
+func printExtracted(results []SearchResult, first int, schema json.RawMessage, outputFile string, config *Config) (err error) {
+	var output io.Writer = os.Stdout
+	if outputFile != "" {
+		file, openErr := createOutput(outputFile)
+		if openErr != nil {
+			return openErr
+		}
+		defer closeOutput(file, &err)
+		output = file
+	}
+
+	client := setupHTTPClient(config)
+	records := make([]extractRecord, len(results))
+	sem := make(chan struct{}, maxExtractions)
+	var wg sync.WaitGroup
+	for i, result := range results {
+		records[i] = extractRecord{Rank: first + i, URL: result.URL, Title: result.Title}
+		wg.Add(1)
+		go func(record *extractRecord) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			data, err := extractResult(client, result, schema, config)
+			if err != nil {
+				record.Error = err.Error()
+				return
+			}
+			record.Data = data
+		}(&records[i])
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, record := range records {
+		if record.Error != "" {
+			failed++
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(output, string(line)); err != nil {
+			return err
+		}
+	}
+	if failed == len(records) && failed > 0 {
+		return fmt.Errorf("no data extracted: %s", records[0].Error)
+	}
+	return nil
+}
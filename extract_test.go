@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadExtractSchema(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	os.WriteFile(good, []byte("{\n  \"type\": \"object\"\n}\n"), 0644)
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`["type"]`), 0644)
+
+	schema, err := loadExtractSchema(good)
+	if err != nil || string(schema) != `{"type":"object"}` {
+		t.Errorf("loadExtractSchema = %s, %v", schema, err)
+	}
+	if _, err := loadExtractSchema(bad); err == nil {
+		t.Error("accepted a schema that is not an object")
+	}
+	if _, err := loadExtractSchema(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("accepted a missing schema file")
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	for _, reply := range []string{
+		`{"name": "Widget", "price": 9.5}`,
+		"```json\n{\"name\": \"Widget\", \"price\": 9.5}\n```",
+		"  ```\n{\"name\":\"Widget\",\"price\":9.5}```\n",
+	} {
+		data, err := extractJSON(reply)
+		if err != nil || string(data) != `{"name":"Widget","price":9.5}` {
+			t.Errorf("extractJSON(%q) = %s, %v", reply, data, err)
+		}
+	}
+	if _, err := extractJSON("The widget costs 9.50."); err == nil {
+		t.Error("accepted prose")
+	}
+}
+
+func TestPrintExtracted(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+	t.Setenv("SX_LLM_API_KEY", "")
+
+	pages := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<html><body><article><h1>Widget</h1><p>The widget costs 9.50 euros and ships in two days from our warehouse.</p></article></body></html>`)
+	}))
+	defer pages.Close()
+
+	var prompts []string
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Messages       []chatMessage `json:"messages"`
+			ResponseFormat struct {
+				Type string `json:"type"`
+			} `json:"response_format"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		prompt := request.Messages[1].Content
+		prompts = append(prompts, prompt)
+		reply := `{"name": "Widget", "price": 9.5}`
+		if request.ResponseFormat.Type != "json_schema" || strings.Contains(prompt, "Snippet only") {
+			reply = "Sorry, no price."
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	defer llm.Close()
+	config.LLM = LLMConfig{URL: llm.URL, Model: "small"}
+
+	results := []SearchResult{
+		{Title: "Widget", URL: pages.URL + "/widget"},
+		{Title: "Gone", URL: pages.URL + "/missing", Content: "Snippet only"},
+	}
+	out := filepath.Join(t.TempDir(), "records.jsonl")
+	if err := printExtracted(results, 3, json.RawMessage(`{"type":"object"}`), out, config); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got:\n%s", data)
+	}
+	var first, second extractRecord
+	json.Unmarshal([]byte(lines[0]), &first)
+	json.Unmarshal([]byte(lines[1]), &second)
+	if first.Rank != 3 || first.Title != "Widget" || string(first.Data) != `{"name":"Widget","price":9.5}` || first.Error != "" {
+		t.Errorf("first record %s", lines[0])
+	}
+	if second.Rank != 4 || second.Data != nil || !strings.Contains(second.Error, "not JSON") {
+		t.Errorf("second record %s", lines[1])
+	}
+	for _, prompt := range prompts {
+		if strings.Contains(prompt, "Widget\nURL:") && !strings.Contains(prompt, "9.50 euros") {
+			t.Errorf("page text not sent:\n%s", prompt)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import "sort"
+
+// groupKeys are the values --group-by accepts.
+var groupKeys = []string{"domain", "engine", "category"}
+
+func validateGroupBy(key string) bool {
+	if key == "" {
+		return true
+	}
+	for _, k := range groupKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// resultGroup lists, by index into the results, the results sharing Name.
+type resultGroup struct {
+	Name    string
+	Indexes []int
+}
+
+// groupResults groups results [startAt, end) by key (domain, engine or
+// category). The largest groups come first, equal ones in the order their
+// first result ranks; within a group results keep their rank. Without a key
+// all results form one unnamed group.
+func groupResults(results []SearchResult, startAt, end int, key string) []resultGroup {
+	if key == "" {
+		group := resultGroup{}
+		for i := startAt; i < end; i++ {
+			group.Indexes = append(group.Indexes, i)
+		}
+		return []resultGroup{group}
+	}
+
+	var groups []resultGroup
+	byName := make(map[string]int)
+	for i := startAt; i < end; i++ {
+		name := groupName(results[i], key)
+		g, ok := byName[name]
+		if !ok {
+			g = len(groups)
+			byName[name] = g
+			groups = append(groups, resultGroup{Name: name})
+		}
+		groups[g].Indexes = append(groups[g].Indexes, i)
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		return len(groups[a].Indexes) > len(groups[b].Indexes)
+	})
+	return groups
+}
+
+// groupName is the group result belongs to under key. Domains are
+// registrable domains, so docs.python.org and www.python.org count as one
+// site.
+func groupName(result SearchResult, key string) string {
+	var name string
+	switch key {
+	case "domain":
+		name = extractDomain(result.URL, true)
+	case "engine":
+		if engines := resultEngineNames(result); len(engines) > 0 {
+			name = engines[0]
+		}
+	case "category":
+		name = result.Category
+	}
+	if name == "" {
+		return tr("group_other")
+	}
+	return name
+}
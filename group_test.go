@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGroupResults(t *testing.T) {
+	results := []SearchResult{
+		{URL: "https://go.dev/doc", Engine: "google"},
+		{URL: "https://github.com/golang/go", Engine: "bing"},
+		{URL: "https://pkg.go.dev/fmt", Engine: "google"},
+		{URL: "https://github.com/avelino/awesome-go", Engine: "google"},
+		{URL: "https://tour.go.dev", Engine: "bing"},
+		{URL: "", Engine: ""},
+	}
+
+	groups := groupResults(results, 0, len(results), "domain")
+	got := fmt.Sprint(groups)
+	want := "[{go.dev [0 2 4]} {github.com [1 3]} {other [5]}]"
+	if got != want {
+		t.Errorf("by domain: got %s, want %s", got, want)
+	}
+
+	groups = groupResults(results, 1, 5, "engine")
+	if got := fmt.Sprint(groups); got != "[{bing [1 4]} {google [2 3]}]" {
+		t.Errorf("by engine: got %s", got)
+	}
+
+	groups = groupResults(results, 2, 4, "")
+	if got := fmt.Sprint(groups); got != "[{ [2 3]}]" {
+		t.Errorf("ungrouped: got %s", got)
+	}
+}
+
+func TestValidateGroupBy(t *testing.T) {
+	for _, key := range []string{"", "domain", "engine", "category"} {
+		if !validateGroupBy(key) {
+			t.Errorf("validateGroupBy(%q) = false", key)
+		}
+	}
+	if validateGroupBy("title") {
+		t.Error("validateGroupBy(\"title\") = true")
+	}
+}
+
+func TestPrintResultsGrouped(t *testing.T) {
+	defer func(key string) { searchOpts.GroupBy = key }(searchOpts.GroupBy)
+	searchOpts.GroupBy = "domain"
+
+	results := []SearchResult{
+		{Title: "Docs", URL: "https://go.dev/doc"},
+		{Title: "Repo", URL: "https://github.com/golang/go"},
+		{Title: "Tour", URL: "https://go.dev/tour"},
+	}
+	var buf bytes.Buffer
+	printResults(&buf, results, 10, 0, false, true, 0, false, "go")
+	out := buf.String()
+	goDev, github := strings.Index(out, "go.dev (2)\n"), strings.Index(out, "github.com (1)\n")
+	if goDev < 0 || github < goDev || strings.Index(out, " 3. Tour") > github {
+		t.Errorf("unexpected grouping:\n%s", out)
+	}
+}
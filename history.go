@@ -144,7 +144,7 @@ func printHistory(limit int) error {
 	}
 
 	if len(entries) == 0 {
-		fmt.Println("No search history.")
+		fmt.Fprintln(os.Stderr, "No search history.")
 		return nil
 	}
 
@@ -166,6 +166,6 @@ func clearHistory() error {
 	if err := os.Remove(historyFile); err != nil && !os.IsNotExist(err) {
 		return err
 	}
-	fmt.Println("History cleared.")
+	fmt.Fprintln(os.Stderr, "History cleared.")
 	return nil
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// hyperlinkMode is how result titles and domains are linked, resolved from
+// the hyperlinks config by applyHyperlinkMode: "always", or "never", or
+// "auto" for terminals known to support OSC 8 hyperlinks.
+var hyperlinkMode = "never"
+
+// applyHyperlinkMode resolves the hyperlinks config against the terminal.
+func applyHyperlinkMode(config *Config) {
+	switch config.Hyperlinks {
+	case "always", "never":
+		hyperlinkMode = config.Hyperlinks
+	default:
+		hyperlinkMode = "never"
+		if supportsHyperlinks(os.Getenv) {
+			hyperlinkMode = "auto"
+		}
+	}
+}
+
+// supportsHyperlinks detects terminals that render OSC 8 hyperlinks.
+// Terminal multiplexers are left out: older tmux and screen versions print
+// the escape sequences literally.
+func supportsHyperlinks(getenv func(string) string) bool {
+	term := getenv("TERM")
+	if term == "dumb" || getenv("TMUX") != "" || strings.HasPrefix(term, "screen") {
+		return false
+	}
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper", "rio":
+		return true
+	}
+	if getenv("WT_SESSION") != "" || getenv("KITTY_WINDOW_ID") != "" || getenv("KONSOLE_VERSION") != "" {
+		return true
+	}
+	// GNOME Terminal, Tilix and other VTE terminals since 0.50
+	if vte, err := strconv.Atoi(getenv("VTE_VERSION")); err == nil && vte >= 5000 {
+		return true
+	}
+	for _, name := range []string{"kitty", "alacritty", "foot", "ghostty", "wezterm"} {
+		if strings.Contains(term, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hyperlink makes text a link to target when writing colored output to w
+// and hyperlinks are on; otherwise text is returned as is.
+func hyperlink(w io.Writer, text, target string, noColor bool) string {
+	if target == "" || noColor || hyperlinkMode == "never" {
+		return text
+	}
+	if hyperlinkMode == "auto" && !isTerminalWriter(w) {
+		return text
+	}
+	return "\x1b]8;;" + target + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// domainURL is the root of the site urlStr is on, which the domain label
+// links to.
+func domainURL(urlStr string) string {
+	u, err := url.Parse(strings.TrimSpace(urlStr))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + "/"
+}
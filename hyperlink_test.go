@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSupportsHyperlinks(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want bool
+	}{
+		{map[string]string{}, false},
+		{map[string]string{"TERM": "xterm-256color"}, false},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, true},
+		{map[string]string{"TERM": "xterm-kitty"}, true},
+		{map[string]string{"VTE_VERSION": "7600"}, true},
+		{map[string]string{"VTE_VERSION": "4800"}, false},
+		{map[string]string{"WT_SESSION": "abc"}, true},
+		{map[string]string{"TERM_PROGRAM": "WezTerm", "TMUX": "/tmp/tmux-1000/default,1,0"}, false},
+		{map[string]string{"TERM": "screen-256color", "VTE_VERSION": "7600"}, false},
+		{map[string]string{"TERM": "dumb", "WT_SESSION": "abc"}, false},
+	}
+	for _, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := supportsHyperlinks(getenv); got != tt.want {
+			t.Errorf("supportsHyperlinks(%v) = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestHyperlink(t *testing.T) {
+	defer func(mode string) { hyperlinkMode = mode }(hyperlinkMode)
+	var buf bytes.Buffer
+
+	hyperlinkMode = "always"
+	want := "\x1b]8;;https://go.dev/\x1b\\Go\x1b]8;;\x1b\\"
+	if got := hyperlink(&buf, "Go", "https://go.dev/", false); got != want {
+		t.Errorf("always: got %q, want %q", got, want)
+	}
+	if got := hyperlink(&buf, "Go", "https://go.dev/", true); got != "Go" {
+		t.Errorf("no color: got %q, want plain text", got)
+	}
+	if got := hyperlink(&buf, "Go", "", false); got != "Go" {
+		t.Errorf("no target: got %q, want plain text", got)
+	}
+
+	// auto only links when writing to a terminal
+	hyperlinkMode = "auto"
+	if got := hyperlink(&buf, "Go", "https://go.dev/", false); got != "Go" {
+		t.Errorf("auto to a buffer: got %q, want plain text", got)
+	}
+}
+
+func TestDomainURL(t *testing.T) {
+	tests := map[string]string{
+		"https://docs.python.org/3/library/": "https://docs.python.org/",
+		"http://example.com:8080/x?y=1":      "http://example.com:8080/",
+		"magnet:?xt=urn:btih:abc":            "",
+		"":                                   "",
+	}
+	for in, want := range tests {
+		if got := domainURL(in); got != want {
+			t.Errorf("domainURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// uiLocale is the language of messages, prompts and dates, set by
+// applyLocale. Machine-readable output (JSON, --porcelain) is never
+// localized.
+var uiLocale = "en"
+
+// catalogs maps a locale to its messages by ID. Every ID must exist in
+// "en", which is also the fallback for IDs missing in other locales.
+// Messages are fmt format strings.
+var catalogs = map[string]map[string]string{
+	"en": {
+		"query":              "Query: %s",
+		"engines":            "Engines: %s",
+		"no_title":           "No title",
+		"also_on":            "also on %s",
+		"peers":              "↑%d seeders, ↓%d leechers",
+		"did_you_mean":       "Did you mean: %s?",
+		"did_you_mean_hint":  "(type 'y' to search for it)",
+		"related":            "Related: %s",
+		"no_results":         "No results found.",
+		"no_results_for":     "No results found for %q.",
+		"no_more_results":    "No more results.",
+		"relaxed":            "No results found; showing results without the %s",
+		"relaxed_for":        "No results found for %q; showing results without the %s",
+		"rerank_failed":      "Reranking failed, keeping the engine's order: %v",
+		"web_failed":         "Web search failed, showing results from %s only: %v",
+		"install":            "install: %s",
+		"rerank_failed_for":  "Reranking %q failed, keeping the engine's order: %v",
+		"relax_time_range":   "time range (%s)",
+		"relax_sites":        "site filter (%s)",
+		"relax_categories":   "categories (%s)",
+		"prompt":             "sx (? for help): ",
+		"debug_enabled":      "Debug mode enabled",
+		"debug_disabled":     "Debug mode disabled",
+		"invalid_time_range": "Invalid time range '%s'. Use: %s",
+		"invalid_sort":       "Invalid sort '%s'. Use: %s",
+		"invalid_index":      "Invalid index specified.",
+		"no_magnet":          "Result %d has no magnet link.",
+		"group_heading":      "%s (%d)",
+		"group_other":        "other",
+		"interactive_help": `
+- Enter a search query to perform a new search.
+- Type 'n', 'p', and 'f' to navigate to the next, previous and first page of results.
+- Type the index (1, 2, 3, etc) to open the search result in a browser (or print its URL with --print-url).
+- Type 'c' plus the index ('c 1', 'c 2') to show the result URL.
+- Type 'm' plus the index ('m 1') to show a torrent's magnet link and open it in the torrent client.
+- Type 'play' plus the index ('play 1') to open the result in the configured media player.
+- Type 'r timerange' to change the search time range (e.g. 'r week').
+- Type 's' plus a key to re-sort the loaded results (seeders, date, score, title, domain).
+- Type 'site:example.com' to filter results by a specific site ('site:a.com,b.com' for several).
+- Type 'x' to toggle showing result URLs.
+- Type 'y' to search for the suggested spelling correction, if any.
+- Type 'd' to toggle debug output.
+- Type 'j' plus the index ('j 1', 'j 2') to show the JSON result for the specified index.
+- Type 'q', 'quit', or 'exit' to exit the program.
+- Type '?' for this help message.
+`,
+		"a11y_heading":     "Search results for %s. %d results.",
+		"a11y_heading_one": "Search results for %s. 1 result.",
+		"a11y_result":      "Result %d of %d: %s",
+		"a11y_result_page": "Result %d (%d of %d): %s",
+		"a11y_group":       "Group %s, %d results.",
+		"a11y_group_one":   "Group %s, 1 result.",
+		"a11y_site":        "Site",
+		"a11y_link":        "Link",
+		"a11y_link_status": "Link status",
+		"a11y_open_access": "Open access",
+		"a11y_also_on":     "Also on",
+		"a11y_summary":     "Summary",
+		"a11y_found_by":    "Found by",
+		"a11y_published":   "Published",
+		"a11y_resolution":  "Resolution",
+		"a11y_source":      "Source",
+		"a11y_image":       "Image",
+		"a11y_length":      "Length",
+		"a11y_author":      "Author",
+		"a11y_album":       "Album",
+		"a11y_install":     "Install",
+		"a11y_address":     "Address",
+		"a11y_coordinates": "Coordinates",
+		"a11y_coords":      "latitude %.6f, longitude %.6f",
+		"a11y_journal":     "Journal",
+		"a11y_publisher":   "Publisher",
+		"a11y_magnet":      "Magnet link",
+		"a11y_size":        "Size",
+		"a11y_peers":       "Peers",
+		"a11y_peers_count": "%d seeders, %d leechers",
+		"a11y_details":     "Details",
+		"link_alive":       "alive, HTTP %d",
+		"link_redirect":    "redirects to %s",
+		"link_dead_code":   "dead, HTTP %d",
+		"oa_free":          "open access (%s): %s",
+		"oa_replaced":      "open access (%s) copy, paywalled at %s",
+		"oa_closed":        "no open access copy",
+		"cited_by":         "cited by %d",
+		"word_count":       "%d words",
+		"a11y_language":    "Language",
+		"site_hosted":      "hosted in %s",
+		"site_certificate": "certificate by %s",
+		"a11y_site_info":   "Hosting",
+		"a11y_domain_tags": "Tags",
+		"archived_copy":    "archived copy: %s",
+		"keywords":         "Keywords: %s",
+		"cluster_lead":     "cluster %d: %d similar results further down",
+		"cluster_member":   "cluster %d: similar to a result further up",
+		"tokens_left_out":  "Left out %d lower-ranked results to fit --max-tokens %d",
+		"diff_was":         "(was %d)",
+		"diff_summary":     "%d added, %d removed, %d moved, %d unchanged",
+		"diff_since":       "Changes since the last run, %s",
+		"diff_no_last_run": "No earlier run of this query; all results are new",
+		"ai_left_out":      "Left out %d pages whose sites restrict AI use (--ai-policy exclude)",
+		"link_dead":        "dead, %s",
+
+		// Errors
+		"error":               "Error: %v",
+		"config_error":        "Error loading config: %v",
+		"config_create_error": "Error creating config: %v",
+		"search_error":        "Search error: %v",
+		"search_error_for":    "Search error for %q: %v",
+		"flag_conflict":       "%s can't be combined with %s",
+		"flag_multi_query":    "%s can't be combined with several queries",
+		"invalid_flag":        "Invalid %s '%s'. Use: %s",
+		"invalid_value":       "Invalid %s: %v",
+		"invalid_category":    "Invalid category '%s'. Supported categories are: %s",
+		"not_negative":        "%s must not be negative",
+		"at_least_one":        "%s must be 1 or greater",
+		"only_with":           "%s only applies to %s",
+		"need_output":         "--append, --output-if-results and --rotate need --output",
+		"rerank_order":        "--rerank orders results itself and can't be combined with --sort or --near",
+		"unpaywall_email":     "--open-access needs unpaywall_email in the config or UNPAYWALL_EMAIL (Unpaywall asks for a contact address)",
+		"no_searxng":          "no SearXNG instance configured (set searxng_url or searxng_urls)",
+		"no_searxng_hint":     "Set searxng_url/searxng_urls in config.toml or use --engine brave/google/serpapi/mojeek/marginalia/tavily/exa/jina/perplexity",
+		"no_answer":           "No instant answer found.",
+		"save_run_failed":     "Debug: saving run: %v",
+		"write_json_error":    "Error writing JSON to file: %v",
+		"format_json_error":   "Error formatting JSON: %v",
+		"write_error":         "Error writing results to file: %v",
+		"links_error":         "Error outputting links: %v",
+		"magnets_error":       "Error outputting magnet links: %v",
+		"feeds_error":         "Error outputting podcast feeds: %v",
+		"html_error":          "Error outputting HTML: %v",
+		"text_error":          "Error outputting text: %v",
+		"extract_error":       "Error extracting data: %v",
+		"download_error":      "Error downloading images: %v",
+		"download_url_error":  "Error downloading %s: %v",
+		"command_exit_error":  "Error: %s exited with status %d",
+		"command_error":       "Error: %s: %v",
+		"open_url_error":      "Error opening URL: %v",
+		"open_magnet_error":   "Error opening magnet link: %v",
+		"play_error":          "Error playing result: %v",
+	},
+	"de": {
+		"query":              "Suche: %s",
+		"engines":            "Suchmaschinen: %s",
+		"no_title":           "Kein Titel",
+		"also_on":            "auch auf %s",
+		"peers":              "↑%d Seeder, ↓%d Leecher",
+		"did_you_mean":       "Meinten Sie: %s?",
+		"did_you_mean_hint":  "('y' eingeben, um danach zu suchen)",
+		"related":            "Verwandt: %s",
+		"no_results":         "Keine Ergebnisse gefunden.",
+		"no_results_for":     "Keine Ergebnisse für %q gefunden.",
+		"no_more_results":    "Keine weiteren Ergebnisse.",
+		"relaxed":            "Keine Ergebnisse gefunden; zeige Ergebnisse ohne %s",
+		"relaxed_for":        "Keine Ergebnisse für %q gefunden; zeige Ergebnisse ohne %s",
+		"rerank_failed":      "Neu-Ranking fehlgeschlagen, behalte die Reihenfolge der Suchmaschine: %v",
+		"web_failed":         "Websuche fehlgeschlagen, zeige nur Ergebnisse von %s: %v",
+		"install":            "installieren: %s",
+		"rerank_failed_for":  "Neu-Ranking von %q fehlgeschlagen, behalte die Reihenfolge der Suchmaschine: %v",
+		"relax_time_range":   "Zeitraum (%s)",
+		"relax_sites":        "Seitenfilter (%s)",
+		"relax_categories":   "Kategorien (%s)",
+		"prompt":             "sx (? für Hilfe): ",
+		"debug_enabled":      "Debug-Modus an",
+		"debug_disabled":     "Debug-Modus aus",
+		"invalid_time_range": "Ungültiger Zeitraum '%s'. Möglich: %s",
+		"invalid_sort":       "Ungültige Sortierung '%s'. Möglich: %s",
+		"invalid_index":      "Ungültige Nummer.",
+		"no_magnet":          "Ergebnis %d hat keinen Magnet-Link.",
+		"group_other":        "sonstige",
+		"interactive_help": `
+- Suchbegriff eingeben, um neu zu suchen.
+- 'n', 'p' und 'f' blättern zur nächsten, vorherigen und ersten Ergebnisseite.
+- Die Nummer (1, 2, 3 usw.) öffnet das Ergebnis im Browser (oder gibt mit --print-url seine URL aus).
+- 'c' und die Nummer ('c 1', 'c 2') zeigen die URL des Ergebnisses.
+- 'm' und die Nummer ('m 1') zeigen den Magnet-Link eines Torrents und öffnen ihn im Torrent-Client.
+- 'play' und die Nummer ('play 1') öffnen das Ergebnis im eingestellten Mediaplayer.
+- 'r zeitraum' ändert den Zeitraum der Suche (z. B. 'r week').
+- 's' und ein Schlüssel sortieren die geladenen Ergebnisse neu (seeders, date, score, title, domain).
+- 'site:example.com' beschränkt die Ergebnisse auf eine Seite ('site:a.com,b.com' für mehrere).
+- 'x' blendet die URLs der Ergebnisse ein und aus.
+- 'y' sucht nach der vorgeschlagenen Schreibweise, falls es eine gibt.
+- 'd' schaltet die Debug-Ausgabe um.
+- 'j' und die Nummer ('j 1', 'j 2') zeigen das Ergebnis als JSON.
+- 'q', 'quit' oder 'exit' beenden das Programm.
+- '?' zeigt diese Hilfe.
+`,
+		"a11y_heading":     "Suchergebnisse für %s. %d Ergebnisse.",
+		"a11y_heading_one": "Suchergebnisse für %s. 1 Ergebnis.",
+		"a11y_result":      "Ergebnis %d von %d: %s",
+		"a11y_result_page": "Ergebnis %d (%d von %d): %s",
+		"a11y_group":       "Gruppe %s, %d Ergebnisse.",
+		"a11y_group_one":   "Gruppe %s, 1 Ergebnis.",
+		"a11y_site":        "Seite",
+		"a11y_link":        "Link",
+		"a11y_link_status": "Linkstatus",
+		"a11y_open_access": "Freier Zugang",
+		"a11y_also_on":     "Auch auf",
+		"a11y_summary":     "Zusammenfassung",
+		"a11y_found_by":    "Gefunden von",
+		"a11y_published":   "Veröffentlicht",
+		"a11y_resolution":  "Auflösung",
+		"a11y_source":      "Quelle",
+		"a11y_image":       "Bild",
+		"a11y_length":      "Länge",
+		"a11y_author":      "Autor",
+		"a11y_album":       "Album",
+		"a11y_install":     "Installieren",
+		"a11y_address":     "Adresse",
+		"a11y_coordinates": "Koordinaten",
+		"a11y_coords":      "Breite %.6f, Länge %.6f",
+		"a11y_journal":     "Zeitschrift",
+		"a11y_publisher":   "Verlag",
+		"a11y_magnet":      "Magnet-Link",
+		"a11y_size":        "Größe",
+		"a11y_peers":       "Peers",
+		"a11y_peers_count": "%d Seeder, %d Leecher",
+		"a11y_details":     "Details",
+		"link_alive":       "erreichbar, HTTP %d",
+		"link_redirect":    "leitet weiter auf %s",
+		"link_dead_code":   "nicht erreichbar, HTTP %d",
+		"oa_free":          "frei zugänglich (%s): %s",
+		"oa_replaced":      "frei zugängliche Kopie (%s), kostenpflichtig unter %s",
+		"oa_closed":        "keine frei zugängliche Kopie",
+		"cited_by":         "%d-mal zitiert",
+		"word_count":       "%d Wörter",
+		"a11y_language":    "Sprache",
+		"site_hosted":      "gehostet in %s",
+		"site_certificate": "Zertifikat von %s",
+		"a11y_site_info":   "Hosting",
+		"a11y_domain_tags": "Markierungen",
+		"archived_copy":    "archivierte Kopie: %s",
+		"keywords":         "Schlüsselwörter: %s",
+		"cluster_lead":     "Gruppe %d: %d ähnliche Ergebnisse weiter unten",
+		"cluster_member":   "Gruppe %d: ähnlich wie ein Ergebnis weiter oben",
+		"tokens_left_out":  "%d niedriger eingestufte Ergebnisse ausgelassen, um --max-tokens %d einzuhalten",
+		"diff_was":         "(vorher %d)",
+		"diff_summary":     "%d neu, %d entfernt, %d verschoben, %d unverändert",
+		"diff_since":       "Änderungen seit der letzten Suche, %s",
+		"diff_no_last_run": "Keine frühere Suche nach dieser Anfrage; alle Ergebnisse sind neu",
+		"ai_left_out":      "%d Seiten ausgelassen, deren Websites die Nutzung für KI einschränken (--ai-policy exclude)",
+		"link_dead":        "nicht erreichbar, %s",
+
+		// Errors
+		"error":               "Fehler: %v",
+		"config_error":        "Fehler beim Laden der Konfiguration: %v",
+		"config_create_error": "Fehler beim Anlegen der Konfiguration: %v",
+		"search_error":        "Fehler bei der Suche: %v",
+		"search_error_for":    "Fehler bei der Suche nach %q: %v",
+		"flag_conflict":       "%s lässt sich nicht mit %s kombinieren",
+		"flag_multi_query":    "%s lässt sich nicht mit mehreren Suchanfragen kombinieren",
+		"invalid_flag":        "Ungültiger Wert für %s: '%s'. Möglich: %s",
+		"invalid_value":       "Ungültiger Wert für %s: %v",
+		"invalid_category":    "Ungültige Kategorie '%s'. Unterstützte Kategorien: %s",
+		"not_negative":        "%s darf nicht negativ sein",
+		"at_least_one":        "%s muss mindestens 1 sein",
+		"only_with":           "%s gilt nur für %s",
+		"need_output":         "--append, --output-if-results und --rotate brauchen --output",
+		"rerank_order":        "--rerank sortiert die Ergebnisse selbst und lässt sich nicht mit --sort oder --near kombinieren",
+		"unpaywall_email":     "--open-access braucht unpaywall_email in der Konfiguration oder UNPAYWALL_EMAIL (Unpaywall verlangt eine Kontaktadresse)",
+		"no_searxng":          "keine SearXNG-Instanz eingerichtet (searxng_url oder searxng_urls setzen)",
+		"no_searxng_hint":     "searxng_url/searxng_urls in config.toml setzen oder --engine brave/google/serpapi/mojeek/marginalia/tavily/exa/jina/perplexity verwenden",
+		"no_answer":           "Keine direkte Antwort gefunden.",
+		"save_run_failed":     "Debug: Suche nicht gespeichert: %v",
+		"write_json_error":    "Fehler beim Schreiben des JSON in die Datei: %v",
+		"format_json_error":   "Fehler beim Formatieren des JSON: %v",
+		"write_error":         "Fehler beim Schreiben der Ergebnisse in die Datei: %v",
+		"links_error":         "Fehler bei der Ausgabe der Links: %v",
+		"magnets_error":       "Fehler bei der Ausgabe der Magnet-Links: %v",
+		"feeds_error":         "Fehler bei der Ausgabe der Podcast-Feeds: %v",
+		"html_error":          "Fehler bei der Ausgabe als HTML: %v",
+		"text_error":          "Fehler bei der Ausgabe des Textes: %v",
+		"extract_error":       "Fehler beim Extrahieren der Daten: %v",
+		"download_error":      "Fehler beim Herunterladen der Bilder: %v",
+		"download_url_error":  "Fehler beim Herunterladen von %s: %v",
+		"command_exit_error":  "Fehler: %s wurde mit Status %d beendet",
+		"command_error":       "Fehler: %s: %v",
+		"open_url_error":      "Fehler beim Öffnen der URL: %v",
+		"open_magnet_error":   "Fehler beim Öffnen des Magnet-Links: %v",
+		"play_error":          "Fehler beim Abspielen des Ergebnisses: %v",
+	},
+}
+
+// monthNames are the localized month names for formatDate.
+var monthNames = map[string][12]string{
+	"de": {"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+}
+
+// applyLocale selects the UI locale from the locale config, then the
+// environment.
+func applyLocale(config *Config) {
+	uiLocale = detectLocale(config.Locale, os.Getenv)
+}
+
+// detectLocale returns the first supported locale of configured, $LC_ALL,
+// $LC_MESSAGES and $LANG, so "de_DE.UTF-8" selects "de". Anything
+// unsupported falls back to English; like gettext, the first variable that
+// is set decides.
+func detectLocale(configured string, getenv func(string) string) string {
+	value := configured
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value != "" {
+			break
+		}
+		value = getenv(key)
+	}
+	lang := strings.ToLower(value)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return "en"
+}
+
+// tr returns message id in the UI locale, formatted with args.
+func tr(id string, args ...interface{}) string {
+	msg, ok := catalogs[uiLocale][id]
+	if !ok {
+		msg = catalogs["en"][id]
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// formatDate formats a result date in the UI locale: "January 2, 2006" in
+// English, "2. Januar 2006" in German.
+func formatDate(t time.Time) string {
+	names, ok := monthNames[uiLocale]
+	if !ok {
+		return t.Format("January 2, 2006")
+	}
+	return fmt.Sprintf("%d. %s %d", t.Day(), names[t.Month()-1], t.Year())
+}
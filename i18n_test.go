@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectLocale(t *testing.T) {
+	tests := []struct {
+		configured string
+		env        map[string]string
+		want       string
+	}{
+		{"", map[string]string{}, "en"},
+		{"", map[string]string{"LANG": "de_DE.UTF-8"}, "de"},
+		{"", map[string]string{"LANG": "de_AT@euro"}, "de"},
+		{"", map[string]string{"LANG": "fr_FR.UTF-8"}, "en"},
+		{"", map[string]string{"LANG": "C"}, "en"},
+		{"", map[string]string{"LC_ALL": "en_US.UTF-8", "LANG": "de_DE.UTF-8"}, "en"},
+		{"", map[string]string{"LC_MESSAGES": "de_CH", "LANG": "en_GB"}, "de"},
+		{"de", map[string]string{"LANG": "en_US.UTF-8"}, "de"},
+		{"DE", map[string]string{}, "de"},
+	}
+	for _, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := detectLocale(tt.configured, getenv); got != tt.want {
+			t.Errorf("detectLocale(%q, %v) = %q, want %q", tt.configured, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	for locale, messages := range catalogs {
+		for id, msg := range messages {
+			en, ok := catalogs["en"][id]
+			if !ok {
+				t.Errorf("%s: message %q missing in en", locale, id)
+				continue
+			}
+			if strings.Count(msg, "%") != strings.Count(en, "%") {
+				t.Errorf("%s: message %q has other format verbs than en: %q", locale, id, msg)
+			}
+		}
+	}
+}
+
+func TestTr(t *testing.T) {
+	defer func(l string) { uiLocale = l }(uiLocale)
+
+	uiLocale = "en"
+	if got := tr("no_results_for", "go"); got != `No results found for "go".` {
+		t.Errorf("en: got %q", got)
+	}
+	uiLocale = "de"
+	if got := tr("no_results_for", "go"); got != `Keine Ergebnisse für "go" gefunden.` {
+		t.Errorf("de: got %q", got)
+	}
+
+	// Messages missing in a locale fall back to English
+	msg := catalogs["de"]["no_results"]
+	delete(catalogs["de"], "no_results")
+	defer func() { catalogs["de"]["no_results"] = msg }()
+	if got := tr("no_results"); got != "No results found." {
+		t.Errorf("fallback: got %q", got)
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	defer func(l string) { uiLocale = l }(uiLocale)
+	date := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	uiLocale = "en"
+	if got := formatDate(date); got != "March 5, 2024" {
+		t.Errorf("en: got %q", got)
+	}
+	uiLocale = "de"
+	if got := formatDate(date); got != "5. März 2024" {
+		t.Errorf("de: got %q", got)
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+const (
+	defaultImageDir     = "images"
+	imageDownloadWorker = 4
+	maxImageBytes       = 50 << 20
+)
+
+var imageFormats = []string{"png", "jpeg", "gif", "webp"}
+
+// imageOptions configures the --download pipeline for image results.
+type imageOptions struct {
+	Dir        string // output directory
+	Resize     int    // fit within Resize x Resize pixels; 0 keeps the size
+	Format     string // target format; "" keeps the original encoding
+	Thumbnails bool   // download thumbnails instead of originals
+}
+
+// imageJob is one image to download, numbered by its result position.
+type imageJob struct {
+	index int
+	title string
+	url   string
+}
+
+// imageOutcome reports where an image was saved, or why it failed.
+type imageOutcome struct {
+	job  imageJob
+	path string
+	err  error
+}
+
+func validateImageFormat(format string) bool {
+	if format == "" || format == "jpg" {
+		return true
+	}
+	for _, f := range imageFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// downloadImages fetches the images of results concurrently into
+// opts.Dir, converting and resizing them as requested. Saved paths are
+// printed to stdout in result order; per-image failures go to stderr.
+func downloadImages(results []SearchResult, startAt int, opts imageOptions, config *Config) error {
+	if opts.Dir == "" {
+		opts.Dir = defaultImageDir
+	}
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	var jobs []imageJob
+	for i, result := range results {
+		src := result.ImgSrc
+		if opts.Thumbnails && result.ThumbnailSrc != "" {
+			src = result.ThumbnailSrc
+		}
+		if src == "" {
+			continue
+		}
+		jobs = append(jobs, imageJob{index: startAt + i + 1, title: result.Title, url: absoluteImageURL(src)})
+	}
+	if len(jobs) == 0 {
+		return fmt.Errorf("no image URLs in results (use --categories images)")
+	}
+
+	client := setupHTTPClient(config)
+	queue := make(chan imageJob)
+	outcomes := make([]imageOutcome, len(jobs))
+	positions := make(map[int]int, len(jobs))
+	for i, job := range jobs {
+		positions[job.index] = i
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < imageDownloadWorker; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				path, err := downloadImage(client, job, opts, config)
+				outcomes[positions[job.index]] = imageOutcome{job: job, path: path, err: err}
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	failed := 0
+	for _, outcome := range outcomes {
+		if outcome.err != nil {
+			failed++
+			fmt.Fprintln(os.Stderr, tr("download_url_error", outcome.job.url, outcome.err))
+			continue
+		}
+		fmt.Println(outcome.path)
+	}
+	if failed == len(outcomes) {
+		return fmt.Errorf("all %d image downloads failed", failed)
+	}
+	return nil
+}
+
+// downloadImage fetches a single image and writes it to opts.Dir.
+func downloadImage(client *http.Client, job imageJob, opts imageOptions, config *Config) (string, error) {
+	req, err := setupHTTPRequest("GET", job.url, config)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "image/avif,image/webp,image/apng,image/*,*/*;q=0.8")
+	req.Header.Del("Accept-Encoding") // let net/http negotiate and decompress
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes))
+	if err != nil {
+		return "", err
+	}
+
+	base := filepath.Join(opts.Dir, fmt.Sprintf("%03d-%s", job.index, slugify(job.title, 40)))
+
+	// Keep the original bytes when no conversion is requested
+	if opts.Resize <= 0 && opts.Format == "" {
+		out := base + imageExtension(resp.Header.Get("Content-Type"), job.url)
+		return out, os.WriteFile(out, data, 0644)
+	}
+
+	img, srcFormat, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %v", err)
+	}
+	if opts.Resize > 0 {
+		img = fitImage(img, opts.Resize)
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = srcFormat
+	}
+	return encodeImage(img, format, base)
+}
+
+// fitImage scales img down to fit within max x max pixels, preserving the
+// aspect ratio. Smaller images are returned unchanged.
+func fitImage(img image.Image, max int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= max && h <= max {
+		return img
+	}
+	if w >= h {
+		h = h * max / w
+		w = max
+	} else {
+		w = w * max / h
+		h = max
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
+
+// encodeImage writes img as base.<format> and returns the path. WebP has no
+// pure-Go encoder, so it is produced with the cwebp tool from libwebp.
+func encodeImage(img image.Image, format, base string) (string, error) {
+	switch format {
+	case "png":
+		return writeImage(base+".png", func(w io.Writer) error { return png.Encode(w, img) })
+	case "jpeg", "jpg":
+		return writeImage(base+".jpg", func(w io.Writer) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: 90})
+		})
+	case "gif":
+		return writeImage(base+".gif", func(w io.Writer) error { return gif.Encode(w, img, nil) })
+	case "webp":
+		cwebp, err := exec.LookPath("cwebp")
+		if err != nil {
+			return "", fmt.Errorf("webp output needs the cwebp tool (libwebp) on PATH")
+		}
+		tmp, err := writeImage(base+".tmp.png", func(w io.Writer) error { return png.Encode(w, img) })
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(tmp)
+		out := base + ".webp"
+		if msg, err := exec.Command(cwebp, "-quiet", "-q", "85", tmp, "-o", out).CombinedOutput(); err != nil {
+			return "", fmt.Errorf("cwebp failed: %v %s", err, strings.TrimSpace(string(msg)))
+		}
+		return out, nil
+	default:
+		return "", fmt.Errorf("unsupported image format %q", format)
+	}
+}
+
+func writeImage(path string, encode func(io.Writer) error) (string, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := encode(f); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// imageExtension picks a file extension from the Content-Type header,
+// falling back to the URL path and finally ".img".
+func imageExtension(contentType, rawURL string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "image/jpeg":
+			return ".jpg"
+		case "image/png":
+			return ".png"
+		case "image/gif":
+			return ".gif"
+		case "image/webp":
+			return ".webp"
+		case "image/svg+xml":
+			return ".svg"
+		case "image/avif":
+			return ".avif"
+		}
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		if ext := strings.ToLower(path.Ext(u.Path)); ext != "" && len(ext) <= 5 {
+			return ext
+		}
+	}
+	return ".img"
+}
+
+// absoluteImageURL fixes protocol-relative image URLs ("//host/img.jpg")
+// that SearXNG returns for some engines.
+func absoluteImageURL(src string) string {
+	if strings.HasPrefix(src, "//") {
+		return "https:" + src
+	}
+	return src
+}
+
+// slugify turns s into a lowercase, dash-separated file name component of
+// at most max bytes. It returns "untitled" when nothing usable remains.
+func slugify(s string, max int) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		} else {
+			dash = true
+		}
+		if max > 0 && b.Len() >= max {
+			break
+		}
+	}
+	slug := b.String()
+	if max > 0 && len(slug) > max {
+		slug = strings.ToValidUTF8(slug[:max], "")
+	}
+	if slug == "" {
+		return "untitled"
+	}
+	return slug
+}
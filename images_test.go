@@ -0,0 +1,59 @@
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestFitImage(t *testing.T) {
+	tests := []struct {
+		w, h, max    int
+		wantW, wantH int
+	}{
+		{1024, 512, 256, 256, 128},
+		{300, 900, 300, 100, 300},
+		{100, 50, 512, 100, 50},
+	}
+	for _, tt := range tests {
+		img := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+		got := fitImage(img, tt.max).Bounds()
+		if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+			t.Errorf("fitImage(%dx%d, %d) = %dx%d, want %dx%d",
+				tt.w, tt.h, tt.max, got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestImageExtension(t *testing.T) {
+	tests := []struct {
+		contentType, url, want string
+	}{
+		{"image/jpeg", "https://example.com/a", ".jpg"},
+		{"image/png; charset=binary", "https://example.com/a.jpg", ".png"},
+		{"application/octet-stream", "https://example.com/cat.GIF?size=l", ".gif"},
+		{"", "https://example.com/image", ".img"},
+	}
+	for _, tt := range tests {
+		if got := imageExtension(tt.contentType, tt.url); got != tt.want {
+			t.Errorf("imageExtension(%q, %q) = %q, want %q", tt.contentType, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"Golden Gate Bridge at Night!", 0, "golden-gate-bridge-at-night"},
+		{"  --Hello,   World--  ", 0, "hello-world"},
+		{"a very long title indeed", 6, "a-very"},
+		{"???", 0, "untitled"},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.in, tt.max); got != tt.want {
+			t.Errorf("slugify(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
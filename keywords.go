@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+)
+
+// maxKeywords is how many terms --keywords lists.
+const maxKeywords = 10
+
+// keywordFiller are frequent words that say nothing about a topic, on top
+// of the stopwords language detection uses.
+var keywordFiller = map[string]bool{
+	"about": true, "all": true, "also": true, "any": true, "been": true, "but": true,
+	"does": true, "each": true, "get": true, "had": true, "has": true, "here": true,
+	"into": true, "its": true, "just": true, "may": true, "more": true, "most": true,
+	"many": true, "new": true, "one": true, "only": true, "other": true, "our": true,
+	"out": true, "over": true, "such": true, "than": true, "their": true, "them": true,
+	"then": true, "there": true, "these": true, "they": true, "use": true, "very": true,
+	"was": true, "were": true, "what": true, "when": true, "where": true, "who": true,
+	"why": true, "will": true, "your": true, "best": true, "how": true, "can": true,
+	"read": true, "www": true, "com": true, "http": true, "https": true,
+	"aus": true, "über": true, "nach": true, "noch": true, "nur": true, "sie": true,
+}
+
+// keywordTokens splits text into lowercase words and reports for each
+// whether it can be a keyword: not a stopword, filler, number or query
+// term, and at least three letters long.
+func keywordTokens(text string, query map[string]bool) (words []string, usable []bool) {
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '+' && r != '#'
+	}) {
+		word = strings.Trim(word, "-")
+		if word == "" {
+			continue
+		}
+		ok := len([]rune(word)) >= 3 && !keywordFiller[word] && !query[word] &&
+			strings.IndexFunc(word, unicode.IsLetter) >= 0
+		for _, set := range stopwordSets {
+			if set[word] {
+				ok = false
+				break
+			}
+		}
+		words = append(words, word)
+		usable = append(usable, ok)
+	}
+	return words, usable
+}
+
+// extractKeywords returns the terms that best characterize the titles and
+// snippets of results, for refining query. Words and two-word phrases are
+// scored TF-IDF style, each result being a document: terms count by how
+// often they occur, weighted up when few results use them, and only terms
+// that occur in two or more results qualify, so one page's jargon doesn't
+// crowd out what the results share. Words that only occur inside a listed
+// phrase are left out.
+func extractKeywords(results []SearchResult, query string, n int) []string {
+	queryTerms := map[string]bool{}
+	words, _ := keywordTokens(query, nil)
+	for _, word := range words {
+		queryTerms[word] = true
+	}
+
+	tf := map[string]int{}
+	df := map[string]int{}
+	for _, result := range results {
+		words, usable := keywordTokens(result.Title+" "+result.Content, queryTerms)
+		seen := map[string]bool{}
+		add := func(term string) {
+			tf[term]++
+			if !seen[term] {
+				seen[term] = true
+				df[term]++
+			}
+		}
+		for i, word := range words {
+			if !usable[i] {
+				continue
+			}
+			add(word)
+			if i+1 < len(words) && usable[i+1] && words[i+1] != word {
+				add(word + " " + words[i+1])
+			}
+		}
+	}
+
+	minDF := 2
+	if len(results) < 2 {
+		minDF = 1
+	}
+	type scored struct {
+		term  string
+		score float64
+	}
+	var terms []scored
+	for term, count := range tf {
+		if df[term] < minDF {
+			continue
+		}
+		idf := 1 + math.Log(float64(len(results))/float64(df[term]))
+		weight := float64(len(strings.Fields(term)))
+		terms = append(terms, scored{term, float64(count) * idf * weight})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].score != terms[j].score {
+			return terms[i].score > terms[j].score
+		}
+		return terms[i].term < terms[j].term
+	})
+
+	var keywords []string
+	inPhrase := map[string]int{} // df of the phrases listed so far, by word
+	for _, t := range terms {
+		if len(keywords) == n {
+			break
+		}
+		if first, second, isPhrase := strings.Cut(t.term, " "); isPhrase {
+			inPhrase[first] = max(inPhrase[first], df[t.term])
+			inPhrase[second] = max(inPhrase[second], df[t.term])
+		} else if inPhrase[t.term] >= df[t.term] {
+			continue
+		}
+		keywords = append(keywords, t.term)
+	}
+	return keywords
+}
+
+// printKeywords writes the --keywords line below the results.
+func printKeywords(w io.Writer, keywords []string, noColor bool) {
+	if len(keywords) == 0 {
+		return
+	}
+	if noColor {
+		color.NoColor = true
+	}
+	fmt.Fprintln(w, color.New(color.FgHiBlack).Sprint(tr("keywords", strings.Join(keywords, ", "))))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestExtractKeywords(t *testing.T) {
+	results := []SearchResult{
+		{Title: "Tokio tutorial", Content: "Tokio is an asynchronous runtime for Rust. The event loop drives futures."},
+		{Title: "Async Rust with Tokio", Content: "Learn how the event loop and the work stealing scheduler run futures."},
+		{Title: "async-std", Content: "An alternative runtime; its event loop spawns futures."},
+		{Title: "Unrelated", Content: "Sourdough bread needs a starter and patience."},
+	}
+	got := extractKeywords(results, "rust async runtime", 4)
+	want := []string{"event loop", "tokio", "futures"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("extractKeywords = %q, want %q", got, want)
+	}
+}
+
+func TestExtractKeywordsSingleResult(t *testing.T) {
+	got := extractKeywords([]SearchResult{{Title: "Golang generics", Content: "Type parameters in Go 1.18"}}, "go", 2)
+	if len(got) != 2 {
+		t.Errorf("extractKeywords of one result = %q", got)
+	}
+}
+
+func TestPrintKeywords(t *testing.T) {
+	saved := uiLocale
+	defer func() { uiLocale = saved }()
+	uiLocale = "en"
+
+	var buf bytes.Buffer
+	printKeywords(&buf, []string{"tokio", "event loop"}, true)
+	if buf.String() != "Keywords: tokio, event loop\n" {
+		t.Errorf("printKeywords wrote %q", buf.String())
+	}
+	buf.Reset()
+	printKeywords(&buf, nil, true)
+	if buf.Len() != 0 {
+		t.Errorf("printKeywords without keywords wrote %q", buf.String())
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// stopwords are frequent short words of the languages detectLanguage tells
+// apart within the Latin script.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "for", "with", "are", "this", "on", "it", "you", "was", "be", "as", "by", "from", "have", "not", "or", "which", "can", "how"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "ein", "eine", "den", "von", "zu", "sich", "auf", "für", "im", "dem", "auch", "es", "wie", "oder", "bei", "sind", "wird", "werden"},
+	"fr": {"le", "la", "les", "et", "de", "des", "est", "une", "du", "que", "dans", "pour", "qui", "pas", "sur", "au", "avec", "il", "sont", "ce", "par", "plus", "aux", "vous", "nous", "mais"},
+	"es": {"el", "la", "los", "las", "y", "de", "que", "es", "en", "por", "una", "del", "con", "para", "se", "al", "lo", "como", "más", "pero", "sus", "su", "son", "está", "este", "también"},
+	"it": {"il", "di", "che", "è", "e", "la", "per", "un", "una", "sono", "della", "con", "non", "del", "nel", "gli", "alla", "anche", "come", "più", "questo", "dei", "delle", "ha", "lo"},
+	"nl": {"de", "het", "een", "en", "van", "is", "niet", "op", "dat", "met", "voor", "zijn", "ook", "aan", "bij", "wordt", "worden", "naar", "hij", "maar", "deze", "kan", "om", "uit", "dit"},
+	"pt": {"o", "os", "as", "de", "que", "do", "da", "não", "em", "um", "uma", "para", "com", "dos", "das", "no", "na", "se", "por", "mais", "ao", "como", "é", "são", "foi", "também"},
+	"sv": {"och", "att", "det", "som", "är", "en", "på", "för", "med", "av", "inte", "den", "till", "har", "om", "ett", "var", "jag", "de", "men", "kan", "vi", "sig", "så", "eller"},
+	"pl": {"i", "w", "na", "nie", "się", "jest", "z", "do", "to", "że", "co", "jak", "od", "po", "przez", "dla", "są", "jego", "oraz", "tak", "ale", "czy", "być", "może", "tym"},
+}
+
+var stopwordSets = func() map[string]map[string]bool {
+	sets := map[string]map[string]bool{}
+	for lang, words := range stopwords {
+		sets[lang] = map[string]bool{}
+		for _, word := range words {
+			sets[lang][word] = true
+		}
+	}
+	return sets
+}()
+
+// scriptLanguages maps scripts used mostly by one language to it. Han
+// text is Chinese unless it contains kana (Japanese).
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// detectLanguage guesses the language of text as an ISO 639-1 code, by
+// its script or, for Latin text, by which language's stopwords it uses
+// most. It returns "" when the text is too short or too mixed to tell.
+func detectLanguage(text string) string {
+	scripts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.table, r) {
+				scripts[s.lang]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	if scripts["ja"] > 0 && scripts["ja"]+scripts["zh"] > letters/2 {
+		return "ja"
+	}
+	for _, s := range scriptLanguages {
+		if scripts[s.lang] > letters/2 {
+			return s.lang
+		}
+	}
+
+	counts := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		for lang, set := range stopwordSets {
+			if set[word] {
+				counts[lang]++
+			}
+		}
+	}
+	best, second := "", 0
+	for _, lang := range slices.Sorted(maps.Keys(counts)) {
+		switch n := counts[lang]; {
+		case best == "" || n > counts[best]:
+			best, second = lang, counts[best]
+		case n > second:
+			second = n
+		}
+	}
+	// Two stopwords, and clearly more than any other language has
+	if best == "" || counts[best] < 2 || counts[best] < second*3/2 {
+		return ""
+	}
+	return best
+}
+
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// parseResultLanguages normalizes --result-lang values to primary language
+// subtags ("de-AT" is "de").
+func parseResultLanguages(values []string) ([]string, error) {
+	var langs []string
+	for _, value := range values {
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(value)), "-")
+		if !languageCode.MatchString(lang) {
+			return nil, fmt.Errorf("invalid --result-lang %q (use a language code like de or en)", value)
+		}
+		langs = append(langs, lang)
+	}
+	return langs, nil
+}
+
+// filterResultLanguages keeps the results written in one of langs. Their
+// language is detected from the title and snippet, or from the page when
+// those are too short to tell. Results whose language stays unknown are
+// kept.
+func filterResultLanguages(results []SearchResult, langs []string, config *Config) []SearchResult {
+	if len(langs) == 0 {
+		return results
+	}
+	var unknown []SearchResult
+	var unknownAt []int
+	for i := range results {
+		if results[i].Language == "" {
+			results[i].Language = detectLanguage(results[i].Title + " " + results[i].Content)
+		}
+		if results[i].Language == "" {
+			unknown = append(unknown, results[i])
+			unknownAt = append(unknownAt, i)
+		}
+	}
+	if len(unknown) > 0 {
+		enrichLanguages(unknown, config)
+		for j, i := range unknownAt {
+			results[i].Language = unknown[j].Language
+		}
+	}
+
+	filtered := results[:0:0]
+	for _, result := range results {
+		if result.Language == "" || slices.Contains(langs, result.Language) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]string{
+		"The quick brown fox jumps over the lazy dog and runs into the woods": "en",
+		"Die Katze sitzt auf dem Dach und das ist nicht gut für sie":          "de",
+		"Le chat est sur le toit et il ne veut pas descendre pour le dîner":   "fr",
+		"El gato está en el tejado y no quiere bajar para la cena":            "es",
+		"De kat zit op het dak en wil niet naar beneden komen voor het eten":  "nl",
+		"La historia de la ciudad de México es una de las más antiguas":       "es",
+		"Los precios de la vivienda suben en Madrid y Barcelona":              "es",
+		"A cidade de Lisboa é a capital de Portugal e tem muitos museus":      "pt",
+		"O governo anunciou novas medidas de apoio às famílias":               "pt",
+		"Het museum de Lakenhal is open van dinsdag tot en met zondag":        "nl",
+		"東京は日本の首都です。たくさんの人が住んでいます。":                                           "ja",
+		"北京是中国的首都":                                                            "zh",
+		"Москва является столицей России":                                     "ru",
+		"Rust async runtime": "",
+		"":                   "",
+	}
+	for text, want := range tests {
+		if got := detectLanguage(text); got != want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestParseResultLanguages(t *testing.T) {
+	langs, err := parseResultLanguages([]string{"DE", "pt-BR", " en "})
+	if err != nil || !reflect.DeepEqual(langs, []string{"de", "pt", "en"}) {
+		t.Errorf("parseResultLanguages = %q, %v", langs, err)
+	}
+	for _, value := range []string{"", "german", "d3"} {
+		if _, err := parseResultLanguages([]string{value}); err == nil {
+			t.Errorf("parseResultLanguages(%q) succeeded", value)
+		}
+	}
+}
+
+func TestFilterResultLanguages(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+	pageInfos.pages = map[string]*pageFetch{}
+
+	var fetched []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched = append(fetched, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/de":
+			w.Write([]byte(`<body>Die Katze sitzt auf dem Dach und das ist nicht gut.</body>`))
+		case "/fr":
+			w.Write([]byte(`<body>Le chat est sur le toit et il ne veut pas descendre.</body>`))
+		default:
+			w.Write([]byte(`<body>404</body>`))
+		}
+	}))
+	defer server.Close()
+
+	results := []SearchResult{
+		{Title: "Die Katze", Content: "Die Katze sitzt auf dem Dach und das ist nicht gut", URL: server.URL + "/snippet-de"},
+		{Title: "The cat", Content: "The cat is on the roof and it does not want to come down", URL: server.URL + "/snippet-en"},
+		{Title: "Katze", URL: server.URL + "/de"},
+		{Title: "Chat", URL: server.URL + "/fr"},
+		{Title: "Mystery", URL: server.URL + "/unknown"},
+	}
+	got := filterResultLanguages(results, []string{"de"}, config)
+	if want := []string{"Die Katze", "Katze", "Mystery"}; !reflect.DeepEqual(titles(got), want) {
+		t.Errorf("filterResultLanguages kept %q, want %q", titles(got), want)
+	}
+	sort.Strings(fetched)
+	if want := []string{"/de", "/fr", "/unknown"}; !reflect.DeepEqual(fetched, want) {
+		t.Errorf("fetched %q, want only the pages of undecided snippets %q", fetched, want)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+
+	"sx/backends"
+)
+
+// maxLinkChecks limits how many result URLs --check-links probes at once.
+const maxLinkChecks = 8
+
+// checkLinks probes the URL of each result not checked yet and records
+// whether it is alive, redirects elsewhere, or is dead.
+func checkLinks(results []SearchResult, config *Config) {
+	client := setupHTTPClient(config)
+	sem := make(chan struct{}, maxLinkChecks)
+	var wg sync.WaitGroup
+	for i := range results {
+		if results[i].URL == "" || results[i].Link != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(result *SearchResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			result.Link = checkLink(client, result.URL, config)
+		}(&results[i])
+	}
+	wg.Wait()
+}
+
+// checkLink requests rawURL with HEAD, retrying with GET for servers that
+// reject HEAD or drop the connection on it, and classifies the outcome.
+func checkLink(client *http.Client, rawURL string, config *Config) *backends.LinkCheck {
+	resp, err := probeLink(client, "HEAD", rawURL, config)
+	if err != nil || resp.StatusCode >= 400 {
+		if get, getErr := probeLink(client, "GET", rawURL, config); getErr == nil {
+			resp, err = get, nil
+		}
+	}
+	if err != nil {
+		return &backends.LinkCheck{Status: "dead", Error: linkError(err)}
+	}
+
+	check := &backends.LinkCheck{Status: "alive", Code: resp.StatusCode}
+	if resp.StatusCode >= 400 {
+		check.Status = "dead"
+	} else if final := resp.Request.URL.String(); !sameURL(final, rawURL) {
+		check.Status = "redirect"
+		check.Target = final
+	}
+	return check
+}
+
+func probeLink(client *http.Client, method, rawURL string, config *Config) (*http.Response, error) {
+	req, err := setupHTTPRequest(method, rawURL, config)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// Only the status matters; don't download bodies
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	return resp, nil
+}
+
+// sameURL ignores differences that aren't a real redirect, such as an
+// added trailing slash or a switch from http to https.
+func sameURL(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return strings.EqualFold(ua.Host, ub.Host) &&
+		strings.TrimSuffix(ua.Path, "/") == strings.TrimSuffix(ub.Path, "/") &&
+		ua.RawQuery == ub.RawQuery
+}
+
+// linkError shortens network errors to their cause.
+func linkError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return "no such host"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// formatLinkCheck renders a check for the result listing.
+func formatLinkCheck(check *backends.LinkCheck) string {
+	switch check.Status {
+	case "alive":
+		return color.GreenString("✓ alive (%d)", check.Code)
+	case "redirect":
+		return color.YellowString("→ redirects to %s", check.Target)
+	}
+	if check.Code != 0 {
+		return color.RedString("✗ dead (%d)", check.Code)
+	}
+	return color.RedString("✗ dead (%s)", check.Error)
+}
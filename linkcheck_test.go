@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckLinks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/drops-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	results := []SearchResult{
+		{URL: srv.URL + "/ok"},
+		{URL: srv.URL + "/gone"},
+		{URL: srv.URL + "/moved"},
+		{URL: srv.URL + "/no-head"},
+		{URL: "http://127.0.0.1:1/"},
+		{URL: srv.URL + "/drops-head"},
+		{Title: "no url"},
+	}
+	checkLinks(results, &Config{Timeout: 5})
+
+	want := []struct {
+		status string
+		code   int
+	}{
+		{"alive", 200}, {"dead", 410}, {"redirect", 200}, {"alive", 200}, {"dead", 0}, {"alive", 200},
+	}
+	for i, w := range want {
+		link := results[i].Link
+		if link == nil {
+			t.Fatalf("result %d was not checked", i)
+		}
+		if link.Status != w.status || link.Code != w.code {
+			t.Errorf("result %d: got %s (%d), want %s (%d)", i, link.Status, link.Code, w.status, w.code)
+		}
+	}
+	if results[2].Link.Target != srv.URL+"/ok" {
+		t.Errorf("redirect target = %q", results[2].Link.Target)
+	}
+	if results[4].Link.Error == "" {
+		t.Error("unreachable link has no error")
+	}
+	if results[6].Link != nil {
+		t.Error("result without URL was checked")
+	}
+}
+
+func TestSameURL(t *testing.T) {
+	if !sameURL("http://example.com/a", "https://EXAMPLE.com/a/") {
+		t.Error("scheme and trailing slash changes should not count as redirects")
+	}
+	if sameURL("https://example.com/a", "https://example.com/b") {
+		t.Error("different paths should count as redirects")
+	}
+}
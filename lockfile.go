@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// lockWait is how long lockFile waits for another sx to release a lock.
+var lockWait = time.Minute
+
+// lockFile takes the lock on path, a file next to it named path+".lock"
+// holding the process ID, so that sx processes changing the same file (say
+// a timer's `sx saved run` and `sx saved add`) take turns. A lock left by a
+// process that is no longer running is taken over. It returns the function
+// that releases the lock.
+func lockFile(path string) (func(), error) {
+	lock := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lock), 0755); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lock)
+				return nil, err
+			}
+			return func() { os.Remove(lock) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if lockAbandoned(lock) {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is in use by another sx (remove %s if none is running)", path, lock)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// lockAbandoned reports whether the process that took lock has exited.
+// A lock whose process can't be told is assumed to be held.
+func lockAbandoned(lock string) bool {
+	data, err := os.ReadFile(lock)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		// Windows can't open a process that has exited
+		return runtime.GOOS == "windows"
+	}
+	if runtime.GOOS == "windows" {
+		p.Release()
+		return false
+	}
+	return errors.Is(p.Signal(syscall.Signal(0)), os.ErrProcessDone)
+}
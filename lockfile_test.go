@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	defer func(wait time.Duration) { lockWait = wait }(lockWait)
+	lockWait = 100 * time.Millisecond
+	path := filepath.Join(t.TempDir(), "state", "saved.json")
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lockFile(path); err == nil {
+		t.Fatal("second lock taken while the first is held")
+	}
+	unlock()
+	unlock, err = lockFile(path)
+	if err != nil {
+		t.Fatalf("released lock: %v", err)
+	}
+	unlock()
+
+	// A lock left by a process that has exited is taken over
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".lock", []byte(strconv.Itoa(cmd.Process.Pid)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err = lockFile(path)
+	if err != nil {
+		t.Fatalf("abandoned lock: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock left behind: %v", err)
+	}
+}
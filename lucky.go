@@ -0,0 +1,48 @@
+package main
+
+import "math/rand"
+
+// chosenResult is the result --first opens, the first one, or else the
+// one --lucky picks.
+func chosenResult(results []SearchResult, opts *SearchOptions, rng *rand.Rand) SearchResult {
+	if opts.First {
+		return results[0]
+	}
+	return pickLucky(results, opts.Weighted, rng)
+}
+
+// pickLucky chooses a result for --lucky. Without weighted every result is
+// equally likely. With weighted better results are likelier: each counts
+// with its score when all results have one, otherwise with 1/rank.
+func pickLucky(results []SearchResult, weighted bool, rng *rand.Rand) SearchResult {
+	if !weighted {
+		return results[rng.Intn(len(results))]
+	}
+
+	weights := make([]float64, len(results))
+	useScores := true
+	for _, result := range results {
+		if result.Score <= 0 {
+			useScores = false
+			break
+		}
+	}
+	total := 0.0
+	for i, result := range results {
+		if useScores {
+			weights[i] = result.Score
+		} else {
+			weights[i] = 1 / float64(i+1)
+		}
+		total += weights[i]
+	}
+
+	r := rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return results[i]
+		}
+		r -= w
+	}
+	return results[len(results)-1]
+}
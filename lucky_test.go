@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPickLuckySeeded(t *testing.T) {
+	results := []SearchResult{{URL: "a"}, {URL: "b"}, {URL: "c"}, {URL: "d"}}
+	for _, weighted := range []bool{false, true} {
+		first := pickLucky(results, weighted, rand.New(rand.NewSource(42)))
+		again := pickLucky(results, weighted, rand.New(rand.NewSource(42)))
+		if first.URL != again.URL {
+			t.Errorf("weighted=%v: same seed picked %q and %q", weighted, first.URL, again.URL)
+		}
+	}
+}
+
+func TestPickLuckyWeighted(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	counts := map[string]int{}
+
+	byScore := []SearchResult{{URL: "low", Score: 1}, {URL: "high", Score: 9}}
+	for i := 0; i < 1000; i++ {
+		counts[pickLucky(byScore, true, rng).URL]++
+	}
+	if counts["high"] < 800 {
+		t.Errorf("score weighting: %v", counts)
+	}
+
+	clear(counts)
+	byRank := []SearchResult{{URL: "first"}, {URL: "second"}, {URL: "third"}}
+	for i := 0; i < 1000; i++ {
+		counts[pickLucky(byRank, true, rng).URL]++
+	}
+	if counts["first"] <= counts["second"] || counts["second"] <= counts["third"] {
+		t.Errorf("rank weighting: %v", counts)
+	}
+}
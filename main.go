@@ -4,11 +4,11 @@ import (
 	"bufio"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"os"
-	"os/exec"
-	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -38,9 +38,11 @@ func main() {
 	var err error
 	config, err = loadConfig()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		uiLocale = detectLocale("", os.Getenv)
+		fmt.Fprintln(os.Stderr, tr("config_error", err))
 		os.Exit(1)
 	}
+	applyLocale(config)
 
 	var rootCmd = &cobra.Command{
 		Use:                   "sx [query...]",
@@ -57,30 +59,91 @@ func main() {
 	rootCmd.Flags().StringSliceVar(&config.SearxngURLs, "searxng-urls", config.SearxngURLs, "Additional SearXNG instance URLs for failover")
 	rootCmd.Flags().StringVar(&config.SearxngStrategy, "searxng-strategy", config.SearxngStrategy, "SearXNG instance strategy (ordered, parallel-fastest)")
 	rootCmd.Flags().StringSliceVar(&searchOpts.Categories, "categories", nil, fmt.Sprintf("list of categories to search in: %s", strings.Join(searxngCategories, ", ")))
+	rootCmd.Flags().BoolVar(&searchOpts.AllCategories, "all-categories", false, "search every category (or those of --categories) at once and group the results by category")
 	rootCmd.Flags().BoolVar(&searchOpts.JSON, "json", false, "output search results in JSON format")
+	rootCmd.Flags().BoolVar(&searchOpts.CheckLinks, "check-links", false, "check whether result URLs are alive, redirect or dead")
+	rootCmd.Flags().StringVar(&searchOpts.OpenAccess, "open-access", "", "look up free copies of papers on Unpaywall: annotate (the default) or replace paywalled links")
+	rootCmd.Flags().Lookup("open-access").NoOptDefVal = "annotate"
+	rootCmd.Flags().BoolVar(&searchOpts.Rerank, "rerank", false, "order results by semantic relevance with the rerank or embeddings API from the config")
+	rootCmd.Flags().BoolVar(&searchOpts.Cluster, "cluster", false, "group similar results and list one of each group first")
+	rootCmd.Flags().BoolVar(&searchOpts.Keywords, "keywords", false, "list the terms the results share, to refine the query")
+	rootCmd.Flags().StringSliceVar(&searchOpts.Enrich, "enrich", nil, fmt.Sprintf("add data to results: %s (repeatable or comma-separated)", strings.Join(enrichKinds, ", ")))
+	rootCmd.Flags().BoolVar(&searchOpts.ArchiveCopies, "archive-paywalled", false, "mark paywalled results and link their latest archive.org copy (--enrich paywall); --text reads the copy instead")
+	rootCmd.Flags().BoolVar(&searchOpts.SiteInfo, "site-info", false, "show the hosting country, IP address and TLS certificate issuer of each result's site; sends the sites' addresses to ipwho.is, through the configured proxy (same as --enrich site)")
+	rootCmd.Flags().BoolVar(&searchOpts.Porcelain, "porcelain", false, "stable machine-readable output: one tab-separated line per result (rank, url, title, engine, snippet)")
 	rootCmd.Flags().BoolVarP(&searchOpts.Clean, "clean", "c", false, "omit empty and null values in JSON output")
-	rootCmd.Flags().StringSliceVarP(&searchOpts.SearxngEngines, "engines", "e", nil, "list of SearXNG engines to use for search")
+	rootCmd.Flags().StringSliceVarP(&searchOpts.SearxngEngines, "engines", "e", nil, "list of SearXNG engines to use for search; bookmarks, notes, elastic and meilisearch add your imported bookmarks, notes directory and search indexes, github, stackexchange, cve and apps sx's GitHub, Stack Exchange, security advisory and app store search")
 	rootCmd.Flags().StringVar(&searchOpts.ExplicitEngine, "engine", "", fmt.Sprintf("search backend to use (%s)", validEngineNames()))
 	rootCmd.Flags().BoolVarP(&searchOpts.Expand, "expand", "x", config.Expand, "show complete URL in search results (URLs are shown by default)")
+	rootCmd.Flags().BoolVar(&searchOpts.RawURLs, "raw-urls", false, "keep result URLs as returned (no tracking-parameter stripping or redirect unwrapping)")
+	rootCmd.Flags().BoolVar(&config.A11y, "a11y", config.A11y, "screen-reader friendly output: linear, labelled lines without colors or symbols")
+	rootCmd.Flags().BoolVar(&config.ShortDomains, "short-domains", config.ShortDomains, "label results with their registrable domain (python.org instead of docs.python.org)")
 	rootCmd.Flags().BoolVarP(&searchOpts.First, "first", "j", false, "open the first result in web browser and exit")
 	rootCmd.Flags().StringVar(&config.HTTPMethod, "http-method", config.HTTPMethod, "HTTP method to use for search requests (GET or POST)")
 	rootCmd.Flags().Float64Var(&config.Timeout, "timeout", config.Timeout, "HTTP request timeout in seconds")
+	rootCmd.Flags().Float64Var(&config.FetchTimeout, "fetch-timeout", config.FetchTimeout, "timeout in seconds for each page fetched by --html/--text (default: --timeout)")
 	rootCmd.Flags().StringVarP(&searchOpts.Language, "language", "l", "", "search results in a specific language")
+	rootCmd.Flags().StringSliceVar(&searchOpts.ResultLang, "result-lang", nil, "keep only results detected as written in these languages, e.g. de (repeatable or comma-separated)")
+	rootCmd.Flags().StringVar(&searchOpts.Country, "country", "", "search results for a country, as a two-letter code (Brave, Google, SerpAPI, Mojeek, Jina)")
+	rootCmd.Flags().BoolVar(&searchOpts.Play, "play", false, "play the first result in the configured media player and exit")
+	rootCmd.Flags().BoolVar(&searchOpts.OpenMap, "open-map", false, "open the first map result in the configured map provider and exit")
+	rootCmd.Flags().StringVar(&searchOpts.Near, "near", "", "sort map results by distance from lat,lon")
 	rootCmd.Flags().BoolVar(&searchOpts.Lucky, "lucky", false, "opens a random result in web browser and exit")
+	rootCmd.Flags().BoolVar(&searchOpts.Weighted, "weighted", false, "with --lucky, favor results by score (or rank when results have no score)")
+	rootCmd.Flags().Int64Var(&searchOpts.Seed, "seed", 0, "with --lucky, seed the random pick so it is reproducible")
+	rootCmd.Flags().BoolVar(&searchOpts.PrintURL, "print-url", false, "with --first, --lucky, --open-map or an interactive index, print the URL instead of opening it")
 	rootCmd.Flags().BoolVar(&config.NoVerifySSL, "no-verify-ssl", config.NoVerifySSL, "do not verify SSL certificates")
 	rootCmd.Flags().BoolVar(&config.NoColor, "nocolor", config.NoColor, "disable colored output")
 	rootCmd.Flags().BoolVar(&config.NoUserAgent, "noua", config.NoUserAgent, "disable user agent")
 	rootCmd.Flags().IntVarP(&config.ResultCount, "num", "n", config.ResultCount, "show N results per page")
+	rootCmd.Flags().IntVar(&config.SnippetWords, "snippet", config.SnippetWords, "cut result snippets to N words (0 for no limit)")
+	rootCmd.Flags().BoolVar(&searchOpts.FullContent, "full-content", false, "show result snippets in full (same as --snippet 0)")
 	rootCmd.Flags().StringVar(&searchOpts.SafeSearch, "safe-search", config.SafeSearch, "filter results for safe search (none, moderate, strict)")
-	rootCmd.Flags().StringVarP(&searchOpts.Site, "site", "w", "", "search sites using site: operator")
+	rootCmd.Flags().StringVar(&searchOpts.Profile, "profile", "", "use a restriction profile from the config (default: profile setting)")
+	rootCmd.Flags().StringSliceVarP(&searchOpts.Sites, "site", "w", nil, "search sites using site: operator (repeatable or comma-separated)")
+	rootCmd.Flags().StringSliceVar(&searchOpts.ExcludeSites, "exclude-site", nil, "exclude sites using -site: operator (repeatable or comma-separated)")
 	rootCmd.Flags().StringVarP(&searchOpts.TimeRange, "time-range", "r", "", "search results within a specific time range (day, week, month, year)")
 	rootCmd.Flags().BoolVar(&searchOpts.Unsafe, "unsafe", false, "allow unsafe search results")
-	rootCmd.Flags().BoolVar(&config.Debug, "debug", config.Debug, "show debug output")
+	rootCmd.Flags().BoolVar(&config.Debug, "debug", config.Debug, "show debug output (requests are logged with secrets redacted)")
+	rootCmd.Flags().BoolVar(&searchOpts.DryRun, "dry-run", false, "print the request the selected backend would send, without sending it")
+	rootCmd.Flags().StringVar(&config.DebugDump, "debug-dump", config.DebugDump, "write sanitized request/response pairs to this directory (implies --debug)")
 	rootCmd.Flags().BoolVarP(&searchOpts.HTMLOnly, "html", "H", false, "fetch and output raw HTML with anti-bot detection")
 	rootCmd.Flags().BoolVarP(&searchOpts.LinksOnly, "links-only", "L", false, "output only URLs, one per line")
+	rootCmd.Flags().BoolVar(&searchOpts.MagnetOnly, "magnet-only", false, "output only torrent magnet links, one per line")
+	rootCmd.Flags().BoolVar(&searchOpts.Subscribe, "subscribe", false, "output the podcasts of the results as an OPML subscription list (searches podcasts unless -e or --engine is given)")
+	rootCmd.Flags().StringVar(&searchOpts.GroupBy, "group-by", "", fmt.Sprintf("show results under a header per %s, largest groups first", strings.Join(groupKeys, ", ")))
+	rootCmd.Flags().StringVar(&searchOpts.Sort, "sort", "", fmt.Sprintf("sort results by %s", strings.Join(sortKeys, ", ")))
 	rootCmd.Flags().BoolVarP(&searchOpts.TextOnly, "text", "T", false, "fetch pages and convert to clean markdown (uses readability)")
-	rootCmd.Flags().StringVarP(&searchOpts.OutputFile, "output", "o", "", "save output to file")
+	rootCmd.Flags().StringVar(&searchOpts.ExtractSchema, "extract-schema", "", "extract data matching this JSON schema file from each result's page with the [llm] model, as JSON lines")
+	rootCmd.Flags().StringVar(&searchOpts.AIPolicy, "ai-policy", "", "with --text, check robots.txt, ai.txt and noai tags for AI use restrictions: report or exclude restricted pages")
+	rootCmd.Flags().IntVar(&searchOpts.MaxTokens, "max-tokens", 0, "with --text, trim the pages to fit about N LLM tokens in total, favoring higher-ranked results")
+	rootCmd.Flags().StringVar(&searchOpts.Pipe, "pipe", "", "stream the output into a command's stdin, e.g. --pipe 'jq .'; sx exits with its status")
+	rootCmd.Flags().StringArrayVar(&searchOpts.Sinks, "sink", nil, "deliver results to stdout, file:PATH, webhook:URL, email or command:CMD (repeatable)")
+	rootCmd.Flags().StringVarP(&searchOpts.OutputFile, "output", "o", "", "save output to file; the path may contain {{query}}, {{query|slug}}, {{date}}, {{engine}} and similar placeholders")
+	rootCmd.Flags().BoolVar(&fileOutput.Append, "append", false, "with -o, append to the file instead of replacing it")
+	rootCmd.Flags().BoolVar(&fileOutput.IfResults, "output-if-results", false, "with -o, don't create the file when there is nothing to write")
+	rootCmd.Flags().StringVar(&fileOutput.Rotate, "rotate", "", "with -o, rotate the file daily or at a size such as 10MB before appending (implies --append)")
 	rootCmd.Flags().BoolVar(&searchOpts.Top, "top", false, "show only the top result")
+	rootCmd.Flags().BoolVar(&searchOpts.DiffLast, "diff-last", false, "show how the results changed since the last search for the same query")
+	rootCmd.Flags().BoolVar(&searchOpts.Download, "download", false, "download image results into the --output directory (default \"images\")")
+	rootCmd.Flags().IntVar(&searchOpts.Resize, "resize", 0, "with --download, shrink images to fit within N x N pixels")
+	rootCmd.Flags().StringVar(&searchOpts.ImageFormat, "format", "", fmt.Sprintf("with --download, convert images to this format (%s)", strings.Join(imageFormats, ", ")))
+	rootCmd.Flags().BoolVar(&searchOpts.Thumbnails, "thumbnails", false, "with --download, fetch thumbnails instead of original images")
+	rootCmd.Flags().IntVar(&searchOpts.Skip, "skip", 0, "skip the first N results")
+	rootCmd.Flags().BoolVar(&searchOpts.All, "all", false, "keep paginating until no new results or --max is reached")
+	rootCmd.Flags().IntVar(&searchOpts.Max, "max", defaultMaxResults, "maximum number of results to collect with --all")
+	rootCmd.Flags().IntVar(&searchOpts.Page, "page", 0, "start at page N (pages are --num results long)")
+	rootCmd.Flags().BoolVar(&searchOpts.AnswerOnly, "answer-only", false, "print only the instant answer (calculation, unit or currency conversion)")
+	rootCmd.Flags().BoolVar(&searchOpts.Autocorrect, "autocorrect", false, "automatically search for the suggested spelling correction")
+	rootCmd.Flags().StringSliceVar(&searchOpts.AllOf, "all-of", nil, "require all of these terms (repeatable or comma-separated)")
+	rootCmd.Flags().StringSliceVar(&searchOpts.AnyOf, "any-of", nil, "require at least one of these terms (repeatable or comma-separated)")
+	rootCmd.Flags().StringSliceVar(&searchOpts.NoneOf, "none-of", nil, "exclude results with any of these terms (repeatable or comma-separated)")
+	rootCmd.Flags().StringArrayVar(&searchOpts.Exact, "exact", nil, "require an exact phrase (repeatable)")
+	rootCmd.Flags().StringVar(&searchOpts.QueryFile, "query-file", "", "read the query from a file")
+	rootCmd.Flags().StringVar(&searchOpts.ExpandQuery, "expand-query", "", "expand query terms with the synonym groups in this TOML file (golang -> golang OR \"go language\")")
+	rootCmd.Flags().BoolVar(&searchOpts.Relax, "relax", false, "when nothing is found, retry without the time range, then the site filter, then the categories")
+	rootCmd.Flags().StringArrayVar(&searchOpts.Queries, "query", nil, "search another query in the same run (repeatable; same as separating queries with :::)")
+	rootCmd.Flags().StringVar(&searchOpts.StdinMode, "stdin-mode", stdinModeQuery, fmt.Sprintf("how to use piped input (%s)", strings.Join(stdinModes, ", ")))
 
 	// Interactive mode (non-interactive is now the default)
 	rootCmd.Flags().BoolVarP(&searchOpts.Interactive, "interactive", "i", false, "enter interactive mode after displaying results")
@@ -104,7 +167,7 @@ func main() {
 		Run: func(cmd *cobra.Command, args []string) {
 			limit, _ := cmd.Flags().GetInt("limit")
 			if err := printHistory(limit); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				fmt.Fprintln(os.Stderr, tr("error", err))
 				os.Exit(1)
 			}
 		},
@@ -116,13 +179,335 @@ func main() {
 		Short: "Clear search history",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := clearHistory(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				fmt.Fprintln(os.Stderr, tr("error", err))
 				os.Exit(1)
 			}
 		},
 	}
 	historyCmd.AddCommand(historyClearCmd)
 
+	// Saved searches
+	savedCmd := &cobra.Command{
+		Use:   "saved",
+		Short: "Manage saved searches that report new results",
+		Long:  "Saved searches are repeated by `sx saved run`, e.g. from cron or a systemd timer, which reports only results that weren't reported before. They are kept with the URLs they have seen in the state directory.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := printSavedSearches(os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	savedAddCmd := &cobra.Command{
+		Use:   "add <query...>",
+		Short: "Save a search",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			s := savedSearch{Query: strings.Join(args, " ")}
+			s.Name, _ = cmd.Flags().GetString("name")
+			s.Interval, _ = cmd.Flags().GetString("interval")
+			s.Categories, _ = cmd.Flags().GetStringSlice("categories")
+			s.Engine, _ = cmd.Flags().GetString("engine")
+			s.Sites, _ = cmd.Flags().GetStringSlice("site")
+			s.TimeRange, _ = cmd.Flags().GetString("time-range")
+			if err := addSavedSearch(s); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	savedAddCmd.Flags().String("interval", "1d", "how often `sx saved run` repeats the search (e.g. 30m, 12h, 1d, 1w)")
+	savedAddCmd.Flags().String("name", "", "name of the saved search (default: the query)")
+	savedAddCmd.Flags().StringSlice("categories", nil, "search categories")
+	savedAddCmd.Flags().String("engine", "", fmt.Sprintf("search backend to use (%s)", validEngineNames()))
+	savedAddCmd.Flags().StringSlice("site", nil, "search within these sites")
+	savedAddCmd.Flags().String("time-range", "", "only results from the last day, week, month or year")
+
+	savedListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List saved searches",
+		Run:   savedCmd.Run,
+	}
+	savedRemoveCmd := &cobra.Command{
+		Use:   "remove <name|number>",
+		Short: "Remove a saved search",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := removeSavedSearch(args[0]); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	savedRunCmd := &cobra.Command{
+		Use:   "run [name|number...]",
+		Short: "Run the saved searches that are due and print new results",
+		Run: func(cmd *cobra.Command, args []string) {
+			force, _ := cmd.Flags().GetBool("force")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			email, _ := cmd.Flags().GetBool("email")
+			specs, _ := cmd.Flags().GetStringArray("sink")
+			if email && len(specs) == 0 {
+				specs = []string{"stdout", "email"}
+			} else if email {
+				specs = append(specs, "email")
+			}
+
+			applyColorMode(config)
+			sinks, err := outputSinks(specs, "")
+			if err == nil {
+				err = runSavedSearches(args, force, asJSON, sinks)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	savedRunCmd.Flags().Bool("force", false, "run every saved search, whether due or not")
+	savedRunCmd.Flags().Bool("json", false, "output new results as JSON")
+	savedRunCmd.Flags().Bool("email", false, "also mail new results through the [email] SMTP server (adds --sink email)")
+	savedRunCmd.Flags().StringArray("sink", nil, "deliver new results to stdout, file:PATH, webhook:URL, email or command:CMD (repeatable)")
+	savedInstallTimerCmd := &cobra.Command{
+		Use:   "install-timer <name|number>",
+		Short: "Run a saved search on its interval with a systemd timer or launchd agent",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			printOnly, _ := cmd.Flags().GetBool("print")
+			email, _ := cmd.Flags().GetBool("email")
+			if err := installTimer(args[0], printOnly, email); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	savedInstallTimerCmd.Flags().Bool("print", false, "print the unit files (or plist) instead of installing them")
+	savedInstallTimerCmd.Flags().Bool("email", false, "mail new results from each run through the [email] SMTP server")
+	savedCmd.AddCommand(savedAddCmd, savedListCmd, savedRemoveCmd, savedRunCmd, savedInstallTimerCmd)
+
+	// Weather subcommand
+	weatherCmd := &cobra.Command{
+		Use:   "weather [place...]",
+		Short: "Show a compact weather forecast",
+		Long:  "Show current conditions and a short forecast for a place (or your location, detected by IP) from wttr.in or the configured weather_url.",
+		Run: func(cmd *cobra.Command, args []string) {
+			days, _ := cmd.Flags().GetInt("days")
+			imperial, _ := cmd.Flags().GetBool("imperial")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			applyColorMode(config)
+			if err := runWeather(strings.Join(args, " "), days, imperial, asJSON); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	weatherCmd.Flags().Int("days", 3, "number of forecast days to show (0 for current conditions only)")
+	weatherCmd.Flags().Bool("imperial", false, "use °F and mph")
+	weatherCmd.Flags().Bool("json", false, "output the forecast as JSON")
+
+	// Paper subcommand
+	paperCmd := &cobra.Command{
+		Use:   "paper <title...>",
+		Short: "Find a paper, download its PDF and open it",
+		Long:  "Search the science category for a paper, download the PDF of the best match that has one (preferring direct PDF links and preprint servers such as arXiv) and open it with url_handler or the system viewer.",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, _ := cmd.Flags().GetString("dir")
+			if !cmd.Flags().Changed("dir") && config.PaperDir != "" {
+				dir = config.PaperDir
+			}
+			printOnly, _ := cmd.Flags().GetBool("print")
+			noOpen, _ := cmd.Flags().GetBool("no-open")
+
+			applyColorMode(config)
+			if err := runPaper(strings.Join(args, " "), dir, printOnly, noOpen); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	paperCmd.Flags().String("dir", ".", "directory to save the PDF in (paper_dir in config)")
+	paperCmd.Flags().Bool("print", false, "print the PDF link instead of downloading it")
+	paperCmd.Flags().Bool("no-open", false, "download the PDF without opening it")
+
+	// Ask subcommand
+	askCmd := &cobra.Command{
+		Use:   "ask <question...>",
+		Short: "Answer a question from the top results with an LLM",
+		Long:  "Search for a question, read the top result pages and stream an answer from the OpenAI-compatible chat API in the [llm] config table, citing the pages it draws on.",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			sources, _ := cmd.Flags().GetInt("sources")
+			chatMode, _ := cmd.Flags().GetBool("chat")
+
+			applyColorMode(config)
+			if err := runAsk(strings.Join(args, " "), sources, chatMode); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	askCmd.Flags().Int("sources", 5, "number of result pages to read")
+	askCmd.Flags().BoolP("chat", "i", false, "keep asking follow-up questions; the model searches again when it needs to")
+
+	// Snapshot subcommand
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot <query...>",
+		Short: "Save results and their pages as a dataset",
+		Long:  "Search for a query and save the results as results.json (the --json format) with a manifest.json recording how they were found. --with-content also saves each result's page as raw HTML and extracted markdown, with its checksum in the manifest, for reproducible corpora.",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			pages, _ := cmd.Flags().GetInt("pages")
+			withContent, _ := cmd.Flags().GetBool("with-content")
+			dir, _ := cmd.Flags().GetString("output")
+			aiPolicy, _ := cmd.Flags().GetString("ai-policy")
+
+			applyColorMode(config)
+			if err := runSnapshot(cmd.Flags(), strings.Join(args, " "), pages, withContent, dir, aiPolicy); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	snapshotCmd.Flags().Int("pages", 1, "number of result pages to collect")
+	snapshotCmd.Flags().Bool("with-content", false, "also save each result's page as HTML and markdown")
+	snapshotCmd.Flags().String("ai-policy", "", "with --with-content, check each page for AI use restrictions: report or exclude restricted pages")
+	snapshotCmd.Flags().StringP("output", "o", "", "directory to save the snapshot in (default: <query>-<date>)")
+
+	// Rank subcommand
+	rankCmd := &cobra.Command{
+		Use:   "rank",
+		Short: "Track where a domain ranks for a query over time",
+		Long:  "`sx rank track`, e.g. run daily from cron, records the position of a domain in the results for a query. `sx rank report` shows how the positions changed. They are kept in the state directory.",
+	}
+	rankTrackCmd := &cobra.Command{
+		Use:   "track <query...>",
+		Short: "Record the position of domains in the results for a query",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			domains, _ := cmd.Flags().GetStringSlice("domain")
+			depth, _ := cmd.Flags().GetInt("depth")
+			engine, _ := cmd.Flags().GetString("engine")
+
+			applyColorMode(config)
+			if err := runRankTrack(strings.Join(args, " "), domains, depth, engine); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	rankTrackCmd.Flags().StringSlice("domain", nil, "domain to find, subdomains included (repeatable or comma-separated)")
+	rankTrackCmd.Flags().Int("depth", defaultRankDepth, "number of results to look through")
+	rankTrackCmd.Flags().String("engine", "", fmt.Sprintf("search backend to use (%s)", validEngineNames()))
+	rankReportCmd := &cobra.Command{
+		Use:   "report [query...]",
+		Short: "Show the tracked positions, as sparklines or the runs of one query",
+		Run: func(cmd *cobra.Command, args []string) {
+			domain, _ := cmd.Flags().GetString("domain")
+			limit, _ := cmd.Flags().GetInt("limit")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			applyColorMode(config)
+			if err := runRankReport(strings.Join(args, " "), domain, limit, asJSON); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	rankReportCmd.Flags().String("domain", "", "only show this domain")
+	rankReportCmd.Flags().Int("limit", 30, "show the last N runs, 0 for all")
+	rankReportCmd.Flags().Bool("json", false, "output the position history as JSON")
+	rankCmd.AddCommand(rankTrackCmd, rankReportCmd)
+
+	// Bookmarks subcommand
+	bookmarksCmd := &cobra.Command{
+		Use:   "bookmarks",
+		Short: "Search your own bookmarks and history with --engine bookmarks",
+		Long:  "`sx bookmarks import` reads the bookmarks, and with --history the browsing history, of your Firefox and Chromium-based browsers, or of exported files, into an index that `sx --engine bookmarks` searches.",
+	}
+	bookmarksImportCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import browser bookmarks and history for --engine bookmarks",
+		Long:  "Import the bookmarks of every Firefox, Chrome, Chromium, Brave, Edge and Vivaldi profile found, or of --browser ones. --file imports a places.sqlite, History or Bookmarks file, a Firefox .json backup or an exported bookmarks.html instead (databases are read with the sqlite3 tool). Importing a source again replaces what was imported from it before.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			browsers, _ := cmd.Flags().GetStringSlice("browser")
+			files, _ := cmd.Flags().GetStringSlice("file")
+			history, _ := cmd.Flags().GetBool("history")
+
+			applyColorMode(config)
+			if err := runBookmarksImport(browsers, files, history); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	bookmarksImportCmd.Flags().StringSlice("browser", nil, "browsers to import from: "+strings.Join(browserNames, ", ")+" (default: all found)")
+	bookmarksImportCmd.Flags().StringSlice("file", nil, "import a bookmarks or history file instead (repeatable)")
+	bookmarksImportCmd.Flags().Bool("history", false, "also import visited pages")
+	bookmarksCmd.AddCommand(bookmarksImportCmd)
+
+	// Diff subcommand
+	diffCmd := &cobra.Command{
+		Use:   "diff <old> <new>",
+		Short: "Compare two saved result sets",
+		Long:  "Compare result sets saved with --json -o or sx snapshot (the results.json or its directory) and list the URLs that were added, removed or moved, with their ranks.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			applyColorMode(config)
+			if err := runDiff(args[0], args[1], asJSON); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	diffCmd.Flags().Bool("json", false, "output the changes as JSON")
+
+	// DNS subcommand
+	dnsCmd := &cobra.Command{
+		Use:   "dns <domain...>",
+		Short: "Look up the DNS records of domains",
+		Long:  "Look up the A, AAAA, CNAME, MX, NS and TXT records of domains, or the names of IP addresses (PTR records), with the system resolver or --server. Result URLs are accepted in place of domains.",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			types, _ := cmd.Flags().GetStringSlice("type")
+			server, _ := cmd.Flags().GetString("server")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			applyColorMode(config)
+			if err := runDNS(args, types, server, asJSON); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	dnsCmd.Flags().StringSliceP("type", "t", nil, "record types to look up: A, AAAA, CNAME, MX, NS, TXT or PTR (default: all but PTR)")
+	dnsCmd.Flags().String("server", "", "DNS server to ask, e.g. 1.1.1.1 (default: the system resolver)")
+	dnsCmd.Flags().Bool("json", false, "output the records as JSON")
+
+	// Whois subcommand
+	whoisCmd := &cobra.Command{
+		Use:   "whois <domain...>",
+		Short: "Look up who registered domains, and when",
+		Long:  "Look up the registrar, registration and expiry dates, status and name servers of the domains registered for hosts or result URLs, with RDAP through rdap.org or the configured whois_url. Registries without RDAP are asked over WHOIS.",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, _ := cmd.Flags().GetBool("raw")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			applyColorMode(config)
+			if err := runWhois(args, raw, asJSON); err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				os.Exit(1)
+			}
+		},
+	}
+	whoisCmd.Flags().Bool("raw", false, "also print a WHOIS server's whole answer")
+	whoisCmd.Flags().Bool("json", false, "output the registrations as JSON")
+
 	// Completion subcommand
 	completionCmd := &cobra.Command{
 		Use:   "completion [bash|zsh|fish|powershell]",
@@ -171,6 +556,18 @@ PowerShell:
 
 	rootCmd.AddCommand(historyCmd)
 	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(weatherCmd)
+	rootCmd.AddCommand(savedCmd)
+	rootCmd.AddCommand(paperCmd)
+	rootCmd.AddCommand(askCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(rankCmd)
+	rootCmd.AddCommand(bookmarksCmd)
+	rootCmd.AddCommand(dnsCmd)
+	rootCmd.AddCommand(whoisCmd)
+	// Config shortcuts come last so they can't shadow built-in commands
+	rootCmd.AddCommand(shortcutCommands(rootCmd, config.Shortcuts)...)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -178,33 +575,62 @@ PowerShell:
 }
 
 func runSearch(cmd *cobra.Command, args []string) {
-	var query string
-
-	// Check for piped input
-	if isPipeInput() {
-		input, err := readFromStdin()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading from stdin: %v\n", err)
-			return
-		}
-		query = strings.TrimSpace(input)
-		if query == "" {
-			fmt.Fprintf(os.Stderr, "Error: empty input from stdin\n")
-			return
-		}
-	} else if len(args) == 0 {
+	queries, err := resolveQueries(args, searchOpts.Queries, searchOpts.QueryFile, searchOpts.StdinMode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("error", err))
+		return
+	}
+	if len(queries) == 0 && !searchOpts.hasQueryTerms() {
 		cmd.Help()
 		return
-	} else {
-		query = strings.Join(args, " ")
+	}
+	query := ""
+	if len(queries) > 0 {
+		query = queries[0]
 	}
 
 	// Ensure config file exists for actual searches
 	if err := ensureConfig(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating config: %v\n", err)
+		fmt.Fprintln(os.Stderr, tr("config_create_error", err))
 		return
 	}
 
+	// --pipe streams everything sx prints into a command, whose exit
+	// status becomes sx's
+	if searchOpts.Pipe != "" {
+		if searchOpts.OutputFile != "" && !searchOpts.Download {
+			fmt.Fprintln(os.Stderr, tr("error", tr("flag_conflict", "--pipe", "--output")))
+			return
+		}
+		finish, err := startPipe(searchOpts.Pipe)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("error", err))
+			return
+		}
+		defer func() {
+			if status := finish(); status != 0 {
+				os.Exit(status)
+			}
+		}()
+	}
+
+	// Piped output is plain unless CLICOLOR_FORCE asks otherwise
+	applyColorMode(config)
+
+	// Log backend requests with secrets redacted in debug mode
+	if config.DebugDump != "" {
+		config.Debug = true
+	}
+	installTransports(config)
+	// --dry-run swaps every backend transport for one that prints requests
+	var dryRun *dryRunTransport
+	if searchOpts.DryRun {
+		dryRun = &dryRunTransport{out: os.Stdout}
+		backends.TransportWrapper = func(http.RoundTripper) http.RoundTripper {
+			return dryRun
+		}
+	}
+
 	// Initialize backend manager
 	backendMgr = initBackendManager(config)
 
@@ -222,7 +648,38 @@ func runSearch(cmd *cobra.Command, args []string) {
 		interactive = false
 	}
 	// Special output formats are never interactive
-	if searchOpts.JSON || searchOpts.LinksOnly || searchOpts.HTMLOnly || searchOpts.TextOnly || searchOpts.Top {
+	if searchOpts.JSON || searchOpts.LinksOnly || searchOpts.HTMLOnly || searchOpts.TextOnly || searchOpts.ExtractSchema != "" || searchOpts.DiffLast || searchOpts.Top || searchOpts.Download || searchOpts.Play || searchOpts.MagnetOnly || searchOpts.Subscribe || searchOpts.OpenMap || searchOpts.AnswerOnly || searchOpts.DryRun || searchOpts.Porcelain {
+		interactive = false
+	}
+
+	// Several queries are searched one after another and output together
+	if len(queries) > 1 {
+		if flag := singleQueryFlag(&searchOpts); flag != "" {
+			fmt.Fprintln(os.Stderr, tr("error", tr("flag_multi_query", flag)))
+			return
+		}
+		interactive = false
+	}
+	if searchOpts.AllCategories {
+		if len(queries) > 1 {
+			fmt.Fprintln(os.Stderr, tr("error", tr("flag_multi_query", "--all-categories")))
+			return
+		}
+		if flag := singleQueryFlag(&searchOpts); flag != "" {
+			fmt.Fprintln(os.Stderr, tr("error", tr("flag_conflict", flag, "--all-categories")))
+			return
+		}
+		interactive = false
+	}
+	if len(searchOpts.Sinks) > 0 {
+		if flag := singleQueryFlag(&searchOpts); flag != "" {
+			fmt.Fprintln(os.Stderr, tr("error", tr("flag_conflict", flag, "--sink")))
+			return
+		}
+		if _, err := outputSinks(searchOpts.Sinks, ""); err != nil {
+			fmt.Fprintln(os.Stderr, tr("error", err))
+			return
+		}
 		interactive = false
 	}
 
@@ -243,11 +700,149 @@ func runSearch(cmd *cobra.Command, args []string) {
 		searchOpts.Categories = []string{"videos"}
 	}
 
+	// Image downloads search the images category unless told otherwise
+	if searchOpts.Download {
+		if len(searchOpts.Categories) == 0 {
+			searchOpts.Categories = []string{"images"}
+		}
+		searchOpts.ImageFormat = strings.ToLower(searchOpts.ImageFormat)
+		if !validateImageFormat(searchOpts.ImageFormat) {
+			fmt.Fprintln(os.Stderr, tr("error", tr("invalid_flag", "--format", searchOpts.ImageFormat, strings.Join(imageFormats, ", "))))
+			return
+		}
+	}
+
+	// Magnet links come from torrent results in the files category
+	if searchOpts.MagnetOnly && len(searchOpts.Categories) == 0 {
+		searchOpts.Categories = []string{"files"}
+	}
+
+	// Podcast feeds come from the podcasts backend
+	if searchOpts.Subscribe && searchOpts.ExplicitEngine == "" && len(searchOpts.SearxngEngines) == 0 {
+		searchOpts.ExplicitEngine = "podcasts"
+	}
+
+	// Map features search the map category unless told otherwise
+	var nearLat, nearLon float64
+	if searchOpts.Near != "" {
+		var err error
+		if nearLat, nearLon, err = parseLatLon(searchOpts.Near); err != nil {
+			fmt.Fprintln(os.Stderr, tr("error", tr("invalid_value", "--near", err)))
+			return
+		}
+	}
+	if (searchOpts.OpenMap || searchOpts.Near != "") && len(searchOpts.Categories) == 0 {
+		searchOpts.Categories = []string{"map"}
+	}
+
+	if searchOpts.Sort != "" && !validateSortKey(searchOpts.Sort) {
+		fmt.Fprintln(os.Stderr, tr("error", tr("invalid_sort", searchOpts.Sort, strings.Join(sortKeys, ", "))))
+		return
+	}
+	if !validateGroupBy(searchOpts.GroupBy) {
+		fmt.Fprintln(os.Stderr, tr("error", tr("invalid_flag", "--group-by", searchOpts.GroupBy, strings.Join(groupKeys, ", "))))
+		return
+	}
+	if len(searchOpts.ResultLang) > 0 {
+		langs, err := parseResultLanguages(searchOpts.ResultLang)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("error", err))
+			return
+		}
+		searchOpts.ResultLang = langs
+		// Ask the engines too, though many ignore it
+		if searchOpts.Language == "" && len(langs) == 1 {
+			searchOpts.Language = langs[0]
+		}
+	}
+	if searchOpts.Rerank {
+		if searchOpts.Sort != "" || searchOpts.Near != "" {
+			fmt.Fprintln(os.Stderr, tr("error", tr("rerank_order")))
+			return
+		}
+		if err := config.Rerank.check(); err != nil {
+			fmt.Fprintln(os.Stderr, tr("error", err))
+			return
+		}
+	}
+	if searchOpts.MaxTokens < 0 {
+		fmt.Fprintln(os.Stderr, tr("error", tr("not_negative", "--max-tokens")))
+		return
+	}
+	if !cmd.Flags().Changed("enrich") {
+		searchOpts.Enrich = config.Enrich
+	}
+	if kind := invalidEnrichKind(searchOpts.Enrich); kind != "" {
+		fmt.Fprintln(os.Stderr, tr("error", tr("invalid_flag", "--enrich", kind, strings.Join(enrichKinds, ", "))))
+		return
+	}
+	if searchOpts.AIPolicy != "" {
+		if !validateAIPolicyMode(searchOpts.AIPolicy) {
+			fmt.Fprintln(os.Stderr, tr("error", tr("invalid_flag", "--ai-policy", searchOpts.AIPolicy, strings.Join(aiPolicyModes, ", "))))
+			return
+		}
+		if !searchOpts.TextOnly {
+			fmt.Fprintln(os.Stderr, tr("error", tr("only_with", "--ai-policy", "--text")))
+			return
+		}
+	}
+	if searchOpts.OpenAccess != "" {
+		if !validateOpenAccessMode(searchOpts.OpenAccess) {
+			fmt.Fprintln(os.Stderr, tr("error", tr("invalid_flag", "--open-access", searchOpts.OpenAccess, strings.Join(openAccessModes, ", "))))
+			return
+		}
+		if unpaywallEmail(config) == "" {
+			fmt.Fprintln(os.Stderr, tr("error", tr("unpaywall_email")))
+			return
+		}
+	}
+
+	// Apply the restriction profile before the unsafe flag so an enforcing
+	// profile can reject it
+	if err := applyProfile(config, searchOpts.Profile, &searchOpts, cmd.Flags().Changed("safe-search")); err != nil {
+		fmt.Fprintln(os.Stderr, tr("error", err))
+		return
+	}
+
+	if searchOpts.ExpandQuery != "" {
+		synonyms, err := loadSynonyms(searchOpts.ExpandQuery)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("error", err))
+			return
+		}
+		searchOpts.Synonyms = synonyms
+	}
+	if searchOpts.ExtractSchema != "" {
+		schema, err := loadExtractSchema(searchOpts.ExtractSchema)
+		if err == nil {
+			err = config.LLM.check()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("error", err))
+			return
+		}
+		searchOpts.Schema = schema
+	}
+
 	// Handle unsafe flag
 	if searchOpts.Unsafe {
 		searchOpts.SafeSearch = "none"
 	}
 
+	if searchOpts.FullContent {
+		config.SnippetWords = 0
+	}
+	if searchOpts.RawURLs {
+		config.CleanURLs = false
+	}
+	if searchOpts.ArchiveCopies {
+		config.ArchivePaywalled = true
+	}
+	if config.SnippetWords < 0 {
+		fmt.Fprintln(os.Stderr, tr("error", tr("not_negative", "--snippet")))
+		return
+	}
+
 	// Handle top flag - show only first result
 	if searchOpts.Top {
 		config.ResultCount = 1
@@ -262,16 +857,15 @@ func runSearch(cmd *cobra.Command, args []string) {
 		engineToUse = "searxng"
 	}
 	if engineToUse == "searxng" && !hasSearxngConfigured(config) {
-		fmt.Fprintf(os.Stderr, "Error: no SearXNG instance configured (set searxng_url or searxng_urls)\n")
-		fmt.Fprintf(os.Stderr, "Set searxng_url/searxng_urls in config.toml or use --engine brave/tavily/exa/jina\n")
+		fmt.Fprintln(os.Stderr, tr("error", tr("no_searxng")))
+		fmt.Fprintln(os.Stderr, tr("no_searxng_hint"))
 		return
 	}
 
 	// Validate categories
 	for _, category := range searchOpts.Categories {
 		if !validateCategory(category) {
-			fmt.Fprintf(os.Stderr, "Error: Invalid category '%s'. Supported categories are: %s\n",
-				category, strings.Join(searxngCategories, ", "))
+			fmt.Fprintln(os.Stderr, tr("error", tr("invalid_category", category, strings.Join(searxngCategories, ", "))))
 			return
 		}
 	}
@@ -279,8 +873,7 @@ func runSearch(cmd *cobra.Command, args []string) {
 	// Validate time range
 	if searchOpts.TimeRange != "" {
 		if !validateTimeRange(searchOpts.TimeRange) {
-			fmt.Fprintf(os.Stderr, "Error: Invalid time range '%s'. Use: %s\n",
-				searchOpts.TimeRange, strings.Join(timeRangeOptions, ", "))
+			fmt.Fprintln(os.Stderr, tr("error", tr("invalid_time_range", searchOpts.TimeRange, strings.Join(timeRangeOptions, ", "))))
 			return
 		}
 		searchOpts.TimeRange = expandTimeRange(searchOpts.TimeRange)
@@ -292,137 +885,383 @@ func runSearch(cmd *cobra.Command, args []string) {
 	}
 
 	// Record query in history
-	_ = appendHistory(query)
+	if !searchOpts.DryRun {
+		for _, q := range queries {
+			_ = appendHistory(displayQuery(q, &searchOpts))
+		}
+	}
+
+	// Non-interactive pagination: start output at --skip or --page
+	if searchOpts.Skip < 0 {
+		fmt.Fprintln(os.Stderr, tr("error", tr("not_negative", "--skip")))
+		return
+	}
+	if searchOpts.Page < 0 {
+		fmt.Fprintln(os.Stderr, tr("error", tr("at_least_one", "--page")))
+		return
+	}
+	if searchOpts.Skip > 0 && searchOpts.Page > 0 {
+		fmt.Fprintln(os.Stderr, tr("error", tr("flag_conflict", "--skip", "--page")))
+		return
+	}
 
 	searchOpts.PageNo = 1
-	startAt := 0
+	startAt := searchOpts.Skip
+	if searchOpts.Page > 1 {
+		startAt = (searchOpts.Page - 1) * config.ResultCount
+	}
+	// --all keeps paginating up to --max results and outputs all of them
+	if searchOpts.All && searchOpts.Max <= 0 {
+		fmt.Fprintln(os.Stderr, tr("error", tr("at_least_one", "--max")))
+		return
+	}
+	wanted := config.ResultCount
+	outputCount := config.ResultCount
+	if searchOpts.All {
+		wanted = searchOpts.Max
+		outputCount = 0
+	}
+
+	// Show what the selected backend would send, without fallbacks
+	if dryRun != nil {
+		for _, q := range queries {
+			before := dryRun.requests()
+			_, _, err := performSearch(q, config, &searchOpts, backendMgr, engineToUse)
+			if dryRun.requests() == before && err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+			}
+		}
+		return
+	}
+
+	// Calculations and unit conversions are answered locally
+	if searchOpts.AnswerOnly {
+		if answer, ok := instantAnswer(query); ok {
+			fmt.Println(answer)
+			return
+		}
+		resp, engine, err := performSearch(query, config, &searchOpts, backendMgr, searchOpts.ExplicitEngine)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("search_error", err))
+			return
+		}
+		answers := mergeAnswers(resp.Answers, engineAnswers(query, &searchOpts, config, backendMgr, engine))
+		if len(answers) == 0 {
+			fmt.Fprintln(os.Stderr, tr("no_answer"))
+			return
+		}
+		for _, answer := range answers {
+			fmt.Println(answer)
+		}
+		return
+	}
+
+	if (fileOutput.Append || fileOutput.IfResults || fileOutput.Rotate != "") && searchOpts.OutputFile == "" {
+		fmt.Fprintln(os.Stderr, tr("error", tr("need_output")))
+		return
+	}
+	if err := validateRotate(fileOutput.Rotate); err != nil {
+		fmt.Fprintln(os.Stderr, tr("error", tr("invalid_value", "--rotate", err)))
+		return
+	}
+
+	// -o may hold placeholders filled in once the query and engine are known
+	outputTemplate := searchOpts.OutputFile
+	if _, err := expandOutputPath(outputTemplate, outputVars("", "", time.Now())); err != nil {
+		fmt.Fprintln(os.Stderr, tr("error", err))
+		return
+	}
+
+	if len(queries) > 1 {
+		runMultiSearch(cmd, queries, startAt, wanted, outputCount, outputTemplate, nearLat, nearLon)
+		return
+	}
+	if searchOpts.AllCategories {
+		runCategorySweep(cmd, query, startAt, wanted, outputCount, outputTemplate, nearLat, nearLon)
+		return
+	}
+
 	var allResults []SearchResult
-	var usedEngine string
+	state := searchState{}
+	remembered := false
 
 	for {
 		// Fetch results until we have enough
-		for len(allResults) < startAt+config.ResultCount {
-			results, engine, err := performSearch(query, config, &searchOpts, backendMgr, searchOpts.ExplicitEngine)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Search error: %v\n", err)
-				return
+		state.Query = query
+		allResults, err = fetchResults(&state, allResults, startAt+wanted, &searchOpts, config)
+		query = state.Query
+		var relaxed []relaxation
+		if err == nil && len(allResults) == 0 && searchOpts.Relax {
+			allResults, relaxed, err = fetchRelaxed(&state, startAt+wanted, &searchOpts, config)
+			if len(allResults) > 0 {
+				printNotice("%s", tr("relaxed", describeRelaxed(relaxed)))
 			}
-			if usedEngine == "" {
-				usedEngine = engine
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("search_error", err))
+			return
+		}
+		usedEngine := state.Engine
+		corrections, suggestions, answers := state.Corrections, state.Suggestions, state.Answers
+		var keywords []string
+		if searchOpts.Keywords {
+			keywords = extractKeywords(allResults, displayQuery(query, &searchOpts), maxKeywords)
+		}
+
+		if searchOpts.All && len(allResults) > startAt+wanted {
+			allResults = allResults[:startAt+wanted]
+		}
+		enrichResults(allResults, enrichments(&searchOpts), config)
+		sortResults(allResults, searchOpts.Sort)
+		if searchOpts.Near != "" {
+			sortByDistance(allResults, nearLat, nearLon)
+		}
+		if searchOpts.Rerank {
+			if err := rerankResults(allResults, displayQuery(query, &searchOpts), config); err != nil {
+				printNotice("%s", tr("rerank_failed", err))
 			}
+		}
+		if searchOpts.Cluster {
+			clusterResults(allResults)
+		}
 
-			if len(results) == 0 {
-				break
+		if len(allResults) == 0 || (len(allResults) <= startAt && !interactive) {
+			if !searchOpts.JSON {
+				printAnswers(os.Stdout, answers, config.NoColor)
+			}
+			fmt.Fprintln(os.Stderr, tr("no_results"))
+			printCorrections(corrections, nil, false, config.NoColor)
+			return
+		}
+		if len(allResults) <= startAt {
+			// Paged past the end interactively: stay on the last page
+			fmt.Fprintln(os.Stderr, tr("no_more_results"))
+			startAt = len(allResults) - 1
+			if config.ResultCount > 0 {
+				startAt -= startAt % config.ResultCount
 			}
+		}
 
-			allResults = append(allResults, results...)
-			if config.ResultCount == 0 {
-				break
+		// The first results of each query are kept for --diff-last
+		if !remembered && (config.HistoryEnabled || searchOpts.DiffLast) {
+			remembered = true
+			previous, previousAt, err := swapLastRun(displayQuery(query, &searchOpts), usedEngine, &searchOpts, allResults, time.Now(), config.MaxHistory)
+			if err != nil && config.Debug {
+				fmt.Fprintln(os.Stderr, tr("save_run_failed", err))
+			}
+			if searchOpts.DiffLast {
+				if err := printLastDiff(previous, previousAt, allResults, displayQuery(query, &searchOpts)); err != nil {
+					fmt.Fprintln(os.Stderr, tr("error", err))
+				}
+				return
 			}
-			searchOpts.PageNo++
 		}
 
-		if len(allResults) == 0 {
-			fmt.Println("No results found.")
+		if searchOpts.CheckLinks {
+			checkLinks(resultWindow(allResults, startAt, outputCount), config)
+		}
+		if searchOpts.OpenAccess != "" {
+			resolveOpenAccess(allResults[startAt:], searchOpts.OpenAccess == "replace", config)
+		}
+
+		if len(searchOpts.Sinks) > 0 {
+			section := querySection{
+				Query:       displayQuery(query, &searchOpts),
+				Engine:      usedEngine,
+				Results:     allResults,
+				StartAt:     startAt,
+				Count:       outputCount,
+				Corrections: corrections,
+				Suggestions: suggestions,
+				Answers:     answers,
+				Fallbacks:   state.Fallbacks,
+				RequestID:   state.RequestID,
+				Relaxed:     relaxed,
+				Keywords:    keywords,
+			}
+			outputSections(cmd, []querySection{section}, section.Query, outputTemplate)
 			return
 		}
 
+		if outputTemplate != "" {
+			vars := outputVars(displayQuery(query, &searchOpts), usedEngine, time.Now())
+			path, err := prepareOutputPath(outputTemplate, vars)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+				return
+			}
+			searchOpts.OutputFile = path
+		}
+
+		// Saved JSON and markdown record how they were produced
+		var meta *provenance
+		if searchOpts.OutputFile != "" {
+			p := buildProvenance(cmd.Flags(), displayQuery(query, &searchOpts), usedEngine, &searchOpts, config, time.Now())
+			p.RequestID = state.RequestID
+			meta = &p
+		}
+
 		// Handle special output formats
 		if searchOpts.JSON {
+			extra := map[string]interface{}{"engine_used": usedEngine, "request_id": state.RequestID}
+			if len(state.Fallbacks) > 0 {
+				extra["fallback_chain"] = state.Fallbacks
+			}
+			if len(relaxed) > 0 {
+				extra["relaxed"] = relaxed
+			}
+			if len(corrections) > 0 {
+				extra["corrections"] = corrections
+			}
+			if len(suggestions) > 0 {
+				extra["suggestions"] = suggestions
+			}
+			if len(answers) > 0 {
+				extra["answers"] = answers
+			}
+			if len(keywords) > 0 {
+				extra["keywords"] = keywords
+			}
+			if meta != nil {
+				extra["meta"] = meta
+			}
 			if searchOpts.OutputFile != "" {
-				if err := printJSONToFile(allResults, searchOpts.OutputFile, query, searchOpts.Clean); err != nil {
-					fmt.Fprintf(os.Stderr, "Error writing JSON to file: %v\n", err)
+				if err := printJSONToFile(allResults[startAt:], searchOpts.OutputFile, displayQuery(query, &searchOpts), searchOpts.Clean, extra); err != nil {
+					fmt.Fprintln(os.Stderr, tr("write_json_error", err))
 				}
 			} else {
 				if searchOpts.Clean {
-					if err := printJSONResultsClean(allResults, query); err != nil {
-						fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+					if err := printJSONResultsClean(allResults[startAt:], displayQuery(query, &searchOpts), extra); err != nil {
+						fmt.Fprintln(os.Stderr, tr("format_json_error", err))
 					}
 				} else {
-					if err := printJSONResults(allResults, query); err != nil {
-						fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+					if err := printJSONResults(allResults[startAt:], displayQuery(query, &searchOpts), extra); err != nil {
+						fmt.Fprintln(os.Stderr, tr("format_json_error", err))
 					}
 				}
 			}
 			return
 		}
 
-		if searchOpts.LinksOnly {
-			count := config.ResultCount
-			if count == 0 {
-				count = len(allResults)
+		if searchOpts.Porcelain {
+			window := resultWindow(allResults, startAt, outputCount)
+			if searchOpts.OutputFile == "" {
+				printPorcelain(os.Stdout, window, startAt)
+				return
 			}
-			end := startAt + count
-			if end > len(allResults) {
-				end = len(allResults)
+			file, err := createOutput(searchOpts.OutputFile)
+			if err == nil {
+				printPorcelain(file, window, startAt)
+				err = file.Close()
 			}
-			linksResults := allResults[startAt:end]
+			if err != nil {
+				fmt.Fprintln(os.Stderr, tr("error", err))
+			}
+			return
+		}
+
+		if searchOpts.LinksOnly {
+			linksResults := resultWindow(allResults, startAt, outputCount)
 			if err := printLinksOnly(linksResults, searchOpts.OutputFile); err != nil {
-				fmt.Fprintf(os.Stderr, "Error outputting links: %v\n", err)
+				fmt.Fprintln(os.Stderr, tr("links_error", err))
 			}
 			return
 		}
 
-		if searchOpts.HTMLOnly {
-			count := config.ResultCount
-			if count == 0 {
-				count = len(allResults)
+		if searchOpts.MagnetOnly {
+			if err := printMagnetsOnly(resultWindow(allResults, startAt, outputCount), searchOpts.OutputFile); err != nil {
+				fmt.Fprintln(os.Stderr, tr("magnets_error", err))
 			}
-			end := startAt + count
-			if end > len(allResults) {
-				end = len(allResults)
+			return
+		}
+
+		if searchOpts.Subscribe {
+			if err := printSubscriptions(resultWindow(allResults, startAt, outputCount), displayQuery(query, &searchOpts), searchOpts.OutputFile); err != nil {
+				fmt.Fprintln(os.Stderr, tr("feeds_error", err))
 			}
-			htmlResults := allResults[startAt:end]
+			return
+		}
+
+		if searchOpts.HTMLOnly {
+			htmlResults := resultWindow(allResults, startAt, outputCount)
 			if err := printHTMLOnly(htmlResults, searchOpts.OutputFile, config); err != nil {
-				fmt.Fprintf(os.Stderr, "Error outputting HTML: %v\n", err)
+				fmt.Fprintln(os.Stderr, tr("html_error", err))
+			}
+			return
+		}
+
+		if searchOpts.ExtractSchema != "" {
+			if err := printExtracted(resultWindow(allResults, startAt, outputCount), startAt+1, searchOpts.Schema, searchOpts.OutputFile, config); err != nil {
+				fmt.Fprintln(os.Stderr, tr("extract_error", err))
 			}
 			return
 		}
 
 		if searchOpts.TextOnly {
-			count := config.ResultCount
-			if count == 0 {
-				count = len(allResults)
+			textResults := resultWindow(allResults, startAt, outputCount)
+			if err := printTextOnly(textResults, searchOpts.OutputFile, config, meta, searchOpts.MaxTokens, searchOpts.AIPolicy); err != nil {
+				fmt.Fprintln(os.Stderr, tr("text_error", err))
 			}
-			end := startAt + count
-			if end > len(allResults) {
-				end = len(allResults)
+			return
+		}
+
+		if searchOpts.Download {
+			imgOpts := imageOptions{
+				Dir:        searchOpts.OutputFile,
+				Resize:     searchOpts.Resize,
+				Format:     searchOpts.ImageFormat,
+				Thumbnails: searchOpts.Thumbnails,
 			}
-			textResults := allResults[startAt:end]
-			if err := printTextOnly(textResults, searchOpts.OutputFile, config); err != nil {
-				fmt.Fprintf(os.Stderr, "Error outputting text: %v\n", err)
+			if err := downloadImages(resultWindow(allResults, startAt, outputCount), startAt, imgOpts, config); err != nil {
+				fmt.Fprintln(os.Stderr, tr("download_error", err))
 			}
 			return
 		}
 
 		// Handle first/lucky options
-		if searchOpts.First && len(allResults) > 0 {
-			if err := openURL(allResults[0].URL); err != nil {
-				fmt.Fprintf(os.Stderr, "Error opening URL: %v\n", err)
+		if searchOpts.First || searchOpts.Lucky {
+			rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+			if cmd.Flags().Changed("seed") {
+				rng = rand.New(rand.NewSource(searchOpts.Seed))
+			}
+			chosen := chosenResult(allResults[startAt:], &searchOpts, rng)
+			if err := openResultURL(chosen.URL, &searchOpts, config); err != nil {
+				fmt.Fprintln(os.Stderr, tr("open_url_error", err))
+			}
+			return
+		}
+
+		if searchOpts.OpenMap {
+			if err := openResultURL(mapURL(allResults[startAt], config.MapURL), &searchOpts, config); err != nil {
+				fmt.Fprintln(os.Stderr, tr("open_url_error", err))
 			}
 			return
 		}
 
-		if searchOpts.Lucky && len(allResults) > 0 {
-			randomResult := allResults[rand.Intn(len(allResults))]
-			if err := openURL(randomResult.URL); err != nil {
-				fmt.Fprintf(os.Stderr, "Error opening URL: %v\n", err)
+		if searchOpts.Play {
+			if err := playResult(allResults[startAt], config); err != nil {
+				fmt.Fprintln(os.Stderr, tr("play_error", err))
 			}
 			return
 		}
 
 		// Display results
-		count := config.ResultCount
+		count := outputCount
 		if count == 0 {
 			count = len(allResults)
 		}
 
 		if searchOpts.OutputFile != "" {
-			if err := printResultsToFile(allResults, count, startAt, searchOpts.Expand, config.NoColor, query, searchOpts.OutputFile); err != nil {
-				fmt.Fprintf(os.Stderr, "Error writing results to file: %v\n", err)
+			if err := printResultsToFile(allResults, count, startAt, searchOpts.Expand, config.SnippetWords, config.ShortDomains, displayQuery(query, &searchOpts), searchOpts.OutputFile); err != nil {
+				fmt.Fprintln(os.Stderr, tr("write_error", err))
 			}
 		} else {
-			printResults(allResults, count, startAt, searchOpts.Expand, config.NoColor, query)
+			if startAt == 0 {
+				printAnswers(os.Stdout, answers, config.NoColor)
+			}
+			printResults(os.Stdout, allResults, count, startAt, searchOpts.Expand, config.NoColor, config.SnippetWords, config.ShortDomains, displayQuery(query, &searchOpts))
+			printKeywords(os.Stdout, keywords, config.NoColor)
+			printCorrections(corrections, suggestions, interactive, config.NoColor)
 		}
 
 		// Exit if not interactive
@@ -431,17 +1270,116 @@ func runSearch(cmd *cobra.Command, args []string) {
 		}
 
 		// Interactive prompt
-		if !handleInteractiveSession(&query, &allResults, &startAt, &searchOpts) {
+		if !handleInteractiveSession(&query, &allResults, &startAt, &searchOpts, corrections) {
 			return
 		}
 	}
 }
 
-func handleInteractiveSession(query *string, allResults *[]SearchResult, startAt *int, opts *SearchOptions) bool {
+// runMultiSearch searches each query in turn with the same options and
+// outputs the results together, labeled by query.
+func runMultiSearch(cmd *cobra.Command, queries []string, startAt, wanted, outputCount int, outputTemplate string, nearLat, nearLon float64) {
+	sections := make([]querySection, 0, len(queries))
+	found := false
+	for _, q := range queries {
+		section := searchSection(&searchState{Query: q}, searchOpts, startAt, wanted, outputCount, nearLat, nearLon)
+		if section.Err != nil {
+			fmt.Fprintln(os.Stderr, tr("search_error_for", q, section.Err))
+		}
+		if len(section.window()) == 0 {
+			if section.Err == nil {
+				fmt.Fprintln(os.Stderr, tr("no_results_for", section.Query))
+			}
+		} else {
+			found = true
+		}
+		sections = append(sections, section)
+	}
+	if !found && fileOutput.IfResults {
+		return
+	}
+	outputSections(cmd, sections, strings.Join(queries, " + "), outputTemplate)
+}
+
+// searchSection runs one search of a multi-query search or category sweep
+// with opts, a copy of the global options.
+func searchSection(state *searchState, opts SearchOptions, startAt, wanted, outputCount int, nearLat, nearLon float64) querySection {
+	q := state.Query
+	results, err := fetchResults(state, nil, startAt+wanted, &opts, config)
+	var relaxed []relaxation
+	if err == nil && len(results) == 0 && opts.Relax {
+		results, relaxed, err = fetchRelaxed(state, startAt+wanted, &opts, config)
+		if len(results) > 0 {
+			printNotice("%s", tr("relaxed_for", q, describeRelaxed(relaxed)))
+		}
+	}
+	if searchOpts.All && len(results) > startAt+wanted {
+		results = results[:startAt+wanted]
+	}
+	enrichResults(results, enrichments(&searchOpts), config)
+	sortResults(results, searchOpts.Sort)
+	if searchOpts.Near != "" {
+		sortByDistance(results, nearLat, nearLon)
+	}
+	if searchOpts.Rerank {
+		if err := rerankResults(results, displayQuery(state.Query, &searchOpts), config); err != nil {
+			printNotice("%s", tr("rerank_failed_for", state.Query, err))
+		}
+	}
+	if searchOpts.Cluster {
+		clusterResults(results)
+	}
+	section := querySection{
+		Query:       displayQuery(state.Query, &searchOpts),
+		Engine:      state.Engine,
+		Results:     results,
+		StartAt:     startAt,
+		Count:       outputCount,
+		Corrections: state.Corrections,
+		Suggestions: state.Suggestions,
+		Answers:     state.Answers,
+		Fallbacks:   state.Fallbacks,
+		RequestID:   state.RequestID,
+		Relaxed:     relaxed,
+		Err:         err,
+	}
+	if searchOpts.Keywords {
+		section.Keywords = extractKeywords(results, section.Query, maxKeywords)
+	}
+	if searchOpts.CheckLinks {
+		checkLinks(section.window(), config)
+	}
+	if searchOpts.OpenAccess != "" {
+		resolveOpenAccess(section.window(), searchOpts.OpenAccess == "replace", config)
+	}
+	return section
+}
+
+// outputSections delivers the sections of a multi-query search, category
+// sweep or --sink search to the --sink sinks and the -o file named after
+// label, or to stdout without either.
+func outputSections(cmd *cobra.Command, sections []querySection, label, outputTemplate string) {
+	sinks, err := outputSinks(searchOpts.Sinks, outputTemplate)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("error", err))
+		return
+	}
+	now := time.Now()
+	meta := buildProvenance(cmd.Flags(), label, sections[0].Engine, &searchOpts, config, now)
+	if len(sections) == 1 {
+		meta.RequestID = sections[0].RequestID
+	}
+	batch := resultBatch{Sections: sections, Label: label, Opts: &searchOpts, Meta: &meta, Time: now}
+	if err := deliverAll(sinks, batch); err != nil {
+		fmt.Fprintln(os.Stderr, tr("error", err))
+	}
+}
+
+func handleInteractiveSession(query *string, allResults *[]SearchResult, startAt *int, opts *SearchOptions, corrections []string) bool {
 	reader := bufio.NewReader(os.Stdin)
 
 	for {
-		fmt.Print("sx (? for help): ")
+		fmt.Fprint(os.Stderr, tr("prompt"))
 		input, err := reader.ReadString('\n')
 		if err != nil {
 			return false
@@ -463,7 +1401,7 @@ func handleInteractiveSession(query *string, allResults *[]SearchResult, startAt
 				opts.PageNo++
 				return true // Need to fetch more results
 			}
-			printResults(*allResults, config.ResultCount, *startAt, opts.Expand, config.NoColor, *query)
+			printResults(os.Stdout, *allResults, config.ResultCount, *startAt, opts.Expand, config.NoColor, config.SnippetWords, config.ShortDomains, displayQuery(*query, opts))
 			continue
 
 		case input == "p": // Previous page
@@ -471,22 +1409,30 @@ func handleInteractiveSession(query *string, allResults *[]SearchResult, startAt
 			if *startAt < 0 {
 				*startAt = 0
 			}
-			printResults(*allResults, config.ResultCount, *startAt, opts.Expand, config.NoColor, *query)
+			printResults(os.Stdout, *allResults, config.ResultCount, *startAt, opts.Expand, config.NoColor, config.SnippetWords, config.ShortDomains, displayQuery(*query, opts))
 			continue
 
 		case input == "f": // First page
 			*startAt = 0
-			printResults(*allResults, config.ResultCount, *startAt, opts.Expand, config.NoColor, *query)
+			printResults(os.Stdout, *allResults, config.ResultCount, *startAt, opts.Expand, config.NoColor, config.SnippetWords, config.ShortDomains, displayQuery(*query, opts))
 			continue
 
 		case input == "x": // Toggle expand URLs
 			opts.Expand = !opts.Expand
-			printResults(*allResults, config.ResultCount, *startAt, opts.Expand, config.NoColor, *query)
+			printResults(os.Stdout, *allResults, config.ResultCount, *startAt, opts.Expand, config.NoColor, config.SnippetWords, config.ShortDomains, displayQuery(*query, opts))
 			continue
 
+		case input == "y" && len(corrections) > 0: // Accept "did you mean" correction
+			*query = corrections[0]
+			*startAt = 0
+			opts.PageNo = 1
+			*allResults = []SearchResult{}
+			_ = appendHistory(*query)
+			return true
+
 		case input == "d": // Toggle debug
 			config.Debug = !config.Debug
-			fmt.Printf("Debug mode %s\n", map[bool]string{true: "enabled", false: "disabled"}[config.Debug])
+			fmt.Fprintln(os.Stderr, tr(map[bool]string{true: "debug_enabled", false: "debug_disabled"}[config.Debug]))
 			continue
 
 		case strings.HasPrefix(input, "r "): // Change time range
@@ -498,13 +1444,12 @@ func handleInteractiveSession(query *string, allResults *[]SearchResult, startAt
 				*allResults = []SearchResult{}
 				return true
 			} else {
-				fmt.Printf("Invalid time range '%s'. Use: %s\n", timeRange, strings.Join(timeRangeOptions, ", "))
+				fmt.Fprintln(os.Stderr, tr("invalid_time_range", timeRange, strings.Join(timeRangeOptions, ", ")))
 			}
 			continue
 
 		case strings.HasPrefix(input, "site:"): // Change site filter
-			site := strings.TrimSpace(input[5:])
-			opts.Site = site
+			opts.Sites = splitList(input[5:])
 			*startAt = 0
 			opts.PageNo = 1
 			*allResults = []SearchResult{}
@@ -516,7 +1461,49 @@ func handleInteractiveSession(query *string, allResults *[]SearchResult, startAt
 				url := (*allResults)[index-1].URL
 				fmt.Printf("URL: %s\n", url)
 			} else {
-				fmt.Println("Invalid index specified.")
+				fmt.Fprintln(os.Stderr, tr("invalid_index"))
+			}
+			continue
+
+		case strings.HasPrefix(input, "s "): // Re-sort loaded results
+			key := strings.TrimSpace(input[2:])
+			if !validateSortKey(key) {
+				fmt.Fprintln(os.Stderr, tr("invalid_sort", key, strings.Join(sortKeys, ", ")))
+				continue
+			}
+			// Kept in opts so pages fetched later are merged in order
+			opts.Sort = key
+			enrichResults(*allResults, enrichments(opts), config)
+			sortResults(*allResults, key)
+			*startAt = 0
+			printResults(os.Stdout, *allResults, config.ResultCount, *startAt, opts.Expand, config.NoColor, config.SnippetWords, config.ShortDomains, displayQuery(*query, opts))
+			continue
+
+		case strings.HasPrefix(input, "m "): // Open magnet link
+			indexStr := strings.TrimSpace(input[2:])
+			if index, err := strconv.Atoi(indexStr); err == nil && index > 0 && index <= len(*allResults) {
+				magnet := (*allResults)[index-1].MagnetLink
+				if magnet == "" {
+					fmt.Fprintln(os.Stderr, tr("no_magnet", index))
+					continue
+				}
+				fmt.Printf("Magnet: %s\n", magnet)
+				if err := openMagnet(magnet, config); err != nil {
+					fmt.Fprintln(os.Stderr, tr("open_magnet_error", err))
+				}
+			} else {
+				fmt.Fprintln(os.Stderr, tr("invalid_index"))
+			}
+			continue
+
+		case strings.HasPrefix(input, "play "): // Play in media player
+			indexStr := strings.TrimSpace(input[5:])
+			if index, err := strconv.Atoi(indexStr); err == nil && index > 0 && index <= len(*allResults) {
+				if err := playResult((*allResults)[index-1], config); err != nil {
+					fmt.Fprintln(os.Stderr, tr("play_error", err))
+				}
+			} else {
+				fmt.Fprintln(os.Stderr, tr("invalid_index"))
 			}
 			continue
 
@@ -525,12 +1512,12 @@ func handleInteractiveSession(query *string, allResults *[]SearchResult, startAt
 			if index, err := strconv.Atoi(indexStr); err == nil && index > 0 && index <= len(*allResults) {
 				result := (*allResults)[index-1]
 				if opts.Clean {
-					if err := printJSONResultsClean([]SearchResult{result}, *query); err != nil {
-						fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+					if err := printJSONResultsClean([]SearchResult{result}, *query, nil); err != nil {
+						fmt.Fprintln(os.Stderr, tr("format_json_error", err))
 					}
 				} else {
-					if err := printJSONResults([]SearchResult{result}, *query); err != nil {
-						fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+					if err := printJSONResults([]SearchResult{result}, *query, nil); err != nil {
+						fmt.Fprintln(os.Stderr, tr("format_json_error", err))
 					}
 				}
 			}
@@ -540,8 +1527,8 @@ func handleInteractiveSession(query *string, allResults *[]SearchResult, startAt
 			// Check if it's a number (open result)
 			if index, err := strconv.Atoi(input); err == nil && index > 0 && index <= len(*allResults) {
 				url := (*allResults)[index-1].URL
-				if err := openURL(url); err != nil {
-					fmt.Fprintf(os.Stderr, "Error opening URL: %v\n", err)
+				if err := openResultURL(url, opts, config); err != nil {
+					fmt.Fprintln(os.Stderr, tr("open_url_error", err))
 				}
 				continue
 			}
@@ -561,37 +1548,7 @@ func handleInteractiveSession(query *string, allResults *[]SearchResult, startAt
 }
 
 func printHelp() {
-	help := `
-- Enter a search query to perform a new search.
-- Type 'n', 'p', and 'f' to navigate to the next, previous and first page of results.
-- Type the index (1, 2, 3, etc) to open the search result in a browser.
-- Type 'c' plus the index ('c 1', 'c 2') to show the result URL.
-- Type 'r timerange' to change the search time range (e.g. 'r week').
-- Type 'site:example.com' to filter results by a specific site.
-- Type 'x' to toggle showing result URLs.
-- Type 'd' to toggle debug output.
-- Type 'j' plus the index ('j 1', 'j 2') to show the JSON result for the specified index.
-- Type 'q', 'quit', or 'exit' to exit the program.
-- Type '?' for this help message.
-`
-	fmt.Print(help)
-}
-
-func openURL(url string) error {
-	var cmd *exec.Cmd
-
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("open", url)
-	case "linux":
-		cmd = exec.Command("xdg-open", url)
-	case "windows":
-		cmd = exec.Command("explorer", url)
-	default:
-		return fmt.Errorf("unsupported platform")
-	}
-
-	return cmd.Start()
+	fmt.Fprint(os.Stderr, tr("interactive_help"))
 }
 
 func isPipeInput() bool {
@@ -601,15 +1558,3 @@ func isPipeInput() bool {
 	}
 	return fileInfo.Mode()&os.ModeCharDevice == 0
 }
-
-func readFromStdin() (string, error) {
-	var input strings.Builder
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		if input.Len() > 0 {
-			input.WriteString(" ")
-		}
-		input.WriteString(scanner.Text())
-	}
-	return input.String(), scanner.Err()
-}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"sx/backends"
+)
+
+// tldrDirs lists where tldr clients keep their pages, the configured
+// directory first: tealdeer, tlrc, the Python client and the Node client.
+func tldrDirs(config *Config) []string {
+	var dirs []string
+	if dir := expandHome(config.EnginesMan.TldrDir); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	caches := []string{resolveBase(baseCache, currentPathEnv())}
+	home, _ := os.UserHomeDir()
+	if runtime.GOOS == "darwin" && home != "" {
+		caches = append(caches, filepath.Join(home, "Library", "Caches"))
+	}
+	for _, cache := range caches {
+		if cache == "" {
+			continue
+		}
+		dirs = append(dirs,
+			filepath.Join(cache, "tealdeer", "tldr-pages", "pages.en"),
+			filepath.Join(cache, "tealdeer", "tldr-master", "pages"),
+			filepath.Join(cache, "tlrc", "pages.en"),
+			filepath.Join(cache, "tldr", "pages"),
+		)
+	}
+	if home != "" {
+		dirs = append(dirs, filepath.Join(home, ".tldrc", "tldr", "pages"))
+	}
+	return dirs
+}
+
+// isDocURL reports whether rawURL is a man: or tldr: link of the man
+// backend, which sx shows in the terminal rather than the browser
+func isDocURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "man:") || strings.HasPrefix(rawURL, "tldr:")
+}
+
+// manArgs returns the man arguments for a man:name(section) link
+func manArgs(rawURL string) []string {
+	page := strings.TrimPrefix(rawURL, "man:")
+	if name, section, found := strings.Cut(page, "("); found && strings.HasSuffix(section, ")") {
+		return []string{strings.TrimSuffix(section, ")"), name}
+	}
+	return []string{page}
+}
+
+// pagerCommand is $PAGER, else less
+func pagerCommand() []string {
+	if command := strings.Fields(os.Getenv("PAGER")); len(command) > 0 {
+		return command
+	}
+	return []string{"less"}
+}
+
+// openDoc shows a man page with man, or a tldr page in the pager, waiting
+// until the reader quits
+func openDoc(rawURL string, config *Config) error {
+	if strings.HasPrefix(rawURL, "man:") {
+		cmd := exec.Command("man", manArgs(rawURL)...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		return cmd.Run()
+	}
+
+	page, err := backends.ReadTldrPage(tldrDirs(config), rawURL)
+	if err != nil {
+		return err
+	}
+	if !isTerminal(os.Stdout) {
+		_, err := os.Stdout.Write(page)
+		return err
+	}
+	command := pagerCommand()
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(page), os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pager: %v", err)
+	}
+	return nil
+}
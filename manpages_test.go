@@ -0,0 +1,22 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManArgs(t *testing.T) {
+	tests := map[string][]string{
+		"man:ls(1)":      {"1", "ls"},
+		"man:printf(3p)": {"3p", "printf"},
+		"man:git-add":    {"git-add"},
+	}
+	for url, want := range tests {
+		if got := manArgs(url); !reflect.DeepEqual(got, want) {
+			t.Errorf("manArgs(%q) = %q, want %q", url, got, want)
+		}
+	}
+	if !isDocURL("tldr:common/tar") || !isDocURL("man:ls(1)") || isDocURL("https://man7.org/") {
+		t.Error("isDocURL")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mapProviders are the built-in map URL templates selectable by name in the
+// map_url config. {lat} and {lon} are replaced by the result coordinates;
+// {query} by the result title for results without coordinates.
+var mapProviders = map[string]string{
+	"osm":    "https://www.openstreetmap.org/?mlat={lat}&mlon={lon}#map=17/{lat}/{lon}",
+	"google": "https://www.google.com/maps/search/?api=1&query={lat},{lon}",
+	"apple":  "https://maps.apple.com/?ll={lat},{lon}&q={query}",
+}
+
+var mapSearchURLs = map[string]string{
+	"osm":    "https://www.openstreetmap.org/search?query={query}",
+	"google": "https://www.google.com/maps/search/?api=1&query={query}",
+	"apple":  "https://maps.apple.com/?q={query}",
+}
+
+const defaultMapProvider = "osm"
+
+// hasCoordinates reports whether the result carries a location. SearXNG
+// leaves both at zero when the engine returned none.
+func hasCoordinates(result SearchResult) bool {
+	return result.Latitude != 0 || result.Longitude != 0
+}
+
+// mapURL builds the map link for a result from a provider name or a custom
+// template. Results without coordinates fall back to a search for the title.
+func mapURL(result SearchResult, provider string) string {
+	if provider == "" {
+		provider = defaultMapProvider
+	}
+	template := provider
+	if preset, ok := mapProviders[provider]; ok {
+		template = preset
+		if !hasCoordinates(result) {
+			template = mapSearchURLs[provider]
+		}
+	} else if !hasCoordinates(result) && !strings.Contains(template, "{query}") {
+		return result.URL
+	}
+
+	return strings.NewReplacer(
+		"{lat}", strconv.FormatFloat(result.Latitude, 'f', 6, 64),
+		"{lon}", strconv.FormatFloat(result.Longitude, 'f', 6, 64),
+		"{query}", url.QueryEscape(result.Title),
+	).Replace(template)
+}
+
+// sortByDistance orders results by distance from lat,lon, nearest first.
+// Results without coordinates keep their order after all located ones.
+func sortByDistance(results []SearchResult, lat, lon float64) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if !hasCoordinates(a) || !hasCoordinates(b) {
+			return hasCoordinates(a) && !hasCoordinates(b)
+		}
+		return distanceKm(lat, lon, a.Latitude, a.Longitude) < distanceKm(lat, lon, b.Latitude, b.Longitude)
+	})
+}
+
+// parseLatLon parses a "lat,lon" coordinate pair.
+func parseLatLon(s string) (lat, lon float64, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected lat,lon, got %q", s)
+	}
+	lat, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("invalid latitude %q", parts[0])
+	}
+	lon, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("invalid longitude %q", parts[1])
+	}
+	return lat, lon, nil
+}
+
+// distanceKm returns the great-circle distance between two points using the
+// haversine formula.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
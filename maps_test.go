@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMapURL(t *testing.T) {
+	located := SearchResult{Title: "Brandenburger Tor", URL: "https://example.com/tor", Latitude: 52.516275, Longitude: 13.377704}
+	unlocated := SearchResult{Title: "Brandenburger Tor", URL: "https://example.com/tor"}
+
+	tests := []struct {
+		name     string
+		result   SearchResult
+		provider string
+		want     string
+	}{
+		{"default osm", located, "", "https://www.openstreetmap.org/?mlat=52.516275&mlon=13.377704#map=17/52.516275/13.377704"},
+		{"google", located, "google", "https://www.google.com/maps/search/?api=1&query=52.516275,13.377704"},
+		{"preset search fallback", unlocated, "osm", "https://www.openstreetmap.org/search?query=Brandenburger+Tor"},
+		{"custom template", located, "https://maps.example/{lat}/{lon}", "https://maps.example/52.516275/13.377704"},
+		{"custom template without query", unlocated, "https://maps.example/{lat}/{lon}", "https://example.com/tor"},
+	}
+	for _, tt := range tests {
+		if got := mapURL(tt.result, tt.provider); got != tt.want {
+			t.Errorf("%s: mapURL() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseLatLon(t *testing.T) {
+	lat, lon, err := parseLatLon("52.52, 13.405")
+	if err != nil || lat != 52.52 || lon != 13.405 {
+		t.Errorf("parseLatLon() = %v, %v, %v", lat, lon, err)
+	}
+	for _, bad := range []string{"52.52", "north,13", "91,0", "0,181"} {
+		if _, _, err := parseLatLon(bad); err == nil {
+			t.Errorf("parseLatLon(%q) expected error", bad)
+		}
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	// Berlin to Paris is roughly 878 km
+	d := distanceKm(52.5200, 13.4050, 48.8566, 2.3522)
+	if math.Abs(d-878) > 5 {
+		t.Errorf("distanceKm(Berlin, Paris) = %.1f, want ~878", d)
+	}
+}
+
+func TestSortByDistance(t *testing.T) {
+	results := []SearchResult{
+		{Title: "paris", Latitude: 48.8566, Longitude: 2.3522},
+		{Title: "unknown"},
+		{Title: "potsdam", Latitude: 52.3906, Longitude: 13.0645},
+	}
+	sortByDistance(results, 52.52, 13.405)
+
+	want := []string{"potsdam", "paris", "unknown"}
+	for i, title := range want {
+		if results[i].Title != title {
+			t.Fatalf("sortByDistance() order = %v, want %v", titles(results), want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"sx/backends"
+)
+
+// querySeparator separates several queries given as arguments:
+//
+//	sx "query one" ::: "query two"
+const querySeparator = ":::"
+
+// resolveQueries returns every query of the invocation: the first one
+// assembled by resolveQuery from the arguments before the first ":::", the
+// argument groups after it, then each --query. Duplicates are dropped.
+func resolveQueries(args, extra []string, queryFile, stdinMode string) ([]string, error) {
+	groups := splitQueryArgs(args)
+	var first []string
+	if len(groups) > 0 {
+		first, groups = groups[0], groups[1:]
+	}
+
+	var queries []string
+	// With only --query given, there is nothing to read from stdin
+	if len(first) > 0 || queryFile != "" || len(extra) == 0 {
+		query, err := resolveQuery(first, queryFile, stdinMode)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, query)
+	}
+	for _, group := range groups {
+		queries = append(queries, strings.Join(group, " "))
+	}
+	queries = append(queries, extra...)
+
+	seen := make(map[string]bool)
+	kept := queries[:0]
+	for _, query := range queries {
+		query = strings.TrimSpace(query)
+		if query == "" || seen[query] {
+			continue
+		}
+		seen[query] = true
+		kept = append(kept, query)
+	}
+	return kept, nil
+}
+
+// splitQueryArgs splits args at each ":::" into one argument list per
+// query, dropping empty lists.
+func splitQueryArgs(args []string) [][]string {
+	var groups [][]string
+	var current []string
+	for _, arg := range args {
+		if arg == querySeparator {
+			if len(current) > 0 {
+				groups = append(groups, current)
+			}
+			current = nil
+			continue
+		}
+		current = append(current, arg)
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// singleQueryFlag returns the first given flag that acts on a single
+// result or page and so can't be combined with several queries.
+func singleQueryFlag(opts *SearchOptions) string {
+	flags := []struct {
+		set  bool
+		name string
+	}{
+		{opts.First, "--first"},
+		{opts.Lucky, "--lucky"},
+		{opts.OpenMap, "--open-map"},
+		{opts.Play, "--play"},
+		{opts.Download, "--download"},
+		{opts.AnswerOnly, "--answer-only"},
+		{opts.TextOnly, "--text"},
+		{opts.HTMLOnly, "--html"},
+		{opts.Interactive, "--interactive"},
+		{opts.DiffLast, "--diff-last"},
+	}
+	for _, f := range flags {
+		if f.set {
+			return f.name
+		}
+	}
+	return ""
+}
+
+// querySection holds the outcome of one query of a multi-query search.
+type querySection struct {
+	Query       string
+	Engine      string
+	Results     []SearchResult
+	StartAt     int // first result to output, as with --skip
+	Count       int // results to output, 0 for all from StartAt
+	Corrections []string
+	Suggestions []string
+	Answers     []string
+	Fallbacks   []backends.FallbackAttempt
+	RequestID   string
+	Category    string       // set by --all-categories
+	Relaxed     []relaxation // options dropped by --relax
+	Keywords    []string     // --keywords
+	Err         error
+}
+
+// printMultiResults writes the sections in the selected output format.
+// JSON merges them into one document with an object per query, or per
+// category for --all-categories; the other formats print the sections one
+// after another.
+func printMultiResults(w io.Writer, sections []querySection, opts *SearchOptions, config *Config, meta *provenance) error {
+	switch {
+	case opts.JSON:
+		group := "queries"
+		queries := make(map[string]interface{}, len(sections))
+		for _, s := range sections {
+			extra := map[string]interface{}{"engine_used": s.Engine, "request_id": s.RequestID}
+			if len(s.Fallbacks) > 0 {
+				extra["fallback_chain"] = s.Fallbacks
+			}
+			if len(s.Relaxed) > 0 {
+				extra["relaxed"] = s.Relaxed
+			}
+			if len(s.Corrections) > 0 {
+				extra["corrections"] = s.Corrections
+			}
+			if len(s.Suggestions) > 0 {
+				extra["suggestions"] = s.Suggestions
+			}
+			if len(s.Answers) > 0 {
+				extra["answers"] = s.Answers
+			}
+			if len(s.Keywords) > 0 {
+				extra["keywords"] = s.Keywords
+			}
+			if s.Err != nil {
+				extra["error"] = s.Err.Error()
+			}
+			key := s.Query
+			if s.Category != "" {
+				group, key = "categories", s.Category
+			}
+			queries[key] = jsonOutput(s.window(), s.Query, opts.Clean, extra)
+		}
+		output := map[string]interface{}{group: queries}
+		if meta != nil {
+			output["meta"] = meta
+		}
+		jsonData, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(jsonData, '\n'))
+		return err
+
+	case opts.Porcelain:
+		for _, s := range sections {
+			printPorcelain(w, s.window(), s.StartAt)
+		}
+
+	case opts.LinksOnly:
+		for _, s := range sections {
+			for _, result := range s.window() {
+				if result.URL != "" {
+					fmt.Fprintln(w, result.URL)
+				}
+			}
+		}
+
+	case opts.MagnetOnly:
+		for _, s := range sections {
+			for _, result := range s.window() {
+				if result.MagnetLink != "" {
+					fmt.Fprintln(w, result.MagnetLink)
+				}
+			}
+		}
+
+	case opts.Subscribe:
+		// One subscription list with the podcasts of every query
+		var results []SearchResult
+		var queries []string
+		for _, s := range sections {
+			results = append(results, s.window()...)
+			queries = append(queries, s.Query)
+		}
+		return writeOPML(w, results, strings.Join(queries, ", "), time.Now())
+
+	default:
+		noColor := config.NoColor || !isTerminalWriter(w)
+		// Terminals get the query banner from printResults
+		label := !isTerminalWriter(w)
+		for _, s := range sections {
+			window := s.window()
+			if len(window) == 0 {
+				continue
+			}
+			heading := s.Query
+			if s.Category != "" {
+				heading = fmt.Sprintf("%s · %s", s.Query, s.Category)
+			}
+			if label {
+				fmt.Fprintf(w, "## %s\n\n", heading)
+			}
+			if s.StartAt == 0 {
+				printAnswers(w, s.Answers, noColor)
+			}
+			printResults(w, s.Results, len(window), s.StartAt, opts.Expand, noColor, config.SnippetWords, config.ShortDomains, heading)
+			printKeywords(w, s.Keywords, noColor)
+		}
+	}
+	return nil
+}
+
+func (s querySection) window() []SearchResult {
+	return resultWindow(s.Results, s.StartAt, s.Count)
+}
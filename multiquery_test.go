@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveQueries(t *testing.T) {
+	got, err := resolveQueries([]string{"query", "one", ":::", "query two", ":::", ":::"}, []string{"three", "query two"}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"query one", "query two", "three"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveQueries() = %q, want %q", got, want)
+	}
+
+	got, err = resolveQueries(nil, []string{"only flag"}, "", "")
+	if err != nil || !reflect.DeepEqual(got, []string{"only flag"}) {
+		t.Errorf("--query alone = %q, %v", got, err)
+	}
+}
+
+func TestPrintMultiResultsJSON(t *testing.T) {
+	sections := []querySection{
+		{Query: "go", Results: []SearchResult{{Title: "Go", URL: "https://go.dev"}}},
+		{Query: "rust", Results: []SearchResult{{Title: "Rust", URL: "https://rust-lang.org"}}},
+	}
+	var buf bytes.Buffer
+	if err := printMultiResults(&buf, sections, &SearchOptions{JSON: true, Clean: true}, getDefaultConfig(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var out struct {
+		Queries map[string]struct {
+			Results []struct {
+				URL string `json:"url"`
+			} `json:"results"`
+		} `json:"queries"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(out.Queries) != 2 || out.Queries["rust"].Results[0].URL != "https://rust-lang.org" {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestPrintMultiResultsLabelsSections(t *testing.T) {
+	sections := []querySection{
+		{Query: "go", Results: []SearchResult{{Title: "Go", URL: "https://go.dev"}}},
+		{Query: "rust", Results: []SearchResult{{Title: "Rust", URL: "https://rust-lang.org"}}},
+	}
+	var buf bytes.Buffer
+	if err := printMultiResults(&buf, sections, &SearchOptions{}, getDefaultConfig(), nil); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "## go\n") || strings.Index(out, "## rust\n") < strings.Index(out, "https://go.dev") {
+		t.Errorf("sections not labeled in order:\n%s", out)
+	}
+}
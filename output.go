@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxRotatedOutputs is how many size-rotated copies of an output file are
+// kept (results.log.1 ... results.log.5).
+const maxRotatedOutputs = 5
+
+// outputOptions controls how -o files are opened.
+type outputOptions struct {
+	Append    bool   // add to the file instead of replacing it
+	IfResults bool   // only create the file once something is written
+	Rotate    string // "daily" or a size such as "10MB"; implies Append
+}
+
+// fileOutput holds the --append, --output-if-results and --rotate flags.
+var fileOutput outputOptions
+
+// createOutput opens path for writing according to fileOutput.
+func createOutput(path string) (io.WriteCloser, error) {
+	return openOutput(path, fileOutput, time.Now())
+}
+
+// closeOutput closes an output file from a deferred call, reporting a
+// failed close through err unless the function already failed.
+func closeOutput(file io.Closer, err *error) {
+	if cerr := file.Close(); *err == nil {
+		*err = cerr
+	}
+}
+
+// openOutput opens path for writing. Replaced files are written atomically
+// through a temporary file, so an interrupted run never leaves a truncated
+// file behind; appended files and devices like /dev/stdout are written in
+// place.
+func openOutput(path string, opts outputOptions, now time.Time) (io.WriteCloser, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	atomic := true
+	if opts.Append || opts.Rotate != "" {
+		if err := rotateOutput(path, opts.Rotate, now); err != nil {
+			return nil, fmt.Errorf("failed to rotate output file: %v", err)
+		}
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		atomic = false
+	} else if info, err := os.Stat(path); err == nil && !info.Mode().IsRegular() {
+		atomic = false
+	}
+
+	if atomic {
+		file := &atomicFile{path: path}
+		if !opts.IfResults {
+			if err := file.open(); err != nil {
+				return nil, err
+			}
+		}
+		return file, nil
+	}
+	if opts.IfResults {
+		return &lazyFile{path: path, flags: flags}, nil
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %v", err)
+	}
+	return file, nil
+}
+
+// atomicFile writes to a temporary file next to path and renames it over
+// path on Close. Until then the previous contents stay intact. The
+// temporary file is created on the first write unless open is called.
+type atomicFile struct {
+	path string
+	tmp  *os.File
+	err  error // first write error; the output is discarded on Close
+}
+
+func (a *atomicFile) open() error {
+	// Replace the target of a symlink, not the link itself
+	if target, err := filepath.EvalSymlinks(a.path); err == nil {
+		a.path = target
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(a.path), "."+filepath.Base(a.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+	a.tmp = tmp
+	trackTempOutput(tmp.Name())
+	return nil
+}
+
+func (a *atomicFile) Write(p []byte) (int, error) {
+	if a.err != nil {
+		return 0, a.err
+	}
+	if a.tmp == nil {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		if err := a.open(); err != nil {
+			a.err = err
+			return 0, err
+		}
+	}
+	n, err := a.tmp.Write(p)
+	if err != nil {
+		a.err = err
+	}
+	return n, err
+}
+
+func (a *atomicFile) Close() error {
+	if a.tmp == nil {
+		return a.err
+	}
+	name := a.tmp.Name()
+	defer untrackTempOutput(name)
+
+	err := a.err
+	if closeErr := a.tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// CreateTemp uses 0600; keep the mode of the file being replaced
+		mode := os.FileMode(0644)
+		if info, statErr := os.Stat(a.path); statErr == nil {
+			mode = info.Mode().Perm()
+		}
+		err = os.Chmod(name, mode)
+	}
+	if err == nil {
+		err = os.Rename(name, a.path)
+	}
+	if err != nil {
+		os.Remove(name)
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+var (
+	tempOutputsMu sync.Mutex
+	tempOutputs   = map[string]bool{}
+	tempSignals   chan os.Signal // caught while there are temporary outputs
+)
+
+// trackTempOutput remembers a temporary output file so an interrupt can
+// remove it before exiting.
+func trackTempOutput(name string) {
+	tempOutputsMu.Lock()
+	defer tempOutputsMu.Unlock()
+	if tempSignals == nil {
+		tempSignals = make(chan os.Signal, 1)
+		signal.Notify(tempSignals, os.Interrupt, syscall.SIGTERM)
+		go removeTempOutputsOn(tempSignals)
+	}
+	tempOutputs[name] = true
+}
+
+// untrackTempOutput forgets a temporary output file, and once none are
+// left restores the default handling of interrupts.
+func untrackTempOutput(name string) {
+	tempOutputsMu.Lock()
+	defer tempOutputsMu.Unlock()
+	delete(tempOutputs, name)
+	if len(tempOutputs) == 0 && tempSignals != nil {
+		signal.Stop(tempSignals)
+		close(tempSignals)
+		tempSignals = nil
+	}
+}
+
+// removeTempOutputsOn removes the temporary outputs when a signal arrives
+// and exits with the status the signal would have given, 130 for an
+// interrupt and 143 for SIGTERM. It returns when signals is closed.
+func removeTempOutputsOn(signals chan os.Signal) {
+	sig, ok := <-signals
+	if !ok {
+		return
+	}
+	tempOutputsMu.Lock()
+	for name := range tempOutputs {
+		os.Remove(name)
+	}
+	os.Exit(signalExitStatus(sig))
+}
+
+// signalExitStatus is the shell's exit status for a process killed by sig.
+func signalExitStatus(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// lazyFile creates its file on the first write, so appended outputs that
+// end up empty leave nothing behind.
+type lazyFile struct {
+	path  string
+	flags int
+	file  *os.File
+}
+
+func (l *lazyFile) Write(p []byte) (int, error) {
+	if l.file == nil {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		file, err := os.OpenFile(l.path, l.flags, 0644)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create output file: %v", err)
+		}
+		l.file = file
+	}
+	return l.file.Write(p)
+}
+
+func (l *lazyFile) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// validateRotate checks a --rotate value.
+func validateRotate(spec string) error {
+	if spec == "" || spec == "daily" {
+		return nil
+	}
+	_, err := parseSize(spec)
+	return err
+}
+
+// rotateOutput moves an existing output file aside before appending to
+// it: with "daily" when it was last written on an earlier day (to
+// name.2006-01-02.ext), with a size once it has reached that size (to
+// name.1, shifting older copies up).
+func rotateOutput(path, spec string, now time.Time) error {
+	if spec == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if spec == "daily" {
+		day := info.ModTime().In(now.Location()).Format("2006-01-02")
+		if day == now.Format("2006-01-02") {
+			return nil
+		}
+		ext := filepath.Ext(path)
+		return os.Rename(path, strings.TrimSuffix(path, ext)+"."+day+ext)
+	}
+
+	limit, err := parseSize(spec)
+	if err != nil {
+		return err
+	}
+	if info.Size() < limit {
+		return nil
+	}
+	os.Remove(fmt.Sprintf("%s.%d", path, maxRotatedOutputs))
+	for i := maxRotatedOutputs - 1; i >= 1; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(path, path+".1")
+}
+
+// parseSize parses sizes like "512", "64K", "10MB" or "1GiB" (binary units).
+func parseSize(s string) (int64, error) {
+	spec := strings.ToUpper(strings.TrimSpace(s))
+	spec = strings.TrimSuffix(strings.TrimSuffix(spec, "IB"), "B")
+	multiplier := int64(1)
+	if n := len(spec); n > 0 {
+		switch spec[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			spec = spec[:n-1]
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(spec), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 512K, 10MB or daily)", s)
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func writeOutput(t *testing.T, path string, opts outputOptions, now time.Time, data string) {
+	t.Helper()
+	out, err := openOutput(path, opts, now)
+	if err != nil {
+		t.Fatalf("openOutput: %v", err)
+	}
+	if _, err := io.WriteString(out, data); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func readOutput(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestOpenOutputTruncatesAndAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	now := time.Now()
+
+	writeOutput(t, path, outputOptions{}, now, "one\n")
+	writeOutput(t, path, outputOptions{}, now, "two\n")
+	if got := readOutput(t, path); got != "two\n" {
+		t.Errorf("replaced file = %q", got)
+	}
+	writeOutput(t, path, outputOptions{Append: true}, now, "three\n")
+	if got := readOutput(t, path); got != "two\nthree\n" {
+		t.Errorf("appended file = %q", got)
+	}
+}
+
+func TestOpenOutputIfResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+
+	writeOutput(t, path, outputOptions{IfResults: true}, time.Now(), "")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("empty output created a file: %v", err)
+	}
+	writeOutput(t, path, outputOptions{IfResults: true}, time.Now(), "data\n")
+	if got := readOutput(t, path); got != "data\n" {
+		t.Errorf("file = %q", got)
+	}
+}
+
+func TestOpenOutputIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+	writeOutput(t, path, outputOptions{}, time.Now(), "old\n")
+
+	out, err := openOutput(path, outputOptions{}, time.Now())
+	if err != nil {
+		t.Fatalf("openOutput: %v", err)
+	}
+	io.WriteString(out, "partial")
+	if got := readOutput(t, path); got != "old\n" {
+		t.Errorf("file changed before close: %q", got)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if got := readOutput(t, path); got != "partial" {
+		t.Errorf("file after close = %q", got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestTempOutputSignalHandling(t *testing.T) {
+	dir := t.TempDir()
+	first, err := openOutput(filepath.Join(dir, "a.txt"), outputOptions{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := openOutput(filepath.Join(dir, "b.txt"), outputOptions{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	watching := func() bool {
+		tempOutputsMu.Lock()
+		defer tempOutputsMu.Unlock()
+		return tempSignals != nil
+	}
+	if !watching() {
+		t.Fatal("interrupts aren't caught while writing")
+	}
+	first.Close()
+	if !watching() {
+		t.Error("stopped catching interrupts with an output still open")
+	}
+	second.Close()
+	if watching() {
+		t.Error("still catching interrupts after the last output was closed")
+	}
+}
+
+func TestSignalExitStatus(t *testing.T) {
+	if got := signalExitStatus(os.Interrupt); got != 130 {
+		t.Errorf("interrupt: %d", got)
+	}
+	if got := signalExitStatus(syscall.SIGTERM); got != 143 {
+		t.Errorf("SIGTERM: %d", got)
+	}
+}
+
+func TestRotateOutputBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	opts := outputOptions{Rotate: "10B"}
+	now := time.Now()
+
+	writeOutput(t, path, opts, now, "first-line\n") // 11 bytes, over the limit
+	writeOutput(t, path, opts, now, "second\n")     // rotates first-line
+	writeOutput(t, path, opts, now, "third\n")      // 7 bytes so far, appends
+	writeOutput(t, path, opts, now, "fourth\n")     // rotates again
+
+	if got := readOutput(t, path); got != "fourth\n" {
+		t.Errorf("current file = %q", got)
+	}
+	if got := readOutput(t, path+".1"); got != "second\nthird\n" {
+		t.Errorf("first rotation = %q", got)
+	}
+	if got := readOutput(t, path+".2"); got != "first-line\n" {
+		t.Errorf("second rotation = %q", got)
+	}
+}
+
+func TestRotateOutputDaily(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.jsonl")
+	opts := outputOptions{Rotate: "daily"}
+	yesterday := time.Date(2024, 3, 9, 23, 0, 0, 0, time.Local)
+	today := yesterday.Add(2 * time.Hour)
+
+	writeOutput(t, path, opts, yesterday, "old\n")
+	if err := os.Chtimes(path, yesterday, yesterday); err != nil {
+		t.Fatal(err)
+	}
+	writeOutput(t, path, opts, today, "new\n")
+
+	if got := readOutput(t, path); got != "new\n" {
+		t.Errorf("current file = %q", got)
+	}
+	if got := readOutput(t, filepath.Join(dir, "log.2024-03-09.jsonl")); got != "old\n" {
+		t.Errorf("rotated file = %q", got)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{"512": 512, "64K": 64 << 10, "10MB": 10 << 20, "1GiB": 1 << 30, "2kb": 2 << 10}
+	for in, want := range tests {
+		if got, err := parseSize(in); err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "MB", "-1K", "ten"} {
+		if _, err := parseSize(bad); err == nil {
+			t.Errorf("parseSize(%q) succeeded", bad)
+		}
+	}
+}
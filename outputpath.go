@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// outputPlaceholder matches {{name}} and {{name|filter|...}} in -o paths.
+var outputPlaceholder = regexp.MustCompile(`\{\{\s*([\w.-]*)\s*((?:\|\s*\w+\s*)*)\}\}`)
+
+// maxSlugLength keeps {{query|slug}} file names manageable.
+const maxSlugLength = 80
+
+// outputFilters transform placeholder values, e.g. {{query|slug}}.
+var outputFilters = map[string]func(string) string{
+	"slug":  func(s string) string { return slugify(s, maxSlugLength) },
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// outputVars returns the values available to -o path templates.
+func outputVars(query, engine string, now time.Time) map[string]string {
+	return map[string]string{
+		"query":     query,
+		"engine":    engine,
+		"date":      now.Format("2006-01-02"),
+		"time":      now.Format("150405"),
+		"datetime":  now.Format("20060102-150405"),
+		"timestamp": strconv.FormatInt(now.Unix(), 10),
+	}
+}
+
+// expandOutputPath fills the {{...}} placeholders in an -o path, such as
+// out/{{date}}/{{query|slug}}.json. Paths without placeholders are returned
+// unchanged; unknown names and filters are errors.
+func expandOutputPath(path string, vars map[string]string) (string, error) {
+	var expandErr error
+	expanded := outputPlaceholder.ReplaceAllStringFunc(path, func(m string) string {
+		parts := outputPlaceholder.FindStringSubmatch(m)
+		value, ok := vars[parts[1]]
+		if !ok {
+			if expandErr == nil {
+				expandErr = fmt.Errorf("unknown placeholder {{%s}} in output path", parts[1])
+			}
+			return m
+		}
+		for _, name := range strings.Split(parts[2], "|")[1:] {
+			name = strings.TrimSpace(name)
+			filter, ok := outputFilters[name]
+			if !ok {
+				if expandErr == nil {
+					expandErr = fmt.Errorf("unknown filter %q in output path", name)
+				}
+				return m
+			}
+			value = filter(value)
+		}
+		// Values become part of a path; keep them from adding directories
+		return strings.NewReplacer("/", "-", "\\", "-").Replace(value)
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	if strings.Contains(expanded, "{{") {
+		return "", fmt.Errorf("malformed placeholder in output path %q", path)
+	}
+	return expanded, nil
+}
+
+// prepareOutputPath expands an -o template and creates the directories it
+// names, so templated outputs can sort themselves into fresh folders.
+func prepareOutputPath(template string, vars map[string]string) (string, error) {
+	path, err := expandOutputPath(template, vars)
+	if err != nil || path == template {
+		return path, err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create output directory: %v", err)
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpandOutputPath(t *testing.T) {
+	now := time.Date(2024, 3, 9, 14, 5, 6, 0, time.UTC)
+	vars := outputVars("Go Generics: a/b tour", "searxng", now)
+
+	tests := []struct {
+		path, want string
+	}{
+		{"results.json", "results.json"},
+		{"out/{{date}}/{{query|slug}}.json", "out/2024-03-09/go-generics-a-b-tour.json"},
+		{"{{ engine | upper }}-{{datetime}}.txt", "SEARXNG-20240309-140506.txt"},
+		{"{{query}}.md", "Go Generics: a-b tour.md"},
+		{"{{timestamp}}", "1709993106"},
+	}
+	for _, tt := range tests {
+		got, err := expandOutputPath(tt.path, vars)
+		if err != nil || got != tt.want {
+			t.Errorf("expandOutputPath(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"{{nope}}.json", "{{query|shout}}.json", "{{query.json"} {
+		if _, err := expandOutputPath(bad, vars); err == nil {
+			t.Errorf("expandOutputPath(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestPrepareOutputPathCreatesDirectories(t *testing.T) {
+	dir := t.TempDir()
+	vars := outputVars("cats", "bing", time.Now())
+
+	path, err := prepareOutputPath(filepath.Join(dir, "{{engine}}", "{{query}}.json"), vars)
+	if err != nil {
+		t.Fatalf("prepareOutputPath: %v", err)
+	}
+	if want := filepath.Join(dir, "bing", "cats.json"); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+		t.Errorf("output directory not created: %v", err)
+	}
+}
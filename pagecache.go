@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// pageCacheMaxBody is the largest page kept in the cache
+	pageCacheMaxBody = 10 << 20
+	// pageCacheMaxAge is how long unused entries are kept
+	pageCacheMaxAge = 30 * 24 * time.Hour
+)
+
+// pageCacheEntry is the metadata stored next to a cached page body.
+type pageCacheEntry struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"` // Content-Type and Content-Encoding
+}
+
+// pageCacheTransport makes GET requests conditional on the validators
+// (ETag, Last-Modified) of the copy fetched last time, and answers a 304 Not
+// Modified with that copy, so fetching unchanged pages again costs almost
+// no bandwidth.
+type pageCacheTransport struct {
+	base http.RoundTripper
+	dir  string
+}
+
+var pageCachePruneOnce sync.Once
+
+// withPageCache wraps rt with the page cache when page_cache is enabled.
+func withPageCache(rt http.RoundTripper, config *Config) http.RoundTripper {
+	dir := pageCacheDir()
+	if !config.PageCache || dir == "" {
+		return rt
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	pageCachePruneOnce.Do(func() { prunePageCache(dir, time.Now()) })
+	return &pageCacheTransport{base: rt, dir: dir}
+}
+
+// pageCacheDir is where fetched pages are cached, or "" if the cache
+// directory can't be resolved.
+func pageCacheDir() string {
+	dir := appDir(baseCache)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "pages")
+}
+
+func (t *pageCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests that carry their own validators are left alone
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	key := pageCacheKey(req)
+	entry, body, cached := t.load(key)
+	if cached {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached {
+		resp.Body.Close()
+		now := time.Now()
+		os.Chtimes(filepath.Join(t.dir, key+".json"), now, now)
+		header := entry.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		if cached {
+			os.Remove(filepath.Join(t.dir, key+".json"))
+		}
+		return resp, nil
+	}
+
+	// Read the page to store it; the caller gets the same bytes
+	data, err := io.ReadAll(io.LimitReader(resp.Body, pageCacheMaxBody+1))
+	rest := resp.Body
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), rest), rest}
+	if err != nil || len(data) > pageCacheMaxBody {
+		return resp, nil
+	}
+	header := http.Header{}
+	for _, name := range []string{"Content-Type", "Content-Encoding"} {
+		if value := resp.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	t.store(key, pageCacheEntry{URL: req.URL.String(), ETag: etag, LastModified: lastModified, Header: header}, data)
+	return resp, nil
+}
+
+// pageCacheKey identifies a cached page by URL and Accept-Encoding, since
+// a page requested with gzip is stored compressed.
+func pageCacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept-Encoding")))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (t *pageCacheTransport) load(key string) (pageCacheEntry, []byte, bool) {
+	var entry pageCacheEntry
+	meta, err := os.ReadFile(filepath.Join(t.dir, key+".json"))
+	if err != nil || json.Unmarshal(meta, &entry) != nil {
+		return entry, nil, false
+	}
+	body, err := os.ReadFile(filepath.Join(t.dir, key+".body"))
+	if err != nil {
+		return entry, nil, false
+	}
+	return entry, body, true
+}
+
+// store saves a page, the body first so that metadata never points to a
+// missing or partial body. Failures only mean the page isn't cached.
+func (t *pageCacheTransport) store(key string, entry pageCacheEntry, body []byte) {
+	meta, err := json.Marshal(entry)
+	if err != nil || os.MkdirAll(t.dir, 0o700) != nil {
+		return
+	}
+	os.Remove(filepath.Join(t.dir, key+".json"))
+	if os.WriteFile(filepath.Join(t.dir, key+".body"), body, 0o600) != nil {
+		return
+	}
+	os.WriteFile(filepath.Join(t.dir, key+".json"), meta, 0o600)
+}
+
+// prunePageCache removes entries that haven't been used for
+// pageCacheMaxAge; a page's metadata file is touched whenever it is used.
+func prunePageCache(dir string, now time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || now.Sub(info.ModTime()) < pageCacheMaxAge {
+			continue
+		}
+		key := strings.TrimSuffix(name, ".json")
+		os.Remove(filepath.Join(dir, name))
+		os.Remove(filepath.Join(dir, key+".body"))
+	}
+}
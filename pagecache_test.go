@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPageCacheRevalidates(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<p>page</p>")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &pageCacheTransport{base: http.DefaultTransport, dir: t.TempDir()}}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "<p>page</p>" || resp.Header.Get("Content-Type") != "text/html" {
+			t.Errorf("fetch %d: %d %q %v", i+1, resp.StatusCode, body, resp.Header)
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Errorf("requests = %d, not modified = %d; want 2, 1", requests, notModified)
+	}
+}
+
+func TestPrunePageCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for key, age := range map[string]time.Duration{"old": 40 * 24 * time.Hour, "new": time.Hour} {
+		for _, ext := range []string{".json", ".body"} {
+			path := filepath.Join(dir, key+ext)
+			os.WriteFile(path, []byte("{}"), 0o600)
+			os.Chtimes(path, now.Add(-age), now.Add(-age))
+		}
+	}
+	prunePageCache(dir, now)
+	if _, err := os.Stat(filepath.Join(dir, "old.body")); !os.IsNotExist(err) {
+		t.Error("stale entry was kept")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.json")); err != nil {
+		t.Error("recent entry was removed")
+	}
+}
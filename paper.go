@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxPaperBytes caps a downloaded PDF.
+const maxPaperBytes = 100 << 20
+
+var (
+	arxivAbsPattern = regexp.MustCompile(`^https?://(?:www\.|export\.)?arxiv\.org/(?:abs|pdf)/([^?#]+?)(?:\.pdf)?/?$`)
+	arxivDOIPattern = regexp.MustCompile(`(?i)^10\.48550/arxiv\.(.+)$`)
+	rxivPattern     = regexp.MustCompile(`^(https?://(?:www\.)?(?:biorxiv|medrxiv)\.org/content/10\.1101/[^?#]+?)(?:\.full|\.abstract)?(?:\.pdf)?/?$`)
+	pmcPattern      = regexp.MustCompile(`^https?://(?:www\.)?(?:ncbi\.nlm\.nih\.gov/pmc|pmc\.ncbi\.nlm\.nih\.gov)/articles/(PMC\d+)`)
+	openReviewURL   = regexp.MustCompile(`^https?://openreview\.net/forum\?id=([^&#]+)`)
+	aclAnthologyURL = regexp.MustCompile(`^https?://aclanthology\.org/([^/?#]+?)/?$`)
+)
+
+// paperPDFLinks lists the URLs the PDF of a science result may be
+// downloaded from, best first: the PDF links the engine and Unpaywall
+// reported, the PDF a preprint server or repository serves for the landing
+// page, and the result URL itself when it already points at a PDF.
+func paperPDFLinks(result SearchResult) []string {
+	var links []string
+	seen := map[string]bool{}
+	add := func(link string) {
+		if link != "" && !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+
+	add(result.PDFURL)
+	if result.OpenAccess != nil {
+		add(result.OpenAccess.PDFURL)
+	}
+	if m := arxivDOIPattern.FindStringSubmatch(result.DOI); m != nil {
+		add("https://arxiv.org/pdf/" + m[1])
+	}
+	u := result.URL
+	switch {
+	case arxivAbsPattern.MatchString(u):
+		add("https://arxiv.org/pdf/" + arxivAbsPattern.FindStringSubmatch(u)[1])
+	case rxivPattern.MatchString(u):
+		add(rxivPattern.FindStringSubmatch(u)[1] + ".full.pdf")
+	case pmcPattern.MatchString(u):
+		add("https://pmc.ncbi.nlm.nih.gov/articles/" + pmcPattern.FindStringSubmatch(u)[1] + "/pdf/")
+	case openReviewURL.MatchString(u):
+		add("https://openreview.net/pdf?id=" + openReviewURL.FindStringSubmatch(u)[1])
+	case aclAnthologyURL.MatchString(u):
+		add("https://aclanthology.org/" + aclAnthologyURL.FindStringSubmatch(u)[1] + ".pdf")
+	}
+	if parsed, err := url.Parse(u); err == nil && strings.HasSuffix(strings.ToLower(parsed.Path), ".pdf") {
+		add(u)
+	}
+	return links
+}
+
+// paperFile names the downloaded PDF of a paper after its title.
+func paperFile(dir, title string) string {
+	return filepath.Join(dir, slugify(title, 80)+".pdf")
+}
+
+// downloadPaper fetches the PDF at link into path. Landing pages and
+// login walls served in place of a PDF are rejected by their content.
+func downloadPaper(client *http.Client, link, path string) error {
+	req, err := setupHTTPRequest(http.MethodGet, link, config)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/pdf,*/*;q=0.8")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPaperBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxPaperBytes {
+		return fmt.Errorf("larger than %d MB", maxPaperBytes>>20)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return fmt.Errorf("not a PDF (%s)", resp.Header.Get("Content-Type"))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// runPaper searches the science category for title and downloads the PDF
+// of the first result that has one into dir, then opens it unless noOpen.
+// With printOnly it prints the PDF link instead. When no result links to a
+// PDF, the first result's page is opened so the paper can be found by
+// hand.
+func runPaper(title, dir string, printOnly, noOpen bool) error {
+	installTransports(config)
+	backendMgr = initBackendManager(config)
+
+	opts := SearchOptions{Categories: []string{"science"}, SafeSearch: config.SafeSearch, PageNo: 1}
+	if err := applyProfile(config, "", &opts, false); err != nil {
+		return err
+	}
+	state := searchState{Query: title}
+	results, err := fetchResults(&state, nil, config.ResultCount, &opts, config)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no papers found for %q", title)
+	}
+	if unpaywallEmail(config) != "" {
+		resolveOpenAccess(results, false, config)
+	}
+
+	client := setupHTTPClient(config)
+	for _, result := range results {
+		links := paperPDFLinks(result)
+		if printOnly && len(links) > 0 {
+			fmt.Println(links[0])
+			return nil
+		}
+		for _, link := range links {
+			path := paperFile(dir, result.Title)
+			if err := downloadPaper(client, link, path); err != nil {
+				printNotice("Skipping %s: %v", link, err)
+				continue
+			}
+			fmt.Println(path)
+			if noOpen {
+				return nil
+			}
+			return openURL(path, config)
+		}
+	}
+
+	first := results[0]
+	if printOnly {
+		return fmt.Errorf("no PDF found; the best match is %s", first.URL)
+	}
+	printNotice("No PDF found; opening %s", first.URL)
+	if noOpen {
+		return nil
+	}
+	return openURL(first.URL, config)
+}
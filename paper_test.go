@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPaperPDFLinks(t *testing.T) {
+	tests := []struct {
+		result SearchResult
+		want   []string
+	}{
+		{SearchResult{URL: "https://arxiv.org/abs/1706.03762v7"}, []string{"https://arxiv.org/pdf/1706.03762v7"}},
+		{SearchResult{URL: "https://arxiv.org/abs/hep-th/9711200"}, []string{"https://arxiv.org/pdf/hep-th/9711200"}},
+		{
+			SearchResult{URL: "https://example.org/landing", PDFURL: "https://example.org/a.pdf", DOI: "10.48550/arXiv.1706.03762"},
+			[]string{"https://example.org/a.pdf", "https://arxiv.org/pdf/1706.03762"},
+		},
+		{SearchResult{URL: "https://www.biorxiv.org/content/10.1101/2020.03.01.123456v2"}, []string{"https://www.biorxiv.org/content/10.1101/2020.03.01.123456v2.full.pdf"}},
+		{SearchResult{URL: "https://www.ncbi.nlm.nih.gov/pmc/articles/PMC1234567/"}, []string{"https://pmc.ncbi.nlm.nih.gov/articles/PMC1234567/pdf/"}},
+		{SearchResult{URL: "https://openreview.net/forum?id=abc123"}, []string{"https://openreview.net/pdf?id=abc123"}},
+		{SearchResult{URL: "https://aclanthology.org/N19-1423/"}, []string{"https://aclanthology.org/N19-1423.pdf"}},
+		{SearchResult{URL: "https://example.org/files/Paper.PDF?dl=1"}, []string{"https://example.org/files/Paper.PDF?dl=1"}},
+		{SearchResult{URL: "https://doi.org/10.1038/nature14539"}, nil},
+	}
+	for _, tt := range tests {
+		if got := paperPDFLinks(tt.result); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("paperPDFLinks(%+v) = %q, want %q", tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestPaperFile(t *testing.T) {
+	if got := paperFile("papers", "Attention Is All You Need"); got != filepath.Join("papers", "attention-is-all-you-need.pdf") {
+		t.Errorf("got %q", got)
+	}
+	if got := paperFile(".", "?!"); got != "untitled.pdf" {
+		t.Errorf("untitled: got %q", got)
+	}
+}
+
+func TestDownloadPaperRejectsNonPDF(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/paper.pdf":
+			w.Write([]byte("%PDF-1.7\n..."))
+		case "/missing.pdf":
+			http.NotFound(w, r)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html>Sign in</html>"))
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "paper.pdf")
+	if err := downloadPaper(server.Client(), server.URL+"/paper.pdf", path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "%PDF-1.7\n..." {
+		t.Errorf("saved %q", data)
+	}
+	for _, p := range []string{"/login", "/missing.pdf"} {
+		if err := downloadPaper(server.Client(), server.URL+p, filepath.Join(dir, "other.pdf")); err == nil {
+			t.Errorf("%s: downloaded", p)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.pdf")); !os.IsNotExist(err) {
+		t.Error("rejected download left a file")
+	}
+}
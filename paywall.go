@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// paywalledDomains are news and magazine sites known to put most of their
+// articles behind a paywall. Domains tagged "paywalled" in the
+// domain_tags config are added to them.
+var paywalledDomains = []string{
+	"barrons.com",
+	"bloomberg.com",
+	"economist.com",
+	"faz.net",
+	"ft.com",
+	"handelsblatt.com",
+	"hbr.org",
+	"nytimes.com",
+	"newyorker.com",
+	"sueddeutsche.de",
+	"telegraph.co.uk",
+	"theatlantic.com",
+	"thetimes.co.uk",
+	"washingtonpost.com",
+	"wsj.com",
+}
+
+// waybackAPI finds the latest copy the Internet Archive has of a page.
+var waybackAPI = "https://archive.org/wayback/available"
+
+// accessibleForFree matches the schema.org markup publishers use to tell
+// search engines an article is paywalled.
+var accessibleForFree = regexp.MustCompile(`(?i)"isAccessibleForFree"\s*:\s*"?false"?`)
+
+// onPaywalledDomain reports whether rawURL is on a known paywalled site or
+// one the config tags "paywalled".
+func onPaywalledDomain(rawURL string, config *Config) bool {
+	host := extractDomain(rawURL, false)
+	return matchesAnySite(host, paywalledDomains) || matchesAnySite(host, config.DomainTags["paywalled"])
+}
+
+// pagePaywalled reports whether a page marks its content as paywalled:
+// with isAccessibleForFree false in its structured data or a locked or
+// metered article:content_tier.
+func pagePaywalled(doc *goquery.Document) bool {
+	paywalled := false
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, sel *goquery.Selection) bool {
+		paywalled = accessibleForFree.MatchString(sel.Text())
+		return !paywalled
+	})
+	if paywalled {
+		return true
+	}
+	doc.Find(`meta[property="article:content_tier"], meta[itemprop="isAccessibleForFree"]`).EachWithBreak(func(_ int, sel *goquery.Selection) bool {
+		switch strings.ToLower(strings.TrimSpace(sel.AttrOr("content", ""))) {
+		case "locked", "metered", "false":
+			paywalled = true
+		}
+		return !paywalled
+	})
+	return paywalled
+}
+
+// htmlPaywalled is pagePaywalled for a page's HTML.
+func htmlPaywalled(html []byte) bool {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	return err == nil && pagePaywalled(doc)
+}
+
+// archiveCopies holds the archived copy of each page looked up this run,
+// "" for pages the Internet Archive has no copy of.
+var archiveCopies = struct {
+	sync.Mutex
+	urls map[string]string
+}{urls: map[string]string{}}
+
+// archivedCopy returns the URL of the latest copy of rawURL in the Wayback
+// Machine, or "" if there is none.
+func archivedCopy(client *http.Client, rawURL string) (string, error) {
+	archiveCopies.Lock()
+	copyURL, ok := archiveCopies.urls[rawURL]
+	archiveCopies.Unlock()
+	if ok {
+		return copyURL, nil
+	}
+
+	resp, err := client.Get(waybackAPI + "?url=" + url.QueryEscape(rawURL))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Wayback Machine: HTTP %d", resp.StatusCode)
+	}
+	var data struct {
+		ArchivedSnapshots struct {
+			Closest struct {
+				Available bool   `json:"available"`
+				URL       string `json:"url"`
+				Status    string `json:"status"`
+			} `json:"closest"`
+		} `json:"archived_snapshots"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return "", fmt.Errorf("Wayback Machine: failed to parse response: %v", err)
+	}
+	closest := data.ArchivedSnapshots.Closest
+	if closest.Available && closest.Status == "200" {
+		// The API gives http:// links to its https:// site
+		copyURL = closest.URL
+		if rest, ok := strings.CutPrefix(copyURL, "http://"); ok {
+			copyURL = "https://" + rest
+		}
+	}
+	archiveCopies.Lock()
+	archiveCopies.urls[rawURL] = copyURL
+	archiveCopies.Unlock()
+	return copyURL, nil
+}
+
+// enrichPaywalls marks results on paywalled sites or whose pages say they
+// are paywalled. With archive_paywalled, paywalled results get the link
+// to their latest archived copy.
+func enrichPaywalls(results []SearchResult, config *Config) {
+	eachResultPage(results, config, func(result *SearchResult, page *pageInfo) {
+		result.Paywalled = onPaywalledDomain(result.URL, config) || page != nil && page.Paywalled
+	})
+	if !config.ArchivePaywalled {
+		return
+	}
+	client := setupHTTPClient(config)
+	sem := make(chan struct{}, maxPageFetches)
+	var wg sync.WaitGroup
+	for i := range results {
+		if !results[i].Paywalled {
+			continue
+		}
+		wg.Add(1)
+		go func(result *SearchResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			copyURL, err := archivedCopy(client, result.URL)
+			if err != nil && config.Debug {
+				fmt.Fprintf(os.Stderr, "Debug: archive %s: %v\n", result.URL, err)
+			}
+			result.ArchiveURL = copyURL
+		}(&results[i])
+	}
+	wg.Wait()
+}
+
+// resultBadges are the badges of a result's header line: its domain tags
+// and whether it is paywalled.
+func resultBadges(result SearchResult) []string {
+	badges := result.DomainTags
+	if result.Paywalled && !slices.Contains(badges, "paywalled") {
+		badges = append(slices.Clone(badges), "paywalled")
+	}
+	return badges
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPagePaywalled(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want bool
+	}{
+		{"structured data", `<script type="application/ld+json">{"@type": "NewsArticle", "isAccessibleForFree": "False"}</script>`, true},
+		{"free structured data", `<script type="application/ld+json">{"@type": "NewsArticle", "isAccessibleForFree": true}</script>`, false},
+		{"content tier", `<meta property="article:content_tier" content="locked">`, true},
+		{"free content tier", `<meta property="article:content_tier" content="free">`, false},
+		{"microdata", `<meta itemprop="isAccessibleForFree" content="false">`, true},
+		{"no markers", `<p>isAccessibleForFree: false</p>`, false},
+	}
+	for _, tt := range tests {
+		if got := htmlPaywalled([]byte("<html><head>" + tt.html + "</head><body></body></html>")); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOnPaywalledDomain(t *testing.T) {
+	config := &Config{DomainTags: map[string][]string{"paywalled": {"lokalzeitung.example"}}}
+	for rawURL, want := range map[string]bool{
+		"https://www.nytimes.com/2026/01/01/world/story.html": true,
+		"https://markets.ft.com/data":                         true,
+		"https://e-paper.lokalzeitung.example/":               true,
+		"https://notft.com/":                                  false,
+		"https://en.wikipedia.org/wiki/Paywall":               false,
+	} {
+		if got := onPaywalledDomain(rawURL, config); got != want {
+			t.Errorf("%s: got %v, want %v", rawURL, got, want)
+		}
+	}
+}
+
+// withWayback points archivedCopy at a fake Wayback Machine with copies of
+// the pages in snapshots.
+func withWayback(t *testing.T, snapshots map[string]string) *int {
+	t.Helper()
+	lookups := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		closest := `{}`
+		if snapshot, ok := snapshots[r.URL.Query().Get("url")]; ok {
+			closest = `{"closest": {"available": true, "url": "` + snapshot + `", "timestamp": "20260301000000", "status": "200"}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"url": "x", "archived_snapshots": ` + closest + `}`))
+	}))
+	t.Cleanup(server.Close)
+	saved := waybackAPI
+	waybackAPI = server.URL
+	archiveCopies.urls = map[string]string{}
+	t.Cleanup(func() {
+		waybackAPI = saved
+		archiveCopies.urls = map[string]string{}
+	})
+	return &lookups
+}
+
+func TestArchivedCopy(t *testing.T) {
+	lookups := withWayback(t, map[string]string{
+		"https://www.ft.com/content/1": "http://web.archive.org/web/20260301000000/https://www.ft.com/content/1",
+	})
+	client := &http.Client{}
+	for range 2 {
+		copyURL, err := archivedCopy(client, "https://www.ft.com/content/1")
+		if err != nil || copyURL != "https://web.archive.org/web/20260301000000/https://www.ft.com/content/1" {
+			t.Errorf("got %q, %v", copyURL, err)
+		}
+	}
+	if copyURL, err := archivedCopy(client, "https://www.ft.com/content/2"); err != nil || copyURL != "" {
+		t.Errorf("page without a copy: got %q, %v", copyURL, err)
+	}
+	if *lookups != 2 {
+		t.Errorf("looked up %d times, want the first page once", *lookups)
+	}
+}
+
+func TestEnrichPaywalls(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+	pageInfos.pages = map[string]*pageFetch{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/locked" {
+			w.Write([]byte(`<html><head><meta property="article:content_tier" content="metered"></head><body><p>Subscribe</p></body></html>`))
+			return
+		}
+		w.Write([]byte(`<html><body><p>Free to read</p></body></html>`))
+	}))
+	defer server.Close()
+	withWayback(t, map[string]string{
+		server.URL + "/locked": "https://web.archive.org/web/1/" + server.URL + "/locked",
+	})
+
+	results := []SearchResult{
+		{URL: server.URL + "/locked"},
+		{URL: server.URL + "/free"},
+	}
+	enrichResults(results, []string{"paywall"}, config)
+	if !results[0].Paywalled || results[1].Paywalled || results[0].ArchiveURL != "" {
+		t.Errorf("without archive_paywalled: %+v", results)
+	}
+
+	config.ArchivePaywalled = true
+	enrichResults(results, []string{"paywall"}, config)
+	if results[0].ArchiveURL != "https://web.archive.org/web/1/"+server.URL+"/locked" || results[1].ArchiveURL != "" {
+		t.Errorf("archive links %q, %q", results[0].ArchiveURL, results[1].ArchiveURL)
+	}
+	cleaned := cleanSearchResult(results[0])
+	if cleaned["paywalled"] != true || !strings.HasPrefix(cleaned["archive_url"].(string), "https://web.archive.org/") {
+		t.Errorf("JSON result %v", cleaned)
+	}
+}
+
+func TestResultBadges(t *testing.T) {
+	result := SearchResult{DomainTags: []string{"trusted"}, Paywalled: true}
+	if got := resultBadges(result); !reflect.DeepEqual(got, []string{"trusted", "paywalled"}) {
+		t.Errorf("got %q", got)
+	}
+	if !reflect.DeepEqual(result.DomainTags, []string{"trusted"}) {
+		t.Errorf("domain tags changed to %q", result.DomainTags)
+	}
+	// Domains tagged paywalled aren't badged twice
+	result.DomainTags = []string{"paywalled"}
+	if got := resultBadges(result); !reflect.DeepEqual(got, []string{"paywalled"}) {
+		t.Errorf("got %q", got)
+	}
+}
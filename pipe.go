@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// startPipe starts command with sx's stdout connected to its stdin, so
+// every output format streams into it as it is written. The command is
+// split like a --sink command and run without a shell, the same on every
+// platform. finish restores stdout, waits for the command and returns its
+// exit status, reporting a failure on stderr.
+func startPipe(command string) (finish func() int, err error) {
+	args, err := splitCommandLine(command)
+	if err != nil {
+		return nil, fmt.Errorf("--pipe: %v", err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("--pipe needs a command")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = r
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return nil, fmt.Errorf("--pipe: %v", err)
+	}
+	r.Close()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	return func() int {
+		os.Stdout = stdout
+		w.Close()
+		return pipeStatus(args[0], cmd.Wait())
+	}, nil
+}
+
+// pipeStatus turns the result of waiting for the --pipe command into sx's
+// exit status: the command's own, or 1 if it couldn't report one.
+func pipeStatus(name string, err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		fmt.Fprintln(os.Stderr, tr("command_exit_error", name, exitErr.ExitCode()))
+		return exitErr.ExitCode()
+	}
+	fmt.Fprintln(os.Stderr, tr("command_error", name, err))
+	return 1
+}
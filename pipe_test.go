@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartPipe(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("needs sh")
+	}
+	out := filepath.Join(t.TempDir(), "piped")
+	stdout := os.Stdout
+	finish, err := startPipe(fmt.Sprintf(`sh -c 'cat > "$0"; exit 4' %q`, out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("https://go.dev")
+	status := finish()
+	if os.Stdout != stdout {
+		t.Error("stdout not restored")
+	}
+	if status != 4 {
+		t.Errorf("status %d, want 4", status)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "https://go.dev\n" {
+		t.Errorf("command read %q", data)
+	}
+}
+
+func TestStartPipeErrors(t *testing.T) {
+	for _, command := range []string{"", `"unterminated`, "sx-no-such-command-here"} {
+		if _, err := startPipe(command); err == nil {
+			t.Errorf("startPipe(%q) succeeded", command)
+		}
+	}
+}
+
+func TestPipeStatusLocalized(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("needs sh")
+	}
+	defer func(l string) { uiLocale = l }(uiLocale)
+	uiLocale = "de"
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	status := pipeStatus("sh", exec.Command("sh", "-c", "exit 3").Run())
+	os.Stderr = stderr
+	w.Close()
+	msg, _ := io.ReadAll(r)
+	if status != 3 || string(msg) != "Fehler: sh wurde mit Status 3 beendet\n" {
+		t.Errorf("status %d, stderr %q", status, msg)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// defaultPlayer handles any category without its own [players] entry.
+// mpv resolves YouTube and most media sites through yt-dlp.
+const defaultPlayer = "mpv"
+
+// playerCommand returns the command line for playing a result of the given
+// category. The [players] config maps categories to commands; the "default"
+// key applies to categories without an entry.
+func playerCommand(players map[string]string, category string) []string {
+	command := players[normalizeCategory(strings.ToLower(category))]
+	if strings.TrimSpace(command) == "" {
+		command = players["default"]
+	}
+	if strings.TrimSpace(command) == "" {
+		command = defaultPlayer
+	}
+	return strings.Fields(command)
+}
+
+// playerArgs substitutes {url} in the player arguments, appending the URL
+// when no placeholder is present.
+func playerArgs(args []string, url string) []string {
+	out := make([]string, 0, len(args)+1)
+	substituted := false
+	for _, arg := range args {
+		if strings.Contains(arg, "{url}") {
+			arg = strings.ReplaceAll(arg, "{url}", url)
+			substituted = true
+		}
+		out = append(out, arg)
+	}
+	if !substituted {
+		out = append(out, url)
+	}
+	return out
+}
+
+// playResult hands a result URL to the configured media player and waits
+// for it to exit. The player inherits the terminal so its controls work.
+func playResult(result SearchResult, config *Config) error {
+	command := playerCommand(config.Players, result.Category)
+	args := playerArgs(command[1:], result.URL)
+
+	if config.Debug {
+		fmt.Fprintf(os.Stderr, "Debug: Playing with %s %s\n", command[0], strings.Join(args, " "))
+	}
+
+	cmd := exec.Command(command[0], args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if _, lookErr := exec.LookPath(command[0]); lookErr != nil {
+			return fmt.Errorf("player %q not found; set one in the [players] config section", command[0])
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlayerCommand(t *testing.T) {
+	players := map[string]string{
+		"videos":  "mpv --fs",
+		"music":   "mpv --no-video",
+		"default": "vlc",
+	}
+	tests := []struct {
+		players  map[string]string
+		category string
+		want     []string
+	}{
+		{players, "videos", []string{"mpv", "--fs"}},
+		{players, "Music", []string{"mpv", "--no-video"}},
+		{players, "general", []string{"vlc"}},
+		{nil, "videos", []string{"mpv"}},
+	}
+	for _, tt := range tests {
+		if got := playerCommand(tt.players, tt.category); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("playerCommand(%q) = %v, want %v", tt.category, got, tt.want)
+		}
+	}
+}
+
+func TestPlayerArgs(t *testing.T) {
+	url := "https://example.com/watch?v=1"
+	if got := playerArgs([]string{"--fs"}, url); !reflect.DeepEqual(got, []string{"--fs", url}) {
+		t.Errorf("playerArgs() without placeholder = %v", got)
+	}
+	got := playerArgs([]string{"-o", "-", "{url}", "--quiet"}, url)
+	if want := []string{"-o", "-", url, "--quiet"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("playerArgs() with placeholder = %v, want %v", got, want)
+	}
+}
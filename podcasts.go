@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"time"
+)
+
+// opmlDocument is an OPML 2.0 subscription list, the format podcast apps
+// import and export
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title       string `xml:"title"`
+		DateCreated string `xml:"dateCreated"`
+	} `xml:"head"`
+	Outlines []opmlOutline `xml:"body>outline"`
+}
+
+// opmlOutline is a podcast in an OPML subscription list
+type opmlOutline struct {
+	Type   string `xml:"type,attr"`
+	Text   string `xml:"text,attr"`
+	Title  string `xml:"title,attr"`
+	XMLURL string `xml:"xmlUrl,attr"`
+}
+
+// podcastFeeds lists the podcasts of the results that have a feed, each
+// once, in the order they first appear
+func podcastFeeds(results []SearchResult) []opmlOutline {
+	var feeds []opmlOutline
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if result.FeedURL == "" || seen[result.FeedURL] {
+			continue
+		}
+		seen[result.FeedURL] = true
+		// Episodes name their podcast as the author
+		name := result.Author
+		if name == "" {
+			name = result.Title
+		}
+		feeds = append(feeds, opmlOutline{Type: "rss", Text: name, Title: name, XMLURL: result.FeedURL})
+	}
+	return feeds
+}
+
+// writeOPML writes the podcasts of the results as an OPML subscription
+// list titled after the query
+func writeOPML(w io.Writer, results []SearchResult, query string, now time.Time) error {
+	doc := opmlDocument{Version: "2.0", Outlines: podcastFeeds(results)}
+	doc.Head.Title = "sx: " + query
+	doc.Head.DateCreated = now.Format(time.RFC1123Z)
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, xml.Header+string(data)+"\n")
+	return err
+}
+
+// printSubscriptions writes the podcasts of the results as OPML, for
+// --subscribe, to stdout or outputFile
+func printSubscriptions(results []SearchResult, query string, outputFile string) (err error) {
+	if len(podcastFeeds(results)) == 0 {
+		printNotice("no podcast feeds among the results")
+	}
+
+	var output io.Writer = os.Stdout
+	if outputFile != "" {
+		file, openErr := createOutput(outputFile)
+		if openErr != nil {
+			return openErr
+		}
+		defer closeOutput(file, &err)
+		output = file
+	}
+	return writeOPML(output, results, query, time.Now())
+}
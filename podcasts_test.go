@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteOPML(t *testing.T) {
+	results := []SearchResult{
+		{Title: "Async Rust", Author: "Rustacean Station", FeedURL: "https://rustacean-station.org/podcast.rss"},
+		{Title: "Not a podcast", URL: "https://example.com/"},
+		{Title: "Traits", Author: "Rustacean Station", FeedURL: "https://rustacean-station.org/podcast.rss"},
+		{Title: "Q&A", FeedURL: "https://example.com/feed?a=1&b=2"},
+	}
+	var out strings.Builder
+	if err := writeOPML(&out, results, "rust <async>", time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head>
+    <title>sx: rust &lt;async&gt;</title>
+    <dateCreated>Sat, 09 Mar 2024 12:00:00 +0000</dateCreated>
+  </head>
+  <body>
+    <outline type="rss" text="Rustacean Station" title="Rustacean Station" xmlUrl="https://rustacean-station.org/podcast.rss"></outline>
+    <outline type="rss" text="Q&amp;A" title="Q&amp;A" xmlUrl="https://example.com/feed?a=1&amp;b=2"></outline>
+  </body>
+</opml>
+`
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// printPorcelain writes one line per result for scripts:
+//
+//	rank<TAB>url<TAB>title<TAB>engine<TAB>snippet
+//
+// The columns and their order are stable across releases and independent of
+// the terminal, colors and display settings. Snippets are never truncated,
+// and tabs and line breaks inside fields are folded to single spaces. New
+// columns, if any, will only be appended.
+func printPorcelain(w io.Writer, results []SearchResult, startAt int) {
+	for i, result := range results {
+		engine := result.Engine
+		if engine == "" && len(result.Engines) > 0 {
+			engine = result.Engines[0]
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			startAt+i+1,
+			porcelainField(result.URL),
+			porcelainField(result.Title),
+			porcelainField(engine),
+			formatContent(result.Content, 0))
+	}
+}
+
+// porcelainField folds whitespace runs, including tabs and newlines, to a
+// single space so a field can't break the line format.
+func porcelainField(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
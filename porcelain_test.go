@@ -0,0 +1,20 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintPorcelain(t *testing.T) {
+	var buf bytes.Buffer
+	printPorcelain(&buf, []SearchResult{
+		{URL: "https://example.com/a", Title: "Tabs\tand\nnewlines", Engine: "google", Content: "<b>Bold</b>   snippet\twith tab"},
+		{URL: "https://example.org/", Engines: []string{"bing", "brave"}},
+	}, 10)
+
+	want := "11\thttps://example.com/a\tTabs and newlines\tgoogle\tBold snippet with tab\n" +
+		"12\thttps://example.org/\t\tbing\t\n"
+	if got := buf.String(); got != want {
+		t.Errorf("printPorcelain() =\n%q\nwant\n%q", got, want)
+	}
+}
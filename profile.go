@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// safeSearchLevels lists the safe search settings from least to most
+// restrictive.
+var safeSearchLevels = []string{"none", "moderate", "strict"}
+
+// Profile is a named set of search restrictions from the [profiles]
+// config table, e.g. a "kids" or "work" profile on a shared machine.
+type Profile struct {
+	// SafeSearch is the profile's default level and, when enforced, the
+	// minimum level allowed
+	SafeSearch string `toml:"safe_search,omitempty"`
+	// EnforceSafeSearch locks the profile: weaker safe search settings,
+	// --unsafe and switching to another profile are rejected
+	EnforceSafeSearch bool `toml:"enforce_safe_search,omitempty"`
+	// BlockedDomains are removed from all results (subdomains included)
+	BlockedDomains []string `toml:"blocked_domains,omitempty"`
+}
+
+func safeSearchRank(level string) int {
+	for i, l := range safeSearchLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyProfile activates the configured profile, or requested when given,
+// and applies its safe search and blocked domain settings to opts.
+// safeSearchSet reports whether --safe-search was given explicitly.
+func applyProfile(config *Config, requested string, opts *SearchOptions, safeSearchSet bool) error {
+	name := config.Profile
+	if requested != "" && requested != name {
+		if current, ok := config.Profiles[name]; ok && current.EnforceSafeSearch {
+			return fmt.Errorf("the %q profile is locked and cannot be switched", name)
+		}
+		name = requested
+	}
+	if name == "" {
+		return nil
+	}
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+
+	minimum := profile.SafeSearch
+	if minimum == "" {
+		minimum = "moderate"
+	}
+	if safeSearchRank(minimum) < 0 {
+		return fmt.Errorf("profile %q: invalid safe_search %q (use %s)", name, minimum, strings.Join(safeSearchLevels, ", "))
+	}
+	if profile.SafeSearch != "" && !safeSearchSet {
+		opts.SafeSearch = profile.SafeSearch
+	}
+
+	if profile.EnforceSafeSearch {
+		if len(profile.BlockedDomains) == 0 {
+			return fmt.Errorf("profile %q enforces safe search but has no blocked_domains", name)
+		}
+		if opts.Unsafe {
+			return fmt.Errorf("--unsafe is not allowed: the %q profile enforces safe search", name)
+		}
+		if safeSearchRank(opts.SafeSearch) < safeSearchRank(minimum) {
+			if safeSearchSet {
+				return fmt.Errorf("--safe-search %s is not allowed: the %q profile requires at least %q", opts.SafeSearch, name, minimum)
+			}
+			opts.SafeSearch = minimum
+		}
+	}
+
+	opts.BlockedDomains = append(opts.BlockedDomains, profile.BlockedDomains...)
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func profileConfig() *Config {
+	cfg := getDefaultConfig()
+	cfg.Profile = "kids"
+	cfg.Profiles = map[string]Profile{
+		"kids": {SafeSearch: "strict", EnforceSafeSearch: true, BlockedDomains: []string{"reddit.com"}},
+		"work": {SafeSearch: "moderate", BlockedDomains: []string{"youtube.com"}},
+	}
+	return cfg
+}
+
+func TestApplyProfile_Enforced(t *testing.T) {
+	cfg := profileConfig()
+
+	opts := SearchOptions{SafeSearch: "none"}
+	if err := applyProfile(cfg, "", &opts, false); err != nil {
+		t.Fatalf("applyProfile failed: %v", err)
+	}
+	if opts.SafeSearch != "strict" {
+		t.Errorf("SafeSearch = %q, want strict", opts.SafeSearch)
+	}
+	if !reflect.DeepEqual(opts.BlockedDomains, []string{"reddit.com"}) {
+		t.Errorf("BlockedDomains = %v", opts.BlockedDomains)
+	}
+
+	rejected := []struct {
+		name      string
+		opts      SearchOptions
+		requested string
+		flagSet   bool
+		errPart   string
+	}{
+		{"unsafe", SearchOptions{Unsafe: true}, "", false, "--unsafe"},
+		{"safe-search none", SearchOptions{SafeSearch: "none"}, "", true, "--safe-search none"},
+		{"weaker than profile", SearchOptions{SafeSearch: "moderate"}, "", true, "at least"},
+		{"switch profile", SearchOptions{}, "work", false, "locked"},
+	}
+	for _, tt := range rejected {
+		err := applyProfile(cfg, tt.requested, &tt.opts, tt.flagSet)
+		if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.errPart, err)
+		}
+	}
+}
+
+func TestApplyProfile_RequiresBlockedDomains(t *testing.T) {
+	cfg := getDefaultConfig()
+	cfg.Profiles = map[string]Profile{"kids": {EnforceSafeSearch: true}}
+	opts := SearchOptions{}
+	if err := applyProfile(cfg, "kids", &opts, false); err == nil || !strings.Contains(err.Error(), "blocked_domains") {
+		t.Errorf("expected missing blocked_domains error, got %v", err)
+	}
+}
+
+func TestApplyProfile_Unenforced(t *testing.T) {
+	cfg := profileConfig()
+	cfg.Profile = "work"
+
+	opts := SearchOptions{SafeSearch: "none", Unsafe: true}
+	if err := applyProfile(cfg, "", &opts, true); err != nil {
+		t.Fatalf("applyProfile failed: %v", err)
+	}
+	if opts.SafeSearch != "none" {
+		t.Errorf("explicit --safe-search was overridden: %q", opts.SafeSearch)
+	}
+
+	// An unlocked profile can be switched, and unknown ones are errors
+	if err := applyProfile(cfg, "kids", &SearchOptions{}, false); err != nil {
+		t.Errorf("switching from an unlocked profile failed: %v", err)
+	}
+	if err := applyProfile(cfg, "nope", &SearchOptions{}, false); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+	if err := applyProfile(getDefaultConfig(), "", &SearchOptions{}, false); err != nil {
+		t.Errorf("no profile configured should be a no-op, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// provenance records how saved results were obtained, so research
+// artifacts written with -o can be reproduced later.
+type provenance struct {
+	RetrievedAt string                 `json:"retrieved_at"`
+	Query       string                 `json:"query"`
+	Backend     string                 `json:"backend"`
+	RequestID   string                 `json:"request_id,omitempty"` // X-Request-ID sent to the backend
+	Version     string                 `json:"sx_version"`
+	Options     map[string]interface{} `json:"options"`
+}
+
+// buildProvenance captures the effective search options plus every flag
+// set on the command line. Flags that look like credentials are masked.
+func buildProvenance(flags *pflag.FlagSet, query, backend string, opts *SearchOptions, config *Config, now time.Time) provenance {
+	options := map[string]interface{}{
+		"categories":   opts.Categories,
+		"engines":      opts.SearxngEngines,
+		"language":     opts.Language,
+		"country":      opts.Country,
+		"safe-search":  opts.SafeSearch,
+		"time-range":   opts.TimeRange,
+		"site":         opts.Sites,
+		"exclude-site": opts.ExcludeSites,
+		"num":          config.ResultCount,
+	}
+	if flags != nil {
+		flags.Visit(func(f *pflag.Flag) {
+			// Sinks say where results went, not how they were found, and
+			// webhook URLs often embed tokens
+			if _, ok := options[f.Name]; ok || f.Name == "sink" {
+				return
+			}
+			if sensitiveNamePattern.MatchString(f.Name) {
+				options[f.Name] = redacted
+				return
+			}
+			value := f.Value.String()
+			if u, err := url.Parse(value); err == nil && u.User != nil {
+				value = redactURL(u)
+			}
+			options[f.Name] = value
+		})
+	}
+	for name, value := range options {
+		if isEmptyOption(value) {
+			delete(options, name)
+		}
+	}
+
+	return provenance{
+		RetrievedAt: now.UTC().Format(time.RFC3339),
+		Query:       query,
+		Backend:     backend,
+		Version:     version,
+		Options:     options,
+	}
+}
+
+func isEmptyOption(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v == ""
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+// writeFrontMatter writes the provenance as a YAML front matter block, the
+// header markdown tools expect for document metadata.
+func writeFrontMatter(w io.Writer, p provenance) {
+	fmt.Fprintln(w, "---")
+	fmt.Fprintf(w, "retrieved_at: %s\n", p.RetrievedAt)
+	fmt.Fprintf(w, "query: %s\n", strconv.Quote(p.Query))
+	fmt.Fprintf(w, "backend: %s\n", strconv.Quote(p.Backend))
+	if p.RequestID != "" {
+		fmt.Fprintf(w, "request_id: %s\n", p.RequestID)
+	}
+	fmt.Fprintf(w, "sx_version: %s\n", strconv.Quote(p.Version))
+	if len(p.Options) > 0 {
+		names := make([]string, 0, len(p.Options))
+		for name := range p.Options {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintln(w, "options:")
+		for _, name := range names {
+			fmt.Fprintf(w, "  %s: %s\n", name, yamlValue(p.Options[name]))
+		}
+	}
+	fmt.Fprintln(w, "---")
+	fmt.Fprintln(w)
+}
+
+func yamlValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = strconv.Quote(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
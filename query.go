@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Values accepted by --stdin-mode.
+const (
+	stdinModeQuery   = "query"   // piped input is the query (default)
+	stdinModeContext = "context" // piped input is appended as quoted terms
+)
+
+var stdinModes = []string{stdinModeQuery, stdinModeContext}
+
+// resolveQuery assembles the search query from the positional arguments,
+// --query-file and piped stdin. In query mode stdin is only read when no
+// query was given on the command line or in a file, so scripts that run sx
+// with a non-terminal stdin are not mistaken for pipes. In context mode
+// every non-empty stdin line is appended to the query as a quoted term.
+// An empty query with a nil error means nothing was given at all.
+func resolveQuery(args []string, queryFile, stdinMode string) (string, error) {
+	var parts []string
+	if queryFile != "" {
+		lines, err := readQueryFile(queryFile)
+		if err != nil {
+			return "", err
+		}
+		if len(lines) == 0 {
+			return "", fmt.Errorf("query file %s is empty", queryFile)
+		}
+		parts = append(parts, strings.Join(lines, " "))
+	}
+	if len(args) > 0 {
+		parts = append(parts, strings.Join(args, " "))
+	}
+
+	switch stdinMode {
+	case "", stdinModeQuery:
+		if len(parts) > 0 || !isPipeInput() {
+			break
+		}
+		lines, err := readLines(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("reading from stdin: %v", err)
+		}
+		if len(lines) == 0 {
+			return "", fmt.Errorf("empty input from stdin")
+		}
+		parts = append(parts, strings.Join(lines, " "))
+	case stdinModeContext:
+		if len(parts) == 0 {
+			return "", fmt.Errorf("--stdin-mode context needs a query argument or --query-file")
+		}
+		if isPipeInput() {
+			lines, err := readLines(os.Stdin)
+			if err != nil {
+				return "", fmt.Errorf("reading from stdin: %v", err)
+			}
+			for _, line := range lines {
+				parts = append(parts, quoteTerm(line))
+			}
+		}
+	default:
+		return "", fmt.Errorf("invalid stdin mode '%s'. Use: %s", stdinMode, strings.Join(stdinModes, ", "))
+	}
+
+	return strings.TrimSpace(strings.Join(parts, " ")), nil
+}
+
+// readQueryFile reads the non-empty lines of a query file.
+func readQueryFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open query file: %v", err)
+	}
+	defer f.Close()
+	return readLines(f)
+}
+
+// readLines returns the trimmed, non-empty lines of r.
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// quoteTerm wraps s in double quotes for exact-phrase matching, dropping any
+// quotes inside it that would break the phrase.
+func quoteTerm(s string) string {
+	return `"` + strings.Join(strings.Fields(strings.ReplaceAll(s, `"`, " ")), " ") + `"`
+}
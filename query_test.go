@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveQueryFromArgsAndFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.txt")
+	if err := os.WriteFile(path, []byte("golang\n\n  generics tutorial \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := resolveQuery([]string{"2024"}, path, stdinModeQuery)
+	if err != nil {
+		t.Fatalf("resolveQuery: %v", err)
+	}
+	if got != "golang generics tutorial 2024" {
+		t.Errorf("resolveQuery() = %q", got)
+	}
+
+	got, err = resolveQuery([]string{"rust", "ownership"}, "", stdinModeQuery)
+	if err != nil || got != "rust ownership" {
+		t.Errorf("resolveQuery(args) = %q, %v", got, err)
+	}
+}
+
+func TestResolveQueryErrors(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(empty, []byte("\n \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolveQuery(nil, empty, stdinModeQuery); err == nil {
+		t.Error("expected error for empty query file")
+	}
+	if _, err := resolveQuery(nil, filepath.Join(t.TempDir(), "missing"), stdinModeQuery); err == nil {
+		t.Error("expected error for missing query file")
+	}
+	if _, err := resolveQuery([]string{"q"}, "", "bogus"); err == nil || !strings.Contains(err.Error(), "invalid stdin mode") {
+		t.Errorf("expected invalid stdin mode error, got %v", err)
+	}
+	if _, err := resolveQuery(nil, "", stdinModeContext); err == nil {
+		t.Error("expected error for context mode without a query")
+	}
+}
+
+func TestQuoteTerm(t *testing.T) {
+	if got := quoteTerm(`error "code  42"`); got != `"error code 42"` {
+		t.Errorf("quoteTerm() = %q", got)
+	}
+}
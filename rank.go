@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// defaultRankDepth is how many results `sx rank track` looks through for
+// a domain
+const defaultRankDepth = 50
+
+// sparkBlocks draw a position history, the top rank as the tallest block
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// rankRecord is a domain's position for a query in one `sx rank track`
+// run. Position is 0 when the domain wasn't in the first Depth results.
+type rankRecord struct {
+	Time     time.Time `json:"time"`
+	Query    string    `json:"query"`
+	Domain   string    `json:"domain"`
+	Position int       `json:"position"`
+	URL      string    `json:"url,omitempty"`
+	Depth    int       `json:"depth"`
+	Engine   string    `json:"engine,omitempty"`
+}
+
+// rankSeries is the position history of a domain for a query, oldest
+// first.
+type rankSeries struct {
+	Query   string       `json:"query"`
+	Domain  string       `json:"domain"`
+	History []rankRecord `json:"history"`
+}
+
+// getRankFile is where tracked positions are kept, a JSON line per
+// record.
+func getRankFile() string {
+	return filepath.Join(getStateDir(), "rank.jsonl")
+}
+
+// findPosition returns the 1-based rank of the first result on domain or
+// one of its subdomains, with its URL, or 0 if there is none.
+func findPosition(results []SearchResult, domain string) (int, string) {
+	for i, result := range results {
+		if matchesAnySite(extractDomain(result.URL, false), []string{domain}) {
+			return i + 1, result.URL
+		}
+	}
+	return 0, ""
+}
+
+func appendRankRecords(path string, records []rankRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// loadRankRecords reads the tracked positions in the order they were
+// recorded. Lines that can't be parsed are skipped.
+func loadRankRecords(path string) ([]rankRecord, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []rankRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r rankRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err == nil && r.Query != "" {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// rankSeriesOf groups records by query and domain, in the order each pair
+// was first tracked. An empty query or domain matches any; queries match
+// case-insensitively.
+func rankSeriesOf(records []rankRecord, query, domain string) []rankSeries {
+	var series []rankSeries
+	index := make(map[string]int)
+	for _, r := range records {
+		if query != "" && !strings.EqualFold(r.Query, query) || domain != "" && !strings.EqualFold(r.Domain, domain) {
+			continue
+		}
+		key := strings.ToLower(r.Query) + "\x00" + strings.ToLower(r.Domain)
+		i, ok := index[key]
+		if !ok {
+			i = len(series)
+			index[key] = i
+			series = append(series, rankSeries{Query: r.Query, Domain: r.Domain})
+		}
+		series[i].History = append(series[i].History, r)
+	}
+	return series
+}
+
+// sparkline draws positions as blocks, the top rank tallest and depth
+// lowest, with a dot where the domain wasn't found.
+func sparkline(history []rankRecord) string {
+	var b strings.Builder
+	for _, r := range history {
+		if r.Position == 0 || r.Depth <= 1 {
+			b.WriteRune('·')
+			continue
+		}
+		level := (r.Depth - min(r.Position, r.Depth)) * (len(sparkBlocks) - 1) / (r.Depth - 1)
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// formatPosition shows a position as #N, or as not in the top depth.
+func formatPosition(position, depth int) string {
+	if position == 0 {
+		return fmt.Sprintf(">%d", depth)
+	}
+	return fmt.Sprintf("#%d", position)
+}
+
+// positionChange describes the move from the previous position to the
+// current one: ↑N, ↓N, = when unchanged, new or lost when the domain
+// entered or left the results.
+func positionChange(previous, current rankRecord) string {
+	switch {
+	case previous.Position == current.Position:
+		return "="
+	case previous.Position == 0:
+		return "new"
+	case current.Position == 0:
+		return "lost"
+	case current.Position < previous.Position:
+		return fmt.Sprintf("↑%d", previous.Position-current.Position)
+	}
+	return fmt.Sprintf("↓%d", current.Position-previous.Position)
+}
+
+// runRankTrack searches query once, records the position of each domain
+// in the first depth results and prints it with the change since the
+// previous run.
+func runRankTrack(query string, domains []string, depth int, engine string) error {
+	if len(domains) == 0 {
+		return fmt.Errorf("--domain is required")
+	}
+	if depth < 1 {
+		return fmt.Errorf("--depth must be at least 1")
+	}
+	for i, domain := range domains {
+		domains[i] = strings.TrimPrefix(unicodeHost(urlHost(domain)), "www.")
+		if domains[i] == "" {
+			return fmt.Errorf("invalid domain %q", domain)
+		}
+	}
+	path := getRankFile()
+	records, err := loadRankRecords(path)
+	if err != nil {
+		return err
+	}
+
+	installTransports(config)
+	backendMgr = initBackendManager(config)
+
+	opts := SearchOptions{SafeSearch: config.SafeSearch, ExplicitEngine: engine, PageNo: 1}
+	if err := applyProfile(config, "", &opts, false); err != nil {
+		return err
+	}
+	state := searchState{Query: query}
+	results, err := fetchResults(&state, nil, depth, &opts, config)
+	if err != nil {
+		return err
+	}
+	if len(results) > depth {
+		results = results[:depth]
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	tracked := make([]rankRecord, len(domains))
+	for i, domain := range domains {
+		position, url := findPosition(results, domain)
+		tracked[i] = rankRecord{Time: now, Query: query, Domain: domain, Position: position, URL: url, Depth: depth, Engine: state.Engine}
+	}
+	if err := appendRankRecords(path, tracked); err != nil {
+		return err
+	}
+
+	for _, r := range tracked {
+		line := fmt.Sprintf("%s  %s", r.Domain, formatPosition(r.Position, r.Depth))
+		if series := rankSeriesOf(records, query, r.Domain); len(series) > 0 {
+			history := series[0].History
+			line += "  " + positionChange(history[len(history)-1], r)
+		}
+		if r.URL != "" {
+			line += "  " + r.URL
+		}
+		fmt.Println(line)
+	}
+	if len(results) < depth {
+		printNotice("Only %d results were found, so positions below that couldn't be checked", len(results))
+	}
+	return nil
+}
+
+// runRankReport prints the tracked position history: a row per query and
+// domain with a sparkline of the last limit runs, or the runs themselves
+// when only one query and domain match.
+func runRankReport(query, domain string, limit int, asJSON bool) error {
+	records, err := loadRankRecords(getRankFile())
+	if err != nil {
+		return err
+	}
+	series := rankSeriesOf(records, query, domain)
+	if limit > 0 {
+		for i := range series {
+			if n := len(series[i].History); n > limit {
+				series[i].History = series[i].History[n-limit:]
+			}
+		}
+	}
+	if asJSON {
+		if series == nil {
+			series = []rankSeries{}
+		}
+		data, err := json.MarshalIndent(series, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(series) == 0 {
+		fmt.Fprintln(os.Stderr, "No tracked positions. Start with: sx rank track \"query\" --domain example.com")
+		return nil
+	}
+	if len(series) == 1 {
+		printRankHistory(os.Stdout, series[0])
+		return nil
+	}
+	printRankTable(os.Stdout, series)
+	return nil
+}
+
+// printRankTable writes a row per series: the latest and best positions
+// and a sparkline of the history.
+func printRankTable(w io.Writer, series []rankSeries) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "QUERY\tDOMAIN\tLAST\tBEST\tHISTORY\tRUNS")
+	for _, s := range series {
+		last := s.History[len(s.History)-1]
+		best := 0
+		for _, r := range s.History {
+			if r.Position > 0 && (best == 0 || r.Position < best) {
+				best = r.Position
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\n", s.Query, s.Domain, formatPosition(last.Position, last.Depth),
+			formatPosition(best, last.Depth), sparkline(s.History), len(s.History))
+	}
+	tw.Flush()
+}
+
+// printRankHistory writes a line per run of one series, newest last, under
+// its sparkline.
+func printRankHistory(w io.Writer, s rankSeries) {
+	fmt.Fprintf(w, "%s  %s  %s\n", s.Query, s.Domain, sparkline(s.History))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, r := range s.History {
+		change := ""
+		if i > 0 {
+			change = positionChange(s.History[i-1], r)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Time.Local().Format("2006-01-02 15:04"), formatPosition(r.Position, r.Depth), change, r.URL)
+	}
+	tw.Flush()
+}
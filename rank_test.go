@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFindPosition(t *testing.T) {
+	results := []SearchResult{
+		{URL: "https://example.org/a"},
+		{URL: "https://blog.mysite.com/post"},
+		{URL: "https://mysite.com/"},
+	}
+	if position, url := findPosition(results, "mysite.com"); position != 2 || url != "https://blog.mysite.com/post" {
+		t.Errorf("findPosition = %d, %q", position, url)
+	}
+	if position, _ := findPosition(results, "othersite.com"); position != 0 {
+		t.Errorf("findPosition of a missing domain = %d", position)
+	}
+}
+
+func TestRankRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rank.jsonl")
+	day := time.Date(2024, 3, 9, 6, 0, 0, 0, time.UTC)
+	err := appendRankRecords(path, []rankRecord{
+		{Time: day, Query: "go sqlite", Domain: "mysite.com", Position: 9, Depth: 50},
+		{Time: day, Query: "go sqlite", Domain: "rival.com", Position: 3, Depth: 50},
+	})
+	if err == nil {
+		err = appendRankRecords(path, []rankRecord{{Time: day.AddDate(0, 0, 1), Query: "Go SQLite", Domain: "mysite.com", Position: 4, Depth: 50}})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := loadRankRecords(path)
+	if err != nil || len(records) != 3 {
+		t.Fatalf("loadRankRecords = %v, %v", records, err)
+	}
+
+	series := rankSeriesOf(records, "", "")
+	if len(series) != 2 || series[0].Domain != "mysite.com" || len(series[0].History) != 2 || series[1].Domain != "rival.com" {
+		t.Fatalf("rankSeriesOf = %+v", series)
+	}
+	if series := rankSeriesOf(records, "go sqlite", "RIVAL.com"); len(series) != 1 || series[0].History[0].Position != 3 {
+		t.Errorf("filtered rankSeriesOf = %+v", series)
+	}
+
+	if records, err := loadRankRecords(filepath.Join(t.TempDir(), "none.jsonl")); err != nil || records != nil {
+		t.Errorf("missing file: %v, %v", records, err)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	history := []rankRecord{
+		{Position: 1, Depth: 50},
+		{Position: 50, Depth: 50},
+		{Position: 0, Depth: 50},
+		{Position: 25, Depth: 50},
+		{Position: 3, Depth: 1},
+	}
+	if got := sparkline(history); got != "█▁·▄·" {
+		t.Errorf("sparkline = %q", got)
+	}
+}
+
+func TestPositionChange(t *testing.T) {
+	tests := []struct {
+		previous, current int
+		want              string
+	}{
+		{5, 5, "="},
+		{9, 4, "↑5"},
+		{4, 9, "↓5"},
+		{0, 7, "new"},
+		{7, 0, "lost"},
+		{0, 0, "="},
+	}
+	for _, tt := range tests {
+		if got := positionChange(rankRecord{Position: tt.previous}, rankRecord{Position: tt.current}); got != tt.want {
+			t.Errorf("positionChange(%d, %d) = %q, want %q", tt.previous, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestPrintRankTable(t *testing.T) {
+	var buf bytes.Buffer
+	printRankTable(&buf, []rankSeries{{
+		Query:  "go sqlite",
+		Domain: "mysite.com",
+		History: []rankRecord{
+			{Position: 9, Depth: 50},
+			{Position: 0, Depth: 50},
+			{Position: 4, Depth: 50},
+		},
+	}})
+	want := "QUERY      DOMAIN      LAST  BEST  HISTORY  RUNS\n" +
+		"go sqlite  mysite.com  #4    #4    ▆·▇      3\n"
+	if buf.String() != want {
+		t.Errorf("printRankTable:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
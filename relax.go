@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+)
+
+// relaxation is an option --relax dropped: Option is its stable name in
+// JSON output (time_range, sites or categories), Values what it was set to.
+type relaxation struct {
+	Option string   `json:"option"`
+	Values []string `json:"values"`
+}
+
+// describeRelaxed says what was relaxed in the UI locale, for the notice:
+// "time range (week), site filter (go.dev)".
+func describeRelaxed(relaxed []relaxation) string {
+	parts := make([]string, len(relaxed))
+	for i, r := range relaxed {
+		parts[i] = tr("relax_"+r.Option, strings.Join(r.Values, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// relaxOptions drops the next narrowing option for --relax, in the order
+// time range, site filter, categories, and returns what it dropped. It
+// returns false when there is nothing left to relax.
+func relaxOptions(opts *SearchOptions) (relaxation, bool) {
+	switch {
+	case opts.TimeRange != "":
+		r := relaxation{"time_range", []string{opts.TimeRange}}
+		opts.TimeRange = ""
+		return r, true
+	case len(opts.Sites) > 0:
+		r := relaxation{"sites", opts.Sites}
+		opts.Sites = nil
+		return r, true
+	case len(opts.Categories) > 0 && !(len(opts.Categories) == 1 && opts.Categories[0] == "general"):
+		r := relaxation{"categories", opts.Categories}
+		opts.Categories = nil
+		return r, true
+	}
+	return relaxation{}, false
+}
+
+// fetchRelaxed retries a search that found nothing, relaxing one more
+// option each time until results turn up. It returns the results and what
+// was relaxed; opts keeps the relaxed options for further pages.
+func fetchRelaxed(state *searchState, want int, opts *SearchOptions, config *Config) ([]SearchResult, []relaxation, error) {
+	var relaxed []relaxation
+	for {
+		r, ok := relaxOptions(opts)
+		if !ok {
+			return nil, relaxed, nil
+		}
+		relaxed = append(relaxed, r)
+		opts.PageNo = 1
+		results, err := fetchResults(state, nil, want, opts, config)
+		if err != nil || len(results) > 0 {
+			return results, relaxed, err
+		}
+	}
+}
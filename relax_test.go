@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRelaxOptions(t *testing.T) {
+	opts := &SearchOptions{TimeRange: "week", Sites: []string{"go.dev", "example.com"}, Categories: []string{"news"}}
+
+	var steps []relaxation
+	for {
+		r, ok := relaxOptions(opts)
+		if !ok {
+			break
+		}
+		steps = append(steps, r)
+	}
+	want := []relaxation{{"time_range", []string{"week"}}, {"sites", []string{"go.dev", "example.com"}}, {"categories", []string{"news"}}}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("relaxed %v, want %v", steps, want)
+	}
+	if got := describeRelaxed(steps); got != "time range (week), site filter (go.dev, example.com), categories (news)" {
+		t.Errorf("notice %q", got)
+	}
+
+	if _, ok := relaxOptions(&SearchOptions{Categories: []string{"general"}}); ok {
+		t.Error("general category relaxed")
+	}
+}
+
+func TestRelaxedIsNotLocalized(t *testing.T) {
+	defer func(l string) { uiLocale = l }(uiLocale)
+	uiLocale = "de"
+	relaxed := []relaxation{{"time_range", []string{"week"}}}
+	if got := describeRelaxed(relaxed); got != "Zeitraum (week)" {
+		t.Errorf("notice %q", got)
+	}
+	data, err := json.Marshal(relaxed)
+	if err != nil || string(data) != `[{"option":"time_range","values":["week"]}]` {
+		t.Errorf("JSON %s, %v", data, err)
+	}
+}
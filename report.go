@@ -0,0 +1,79 @@
+package main
+
+import (
+	"html/template"
+	"io"
+)
+
+// reportTemplate renders result sections as a self-contained HTML page,
+// with inline styles so mail clients show it as intended.
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body style="font-family: sans-serif; max-width: 46em; margin: 1em auto; color: #222;">
+<h1 style="font-size: 1.3em;">{{.Title}}</h1>
+{{- range .Sections}}
+<h2 style="font-size: 1.1em; border-bottom: 1px solid #ddd;">{{.Name}} <span style="color: #888; font-weight: normal;">({{len .Results}})</span></h2>
+<ol>
+{{- range .Results}}
+<li style="margin-bottom: 0.8em;">
+{{- if .Favicon}}
+<img src="{{.Favicon}}" alt="" width="16" height="16" style="vertical-align: middle;">
+{{- end}}
+<a href="{{.URL}}" style="color: #1a0dab; text-decoration: none;">{{.Title}}</a>
+<span style="color: #060;">{{.Domain}}</span>
+{{- range .Tags}}
+<span style="color: #555; background: #eee; border-radius: 3px; padding: 0 0.3em; font-size: 0.8em;">{{.}}</span>
+{{- end}}
+{{- if .Snippet}}
+<div style="color: #444; font-size: 0.9em;">{{.Snippet}}</div>
+{{- end}}
+</li>
+{{- end}}
+</ol>
+{{- end}}
+</body>
+</html>
+`))
+
+type reportResult struct {
+	Title, URL, Domain, Snippet, Favicon string
+	Tags                                 []string // domain_tags of the result's site
+}
+
+type reportSection struct {
+	Name    string
+	Results []reportResult
+}
+
+// renderHTMLReport writes the output window of each section as an HTML
+// page titled title. Snippets are cut to snippetWords words as in text
+// output.
+func renderHTMLReport(w io.Writer, title string, sections []querySection, snippetWords int) error {
+	data := struct {
+		Title    string
+		Sections []reportSection
+	}{Title: title}
+	for _, s := range sections {
+		section := reportSection{Name: s.Query}
+		for _, result := range s.window() {
+			r := reportResult{
+				Title:   result.Title,
+				URL:     result.URL,
+				Domain:  extractDomain(result.URL, false),
+				Snippet: formatContent(result.Content, snippetWords),
+				Favicon: result.Favicon,
+				Tags:    resultBadges(result),
+			}
+			if r.Title == "" {
+				r.Title = tr("no_title")
+			}
+			section.Results = append(section.Results, r)
+		}
+		data.Sections = append(data.Sections, section)
+	}
+	return reportTemplate.Execute(w, data)
+}
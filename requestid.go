@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync/atomic"
+
+	"sx/backends"
+)
+
+// requestIDHeader carries the search's request ID to SearXNG, so the
+// instance's logs can be matched with sx sessions. Third-party APIs aren't
+// sent it (see backends.SearxngTransportWrapper).
+const requestIDHeader = "X-Request-ID"
+
+// currentRequestID holds the ID of the search in progress.
+var currentRequestID atomic.Value
+
+// startRequestID gives the search that is starting a new request ID and
+// returns it.
+func startRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+	currentRequestID.Store(id)
+	return id
+}
+
+// installTransports sets up the transports of the backends a command is
+// about to create: every backend logs its requests in debug mode, and only
+// SearXNG, whose logs the user can read, is sent the request ID. Commands
+// call it before initBackendManager.
+func installTransports(config *Config) {
+	backends.TransportWrapper = func(rt http.RoundTripper) http.RoundTripper {
+		return withDebug(rt, config)
+	}
+	backends.SearxngTransportWrapper = withRequestID
+}
+
+// requestIDTransport adds the current request ID to requests that don't
+// have one.
+type requestIDTransport struct {
+	base http.RoundTripper
+}
+
+// withRequestID wraps rt (nil for http.DefaultTransport) so its requests
+// carry the current request ID.
+func withRequestID(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &requestIDTransport{base: rt}
+}
+
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id, _ := currentRequestID.Load().(string)
+	if id == "" || req.Header.Get(requestIDHeader) != "" {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(requestIDHeader, id)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sx/backends"
+)
+
+func TestRequestIDTransport(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(requestIDHeader))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: withRequestID(nil)}
+	first := startRequestID()
+	client.Get(server.URL)
+	client.Get(server.URL)
+	second := startRequestID()
+	client.Get(server.URL)
+
+	if len(first) != 16 || first == second {
+		t.Fatalf("request IDs %q, %q", first, second)
+	}
+	if len(got) != 3 || got[0] != first || got[1] != first || got[2] != second {
+		t.Errorf("sent %q, want %q twice then %q", got, first, second)
+	}
+}
+
+func TestInstallTransports(t *testing.T) {
+	defer func() { backends.TransportWrapper, backends.SearxngTransportWrapper = nil, nil }()
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(requestIDHeader))
+	}))
+	defer server.Close()
+
+	installTransports(getDefaultConfig())
+	if backends.TransportWrapper == nil || backends.SearxngTransportWrapper == nil {
+		t.Fatal("transports not installed")
+	}
+	id := startRequestID()
+	(&http.Client{Transport: backends.TransportWrapper(nil)}).Get(server.URL)
+	(&http.Client{Transport: backends.SearxngTransportWrapper(backends.TransportWrapper(nil))}).Get(server.URL)
+	if len(got) != 2 || got[0] != "" || got[1] != id {
+		t.Errorf("sent %q, want no ID then %q", got, id)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// maxRerankDocument caps the text sent per result; titles and snippets
+// rarely come close.
+const maxRerankDocument = 2000
+
+// rerankAPIs are the [rerank] api values: a rerank endpoint that scores
+// documents against the query (rerank for Jina, Cohere and Voyage, tei for
+// a local text-embeddings-inference server), or an OpenAI-compatible
+// embeddings endpoint whose vectors are compared (OpenAI, Ollama,
+// llama.cpp, LM Studio).
+var rerankAPIs = []string{"rerank", "tei", "embeddings"}
+
+// api is the configured API kind, rerank by default.
+func (c RerankConfig) api() string {
+	if c.API == "" {
+		return "rerank"
+	}
+	return c.API
+}
+
+// check reports what is missing to rerank.
+func (c RerankConfig) check() error {
+	if c.URL == "" {
+		return fmt.Errorf("--rerank needs the url of a rerank or embeddings API in the [rerank] config table")
+	}
+	for _, api := range rerankAPIs {
+		if c.api() == api {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid [rerank] api %q (use %s)", c.API, strings.Join(rerankAPIs, ", "))
+}
+
+// rerankDocument is the text of result the reranker judges.
+func rerankDocument(result SearchResult) string {
+	doc := strings.TrimSpace(result.Title + "\n" + result.Content)
+	if len(doc) > maxRerankDocument {
+		doc = strings.ToValidUTF8(doc[:maxRerankDocument], "")
+	}
+	return doc
+}
+
+// rerankResults orders results by how relevant the reranker finds them to
+// query, most relevant first, and records the scores in Relevance.
+func rerankResults(results []SearchResult, query string, config *Config) error {
+	if len(results) == 0 {
+		return nil
+	}
+	c := config.Rerank
+	if err := c.check(); err != nil {
+		return err
+	}
+	docs := make([]string, len(results))
+	for i, result := range results {
+		docs[i] = rerankDocument(result)
+	}
+	var scores []float64
+	var err error
+	if c.api() == "embeddings" {
+		scores, err = embeddingScores(c, query, docs, config)
+	} else {
+		scores, err = rerankScores(c, query, docs, config)
+	}
+	if err != nil {
+		return err
+	}
+	// Results the reranker left out (NaN) go last
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		sa, sb := scores[order[a]], scores[order[b]]
+		return !math.IsNaN(sa) && (math.IsNaN(sb) || sa > sb)
+	})
+	reranked := make([]SearchResult, len(results))
+	for k, i := range order {
+		reranked[k] = results[i]
+		if !math.IsNaN(scores[i]) {
+			reranked[k].Relevance = scores[i]
+		}
+	}
+	copy(results, reranked)
+	return nil
+}
+
+// rerankScores asks a rerank endpoint to score docs against query. Jina,
+// Cohere and Voyage take {"query", "documents"} and answer
+// {"results": [{"index", "relevance_score"}]}; text-embeddings-inference
+// takes {"query", "texts"} and answers [{"index", "score"}].
+func rerankScores(c RerankConfig, query string, docs []string, config *Config) ([]float64, error) {
+	body := map[string]interface{}{"query": query, "documents": docs}
+	if c.api() == "tei" {
+		body = map[string]interface{}{"query": query, "texts": docs}
+	}
+	if c.Model != "" {
+		body["model"] = c.Model
+	}
+	var raw json.RawMessage
+	if err := rerankRequest(c, body, &raw, config); err != nil {
+		return nil, err
+	}
+	type scored struct {
+		Index          int      `json:"index"`
+		RelevanceScore *float64 `json:"relevance_score"`
+		Score          *float64 `json:"score"`
+	}
+	var wrapped struct {
+		Results []scored `json:"results"`
+	}
+	var list []scored
+	if err := json.Unmarshal(raw, &wrapped); err == nil && wrapped.Results != nil {
+		list = wrapped.Results
+	} else if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("rerank: unexpected response")
+	}
+
+	scores := make([]float64, len(docs))
+	for i := range scores {
+		scores[i] = math.NaN()
+	}
+	for _, s := range list {
+		if s.Index < 0 || s.Index >= len(docs) {
+			return nil, fmt.Errorf("rerank: result index %d out of range", s.Index)
+		}
+		switch {
+		case s.RelevanceScore != nil:
+			scores[s.Index] = *s.RelevanceScore
+		case s.Score != nil:
+			scores[s.Index] = *s.Score
+		}
+	}
+	return scores, nil
+}
+
+// embeddingScores embeds query and docs in one request and scores each
+// doc by the cosine similarity of its vector to the query's.
+func embeddingScores(c RerankConfig, query string, docs []string, config *Config) ([]float64, error) {
+	body := map[string]interface{}{"input": append([]string{query}, docs...)}
+	if c.Model != "" {
+		body["model"] = c.Model
+	}
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := rerankRequest(c, body, &resp, config); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float64, len(docs)+1)
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("embeddings: index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("embeddings: no vector for input %d", i)
+		}
+	}
+	scores := make([]float64, len(docs))
+	for i := range docs {
+		scores[i] = cosine(vectors[0], vectors[i+1])
+	}
+	return scores, nil
+}
+
+// cosine is the cosine similarity of a and b, 0 if either is zero or
+// their lengths differ.
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func rerankRequest(c RerankConfig, body interface{}, v interface{}, config *Config) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sx/"+version)
+	apiKey := os.Getenv("SX_RERANK_API_KEY")
+	if apiKey == "" {
+		apiKey = c.APIKey
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := setupHTTPClient(config).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: HTTP %d: %s", c.api(), resp.StatusCode, strings.TrimSpace(string(data[:min(len(data), 200)])))
+	}
+	return json.Unmarshal(data, v)
+}
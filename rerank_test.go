@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRerankConfigCheck(t *testing.T) {
+	if err := (RerankConfig{}).check(); err == nil {
+		t.Error("check passed without a url")
+	}
+	if err := (RerankConfig{URL: "http://x", API: "onnx"}).check(); err == nil {
+		t.Error("check passed with an unknown api")
+	}
+	for _, api := range []string{"", "rerank", "tei", "embeddings"} {
+		if err := (RerankConfig{URL: "http://x", API: api}).check(); err != nil {
+			t.Errorf("api %q: %v", api, err)
+		}
+	}
+}
+
+func TestRerankResults(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+	t.Setenv("SX_RERANK_API_KEY", "")
+
+	var got map[string]interface{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		switch r.URL.Path {
+		case "/rerank":
+			w.Write([]byte(`{"results": [{"index": 2, "relevance_score": 0.9}, {"index": 0, "relevance_score": 0.4}]}`))
+		case "/tei":
+			w.Write([]byte(`[{"index": 1, "score": 3.5}, {"index": 0, "score": -1.2}, {"index": 2, "score": 0.1}]`))
+		case "/embeddings":
+			// query, then three results: the second points the query's way
+			w.Write([]byte(`{"data": [
+				{"index": 0, "embedding": [1, 0]},
+				{"index": 1, "embedding": [0, 1]},
+				{"index": 2, "embedding": [1, 0.1]},
+				{"index": 3, "embedding": [-1, 0]}]}`))
+		default:
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	fresh := func() []SearchResult {
+		return []SearchResult{{Title: "a", Content: "first"}, {Title: "b"}, {Title: "c"}}
+	}
+	tests := []struct {
+		api, path string
+		want      []string
+	}{
+		{"", "/rerank", []string{"c", "a", "b"}},
+		{"tei", "/tei", []string{"b", "c", "a"}},
+		{"embeddings", "/embeddings", []string{"b", "a", "c"}},
+	}
+	for _, tt := range tests {
+		config.Rerank = RerankConfig{API: tt.api, URL: server.URL + tt.path, Model: "m", APIKey: "secret"}
+		results := fresh()
+		if err := rerankResults(results, "query", config); err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		if order := titles(results); !reflect.DeepEqual(order, tt.want) {
+			t.Errorf("%s: order %q, want %q", tt.path, order, tt.want)
+		}
+		if got["model"] != "m" || auth != "Bearer secret" {
+			t.Errorf("%s: request %v, Authorization %q", tt.path, got, auth)
+		}
+	}
+	if _, ok := got["input"]; !ok {
+		t.Errorf("embeddings request %v has no input", got)
+	}
+
+	config.Rerank = RerankConfig{URL: server.URL + "/rerank"}
+	results := fresh()
+	rerankResults(results, "query", config)
+	if results[0].Relevance != 0.9 || results[2].Relevance != 0 {
+		t.Errorf("relevance %v, %v", results[0].Relevance, results[2].Relevance)
+	}
+
+	config.Rerank = RerankConfig{URL: server.URL + "/down"}
+	results = fresh()
+	err := rerankResults(results, "query", config)
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("failing API: %v", err)
+	}
+	if order := titles(results); !reflect.DeepEqual(order, []string{"a", "b", "c"}) {
+		t.Errorf("failing API reordered results: %q", order)
+	}
+}
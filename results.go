@@ -0,0 +1,97 @@
+package main
+
+import "strings"
+
+// resultWindow returns up to count results starting at startAt, clamped to
+// the available results. A count of 0 means all remaining results.
+func resultWindow(results []SearchResult, startAt, count int) []SearchResult {
+	if startAt >= len(results) {
+		return nil
+	}
+	end := len(results)
+	if count > 0 && startAt+count < end {
+		end = startAt + count
+	}
+	return results[startAt:end]
+}
+
+// dedupeResults returns the results in page whose URL is not already in
+// existing (or repeated earlier in page). Results without a URL are kept.
+func dedupeResults(existing, page []SearchResult) []SearchResult {
+	seen := make(map[string]struct{}, len(existing)+len(page))
+	for _, result := range existing {
+		seen[result.URL] = struct{}{}
+	}
+	fresh := page[:0:0]
+	for _, result := range page {
+		if result.URL == "" {
+			fresh = append(fresh, result)
+			continue
+		}
+		if _, ok := seen[result.URL]; ok {
+			continue
+		}
+		seen[result.URL] = struct{}{}
+		fresh = append(fresh, result)
+	}
+	return fresh
+}
+
+// filterExcludedSites drops results hosted on any of the excluded sites
+// (or their subdomains). Backends that ignore -site: operators still honor
+// --exclude-site this way.
+func filterExcludedSites(results []SearchResult, excluded []string) []SearchResult {
+	if len(excluded) == 0 {
+		return results
+	}
+	filtered := results[:0:0]
+	for _, result := range results {
+		if !matchesAnySite(extractDomain(result.URL, false), excluded) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+// matchesAnySite reports whether host equals one of sites or is a subdomain
+// of one. Comparison is case-insensitive and ignores a leading "www.".
+func matchesAnySite(host string, sites []string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	if host == "" {
+		return false
+	}
+	for _, site := range sites {
+		site = strings.TrimPrefix(unicodeHost(strings.ToLower(strings.TrimSpace(site))), "www.")
+		if site == "" {
+			continue
+		}
+		if host == site || strings.HasSuffix(host, "."+site) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterNoneOf drops results whose title or snippet mentions any of the
+// --none-of terms. Backends without a negation operator get the terms
+// dropped from their query, so the exclusion is enforced here as well.
+func filterNoneOf(results []SearchResult, terms []string) []SearchResult {
+	if len(terms) == 0 {
+		return results
+	}
+	filtered := results[:0:0]
+	for _, result := range results {
+		text := strings.ToLower(result.Title + " " + result.Content)
+		excluded := false
+		for _, term := range terms {
+			if term = strings.ToLower(strings.TrimSpace(term)); term != "" && strings.Contains(text, term) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,92 @@
+package main
+
+import "testing"
+
+func TestFilterExcludedSites(t *testing.T) {
+	results := []SearchResult{
+		{URL: "https://www.pinterest.com/pin/1"},
+		{URL: "https://go.dev/doc"},
+		{URL: "https://de.pinterest.com/pin/2"},
+		{URL: "https://notpinterest.com/"},
+	}
+	got := filterExcludedSites(results, []string{"pinterest.com"})
+	if len(got) != 2 {
+		t.Fatalf("expected 2 results, got %d: %v", len(got), got)
+	}
+	if got[0].URL != "https://go.dev/doc" || got[1].URL != "https://notpinterest.com/" {
+		t.Errorf("unexpected results kept: %v", got)
+	}
+}
+
+func TestMatchesAnySite(t *testing.T) {
+	tests := []struct {
+		host  string
+		sites []string
+		want  bool
+	}{
+		{"example.com", []string{"example.com"}, true},
+		{"www.example.com", []string{"example.com"}, true},
+		{"docs.example.com", []string{"EXAMPLE.com"}, true},
+		{"example.org", []string{"example.com"}, false},
+		{"badexample.com", []string{"example.com"}, false},
+		{"", []string{"example.com"}, false},
+		{"bücher.de", []string{"xn--bcher-kva.de"}, true},
+	}
+	for _, tt := range tests {
+		if got := matchesAnySite(tt.host, tt.sites); got != tt.want {
+			t.Errorf("matchesAnySite(%q, %v) = %v, want %v", tt.host, tt.sites, got, tt.want)
+		}
+	}
+}
+
+func TestFilterNoneOf(t *testing.T) {
+	results := []SearchResult{
+		{Title: "Go error handling", Content: "Use errors.Is"},
+		{Title: "Panic and recover", Content: "when to PANIC"},
+		{Title: "Java", Content: "java script tips"},
+	}
+	got := filterNoneOf(results, []string{"panic", "Java Script"})
+	if len(got) != 1 || got[0].Title != "Go error handling" {
+		t.Errorf("unexpected results kept: %v", got)
+	}
+}
+
+func TestResultWindow(t *testing.T) {
+	results := make([]SearchResult, 5)
+	for i := range results {
+		results[i].Title = string(rune('a' + i))
+	}
+	tests := []struct {
+		startAt, count int
+		want           string
+	}{
+		{0, 2, "ab"},
+		{3, 10, "de"},
+		{2, 0, "cde"},
+		{5, 2, ""},
+		{9, 2, ""},
+	}
+	for _, tt := range tests {
+		var got string
+		for _, r := range resultWindow(results, tt.startAt, tt.count) {
+			got += r.Title
+		}
+		if got != tt.want {
+			t.Errorf("resultWindow(%d, %d) = %q, want %q", tt.startAt, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestDedupeResults(t *testing.T) {
+	existing := []SearchResult{{URL: "https://a.example"}}
+	page := []SearchResult{
+		{URL: "https://a.example"},
+		{URL: "https://b.example"},
+		{URL: "https://b.example"},
+		{URL: ""},
+	}
+	got := dedupeResults(existing, page)
+	if len(got) != 2 || got[0].URL != "https://b.example" || got[1].URL != "" {
+		t.Errorf("unexpected deduped results: %v", got)
+	}
+}
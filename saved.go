@@ -0,0 +1,341 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// savedSeenLimit caps the URLs remembered per saved search; the oldest are
+// forgotten first.
+const savedSeenLimit = 2000
+
+// savedSearch is a search that `sx saved run` repeats every Interval,
+// reporting only results it hasn't reported before.
+type savedSearch struct {
+	Name       string    `json:"name"`
+	Query      string    `json:"query"`
+	Interval   string    `json:"interval"`
+	Categories []string  `json:"categories,omitempty"`
+	Engine     string    `json:"engine,omitempty"`
+	Sites      []string  `json:"sites,omitempty"`
+	TimeRange  string    `json:"time_range,omitempty"`
+	LastRun    time.Time `json:"last_run,omitempty"`
+	Seen       []string  `json:"seen,omitempty"` // reported URLs, oldest first
+}
+
+// getSavedFile is where saved searches and the URLs they have seen are
+// kept.
+func getSavedFile() string {
+	return filepath.Join(getStateDir(), "saved.json")
+}
+
+// parseInterval parses a saved search interval: a Go duration ("12h",
+// "90m") or a whole number of days or weeks ("1d", "2w").
+func parseInterval(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, suffix)); err == nil && strings.HasSuffix(s, suffix) {
+			if n <= 0 {
+				return 0, fmt.Errorf("interval %q must be positive", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q (use e.g. 30m, 12h, 1d, 1w)", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("interval %q must be positive", s)
+	}
+	return d, nil
+}
+
+// due reports whether the search should run at now.
+func (s *savedSearch) due(now time.Time) bool {
+	interval, err := parseInterval(s.Interval)
+	return err != nil || s.LastRun.IsZero() || now.Sub(s.LastRun) >= interval
+}
+
+// newResults returns the results whose URLs s hasn't seen and records
+// them as seen.
+func (s *savedSearch) newResults(results []SearchResult) []SearchResult {
+	seen := make(map[string]bool, len(s.Seen))
+	for _, u := range s.Seen {
+		seen[u] = true
+	}
+	var fresh []SearchResult
+	for _, result := range results {
+		if result.URL == "" || seen[result.URL] {
+			continue
+		}
+		seen[result.URL] = true
+		s.Seen = append(s.Seen, result.URL)
+		fresh = append(fresh, result)
+	}
+	if len(s.Seen) > savedSeenLimit {
+		s.Seen = s.Seen[len(s.Seen)-savedSeenLimit:]
+	}
+	return fresh
+}
+
+func loadSavedSearches(path string) ([]savedSearch, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var searches []savedSearch
+	if err := json.Unmarshal(data, &searches); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return searches, nil
+}
+
+// storeSavedSearches replaces the saved searches file, through a temporary
+// file of its own so an interrupted run can't leave it truncated. Callers
+// hold the file's lock (lockFile) from loading it until it is stored.
+func storeSavedSearches(path string, searches []savedSearch) error {
+	data, err := json.MarshalIndent(searches, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(append(data, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// findSavedSearch returns the index of the saved search named ref, or
+// numbered ref as in `sx saved list`, or -1.
+func findSavedSearch(searches []savedSearch, ref string) int {
+	for i, s := range searches {
+		if s.Name == ref {
+			return i
+		}
+	}
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(searches) {
+		return n - 1
+	}
+	return -1
+}
+
+// addSavedSearch validates s and adds it, replacing a saved search of the
+// same name.
+func addSavedSearch(s savedSearch) error {
+	if strings.TrimSpace(s.Query) == "" {
+		return fmt.Errorf("no query given")
+	}
+	if s.Name == "" {
+		s.Name = s.Query
+	}
+	if _, err := parseInterval(s.Interval); err != nil {
+		return err
+	}
+	for i, category := range s.Categories {
+		if !validateCategory(category) {
+			return fmt.Errorf("invalid category '%s'. Supported categories are: %s", category, strings.Join(searxngCategories, ", "))
+		}
+		s.Categories[i] = normalizeCategory(category)
+	}
+	if s.TimeRange != "" {
+		if !validateTimeRange(s.TimeRange) {
+			return fmt.Errorf("invalid time range '%s'. Use: %s", s.TimeRange, strings.Join(timeRangeOptions, ", "))
+		}
+		s.TimeRange = expandTimeRange(s.TimeRange)
+	}
+
+	path := getSavedFile()
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	searches, err := loadSavedSearches(path)
+	if err != nil {
+		return err
+	}
+	if i := findSavedSearch(searches, s.Name); i >= 0 && searches[i].Name == s.Name {
+		searches[i] = s
+	} else {
+		searches = append(searches, s)
+	}
+	if err := storeSavedSearches(path, searches); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Saved %q, run every %s.\n", s.Name, s.Interval)
+	return nil
+}
+
+func removeSavedSearch(ref string) error {
+	path := getSavedFile()
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	searches, err := loadSavedSearches(path)
+	if err != nil {
+		return err
+	}
+	i := findSavedSearch(searches, ref)
+	if i < 0 {
+		return fmt.Errorf("no saved search %q", ref)
+	}
+	name := searches[i].Name
+	searches = append(searches[:i], searches[i+1:]...)
+	if err := storeSavedSearches(path, searches); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Removed %q.\n", name)
+	return nil
+}
+
+func printSavedSearches(w io.Writer) error {
+	searches, err := loadSavedSearches(getSavedFile())
+	if err != nil {
+		return err
+	}
+	if len(searches) == 0 {
+		fmt.Fprintln(os.Stderr, "No saved searches.")
+		return nil
+	}
+	for i, s := range searches {
+		last := "never"
+		if !s.LastRun.IsZero() {
+			last = s.LastRun.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%3d. %s  every %s, last run %s, %d seen\n", i+1, s.Name, s.Interval, last, len(s.Seen))
+		if s.Name != s.Query {
+			fmt.Fprintf(w, "     %s\n", s.Query)
+		}
+	}
+	return nil
+}
+
+// runSavedSearches runs the saved searches that are due, or those named by
+// refs, or all of them with force, and delivers the results each hasn't
+// reported before to sinks, as one section per search. Searches with
+// nothing new deliver nothing, so a cron job only mails when something
+// turned up. A search that fails, or whose new results can't be delivered,
+// keeps its last run time and seen URLs and is retried next time. The
+// saved searches file stays locked for the whole run, so overlapping runs
+// and edits wait for each other instead of losing each other's changes.
+func runSavedSearches(refs []string, force, asJSON bool, sinks []sink) error {
+	path := getSavedFile()
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	searches, err := loadSavedSearches(path)
+	if err != nil {
+		return err
+	}
+	selected := make([]bool, len(searches))
+	for _, ref := range refs {
+		i := findSavedSearch(searches, ref)
+		if i < 0 {
+			return fmt.Errorf("no saved search %q", ref)
+		}
+		selected[i] = true
+	}
+
+	installTransports(config)
+	backendMgr = initBackendManager(config)
+
+	now := time.Now()
+	var sections []querySection
+	// ran holds the searches that ran, updated, until their new results
+	// are delivered
+	type savedRun struct {
+		index   int
+		updated savedSearch
+		found   bool // new results to deliver
+	}
+	var ran []savedRun
+	failed := 0
+	for i := range searches {
+		s := &searches[i]
+		if len(refs) > 0 && !selected[i] || len(refs) == 0 && !force && !s.due(now) {
+			continue
+		}
+		opts := SearchOptions{
+			Categories:     s.Categories,
+			Sites:          s.Sites,
+			TimeRange:      s.TimeRange,
+			ExplicitEngine: s.Engine,
+			SafeSearch:     config.SafeSearch,
+			PageNo:         1,
+		}
+		if err := applyProfile(config, "", &opts, false); err != nil {
+			return err
+		}
+		state := searchState{Query: s.Query}
+		results, err := fetchResults(&state, nil, config.ResultCount, &opts, config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Search error for %q: %v\n", s.Name, err)
+			failed++
+			continue
+		}
+		updated := *s
+		updated.Seen = slices.Clone(s.Seen)
+		updated.LastRun = now
+		fresh := updated.newResults(results)
+		if len(fresh) > 0 {
+			sections = append(sections, querySection{Query: s.Name, Engine: state.Engine, Results: fresh, RequestID: state.RequestID})
+		}
+		ran = append(ran, savedRun{i, updated, len(fresh) > 0})
+	}
+
+	var deliverErr error
+	if len(sections) > 0 {
+		names := make([]string, len(sections))
+		for i, s := range sections {
+			names[i] = s.Query
+		}
+		batch := resultBatch{Sections: sections, Label: strings.Join(names, " + "), Opts: &SearchOptions{JSON: asJSON}, Time: now}
+		deliverErr = deliverAll(sinks, batch)
+	}
+	// Results are only recorded as seen once delivered; when a sink fails,
+	// only the searches that had nothing to deliver count as run
+	for _, r := range ran {
+		if deliverErr == nil || !r.found {
+			searches[r.index] = r.updated
+		}
+	}
+	if err := storeSavedSearches(path, searches); err != nil {
+		return err
+	}
+	if deliverErr != nil {
+		return deliverErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d saved search(es) failed", failed)
+	}
+	return nil
+}
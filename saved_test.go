@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"sx/backends"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := map[string]time.Duration{
+		"1d":    24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"12h":   12 * time.Hour,
+		"1h30m": 90 * time.Minute,
+	}
+	for in, want := range tests {
+		if got, err := parseInterval(in); err != nil || got != want {
+			t.Errorf("parseInterval(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0d", "-1h", "2x", "d"} {
+		if _, err := parseInterval(in); err == nil {
+			t.Errorf("parseInterval(%q): expected an error", in)
+		}
+	}
+}
+
+func TestSavedSearchDue(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := savedSearch{Interval: "1d"}
+	if !s.due(now) {
+		t.Error("never run: not due")
+	}
+	s.LastRun = now.Add(-23 * time.Hour)
+	if s.due(now) {
+		t.Error("run 23h ago with a 1d interval: due")
+	}
+	s.LastRun = now.Add(-24 * time.Hour)
+	if !s.due(now) {
+		t.Error("run 24h ago with a 1d interval: not due")
+	}
+}
+
+func TestSavedSearchNewResults(t *testing.T) {
+	s := savedSearch{Seen: []string{"https://a.example/"}}
+	fresh := s.newResults([]SearchResult{
+		{URL: "https://a.example/"},
+		{URL: "https://b.example/"},
+		{URL: "https://b.example/"},
+		{URL: ""},
+	})
+	if len(fresh) != 1 || fresh[0].URL != "https://b.example/" {
+		t.Errorf("fresh = %v", fresh)
+	}
+	if fmt.Sprint(s.Seen) != "[https://a.example/ https://b.example/]" {
+		t.Errorf("seen = %v", s.Seen)
+	}
+	if fresh := s.newResults([]SearchResult{{URL: "https://b.example/"}}); len(fresh) != 0 {
+		t.Errorf("seen results reported again: %v", fresh)
+	}
+
+	// The oldest URLs are forgotten beyond the limit
+	var many []SearchResult
+	for i := 0; i < savedSeenLimit+5; i++ {
+		many = append(many, SearchResult{URL: fmt.Sprintf("https://example.com/%d", i)})
+	}
+	s.newResults(many)
+	if len(s.Seen) != savedSeenLimit || s.Seen[len(s.Seen)-1] != many[len(many)-1].URL {
+		t.Errorf("seen has %d URLs, last %q", len(s.Seen), s.Seen[len(s.Seen)-1])
+	}
+}
+
+func TestAddSavedSearchConcurrently(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := addSavedSearch(savedSearch{Name: fmt.Sprint(i), Query: "golang", Interval: "1d"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	searches, err := loadSavedSearches(getSavedFile())
+	if err != nil || len(searches) != 8 {
+		t.Errorf("got %d searches, %v", len(searches), err)
+	}
+	if tmp, _ := filepath.Glob(getSavedFile() + "*.tmp"); len(tmp) > 0 {
+		t.Errorf("temporary files left: %v", tmp)
+	}
+}
+
+func TestSavedSearchesStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "saved.json")
+	if searches, err := loadSavedSearches(path); err != nil || searches != nil {
+		t.Fatalf("missing file: %v, %v", searches, err)
+	}
+
+	searches := []savedSearch{
+		{Name: "go", Query: "golang release", Interval: "1d", Seen: []string{"https://go.dev/"}},
+		{Name: "12", Query: "twelve", Interval: "1w"},
+	}
+	if err := storeSavedSearches(path, searches); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadSavedSearches(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 2 || loaded[0].Query != "golang release" || loaded[0].Seen[0] != "https://go.dev/" {
+		t.Errorf("loaded = %+v", loaded)
+	}
+
+	if i := findSavedSearch(loaded, "go"); i != 0 {
+		t.Errorf("by name: %d", i)
+	}
+	if i := findSavedSearch(loaded, "2"); i != 1 {
+		t.Errorf("by number: %d", i)
+	}
+	if i := findSavedSearch(loaded, "12"); i != 1 {
+		t.Errorf("names win over numbers: %d", i)
+	}
+	if i := findSavedSearch(loaded, "rust"); i != -1 {
+		t.Errorf("unknown: %d", i)
+	}
+}
+
+func TestRunSavedSearchesRecordsDeliveredResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": [{"title": "Go 1.23", "url": "https://go.dev/doc/go1.23"}]}`))
+	}))
+	defer server.Close()
+	saved := config
+	defer func() {
+		config = saved
+		backends.TransportWrapper, backends.SearxngTransportWrapper = nil, nil
+	}()
+	config = getDefaultConfig()
+	config.SearxngURL = server.URL
+	config.FallbackEngines = nil
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path := getSavedFile()
+	if err := storeSavedSearches(path, []savedSearch{{Name: "go", Query: "golang release", Interval: "1d"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A sink that fails leaves the results to be reported next time
+	if err := runSavedSearches(nil, true, false, []sink{failingSink{}}); err == nil {
+		t.Fatal("failing sink: no error")
+	}
+	searches, _ := loadSavedSearches(path)
+	if len(searches[0].Seen) != 0 || !searches[0].LastRun.IsZero() {
+		t.Errorf("undelivered results recorded: %+v", searches[0])
+	}
+
+	out := filepath.Join(t.TempDir(), "new.txt")
+	if err := runSavedSearches(nil, true, false, []sink{fileSink{Path: out}}); err != nil {
+		t.Fatal(err)
+	}
+	searches, _ = loadSavedSearches(path)
+	if len(searches[0].Seen) != 1 || searches[0].LastRun.IsZero() {
+		t.Errorf("delivered results not recorded: %+v", searches[0])
+	}
+	if data, err := os.ReadFile(out); err != nil || len(data) == 0 {
+		t.Errorf("delivered %q, %v", data, err)
+	}
+
+	// With nothing new to deliver, a failing sink doesn't matter
+	if err := runSavedSearches(nil, true, false, []sink{failingSink{}}); err != nil {
+		t.Errorf("nothing new: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -28,6 +29,7 @@ var categoryAliases = map[string]string{
 // initBackendManager creates and configures the backend manager from config
 func initBackendManager(config *Config) *backends.Manager {
 	mgr := backends.NewManager()
+	backends.RootCAs = rootCAs(config)
 
 	// Register SearXNG backend (single or multi-instance)
 	searxngURLs := make([]string, 0, len(config.SearxngURLs)+1)
@@ -56,6 +58,13 @@ func initBackendManager(config *Config) *backends.Manager {
 		config.NoUserAgent,
 		searxngStrategy,
 	)
+	searxng.SetPreferences(config.SearxngPreferences, config.SearxngCookie)
+	if config.TorProxy != "" {
+		searxng.SetTorProxy(config.TorProxy)
+	}
+	if err := searxng.SetClientCert(config.SearxngClientCert, config.SearxngClientKey); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 	mgr.Register(searxng)
 
 	// Register Brave backend
@@ -69,6 +78,53 @@ func initBackendManager(config *Config) *backends.Manager {
 	)
 	mgr.Register(brave)
 
+	// Register Google Programmable Search backend
+	googleAPIKey := config.EnginesGoogle.APIKey
+	if envKey := os.Getenv("GOOGLE_API_KEY"); envKey != "" {
+		googleAPIKey = envKey
+	}
+	googleCX := config.EnginesGoogle.CX
+	if envCX := os.Getenv("GOOGLE_CSE_ID"); envCX != "" {
+		googleCX = envCX
+	}
+	mgr.Register(backends.NewGoogleCSEBackend(
+		googleAPIKey,
+		googleCX,
+		time.Duration(config.Timeout)*time.Second,
+	))
+
+	// Register SerpAPI backend
+	serpAPIKey := config.EnginesSerpAPI.APIKey
+	if envKey := os.Getenv("SERPAPI_API_KEY"); envKey != "" {
+		serpAPIKey = envKey
+	}
+	mgr.Register(backends.NewSerpAPIBackend(
+		serpAPIKey,
+		config.EnginesSerpAPI.Engine,
+		time.Duration(config.Timeout)*time.Second,
+	))
+
+	// Register Mojeek backend
+	mojeekAPIKey := config.EnginesMojeek.APIKey
+	if envKey := os.Getenv("MOJEEK_API_KEY"); envKey != "" {
+		mojeekAPIKey = envKey
+	}
+	mgr.Register(backends.NewMojeekBackend(
+		mojeekAPIKey,
+		time.Duration(config.Timeout)*time.Second,
+	))
+
+	// Register Marginalia backend
+	marginaliaAPIKey := config.EnginesMarginalia.APIKey
+	if envKey := os.Getenv("MARGINALIA_API_KEY"); envKey != "" {
+		marginaliaAPIKey = envKey
+	}
+	mgr.Register(backends.NewMarginaliaBackend(
+		marginaliaAPIKey,
+		config.EnginesMarginalia.Index,
+		time.Duration(config.Timeout)*time.Second,
+	))
+
 	// Register Tavily backend
 	tavilyAPIKey := config.EnginesTavily.APIKey
 	if envKey := os.Getenv("TAVILY_API_KEY"); envKey != "" {
@@ -85,6 +141,7 @@ func initBackendManager(config *Config) *backends.Manager {
 		config.EnginesTavily.IncludeRawContent,
 		config.EnginesTavily.IncludeAnswer,
 	)
+	tavily.Topic = config.EnginesTavily.Topic
 	mgr.Register(tavily)
 
 	// Register Exa backend (API + MCP + auto mode)
@@ -100,6 +157,9 @@ func initBackendManager(config *Config) *backends.Manager {
 		config.EnginesExa.MCPTool,
 		config.EnginesExa.NumResults,
 	)
+	exa.Type = config.EnginesExa.Type
+	exa.UseAutoprompt = config.EnginesExa.UseAutoprompt
+	exa.Highlights = config.EnginesExa.Highlights
 	mgr.Register(exa)
 
 	// Register keyless scraper backends (no API key or configuration needed)
@@ -119,6 +179,116 @@ func initBackendManager(config *Config) *backends.Manager {
 	)
 	mgr.Register(jina)
 
+	// Register Perplexity backend
+	perplexityAPIKey := config.EnginesPerplexity.APIKey
+	if envKey := os.Getenv("PERPLEXITY_API_KEY"); envKey != "" {
+		perplexityAPIKey = envKey
+	}
+	mgr.Register(backends.NewPerplexityBackend(
+		perplexityAPIKey,
+		config.EnginesPerplexity.Model,
+		time.Duration(config.Timeout)*time.Second,
+	))
+
+	// Register arXiv backend (also mixed into science searches)
+	mgr.Register(backends.NewArxivBackend(time.Duration(config.Timeout) * time.Second))
+
+	// Register Crossref backend (unpaywall_email as the contact address)
+	mgr.Register(backends.NewCrossrefBackend(unpaywallEmail(config), time.Duration(config.Timeout)*time.Second))
+
+	// Register podcasts backend (iTunes Search, also mixed into music searches)
+	mgr.Register(backends.NewPodcastsBackend(time.Duration(config.Timeout) * time.Second))
+
+	// Register GitHub backend (token optional, except for code search)
+	githubToken := config.EnginesGitHub.Token
+	if envKey := os.Getenv("GITHUB_TOKEN"); envKey != "" {
+		githubToken = envKey
+	}
+	mgr.Register(backends.NewGitHubBackend(
+		githubToken,
+		config.EnginesGitHub.Type,
+		time.Duration(config.Timeout)*time.Second,
+	))
+
+	// Register MusicBrainz and lyrics backends (also mixed into music searches)
+	mgr.Register(backends.NewMusicBrainzBackend(time.Duration(config.Timeout) * time.Second))
+	mgr.Register(backends.NewLyricsBackend(time.Duration(config.Timeout) * time.Second))
+
+	// Register Stack Exchange backend (key optional)
+	stackexchangeKey := config.EnginesStackExchange.Key
+	if envKey := os.Getenv("STACKEXCHANGE_KEY"); envKey != "" {
+		stackexchangeKey = envKey
+	}
+	mgr.Register(backends.NewStackExchangeBackend(
+		stackexchangeKey,
+		config.EnginesStackExchange.Sites,
+		time.Duration(config.Timeout)*time.Second,
+	))
+
+	// Register the CVE backend (NVD and OSV, API key optional)
+	cveKey := config.EnginesCVE.APIKey
+	if envKey := os.Getenv("NVD_API_KEY"); envKey != "" {
+		cveKey = envKey
+	}
+	mgr.Register(backends.NewCVEBackend(cveKey, time.Duration(config.Timeout)*time.Second))
+
+	// Register the Elasticsearch/OpenSearch backend (a configured index)
+	elastic := backends.NewElasticBackend(
+		config.EnginesElastic.URL,
+		config.EnginesElastic.Index,
+		time.Duration(config.Timeout)*time.Second,
+	)
+	elastic.Query = config.EnginesElastic.Query
+	elastic.APIKey = config.EnginesElastic.APIKey
+	if envKey := os.Getenv("ELASTIC_API_KEY"); envKey != "" {
+		elastic.APIKey = envKey
+	}
+	elastic.Username = config.EnginesElastic.Username
+	elastic.Password = config.EnginesElastic.Password
+	if envKey := os.Getenv("ELASTIC_PASSWORD"); envKey != "" {
+		elastic.Password = envKey
+	}
+	elastic.TitleField = config.EnginesElastic.TitleField
+	elastic.URLField = config.EnginesElastic.URLField
+	elastic.ContentField = config.EnginesElastic.ContentField
+	elastic.DateField = config.EnginesElastic.DateField
+	if err := elastic.SetClientCert(config.EnginesElastic.ClientCert, config.EnginesElastic.ClientKey); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: elastic: %v\n", err)
+	}
+	mgr.Register(elastic)
+
+	// Register the Meilisearch backend (a configured index)
+	meilisearchKey := config.EnginesMeilisearch.APIKey
+	if envKey := os.Getenv("MEILISEARCH_API_KEY"); envKey != "" {
+		meilisearchKey = envKey
+	}
+	meilisearch := backends.NewMeilisearchBackend(
+		config.EnginesMeilisearch.URL,
+		config.EnginesMeilisearch.Index,
+		meilisearchKey,
+		time.Duration(config.Timeout)*time.Second,
+	)
+	meilisearch.TitleField = config.EnginesMeilisearch.TitleField
+	meilisearch.URLField = config.EnginesMeilisearch.URLField
+	meilisearch.ContentField = config.EnginesMeilisearch.ContentField
+	meilisearch.DateField = config.EnginesMeilisearch.DateField
+	if err := meilisearch.SetClientCert(config.EnginesMeilisearch.ClientCert, config.EnginesMeilisearch.ClientKey); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: meilisearch: %v\n", err)
+	}
+	mgr.Register(meilisearch)
+
+	// Register the app store backend (Flathub and F-Droid)
+	mgr.Register(backends.NewAppsBackend(config.EnginesApps.Stores, time.Duration(config.Timeout)*time.Second))
+
+	// Register the bookmarks backend (the index `sx bookmarks import` writes)
+	mgr.Register(backends.NewBookmarksBackend(getBookmarksFile()))
+
+	// Register the notes backend (a notes directory, indexed in the state dir)
+	mgr.Register(backends.NewNotesBackend(expandHome(config.EnginesNotes.Dir), getNotesIndexFile()))
+
+	// Register the man backend (apropos and tldr pages, mixed into IT searches)
+	mgr.Register(backends.NewManBackend(tldrDirs(config)))
+
 	// Set primary engine
 	engine := config.Engine
 	if engine == "" {
@@ -135,32 +305,307 @@ func initBackendManager(config *Config) *backends.Manager {
 			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 		}
 	}
+	if err := mgr.SetFallbackOn(config.FallbackOn); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, falling back on every failure\n", err)
+	}
 
 	return mgr
 }
 
 // performSearch executes a search using the backend manager
-func performSearch(query string, config *Config, searchOpts *SearchOptions, mgr *backends.Manager, explicitEngine string) ([]backends.SearchResult, string, error) {
+func performSearch(query string, config *Config, searchOpts *SearchOptions, mgr *backends.Manager, explicitEngine string) (*backends.SearchResponse, string, error) {
 	opts := backends.SearchOptions{
-		Query:      query,
-		Categories: searchOpts.Categories,
-		Engines:    searchOpts.SearxngEngines,
-		Language:   searchOpts.Language,
-		TimeRange:  searchOpts.TimeRange,
-		Site:       searchOpts.Site,
-		SafeSearch: searchOpts.SafeSearch,
-		PageNo:     searchOpts.PageNo,
-		NumResults: config.ResultCount,
-	}
-
-	// If an explicit engine was requested via --engine flag, use only that
+		Query:        query,
+		Categories:   searchOpts.Categories,
+		Engines:      searchOpts.SearxngEngines,
+		Language:     searchOpts.Language,
+		Country:      searchOpts.Country,
+		TimeRange:    searchOpts.TimeRange,
+		Sites:        searchOpts.Sites,
+		ExcludeSites: searchOpts.ExcludeSites,
+		AllOf:        searchOpts.AllOf,
+		AnyOf:        searchOpts.AnyOf,
+		NoneOf:       searchOpts.NoneOf,
+		Exact:        searchOpts.Exact,
+		Synonyms:     searchOpts.Synonyms,
+		SafeSearch:   searchOpts.SafeSearch,
+		PageNo:       searchOpts.PageNo,
+		NumResults:   config.ResultCount,
+	}
+	// "bookmarks", "notes", "elastic", "meilisearch", "github",
+	// "stackexchange", "cve" or "apps" among the SearXNG engines mixes the
+	// user's own bookmarks, notes or search indexes, or sx's GitHub, Stack
+	// Exchange, advisory or app store search, into the web results
+	var mixed []string
+	opts.Engines, mixed = withoutMixedEngines(opts.Engines)
+	if explicitEngine == "" && len(opts.Engines) == 0 {
+		// Unlike engines asked for by name, a category's backends are
+		// left out quietly where they can't search, e.g. without man pages
+		for _, name := range categoryBackends(opts.Categories) {
+			if backend, ok := mgr.GetBackend(name); ok && backend.IsAvailable() {
+				mixed = append(mixed, name)
+			}
+		}
+	}
+
+	var resp *backends.SearchResponse
+	var engine string
+	var err error
 	if explicitEngine != "" {
-		results, err := mgr.SearchExplicit(explicitEngine, opts)
-		return results, explicitEngine, err
+		// If an explicit engine was requested via --engine flag, use only that
+		resp, err = mgr.SearchExplicitDetailed(explicitEngine, opts)
+		engine = explicitEngine
+	} else {
+		// Otherwise use primary + fallback chain
+		resp, engine, err = mgr.SearchDetailed(opts)
+	}
+	if err == nil && len(mixed) > 0 {
+		mixInResults(resp, mgr, opts, mixed, engine)
 	}
+	if err != nil && explicitEngine == "" && len(mixed) > 0 {
+		// With the web search failing, e.g. without a SearXNG instance,
+		// the mixed-in backends' results are still shown
+		fallback := &backends.SearchResponse{}
+		mixInResults(fallback, mgr, opts, mixed, "")
+		if len(fallback.Results) > 0 {
+			if opts.PageNo <= 1 {
+				printNotice("%s", tr("web_failed", strings.Join(mixed, ", "), err))
+			}
+			return fallback, strings.Join(mixed, ", "), nil
+		}
+	}
+	return resp, engine, err
+}
 
-	// Otherwise use primary + fallback chain
-	return mgr.Search(opts)
+// mixedEngines are the backends the SearXNG engine list mixes into web
+// results: those searching the user's own data, including Elasticsearch
+// and Meilisearch indexes, GitHub, which stands in for SearXNG's engine of
+// that name, Stack Exchange, security advisories and the app stores
+var mixedEngines = []string{"bookmarks", "notes", "elastic", "meilisearch", "github", "stackexchange", "cve", "apps"}
+
+// withoutMixedEngines removes the mixed-in engines from a list of SearXNG
+// engines, returning the ones that were there.
+func withoutMixedEngines(engines []string) (web, mixed []string) {
+	for _, engine := range engines {
+		name := strings.ToLower(strings.TrimSpace(engine))
+		if slices.Contains(mixedEngines, name) {
+			if !slices.Contains(mixed, name) {
+				mixed = append(mixed, name)
+			}
+		} else {
+			web = append(web, engine)
+		}
+	}
+	if mixed == nil {
+		return engines, nil
+	}
+	return web, mixed
+}
+
+// categoryEngines are backends of their own for SearXNG categories. A
+// search of the category mixes their results in, unless it names SearXNG
+// engines or a backend.
+var categoryEngines = map[string][]string{
+	"science": {"arxiv"},
+	"it":      {"man"},
+	"music":   {"musicbrainz", "lyrics", "podcasts"},
+}
+
+// categoryBackends returns the backends of the given categories
+func categoryBackends(categories []string) []string {
+	var names []string
+	for _, category := range categories {
+		for _, name := range categoryEngines[normalizeCategory(category)] {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// mixInResults mixes the results of the named backends matching opts into
+// a page of results from engine, taking turns starting with a mixed-in
+// result. A page found both ways is listed once, as the mixed-in result.
+func mixInResults(resp *backends.SearchResponse, mgr *backends.Manager, opts backends.SearchOptions, names []string, engine string) {
+	var mixed []SearchResult
+	for _, name := range names {
+		backend, ok := mgr.GetBackend(name)
+		if !ok || name == engine {
+			continue
+		}
+		results, err := backend.Search(opts)
+		if err != nil {
+			if opts.PageNo <= 1 {
+				printNotice("%v", err)
+			}
+			continue
+		}
+		mixed = interleaveResults(mixed, results)
+	}
+	resp.Results = interleaveResults(mixed, resp.Results)
+}
+
+// interleaveResults alternates between local and web results, dropping web
+// results for pages already in local and crediting their engines to the
+// local result.
+func interleaveResults(local, web []SearchResult) []SearchResult {
+	index := make(map[string]int, len(local))
+	for i, r := range local {
+		index[r.URL] = i
+	}
+	var rest []SearchResult
+	for _, r := range web {
+		if i, ok := index[r.URL]; ok {
+			local[i].Engines = append(local[i].Engines, r.Engines...)
+			continue
+		}
+		rest = append(rest, r)
+	}
+	merged := make([]SearchResult, 0, len(local)+len(rest))
+	for i := 0; i < max(len(local), len(rest)); i++ {
+		if i < len(local) {
+			merged = append(merged, local[i])
+		}
+		if i < len(rest) {
+			merged = append(merged, rest[i])
+		}
+	}
+	return merged
+}
+
+// searchState carries what a search learns while paging: the engine that
+// answered first and the first page's corrections, suggestions and answers.
+// Query changes when --autocorrect applies a correction.
+type searchState struct {
+	Query         string
+	Engine        string
+	Corrections   []string
+	Suggestions   []string
+	Answers       []string
+	Fallbacks     []backends.FallbackAttempt // backends tried before Engine
+	RequestID     string                     // sent as X-Request-ID, see startRequestID
+	autocorrected bool
+	exhausted     bool // a page added nothing new; later pages aren't fetched
+	// sharedRequestID keeps RequestID, set for several concurrent searches
+	// (--all-categories), instead of starting a new one
+	sharedRequestID bool
+}
+
+// fetchResults pages through search results, cleaning, deduplicating and
+// filtering each page, until results holds want entries or a page adds
+// nothing new. With result_count 0 a single page is fetched. Once a page has
+// added nothing, asking for further pages of the same search fetches none.
+func fetchResults(state *searchState, results []SearchResult, want int, opts *SearchOptions, config *Config) ([]SearchResult, error) {
+	if opts.PageNo <= 1 {
+		state.exhausted = false
+		if !state.sharedRequestID {
+			state.RequestID = startRequestID()
+		}
+	}
+	for len(results) < want && !state.exhausted {
+		resp, engine, err := performSearch(state.Query, config, opts, backendMgr, opts.ExplicitEngine)
+		if err != nil {
+			return results, err
+		}
+		if state.Engine == "" {
+			state.Engine = engine
+		}
+		page := resp.Results
+
+		if opts.PageNo == 1 {
+			state.Corrections, state.Suggestions = resp.Corrections, resp.Suggestions
+			state.Answers = resp.Answers
+			state.Fallbacks = resp.Fallbacks
+			if len(resp.Fallbacks) > 0 && len(resp.Results) > 0 {
+				printNotice("%s", fallbackNotice(resp.Fallbacks, engine))
+			}
+			if answer, ok := instantAnswer(state.Query); ok {
+				state.Answers = mergeAnswers([]string{answer}, state.Answers)
+			}
+			// Apply the first correction once and search again
+			if opts.Autocorrect && !state.autocorrected && len(state.Corrections) > 0 {
+				fmt.Fprintf(os.Stderr, "Showing results for %q (autocorrected from %q)\n", state.Corrections[0], state.Query)
+				state.Query = state.Corrections[0]
+				state.autocorrected = true
+				continue
+			}
+			// answer_engines add their answers to the engine's, e.g.
+			// Tavily's answer to SearXNG's infoboxes
+			state.Answers = mergeAnswers(state.Answers, engineAnswers(state.Query, opts, config, backendMgr, engine))
+		}
+
+		if config.CleanURLs {
+			page = normalizeResults(page)
+		}
+
+		// Stop once a page adds nothing new: backends that ignore the
+		// page number keep returning the same results
+		page = dedupeResults(results, page)
+		if len(page) == 0 {
+			state.exhausted = true
+			break
+		}
+
+		page = filterExcludedSites(page, opts.ExcludeSites)
+		page = filterExcludedSites(page, opts.BlockedDomains)
+		page = filterNoneOf(page, opts.NoneOf)
+		page = filterResultLanguages(page, opts.ResultLang, config)
+		if config.CollapseTitles {
+			page = collapseDuplicates(results, page)
+		}
+		tagDomains(page, config.DomainTags)
+		results = append(results, page...)
+		if config.ResultCount == 0 {
+			break
+		}
+		opts.PageNo++
+	}
+	return results, nil
+}
+
+// fallbackNotice tells where results came from when the primary backend
+// didn't provide them: "primary searxng failed (timeout), used brave-web".
+func fallbackNotice(attempts []backends.FallbackAttempt, used string) string {
+	parts := make([]string, len(attempts))
+	for i, attempt := range attempts {
+		if attempt.Reason == "no results" {
+			parts[i] = attempt.Backend + " returned no results"
+		} else {
+			parts[i] = fmt.Sprintf("%s failed (%s)", attempt.Backend, attempt.Reason)
+		}
+	}
+	return "primary " + strings.Join(parts, ", ") + ", used " + used
+}
+
+// hasQueryTerms reports whether any query builder flag was given, in which
+// case a search can run without a positional query.
+func (o *SearchOptions) hasQueryTerms() bool {
+	return len(o.AllOf) > 0 || len(o.AnyOf) > 0 || len(o.Exact) > 0
+}
+
+// displayQuery renders the query together with the query builder terms, as
+// shown in output and recorded in history.
+func displayQuery(query string, opts *SearchOptions) string {
+	return backends.ComposeQuery(backends.SearchOptions{
+		Query:  query,
+		AllOf:  opts.AllOf,
+		AnyOf:  opts.AnyOf,
+		NoneOf: opts.NoneOf,
+		Exact:  opts.Exact,
+	})
+}
+
+// splitList splits a comma-separated list, trimming whitespace and dropping
+// empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
 }
 
 func validateCategory(category string) bool {
@@ -213,5 +658,5 @@ func expandTimeRange(timeRange string) string {
 
 // validEngineNames returns all valid engine names for help text
 func validEngineNames() string {
-	return strings.Join([]string{"searxng", "bing", "brave-web", "brave", "tavily", "exa", "jina"}, ", ")
+	return strings.Join([]string{"searxng", "bing", "brave-web", "brave", "google", "serpapi", "mojeek", "marginalia", "tavily", "exa", "jina", "perplexity", "arxiv", "crossref", "podcasts", "musicbrainz", "lyrics", "man", "github", "stackexchange", "cve", "apps", "elastic", "meilisearch", "bookmarks", "notes"}, ", ")
 }
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"errors"
+	"reflect"
 	"testing"
+
+	"sx/backends"
 )
 
 func TestValidateCategory(t *testing.T) {
@@ -100,3 +104,100 @@ func searchString(s, substr string) bool {
 	}
 	return false
 }
+
+func TestFallbackNotice(t *testing.T) {
+	got := fallbackNotice([]backends.FallbackAttempt{
+		{Backend: "searxng", Reason: "timeout"},
+		{Backend: "brave-web", Reason: "no results"},
+	}, "bing")
+	want := "primary searxng failed (timeout), brave-web returned no results, used bing"
+	if got != want {
+		t.Errorf("fallbackNotice() = %q, want %q", got, want)
+	}
+}
+
+func TestInterleaveResults(t *testing.T) {
+	local := []SearchResult{
+		{URL: "https://go.dev/", Engines: []string{"bookmarks"}},
+		{URL: "https://pkg.go.dev/", Engines: []string{"bookmarks"}},
+		{URL: "https://gobyexample.com/", Engines: []string{"bookmarks"}},
+	}
+	web := []SearchResult{
+		{URL: "https://en.wikipedia.org/wiki/Go", Engines: []string{"google"}},
+		{URL: "https://go.dev/", Engines: []string{"google", "bing"}},
+	}
+	var got []string
+	merged := interleaveResults(local, web)
+	for _, r := range merged {
+		got = append(got, r.URL)
+	}
+	want := []string{"https://go.dev/", "https://en.wikipedia.org/wiki/Go", "https://pkg.go.dev/", "https://gobyexample.com/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(merged[0].Engines, []string{"bookmarks", "google", "bing"}) {
+		t.Errorf("engines of a page found both ways: %q", merged[0].Engines)
+	}
+}
+
+func TestWithoutMixedEngines(t *testing.T) {
+	if web, mixed := withoutMixedEngines([]string{"google", "Bookmarks", "notes", "bing", "bookmarks", "Elastic", "meilisearch", "GitHub", "stackexchange", "CVE", "apps"}); !reflect.DeepEqual(web, []string{"google", "bing"}) || !reflect.DeepEqual(mixed, []string{"bookmarks", "notes", "elastic", "meilisearch", "github", "stackexchange", "cve", "apps"}) {
+		t.Errorf("got %q, %q", web, mixed)
+	}
+	if web, mixed := withoutMixedEngines([]string{"bookmarks"}); web != nil || len(mixed) != 1 {
+		t.Errorf("only bookmarks: %q, %q", web, mixed)
+	}
+	if web, mixed := withoutMixedEngines([]string{"google"}); mixed != nil || len(web) != 1 {
+		t.Errorf("no mixed-in engines: %q, %q", web, mixed)
+	}
+}
+
+func TestCategoryBackends(t *testing.T) {
+	if got := categoryBackends([]string{"general", "science", "science"}); !reflect.DeepEqual(got, []string{"arxiv"}) {
+		t.Errorf("got %q", got)
+	}
+	if got := categoryBackends([]string{"it", "science"}); !reflect.DeepEqual(got, []string{"man", "arxiv"}) {
+		t.Errorf("it and science: %q", got)
+	}
+	if got := categoryBackends([]string{"music"}); !reflect.DeepEqual(got, []string{"musicbrainz", "lyrics", "podcasts"}) {
+		t.Errorf("music: %q", got)
+	}
+	if got := categoryBackends([]string{"news"}); got != nil {
+		t.Errorf("news: %q", got)
+	}
+}
+
+// stubBackend answers every search with its results or err
+type stubBackend struct {
+	name    string
+	results []SearchResult
+	err     error
+}
+
+func (s *stubBackend) Name() string      { return s.name }
+func (s *stubBackend) IsAvailable() bool { return true }
+func (s *stubBackend) Search(opts backends.SearchOptions) ([]SearchResult, error) {
+	return s.results, s.err
+}
+
+func TestPerformSearchWithoutWeb(t *testing.T) {
+	mgr := backends.NewManager()
+	mgr.Register(&stubBackend{name: "searxng", err: &backends.BackendError{Backend: "searxng", Err: errors.New("connection refused"), Code: backends.ErrCodeNetwork}})
+	mgr.Register(&stubBackend{name: "musicbrainz", results: []SearchResult{{Title: "Track", URL: "https://musicbrainz.org/recording/1"}}})
+	mgr.Register(&stubBackend{name: "lyrics"})
+	mgr.Register(&stubBackend{name: "podcasts"})
+	mgr.SetPrimary("searxng")
+	config := &Config{ResultCount: 10}
+
+	resp, engine, err := performSearch("track", config, &SearchOptions{Categories: []string{"music"}}, mgr, "")
+	if err != nil || len(resp.Results) != 1 || engine != "musicbrainz, lyrics, podcasts" {
+		t.Errorf("music without the web: %v, %q, %v", resp, engine, err)
+	}
+	// Nothing to fall back on for other categories, or a named engine
+	if _, _, err := performSearch("track", config, &SearchOptions{Categories: []string{"news"}}, mgr, ""); err == nil {
+		t.Error("news without the web should fail")
+	}
+	if _, _, err := performSearch("track", config, &SearchOptions{Categories: []string{"music"}}, mgr, "searxng"); err == nil {
+		t.Error("an explicit engine's failure should stand")
+	}
+}
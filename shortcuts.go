@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// shortcutCommands builds a subcommand for each configured shortcut. The
+// subcommands share the root command's search flags, so `sx yt -n 5 lofi`
+// works like `sx -n 5 lofi` with the shortcut's presets applied. Shortcuts
+// that would shadow a built-in command are skipped with a warning.
+func shortcutCommands(root *cobra.Command, shortcuts map[string]Shortcut) []*cobra.Command {
+	names := make([]string, 0, len(shortcuts))
+	for name := range shortcuts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var commands []*cobra.Command
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, " \t") {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring shortcut %q: names must be a single word\n", name)
+			continue
+		}
+		if name == "help" {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring shortcut %q: it conflicts with the %q command\n", name, name)
+			continue
+		}
+		if existing, _, err := root.Find([]string{name}); err == nil && existing != root {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring shortcut %q: it conflicts with the %q command\n", name, existing.Name())
+			continue
+		}
+
+		shortcut := shortcuts[name]
+		short := shortcut.Description
+		if short == "" {
+			short = shortcutSummary(shortcut)
+		}
+		cmd := &cobra.Command{
+			Use:                   name + " [query...]",
+			Short:                 short,
+			Args:                  cobra.ArbitraryArgs,
+			DisableFlagsInUseLine: true,
+			Run: func(cmd *cobra.Command, args []string) {
+				applyShortcut(&searchOpts, shortcut)
+				runSearch(cmd, args)
+			},
+		}
+		cmd.Flags().AddFlagSet(root.Flags())
+		commands = append(commands, cmd)
+	}
+	return commands
+}
+
+// applyShortcut fills in the shortcut's presets for anything not set on the
+// command line.
+func applyShortcut(opts *SearchOptions, shortcut Shortcut) {
+	if len(opts.Categories) == 0 {
+		opts.Categories = shortcut.Categories
+	}
+	if len(opts.SearxngEngines) == 0 {
+		opts.SearxngEngines = shortcut.Engines
+	}
+	if opts.ExplicitEngine == "" {
+		opts.ExplicitEngine = shortcut.Engine
+	}
+	if len(opts.Sites) == 0 {
+		opts.Sites = shortcut.Sites
+	}
+}
+
+// shortcutSummary describes a shortcut without a description for help output.
+func shortcutSummary(shortcut Shortcut) string {
+	var parts []string
+	if len(shortcut.Categories) > 0 {
+		parts = append(parts, "categories: "+strings.Join(shortcut.Categories, ", "))
+	}
+	if len(shortcut.Engines) > 0 {
+		parts = append(parts, "engines: "+strings.Join(shortcut.Engines, ", "))
+	}
+	if shortcut.Engine != "" {
+		parts = append(parts, "backend: "+shortcut.Engine)
+	}
+	if len(shortcut.Sites) > 0 {
+		parts = append(parts, "sites: "+strings.Join(shortcut.Sites, ", "))
+	}
+	if len(parts) == 0 {
+		return "Search shortcut"
+	}
+	return "Search shortcut (" + strings.Join(parts, "; ") + ")"
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestApplyShortcut(t *testing.T) {
+	shortcut := Shortcut{
+		Categories: []string{"videos"},
+		Engines:    []string{"youtube"},
+		Engine:     "searxng",
+		Sites:      []string{"youtube.com"},
+	}
+
+	opts := SearchOptions{}
+	applyShortcut(&opts, shortcut)
+	if !reflect.DeepEqual(opts.Categories, []string{"videos"}) || !reflect.DeepEqual(opts.SearxngEngines, []string{"youtube"}) ||
+		opts.ExplicitEngine != "searxng" || !reflect.DeepEqual(opts.Sites, []string{"youtube.com"}) {
+		t.Errorf("applyShortcut() did not apply presets: %+v", opts)
+	}
+
+	// Command line values win over presets
+	opts = SearchOptions{Categories: []string{"music"}, ExplicitEngine: "brave"}
+	applyShortcut(&opts, shortcut)
+	if !reflect.DeepEqual(opts.Categories, []string{"music"}) || opts.ExplicitEngine != "brave" {
+		t.Errorf("applyShortcut() overrode command line values: %+v", opts)
+	}
+}
+
+func TestShortcutCommands(t *testing.T) {
+	root := &cobra.Command{Use: "sx", Run: func(*cobra.Command, []string) {}}
+	root.Flags().IntP("num", "n", 10, "")
+	root.AddCommand(&cobra.Command{Use: "history", Run: func(*cobra.Command, []string) {}})
+
+	commands := shortcutCommands(root, map[string]Shortcut{
+		"yt":       {Categories: []string{"videos"}, Description: "YouTube"},
+		"gh":       {Sites: []string{"github.com"}},
+		"history":  {Categories: []string{"news"}},
+		"bad name": {},
+	})
+
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.Name())
+		if cmd.Flags().Lookup("num") == nil {
+			t.Errorf("shortcut %q does not share the root flags", cmd.Name())
+		}
+	}
+	if want := []string{"gh", "yt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("shortcut commands = %v, want %v", names, want)
+	}
+	if commands[0].Short != "Search shortcut (sites: github.com)" || commands[1].Short != "YouTube" {
+		t.Errorf("unexpected descriptions: %q, %q", commands[0].Short, commands[1].Short)
+	}
+}
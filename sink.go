@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// sinkKinds are the --sink values; file, webhook and command take an
+// argument after a colon.
+var sinkKinds = []string{"stdout", "file:PATH", "webhook:URL", "email", "command:CMD"}
+
+// A sink delivers the results of a search somewhere.
+type sink interface {
+	deliver(b resultBatch) error
+}
+
+// resultBatch is the output of one search, multi-query search or saved
+// searches run, as handed to each sink.
+type resultBatch struct {
+	Sections []querySection
+	Label    string         // names the search in file paths and mail subjects
+	Opts     *SearchOptions // output format
+	Meta     *provenance    // recorded in saved files and webhook payloads
+	Time     time.Time
+}
+
+// render writes the batch to w in the selected output format.
+func (b resultBatch) render(w io.Writer, meta *provenance) error {
+	return printMultiResults(w, b.Sections, b.Opts, config, meta)
+}
+
+// stdoutSink prints to stdout, the default.
+type stdoutSink struct{}
+
+func (stdoutSink) deliver(b resultBatch) error {
+	return b.render(os.Stdout, nil)
+}
+
+// fileSink writes a file as -o does; the path may contain placeholders.
+type fileSink struct {
+	Path string
+}
+
+func (s fileSink) deliver(b resultBatch) error {
+	engine := ""
+	if len(b.Sections) > 0 {
+		engine = b.Sections[0].Engine
+	}
+	path, err := prepareOutputPath(s.Path, outputVars(b.Label, engine, b.Time))
+	if err != nil {
+		return err
+	}
+	file, err := createOutput(path)
+	if err != nil {
+		return err
+	}
+	if err := b.render(file, b.Meta); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// webhookSink posts the batch as JSON, whatever the output format.
+type webhookSink struct {
+	URL string
+}
+
+func (s webhookSink) deliver(b resultBatch) error {
+	opts := *b.Opts
+	opts.JSON, opts.Porcelain, opts.LinksOnly, opts.MagnetOnly, opts.Subscribe = true, false, false, false, false
+	b.Opts = &opts
+	var body bytes.Buffer
+	if err := b.render(&body, b.Meta); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sx/"+version)
+	// Webhook URLs carry their secret in the path or query, so errors and
+	// debug output only name the host
+	resp, err := setupHTTPClient(config).Do(withHostOnlyDebug(req))
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("webhook %s: %v", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s: %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
+
+// emailSink mails the batch through the [email] SMTP server.
+type emailSink struct{}
+
+func (emailSink) deliver(b resultBatch) error {
+	return emailDigest(config.Email, b.Sections, b.Time)
+}
+
+// commandSink runs a command with the output on its stdin. The command
+// shares sx's stdout and stderr.
+type commandSink struct {
+	Args []string
+}
+
+func (s commandSink) deliver(b resultBatch) error {
+	var input bytes.Buffer
+	if err := b.render(&input, nil); err != nil {
+		return err
+	}
+	cmd := exec.Command(s.Args[0], s.Args[1:]...)
+	cmd.Stdin = &input
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// parseSink parses a --sink value, one of sinkKinds.
+func parseSink(spec string) (sink, error) {
+	kind, arg, hasArg := strings.Cut(spec, ":")
+	switch {
+	case spec == "stdout" || spec == "-":
+		return stdoutSink{}, nil
+	case spec == "email":
+		if err := config.Email.check(); err != nil {
+			return nil, err
+		}
+		return emailSink{}, nil
+	case kind == "file" && hasArg && arg != "":
+		if _, err := expandOutputPath(arg, outputVars("", "", time.Now())); err != nil {
+			return nil, err
+		}
+		return fileSink{Path: arg}, nil
+	case kind == "webhook" && hasArg:
+		if !strings.HasPrefix(arg, "http://") && !strings.HasPrefix(arg, "https://") {
+			return nil, fmt.Errorf("webhook sink needs an http or https URL, got %q", arg)
+		}
+		return webhookSink{URL: arg}, nil
+	case kind == "command" && hasArg:
+		args, err := splitCommandLine(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) == 0 {
+			return nil, fmt.Errorf("command sink needs a command")
+		}
+		return commandSink{Args: args}, nil
+	}
+	return nil, fmt.Errorf("invalid sink %q (use %s)", spec, strings.Join(sinkKinds, ", "))
+}
+
+// outputSinks parses the --sink values, adding a file sink for -o. Without
+// either, output goes to stdout.
+func outputSinks(specs []string, outputFile string) ([]sink, error) {
+	var sinks []sink
+	for _, spec := range specs {
+		s, err := parseSink(spec)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	if outputFile != "" {
+		sinks = append(sinks, fileSink{Path: outputFile})
+	}
+	if len(sinks) == 0 {
+		sinks = []sink{stdoutSink{}}
+	}
+	return sinks, nil
+}
+
+// deliverAll hands the batch to every sink, so one failing sink doesn't
+// keep the results from the others.
+func deliverAll(sinks []sink, b resultBatch) error {
+	var errs []error
+	for _, s := range sinks {
+		if err := s.deliver(b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// splitCommandLine splits a command into arguments at unquoted spaces.
+// Single and double quotes group words and a backslash escapes the next
+// character outside single quotes, as in a POSIX shell but without
+// expansion, so commands behave the same on every platform.
+func splitCommandLine(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", s)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
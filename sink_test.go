@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSink(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+
+	tests := map[string]sink{
+		"stdout":                    stdoutSink{},
+		"-":                         stdoutSink{},
+		"file:out/{{query}}.md":     fileSink{Path: "out/{{query}}.md"},
+		"webhook:https://h.example": webhookSink{URL: "https://h.example"},
+		`command:llm -s "sum up"`:   commandSink{Args: []string{"llm", "-s", "sum up"}},
+	}
+	for spec, want := range tests {
+		got, err := parseSink(spec)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("parseSink(%q) = %#v, %v; want %#v", spec, got, err, want)
+		}
+	}
+	for _, spec := range []string{"", "file", "file:", "webhook:ftp://x", "command:", "email", "slack"} {
+		if _, err := parseSink(spec); err == nil {
+			t.Errorf("parseSink(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestOutputSinks(t *testing.T) {
+	sinks, err := outputSinks(nil, "")
+	if err != nil || !reflect.DeepEqual(sinks, []sink{stdoutSink{}}) {
+		t.Errorf("no sinks: %#v, %v", sinks, err)
+	}
+	sinks, err = outputSinks(nil, "out.json")
+	if err != nil || !reflect.DeepEqual(sinks, []sink{fileSink{Path: "out.json"}}) {
+		t.Errorf("-o only: %#v, %v", sinks, err)
+	}
+	sinks, err = outputSinks([]string{"stdout"}, "out.json")
+	if err != nil || len(sinks) != 2 {
+		t.Errorf("--sink with -o: %#v, %v", sinks, err)
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := map[string][]string{
+		"llm summarize":            {"llm", "summarize"},
+		`  jq  '.queries[] | .q' `: {"jq", ".queries[] | .q"},
+		`say "it's \"here\""`:      {"say", `it's "here"`},
+		`a\ b ''`:                  {"a b", ""},
+		"":                         nil,
+	}
+	for in, want := range tests {
+		got, err := splitCommandLine(in)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("splitCommandLine(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{`"open`, `trailing\`} {
+		if _, err := splitCommandLine(in); err == nil {
+			t.Errorf("splitCommandLine(%q) succeeded", in)
+		}
+	}
+}
+
+func TestWebhookSinkPostsJSON(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+
+	var body []byte
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		contentType = r.Header.Get("Content-Type")
+		if r.URL.Path == "/fail" {
+			http.Error(w, "nope", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	batch := resultBatch{
+		Sections: []querySection{{Query: "go", Results: []SearchResult{{Title: "Go", URL: "https://go.dev"}}}},
+		Opts:     &SearchOptions{LinksOnly: true},
+		Meta:     &provenance{Query: "go"},
+	}
+	if err := (webhookSink{URL: server.URL + "/hook"}).deliver(batch); err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Queries map[string]json.RawMessage `json:"queries"`
+		Meta    provenance                 `json:"meta"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Queries["go"] == nil || payload.Meta.Query != "go" {
+		t.Errorf("payload %s (%v)", body, err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type %q", contentType)
+	}
+	if !batch.Opts.LinksOnly || batch.Opts.JSON {
+		t.Error("webhook changed the caller's options")
+	}
+	if err := (webhookSink{URL: server.URL + "/fail?token=s3cret"}).deliver(batch); err == nil || !strings.Contains(err.Error(), "400") || strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("failing webhook: %v", err)
+	}
+	// Unreachable: the error names the host, not the secret path
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	err := (webhookSink{URL: closed.URL + "/hooks/T000/B000/s3cret"}).deliver(batch)
+	if err == nil || strings.Contains(err.Error(), "s3cret") || !strings.Contains(err.Error(), strings.TrimPrefix(closed.URL, "http://")) {
+		t.Errorf("unreachable webhook: %v", err)
+	}
+}
+
+type failingSink struct{}
+
+func (failingSink) deliver(resultBatch) error { return errors.New("down") }
+
+func TestDeliverAllContinuesPastFailures(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+
+	path := filepath.Join(t.TempDir(), "{{query}}.txt")
+	batch := resultBatch{
+		Sections: []querySection{{Query: "go", Results: []SearchResult{{Title: "Go", URL: "https://go.dev"}}}},
+		Label:    "go",
+		Opts:     &SearchOptions{LinksOnly: true},
+		Time:     time.Now(),
+	}
+	err := deliverAll([]sink{failingSink{}, fileSink{Path: path}}, batch)
+	if err == nil || err.Error() != "down" {
+		t.Errorf("err = %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(filepath.Dir(path), "go.txt"))
+	if string(data) != "https://go.dev\n" {
+		t.Errorf("file sink wrote %q", data)
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"sx/backends"
+)
+
+// SiteInfo is where a result's site is hosted (see backends.SiteInfo).
+type SiteInfo = backends.SiteInfo
+
+// geoIPAPI tells the country and network of the address appended to it,
+// over HTTPS and without an API key.
+var geoIPAPI = "https://ipwho.is/"
+
+// siteInfoMaxAge is how long a site's info is taken from the cache
+const siteInfoMaxAge = 7 * 24 * time.Hour
+
+// environmentProxy picks the proxy of requests to sites, as set by
+// $HTTPS_PROXY, $HTTP_PROXY and $NO_PROXY. Tests replace it.
+var environmentProxy = http.ProxyFromEnvironment
+
+// siteInfoEntry is a site's info in the cache file, with when it was found.
+type siteInfoEntry struct {
+	Info    SiteInfo  `json:"info"`
+	Checked time.Time `json:"checked"`
+}
+
+// siteInfoCache holds the info of each site found this run or within
+// siteInfoMaxAge before, by host name. It is read from siteinfo.json in the
+// cache directory on first use and written back when sites are added.
+var siteInfoCache = struct {
+	sync.Mutex
+	loaded bool
+	sites  map[string]siteInfoEntry
+}{sites: map[string]siteInfoEntry{}}
+
+// siteInfoFile is where site info is cached, or "" if the cache directory
+// can't be resolved.
+func siteInfoFile() string {
+	dir := appDir(baseCache)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "siteinfo.json")
+}
+
+// loadSiteInfoCache reads the cache file into siteInfoCache, once.
+// Callers hold its lock.
+func loadSiteInfoCache(now time.Time) {
+	if siteInfoCache.loaded {
+		return
+	}
+	siteInfoCache.loaded = true
+	path := siteInfoFile()
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var sites map[string]siteInfoEntry
+	if json.Unmarshal(data, &sites) != nil {
+		return
+	}
+	for host, entry := range sites {
+		if now.Sub(entry.Checked) < siteInfoMaxAge {
+			siteInfoCache.sites[host] = entry
+		}
+	}
+}
+
+// saveSiteInfoCache writes siteInfoCache to the cache file. Callers hold
+// its lock.
+func saveSiteInfoCache() error {
+	path := siteInfoFile()
+	if path == "" {
+		return fmt.Errorf("no cache directory")
+	}
+	data, err := json.MarshalIndent(siteInfoCache.sites, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// siteProbe is a site to look up: its host name and the URL it is probed
+// at, the result's scheme and host.
+type siteProbe struct {
+	host string
+	url  *url.URL
+}
+
+// enrichSiteInfo sets where each result's site is hosted: its address,
+// the country and network of the address and the issuer of its TLS
+// certificate. Sites are looked up once and cached; lookups that fail are
+// retried on the next call.
+func enrichSiteInfo(results []SearchResult, config *Config) {
+	now := time.Now()
+	siteInfoCache.Lock()
+	loadSiteInfoCache(now)
+	var probes []siteProbe
+	queued := map[string]bool{}
+	for _, result := range results {
+		u, err := url.Parse(result.URL)
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+			continue
+		}
+		host := urlHost(result.URL)
+		if _, ok := siteInfoCache.sites[host]; host == "" || ok || queued[host] {
+			continue
+		}
+		queued[host] = true
+		probes = append(probes, siteProbe{host: host, url: &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"}})
+	}
+	siteInfoCache.Unlock()
+
+	if len(probes) > 0 {
+		found := probeSites(probes, config)
+		if len(found) > 0 {
+			siteInfoCache.Lock()
+			for host, info := range found {
+				siteInfoCache.sites[host] = siteInfoEntry{Info: info, Checked: now}
+			}
+			if err := saveSiteInfoCache(); err != nil && config.Debug {
+				fmt.Fprintf(os.Stderr, "Debug: site info cache: %v\n", err)
+			}
+			siteInfoCache.Unlock()
+		}
+	}
+
+	siteInfoCache.Lock()
+	defer siteInfoCache.Unlock()
+	for i := range results {
+		if entry, ok := siteInfoCache.sites[urlHost(results[i].URL)]; ok {
+			info := entry.Info
+			results[i].SiteInfo = &info
+		}
+	}
+}
+
+// siteInfoProxy picks the proxy a request for site info goes through:
+// tor_proxy for onion sites, otherwise the environment's.
+func siteInfoProxy(config *Config) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if strings.HasSuffix(strings.ToLower(req.URL.Hostname()), ".onion") {
+			return url.Parse(firstNonEmpty(config.TorProxy, backends.DefaultTorProxy))
+		}
+		return environmentProxy(req)
+	}
+}
+
+// siteInfoClient returns the client site info is looked up with, through
+// siteInfoProxy and wrapped like the backends' clients (e.g. to log
+// requests with --debug). With verify unset, certificates are read, not
+// trusted: expired and self-signed certificates name their issuer too.
+// Redirects aren't followed; the site's own response tells all.
+func siteInfoClient(config *Config, verify bool) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = siteInfoProxy(config)
+	t.TLSClientConfig = &tls.Config{InsecureSkipVerify: !verify || config.NoVerifySSL, RootCAs: rootCAs(config)}
+	var rt http.RoundTripper = t
+	if backends.TransportWrapper != nil {
+		rt = backends.TransportWrapper(rt)
+	} else {
+		rt = withDebug(rt, config)
+	}
+	return &http.Client{
+		Timeout:   config.fetchTimeout(),
+		Transport: rt,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// probeSites looks up the sites of probes, maxPageFetches at a time, and
+// returns the info of those whose lookups all succeeded by host name.
+func probeSites(probes []siteProbe, config *Config) map[string]SiteInfo {
+	client, geoClient := siteInfoClient(config, false), siteInfoClient(config, true)
+	infos := make([]SiteInfo, len(probes))
+	ok := make([]bool, len(probes))
+	sem := make(chan struct{}, maxPageFetches)
+	var wg sync.WaitGroup
+	for i, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			infos[i], ok[i] = probeSite(client, probe, config)
+			if !ok[i] || infos[i].IP == "" {
+				return
+			}
+			place, err := lookupGeoIP(geoClient, infos[i].IP)
+			if err != nil {
+				if config.Debug {
+					fmt.Fprintf(os.Stderr, "Debug: GeoIP: %v\n", err)
+				}
+				ok[i] = false
+				return
+			}
+			infos[i].Country = place.CountryCode
+			infos[i].Network = firstNonEmpty(place.Connection.Org, place.Connection.ISP)
+		}()
+	}
+	wg.Wait()
+
+	found := map[string]SiteInfo{}
+	for i, probe := range probes {
+		if ok[i] {
+			found[probe.host] = infos[i]
+		}
+	}
+	return found
+}
+
+// probeSite finds the address of a site and the issuer of its certificate
+// with one HEAD request, which goes through the site info proxy like any
+// other request to the site. The address is that of the connection, so it
+// is only known when the site is reached directly: behind a proxy, looking
+// it up would bypass the proxy. Sites with nothing to find are not probed.
+func probeSite(client *http.Client, probe siteProbe, config *Config) (SiteInfo, bool) {
+	var info SiteInfo
+	if net.ParseIP(probe.url.Hostname()) != nil {
+		info.IP = probe.url.Hostname()
+	}
+	req, err := http.NewRequest(http.MethodHead, probe.url.String(), nil)
+	if err != nil {
+		return info, false
+	}
+	proxy, err := siteInfoProxy(config)(req)
+	direct := err == nil && proxy == nil
+	if probe.url.Scheme != "https" && (info.IP != "" || !direct) {
+		return info, info.IP != ""
+	}
+
+	var remote net.Addr
+	if direct && info.IP == "" {
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+			GotConn: func(conn httptrace.GotConnInfo) { remote = conn.Conn.RemoteAddr() },
+		}))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if config.Debug {
+			fmt.Fprintf(os.Stderr, "Debug: site info %s: %v\n", probe.host, err)
+		}
+		return info, info.IP != ""
+	}
+	resp.Body.Close()
+	if addr, ok := remote.(*net.TCPAddr); ok {
+		info.IP = addr.IP.String()
+	}
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		issuer := resp.TLS.PeerCertificates[0].Issuer
+		info.TLSIssuer = firstNonEmpty(strings.Join(issuer.Organization, ", "), issuer.CommonName)
+	}
+	return info, info.IP != "" || info.TLSIssuer != ""
+}
+
+// geoIPPlace is where the GeoIP service places an address. Addresses it
+// can't place, such as private ones, have no country or network.
+type geoIPPlace struct {
+	Success     bool   `json:"success"`
+	CountryCode string `json:"country_code"`
+	Connection  struct {
+		Org string `json:"org"`
+		ISP string `json:"isp"`
+	} `json:"connection"`
+}
+
+// lookupGeoIP looks up the place of ip.
+func lookupGeoIP(client *http.Client, ip string) (geoIPPlace, error) {
+	var place geoIPPlace
+	resp, err := client.Get(geoIPAPI + url.PathEscape(ip))
+	if err != nil {
+		return place, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return place, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&place); err != nil {
+		return place, fmt.Errorf("failed to parse response: %v", err)
+	}
+	if !place.Success {
+		return geoIPPlace{}, nil
+	}
+	return place, nil
+}
+
+// formatSiteInfo summarizes a site's info, e.g. "hosted in DE · 192.0.2.1
+// · Hetzner Online GmbH · certificate by Let's Encrypt".
+func formatSiteInfo(info *SiteInfo) string {
+	var facts []string
+	if info.Country != "" {
+		facts = append(facts, tr("site_hosted", info.Country))
+	}
+	if info.IP != "" {
+		facts = append(facts, info.IP)
+	}
+	if info.Network != "" {
+		facts = append(facts, info.Network)
+	}
+	if info.TLSIssuer != "" {
+		facts = append(facts, tr("site_certificate", info.TLSIssuer))
+	}
+	return strings.Join(facts, " · ")
+}
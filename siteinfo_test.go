@@ -0,0 +1,168 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// resetSiteInfoCache forgets the sites found so far, as a new run would.
+func resetSiteInfoCache() {
+	siteInfoCache.Lock()
+	defer siteInfoCache.Unlock()
+	siteInfoCache.loaded = false
+	siteInfoCache.sites = map[string]siteInfoEntry{}
+}
+
+func TestEnrichSiteInfo(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	resetSiteInfoCache()
+	defer resetSiteInfoCache()
+
+	// httptest's certificate is issued by "Acme Co"
+	site := httptest.NewTLSServer(http.NotFoundHandler())
+	defer site.Close()
+
+	lookups := 0
+	geoIP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		switch r.URL.Path {
+		case "/127.0.0.1":
+			w.Write([]byte(`{"success": true, "country_code": "DE", "connection": {"isp": "Example ISP", "org": "Example Hosting"}}`))
+		default:
+			w.Write([]byte(`{"success": false, "message": "Reserved range"}`))
+		}
+	}))
+	defer geoIP.Close()
+	defer func(api string) { geoIPAPI = api }(geoIPAPI)
+	geoIPAPI = geoIP.URL + "/"
+
+	// The address of localhost is the one connected to
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(site.URL, "https://"))
+	results := []SearchResult{
+		{URL: site.URL + "/a"},
+		{URL: site.URL + "/b"},
+		{URL: "http://[::1]/"},
+		{URL: "file:///home/me/notes/todo.md"},
+		{URL: "https://localhost:" + port + "/c"},
+	}
+	cfg := getDefaultConfig()
+	enrichSiteInfo(results, cfg)
+
+	want := &SiteInfo{IP: "127.0.0.1", Country: "DE", Network: "Example Hosting", TLSIssuer: "Acme Co"}
+	if !reflect.DeepEqual(results[0].SiteInfo, want) || !reflect.DeepEqual(results[1].SiteInfo, want) {
+		t.Errorf("got %+v and %+v, want %+v", results[0].SiteInfo, results[1].SiteInfo, want)
+	}
+	if got := results[2].SiteInfo; got == nil || got.IP != "::1" || got.Country != "" || got.TLSIssuer != "" {
+		t.Errorf("unexpected site info for ::1: %+v", got)
+	}
+	if results[3].SiteInfo != nil {
+		t.Errorf("local files have no site: %+v", results[3].SiteInfo)
+	}
+	if !reflect.DeepEqual(results[4].SiteInfo, want) {
+		t.Errorf("localhost: got %+v, want %+v", results[4].SiteInfo, want)
+	}
+	// One lookup per site's address
+	if lookups != 3 {
+		t.Errorf("expected three GeoIP requests, got %d", lookups)
+	}
+
+	// Another run takes the sites from the cache file
+	resetSiteInfoCache()
+	again := []SearchResult{{URL: site.URL + "/c"}}
+	enrichSiteInfo(again, cfg)
+	if !reflect.DeepEqual(again[0].SiteInfo, want) || lookups != 3 {
+		t.Errorf("cached: got %+v after %d lookups", again[0].SiteInfo, lookups)
+	}
+}
+
+func TestEnrichSiteInfoGeoIPFailure(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	resetSiteInfoCache()
+	defer resetSiteInfoCache()
+
+	geoIP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+	}))
+	defer geoIP.Close()
+	defer func(api string) { geoIPAPI = api }(geoIPAPI)
+	geoIPAPI = geoIP.URL
+
+	results := []SearchResult{{URL: "http://127.0.0.1/"}}
+	enrichSiteInfo(results, getDefaultConfig())
+	if results[0].SiteInfo != nil {
+		t.Errorf("expected no site info, got %+v", results[0].SiteInfo)
+	}
+	if len(siteInfoCache.sites) != 0 {
+		t.Errorf("failed lookups should not be cached: %+v", siteInfoCache.sites)
+	}
+}
+
+func TestEnrichSiteInfoThroughProxy(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	resetSiteInfoCache()
+	defer resetSiteInfoCache()
+
+	var mu sync.Mutex
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r.Method+" "+r.Host)
+		mu.Unlock()
+		http.Error(w, "no", http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+	defer func(p func(*http.Request) (*url.URL, error)) { environmentProxy = p }(environmentProxy)
+	environmentProxy = func(*http.Request) (*url.URL, error) { return url.Parse(proxy.URL) }
+	defer func(api string) { geoIPAPI = api }(geoIPAPI)
+	geoIPAPI = proxy.URL + "/"
+
+	// The HTTPS site's certificate is asked for through the proxy; its
+	// address isn't looked up around it, so there is nothing to ask GeoIP.
+	// The HTTP site has nothing to tell.
+	results := []SearchResult{{URL: "https://site.example.test/"}, {URL: "http://plain.example.test/"}}
+	enrichSiteInfo(results, getDefaultConfig())
+	if want := []string{"CONNECT site.example.test:443"}; !reflect.DeepEqual(proxied, want) {
+		t.Errorf("proxy saw %q, want %q", proxied, want)
+	}
+	if results[0].SiteInfo != nil || results[1].SiteInfo != nil {
+		t.Errorf("got %+v and %+v", results[0].SiteInfo, results[1].SiteInfo)
+	}
+}
+
+func TestSiteInfoProxy(t *testing.T) {
+	defer func(p func(*http.Request) (*url.URL, error)) { environmentProxy = p }(environmentProxy)
+	environmentProxy = func(*http.Request) (*url.URL, error) { return nil, nil }
+	cfg := getDefaultConfig()
+	for target, want := range map[string]string{
+		"http://abcdef.onion/":   "socks5://127.0.0.1:9050",
+		"https://example.org/":   "",
+		"https://Example.ONION/": "socks5://127.0.0.1:9050",
+	} {
+		req, _ := http.NewRequest(http.MethodHead, target, nil)
+		got, err := siteInfoProxy(cfg)(req)
+		if err != nil || (got == nil) != (want == "") || got != nil && got.String() != want {
+			t.Errorf("%s: proxy %v, %v", target, got, err)
+		}
+	}
+	cfg.TorProxy = "socks5://127.0.0.1:9150"
+	req, _ := http.NewRequest(http.MethodHead, "http://abcdef.onion/", nil)
+	if got, _ := siteInfoProxy(cfg)(req); got == nil || got.Host != "127.0.0.1:9150" {
+		t.Errorf("tor_proxy: %v", got)
+	}
+}
+
+func TestFormatSiteInfo(t *testing.T) {
+	info := &SiteInfo{IP: "192.0.2.10", Country: "DE", Network: "Hetzner Online GmbH", TLSIssuer: "Let's Encrypt"}
+	if got, want := formatSiteInfo(info), "hosted in DE · 192.0.2.10 · Hetzner Online GmbH · certificate by Let's Encrypt"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := formatSiteInfo(&SiteInfo{IP: "192.0.2.10"}); got != "192.0.2.10" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// snapshotManifest is the manifest.json of an `sx snapshot` directory:
+// how the results were found and where each result's page is kept.
+type snapshotManifest struct {
+	provenance
+	Pages       int             `json:"pages"`
+	WithContent bool            `json:"with_content"`
+	AIPolicy    string          `json:"ai_policy,omitempty"`
+	Results     []snapshotEntry `json:"results"`
+}
+
+// snapshotEntry is a result in the manifest. Paths are relative to the
+// snapshot directory.
+type snapshotEntry struct {
+	Rank      int    `json:"rank"`
+	URL       string `json:"url"`
+	Title     string `json:"title"`
+	HTML      string `json:"html,omitempty"`
+	Markdown  string `json:"markdown,omitempty"`
+	SHA256    string `json:"sha256,omitempty"` // of the HTML
+	Bytes     int    `json:"bytes,omitempty"`
+	FetchedAt string `json:"fetched_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Why the page's site restricts AI use, with --ai-policy
+	AIRestrictions []string `json:"ai_restrictions,omitempty"`
+}
+
+// snapshotDir is the directory a snapshot of query goes to when none is
+// given.
+func snapshotDir(query string, now time.Time) string {
+	return slugify(query, 60) + "-" + now.Format("20060102-150405")
+}
+
+// savePage fetches entry's page into the snapshot directory as HTML and,
+// when readability finds an article in it, markdown. With a checker it
+// records whether the page's site restricts AI use and, when excluding,
+// doesn't save restricted pages.
+func savePage(client *http.Client, dir string, entry *snapshotEntry, config *Config, checker *aiUseChecker) {
+	if checker != nil {
+		entry.AIRestrictions = checker.siteRestrictions(entry.URL)
+		if checker.exclude && len(entry.AIRestrictions) > 0 {
+			return
+		}
+	}
+	html, err := fetchPageHTML(client, entry.URL, config)
+	if err != nil {
+		entry.Error = err.Error()
+		return
+	}
+	if checker != nil {
+		entry.AIRestrictions = append(entry.AIRestrictions, pageRestrictions(html)...)
+		if checker.exclude && len(entry.AIRestrictions) > 0 {
+			return
+		}
+	}
+	entry.FetchedAt = time.Now().UTC().Format(time.RFC3339)
+	sum := sha256.Sum256(html)
+	entry.SHA256, entry.Bytes = hex.EncodeToString(sum[:]), len(html)
+
+	base := filepath.Join("pages", fmt.Sprintf("%03d-%s", entry.Rank, slugify(entry.Title, 40)))
+	if err := os.WriteFile(filepath.Join(dir, base+".html"), html, 0644); err != nil {
+		entry.Error = err.Error()
+		return
+	}
+	entry.HTML = base + ".html"
+
+	_, markdown, err := articleFromHTML(html, entry.URL)
+	if err != nil {
+		entry.Error = err.Error()
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, base+".md"), []byte(markdown), 0644); err != nil {
+		entry.Error = err.Error()
+		return
+	}
+	entry.Markdown = base + ".md"
+}
+
+// runSnapshot searches for query, collecting pages pages of results, and
+// saves them to dir as results.json, in the --json format, with a
+// manifest.json. withContent also saves each result's page as HTML and
+// markdown under pages/. aiPolicy, if set, checks those pages for AI use
+// restrictions as --ai-policy does.
+func runSnapshot(flags *pflag.FlagSet, query string, pages int, withContent bool, dir, aiPolicy string) error {
+	if pages < 1 {
+		return fmt.Errorf("--pages must be at least 1")
+	}
+	if aiPolicy != "" {
+		if !validateAIPolicyMode(aiPolicy) {
+			return fmt.Errorf("invalid --ai-policy %q, use: %s", aiPolicy, strings.Join(aiPolicyModes, ", "))
+		}
+		if !withContent {
+			return fmt.Errorf("--ai-policy requires --with-content")
+		}
+	}
+	now := time.Now()
+	if dir == "" {
+		dir = snapshotDir(query, now)
+	}
+	manifestPath := filepath.Join(dir, "manifest.json")
+	if _, err := os.Stat(manifestPath); err == nil {
+		return fmt.Errorf("%s already holds a snapshot", dir)
+	}
+
+	installTransports(config)
+	backendMgr = initBackendManager(config)
+
+	opts := SearchOptions{SafeSearch: config.SafeSearch, PageNo: 1}
+	if err := applyProfile(config, "", &opts, false); err != nil {
+		return err
+	}
+	perPage := config.ResultCount
+	if perPage <= 0 {
+		perPage = defaultResultCount
+	}
+	state := searchState{Query: query}
+	results, err := fetchResults(&state, nil, pages*perPage, &opts, config)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no results found for %q", query)
+	}
+
+	pagesDir := dir
+	if withContent {
+		pagesDir = filepath.Join(dir, "pages")
+	}
+	if err := os.MkdirAll(pagesDir, 0755); err != nil {
+		return err
+	}
+	manifest := snapshotManifest{
+		provenance:  buildProvenance(flags, query, state.Engine, &opts, config, now),
+		Pages:       pages,
+		WithContent: withContent,
+		AIPolicy:    aiPolicy,
+		Results:     make([]snapshotEntry, len(results)),
+	}
+	manifest.RequestID = state.RequestID
+
+	output := jsonOutput(results, query, false, map[string]interface{}{
+		"engine_used": state.Engine,
+		"request_id":  state.RequestID,
+		"meta":        manifest.provenance,
+	})
+	if err := writeJSONFile(filepath.Join(dir, "results.json"), output); err != nil {
+		return err
+	}
+
+	client := setupHTTPClient(config)
+	var checker *aiUseChecker
+	if aiPolicy != "" {
+		checker = newAIUseChecker(client, config, aiPolicy == "exclude")
+	}
+	sem := make(chan struct{}, maxPageFetches)
+	var wg sync.WaitGroup
+	for i, result := range results {
+		entry := &manifest.Results[i]
+		*entry = snapshotEntry{Rank: i + 1, URL: result.URL, Title: result.Title}
+		if !withContent || result.URL == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			savePage(client, dir, entry, config, checker)
+		}()
+	}
+	wg.Wait()
+
+	failed, restricted := 0, 0
+	for _, entry := range manifest.Results {
+		if entry.Error != "" {
+			failed++
+			printNotice("%d. %s: %s", entry.Rank, entry.URL, entry.Error)
+		}
+		if len(entry.AIRestrictions) > 0 {
+			restricted++
+		}
+	}
+	if err := writeJSONFile(manifestPath, manifest); err != nil {
+		return err
+	}
+	if failed > 0 {
+		printNotice("%d of %d pages could not be saved", failed, len(results))
+	}
+	switch {
+	case restricted > 0 && aiPolicy == "exclude":
+		printNotice("%d pages whose sites restrict AI use were not saved", restricted)
+	case restricted > 0:
+		printNotice("%d pages are on sites that restrict AI use (see ai_restrictions in manifest.json)", restricted)
+	}
+	fmt.Println(dir)
+	return nil
+}
+
+// writeJSONFile writes v to path as indented JSON.
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
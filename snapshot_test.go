@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotDir(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	if got := snapshotDir("Rust async runtimes?", now); got != "rust-async-runtimes-20240501-093000" {
+		t.Errorf("snapshotDir = %q", got)
+	}
+}
+
+func TestSavePage(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config = getDefaultConfig()
+
+	page := `<html><head><title>Tokio</title></head><body><article><h1>Tokio</h1>` +
+		`<p>Tokio is an asynchronous runtime for the Rust programming language, with I/O, timers and a scheduler.</p>` +
+		`</article></body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "pages"), 0755)
+	client := setupHTTPClient(config)
+
+	entry := snapshotEntry{Rank: 2, URL: server.URL + "/tokio", Title: "Tokio: an async runtime"}
+	savePage(client, dir, &entry, config, nil)
+	if entry.Error != "" {
+		t.Fatal(entry.Error)
+	}
+	if entry.HTML != "pages/002-tokio-an-async-runtime.html" || entry.Markdown != "pages/002-tokio-an-async-runtime.md" {
+		t.Errorf("paths %q, %q", entry.HTML, entry.Markdown)
+	}
+	if entry.Bytes != len(page) || len(entry.SHA256) != 64 || entry.FetchedAt == "" {
+		t.Errorf("entry %+v", entry)
+	}
+	html, _ := os.ReadFile(filepath.Join(dir, entry.HTML))
+	markdown, _ := os.ReadFile(filepath.Join(dir, entry.Markdown))
+	if string(html) != page || !strings.Contains(string(markdown), "asynchronous runtime for the Rust") {
+		t.Errorf("saved HTML %q, markdown %q", html, markdown)
+	}
+
+	gone := snapshotEntry{Rank: 3, URL: server.URL + "/gone", Title: "Gone"}
+	savePage(client, dir, &gone, config, nil)
+	if !strings.Contains(gone.Error, "404") || gone.HTML != "" {
+		t.Errorf("missing page: %+v", gone)
+	}
+}
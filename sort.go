@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// sortKeys lists the values accepted by --sort and the interactive 's'
+// command.
+var sortKeys = []string{"seeders", "date", "score", "title", "domain", "citations"}
+
+func validateSortKey(key string) bool {
+	for _, k := range sortKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// sortResults reorders results in place by key: seeders, date (newest
+// first), score and citations descending, title and domain alphabetically.
+// The sort is stable so results that compare equal keep the backend's
+// ranking, and results without a date or citation count sort after the
+// others.
+func sortResults(results []SearchResult, key string) {
+	switch key {
+	case "seeders":
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Seed > results[j].Seed
+		})
+	case "date":
+		sort.SliceStable(results, func(i, j int) bool {
+			di, dj := parseDate(results[i].PublishedDate), parseDate(results[j].PublishedDate)
+			if di == nil || dj == nil {
+				return di != nil && dj == nil
+			}
+			return di.After(*dj)
+		})
+	case "score":
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Score > results[j].Score
+		})
+	case "title":
+		sort.SliceStable(results, func(i, j int) bool {
+			return strings.ToLower(results[i].Title) < strings.ToLower(results[j].Title)
+		})
+	case "domain":
+		sort.SliceStable(results, func(i, j int) bool {
+			return sortDomain(results[i]) < sortDomain(results[j])
+		})
+	case "citations":
+		sort.SliceStable(results, func(i, j int) bool {
+			ci, cj := results[i].Citations, results[j].Citations
+			if ci == nil || cj == nil {
+				return ci != nil && cj == nil
+			}
+			return *ci > *cj
+		})
+	}
+}
+
+func sortDomain(result SearchResult) string {
+	return strings.TrimPrefix(extractDomain(result.URL, false), "www.")
+}
@@ -0,0 +1,82 @@
+package main
+
+import "testing"
+
+func TestSortResults_Seeders(t *testing.T) {
+	results := []SearchResult{
+		{Title: "a", Seed: 5},
+		{Title: "b", Seed: 120},
+		{Title: "c", Seed: 5},
+		{Title: "d"},
+	}
+	sortResults(results, "seeders")
+
+	want := []string{"b", "a", "c", "d"}
+	for i, title := range want {
+		if results[i].Title != title {
+			t.Fatalf("sortResults() order = %v, want %v", titles(results), want)
+		}
+	}
+}
+
+func TestSortResults_NoKey(t *testing.T) {
+	results := []SearchResult{{Title: "a", Seed: 1}, {Title: "b", Seed: 2}}
+	sortResults(results, "")
+	if results[0].Title != "a" {
+		t.Errorf("sortResults() with no key reordered results: %v", titles(results))
+	}
+}
+
+func titles(results []SearchResult) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.Title
+	}
+	return out
+}
+
+func TestSortResults_Keys(t *testing.T) {
+	results := []SearchResult{
+		{Title: "beta", URL: "https://www.zeta.org/", PublishedDate: "2023-05-01", Score: 1.5},
+		{Title: "Alpha", URL: "https://mid.example/", Score: 3},
+		{Title: "gamma", URL: "https://alpha.net/", PublishedDate: "2024-01-15T10:00:00Z"},
+	}
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"date", []string{"gamma", "beta", "Alpha"}},
+		{"score", []string{"Alpha", "beta", "gamma"}},
+		{"title", []string{"Alpha", "beta", "gamma"}},
+		{"domain", []string{"gamma", "Alpha", "beta"}},
+	}
+	for _, tt := range tests {
+		sorted := append([]SearchResult(nil), results...)
+		sortResults(sorted, tt.key)
+		got := titles(sorted)
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("sortResults(%q) = %v, want %v", tt.key, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSortResults_Citations(t *testing.T) {
+	cites := func(n int) *int { return &n }
+	results := []SearchResult{
+		{Title: "blog"},
+		{Title: "few", Citations: cites(3)},
+		{Title: "many", Citations: cites(900)},
+		{Title: "none", Citations: cites(0)},
+	}
+	sortResults(results, "citations")
+	got := titles(results)
+	want := []string{"many", "few", "none", "blog"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sortResults(citations) = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// sqliteCommand is the SQLite command-line shell browser databases are
+// read with.
+var sqliteCommand = "sqlite3"
+
+// sqliteDB is a copy of an SQLite database, such as a browser's bookmarks
+// and history, read with the sqlite3 shell. A running browser keeps its
+// databases locked and the latest changes in the write-ahead log, so both
+// are copied before reading.
+type sqliteDB struct {
+	path string // the copy
+	dir  string
+}
+
+func openSQLite(path string) (*sqliteDB, error) {
+	header := make([]byte, 16)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.ReadFull(f, header)
+	f.Close()
+	if err != nil || string(header) != "SQLite format 3\x00" {
+		return nil, fmt.Errorf("%s is not an SQLite database", path)
+	}
+	if _, err := exec.LookPath(sqliteCommand); err != nil {
+		return nil, fmt.Errorf("reading %s needs the sqlite3 command-line tool on PATH", filepath.Base(path))
+	}
+
+	dir, err := os.MkdirTemp("", "sx-sqlite-")
+	if err != nil {
+		return nil, err
+	}
+	db := &sqliteDB{path: filepath.Join(dir, filepath.Base(path)), dir: dir}
+	for _, suffix := range []string{"", "-wal"} {
+		if err := copyFile(path+suffix, db.path+suffix); err != nil && !(suffix != "" && os.IsNotExist(err)) {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// Close removes the copy of the database.
+func (db *sqliteDB) Close() error {
+	return os.RemoveAll(db.dir)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// query runs a statement and returns its rows as column maps. Integers are
+// int64, as browsers keep times in microseconds that float64 can't hold
+// exactly.
+func (db *sqliteDB) query(sql string) ([]map[string]interface{}, error) {
+	// -init skips the user's ~/.sqliterc, which could change the output
+	cmd := exec.Command(sqliteCommand, "-batch", "-init", os.DevNull, "-json", db.path, sql)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("sqlite3: %s", msg)
+		}
+		return nil, fmt.Errorf("sqlite3: %v", err)
+	}
+	// No rows print nothing
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	dec.UseNumber()
+	var rows []map[string]interface{}
+	if err := dec.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("sqlite3: unexpected output: %v", err)
+	}
+	for _, row := range rows {
+		for column, value := range row {
+			n, ok := value.(json.Number)
+			if !ok {
+				continue
+			}
+			if i, err := n.Int64(); err == nil {
+				row[column] = i
+			} else if f, err := n.Float64(); err == nil {
+				row[column] = f
+			}
+		}
+	}
+	return rows, nil
+}
+
+// tables lists the database's tables.
+func (db *sqliteDB) tables() ([]string, error) {
+	rows, err := db.query("SELECT name FROM sqlite_master WHERE type = 'table'")
+	var names []string
+	for _, row := range rows {
+		names = append(names, rowString(row, "name"))
+	}
+	return names, err
+}
+
+// rows calls fn with each row of a table.
+func (db *sqliteDB) rows(table string, fn func(row map[string]interface{})) error {
+	rows, err := db.query("SELECT * FROM " + sqliteIdent(table))
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		fn(row)
+	}
+	return nil
+}
+
+// sqliteIdent quotes an identifier.
+func sqliteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The databases in testdata/bookmarks are made by make.py there.
+
+// needSQLite skips tests that read databases when the sqlite3 shell isn't
+// installed.
+func needSQLite(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath(sqliteCommand); err != nil {
+		t.Skip("needs sqlite3")
+	}
+}
+
+func TestSQLiteRows(t *testing.T) {
+	needSQLite(t)
+	db, err := openSQLite(filepath.Join("testdata", "bookmarks", "places.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	places := make(map[int64]map[string]interface{})
+	if err := db.rows("moz_places", func(row map[string]interface{}) { places[rowInt(row, "id")] = row }); err != nil {
+		t.Fatal(err)
+	}
+	// 6 places, 60 filler pages and 1 only in the write-ahead log
+	if len(places) != 67 {
+		t.Errorf("got %d places, want 67", len(places))
+	}
+	if p := places[1]; rowString(p, "url") != "https://go.dev/" || rowInt(p, "visit_count") != 12 || p["last_visit_date"] != int64(1700000000000000) {
+		t.Errorf("place 1: %v", p)
+	}
+	if p := places[2]; p["last_visit_date"] != nil {
+		t.Errorf("NULL read as %v", p["last_visit_date"])
+	}
+	if url := rowString(places[3], "url"); len(url) != 1229 || !strings.HasSuffix(url, "xxx") {
+		t.Errorf("long URL has %d bytes", len(url))
+	}
+	if rowString(places[7], "url") != "https://pkg.go.dev/" {
+		t.Errorf("row only in the write-ahead log: %v", places[7])
+	}
+
+	tables, err := db.tables()
+	if err != nil || len(tables) != 2 {
+		t.Errorf("tables %q, %v", tables, err)
+	}
+	if err := db.rows("moz_nothing", func(map[string]interface{}) {}); err == nil {
+		t.Error("missing table didn't fail")
+	}
+
+	// The copy is gone once closed, the original untouched
+	dir := db.dir
+	db.Close()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("copy left in %s", dir)
+	}
+	if _, err := os.Stat(filepath.Join("testdata", "bookmarks", "places.sqlite-shm")); err == nil {
+		t.Error("the original database was opened")
+	}
+}
+
+func TestChromeTimesKeepPrecision(t *testing.T) {
+	needSQLite(t)
+	db, err := openSQLite(filepath.Join("testdata", "bookmarks", "History"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.query("SELECT last_visit_time FROM urls LIMIT 1")
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("got %v, %v", rows, err)
+	}
+	// Past 2^53, where float64 rounds
+	if got := rows[0]["last_visit_time"]; got != int64(1700000000000000+11644473600000000) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestOpenSQLiteRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Bookmarks")
+	os.WriteFile(path, []byte(`{"roots": {}}`), 0644)
+	if _, err := openSQLite(path); err == nil {
+		t.Error("JSON file opened as a database")
+	}
+}
+
+func TestOpenSQLiteWithoutShell(t *testing.T) {
+	defer func(command string) { sqliteCommand = command }(sqliteCommand)
+	sqliteCommand = "sx-no-such-sqlite3"
+	_, err := openSQLite(filepath.Join("testdata", "bookmarks", "History"))
+	if err == nil || !strings.Contains(err.Error(), "sqlite3") {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// sweepCategories returns the categories --all-categories searches: the
+// --categories given, or every SearXNG category.
+func sweepCategories(opts *SearchOptions) []string {
+	if len(opts.Categories) > 0 {
+		return opts.Categories
+	}
+	return searxngCategories
+}
+
+// runCategorySweep searches query once per category, concurrently, and
+// outputs the results grouped by category. The searches share one request
+// ID. Categories without results are left out.
+func runCategorySweep(cmd *cobra.Command, query string, startAt, wanted, outputCount int, outputTemplate string, nearLat, nearLon float64) {
+	categories := sweepCategories(&searchOpts)
+	sections := make([]querySection, len(categories))
+	requestID := startRequestID()
+
+	var wg sync.WaitGroup
+	for i, category := range categories {
+		wg.Add(1)
+		go func(i int, category string) {
+			defer wg.Done()
+			opts := searchOpts
+			opts.Categories = []string{category}
+			state := &searchState{Query: query, RequestID: requestID, sharedRequestID: true}
+			sections[i] = searchSection(state, opts, startAt, wanted, outputCount, nearLat, nearLon)
+			sections[i].Category = category
+		}(i, category)
+	}
+	wg.Wait()
+
+	// Every category gets the same instant answers; show them once
+	var answers [][]string
+	for i := range sections {
+		answers = append(answers, sections[i].Answers)
+		sections[i].Answers = nil
+	}
+
+	found := false
+	kept := sections[:0]
+	for _, section := range sections {
+		if section.Err != nil {
+			fmt.Fprintf(os.Stderr, "Search error for %s: %v\n", section.Category, section.Err)
+		}
+		if len(section.window()) > 0 {
+			found = true
+		}
+		// JSON lists every category, including empty ones
+		if len(section.window()) > 0 || searchOpts.JSON {
+			kept = append(kept, section)
+		}
+	}
+	if !found {
+		fmt.Fprintln(os.Stderr, tr("no_results"))
+		if fileOutput.IfResults || len(kept) == 0 {
+			return
+		}
+	}
+	if len(kept) > 0 {
+		kept[0].Answers = mergeAnswers(answers...)
+	}
+	outputSections(cmd, kept, query, outputTemplate)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSweepCategories(t *testing.T) {
+	if got := sweepCategories(&SearchOptions{}); len(got) != len(searxngCategories) {
+		t.Errorf("without --categories: got %v, want every category", got)
+	}
+	if got := sweepCategories(&SearchOptions{Categories: []string{"news", "it"}}); strings.Join(got, ",") != "news,it" {
+		t.Errorf("with --categories: got %v", got)
+	}
+}
+
+func TestPrintMultiResultsCategories(t *testing.T) {
+	sections := []querySection{
+		{Query: "go", Category: "news", Results: []SearchResult{{Title: "Go 1.25", URL: "https://go.dev/blog"}}},
+		{Query: "go", Category: "it", Results: []SearchResult{{Title: "Go", URL: "https://go.dev"}}},
+	}
+
+	var buf bytes.Buffer
+	if err := printMultiResults(&buf, sections, &SearchOptions{JSON: true}, getDefaultConfig(), nil); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Categories map[string]struct {
+			Query string `json:"query"`
+		} `json:"categories"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(out.Categories) != 2 || out.Categories["it"].Query != "go" {
+		t.Errorf("unexpected JSON:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := printMultiResults(&buf, sections, &SearchOptions{}, getDefaultConfig(), nil); err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+	if !strings.Contains(text, "## go · news\n") || strings.Index(text, "## go · it\n") < strings.Index(text, "https://go.dev/blog") {
+		t.Errorf("categories not labeled in order:\n%s", text)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+)
+
+// synonymFile is the format of --expand-query files:
+//
+//	groups = [
+//	  ["golang", "go language"],
+//	  ["k8s", "kubernetes"],
+//	]
+type synonymFile struct {
+	Groups [][]string `toml:"groups"`
+}
+
+// loadSynonyms reads the synonym groups of an --expand-query file. Groups
+// need at least two terms to expand anything.
+func loadSynonyms(path string) ([][]string, error) {
+	var file synonymFile
+	if _, err := toml.DecodeFile(path, &file); err != nil {
+		return nil, fmt.Errorf("failed to read synonyms: %v", err)
+	}
+	for i, group := range file.Groups {
+		if len(group) < 2 {
+			return nil, fmt.Errorf("%s: synonym group %d needs at least two terms", path, i+1)
+		}
+	}
+	return file.Groups, nil
+}
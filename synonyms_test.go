@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadSynonyms(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "synonyms.toml")
+	os.WriteFile(path, []byte(`groups = [["golang", "go language"], ["k8s", "kubernetes"]]`), 0644)
+
+	groups, err := loadSynonyms(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"golang", "go language"}, {"k8s", "kubernetes"}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("loadSynonyms() = %q", groups)
+	}
+
+	os.WriteFile(path, []byte(`groups = [["lonely"]]`), 0644)
+	if _, err := loadSynonyms(path); err == nil {
+		t.Error("single-term group accepted")
+	}
+}
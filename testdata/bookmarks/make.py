@@ -0,0 +1,89 @@
+#!/usr/bin/env python3
+"""Builds the browser databases bookmarks_test.go and sqlite_test.go read.
+
+places.sqlite is a cut-down Firefox database with small pages, so its
+tables span interior pages and its long URL overflows, and an uncommitted
+write-ahead log holding the last bookmark. History is a Chromium history
+database.
+"""
+import os
+import shutil
+import sqlite3
+import tempfile
+
+HERE = os.path.dirname(os.path.abspath(__file__))
+T = 1700000000000000  # microseconds since 1970
+CHROME_EPOCH = 11644473600000000
+
+
+def places():
+    tmp = tempfile.mkdtemp()
+    path = os.path.join(tmp, "places.sqlite")
+    db = sqlite3.connect(path)
+    db.execute("PRAGMA page_size = 512")
+    db.executescript("""
+        CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR,
+            rev_host LONGVARCHAR, visit_count INTEGER DEFAULT 0, hidden INTEGER DEFAULT 0 NOT NULL,
+            typed INTEGER DEFAULT 0 NOT NULL, frecency INTEGER DEFAULT -1 NOT NULL,
+            last_visit_date INTEGER, guid TEXT);
+        CREATE TABLE moz_bookmarks (id INTEGER PRIMARY KEY, type INTEGER, fk INTEGER DEFAULT NULL,
+            parent INTEGER, position INTEGER, title LONGVARCHAR, keyword_id INTEGER,
+            folder_type TEXT, dateAdded INTEGER, lastModified INTEGER, guid TEXT);
+    """)
+    long_url = "https://example.org/search?q=" + "x" * 1200
+    db.executemany("INSERT INTO moz_places (id, url, title, visit_count, hidden, last_visit_date) VALUES (?, ?, ?, ?, ?, ?)", [
+        (1, "https://go.dev/", "The Go Programming Language", 12, 0, T),
+        (2, "javascript:alert(1)", "Bookmarklet", 0, 0, None),
+        (3, long_url, "Long query", 1, 0, T - 1000),
+        (4, "https://doc.rust-lang.org/book/", "The Rust Programming Language", 5, 0, T - 2000),
+        (5, "https://example.org/redirect", "Redirect", 3, 1, T),
+        (6, "https://example.org/never", "Never visited", 0, 0, None),
+    ] + [
+        (100 + i, "https://example.com/page/%d" % i, "Filler page %d" % i, 1, 0, T - 10**9) for i in range(60)
+    ])
+    db.executemany("INSERT INTO moz_bookmarks (id, type, fk, parent, position, title, dateAdded, guid) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", [
+        (1, 2, None, 0, 0, "", T, "root________"),
+        (2, 2, None, 1, 0, "menu", T, "menu________"),
+        (3, 2, None, 1, 1, "toolbar", T, "toolbar_____"),
+        (4, 2, None, 1, 2, "tags", T, "tags________"),
+        (5, 2, None, 1, 3, "unfiled", T, "unfiled_____"),
+        (6, 2, None, 3, 0, "Go", T, "folder_go___"),
+        (7, 1, 1, 6, 0, "Go home", T - 5000, "bookmark_go_"),
+        (8, 2, None, 4, 0, "golang", T, "tag_golang__"),
+        (9, 1, 1, 8, 0, None, T, "tag_item____"),
+        (10, 1, 2, 2, 0, "Bookmarklet", T, "bookmarklet_"),
+        (11, 1, 3, 5, 0, None, T, "long_url____"),
+    ])
+    db.commit()
+    db.execute("PRAGMA journal_mode = WAL")
+    db.execute("PRAGMA wal_autocheckpoint = 0")
+    db.execute("INSERT INTO moz_places (id, url, title, visit_count, last_visit_date) VALUES (7, 'https://pkg.go.dev/', 'Go Packages', 2, ?)", (T,))
+    db.execute("INSERT INTO moz_bookmarks (id, type, fk, parent, position, title, dateAdded, guid) VALUES (12, 1, 7, 6, 1, 'Go Packages', ?, 'bookmark_pkg')", (T,))
+    db.commit()
+    # Copy while the connection is open: closing checkpoints the log
+    shutil.copy(path, os.path.join(HERE, "places.sqlite"))
+    shutil.copy(path + "-wal", os.path.join(HERE, "places.sqlite-wal"))
+    db.close()
+    shutil.rmtree(tmp)
+
+
+def history():
+    path = os.path.join(HERE, "History")
+    if os.path.exists(path):
+        os.remove(path)
+    db = sqlite3.connect(path)
+    db.execute("PRAGMA page_size = 512")
+    db.execute("""CREATE TABLE urls (id INTEGER PRIMARY KEY AUTOINCREMENT, url LONGVARCHAR, title LONGVARCHAR,
+        visit_count INTEGER DEFAULT 0 NOT NULL, typed_count INTEGER DEFAULT 0 NOT NULL,
+        last_visit_time INTEGER NOT NULL, hidden INTEGER DEFAULT 0 NOT NULL)""")
+    db.executemany("INSERT INTO urls (url, title, visit_count, last_visit_time, hidden) VALUES (?, ?, ?, ?, ?)", [
+        ("https://go.dev/doc/", "Documentation - The Go Programming Language", 4, T + CHROME_EPOCH, 0),
+        ("https://example.org/hidden", "Hidden", 1, T + CHROME_EPOCH, 1),
+        ("chrome://settings/", "Settings", 9, T + CHROME_EPOCH, 0),
+    ])
+    db.commit()
+    db.close()
+
+
+places()
+history()
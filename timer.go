@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// timerFile is a file written by `sx saved install-timer`.
+type timerFile struct {
+	Path    string
+	Content string
+}
+
+// timerName is the name shared by the units or the launchd job of saved
+// search s: sx-saved-<slug>-<hash>, ASCII only as systemd requires. The
+// hash of the full name keeps searches whose slugs match, such as names
+// without any ASCII letters, from overwriting each other's units.
+func timerName(s savedSearch) string {
+	var b strings.Builder
+	for _, r := range slugify(s.Name, 40) {
+		if r < 0x80 {
+			b.WriteRune(r)
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		slug = "search"
+	}
+	sum := sha256.Sum256([]byte(s.Name))
+	return "sx-saved-" + slug + "-" + hex.EncodeToString(sum[:3])
+}
+
+// savedRunArgs are the sx arguments that run saved search s, mailing its
+// new results with email. The name comes last, after "--" so a name
+// starting with "-" isn't taken for a flag.
+func savedRunArgs(s savedSearch, email bool) []string {
+	if email {
+		return []string{"saved", "run", "--email", "--", s.Name}
+	}
+	return []string{"saved", "run", "--", s.Name}
+}
+
+// systemdTimerFiles returns a user service that runs saved search s and a
+// timer that starts it every interval, in unitDir.
+func systemdTimerFiles(s savedSearch, sx, unitDir string, interval time.Duration, email bool) []timerFile {
+	name := timerName(s)
+	// Only the executable and the search name need quoting
+	args := savedRunArgs(s, email)
+	execStart := append([]string{systemdQuote(sx)}, args[:len(args)-1]...)
+	execStart = append(execStart, systemdQuote(s.Name))
+	description := systemdDescription(s.Name)
+	service := fmt.Sprintf(`[Unit]
+Description=sx saved search %s
+
+[Service]
+Type=oneshot
+ExecStart=%s
+`, description, strings.Join(execStart, " "))
+	timer := fmt.Sprintf(`[Unit]
+Description=Run the sx saved search %s every %s
+
+[Timer]
+OnBootSec=5min
+OnUnitActiveSec=%ds
+Unit=%s.service
+
+[Install]
+WantedBy=timers.target
+`, description, s.Interval, int64(interval/time.Second), name)
+	return []timerFile{
+		{filepath.Join(unitDir, name+".service"), service},
+		{filepath.Join(unitDir, name+".timer"), timer},
+	}
+}
+
+// systemdQuote quotes an ExecStart argument, escaping the characters
+// systemd would otherwise expand as specifiers or variables, and line
+// breaks that would end the setting.
+func systemdQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$", "\n", `\n`, "\r", `\r`).Replace(s)
+	return `"` + s + `"`
+}
+
+// systemdDescription makes a search name safe for a Description= setting:
+// "%" would start a specifier, and a line break or a trailing backslash
+// would let the name add settings of its own.
+func systemdDescription(name string) string {
+	name = strings.NewReplacer("%", "%%", "\r\n", " ", "\n", " ", "\r", " ").Replace(name)
+	return strings.TrimRight(name, `\`)
+}
+
+// launchdPlist returns a launchd agent in agentDir that runs saved search s
+// every interval, logging to logDir.
+func launchdPlist(s savedSearch, sx, agentDir, logDir string, interval time.Duration, email bool) timerFile {
+	label := "com.byteowlz." + strings.ReplaceAll(timerName(s), "-", ".")
+	var args strings.Builder
+	for _, arg := range append([]string{sx}, savedRunArgs(s, email)...) {
+		args.WriteString("\t\t<string>")
+		xml.EscapeText(&args, []byte(arg))
+		args.WriteString("</string>\n")
+	}
+	var logPath bytes.Buffer
+	xml.EscapeText(&logPath, []byte(filepath.Join(logDir, timerName(s)+".log")))
+	content := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+%s	</array>
+	<key>StartInterval</key>
+	<integer>%d</integer>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, label, args.String(), int64(interval/time.Second), logPath.String(), logPath.String())
+	return timerFile{filepath.Join(agentDir, label+".plist"), content}
+}
+
+// installTimer schedules saved search ref with a user systemd timer, or a
+// launchd agent on macOS, and enables it unless printOnly, which prints the
+// files instead of writing them. With email the runs mail new results
+// instead of only logging them.
+func installTimer(ref string, printOnly, email bool) error {
+	searches, err := loadSavedSearches(getSavedFile())
+	if err != nil {
+		return err
+	}
+	i := findSavedSearch(searches, ref)
+	if i < 0 {
+		return fmt.Errorf("no saved search %q", ref)
+	}
+	s := searches[i]
+	if email {
+		if err := config.Email.check(); err != nil {
+			return err
+		}
+	}
+	interval, err := parseInterval(s.Interval)
+	if err != nil {
+		return err
+	}
+	sx, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("can't locate the sx executable: %v", err)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	var files []timerFile
+	var enable [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		plist := launchdPlist(s, sx, filepath.Join(home, "Library", "LaunchAgents"), getStateDir(), interval, email)
+		files = []timerFile{plist}
+		enable = [][]string{{"launchctl", "load", "-w", plist.Path}}
+	case "linux":
+		unitDir := filepath.Join(resolveBase(baseConfig, currentPathEnv()), "systemd", "user")
+		files = systemdTimerFiles(s, sx, unitDir, interval, email)
+		enable = [][]string{
+			{"systemctl", "--user", "daemon-reload"},
+			{"systemctl", "--user", "enable", "--now", timerName(s) + ".timer"},
+		}
+	default:
+		return fmt.Errorf("timers need systemd or launchd; on %s, schedule `sx saved run` with the system scheduler", runtime.GOOS)
+	}
+
+	if printOnly {
+		for _, f := range files {
+			fmt.Printf("# %s\n%s\n", f.Path, f.Content)
+		}
+		return nil
+	}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.Path, []byte(f.Content), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", f.Path)
+	}
+	if runtime.GOOS == "darwin" {
+		if err := os.MkdirAll(getStateDir(), 0755); err != nil {
+			return err
+		}
+	}
+	for _, command := range enable {
+		if out, err := exec.Command(command[0], command[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %v %s(enable it by hand once that works)", strings.Join(command, " "), err, out)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%q now runs every %s.\n", s.Name, s.Interval)
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimerName(t *testing.T) {
+	tests := map[string]string{
+		"golang releases": "sx-saved-golang-releases-793b0a",
+		"Über Käse":       "sx-saved-ber-kse-510529",
+		"日本":              "sx-saved-search-cf2abf",
+		"中文":              "sx-saved-search-72726d",
+	}
+	for name, want := range tests {
+		if got := timerName(savedSearch{Name: name}); got != want {
+			t.Errorf("timerName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSystemdTimerFiles(t *testing.T) {
+	s := savedSearch{Name: `go "100%" $HOME`, Interval: "12h"}
+	files := systemdTimerFiles(s, "/usr/bin/sx", "/home/u/.config/systemd/user", 12*time.Hour, false)
+	if len(files) != 2 {
+		t.Fatalf("got %d files", len(files))
+	}
+	service, timer := files[0], files[1]
+	if service.Path != "/home/u/.config/systemd/user/sx-saved-go-100-home-ba4939.service" {
+		t.Errorf("service path %q", service.Path)
+	}
+	if !strings.Contains(service.Content, "Description=sx saved search go \"100%%\" $HOME\n") ||
+		!strings.Contains(service.Content, `ExecStart="/usr/bin/sx" saved run -- "go \"100%%\" $$HOME"`) {
+		t.Errorf("service:\n%s", service.Content)
+	}
+	if !strings.HasSuffix(timer.Path, "sx-saved-go-100-home-ba4939.timer") ||
+		!strings.Contains(timer.Content, "OnUnitActiveSec=43200s\n") ||
+		!strings.Contains(timer.Content, "Unit=sx-saved-go-100-home-ba4939.service\n") {
+		t.Errorf("timer %s:\n%s", timer.Path, timer.Content)
+	}
+	service = systemdTimerFiles(s, "/usr/bin/sx", "/u", 12*time.Hour, true)[0]
+	if !strings.Contains(service.Content, `ExecStart="/usr/bin/sx" saved run --email -- "go`) {
+		t.Errorf("service with email:\n%s", service.Content)
+	}
+}
+
+func TestSystemdTimerFilesLineBreaks(t *testing.T) {
+	s := savedSearch{Name: "news\nExecStartPre=/bin/evil\r\nx\\", Interval: "1h"}
+	for _, file := range systemdTimerFiles(s, "/usr/bin/sx", "/u", time.Hour, false) {
+		for _, line := range strings.Split(file.Content, "\n") {
+			if strings.HasPrefix(line, "ExecStartPre") || strings.HasSuffix(line, `\`) {
+				t.Errorf("%s: name escaped its setting:\n%s", file.Path, file.Content)
+			}
+		}
+	}
+	service := systemdTimerFiles(s, "/usr/bin/sx", "/u", time.Hour, false)[0]
+	if !strings.Contains(service.Content, `-- "news\nExecStartPre=/bin/evil\r\nx\\"`) {
+		t.Errorf("service:\n%s", service.Content)
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	s := savedSearch{Name: "rust & go", Interval: "1d"}
+	plist := launchdPlist(s, "/opt/sx", "/Users/u/Library/LaunchAgents", "/Users/u/.local/state/sx", 24*time.Hour, false)
+	if plist.Path != "/Users/u/Library/LaunchAgents/com.byteowlz.sx.saved.rust.go.d1053d.plist" {
+		t.Errorf("path %q", plist.Path)
+	}
+	if err := xml.Unmarshal([]byte(plist.Content), new(struct{})); err != nil {
+		t.Errorf("invalid XML: %v\n%s", err, plist.Content)
+	}
+	for _, want := range []string{"<string>rust &amp; go</string>", "<integer>86400</integer>", "sx-saved-rust-go-d1053d.log"} {
+		if !strings.Contains(plist.Content, want) {
+			t.Errorf("plist lacks %q:\n%s", want, plist.Content)
+		}
+	}
+}
+
+func TestSavedRunArgsEndFlags(t *testing.T) {
+	s := savedSearch{Name: "-v", Interval: "1d"}
+	if got := strings.Join(savedRunArgs(s, true), " "); got != "saved run --email -- -v" {
+		t.Errorf("args %q", got)
+	}
+	service := systemdTimerFiles(s, "/usr/bin/sx", "/home/u/.config/systemd/user", 24*time.Hour, false)[0]
+	if !strings.Contains(service.Content, `ExecStart="/usr/bin/sx" saved run -- "-v"`) {
+		t.Errorf("service:\n%s", service.Content)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// minResultTokens is the smallest share of a --max-tokens budget
+	// worth giving a result; with less, lower-ranked results are left out
+	minResultTokens = 200
+	// trimMarker ends content cut to fit a token budget
+	trimMarker = "\n\n[…]"
+)
+
+// tokenPiece splits text the way GPT tokenizers pre-tokenize it: words
+// with their leading space, up to three digits, punctuation runs and
+// whitespace.
+var tokenPiece = regexp.MustCompile(`'(?:[sdmt]|ll|ve|re)| ?\pL+| ?\pN{1,3}| ?[^\s\pL\pN]+|\s+`)
+
+// pieceTokens estimates the tokens of one pre-tokenized piece: a token for
+// common words and about one per six letters of longer ones, a token per
+// character for scripts such as Chinese, two characters per token for
+// punctuation.
+func pieceTokens(piece string) int {
+	word := strings.TrimPrefix(piece, " ")
+	r, _ := utf8.DecodeRuneInString(word)
+	switch {
+	case word == "" || unicode.IsSpace(r) || unicode.IsNumber(r):
+		return 1
+	case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+		return utf8.RuneCountInString(word)
+	case unicode.IsLetter(r):
+		return max(1, (utf8.RuneCountInString(word)+4)/6)
+	}
+	return (utf8.RuneCountInString(word) + 1) / 2
+}
+
+// estimateTokens estimates how many tokens text is for an LLM, in the
+// style of tiktoken's cl100k encoding: close enough to budget with, not
+// exact.
+func estimateTokens(text string) int {
+	n := 0
+	for _, piece := range tokenPiece.FindAllString(text, -1) {
+		n += pieceTokens(piece)
+	}
+	return n
+}
+
+// trimToTokens cuts text to about limit tokens, at the last paragraph,
+// sentence or word break that fits, and marks the cut.
+func trimToTokens(text string, limit int) string {
+	if estimateTokens(text) <= limit {
+		return text
+	}
+	limit -= estimateTokens(trimMarker)
+	if limit <= 0 {
+		return ""
+	}
+	end, n := 0, 0
+	for _, loc := range tokenPiece.FindAllStringIndex(text, -1) {
+		n += pieceTokens(text[loc[0]:loc[1]])
+		if n > limit {
+			break
+		}
+		end = loc[1]
+	}
+	cut := text[:end]
+	// Prefer a paragraph, then a sentence break in the last quarter
+	for _, sep := range []string{"\n\n", ". ", "\n"} {
+		if i := strings.LastIndex(cut, sep); i >= len(cut)*3/4 {
+			cut = cut[:i+len(sep)]
+			break
+		}
+	}
+	return strings.TrimRightFunc(cut, unicode.IsSpace) + trimMarker
+}
+
+// packTokens splits a budget of tokens among documents that need needs[i]
+// tokens each, in rank order. When the budget can't give every document
+// minResultTokens, the lowest-ranked ones get nothing. The others get
+// equal shares, except that what short documents leave over is shared
+// among the longer ones, and any remainder goes to the highest-ranked.
+func packTokens(needs []int, budget int) []int {
+	alloc := make([]int, len(needs))
+	kept := len(needs)
+	for kept > 1 && budget/kept < minResultTokens {
+		kept--
+	}
+	open := make([]int, 0, kept)
+	for i := range kept {
+		open = append(open, i)
+	}
+	left := budget
+	for len(open) > 0 {
+		share := left / len(open)
+		var rest []int
+		for _, i := range open {
+			if needs[i] <= share {
+				alloc[i] = needs[i]
+				left -= needs[i]
+			} else {
+				rest = append(rest, i)
+			}
+		}
+		if len(rest) == len(open) {
+			// Everyone left needs more than a share
+			for k, i := range rest {
+				alloc[i] = share
+				if k < left%len(rest) {
+					alloc[i]++
+				}
+			}
+			break
+		}
+		open = rest
+	}
+	return alloc
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"Hello, world!", 4},
+		{"internationalization", 4},
+		{"in 2024", 3},
+		{"中文字", 3},
+	}
+	for _, tt := range tests {
+		if got := estimateTokens(tt.text); got != tt.want {
+			t.Errorf("estimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTrimToTokens(t *testing.T) {
+	text := "First paragraph is here.\n\nSecond paragraph has more words in it. And a second sentence."
+	if got := trimToTokens(text, 100); got != text {
+		t.Errorf("text within the limit changed: %q", got)
+	}
+	if got := trimToTokens(text, 21); got != "First paragraph is here.\n\nSecond paragraph has more words in it."+trimMarker {
+		t.Errorf("cut at a sentence: %q", got)
+	}
+	if got := trimToTokens(text, 11); got != "First paragraph is here."+trimMarker {
+		t.Errorf("cut at a paragraph: %q", got)
+	}
+	got := trimToTokens(strings.Repeat("word ", 200), 50)
+	if n := estimateTokens(got); n > 50 || !strings.HasSuffix(got, trimMarker) {
+		t.Errorf("trimmed to %d tokens: %q", n, got)
+	}
+	if got := trimToTokens(text, 1); got != "" {
+		t.Errorf("no room for content: %q", got)
+	}
+}
+
+func TestPackTokens(t *testing.T) {
+	tests := []struct {
+		needs  []int
+		budget int
+		want   []int
+	}{
+		// Everything fits
+		{[]int{100, 200}, 1000, []int{100, 200}},
+		// A short page leaves its share to the long ones
+		{[]int{3000, 100, 3000}, 1000, []int{450, 100, 450}},
+		// The remainder goes to the top result
+		{[]int{3000, 3000}, 1001, []int{501, 500}},
+		// Too little for everyone: the lowest-ranked are left out
+		{[]int{3000, 3000, 3000}, 500, []int{250, 250, 0}},
+		{[]int{3000, 3000}, 50, []int{50, 0}},
+	}
+	for _, tt := range tests {
+		if got := packTokens(tt.needs, tt.budget); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("packTokens(%v, %d) = %v, want %v", tt.needs, tt.budget, got, tt.want)
+		}
+	}
+}
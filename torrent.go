@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// printMagnetsOnly writes the magnet link of each torrent result, one per
+// line. Results without a magnet link are skipped.
+func printMagnetsOnly(results []SearchResult, outputFile string) (err error) {
+	var output io.Writer = os.Stdout
+
+	if outputFile != "" {
+		file, openErr := createOutput(outputFile)
+		if openErr != nil {
+			return openErr
+		}
+		defer closeOutput(file, &err)
+		output = file
+	}
+
+	for _, result := range results {
+		if result.MagnetLink != "" {
+			fmt.Fprintln(output, result.MagnetLink)
+		}
+	}
+
+	return nil
+}
+
+// openMagnet hands a magnet link to the configured torrent client, or to the
+// system URL handler when torrent_client is not set.
+func openMagnet(magnet string, config *Config) error {
+	command := strings.Fields(config.TorrentClient)
+	if len(command) == 0 {
+		return openURL(magnet, config)
+	}
+	cmd := exec.Command(command[0], append(command[1:], magnet)...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the client once it exits instead of leaving a zombie until sx
+	// does
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+)
+
+// useColor decides whether output is colored. --nocolor, no_color and a
+// non-empty NO_COLOR turn colors off. Otherwise colors are on when stdout is
+// a terminal, or when CLICOLOR_FORCE is set to anything but "0" (for
+// `sx ... | less -R`).
+func useColor(noColor bool, getenv func(string) string, stdoutTTY bool) bool {
+	if noColor || getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	return stdoutTTY
+}
+
+// applyColorMode resolves config.NoColor against the environment and the
+// terminal and applies it to all color output. Accessible output is never
+// colored or hyperlinked.
+func applyColorMode(config *Config) {
+	accessibleOutput = config.A11y
+	config.NoColor = config.A11y || !useColor(config.NoColor, os.Getenv, isTerminal(os.Stdout))
+	color.NoColor = config.NoColor
+	applyHyperlinkMode(config)
+	if config.A11y {
+		hyperlinkMode = "never"
+	}
+}
+
+// isTerminalWriter reports whether w is a terminal, as opposed to a file,
+// a pipe or a buffer.
+func isTerminalWriter(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	return ok && isTerminal(file)
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestUseColor(t *testing.T) {
+	tests := []struct {
+		name    string
+		noColor bool
+		env     map[string]string
+		tty     bool
+		want    bool
+	}{
+		{"terminal", false, nil, true, true},
+		{"piped", false, nil, false, false},
+		{"flag", true, nil, true, false},
+		{"NO_COLOR", false, map[string]string{"NO_COLOR": "1"}, true, false},
+		{"empty NO_COLOR", false, map[string]string{"NO_COLOR": ""}, true, true},
+		{"CLICOLOR_FORCE piped", false, map[string]string{"CLICOLOR_FORCE": "1"}, false, true},
+		{"CLICOLOR_FORCE=0", false, map[string]string{"CLICOLOR_FORCE": "0"}, false, false},
+		{"NO_COLOR beats force", false, map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, false, false},
+		{"flag beats force", true, map[string]string{"CLICOLOR_FORCE": "1"}, true, false},
+	}
+	for _, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := useColor(tt.noColor, getenv, tt.tty); got != tt.want {
+			t.Errorf("%s: useColor() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
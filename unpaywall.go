@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+
+	"sx/backends"
+)
+
+// maxOpenAccessLookups limits how many DOIs --open-access looks up at once.
+const maxOpenAccessLookups = 8
+
+// unpaywallAPI is the Unpaywall REST API, which takes a DOI and a contact
+// email address.
+var unpaywallAPI = "https://api.unpaywall.org/v2/"
+
+// openAccessModes are the --open-access values: annotate adds where a free
+// copy is, replace also swaps paywalled result links for it.
+var openAccessModes = []string{"annotate", "replace"}
+
+func validateOpenAccessMode(mode string) bool {
+	for _, m := range openAccessModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+var doiPattern = regexp.MustCompile(`\b10\.\d{4,9}/[^\s"<>?#]+`)
+
+// resultDOI returns the DOI of a science result, from the engine or from a
+// doi.org or publisher URL that contains one.
+func resultDOI(result SearchResult) string {
+	if result.DOI != "" {
+		return strings.TrimPrefix(strings.TrimPrefix(result.DOI, "https://doi.org/"), "doi:")
+	}
+	if unescaped, err := url.PathUnescape(result.URL); err == nil {
+		return strings.TrimRight(doiPattern.FindString(unescaped), ".,;")
+	}
+	return ""
+}
+
+// unpaywallEmail is the contact address Unpaywall requires, from the
+// config or UNPAYWALL_EMAIL.
+func unpaywallEmail(config *Config) string {
+	if config.UnpaywallEmail != "" {
+		return config.UnpaywallEmail
+	}
+	return os.Getenv("UNPAYWALL_EMAIL")
+}
+
+// resolveOpenAccess looks up the results with a DOI on Unpaywall and
+// records where a free copy is. With replace, paywalled results (status
+// green: free only outside the publisher) point at the free copy instead,
+// its PDF if there is one.
+func resolveOpenAccess(results []SearchResult, replace bool, config *Config) {
+	email := unpaywallEmail(config)
+	client := setupHTTPClient(config)
+	sem := make(chan struct{}, maxOpenAccessLookups)
+	var wg sync.WaitGroup
+	for i := range results {
+		doi := resultDOI(results[i])
+		if doi == "" || results[i].OpenAccess != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(result *SearchResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			oa, err := lookupOpenAccess(client, doi, email)
+			if err != nil {
+				if config.Debug {
+					fmt.Fprintf(os.Stderr, "Debug: Unpaywall %s: %v\n", doi, err)
+				}
+				return
+			}
+			if result.PDFURL == "" {
+				result.PDFURL = oa.PDFURL
+			}
+			free := oa.PDFURL
+			if free == "" {
+				free = oa.URL
+			}
+			if replace && oa.Status == "green" && free != "" && !isFreeLocation(result.URL, oa) {
+				oa.OriginalURL = result.URL
+				result.URL = free
+			}
+			result.OpenAccess = oa
+		}(&results[i])
+	}
+	wg.Wait()
+}
+
+// isFreeLocation reports whether link already leads to the free copy.
+func isFreeLocation(link string, oa *backends.OpenAccess) bool {
+	return oa.URL != "" && sameURL(link, oa.URL) || oa.PDFURL != "" && sameURL(link, oa.PDFURL)
+}
+
+// lookupOpenAccess asks Unpaywall for the best free copy of doi.
+func lookupOpenAccess(client *http.Client, doi, email string) (*backends.OpenAccess, error) {
+	endpoint := unpaywallAPI + url.PathEscape(doi) + "?email=" + url.QueryEscape(email)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "sx/"+version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var body struct {
+		OAStatus string `json:"oa_status"`
+		Best     *struct {
+			URL       string `json:"url"`
+			URLForPDF string `json:"url_for_pdf"`
+			License   string `json:"license"`
+		} `json:"best_oa_location"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, err
+	}
+	oa := &backends.OpenAccess{Status: body.OAStatus}
+	if oa.Status == "" {
+		oa.Status = "closed"
+	}
+	if body.Best != nil {
+		oa.URL, oa.PDFURL, oa.License = body.Best.URL, body.Best.URLForPDF, body.Best.License
+	}
+	return oa, nil
+}
+
+// formatOpenAccess renders an Unpaywall result for the result listing.
+func formatOpenAccess(oa *backends.OpenAccess) string {
+	free := oa.PDFURL
+	if free == "" {
+		free = oa.URL
+	}
+	switch {
+	case oa.OriginalURL != "":
+		return color.GreenString("%s", tr("oa_replaced", oa.Status, oa.OriginalURL))
+	case oa.Status == "closed" || free == "":
+		return color.RedString("%s", tr("oa_closed"))
+	}
+	return color.GreenString("%s", tr("oa_free", oa.Status, free))
+}
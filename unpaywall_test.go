@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"sx/backends"
+)
+
+func TestResultDOI(t *testing.T) {
+	tests := []struct {
+		result SearchResult
+		want   string
+	}{
+		{SearchResult{DOI: "10.1038/nature14539"}, "10.1038/nature14539"},
+		{SearchResult{DOI: "https://doi.org/10.1038/nature14539"}, "10.1038/nature14539"},
+		{SearchResult{URL: "https://doi.org/10.1145/3065386"}, "10.1145/3065386"},
+		{SearchResult{URL: "https://link.springer.com/article/10.1007%2Fs11263-015-0816-y"}, "10.1007/s11263-015-0816-y"},
+		{SearchResult{URL: "https://onlinelibrary.wiley.com/doi/full/10.1002/andp.19053220806?af=R"}, "10.1002/andp.19053220806"},
+		{SearchResult{URL: "https://arxiv.org/abs/1706.03762"}, ""},
+	}
+	for _, tt := range tests {
+		if got := resultDOI(tt.result); got != tt.want {
+			t.Errorf("resultDOI(%+v) = %q, want %q", tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestResolveOpenAccess(t *testing.T) {
+	var mu sync.Mutex
+	var emails []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		emails = append(emails, r.URL.Query().Get("email"))
+		mu.Unlock()
+		switch strings.TrimPrefix(r.URL.Path, "/") {
+		case "10.1234/green":
+			w.Write([]byte(`{"oa_status": "green", "best_oa_location": {"url": "https://repo.example/1", "url_for_pdf": "https://repo.example/1.pdf", "license": "cc-by"}}`))
+		case "10.1234/gold":
+			w.Write([]byte(`{"oa_status": "gold", "best_oa_location": {"url": "https://journal.example/2", "url_for_pdf": "https://journal.example/2.pdf"}}`))
+		case "10.1234/closed":
+			w.Write([]byte(`{"oa_status": "closed", "best_oa_location": null}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	saved := unpaywallAPI
+	defer func() { unpaywallAPI = saved }()
+	unpaywallAPI = srv.URL + "/"
+
+	results := []SearchResult{
+		{URL: "https://publisher.example/green", DOI: "10.1234/green"},
+		{URL: "https://journal.example/2", DOI: "10.1234/gold"},
+		{URL: "https://doi.org/10.1234/closed"},
+		{URL: "https://doi.org/10.1234/unknown"},
+		{URL: "https://example.org/no-doi"},
+	}
+	resolveOpenAccess(results, true, &Config{Timeout: 5, UnpaywallEmail: "me@example.org"})
+
+	green := results[0]
+	if green.URL != "https://repo.example/1.pdf" || green.PDFURL != "https://repo.example/1.pdf" ||
+		green.OpenAccess == nil || green.OpenAccess.OriginalURL != "https://publisher.example/green" || green.OpenAccess.License != "cc-by" {
+		t.Errorf("green: %+v %+v", green, green.OpenAccess)
+	}
+	if gold := results[1]; gold.URL != "https://journal.example/2" || gold.PDFURL != "https://journal.example/2.pdf" || gold.OpenAccess.OriginalURL != "" {
+		t.Errorf("gold result was replaced: %+v %+v", gold, gold.OpenAccess)
+	}
+	if oa := results[2].OpenAccess; oa == nil || oa.Status != "closed" || results[2].URL != "https://doi.org/10.1234/closed" {
+		t.Errorf("closed: %+v", oa)
+	}
+	if results[3].OpenAccess != nil || results[4].OpenAccess != nil {
+		t.Error("unresolved results were annotated")
+	}
+	if len(emails) != 4 || emails[0] != "me@example.org" {
+		t.Errorf("lookups sent emails %q", emails)
+	}
+}
+
+func TestFormatOpenAccess(t *testing.T) {
+	tests := []struct {
+		oa   SearchResult
+		want string
+	}{
+		{SearchResult{OpenAccess: &backends.OpenAccess{Status: "green", PDFURL: "https://r/1.pdf"}}, "open access (green): https://r/1.pdf"},
+		{SearchResult{OpenAccess: &backends.OpenAccess{Status: "green", URL: "https://r/1", OriginalURL: "https://p/1"}}, "open access (green) copy, paywalled at https://p/1"},
+		{SearchResult{OpenAccess: &backends.OpenAccess{Status: "closed"}}, "no open access copy"},
+	}
+	for _, tt := range tests {
+		if got := formatOpenAccess(tt.oa.OpenAccess); got != tt.want {
+			t.Errorf("formatOpenAccess(%+v) = %q, want %q", tt.oa.OpenAccess, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const defaultWeatherURL = "https://wttr.in"
+
+// wttrValue is wttr.in's wrapper for text fields: [{"value": "..."}].
+type wttrValue []struct {
+	Value string `json:"value"`
+}
+
+func (v wttrValue) String() string {
+	if len(v) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(v[0].Value)
+}
+
+// wttrResponse is the subset of wttr.in's format=j1 JSON that sx renders.
+type wttrResponse struct {
+	CurrentCondition []struct {
+		TempC          string    `json:"temp_C"`
+		TempF          string    `json:"temp_F"`
+		FeelsLikeC     string    `json:"FeelsLikeC"`
+		FeelsLikeF     string    `json:"FeelsLikeF"`
+		Humidity       string    `json:"humidity"`
+		WindspeedKmph  string    `json:"windspeedKmph"`
+		WindspeedMiles string    `json:"windspeedMiles"`
+		WindDir        string    `json:"winddir16Point"`
+		WeatherDesc    wttrValue `json:"weatherDesc"`
+	} `json:"current_condition"`
+	NearestArea []struct {
+		AreaName wttrValue `json:"areaName"`
+		Region   wttrValue `json:"region"`
+		Country  wttrValue `json:"country"`
+	} `json:"nearest_area"`
+	Weather []struct {
+		Date     string `json:"date"`
+		MaxTempC string `json:"maxtempC"`
+		MinTempC string `json:"mintempC"`
+		MaxTempF string `json:"maxtempF"`
+		MinTempF string `json:"mintempF"`
+		Hourly   []struct {
+			Time         string    `json:"time"`
+			ChanceOfRain string    `json:"chanceofrain"`
+			WeatherDesc  wttrValue `json:"weatherDesc"`
+		} `json:"hourly"`
+	} `json:"weather"`
+}
+
+// weatherDay is one day of the rendered forecast.
+type weatherDay struct {
+	Date         string `json:"date"`
+	Min          int    `json:"min"`
+	Max          int    `json:"max"`
+	Description  string `json:"description"`
+	ChanceOfRain int    `json:"chance_of_rain"`
+}
+
+// weatherReport is the compact forecast printed by `sx weather` and emitted
+// as JSON with --json.
+type weatherReport struct {
+	Location    string       `json:"location"`
+	Units       string       `json:"units"`
+	Description string       `json:"description"`
+	Temperature int          `json:"temperature"`
+	FeelsLike   int          `json:"feels_like"`
+	Humidity    int          `json:"humidity"`
+	WindSpeed   int          `json:"wind_speed"`
+	WindDir     string       `json:"wind_direction"`
+	Forecast    []weatherDay `json:"forecast"`
+}
+
+// runWeather implements `sx weather`.
+func runWeather(place string, days int, imperial, asJSON bool) error {
+	data, err := fetchWeather(place, config)
+	if err != nil {
+		return err
+	}
+	report := buildWeatherReport(data, days, imperial)
+
+	if asJSON {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format JSON: %v", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+	printWeather(os.Stdout, report, config.NoColor)
+	return nil
+}
+
+// fetchWeather retrieves the forecast for place from a wttr.in compatible
+// service. An empty place lets the service locate the caller by IP.
+func fetchWeather(place string, config *Config) (*wttrResponse, error) {
+	base := strings.TrimRight(config.WeatherURL, "/")
+	if base == "" {
+		base = defaultWeatherURL
+	}
+	reqURL := fmt.Sprintf("%s/%s?format=j1", base, url.PathEscape(strings.TrimSpace(place)))
+
+	client := setupHTTPClient(config)
+	req, err := setupHTTPRequest("GET", reqURL, config)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	// Let net/http negotiate and decode compression itself
+	req.Header.Del("Accept-Encoding")
+
+	if config.Debug {
+		fmt.Fprintf(os.Stderr, "Debug: Fetching weather from %s\n", reqURL)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("weather service returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var data wttrResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to parse weather response: %v", err)
+	}
+	if len(data.CurrentCondition) == 0 {
+		return nil, fmt.Errorf("no weather data for %q", place)
+	}
+	return &data, nil
+}
+
+// buildWeatherReport condenses the wttr.in response to current conditions
+// and up to days days of forecast.
+func buildWeatherReport(data *wttrResponse, days int, imperial bool) weatherReport {
+	cur := data.CurrentCondition[0]
+	report := weatherReport{
+		Units:       "metric",
+		Description: cur.WeatherDesc.String(),
+		Temperature: atoi(cur.TempC),
+		FeelsLike:   atoi(cur.FeelsLikeC),
+		Humidity:    atoi(cur.Humidity),
+		WindSpeed:   atoi(cur.WindspeedKmph),
+		WindDir:     cur.WindDir,
+	}
+	if imperial {
+		report.Units = "imperial"
+		report.Temperature = atoi(cur.TempF)
+		report.FeelsLike = atoi(cur.FeelsLikeF)
+		report.WindSpeed = atoi(cur.WindspeedMiles)
+	}
+
+	if len(data.NearestArea) > 0 {
+		area := data.NearestArea[0]
+		var parts []string
+		for _, p := range []string{area.AreaName.String(), area.Region.String(), area.Country.String()} {
+			if p != "" && (len(parts) == 0 || parts[len(parts)-1] != p) {
+				parts = append(parts, p)
+			}
+		}
+		report.Location = strings.Join(parts, ", ")
+	}
+
+	for i, w := range data.Weather {
+		if i >= days {
+			break
+		}
+		day := weatherDay{Date: w.Date, Min: atoi(w.MinTempC), Max: atoi(w.MaxTempC)}
+		if imperial {
+			day.Min, day.Max = atoi(w.MinTempF), atoi(w.MaxTempF)
+		}
+		for _, h := range w.Hourly {
+			if rain := atoi(h.ChanceOfRain); rain > day.ChanceOfRain {
+				day.ChanceOfRain = rain
+			}
+			// The midday slot describes the day best
+			if h.Time == "1200" || day.Description == "" {
+				day.Description = h.WeatherDesc.String()
+			}
+		}
+		report.Forecast = append(report.Forecast, day)
+	}
+	return report
+}
+
+// printWeather renders the report as a compact terminal forecast.
+func printWeather(w io.Writer, report weatherReport, noColor bool) {
+	if noColor {
+		color.NoColor = true
+	}
+	bold := color.New(color.Bold)
+	cyan := color.New(color.FgCyan)
+	dim := color.New(color.FgHiBlack)
+
+	tempUnit, speedUnit := "°C", "km/h"
+	if report.Units == "imperial" {
+		tempUnit, speedUnit = "°F", "mph"
+	}
+
+	if report.Location != "" {
+		fmt.Fprintln(w, bold.Sprint(report.Location))
+	}
+	fmt.Fprintf(w, "%s  %s %s  %s\n",
+		cyan.Sprint(report.Description),
+		bold.Sprintf("%d%s", report.Temperature, tempUnit),
+		dim.Sprintf("(feels %d%s)", report.FeelsLike, tempUnit),
+		dim.Sprintf("wind %d %s %s, humidity %d%%", report.WindSpeed, speedUnit, report.WindDir, report.Humidity))
+
+	if len(report.Forecast) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, day := range report.Forecast {
+		label := day.Date
+		if t, err := time.Parse("2006-01-02", day.Date); err == nil {
+			label = t.Format("Mon Jan 02")
+		}
+		fmt.Fprintf(w, "%s  %s  %s  %s\n",
+			bold.Sprint(label),
+			fmt.Sprintf("%3d–%d%s", day.Min, day.Max, tempUnit),
+			cyan.Sprint(day.Description),
+			dim.Sprintf("rain %d%%", day.ChanceOfRain))
+	}
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const wttrFixture = `{
+  "current_condition": [{"temp_C": "12", "temp_F": "54", "FeelsLikeC": "10", "FeelsLikeF": "50",
+    "humidity": "71", "windspeedKmph": "11", "windspeedMiles": "7", "winddir16Point": "WSW",
+    "weatherDesc": [{"value": "Partly cloudy"}]}],
+  "nearest_area": [{"areaName": [{"value": "Berlin"}], "region": [{"value": "Berlin"}], "country": [{"value": "Germany"}]}],
+  "weather": [
+    {"date": "2026-10-16", "maxtempC": "14", "mintempC": "8", "maxtempF": "57", "mintempF": "46",
+     "hourly": [{"time": "0", "chanceofrain": "0", "weatherDesc": [{"value": "Clear"}]},
+                {"time": "1200", "chanceofrain": "40", "weatherDesc": [{"value": "Light rain"}]}]},
+    {"date": "2026-10-17", "maxtempC": "15", "mintempC": "9", "maxtempF": "59", "mintempF": "48",
+     "hourly": [{"time": "1200", "chanceofrain": "10", "weatherDesc": [{"value": "Sunny"}]}]}
+  ]
+}`
+
+func TestFetchWeather(t *testing.T) {
+	var gotPath, gotFormat string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotFormat = r.URL.Path, r.URL.Query().Get("format")
+		w.Write([]byte(wttrFixture))
+	}))
+	defer server.Close()
+
+	cfg := getDefaultConfig()
+	cfg.WeatherURL = server.URL
+	data, err := fetchWeather("New York", cfg)
+	if err != nil {
+		t.Fatalf("fetchWeather failed: %v", err)
+	}
+	if gotPath != "/New York" || gotFormat != "j1" {
+		t.Errorf("unexpected request path %q format %q", gotPath, gotFormat)
+	}
+	if len(data.Weather) != 2 {
+		t.Errorf("expected 2 forecast days, got %d", len(data.Weather))
+	}
+}
+
+func TestBuildWeatherReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(wttrFixture))
+	}))
+	defer server.Close()
+
+	cfg := getDefaultConfig()
+	cfg.WeatherURL = server.URL
+	data, err := fetchWeather("", cfg)
+	if err != nil {
+		t.Fatalf("fetchWeather failed: %v", err)
+	}
+
+	report := buildWeatherReport(data, 1, false)
+	if report.Location != "Berlin, Germany" {
+		t.Errorf("Location = %q", report.Location)
+	}
+	if report.Temperature != 12 || report.WindSpeed != 11 {
+		t.Errorf("unexpected metric values: %+v", report)
+	}
+	if len(report.Forecast) != 1 {
+		t.Fatalf("expected 1 forecast day, got %d", len(report.Forecast))
+	}
+	if day := report.Forecast[0]; day.Description != "Light rain" || day.ChanceOfRain != 40 {
+		t.Errorf("unexpected forecast day: %+v", day)
+	}
+
+	imperial := buildWeatherReport(data, 3, true)
+	if imperial.Temperature != 54 || imperial.Forecast[1].Max != 59 {
+		t.Errorf("unexpected imperial values: %+v", imperial)
+	}
+
+	var buf bytes.Buffer
+	printWeather(&buf, report, true)
+	for _, want := range []string{"Berlin, Germany", "12°C", "Fri Oct 16", "rain 40%"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("printWeather output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
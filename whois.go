@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"golang.org/x/net/publicsuffix"
+)
+
+const defaultWhoisURL = "https://rdap.org"
+
+// ianaWhoisServer tells which WHOIS server a top-level domain's registry
+// runs, for registries without RDAP. Overridable for testing.
+var ianaWhoisServer = "whois.iana.org:43"
+
+// whoisRecord is the registration of a domain, as printed by `sx whois`
+// and emitted as JSON with --json.
+type whoisRecord struct {
+	Domain      string   `json:"domain"`
+	Registrar   string   `json:"registrar,omitempty"`
+	Registrant  string   `json:"registrant,omitempty"` // often redacted
+	Created     string   `json:"created,omitempty"`
+	Updated     string   `json:"updated,omitempty"`
+	Expires     string   `json:"expires,omitempty"`
+	Status      []string `json:"status,omitempty"`
+	Nameservers []string `json:"nameservers,omitempty"`
+	DNSSEC      string   `json:"dnssec,omitempty"` // signed or unsigned
+	Source      string   `json:"source"`           // the RDAP URL or WHOIS server that answered
+	Raw         string   `json:"raw,omitempty"`    // a WHOIS server's answer, which has no set format
+}
+
+// rdapDomain is the subset of an RDAP domain response that sx renders.
+type rdapDomain struct {
+	LDHName string   `json:"ldhName"`
+	Status  []string `json:"status"`
+	Events  []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+	Entities    []rdapEntity `json:"entities"`
+	Nameservers []struct {
+		LDHName string `json:"ldhName"`
+	} `json:"nameservers"`
+	SecureDNS *struct {
+		DelegationSigned bool `json:"delegationSigned"`
+	} `json:"secureDNS"`
+}
+
+// rdapEntity is a contact of a domain, such as its registrar.
+type rdapEntity struct {
+	Roles      []string          `json:"roles"`
+	VCardArray []json.RawMessage `json:"vcardArray"` // ["vcard", [[name, params, type, value], ...]]
+}
+
+// name returns the entity's formatted name from its vCard.
+func (e rdapEntity) name() string {
+	if len(e.VCardArray) < 2 {
+		return ""
+	}
+	var props [][]interface{}
+	if json.Unmarshal(e.VCardArray[1], &props) != nil {
+		return ""
+	}
+	for _, prop := range props {
+		if len(prop) >= 4 && prop[0] == "fn" {
+			if fn, ok := prop[3].(string); ok {
+				return strings.TrimSpace(fn)
+			}
+		}
+	}
+	return ""
+}
+
+// whoisDomain returns the registered domain a `sx whois` argument names:
+// docs.python.org and https://docs.python.org/3/ are python.org.
+func whoisDomain(arg string) (string, error) {
+	host := lookupName(arg)
+	if host == "" {
+		return "", fmt.Errorf("no domain name in %q", arg)
+	}
+	if net.ParseIP(host) != nil {
+		return "", fmt.Errorf("%s is an IP address; sx whois looks up domain names", host)
+	}
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain, nil
+	}
+	return host, nil
+}
+
+// fetchWhois looks domain up with RDAP, through whois_url (rdap.org by
+// default, which sends it on to the domain's registry). Registries rdap.org
+// knows no RDAP service for are asked over WHOIS instead.
+func fetchWhois(domain string, config *Config) (*whoisRecord, error) {
+	base := strings.TrimRight(config.WhoisURL, "/")
+	if base == "" {
+		base = defaultWhoisURL
+	}
+	reqURL := base + "/domain/" + url.PathEscape(domain)
+
+	client := setupHTTPClient(config)
+	req, err := setupHTTPRequest("GET", reqURL, config)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/rdap+json, application/json")
+	req.Header.Del("Accept-Encoding")
+
+	if config.Debug {
+		fmt.Fprintf(os.Stderr, "Debug: Fetching RDAP record from %s\n", reqURL)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// A 404 from the registry means the domain isn't registered; from
+		// the bootstrap service itself, that its registry has no RDAP
+		if start, err := url.Parse(base); err == nil && resp.Request.URL.Host == start.Host {
+			return fetchWhoisServer(domain, config)
+		}
+		return nil, fmt.Errorf("%s is not registered", domain)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("RDAP service returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var data rdapDomain
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to parse RDAP response: %v", err)
+	}
+	record := buildWhoisRecord(data)
+	if record.Domain == "" {
+		record.Domain = domain
+	}
+	record.Source = resp.Request.URL.String()
+	return record, nil
+}
+
+// buildWhoisRecord condenses an RDAP domain response.
+func buildWhoisRecord(data rdapDomain) *whoisRecord {
+	record := &whoisRecord{
+		Domain: strings.ToLower(data.LDHName),
+		Status: data.Status,
+	}
+	for _, e := range data.Events {
+		switch e.Action {
+		case "registration":
+			record.Created = e.Date
+		case "last changed":
+			record.Updated = e.Date
+		case "expiration":
+			record.Expires = e.Date
+		}
+	}
+	for _, e := range data.Entities {
+		switch {
+		case slices.Contains(e.Roles, "registrar"):
+			record.Registrar = e.name()
+		case slices.Contains(e.Roles, "registrant"):
+			record.Registrant = e.name()
+		}
+	}
+	for _, ns := range data.Nameservers {
+		if ns.LDHName != "" {
+			record.Nameservers = append(record.Nameservers, strings.ToLower(ns.LDHName))
+		}
+	}
+	if data.SecureDNS != nil {
+		record.DNSSEC = "unsigned"
+		if data.SecureDNS.DelegationSigned {
+			record.DNSSEC = "signed"
+		}
+	}
+	return record
+}
+
+// fetchWhoisServer looks domain up over WHOIS: IANA names its registry's
+// WHOIS server, whose answer is parsed as far as its format allows.
+func fetchWhoisServer(domain string, config *Config) (*whoisRecord, error) {
+	tld := domain[strings.LastIndex(domain, ".")+1:]
+	answer, err := queryWhois(ianaWhoisServer, tld, config)
+	if err != nil {
+		return nil, err
+	}
+	server := whoisField(answer, "whois")
+	if server == "" {
+		return nil, fmt.Errorf("no RDAP or WHOIS service known for .%s", tld)
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "43")
+	}
+	answer, err = queryWhois(server, domain, config)
+	if err != nil {
+		return nil, err
+	}
+	record := parseWhois(answer)
+	record.Domain = domain
+	record.Source = server
+	return record, nil
+}
+
+// queryWhois sends query to a WHOIS server and returns its answer.
+func queryWhois(server, query string, config *Config) (string, error) {
+	if config.Debug {
+		fmt.Fprintf(os.Stderr, "Debug: Asking WHOIS server %s for %s\n", server, query)
+	}
+	conn, err := net.DialTimeout("tcp", server, config.fetchTimeout())
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(config.fetchTimeout()))
+	if _, err := fmt.Fprintf(conn, "%s\r\n", query); err != nil {
+		return "", err
+	}
+	answer, err := io.ReadAll(io.LimitReader(conn, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("WHOIS server %s: %v", server, err)
+	}
+	return string(answer), nil
+}
+
+// whoisKeys are the names WHOIS servers give the fields of whoisRecord,
+// lowercased.
+var whoisKeys = map[string]string{
+	"registrar":                              "registrar",
+	"registrar name":                         "registrar",
+	"sponsoring registrar":                   "registrar",
+	"registrant":                             "registrant",
+	"registrant name":                        "registrant",
+	"registrant organization":                "registrant",
+	"creation date":                          "created",
+	"created":                                "created",
+	"registered":                             "created",
+	"registered on":                          "created",
+	"registration time":                      "created",
+	"updated date":                           "updated",
+	"last updated":                           "updated",
+	"last modified":                          "updated",
+	"changed":                                "updated",
+	"modified":                               "updated",
+	"registry expiry date":                   "expires",
+	"registrar registration expiration date": "expires",
+	"expiry date":                            "expires",
+	"expiration date":                        "expires",
+	"expiration time":                        "expires",
+	"expires":                                "expires",
+	"paid-till":                              "expires",
+	"domain status":                          "status",
+	"status":                                 "status",
+	"state":                                  "status",
+	"name server":                            "nameservers",
+	"nserver":                                "nameservers",
+	"nameserver":                             "nameservers",
+	"dnssec":                                 "dnssec",
+}
+
+// parseWhois reads the "Key: value" lines of a WHOIS answer that it knows,
+// keeping the answer itself for the rest.
+func parseWhois(answer string) *whoisRecord {
+	record := &whoisRecord{Raw: strings.TrimSpace(answer)}
+	scanner := bufio.NewScanner(strings.NewReader(answer))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		value = strings.TrimSpace(value)
+		if !found || value == "" {
+			continue
+		}
+		switch whoisKeys[strings.ToLower(strings.TrimSpace(key))] {
+		case "registrar":
+			record.Registrar = firstNonEmpty(record.Registrar, value)
+		case "registrant":
+			record.Registrant = firstNonEmpty(record.Registrant, value)
+		case "created":
+			record.Created = firstNonEmpty(record.Created, value)
+		case "updated":
+			record.Updated = firstNonEmpty(record.Updated, value)
+		case "expires":
+			record.Expires = firstNonEmpty(record.Expires, value)
+		case "status":
+			// e.g. "clientTransferProhibited https://icann.org/epp#..."
+			if status := strings.Fields(value)[0]; !slices.Contains(record.Status, status) {
+				record.Status = append(record.Status, status)
+			}
+		case "nameservers":
+			if ns := strings.ToLower(strings.TrimSuffix(strings.Fields(value)[0], ".")); !slices.Contains(record.Nameservers, ns) {
+				record.Nameservers = append(record.Nameservers, ns)
+			}
+		case "dnssec":
+			record.DNSSEC = firstNonEmpty(record.DNSSEC, strings.ToLower(value))
+		}
+	}
+	return record
+}
+
+// whoisField returns the first value of key in a WHOIS answer.
+func whoisField(answer, key string) string {
+	for _, line := range strings.Split(answer, "\n") {
+		k, value, found := strings.Cut(line, ":")
+		if found && strings.EqualFold(strings.TrimSpace(k), key) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// firstNonEmpty returns the first of values that isn't empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// runWhois implements `sx whois`.
+func runWhois(args []string, raw, asJSON bool) error {
+	records := []*whoisRecord{}
+	for _, arg := range args {
+		domain, err := whoisDomain(arg)
+		if err != nil {
+			return err
+		}
+		record, err := fetchWhois(domain, config)
+		if err != nil {
+			return fmt.Errorf("%s: %v", domain, err)
+		}
+		records = append(records, record)
+	}
+
+	if asJSON {
+		output, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format JSON: %v", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+	for i, record := range records {
+		if i > 0 {
+			fmt.Println()
+		}
+		printWhois(os.Stdout, record, raw, config.NoColor)
+	}
+	return nil
+}
+
+// printWhois writes a domain's registration as a table of its fields, or a
+// WHOIS server's answer as it is when raw is set or nothing was parsed.
+func printWhois(w io.Writer, record *whoisRecord, raw, noColor bool) {
+	if noColor {
+		color.NoColor = true
+	}
+	bold := color.New(color.Bold)
+	dim := color.New(color.FgHiBlack)
+
+	fmt.Fprintln(w, bold.Sprint(record.Domain))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(tw, "%s\t%s\n", label, value)
+		}
+	}
+	row("Registrar", record.Registrar)
+	row("Registrant", record.Registrant)
+	row("Created", whoisDate(record.Created))
+	row("Updated", whoisDate(record.Updated))
+	row("Expires", whoisDate(record.Expires))
+	row("Status", strings.Join(record.Status, ", "))
+	for i, ns := range record.Nameservers {
+		if i == 0 {
+			row("Nameservers", ns)
+		} else {
+			row("", ns)
+		}
+	}
+	row("DNSSEC", record.DNSSEC)
+	tw.Flush()
+
+	parsed := record.Registrar != "" || record.Created != "" || len(record.Nameservers) > 0
+	if record.Raw != "" && (raw || !parsed) {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, record.Raw)
+	}
+	fmt.Fprintln(w, dim.Sprint(record.Source))
+}
+
+// whoisDate shortens an RDAP or WHOIS timestamp to its date.
+func whoisDate(value string) string {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05Z0700", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC().Format("2006-01-02")
+		}
+	}
+	return value
+}
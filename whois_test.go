@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const rdapFixture = `{
+  "objectClassName": "domain", "ldhName": "PYTHON.ORG",
+  "status": ["client transfer prohibited", "client update prohibited"],
+  "events": [
+    {"eventAction": "registration", "eventDate": "1995-03-27T05:00:00Z"},
+    {"eventAction": "expiration", "eventDate": "2033-03-28T05:00:00Z"},
+    {"eventAction": "last changed", "eventDate": "2024-08-26T22:43:17Z"}
+  ],
+  "entities": [
+    {"roles": ["registrar"], "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Gandi SAS"]]]},
+    {"roles": ["registrant"], "vcardArray": ["vcard", [["fn", {}, "text", "REDACTED FOR PRIVACY"]]]}
+  ],
+  "nameservers": [{"ldhName": "NS-2046.AWSDNS-63.CO.UK"}, {"ldhName": "ns-981.awsdns-58.net"}],
+  "secureDNS": {"delegationSigned": true}
+}`
+
+func TestWhoisDomain(t *testing.T) {
+	for arg, want := range map[string]string{
+		"https://docs.python.org/3/": "python.org",
+		"news.bbc.co.uk":             "bbc.co.uk",
+		"Example.com":                "example.com",
+	} {
+		if got, err := whoisDomain(arg); err != nil || got != want {
+			t.Errorf("%s: got %q, %v, want %q", arg, got, err, want)
+		}
+	}
+	if _, err := whoisDomain("192.0.2.1"); err == nil {
+		t.Error("expected an error for an IP address")
+	}
+}
+
+func TestFetchWhois(t *testing.T) {
+	// The bootstrap service redirects to the registry
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rdap/domain/python.org" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rdap+json")
+		w.Write([]byte(rdapFixture))
+	}))
+	defer registry.Close()
+	bootstrap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, registry.URL+"/rdap"+r.URL.Path, http.StatusFound)
+	}))
+	defer bootstrap.Close()
+
+	cfg := getDefaultConfig()
+	cfg.WhoisURL = bootstrap.URL
+	record, err := fetchWhois("python.org", cfg)
+	if err != nil {
+		t.Fatalf("fetchWhois failed: %v", err)
+	}
+	want := &whoisRecord{
+		Domain:      "python.org",
+		Registrar:   "Gandi SAS",
+		Registrant:  "REDACTED FOR PRIVACY",
+		Created:     "1995-03-27T05:00:00Z",
+		Updated:     "2024-08-26T22:43:17Z",
+		Expires:     "2033-03-28T05:00:00Z",
+		Status:      []string{"client transfer prohibited", "client update prohibited"},
+		Nameservers: []string{"ns-2046.awsdns-63.co.uk", "ns-981.awsdns-58.net"},
+		DNSSEC:      "signed",
+		Source:      registry.URL + "/rdap/domain/python.org",
+	}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("got %+v\nwant %+v", record, want)
+	}
+
+	// The registry's 404 means the domain is free
+	if _, err := fetchWhois("unregistered-name.org", cfg); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Errorf("expected not registered, got %v", err)
+	}
+}
+
+// serveWhois answers WHOIS queries on a local port from answers, keyed by
+// query, and returns its address.
+func serveWhois(t *testing.T, answers map[string]string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			query, _ := bufio.NewReader(conn).ReadString('\n')
+			conn.Write([]byte(answers[strings.TrimSpace(query)]))
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestFetchWhoisServer(t *testing.T) {
+	registry := serveWhois(t, map[string]string{
+		"example.de": "% Restricted rights.\n\nDomain: example.de\nNserver: a.iana-servers.net\nNserver: b.iana-servers.net\nStatus: connect\nChanged: 2018-03-12T21:44:25+01:00\n",
+	})
+	iana := serveWhois(t, map[string]string{
+		"de": "domain:       DE\n\nwhois:        " + registry + "\n\nstatus:       ACTIVE\n",
+	})
+	defer func(server string) { ianaWhoisServer = server }(ianaWhoisServer)
+	ianaWhoisServer = iana
+
+	// rdap.org answers 404 itself for registries without RDAP
+	bootstrap := httptest.NewServer(http.NotFoundHandler())
+	defer bootstrap.Close()
+	cfg := getDefaultConfig()
+	cfg.WhoisURL = bootstrap.URL
+
+	record, err := fetchWhois("example.de", cfg)
+	if err != nil {
+		t.Fatalf("fetchWhois failed: %v", err)
+	}
+	if record.Domain != "example.de" || record.Source != registry || record.Updated != "2018-03-12T21:44:25+01:00" ||
+		!reflect.DeepEqual(record.Status, []string{"connect"}) ||
+		!reflect.DeepEqual(record.Nameservers, []string{"a.iana-servers.net", "b.iana-servers.net"}) ||
+		!strings.HasPrefix(record.Raw, "% Restricted rights.") {
+		t.Errorf("unexpected record %+v", record)
+	}
+
+	if _, err := fetchWhois("example.zz", cfg); err == nil || !strings.Contains(err.Error(), "no RDAP or WHOIS service") {
+		t.Errorf("expected no service for .zz, got %v", err)
+	}
+}
+
+func TestParseWhois(t *testing.T) {
+	answer := `   Domain Name: EXAMPLE.COM
+   Registrar: RESERVED-Internet Assigned Numbers Authority
+   Updated Date: 2024-08-14T07:01:34Z
+   Creation Date: 1995-08-14T04:00:00Z
+   Registry Expiry Date: 2025-08-13T04:00:00Z
+   Domain Status: clientDeleteProhibited https://icann.org/epp#clientDeleteProhibited
+   Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited
+   Name Server: A.IANA-SERVERS.NET
+   Name Server: B.IANA-SERVERS.NET
+   DNSSEC: signedDelegation
+   URL of the ICANN Whois Inaccuracy Complaint Form: https://www.icann.org/wicf/
+>>> Last update of whois database: 2024-10-16T10:00:00Z <<<`
+	record := parseWhois(answer)
+	if record.Registrar != "RESERVED-Internet Assigned Numbers Authority" || record.Created != "1995-08-14T04:00:00Z" ||
+		record.Expires != "2025-08-13T04:00:00Z" || record.Updated != "2024-08-14T07:01:34Z" || record.DNSSEC != "signeddelegation" {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if !reflect.DeepEqual(record.Status, []string{"clientDeleteProhibited", "clientTransferProhibited"}) ||
+		!reflect.DeepEqual(record.Nameservers, []string{"a.iana-servers.net", "b.iana-servers.net"}) {
+		t.Errorf("unexpected status %q or nameservers %q", record.Status, record.Nameservers)
+	}
+}
+
+func TestPrintWhois(t *testing.T) {
+	record := &whoisRecord{
+		Domain:      "python.org",
+		Registrar:   "Gandi SAS",
+		Created:     "1995-03-27T05:00:00Z",
+		Expires:     "2033-03-28T05:00:00Z",
+		Nameservers: []string{"ns1.example", "ns2.example"},
+		Source:      "https://rdap.example/domain/python.org",
+	}
+	var buf bytes.Buffer
+	printWhois(&buf, record, false, true)
+	out := buf.String()
+	for _, want := range []string{"python.org\n", "Registrar    Gandi SAS\n", "Created      1995-03-27\n", "Expires      2033-03-28\n",
+		"Nameservers  ns1.example\n", "             ns2.example\n", "https://rdap.example/domain/python.org\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Updated") {
+		t.Errorf("empty fields should be left out:\n%s", out)
+	}
+
+	// A WHOIS answer nothing was parsed from is printed as it is
+	buf.Reset()
+	printWhois(&buf, &whoisRecord{Domain: "example.zz", Raw: "free-form answer", Source: "whois.example:43"}, false, true)
+	if !strings.Contains(buf.String(), "free-form answer") {
+		t.Errorf("raw answer missing:\n%s", buf.String())
+	}
+}